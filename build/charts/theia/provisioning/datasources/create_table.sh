@@ -372,17 +372,30 @@ clickhouse client -n -h 127.0.0.1 <<-EOSQL
         podName String,
         destinationServicePortName String,
         direction String,
+        sourceNodeName String,
+        destinationNodeName String,
         flowEndSeconds DateTime,
         throughputStandardDeviation Float64,
         aggType String,
         algoType String,
+        algoParams String,
         algoCalc Float64,
+        algoVerdicts String,
+        metric String DEFAULT 'throughput',
         throughput Float64,
         anomaly String,
         id String
     ) engine=ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/{table}', '{replica}')
     ORDER BY (flowStartSeconds);
 
+    --Create a table to store the fingerprints of the Throughput Anomaly Detector alerts sent
+    CREATE TABLE IF NOT EXISTS tadetector_alerts_local (
+        fingerprint String,
+        sentAt DateTime
+    ) engine=ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/{table}', '{replica}')
+    ORDER BY (sentAt)
+    TTL sentAt + INTERVAL 30 DAY;
+
     --Create distributed tables for cluster
     CREATE TABLE IF NOT EXISTS flows AS flows_local
     engine=Distributed('{cluster}', default, flows_local, rand());
@@ -402,5 +415,8 @@ clickhouse client -n -h 127.0.0.1 <<-EOSQL
     CREATE TABLE IF NOT EXISTS tadetector AS tadetector_local
     engine=Distributed('{cluster}', default, tadetector_local, rand());
 
+    CREATE TABLE IF NOT EXISTS tadetector_alerts AS tadetector_alerts_local
+    engine=Distributed('{cluster}', default, tadetector_alerts_local, rand());
+
 EOSQL
 }
@@ -0,0 +1,14 @@
+ALTER TABLE tadetector
+    DROP COLUMN sourceNodeName,
+    DROP COLUMN destinationNodeName,
+    DROP COLUMN algoParams,
+    DROP COLUMN algoVerdicts,
+    DROP COLUMN metric;
+ALTER TABLE tadetector_local
+    DROP COLUMN sourceNodeName,
+    DROP COLUMN destinationNodeName,
+    DROP COLUMN algoParams,
+    DROP COLUMN algoVerdicts,
+    DROP COLUMN metric;
+DROP TABLE tadetector_alerts;
+DROP TABLE tadetector_alerts_local;
@@ -0,0 +1,20 @@
+ALTER TABLE tadetector
+    ADD COLUMN sourceNodeName String,
+    ADD COLUMN destinationNodeName String,
+    ADD COLUMN algoParams String,
+    ADD COLUMN algoVerdicts String,
+    ADD COLUMN metric String DEFAULT 'throughput';
+ALTER TABLE tadetector_local
+    ADD COLUMN sourceNodeName String,
+    ADD COLUMN destinationNodeName String,
+    ADD COLUMN algoParams String,
+    ADD COLUMN algoVerdicts String,
+    ADD COLUMN metric String DEFAULT 'throughput';
+CREATE TABLE IF NOT EXISTS tadetector_alerts_local (
+    fingerprint String,
+    sentAt DateTime
+) engine=ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/{table}', '{replica}')
+ORDER BY (sentAt)
+TTL sentAt + INTERVAL 30 DAY;
+CREATE TABLE IF NOT EXISTS tadetector_alerts AS tadetector_alerts_local
+engine=Distributed('{cluster}', default, tadetector_alerts_local, rand());
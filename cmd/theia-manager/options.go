@@ -23,6 +23,12 @@ import (
 
 	"antrea.io/theia/pkg/apis"
 	managerconfig "antrea.io/theia/pkg/config/theiamanager"
+	controllerutil "antrea.io/theia/pkg/controller"
+)
+
+const (
+	defaultAlertDeduplicationInterval = "24h"
+	defaultJobTimeout                 = "0"
 )
 
 type Options struct {
@@ -84,6 +90,15 @@ func (o *Options) setDefaults() {
 	if o.config.APIServer.SelfSignedCert == nil {
 		o.config.APIServer.SelfSignedCert = ptrBool(true)
 	}
+	if o.config.Alerting.DeduplicationInterval == "" {
+		o.config.Alerting.DeduplicationInterval = defaultAlertDeduplicationInterval
+	}
+	if o.config.Jobs.DefaultTimeout == "" {
+		o.config.Jobs.DefaultTimeout = defaultJobTimeout
+	}
+	if o.config.Jobs.InputBudget.Action == "" {
+		o.config.Jobs.InputBudget.Action = controllerutil.JobInputBudgetActionReject
+	}
 }
 
 func ptrBool(value bool) *bool {
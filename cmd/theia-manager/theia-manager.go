@@ -25,8 +25,10 @@ import (
 	"antrea.io/antrea/pkg/log"
 	"antrea.io/antrea/pkg/signals"
 	"antrea.io/antrea/pkg/util/cipher"
+	"k8s.io/apimachinery/pkg/api/resource"
 	genericapiserver "k8s.io/apiserver/pkg/server"
 	genericoptions "k8s.io/apiserver/pkg/server/options"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog/v2"
@@ -34,8 +36,11 @@ import (
 	"antrea.io/theia/pkg/apiserver"
 	"antrea.io/theia/pkg/apiserver/certificate"
 	"antrea.io/theia/pkg/apiserver/utils/stats"
+	"antrea.io/theia/pkg/apiserver/webhook"
 	crdclientset "antrea.io/theia/pkg/client/clientset/versioned"
 	crdinformers "antrea.io/theia/pkg/client/informers/externalversions"
+	managerconfig "antrea.io/theia/pkg/config/theiamanager"
+	controllerutil "antrea.io/theia/pkg/controller"
 	"antrea.io/theia/pkg/controller/anomalydetector"
 	"antrea.io/theia/pkg/controller/networkpolicyrecommendation"
 	"antrea.io/theia/pkg/querier"
@@ -56,10 +61,15 @@ func createAPIServerConfig(
 	nprq querier.NPRecommendationQuerier,
 	chq querier.ClickHouseStatQuerier,
 	tadq querier.ThroughputAnomalyDetectorQuerier,
+	asq querier.AnomalySuppressionQuerier,
+	nprv webhook.JobValidator,
+	tadv webhook.JobValidator,
 ) (*apiserver.Config, error) {
 	secureServing := genericoptions.NewSecureServingOptions().WithLoopback()
 	authentication := genericoptions.NewDelegatingAuthenticationOptions()
-	authorization := genericoptions.NewDelegatingAuthorizationOptions()
+	// The admission webhooks are called by the kube-apiserver, which is not
+	// authorized to access the API of theia manager.
+	authorization := genericoptions.NewDelegatingAuthorizationOptions().WithAlwaysAllowPaths("/validate/*", "/mutate/*")
 
 	caCertController, err := certificate.ApplyServerCert(selfSignedCert, client, secureServing, apiserver.DefaultCAConfig())
 	if err != nil {
@@ -99,7 +109,10 @@ func createAPIServerConfig(
 		caCertController,
 		nprq,
 		chq,
-		tadq), nil
+		tadq,
+		asq,
+		nprv,
+		tadv), nil
 }
 
 func run(o *Options) error {
@@ -128,11 +141,38 @@ func run(o *Options) error {
 	if err != nil {
 		return fmt.Errorf("error when generating CRD client: %v", err)
 	}
+	dynamicClient, err := dynamic.NewForConfig(kubeConfig)
+	if err != nil {
+		return fmt.Errorf("error when generating dynamic client: %v", err)
+	}
 	crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, informerDefaultResync)
 	npRecommendationInformer := crdInformerFactory.Crd().V1alpha1().NetworkPolicyRecommendations()
-	npRecoController := networkpolicyrecommendation.NewNPRecommendationController(crdClient, kubeClient, npRecommendationInformer)
+	var jobTimeout time.Duration
+	if o.config.Jobs.DefaultTimeout != "" {
+		jobTimeout, err = time.ParseDuration(o.config.Jobs.DefaultTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid job defaultTimeout %s: %v", o.config.Jobs.DefaultTimeout, err)
+		}
+	}
+	if o.config.Jobs.SuccessfulJobsHistoryLimit < 0 || o.config.Jobs.FailedJobsHistoryLimit < 0 {
+		return fmt.Errorf("job history limits should be integers >= 0")
+	}
+	historyLimits := controllerutil.JobHistoryLimits{
+		Successful: o.config.Jobs.SuccessfulJobsHistoryLimit,
+		Failed:     o.config.Jobs.FailedJobsHistoryLimit,
+	}
+	inputBudget, err := parseJobInputBudget(o.config.Jobs.InputBudget)
+	if err != nil {
+		return err
+	}
+	npRecoController := networkpolicyrecommendation.NewNPRecommendationController(crdClient, kubeClient, dynamicClient, npRecommendationInformer, jobTimeout, historyLimits, inputBudget)
 	taDetectorInformer := crdInformerFactory.Crd().V1alpha1().ThroughputAnomalyDetectors()
-	taDetectorController := anomalydetector.NewAnomalyDetectorController(crdClient, kubeClient, taDetectorInformer)
+	anomalySuppressionInformer := crdInformerFactory.Crd().V1alpha1().AnomalySuppressions()
+	alerter, err := anomalydetector.NewAlerter(o.config.Alerting)
+	if err != nil {
+		return fmt.Errorf("error when creating anomaly alerter: %v", err)
+	}
+	taDetectorController := anomalydetector.NewAnomalyDetectorController(crdClient, kubeClient, dynamicClient, taDetectorInformer, anomalySuppressionInformer, alerter, jobTimeout, historyLimits, inputBudget)
 	clickHouseStatQuerierImpl := stats.NewClickHouseStatQuerierImpl(kubeClient)
 
 	cipherSuites, err := cipher.GenerateCipherSuitesList(o.config.APIServer.TLSCipherSuites)
@@ -149,6 +189,9 @@ func run(o *Options) error {
 		cipher.TLSVersionMap[o.config.APIServer.TLSMinVersion],
 		npRecoController,
 		clickHouseStatQuerierImpl,
+		taDetectorController,
+		taDetectorController,
+		npRecoController,
 		taDetectorController)
 	if err != nil {
 		return fmt.Errorf("error creating API server config: %v", err)
@@ -167,3 +210,33 @@ func run(o *Options) error {
 	klog.InfoS("Stopping theia manager")
 	return nil
 }
+
+// parseJobInputBudget validates the input budget of the jobs in the
+// configuration.
+func parseJobInputBudget(config managerconfig.JobInputBudgetConfig) (controllerutil.JobInputBudget, error) {
+	budget := controllerutil.JobInputBudget{
+		MaxRows:           config.MaxRows,
+		Action:            config.Action,
+		ExecutorInstances: config.ExecutorInstances,
+		ExecutorMemory:    config.ExecutorMemory,
+	}
+	if config.MaxRows < 0 || config.ExecutorInstances < 0 {
+		return budget, fmt.Errorf("job input budget maxRows and executorInstances should be integers >= 0")
+	}
+	if config.MaxBytes != "" {
+		maxBytes, err := resource.ParseQuantity(config.MaxBytes)
+		if err != nil {
+			return budget, fmt.Errorf("invalid job input budget maxBytes %s: %v", config.MaxBytes, err)
+		}
+		budget.MaxBytes = maxBytes.Value()
+	}
+	if config.ExecutorMemory != "" {
+		if _, err := resource.ParseQuantity(config.ExecutorMemory); err != nil {
+			return budget, fmt.Errorf("invalid job input budget executorMemory %s: %v", config.ExecutorMemory, err)
+		}
+	}
+	if config.Action != controllerutil.JobInputBudgetActionReject && config.Action != controllerutil.JobInputBudgetActionScaleUp {
+		return budget, fmt.Errorf("job input budget action should be %s or %s", controllerutil.JobInputBudgetActionReject, controllerutil.JobInputBudgetActionScaleUp)
+	}
+	return budget, nil
+}
@@ -256,6 +256,8 @@ if $THEIA_MANAGER; then
    $KUSTOMIZE edit add base manager/network-policy-recommendation-crd.yaml
    cp $CRDS_DIR/anomaly-detector-crd.yaml manager/anomaly-detector-crd.yaml
    $KUSTOMIZE edit add base manager/anomaly-detector-crd.yaml
+   cp $CRDS_DIR/anomaly-suppression-crd.yaml manager/anomaly-suppression-crd.yaml
+   $KUSTOMIZE edit add base manager/anomaly-suppression-crd.yaml
 fi
 
 $KUSTOMIZE build
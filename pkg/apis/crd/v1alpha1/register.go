@@ -54,6 +54,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&NetworkPolicyRecommendationList{},
 		&ThroughputAnomalyDetector{},
 		&ThroughputAnomalyDetectorList{},
+		&AnomalySuppression{},
+		&AnomalySuppressionList{},
 	)
 
 	metav1.AddToGroupVersion(
@@ -20,9 +20,122 @@
 package v1alpha1
 
 import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnomalySuppression) DeepCopyInto(out *AnomalySuppression) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnomalySuppression.
+func (in *AnomalySuppression) DeepCopy() *AnomalySuppression {
+	if in == nil {
+		return nil
+	}
+	out := new(AnomalySuppression)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AnomalySuppression) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnomalySuppressionList) DeepCopyInto(out *AnomalySuppressionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AnomalySuppression, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnomalySuppressionList.
+func (in *AnomalySuppressionList) DeepCopy() *AnomalySuppressionList {
+	if in == nil {
+		return nil
+	}
+	out := new(AnomalySuppressionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AnomalySuppressionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnomalySuppressionSpec) DeepCopyInto(out *AnomalySuppressionSpec) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.EndTime.DeepCopyInto(&out.EndTime)
+	in.ExpirationTime.DeepCopyInto(&out.ExpirationTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnomalySuppressionSpec.
+func (in *AnomalySuppressionSpec) DeepCopy() *AnomalySuppressionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AnomalySuppressionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobInputEstimate) DeepCopyInto(out *JobInputEstimate) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobInputEstimate.
+func (in *JobInputEstimate) DeepCopy() *JobInputEstimate {
+	if in == nil {
+		return nil
+	}
+	out := new(JobInputEstimate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobProgress) DeepCopyInto(out *JobProgress) {
+	*out = *in
+	in.EstimatedEndTime.DeepCopyInto(&out.EstimatedEndTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobProgress.
+func (in *JobProgress) DeepCopy() *JobProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(JobProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkPolicyRecommendation) DeepCopyInto(out *NetworkPolicyRecommendation) {
 	*out = *in
@@ -94,6 +207,23 @@ func (in *NetworkPolicyRecommendationSpec) DeepCopyInto(out *NetworkPolicyRecomm
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.TargetNamespaces != nil {
+		in, out := &in.TargetNamespaces, &out.TargetNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TargetLabels != nil {
+		in, out := &in.TargetLabels, &out.TargetLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -110,6 +240,16 @@ func (in *NetworkPolicyRecommendationSpec) DeepCopy() *NetworkPolicyRecommendati
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkPolicyRecommendationStatus) DeepCopyInto(out *NetworkPolicyRecommendationStatus) {
 	*out = *in
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(JobProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InputEstimate != nil {
+		in, out := &in.InputEstimate, &out.InputEstimate
+		*out = new(JobInputEstimate)
+		**out = **in
+	}
 	in.StartTime.DeepCopyInto(&out.StartTime)
 	in.EndTime.DeepCopyInto(&out.EndTime)
 	return
@@ -153,6 +293,99 @@ func (in *ThroughputAnomalyDetector) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThroughputAnomalyDetectorAlgoParams) DeepCopyInto(out *ThroughputAnomalyDetectorAlgoParams) {
+	*out = *in
+	if in.ARIMAOrder != nil {
+		in, out := &in.ARIMAOrder, &out.ARIMAOrder
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThroughputAnomalyDetectorAlgoParams.
+func (in *ThroughputAnomalyDetectorAlgoParams) DeepCopy() *ThroughputAnomalyDetectorAlgoParams {
+	if in == nil {
+		return nil
+	}
+	out := new(ThroughputAnomalyDetectorAlgoParams)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThroughputAnomalyDetectorFlowFilter) DeepCopyInto(out *ThroughputAnomalyDetectorFlowFilter) {
+	*out = *in
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(ThroughputAnomalyDetectorFlowPeer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Destination != nil {
+		in, out := &in.Destination, &out.Destination
+		*out = new(ThroughputAnomalyDetectorFlowPeer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]ThroughputAnomalyDetectorPortRange, len(*in))
+		copy(*out, *in)
+	}
+	if in.Protocols != nil {
+		in, out := &in.Protocols, &out.Protocols
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FlowTypes != nil {
+		in, out := &in.FlowTypes, &out.FlowTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThroughputAnomalyDetectorFlowFilter.
+func (in *ThroughputAnomalyDetectorFlowFilter) DeepCopy() *ThroughputAnomalyDetectorFlowFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(ThroughputAnomalyDetectorFlowFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThroughputAnomalyDetectorFlowPeer) DeepCopyInto(out *ThroughputAnomalyDetectorFlowPeer) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CIDRs != nil {
+		in, out := &in.CIDRs, &out.CIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThroughputAnomalyDetectorFlowPeer.
+func (in *ThroughputAnomalyDetectorFlowPeer) DeepCopy() *ThroughputAnomalyDetectorFlowPeer {
+	if in == nil {
+		return nil
+	}
+	out := new(ThroughputAnomalyDetectorFlowPeer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ThroughputAnomalyDetectorList) DeepCopyInto(out *ThroughputAnomalyDetectorList) {
 	*out = *in
@@ -186,6 +419,22 @@ func (in *ThroughputAnomalyDetectorList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThroughputAnomalyDetectorPortRange) DeepCopyInto(out *ThroughputAnomalyDetectorPortRange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThroughputAnomalyDetectorPortRange.
+func (in *ThroughputAnomalyDetectorPortRange) DeepCopy() *ThroughputAnomalyDetectorPortRange {
+	if in == nil {
+		return nil
+	}
+	out := new(ThroughputAnomalyDetectorPortRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ThroughputAnomalyDetectorSpec) DeepCopyInto(out *ThroughputAnomalyDetectorSpec) {
 	*out = *in
@@ -196,6 +445,21 @@ func (in *ThroughputAnomalyDetectorSpec) DeepCopyInto(out *ThroughputAnomalyDete
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AlgoParams != nil {
+		in, out := &in.AlgoParams, &out.AlgoParams
+		*out = new(ThroughputAnomalyDetectorAlgoParams)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FlowFilter != nil {
+		in, out := &in.FlowFilter, &out.FlowFilter
+		*out = new(ThroughputAnomalyDetectorFlowFilter)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -212,6 +476,16 @@ func (in *ThroughputAnomalyDetectorSpec) DeepCopy() *ThroughputAnomalyDetectorSp
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ThroughputAnomalyDetectorStatus) DeepCopyInto(out *ThroughputAnomalyDetectorStatus) {
 	*out = *in
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(JobProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InputEstimate != nil {
+		in, out := &in.InputEstimate, &out.InputEstimate
+		*out = new(JobInputEstimate)
+		**out = **in
+	}
 	in.StartTime.DeepCopyInto(&out.StartTime)
 	in.EndTime.DeepCopyInto(&out.EndTime)
 	return
@@ -0,0 +1,50 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func addConversionFuncs(s *runtime.Scheme) error {
+	return s.AddConversionFunc((*url.Values)(nil), (*JobLogOptions)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_url_Values_To_v1alpha1_JobLogOptions(a.(*url.Values), b.(*JobLogOptions), scope)
+	})
+}
+
+// Convert_url_Values_To_v1alpha1_JobLogOptions decodes the query parameters
+// of a request to the log subresource.
+func Convert_url_Values_To_v1alpha1_JobLogOptions(in *url.Values, out *JobLogOptions, s conversion.Scope) error {
+	if values, ok := (*in)["follow"]; ok && len(values) > 0 {
+		follow, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return fmt.Errorf("invalid follow parameter %q: %v", values[0], err)
+		}
+		out.Follow = follow
+	}
+	if values, ok := (*in)["executor"]; ok && len(values) > 0 {
+		executor, err := strconv.Atoi(values[0])
+		if err != nil {
+			return fmt.Errorf("invalid executor parameter %q: %v", values[0], err)
+		}
+		out.Executor = executor
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDecodeJobLogOptions(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, AddToScheme(scheme))
+	codec := runtime.NewParameterCodec(scheme)
+
+	testCases := []struct {
+		name             string
+		query            url.Values
+		expectedOptions  JobLogOptions
+		expectedErrorMsg string
+	}{
+		{
+			name:            "no parameter",
+			query:           url.Values{},
+			expectedOptions: JobLogOptions{},
+		},
+		{
+			name:            "follow executor",
+			query:           url.Values{"follow": {"true"}, "executor": {"2"}},
+			expectedOptions: JobLogOptions{Follow: true, Executor: 2},
+		},
+		{
+			name:             "invalid follow",
+			query:            url.Values{"follow": {"yes please"}},
+			expectedErrorMsg: "invalid follow parameter",
+		},
+		{
+			name:             "invalid executor",
+			query:            url.Values{"executor": {"first"}},
+			expectedErrorMsg: "invalid executor parameter",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			options := &JobLogOptions{}
+			err := codec.DecodeParameters(tc.query, SchemeGroupVersion, options)
+			if tc.expectedErrorMsg != "" {
+				assert.ErrorContains(t, err, tc.expectedErrorMsg)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedOptions, *options)
+		})
+	}
+}
@@ -45,7 +45,7 @@ var (
 )
 
 func init() {
-	localSchemeBuilder.Register(addKnownTypes)
+	localSchemeBuilder.Register(addKnownTypes, addConversionFuncs)
 }
 
 func Resource(resource string) schema.GroupResource {
@@ -55,10 +55,15 @@ func Resource(resource string) schema.GroupResource {
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(
 		SchemeGroupVersion,
+		&AnomalySuppression{},
+		&AnomalySuppressionList{},
+		&JobLogOptions{},
 		&NetworkPolicyRecommendation{},
 		&NetworkPolicyRecommendationList{},
+		&NetworkPolicyRecommendationExplanation{},
 		&ThroughputAnomalyDetector{},
 		&ThroughputAnomalyDetectorList{},
+		&ThroughputAnomalyDetectorContributors{},
 	)
 
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
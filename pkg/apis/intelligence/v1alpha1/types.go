@@ -24,31 +24,70 @@ type NetworkPolicyRecommendation struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Type                string                            `json:"jobType,omitempty"`
-	Limit               int                               `json:"limit,omitempty"`
-	PolicyType          string                            `json:"policyType,omitempty"`
-	StartInterval       metav1.Time                       `json:"startInterval,omitempty"`
-	EndInterval         metav1.Time                       `json:"endInterval,omitempty"`
-	NSAllowList         []string                          `json:"nsAllowList,omitempty"`
-	ExcludeLabels       bool                              `json:"excludeLabels,omitempty"`
-	ToServices          bool                              `json:"toServices,omitempty"`
-	ExecutorInstances   int                               `json:"executorInstances,omitempty"`
-	DriverCoreRequest   string                            `json:"driverCoreRequest,omitempty"`
-	DriverMemory        string                            `json:"driverMemory,omitempty"`
-	ExecutorCoreRequest string                            `json:"executorCoreRequest,omitempty"`
-	ExecutorMemory      string                            `json:"executorMemory,omitempty"`
-	Status              NetworkPolicyRecommendationStatus `json:"status,omitempty"`
+	Type                    string                            `json:"jobType,omitempty"`
+	Limit                   int                               `json:"limit,omitempty"`
+	PolicyType              string                            `json:"policyType,omitempty"`
+	StartInterval           metav1.Time                       `json:"startInterval,omitempty"`
+	EndInterval             metav1.Time                       `json:"endInterval,omitempty"`
+	NSAllowList             []string                          `json:"nsAllowList,omitempty"`
+	TargetNamespaces        []string                          `json:"targetNamespaces,omitempty"`
+	TargetLabels            map[string]string                 `json:"targetLabels,omitempty"`
+	ExcludeLabels           bool                              `json:"excludeLabels,omitempty"`
+	ToServices              bool                              `json:"toServices,omitempty"`
+	Tier                    string                            `json:"tier,omitempty"`
+	BasePriority            float64                           `json:"basePriority,omitempty"`
+	PriorityStep            float64                           `json:"priorityStep,omitempty"`
+	ExecutorInstances       int                               `json:"executorInstances,omitempty"`
+	DriverCoreRequest       string                            `json:"driverCoreRequest,omitempty"`
+	DriverMemory            string                            `json:"driverMemory,omitempty"`
+	ExecutorCoreRequest     string                            `json:"executorCoreRequest,omitempty"`
+	ExecutorMemory          string                            `json:"executorMemory,omitempty"`
+	ActiveDeadlineSeconds   int64                             `json:"activeDeadlineSeconds,omitempty"`
+	TTLSecondsAfterFinished *int32                            `json:"ttlSecondsAfterFinished,omitempty"`
+	Status                  NetworkPolicyRecommendationStatus `json:"status,omitempty"`
+}
+
+// JobInputEstimate is the size of the flow records read by a job, estimated
+// with ClickHouse before its Spark Application is created.
+type JobInputEstimate struct {
+	// Rows is the number of flow records in the time window of the job which
+	// match its filters.
+	Rows int64 `json:"rows,omitempty"`
+	// Bytes is the uncompressed size of these flow records, extrapolated
+	// from the average size of the records of the flows table.
+	Bytes int64 `json:"bytes,omitempty"`
+}
+
+// JobProgress is the detailed progress of the Spark Application of a running
+// job, as reported by the Spark monitoring API.
+type JobProgress struct {
+	// CurrentStage is the name of the earliest stage which is still running.
+	CurrentStage string `json:"currentStage,omitempty"`
+	// CompletedTasks and TotalTasks are the task counts of CurrentStage.
+	CompletedTasks int `json:"completedTasks,omitempty"`
+	TotalTasks     int `json:"totalTasks,omitempty"`
+	// ActiveExecutors is the number of executors which are alive.
+	ActiveExecutors int `json:"activeExecutors,omitempty"`
+	// FailedExecutors is the number of executors which have been lost
+	// while the Spark Application is running.
+	FailedExecutors int `json:"failedExecutors,omitempty"`
+	// EstimatedEndTime extrapolates the elapsed time with the fraction of
+	// the stages and tasks which are completed. It is a rough estimate, as
+	// Spark only plans the stages of a query when the query starts.
+	EstimatedEndTime metav1.Time `json:"estimatedEndTime,omitempty"`
 }
 
 type NetworkPolicyRecommendationStatus struct {
-	State                 string      `json:"state,omitempty"`
-	SparkApplication      string      `json:"sparkApplication,omitempty"`
-	CompletedStages       int         `json:"completedStages,omitempty"`
-	TotalStages           int         `json:"totalStages,omitempty"`
-	RecommendationOutcome string      `json:"recommendationOutcome,omitempty"`
-	ErrorMsg              string      `json:"errorMsg,omitempty"`
-	StartTime             metav1.Time `json:"startTime,omitempty"`
-	EndTime               metav1.Time `json:"endTime,omitempty"`
+	State                 string            `json:"state,omitempty"`
+	SparkApplication      string            `json:"sparkApplication,omitempty"`
+	CompletedStages       int               `json:"completedStages,omitempty"`
+	TotalStages           int               `json:"totalStages,omitempty"`
+	Progress              *JobProgress      `json:"progress,omitempty"`
+	InputEstimate         *JobInputEstimate `json:"inputEstimate,omitempty"`
+	RecommendationOutcome string            `json:"recommendationOutcome,omitempty"`
+	ErrorMsg              string            `json:"errorMsg,omitempty"`
+	StartTime             metav1.Time       `json:"startTime,omitempty"`
+	EndTime               metav1.Time       `json:"endTime,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -68,33 +107,105 @@ type ThroughputAnomalyDetector struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Type                string                           `json:"jobType,omitempty"`
-	StartInterval       metav1.Time                      `json:"startInterval,omitempty"`
-	EndInterval         metav1.Time                      `json:"endInterval,omitempty"`
-	ExecutorInstances   int                              `json:"executorInstances,omitempty"`
-	NSIgnoreList        []string                         `json:"nsIgnoreList,omitempty"`
-	AggregatedFlow      string                           `json:"aggFlow,omitempty"`
-	PodLabel            string                           `json:"podLabel,omitempty"`
-	PodName             string                           `json:"podName,omitempty"`
-	PodNameSpace        string                           `json:"podNameSpace,omitempty"`
-	ExternalIP          string                           `json:"externalIp,omitempty"`
-	ServicePortName     string                           `json:"servicePortName,omitempty"`
-	DriverCoreRequest   string                           `json:"driverCoreRequest,omitempty"`
-	DriverMemory        string                           `json:"driverMemory,omitempty"`
-	ExecutorCoreRequest string                           `json:"executorCoreRequest,omitempty"`
-	ExecutorMemory      string                           `json:"executorMemory,omitempty"`
-	Status              ThroughputAnomalyDetectorStatus  `json:"status,omitempty"`
-	Stats               []ThroughputAnomalyDetectorStats `json:"stats,omitempty"`
+	Type                    string                               `json:"jobType,omitempty"`
+	StartInterval           metav1.Time                          `json:"startInterval,omitempty"`
+	EndInterval             metav1.Time                          `json:"endInterval,omitempty"`
+	ExecutorInstances       int                                  `json:"executorInstances,omitempty"`
+	NSIgnoreList            []string                             `json:"nsIgnoreList,omitempty"`
+	AggregatedFlow          string                               `json:"aggFlow,omitempty"`
+	PodLabel                string                               `json:"podLabel,omitempty"`
+	PodName                 string                               `json:"podName,omitempty"`
+	PodNameSpace            string                               `json:"podNameSpace,omitempty"`
+	ExternalIP              string                               `json:"externalIp,omitempty"`
+	ServicePortName         string                               `json:"servicePortName,omitempty"`
+	NodeName                string                               `json:"nodeName,omitempty"`
+	Metric                  string                               `json:"metric,omitempty"`
+	SaveNormalPoints        bool                                 `json:"saveNormalPoints,omitempty"`
+	DriverCoreRequest       string                               `json:"driverCoreRequest,omitempty"`
+	DriverMemory            string                               `json:"driverMemory,omitempty"`
+	ExecutorCoreRequest     string                               `json:"executorCoreRequest,omitempty"`
+	ExecutorMemory          string                               `json:"executorMemory,omitempty"`
+	ActiveDeadlineSeconds   int64                                `json:"activeDeadlineSeconds,omitempty"`
+	TTLSecondsAfterFinished *int32                               `json:"ttlSecondsAfterFinished,omitempty"`
+	AlgoParams              *ThroughputAnomalyDetectorAlgoParams `json:"algoParams,omitempty"`
+	FlowFilter              *ThroughputAnomalyDetectorFlowFilter `json:"flowFilter,omitempty"`
+	Status                  ThroughputAnomalyDetectorStatus      `json:"status,omitempty"`
+	Stats                   []ThroughputAnomalyDetectorStats     `json:"stats,omitempty"`
+}
+
+// ThroughputAnomalyDetectorAlgoParams holds the optional parameters of the
+// anomaly detection algorithms. Unset parameters use the default values of
+// the algorithm.
+type ThroughputAnomalyDetectorAlgoParams struct {
+	// Sensitivity is the number of standard deviations the throughput may
+	// deviate from the value calculated by EWMA or ARIMA before it is
+	// considered anomalous. Defaults to 1.
+	Sensitivity float64 `json:"sensitivity,omitempty"`
+	// EWMAAlpha is the smoothing factor of EWMA, in (0, 1]. Defaults to 0.5.
+	EWMAAlpha float64 `json:"ewmaAlpha,omitempty"`
+	// ARIMAOrder is the (p, d, q) order of the ARIMA model. Defaults to
+	// [1, 1, 1].
+	ARIMAOrder []int `json:"arimaOrder,omitempty"`
+	// DBSCANEps is the maximum throughput distance between two samples of
+	// the same DBSCAN cluster. Defaults to 250000000.
+	DBSCANEps float64 `json:"dbscanEps,omitempty"`
+	// DBSCANMinSamples is the number of samples in a neighborhood for a
+	// point to be considered as a DBSCAN core point. Defaults to 4.
+	DBSCANMinSamples int `json:"dbscanMinSamples,omitempty"`
+	// Quorum is the number of algorithms which must find a throughput
+	// anomalous for the ENSEMBLE algorithm to report it. Defaults to 2.
+	Quorum int `json:"quorum,omitempty"`
+}
+
+// ThroughputAnomalyDetectorFlowFilter restricts the flows analyzed by a
+// ThroughputAnomalyDetector. A flow is analyzed only if it matches all the
+// set fields of the filter.
+type ThroughputAnomalyDetectorFlowFilter struct {
+	// Source selects the sources of the analyzed flows.
+	Source *ThroughputAnomalyDetectorFlowPeer `json:"source,omitempty"`
+	// Destination selects the destinations of the analyzed flows.
+	Destination *ThroughputAnomalyDetectorFlowPeer `json:"destination,omitempty"`
+	// Ports selects the destination ports of the analyzed flows. A flow
+	// matches if its destination port is in any of the ranges.
+	Ports []ThroughputAnomalyDetectorPortRange `json:"ports,omitempty"`
+	// Protocols selects the protocols of the analyzed flows, among TCP, UDP,
+	// SCTP and ICMP.
+	Protocols []string `json:"protocols,omitempty"`
+	// FlowTypes selects the types of the analyzed flows, among pod_to_pod,
+	// pod_to_svc and pod_to_external.
+	FlowTypes []string `json:"flowTypes,omitempty"`
+}
+
+// ThroughputAnomalyDetectorFlowPeer selects one end of the analyzed flows. A
+// flow end matches if it matches all the set fields.
+type ThroughputAnomalyDetectorFlowPeer struct {
+	// NamespaceSelector selects the Namespaces of the Pods. It is evaluated
+	// against the Namespaces existing when the job starts.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// PodSelector selects the Pods by their labels.
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+	// CIDRs selects the IP addresses. A flow end matches if its IP address
+	// is in any of the CIDRs.
+	CIDRs []string `json:"cidrs,omitempty"`
+}
+
+// ThroughputAnomalyDetectorPortRange is a range of ports. End defaults to
+// Start, to select a single port.
+type ThroughputAnomalyDetectorPortRange struct {
+	Start int `json:"start"`
+	End   int `json:"end,omitempty"`
 }
 
 type ThroughputAnomalyDetectorStatus struct {
-	State            string      `json:"state,omitempty"`
-	SparkApplication string      `json:"sparkApplication,omitempty"`
-	CompletedStages  int         `json:"completedStages,omitempty"`
-	TotalStages      int         `json:"totalStages,omitempty"`
-	ErrorMsg         string      `json:"errorMsg,omitempty"`
-	StartTime        metav1.Time `json:"startTime,omitempty"`
-	EndTime          metav1.Time `json:"endTime,omitempty"`
+	State            string            `json:"state,omitempty"`
+	SparkApplication string            `json:"sparkApplication,omitempty"`
+	CompletedStages  int               `json:"completedStages,omitempty"`
+	TotalStages      int               `json:"totalStages,omitempty"`
+	Progress         *JobProgress      `json:"progress,omitempty"`
+	InputEstimate    *JobInputEstimate `json:"inputEstimate,omitempty"`
+	ErrorMsg         string            `json:"errorMsg,omitempty"`
+	StartTime        metav1.Time       `json:"startTime,omitempty"`
+	EndTime          metav1.Time       `json:"endTime,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -105,6 +216,42 @@ type ThroughputAnomalyDetectorList struct {
 	Items           []ThroughputAnomalyDetector `json:"items"`
 }
 
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AnomalySuppression marks the throughput anomalies matching its fields as
+// expected. Empty fields match any anomaly.
+type AnomalySuppression struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	AggType                    string      `json:"aggType,omitempty"`
+	SourceIP                   string      `json:"sourceIP,omitempty"`
+	DestinationIP              string      `json:"destinationIP,omitempty"`
+	PodNamespace               string      `json:"podNamespace,omitempty"`
+	PodLabels                  string      `json:"podLabels,omitempty"`
+	PodName                    string      `json:"podName,omitempty"`
+	Direction                  string      `json:"direction,omitempty"`
+	DestinationServicePortName string      `json:"destinationServicePortName,omitempty"`
+	SourceNodeName             string      `json:"sourceNodeName,omitempty"`
+	DestinationNodeName        string      `json:"destinationNodeName,omitempty"`
+	StartTime                  metav1.Time `json:"startTime,omitempty"`
+	EndTime                    metav1.Time `json:"endTime,omitempty"`
+	DailyStartTime             string      `json:"dailyStartTime,omitempty"`
+	DailyEndTime               string      `json:"dailyEndTime,omitempty"`
+	ExpirationTime             metav1.Time `json:"expirationTime,omitempty"`
+	Reason                     string      `json:"reason,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type AnomalySuppressionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AnomalySuppression `json:"items"`
+}
+
 type ThroughputAnomalyDetectorStats struct {
 	Id                         string `json:"id,omitempty"`
 	SourceIP                   string `json:"sourceIP,omitempty"`
@@ -117,10 +264,116 @@ type ThroughputAnomalyDetectorStats struct {
 	PodName                    string `json:"podName,omitempty"`
 	Direction                  string `json:"direction,omitempty"`
 	DestinationServicePortName string `json:"destinationServicePortName,omitempty"`
+	SourceNodeName             string `json:"sourceNodeName,omitempty"`
+	DestinationNodeName        string `json:"destinationNodeName,omitempty"`
 	FlowEndSeconds             string `json:"FlowEndSeconds,omitempty"`
+	Metric                     string `json:"metric,omitempty"`
 	Throughput                 string `json:"throughput,omitempty"`
 	AggType                    string `json:"aggType,omitempty"`
 	AlgoType                   string `json:"algoType,omitempty"`
+	AlgoParams                 string `json:"algoParams,omitempty"`
 	AlgoCalc                   string `json:"AlgoCalc,omitempty"`
+	AlgoVerdicts               string `json:"algoVerdicts,omitempty"`
 	Anomaly                    string `json:"anomaly,omitempty"`
 }
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ThroughputAnomalyDetectorContributors is returned by the contributors
+// subresource of a ThroughputAnomalyDetector. It lists, for every anomaly
+// found by the job, the flows which transferred the most bytes around the
+// time of the anomaly.
+type ThroughputAnomalyDetectorContributors struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Anomalies []AnomalyContributors `json:"anomalies,omitempty"`
+}
+
+type AnomalyContributors struct {
+	Anomaly     ThroughputAnomalyDetectorStats `json:"anomaly"`
+	WindowStart metav1.Time                    `json:"windowStart,omitempty"`
+	WindowEnd   metav1.Time                    `json:"windowEnd,omitempty"`
+	Flows       []ContributingFlow             `json:"flows,omitempty"`
+	ErrorMsg    string                         `json:"errorMsg,omitempty"`
+}
+
+type ContributingFlow struct {
+	SourcePodNamespace         string `json:"sourcePodNamespace,omitempty"`
+	SourcePodName              string `json:"sourcePodName,omitempty"`
+	SourceIP                   string `json:"sourceIP,omitempty"`
+	DestinationPodNamespace    string `json:"destinationPodNamespace,omitempty"`
+	DestinationPodName         string `json:"destinationPodName,omitempty"`
+	DestinationIP              string `json:"destinationIP,omitempty"`
+	DestinationServicePortName string `json:"destinationServicePortName,omitempty"`
+	DestinationTransportPort   int    `json:"destinationTransportPort,omitempty"`
+	Protocol                   string `json:"protocol,omitempty"`
+	Bytes                      int64  `json:"bytes"`
+	Packets                    int64  `json:"packets"`
+	FlowCount                  int64  `json:"flowCount"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkPolicyRecommendationExplanation is returned by the explain subresource
+// of a NetworkPolicyRecommendation. It lists, for every recommended rule, the
+// flows observed between StartInterval and EndInterval which are matched by
+// that rule.
+type NetworkPolicyRecommendationExplanation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	StartInterval metav1.Time         `json:"startInterval,omitempty"`
+	EndInterval   metav1.Time         `json:"endInterval,omitempty"`
+	Policies      []PolicyExplanation `json:"policies,omitempty"`
+}
+
+type PolicyExplanation struct {
+	Kind      string            `json:"kind,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	AppliedTo string            `json:"appliedTo,omitempty"`
+	Rules     []RuleExplanation `json:"rules,omitempty"`
+}
+
+type RuleExplanation struct {
+	Direction   string       `json:"direction,omitempty"`
+	Index       int          `json:"index"`
+	Action      string       `json:"action,omitempty"`
+	Peer        string       `json:"peer,omitempty"`
+	Ports       []string     `json:"ports,omitempty"`
+	FlowCount   int64        `json:"flowCount"`
+	Bytes       int64        `json:"bytes"`
+	FirstSeen   metav1.Time  `json:"firstSeen,omitempty"`
+	LastSeen    metav1.Time  `json:"lastSeen,omitempty"`
+	SampleFlows []FlowSample `json:"sampleFlows,omitempty"`
+	ErrorMsg    string       `json:"errorMsg,omitempty"`
+}
+
+type FlowSample struct {
+	SourcePodNamespace         string `json:"sourcePodNamespace,omitempty"`
+	SourcePodName              string `json:"sourcePodName,omitempty"`
+	SourceIP                   string `json:"sourceIP,omitempty"`
+	DestinationPodNamespace    string `json:"destinationPodNamespace,omitempty"`
+	DestinationPodName         string `json:"destinationPodName,omitempty"`
+	DestinationIP              string `json:"destinationIP,omitempty"`
+	DestinationServicePortName string `json:"destinationServicePortName,omitempty"`
+	DestinationTransportPort   int    `json:"destinationTransportPort,omitempty"`
+	Protocol                   string `json:"protocol,omitempty"`
+	FlowCount                  int64  `json:"flowCount"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// JobLogOptions are the query options of the log subresource of
+// NetworkPolicyRecommendation and ThroughputAnomalyDetector, which streams
+// the logs of the Spark driver or executor Pods of the job.
+type JobLogOptions struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Follow streams the logs until the Pod terminates.
+	Follow bool `json:"follow,omitempty"`
+	// Executor selects the logs of the executor with this ID. The logs of
+	// the driver are returned if it is 0.
+	Executor int `json:"executor,omitempty"`
+}
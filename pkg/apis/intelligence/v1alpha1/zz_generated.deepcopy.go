@@ -20,9 +20,186 @@
 package v1alpha1
 
 import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnomalyContributors) DeepCopyInto(out *AnomalyContributors) {
+	*out = *in
+	out.Anomaly = in.Anomaly
+	in.WindowStart.DeepCopyInto(&out.WindowStart)
+	in.WindowEnd.DeepCopyInto(&out.WindowEnd)
+	if in.Flows != nil {
+		in, out := &in.Flows, &out.Flows
+		*out = make([]ContributingFlow, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnomalyContributors.
+func (in *AnomalyContributors) DeepCopy() *AnomalyContributors {
+	if in == nil {
+		return nil
+	}
+	out := new(AnomalyContributors)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnomalySuppression) DeepCopyInto(out *AnomalySuppression) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.EndTime.DeepCopyInto(&out.EndTime)
+	in.ExpirationTime.DeepCopyInto(&out.ExpirationTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnomalySuppression.
+func (in *AnomalySuppression) DeepCopy() *AnomalySuppression {
+	if in == nil {
+		return nil
+	}
+	out := new(AnomalySuppression)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AnomalySuppression) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnomalySuppressionList) DeepCopyInto(out *AnomalySuppressionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AnomalySuppression, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnomalySuppressionList.
+func (in *AnomalySuppressionList) DeepCopy() *AnomalySuppressionList {
+	if in == nil {
+		return nil
+	}
+	out := new(AnomalySuppressionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AnomalySuppressionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContributingFlow) DeepCopyInto(out *ContributingFlow) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContributingFlow.
+func (in *ContributingFlow) DeepCopy() *ContributingFlow {
+	if in == nil {
+		return nil
+	}
+	out := new(ContributingFlow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlowSample) DeepCopyInto(out *FlowSample) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlowSample.
+func (in *FlowSample) DeepCopy() *FlowSample {
+	if in == nil {
+		return nil
+	}
+	out := new(FlowSample)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobInputEstimate) DeepCopyInto(out *JobInputEstimate) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobInputEstimate.
+func (in *JobInputEstimate) DeepCopy() *JobInputEstimate {
+	if in == nil {
+		return nil
+	}
+	out := new(JobInputEstimate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobLogOptions) DeepCopyInto(out *JobLogOptions) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobLogOptions.
+func (in *JobLogOptions) DeepCopy() *JobLogOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(JobLogOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *JobLogOptions) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JobProgress) DeepCopyInto(out *JobProgress) {
+	*out = *in
+	in.EstimatedEndTime.DeepCopyInto(&out.EstimatedEndTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JobProgress.
+func (in *JobProgress) DeepCopy() *JobProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(JobProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkPolicyRecommendation) DeepCopyInto(out *NetworkPolicyRecommendation) {
 	*out = *in
@@ -35,6 +212,23 @@ func (in *NetworkPolicyRecommendation) DeepCopyInto(out *NetworkPolicyRecommenda
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.TargetNamespaces != nil {
+		in, out := &in.TargetNamespaces, &out.TargetNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TargetLabels != nil {
+		in, out := &in.TargetLabels, &out.TargetLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
 	in.Status.DeepCopyInto(&out.Status)
 	return
 }
@@ -57,6 +251,41 @@ func (in *NetworkPolicyRecommendation) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicyRecommendationExplanation) DeepCopyInto(out *NetworkPolicyRecommendationExplanation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.StartInterval.DeepCopyInto(&out.StartInterval)
+	in.EndInterval.DeepCopyInto(&out.EndInterval)
+	if in.Policies != nil {
+		in, out := &in.Policies, &out.Policies
+		*out = make([]PolicyExplanation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicyRecommendationExplanation.
+func (in *NetworkPolicyRecommendationExplanation) DeepCopy() *NetworkPolicyRecommendationExplanation {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicyRecommendationExplanation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NetworkPolicyRecommendationExplanation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkPolicyRecommendationList) DeepCopyInto(out *NetworkPolicyRecommendationList) {
 	*out = *in
@@ -93,6 +322,16 @@ func (in *NetworkPolicyRecommendationList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkPolicyRecommendationStatus) DeepCopyInto(out *NetworkPolicyRecommendationStatus) {
 	*out = *in
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(JobProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InputEstimate != nil {
+		in, out := &in.InputEstimate, &out.InputEstimate
+		*out = new(JobInputEstimate)
+		**out = **in
+	}
 	in.StartTime.DeepCopyInto(&out.StartTime)
 	in.EndTime.DeepCopyInto(&out.EndTime)
 	return
@@ -108,6 +347,57 @@ func (in *NetworkPolicyRecommendationStatus) DeepCopy() *NetworkPolicyRecommenda
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyExplanation) DeepCopyInto(out *PolicyExplanation) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]RuleExplanation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyExplanation.
+func (in *PolicyExplanation) DeepCopy() *PolicyExplanation {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyExplanation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuleExplanation) DeepCopyInto(out *RuleExplanation) {
+	*out = *in
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.FirstSeen.DeepCopyInto(&out.FirstSeen)
+	in.LastSeen.DeepCopyInto(&out.LastSeen)
+	if in.SampleFlows != nil {
+		in, out := &in.SampleFlows, &out.SampleFlows
+		*out = make([]FlowSample, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuleExplanation.
+func (in *RuleExplanation) DeepCopy() *RuleExplanation {
+	if in == nil {
+		return nil
+	}
+	out := new(RuleExplanation)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ThroughputAnomalyDetector) DeepCopyInto(out *ThroughputAnomalyDetector) {
 	*out = *in
@@ -120,6 +410,21 @@ func (in *ThroughputAnomalyDetector) DeepCopyInto(out *ThroughputAnomalyDetector
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AlgoParams != nil {
+		in, out := &in.AlgoParams, &out.AlgoParams
+		*out = new(ThroughputAnomalyDetectorAlgoParams)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FlowFilter != nil {
+		in, out := &in.FlowFilter, &out.FlowFilter
+		*out = new(ThroughputAnomalyDetectorFlowFilter)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Status.DeepCopyInto(&out.Status)
 	if in.Stats != nil {
 		in, out := &in.Stats, &out.Stats
@@ -147,6 +452,132 @@ func (in *ThroughputAnomalyDetector) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThroughputAnomalyDetectorAlgoParams) DeepCopyInto(out *ThroughputAnomalyDetectorAlgoParams) {
+	*out = *in
+	if in.ARIMAOrder != nil {
+		in, out := &in.ARIMAOrder, &out.ARIMAOrder
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThroughputAnomalyDetectorAlgoParams.
+func (in *ThroughputAnomalyDetectorAlgoParams) DeepCopy() *ThroughputAnomalyDetectorAlgoParams {
+	if in == nil {
+		return nil
+	}
+	out := new(ThroughputAnomalyDetectorAlgoParams)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThroughputAnomalyDetectorContributors) DeepCopyInto(out *ThroughputAnomalyDetectorContributors) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if in.Anomalies != nil {
+		in, out := &in.Anomalies, &out.Anomalies
+		*out = make([]AnomalyContributors, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThroughputAnomalyDetectorContributors.
+func (in *ThroughputAnomalyDetectorContributors) DeepCopy() *ThroughputAnomalyDetectorContributors {
+	if in == nil {
+		return nil
+	}
+	out := new(ThroughputAnomalyDetectorContributors)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ThroughputAnomalyDetectorContributors) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThroughputAnomalyDetectorFlowFilter) DeepCopyInto(out *ThroughputAnomalyDetectorFlowFilter) {
+	*out = *in
+	if in.Source != nil {
+		in, out := &in.Source, &out.Source
+		*out = new(ThroughputAnomalyDetectorFlowPeer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Destination != nil {
+		in, out := &in.Destination, &out.Destination
+		*out = new(ThroughputAnomalyDetectorFlowPeer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]ThroughputAnomalyDetectorPortRange, len(*in))
+		copy(*out, *in)
+	}
+	if in.Protocols != nil {
+		in, out := &in.Protocols, &out.Protocols
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FlowTypes != nil {
+		in, out := &in.FlowTypes, &out.FlowTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThroughputAnomalyDetectorFlowFilter.
+func (in *ThroughputAnomalyDetectorFlowFilter) DeepCopy() *ThroughputAnomalyDetectorFlowFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(ThroughputAnomalyDetectorFlowFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThroughputAnomalyDetectorFlowPeer) DeepCopyInto(out *ThroughputAnomalyDetectorFlowPeer) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CIDRs != nil {
+		in, out := &in.CIDRs, &out.CIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThroughputAnomalyDetectorFlowPeer.
+func (in *ThroughputAnomalyDetectorFlowPeer) DeepCopy() *ThroughputAnomalyDetectorFlowPeer {
+	if in == nil {
+		return nil
+	}
+	out := new(ThroughputAnomalyDetectorFlowPeer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ThroughputAnomalyDetectorList) DeepCopyInto(out *ThroughputAnomalyDetectorList) {
 	*out = *in
@@ -180,6 +611,22 @@ func (in *ThroughputAnomalyDetectorList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ThroughputAnomalyDetectorPortRange) DeepCopyInto(out *ThroughputAnomalyDetectorPortRange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ThroughputAnomalyDetectorPortRange.
+func (in *ThroughputAnomalyDetectorPortRange) DeepCopy() *ThroughputAnomalyDetectorPortRange {
+	if in == nil {
+		return nil
+	}
+	out := new(ThroughputAnomalyDetectorPortRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ThroughputAnomalyDetectorStats) DeepCopyInto(out *ThroughputAnomalyDetectorStats) {
 	*out = *in
@@ -199,6 +646,16 @@ func (in *ThroughputAnomalyDetectorStats) DeepCopy() *ThroughputAnomalyDetectorS
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ThroughputAnomalyDetectorStatus) DeepCopyInto(out *ThroughputAnomalyDetectorStatus) {
 	*out = *in
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(JobProgress)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InputEstimate != nil {
+		in, out := &in.InputEstimate, &out.InputEstimate
+		*out = new(JobInputEstimate)
+		**out = **in
+	}
 	in.StartTime.DeepCopyInto(&out.StartTime)
 	in.EndTime.DeepCopyInto(&out.EndTime)
 	return
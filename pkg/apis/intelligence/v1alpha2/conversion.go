@@ -0,0 +1,536 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha2
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+)
+
+// noAnomalyDetected is the v1alpha1 anomaly of the single placeholder stat
+// written by a ThroughputAnomalyDetector which found no anomaly. v1alpha2
+// reports such ThroughputAnomalyDetectors with empty stats instead.
+const noAnomalyDetected = "NO ANOMALY DETECTED"
+
+// RegisterConversions adds the conversion functions between v1alpha1 and
+// v1alpha2 to the given scheme.
+func RegisterConversions(s *runtime.Scheme) error {
+	if err := s.AddConversionFunc((*v1alpha1.NetworkPolicyRecommendation)(nil), (*NetworkPolicyRecommendation)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_NetworkPolicyRecommendation_To_v1alpha2_NetworkPolicyRecommendation(a.(*v1alpha1.NetworkPolicyRecommendation), b.(*NetworkPolicyRecommendation), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*NetworkPolicyRecommendation)(nil), (*v1alpha1.NetworkPolicyRecommendation)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_NetworkPolicyRecommendation_To_v1alpha1_NetworkPolicyRecommendation(a.(*NetworkPolicyRecommendation), b.(*v1alpha1.NetworkPolicyRecommendation), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1alpha1.NetworkPolicyRecommendationList)(nil), (*NetworkPolicyRecommendationList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_NetworkPolicyRecommendationList_To_v1alpha2_NetworkPolicyRecommendationList(a.(*v1alpha1.NetworkPolicyRecommendationList), b.(*NetworkPolicyRecommendationList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*NetworkPolicyRecommendationList)(nil), (*v1alpha1.NetworkPolicyRecommendationList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_NetworkPolicyRecommendationList_To_v1alpha1_NetworkPolicyRecommendationList(a.(*NetworkPolicyRecommendationList), b.(*v1alpha1.NetworkPolicyRecommendationList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1alpha1.ThroughputAnomalyDetector)(nil), (*ThroughputAnomalyDetector)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ThroughputAnomalyDetector_To_v1alpha2_ThroughputAnomalyDetector(a.(*v1alpha1.ThroughputAnomalyDetector), b.(*ThroughputAnomalyDetector), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*ThroughputAnomalyDetector)(nil), (*v1alpha1.ThroughputAnomalyDetector)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_ThroughputAnomalyDetector_To_v1alpha1_ThroughputAnomalyDetector(a.(*ThroughputAnomalyDetector), b.(*v1alpha1.ThroughputAnomalyDetector), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*v1alpha1.ThroughputAnomalyDetectorList)(nil), (*ThroughputAnomalyDetectorList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ThroughputAnomalyDetectorList_To_v1alpha2_ThroughputAnomalyDetectorList(a.(*v1alpha1.ThroughputAnomalyDetectorList), b.(*ThroughputAnomalyDetectorList), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*ThroughputAnomalyDetectorList)(nil), (*v1alpha1.ThroughputAnomalyDetectorList)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_ThroughputAnomalyDetectorList_To_v1alpha1_ThroughputAnomalyDetectorList(a.(*ThroughputAnomalyDetectorList), b.(*v1alpha1.ThroughputAnomalyDetectorList), scope)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func Convert_v1alpha1_NetworkPolicyRecommendation_To_v1alpha2_NetworkPolicyRecommendation(in *v1alpha1.NetworkPolicyRecommendation, out *NetworkPolicyRecommendation, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Type = in.Type
+	out.Limit = in.Limit
+	out.PolicyType = in.PolicyType
+	out.StartInterval = in.StartInterval
+	out.EndInterval = in.EndInterval
+	out.NSAllowList = in.NSAllowList
+	out.TargetNamespaces = in.TargetNamespaces
+	out.TargetLabels = in.TargetLabels
+	out.ExcludeLabels = in.ExcludeLabels
+	out.ToServices = in.ToServices
+	out.Tier = in.Tier
+	out.BasePriority = in.BasePriority
+	out.PriorityStep = in.PriorityStep
+	out.ExecutorInstances = in.ExecutorInstances
+	out.DriverCoreRequest = in.DriverCoreRequest
+	out.DriverMemory = in.DriverMemory
+	out.ExecutorCoreRequest = in.ExecutorCoreRequest
+	out.ExecutorMemory = in.ExecutorMemory
+	out.ActiveDeadlineSeconds = in.ActiveDeadlineSeconds
+	out.TTLSecondsAfterFinished = in.TTLSecondsAfterFinished
+	out.Status = NetworkPolicyRecommendationStatus{
+		State:                 in.Status.State,
+		SparkApplication:      in.Status.SparkApplication,
+		CompletedStages:       in.Status.CompletedStages,
+		TotalStages:           in.Status.TotalStages,
+		Progress:              (*JobProgress)(in.Status.Progress.DeepCopy()),
+		InputEstimate:         (*JobInputEstimate)(in.Status.InputEstimate.DeepCopy()),
+		RecommendationOutcome: in.Status.RecommendationOutcome,
+		ErrorMsg:              in.Status.ErrorMsg,
+		StartTime:             in.Status.StartTime,
+		EndTime:               in.Status.EndTime,
+	}
+	return nil
+}
+
+func Convert_v1alpha2_NetworkPolicyRecommendation_To_v1alpha1_NetworkPolicyRecommendation(in *NetworkPolicyRecommendation, out *v1alpha1.NetworkPolicyRecommendation, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Type = in.Type
+	out.Limit = in.Limit
+	out.PolicyType = in.PolicyType
+	out.StartInterval = in.StartInterval
+	out.EndInterval = in.EndInterval
+	out.NSAllowList = in.NSAllowList
+	out.TargetNamespaces = in.TargetNamespaces
+	out.TargetLabels = in.TargetLabels
+	out.ExcludeLabels = in.ExcludeLabels
+	out.ToServices = in.ToServices
+	out.Tier = in.Tier
+	out.BasePriority = in.BasePriority
+	out.PriorityStep = in.PriorityStep
+	out.ExecutorInstances = in.ExecutorInstances
+	out.DriverCoreRequest = in.DriverCoreRequest
+	out.DriverMemory = in.DriverMemory
+	out.ExecutorCoreRequest = in.ExecutorCoreRequest
+	out.ExecutorMemory = in.ExecutorMemory
+	out.ActiveDeadlineSeconds = in.ActiveDeadlineSeconds
+	out.TTLSecondsAfterFinished = in.TTLSecondsAfterFinished
+	out.Status = v1alpha1.NetworkPolicyRecommendationStatus{
+		State:                 in.Status.State,
+		SparkApplication:      in.Status.SparkApplication,
+		CompletedStages:       in.Status.CompletedStages,
+		TotalStages:           in.Status.TotalStages,
+		Progress:              (*v1alpha1.JobProgress)(in.Status.Progress.DeepCopy()),
+		InputEstimate:         (*v1alpha1.JobInputEstimate)(in.Status.InputEstimate.DeepCopy()),
+		RecommendationOutcome: in.Status.RecommendationOutcome,
+		ErrorMsg:              in.Status.ErrorMsg,
+		StartTime:             in.Status.StartTime,
+		EndTime:               in.Status.EndTime,
+	}
+	return nil
+}
+
+func Convert_v1alpha1_NetworkPolicyRecommendationList_To_v1alpha2_NetworkPolicyRecommendationList(in *v1alpha1.NetworkPolicyRecommendationList, out *NetworkPolicyRecommendationList, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	out.Items = make([]NetworkPolicyRecommendation, len(in.Items))
+	for i := range in.Items {
+		if err := Convert_v1alpha1_NetworkPolicyRecommendation_To_v1alpha2_NetworkPolicyRecommendation(&in.Items[i], &out.Items[i], s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Convert_v1alpha2_NetworkPolicyRecommendationList_To_v1alpha1_NetworkPolicyRecommendationList(in *NetworkPolicyRecommendationList, out *v1alpha1.NetworkPolicyRecommendationList, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	out.Items = make([]v1alpha1.NetworkPolicyRecommendation, len(in.Items))
+	for i := range in.Items {
+		if err := Convert_v1alpha2_NetworkPolicyRecommendation_To_v1alpha1_NetworkPolicyRecommendation(&in.Items[i], &out.Items[i], s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Convert_v1alpha1_ThroughputAnomalyDetector_To_v1alpha2_ThroughputAnomalyDetector(in *v1alpha1.ThroughputAnomalyDetector, out *ThroughputAnomalyDetector, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Type = in.Type
+	out.StartInterval = in.StartInterval
+	out.EndInterval = in.EndInterval
+	out.ExecutorInstances = in.ExecutorInstances
+	out.NSIgnoreList = in.NSIgnoreList
+	out.AggregatedFlow = in.AggregatedFlow
+	out.PodLabel = in.PodLabel
+	out.PodName = in.PodName
+	out.PodNameSpace = in.PodNameSpace
+	out.ExternalIP = in.ExternalIP
+	out.ServicePortName = in.ServicePortName
+	out.NodeName = in.NodeName
+	out.Metric = in.Metric
+	out.SaveNormalPoints = in.SaveNormalPoints
+	out.DriverCoreRequest = in.DriverCoreRequest
+	out.DriverMemory = in.DriverMemory
+	out.ExecutorCoreRequest = in.ExecutorCoreRequest
+	out.ExecutorMemory = in.ExecutorMemory
+	out.ActiveDeadlineSeconds = in.ActiveDeadlineSeconds
+	out.TTLSecondsAfterFinished = in.TTLSecondsAfterFinished
+	out.AlgoParams = (*ThroughputAnomalyDetectorAlgoParams)(in.AlgoParams.DeepCopy())
+	out.FlowFilter = nil
+	if in.FlowFilter != nil {
+		out.FlowFilter = new(ThroughputAnomalyDetectorFlowFilter)
+		if err := Convert_v1alpha1_ThroughputAnomalyDetectorFlowFilter_To_v1alpha2_ThroughputAnomalyDetectorFlowFilter(in.FlowFilter, out.FlowFilter, s); err != nil {
+			return err
+		}
+	}
+	if err := Convert_v1alpha1_ThroughputAnomalyDetectorStatus_To_v1alpha2_ThroughputAnomalyDetectorStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	out.Stats = nil
+	for i := range in.Stats {
+		if in.Stats[i].Anomaly == noAnomalyDetected {
+			continue
+		}
+		var stat ThroughputAnomalyDetectorStats
+		if err := Convert_v1alpha1_ThroughputAnomalyDetectorStats_To_v1alpha2_ThroughputAnomalyDetectorStats(&in.Stats[i], &stat, s); err != nil {
+			return err
+		}
+		out.Stats = append(out.Stats, stat)
+	}
+	return nil
+}
+
+func Convert_v1alpha2_ThroughputAnomalyDetector_To_v1alpha1_ThroughputAnomalyDetector(in *ThroughputAnomalyDetector, out *v1alpha1.ThroughputAnomalyDetector, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Type = in.Type
+	out.StartInterval = in.StartInterval
+	out.EndInterval = in.EndInterval
+	out.ExecutorInstances = in.ExecutorInstances
+	out.NSIgnoreList = in.NSIgnoreList
+	out.AggregatedFlow = in.AggregatedFlow
+	out.PodLabel = in.PodLabel
+	out.PodName = in.PodName
+	out.PodNameSpace = in.PodNameSpace
+	out.ExternalIP = in.ExternalIP
+	out.ServicePortName = in.ServicePortName
+	out.NodeName = in.NodeName
+	out.Metric = in.Metric
+	out.SaveNormalPoints = in.SaveNormalPoints
+	out.DriverCoreRequest = in.DriverCoreRequest
+	out.DriverMemory = in.DriverMemory
+	out.ExecutorCoreRequest = in.ExecutorCoreRequest
+	out.ExecutorMemory = in.ExecutorMemory
+	out.ActiveDeadlineSeconds = in.ActiveDeadlineSeconds
+	out.TTLSecondsAfterFinished = in.TTLSecondsAfterFinished
+	out.AlgoParams = (*v1alpha1.ThroughputAnomalyDetectorAlgoParams)(in.AlgoParams.DeepCopy())
+	out.FlowFilter = nil
+	if in.FlowFilter != nil {
+		out.FlowFilter = new(v1alpha1.ThroughputAnomalyDetectorFlowFilter)
+		if err := Convert_v1alpha2_ThroughputAnomalyDetectorFlowFilter_To_v1alpha1_ThroughputAnomalyDetectorFlowFilter(in.FlowFilter, out.FlowFilter, s); err != nil {
+			return err
+		}
+	}
+	if err := Convert_v1alpha2_ThroughputAnomalyDetectorStatus_To_v1alpha1_ThroughputAnomalyDetectorStatus(&in.Status, &out.Status, s); err != nil {
+		return err
+	}
+	out.Stats = nil
+	for i := range in.Stats {
+		var stat v1alpha1.ThroughputAnomalyDetectorStats
+		if err := Convert_v1alpha2_ThroughputAnomalyDetectorStats_To_v1alpha1_ThroughputAnomalyDetectorStats(&in.Stats[i], &stat, s); err != nil {
+			return err
+		}
+		out.Stats = append(out.Stats, stat)
+	}
+	return nil
+}
+
+func Convert_v1alpha1_ThroughputAnomalyDetectorList_To_v1alpha2_ThroughputAnomalyDetectorList(in *v1alpha1.ThroughputAnomalyDetectorList, out *ThroughputAnomalyDetectorList, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	out.Items = make([]ThroughputAnomalyDetector, len(in.Items))
+	for i := range in.Items {
+		if err := Convert_v1alpha1_ThroughputAnomalyDetector_To_v1alpha2_ThroughputAnomalyDetector(&in.Items[i], &out.Items[i], s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Convert_v1alpha2_ThroughputAnomalyDetectorList_To_v1alpha1_ThroughputAnomalyDetectorList(in *ThroughputAnomalyDetectorList, out *v1alpha1.ThroughputAnomalyDetectorList, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	out.Items = make([]v1alpha1.ThroughputAnomalyDetector, len(in.Items))
+	for i := range in.Items {
+		if err := Convert_v1alpha2_ThroughputAnomalyDetector_To_v1alpha1_ThroughputAnomalyDetector(&in.Items[i], &out.Items[i], s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Convert_v1alpha1_ThroughputAnomalyDetectorFlowFilter_To_v1alpha2_ThroughputAnomalyDetectorFlowFilter(in *v1alpha1.ThroughputAnomalyDetectorFlowFilter, out *ThroughputAnomalyDetectorFlowFilter, s conversion.Scope) error {
+	out.Source = nil
+	if in.Source != nil {
+		out.Source = &ThroughputAnomalyDetectorFlowPeer{
+			NamespaceSelector: in.Source.NamespaceSelector.DeepCopy(),
+			PodSelector:       in.Source.PodSelector.DeepCopy(),
+			CIDRs:             in.Source.CIDRs,
+		}
+	}
+	out.Destination = nil
+	if in.Destination != nil {
+		out.Destination = &ThroughputAnomalyDetectorFlowPeer{
+			NamespaceSelector: in.Destination.NamespaceSelector.DeepCopy(),
+			PodSelector:       in.Destination.PodSelector.DeepCopy(),
+			CIDRs:             in.Destination.CIDRs,
+		}
+	}
+	out.Ports = nil
+	for _, port := range in.Ports {
+		out.Ports = append(out.Ports, ThroughputAnomalyDetectorPortRange(port))
+	}
+	out.Protocols = in.Protocols
+	out.FlowTypes = in.FlowTypes
+	return nil
+}
+
+func Convert_v1alpha2_ThroughputAnomalyDetectorFlowFilter_To_v1alpha1_ThroughputAnomalyDetectorFlowFilter(in *ThroughputAnomalyDetectorFlowFilter, out *v1alpha1.ThroughputAnomalyDetectorFlowFilter, s conversion.Scope) error {
+	out.Source = nil
+	if in.Source != nil {
+		out.Source = &v1alpha1.ThroughputAnomalyDetectorFlowPeer{
+			NamespaceSelector: in.Source.NamespaceSelector.DeepCopy(),
+			PodSelector:       in.Source.PodSelector.DeepCopy(),
+			CIDRs:             in.Source.CIDRs,
+		}
+	}
+	out.Destination = nil
+	if in.Destination != nil {
+		out.Destination = &v1alpha1.ThroughputAnomalyDetectorFlowPeer{
+			NamespaceSelector: in.Destination.NamespaceSelector.DeepCopy(),
+			PodSelector:       in.Destination.PodSelector.DeepCopy(),
+			CIDRs:             in.Destination.CIDRs,
+		}
+	}
+	out.Ports = nil
+	for _, port := range in.Ports {
+		out.Ports = append(out.Ports, v1alpha1.ThroughputAnomalyDetectorPortRange(port))
+	}
+	out.Protocols = in.Protocols
+	out.FlowTypes = in.FlowTypes
+	return nil
+}
+
+// Convert_v1alpha1_ThroughputAnomalyDetectorStats_To_v1alpha2_ThroughputAnomalyDetectorStats
+// parses the values which v1alpha1 formats as strings. Empty strings are
+// converted to zero values.
+func Convert_v1alpha1_ThroughputAnomalyDetectorStatus_To_v1alpha2_ThroughputAnomalyDetectorStatus(in *v1alpha1.ThroughputAnomalyDetectorStatus, out *ThroughputAnomalyDetectorStatus, s conversion.Scope) error {
+	out.State = in.State
+	out.SparkApplication = in.SparkApplication
+	out.CompletedStages = in.CompletedStages
+	out.TotalStages = in.TotalStages
+	out.Progress = (*JobProgress)(in.Progress.DeepCopy())
+	out.InputEstimate = (*JobInputEstimate)(in.InputEstimate.DeepCopy())
+	out.ErrorMsg = in.ErrorMsg
+	out.StartTime = in.StartTime
+	out.EndTime = in.EndTime
+	return nil
+}
+
+func Convert_v1alpha2_ThroughputAnomalyDetectorStatus_To_v1alpha1_ThroughputAnomalyDetectorStatus(in *ThroughputAnomalyDetectorStatus, out *v1alpha1.ThroughputAnomalyDetectorStatus, s conversion.Scope) error {
+	out.State = in.State
+	out.SparkApplication = in.SparkApplication
+	out.CompletedStages = in.CompletedStages
+	out.TotalStages = in.TotalStages
+	out.Progress = (*v1alpha1.JobProgress)(in.Progress.DeepCopy())
+	out.InputEstimate = (*v1alpha1.JobInputEstimate)(in.InputEstimate.DeepCopy())
+	out.ErrorMsg = in.ErrorMsg
+	out.StartTime = in.StartTime
+	out.EndTime = in.EndTime
+	return nil
+}
+
+func Convert_v1alpha1_ThroughputAnomalyDetectorStats_To_v1alpha2_ThroughputAnomalyDetectorStats(in *v1alpha1.ThroughputAnomalyDetectorStats, out *ThroughputAnomalyDetectorStats, s conversion.Scope) error {
+	var err error
+	out.Id = in.Id
+	out.SourceIP = in.SourceIP
+	if out.SourceTransportPort, err = parsePort(in.SourceTransportPort); err != nil {
+		return fmt.Errorf("invalid sourceTransportPort %q: %v", in.SourceTransportPort, err)
+	}
+	out.DestinationIP = in.DestinationIP
+	if out.DestinationTransportPort, err = parsePort(in.DestinationTransportPort); err != nil {
+		return fmt.Errorf("invalid destinationTransportPort %q: %v", in.DestinationTransportPort, err)
+	}
+	if out.FlowStartSeconds, err = parseTime(in.FlowStartSeconds); err != nil {
+		return fmt.Errorf("invalid flowStartSeconds %q: %v", in.FlowStartSeconds, err)
+	}
+	out.PodNamespace = in.PodNamespace
+	out.PodLabels = in.PodLabels
+	out.PodName = in.PodName
+	out.Direction = in.Direction
+	out.DestinationServicePortName = in.DestinationServicePortName
+	out.SourceNodeName = in.SourceNodeName
+	out.DestinationNodeName = in.DestinationNodeName
+	if out.FlowEndSeconds, err = parseTime(in.FlowEndSeconds); err != nil {
+		return fmt.Errorf("invalid flowEndSeconds %q: %v", in.FlowEndSeconds, err)
+	}
+	out.Metric = in.Metric
+	if out.Throughput, err = parseFloat(in.Throughput); err != nil {
+		return fmt.Errorf("invalid throughput %q: %v", in.Throughput, err)
+	}
+	out.AggType = in.AggType
+	out.AlgoType = in.AlgoType
+	out.AlgoParams = nil
+	if in.AlgoParams != "" {
+		out.AlgoParams = new(ThroughputAnomalyDetectorAlgoParams)
+		if err := json.Unmarshal([]byte(in.AlgoParams), out.AlgoParams); err != nil {
+			return fmt.Errorf("invalid algoParams %q: %v", in.AlgoParams, err)
+		}
+	}
+	if out.AlgoCalc, err = parseFloat(in.AlgoCalc); err != nil {
+		return fmt.Errorf("invalid algoCalc %q: %v", in.AlgoCalc, err)
+	}
+	if out.AlgoVerdicts, err = parseVerdicts(in.AlgoVerdicts); err != nil {
+		return fmt.Errorf("invalid algoVerdicts %q: %v", in.AlgoVerdicts, err)
+	}
+	if out.Anomaly, err = parseBool(in.Anomaly); err != nil {
+		return fmt.Errorf("invalid anomaly %q: %v", in.Anomaly, err)
+	}
+	return nil
+}
+
+func Convert_v1alpha2_ThroughputAnomalyDetectorStats_To_v1alpha1_ThroughputAnomalyDetectorStats(in *ThroughputAnomalyDetectorStats, out *v1alpha1.ThroughputAnomalyDetectorStats, s conversion.Scope) error {
+	out.Id = in.Id
+	out.SourceIP = in.SourceIP
+	out.SourceTransportPort = formatPort(in.SourceTransportPort)
+	out.DestinationIP = in.DestinationIP
+	out.DestinationTransportPort = formatPort(in.DestinationTransportPort)
+	out.FlowStartSeconds = formatTime(in.FlowStartSeconds)
+	out.PodNamespace = in.PodNamespace
+	out.PodLabels = in.PodLabels
+	out.PodName = in.PodName
+	out.Direction = in.Direction
+	out.DestinationServicePortName = in.DestinationServicePortName
+	out.SourceNodeName = in.SourceNodeName
+	out.DestinationNodeName = in.DestinationNodeName
+	out.FlowEndSeconds = formatTime(in.FlowEndSeconds)
+	out.Metric = in.Metric
+	out.Throughput = strconv.FormatFloat(in.Throughput, 'g', -1, 64)
+	out.AggType = in.AggType
+	out.AlgoType = in.AlgoType
+	out.AlgoParams = ""
+	if in.AlgoParams != nil {
+		algoParams, err := json.Marshal(in.AlgoParams)
+		if err != nil {
+			return fmt.Errorf("invalid algoParams: %v", err)
+		}
+		out.AlgoParams = string(algoParams)
+	}
+	out.AlgoCalc = strconv.FormatFloat(in.AlgoCalc, 'g', -1, 64)
+	out.AlgoVerdicts = formatVerdicts(in.AlgoVerdicts)
+	out.Anomaly = strconv.FormatBool(in.Anomaly)
+	return nil
+}
+
+func parsePort(port string) (int32, error) {
+	if port == "" {
+		return 0, nil
+	}
+	p, err := strconv.ParseInt(port, 10, 32)
+	return int32(p), err
+}
+
+func formatPort(port int32) string {
+	if port == 0 {
+		return ""
+	}
+	return strconv.Itoa(int(port))
+}
+
+func parseTime(t string) (metav1.Time, error) {
+	if t == "" {
+		return metav1.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, t)
+	if err != nil {
+		return metav1.Time{}, err
+	}
+	return metav1.NewTime(parsed), nil
+}
+
+func formatTime(t metav1.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseFloat(f string) (float64, error) {
+	if f == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(f, 64)
+}
+
+func parseBool(b string) (bool, error) {
+	if b == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(b)
+}
+
+// parseVerdicts parses ENSEMBLE verdicts formatted as
+// "EWMA:true,ARIMA:false,DBSCAN:true".
+func parseVerdicts(verdicts string) ([]ThroughputAnomalyDetectorVerdict, error) {
+	if verdicts == "" {
+		return nil, nil
+	}
+	var result []ThroughputAnomalyDetectorVerdict
+	for _, verdict := range strings.Split(verdicts, ",") {
+		algoType, anomaly, found := strings.Cut(verdict, ":")
+		if !found {
+			return nil, fmt.Errorf("verdict %q should be in the form <algoType>:<anomaly>", verdict)
+		}
+		isAnomaly, err := strconv.ParseBool(anomaly)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ThroughputAnomalyDetectorVerdict{AlgoType: algoType, Anomaly: isAnomaly})
+	}
+	return result, nil
+}
+
+func formatVerdicts(verdicts []ThroughputAnomalyDetectorVerdict) string {
+	result := make([]string, 0, len(verdicts))
+	for _, verdict := range verdicts {
+		result = append(result, fmt.Sprintf("%s:%t", verdict.AlgoType, verdict.Anomaly))
+	}
+	return strings.Join(result, ",")
+}
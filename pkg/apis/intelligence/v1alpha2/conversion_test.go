@@ -0,0 +1,228 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+)
+
+func TestConvertThroughputAnomalyDetector(t *testing.T) {
+	flowStart := metav1.NewTime(time.Date(2022, 8, 11, 6, 26, 54, 0, time.UTC))
+	flowEnd := metav1.NewTime(time.Date(2022, 8, 11, 8, 6, 54, 0, time.UTC))
+	for _, tc := range []struct {
+		name        string
+		in          *v1alpha1.ThroughputAnomalyDetector
+		expected    *ThroughputAnomalyDetector
+		expectedErr string
+	}{
+		{
+			name: "flow stats",
+			in: &v1alpha1.ThroughputAnomalyDetector{
+				ObjectMeta: metav1.ObjectMeta{Name: "tad-1"},
+				Type:       "EWMA",
+				AlgoParams: &v1alpha1.ThroughputAnomalyDetectorAlgoParams{Sensitivity: 2},
+				Status:     v1alpha1.ThroughputAnomalyDetectorStatus{State: "COMPLETED"},
+				Stats: []v1alpha1.ThroughputAnomalyDetectorStats{{
+					Id:                       "tad-1",
+					SourceIP:                 "10.10.1.25",
+					SourceTransportPort:      "58076",
+					DestinationIP:            "10.10.1.33",
+					DestinationTransportPort: "5201",
+					FlowStartSeconds:         "2022-08-11T06:26:54Z",
+					FlowEndSeconds:           "2022-08-11T08:06:54Z",
+					Metric:                   "throughput",
+					Throughput:               "4.005703059e+09",
+					AggType:                  "None",
+					AlgoType:                 "EWMA",
+					AlgoParams:               `{"ewmaAlpha": 0.5, "sensitivity": 2}`,
+					AlgoCalc:                 "1.0001208441920074e+10",
+					Anomaly:                  "true",
+				}},
+			},
+			expected: &ThroughputAnomalyDetector{
+				ObjectMeta: metav1.ObjectMeta{Name: "tad-1"},
+				Type:       "EWMA",
+				AlgoParams: &ThroughputAnomalyDetectorAlgoParams{Sensitivity: 2},
+				Status:     ThroughputAnomalyDetectorStatus{State: "COMPLETED"},
+				Stats: []ThroughputAnomalyDetectorStats{{
+					Id:                       "tad-1",
+					SourceIP:                 "10.10.1.25",
+					SourceTransportPort:      58076,
+					DestinationIP:            "10.10.1.33",
+					DestinationTransportPort: 5201,
+					FlowStartSeconds:         flowStart,
+					FlowEndSeconds:           flowEnd,
+					Metric:                   "throughput",
+					Throughput:               4.005703059e+09,
+					AggType:                  "None",
+					AlgoType:                 "EWMA",
+					AlgoParams:               &ThroughputAnomalyDetectorAlgoParams{Sensitivity: 2, EWMAAlpha: 0.5},
+					AlgoCalc:                 1.0001208441920074e+10,
+					Anomaly:                  true,
+				}},
+			},
+		},
+		{
+			name: "ensemble verdicts",
+			in: &v1alpha1.ThroughputAnomalyDetector{
+				Stats: []v1alpha1.ThroughputAnomalyDetectorStats{{
+					DestinationServicePortName: "default/svc:http",
+					FlowEndSeconds:             "2022-08-11T08:06:54Z",
+					AggType:                    "svc",
+					AlgoType:                   "ENSEMBLE",
+					AlgoCalc:                   "2",
+					AlgoVerdicts:               "EWMA:true,ARIMA:false,DBSCAN:true",
+					Anomaly:                    "true",
+				}},
+			},
+			expected: &ThroughputAnomalyDetector{
+				Stats: []ThroughputAnomalyDetectorStats{{
+					DestinationServicePortName: "default/svc:http",
+					FlowEndSeconds:             flowEnd,
+					AggType:                    "svc",
+					AlgoType:                   "ENSEMBLE",
+					AlgoCalc:                   2,
+					AlgoVerdicts: []ThroughputAnomalyDetectorVerdict{
+						{AlgoType: "EWMA", Anomaly: true},
+						{AlgoType: "ARIMA", Anomaly: false},
+						{AlgoType: "DBSCAN", Anomaly: true},
+					},
+					Anomaly: true,
+				}},
+			},
+		},
+		{
+			name: "no anomaly detected",
+			in: &v1alpha1.ThroughputAnomalyDetector{
+				Stats: []v1alpha1.ThroughputAnomalyDetectorStats{{
+					Id:      "tad-1",
+					Anomaly: "NO ANOMALY DETECTED",
+				}},
+			},
+			expected: &ThroughputAnomalyDetector{},
+		},
+		{
+			name: "invalid throughput",
+			in: &v1alpha1.ThroughputAnomalyDetector{
+				Stats: []v1alpha1.ThroughputAnomalyDetectorStats{{
+					Throughput: "fast",
+				}},
+			},
+			expectedErr: `invalid throughput "fast"`,
+		},
+		{
+			name: "invalid verdicts",
+			in: &v1alpha1.ThroughputAnomalyDetector{
+				Stats: []v1alpha1.ThroughputAnomalyDetectorStats{{
+					AlgoVerdicts: "EWMA",
+				}},
+			},
+			expectedErr: `invalid algoVerdicts "EWMA"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(ThroughputAnomalyDetector)
+			err := Convert_v1alpha1_ThroughputAnomalyDetector_To_v1alpha2_ThroughputAnomalyDetector(tc.in, out, nil)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestConvertThroughputAnomalyDetectorRoundTrip(t *testing.T) {
+	in := &v1alpha1.ThroughputAnomalyDetectorList{
+		Items: []v1alpha1.ThroughputAnomalyDetector{{
+			ObjectMeta:       metav1.ObjectMeta{Name: "tad-1"},
+			Type:             "ENSEMBLE",
+			SaveNormalPoints: true,
+			Stats: []v1alpha1.ThroughputAnomalyDetectorStats{{
+				SourceIP:                 "10.10.1.25",
+				SourceTransportPort:      "58076",
+				DestinationIP:            "10.10.1.33",
+				DestinationTransportPort: "5201",
+				FlowStartSeconds:         "2022-08-11T06:26:54Z",
+				FlowEndSeconds:           "2022-08-11T08:06:54Z",
+				Metric:                   "packetRate",
+				Throughput:               "4.005703059e+09",
+				AlgoType:                 "ENSEMBLE",
+				AlgoParams:               `{"quorum":2}`,
+				AlgoCalc:                 "3",
+				AlgoVerdicts:             "EWMA:true,ARIMA:true,DBSCAN:true",
+				Anomaly:                  "true",
+			}},
+		}},
+	}
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	require.NoError(t, AddToScheme(scheme))
+	converted := new(ThroughputAnomalyDetectorList)
+	require.NoError(t, scheme.Convert(in, converted, nil))
+	out := new(v1alpha1.ThroughputAnomalyDetectorList)
+	require.NoError(t, scheme.Convert(converted, out, nil))
+	assert.Equal(t, in, out)
+}
+
+func TestConvertNetworkPolicyRecommendationRoundTrip(t *testing.T) {
+	ttl := int32(600)
+	in := &v1alpha1.NetworkPolicyRecommendationList{
+		Items: []v1alpha1.NetworkPolicyRecommendation{{
+			ObjectMeta:              metav1.ObjectMeta{Name: "pr-1"},
+			Type:                    "initial",
+			Limit:                   100,
+			PolicyType:              "anp-deny-applied",
+			StartInterval:           metav1.NewTime(time.Date(2022, 8, 11, 6, 0, 0, 0, time.UTC)),
+			NSAllowList:             []string{"kube-system"},
+			TargetNamespaces:        []string{"default"},
+			TargetLabels:            map[string]string{"app": "web"},
+			ToServices:              true,
+			Tier:                    "baseline",
+			BasePriority:            5,
+			PriorityStep:            0.1,
+			ExecutorInstances:       2,
+			ActiveDeadlineSeconds:   3600,
+			TTLSecondsAfterFinished: &ttl,
+			Status: v1alpha1.NetworkPolicyRecommendationStatus{
+				State:            "RUNNING",
+				SparkApplication: "pr-1",
+				CompletedStages:  1,
+				TotalStages:      5,
+				Progress:         &v1alpha1.JobProgress{CurrentStage: "collect", CompletedTasks: 2, TotalTasks: 4},
+				InputEstimate:    &v1alpha1.JobInputEstimate{Rows: 1000, Bytes: 200000},
+			},
+		}},
+	}
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	require.NoError(t, AddToScheme(scheme))
+	converted := new(NetworkPolicyRecommendationList)
+	require.NoError(t, scheme.Convert(in, converted, nil))
+	assert.Equal(t, in.Items[0].TargetLabels, converted.Items[0].TargetLabels)
+	assert.Equal(t, int64(1000), converted.Items[0].Status.InputEstimate.Rows)
+	out := new(v1alpha1.NetworkPolicyRecommendationList)
+	require.NoError(t, scheme.Convert(converted, out, nil))
+	assert.Equal(t, in, out)
+}
@@ -0,0 +1,255 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha2
+
+import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ThroughputAnomalyDetector struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Type                    string                               `json:"jobType,omitempty"`
+	StartInterval           metav1.Time                          `json:"startInterval,omitempty"`
+	EndInterval             metav1.Time                          `json:"endInterval,omitempty"`
+	ExecutorInstances       int                                  `json:"executorInstances,omitempty"`
+	NSIgnoreList            []string                             `json:"nsIgnoreList,omitempty"`
+	AggregatedFlow          string                               `json:"aggFlow,omitempty"`
+	PodLabel                string                               `json:"podLabel,omitempty"`
+	PodName                 string                               `json:"podName,omitempty"`
+	PodNameSpace            string                               `json:"podNameSpace,omitempty"`
+	ExternalIP              string                               `json:"externalIp,omitempty"`
+	ServicePortName         string                               `json:"servicePortName,omitempty"`
+	NodeName                string                               `json:"nodeName,omitempty"`
+	Metric                  string                               `json:"metric,omitempty"`
+	SaveNormalPoints        bool                                 `json:"saveNormalPoints,omitempty"`
+	DriverCoreRequest       string                               `json:"driverCoreRequest,omitempty"`
+	DriverMemory            string                               `json:"driverMemory,omitempty"`
+	ExecutorCoreRequest     string                               `json:"executorCoreRequest,omitempty"`
+	ExecutorMemory          string                               `json:"executorMemory,omitempty"`
+	ActiveDeadlineSeconds   int64                                `json:"activeDeadlineSeconds,omitempty"`
+	TTLSecondsAfterFinished *int32                               `json:"ttlSecondsAfterFinished,omitempty"`
+	AlgoParams              *ThroughputAnomalyDetectorAlgoParams `json:"algoParams,omitempty"`
+	FlowFilter              *ThroughputAnomalyDetectorFlowFilter `json:"flowFilter,omitempty"`
+	Status                  ThroughputAnomalyDetectorStatus      `json:"status,omitempty"`
+	Stats                   []ThroughputAnomalyDetectorStats     `json:"stats,omitempty"`
+}
+
+// ThroughputAnomalyDetectorAlgoParams holds the optional parameters of the
+// anomaly detection algorithms. Unset parameters use the default values of
+// the algorithm.
+type ThroughputAnomalyDetectorAlgoParams struct {
+	// Sensitivity is the number of standard deviations the throughput may
+	// deviate from the value calculated by EWMA or ARIMA before it is
+	// considered anomalous. Defaults to 1.
+	Sensitivity float64 `json:"sensitivity,omitempty"`
+	// EWMAAlpha is the smoothing factor of EWMA, in (0, 1]. Defaults to 0.5.
+	EWMAAlpha float64 `json:"ewmaAlpha,omitempty"`
+	// ARIMAOrder is the (p, d, q) order of the ARIMA model. Defaults to
+	// [1, 1, 1].
+	ARIMAOrder []int `json:"arimaOrder,omitempty"`
+	// DBSCANEps is the maximum throughput distance between two samples of
+	// the same DBSCAN cluster. Defaults to 250000000.
+	DBSCANEps float64 `json:"dbscanEps,omitempty"`
+	// DBSCANMinSamples is the number of samples in a neighborhood for a
+	// point to be considered as a DBSCAN core point. Defaults to 4.
+	DBSCANMinSamples int `json:"dbscanMinSamples,omitempty"`
+	// Quorum is the number of algorithms which must find a throughput
+	// anomalous for the ENSEMBLE algorithm to report it. Defaults to 2.
+	Quorum int `json:"quorum,omitempty"`
+}
+
+// ThroughputAnomalyDetectorFlowFilter restricts the flows analyzed by a
+// ThroughputAnomalyDetector. A flow is analyzed only if it matches all the
+// set fields of the filter.
+type ThroughputAnomalyDetectorFlowFilter struct {
+	// Source selects the sources of the analyzed flows.
+	Source *ThroughputAnomalyDetectorFlowPeer `json:"source,omitempty"`
+	// Destination selects the destinations of the analyzed flows.
+	Destination *ThroughputAnomalyDetectorFlowPeer `json:"destination,omitempty"`
+	// Ports selects the destination ports of the analyzed flows. A flow
+	// matches if its destination port is in any of the ranges.
+	Ports []ThroughputAnomalyDetectorPortRange `json:"ports,omitempty"`
+	// Protocols selects the protocols of the analyzed flows, among TCP, UDP,
+	// SCTP and ICMP.
+	Protocols []string `json:"protocols,omitempty"`
+	// FlowTypes selects the types of the analyzed flows, among pod_to_pod,
+	// pod_to_svc and pod_to_external.
+	FlowTypes []string `json:"flowTypes,omitempty"`
+}
+
+// ThroughputAnomalyDetectorFlowPeer selects one end of the analyzed flows. A
+// flow end matches if it matches all the set fields.
+type ThroughputAnomalyDetectorFlowPeer struct {
+	// NamespaceSelector selects the Namespaces of the Pods. It is evaluated
+	// against the Namespaces existing when the job starts.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// PodSelector selects the Pods by their labels.
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+	// CIDRs selects the IP addresses. A flow end matches if its IP address
+	// is in any of the CIDRs.
+	CIDRs []string `json:"cidrs,omitempty"`
+}
+
+// ThroughputAnomalyDetectorPortRange is a range of ports. End defaults to
+// Start, to select a single port.
+type ThroughputAnomalyDetectorPortRange struct {
+	Start int `json:"start"`
+	End   int `json:"end,omitempty"`
+}
+
+// JobInputEstimate is the size of the flow records read by a job, estimated
+// with ClickHouse before its Spark Application is created.
+type JobInputEstimate struct {
+	// Rows is the number of flow records in the time window of the job which
+	// match its filters.
+	Rows int64 `json:"rows,omitempty"`
+	// Bytes is the uncompressed size of these flow records, extrapolated
+	// from the average size of the records of the flows table.
+	Bytes int64 `json:"bytes,omitempty"`
+}
+
+// JobProgress is the detailed progress of the Spark Application of a running
+// job, as reported by the Spark monitoring API.
+type JobProgress struct {
+	// CurrentStage is the name of the earliest stage which is still running.
+	CurrentStage string `json:"currentStage,omitempty"`
+	// CompletedTasks and TotalTasks are the task counts of CurrentStage.
+	CompletedTasks int `json:"completedTasks,omitempty"`
+	TotalTasks     int `json:"totalTasks,omitempty"`
+	// ActiveExecutors is the number of executors which are alive.
+	ActiveExecutors int `json:"activeExecutors,omitempty"`
+	// FailedExecutors is the number of executors which have been lost
+	// while the Spark Application is running.
+	FailedExecutors int `json:"failedExecutors,omitempty"`
+	// EstimatedEndTime extrapolates the elapsed time with the fraction of
+	// the stages and tasks which are completed. It is a rough estimate, as
+	// Spark only plans the stages of a query when the query starts.
+	EstimatedEndTime metav1.Time `json:"estimatedEndTime,omitempty"`
+}
+
+type ThroughputAnomalyDetectorStatus struct {
+	State            string            `json:"state,omitempty"`
+	SparkApplication string            `json:"sparkApplication,omitempty"`
+	CompletedStages  int               `json:"completedStages,omitempty"`
+	TotalStages      int               `json:"totalStages,omitempty"`
+	Progress         *JobProgress      `json:"progress,omitempty"`
+	InputEstimate    *JobInputEstimate `json:"inputEstimate,omitempty"`
+	ErrorMsg         string            `json:"errorMsg,omitempty"`
+	StartTime        metav1.Time       `json:"startTime,omitempty"`
+	EndTime          metav1.Time       `json:"endTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ThroughputAnomalyDetectorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ThroughputAnomalyDetector `json:"items"`
+}
+
+// ThroughputAnomalyDetectorStats is a throughput calculated by a
+// ThroughputAnomalyDetector. Unlike in v1alpha1, numbers, times and booleans
+// are typed instead of being formatted as strings. Throughput holds the value
+// of the analysed Metric, which is not necessarily the throughput.
+type ThroughputAnomalyDetectorStats struct {
+	Id                         string                               `json:"id,omitempty"`
+	SourceIP                   string                               `json:"sourceIP,omitempty"`
+	SourceTransportPort        int32                                `json:"sourceTransportPort,omitempty"`
+	DestinationIP              string                               `json:"destinationIP,omitempty"`
+	DestinationTransportPort   int32                                `json:"destinationTransportPort,omitempty"`
+	FlowStartSeconds           metav1.Time                          `json:"flowStartSeconds,omitempty"`
+	PodNamespace               string                               `json:"podNamespace,omitempty"`
+	PodLabels                  string                               `json:"podLabels,omitempty"`
+	PodName                    string                               `json:"podName,omitempty"`
+	Direction                  string                               `json:"direction,omitempty"`
+	DestinationServicePortName string                               `json:"destinationServicePortName,omitempty"`
+	SourceNodeName             string                               `json:"sourceNodeName,omitempty"`
+	DestinationNodeName        string                               `json:"destinationNodeName,omitempty"`
+	FlowEndSeconds             metav1.Time                          `json:"flowEndSeconds,omitempty"`
+	Metric                     string                               `json:"metric,omitempty"`
+	Throughput                 float64                              `json:"throughput"`
+	AggType                    string                               `json:"aggType,omitempty"`
+	AlgoType                   string                               `json:"algoType,omitempty"`
+	AlgoParams                 *ThroughputAnomalyDetectorAlgoParams `json:"algoParams,omitempty"`
+	AlgoCalc                   float64                              `json:"algoCalc"`
+	AlgoVerdicts               []ThroughputAnomalyDetectorVerdict   `json:"algoVerdicts,omitempty"`
+	Anomaly                    bool                                 `json:"anomaly"`
+}
+
+// ThroughputAnomalyDetectorVerdict is the result of one of the algorithms
+// combined by the ENSEMBLE algorithm.
+type ThroughputAnomalyDetectorVerdict struct {
+	AlgoType string `json:"algoType"`
+	Anomaly  bool   `json:"anomaly"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NetworkPolicyRecommendation is unchanged from v1alpha1. It is served in
+// v1alpha2 so that clients can use a single version of the API group.
+type NetworkPolicyRecommendation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Type                    string                            `json:"jobType,omitempty"`
+	Limit                   int                               `json:"limit,omitempty"`
+	PolicyType              string                            `json:"policyType,omitempty"`
+	StartInterval           metav1.Time                       `json:"startInterval,omitempty"`
+	EndInterval             metav1.Time                       `json:"endInterval,omitempty"`
+	NSAllowList             []string                          `json:"nsAllowList,omitempty"`
+	TargetNamespaces        []string                          `json:"targetNamespaces,omitempty"`
+	TargetLabels            map[string]string                 `json:"targetLabels,omitempty"`
+	ExcludeLabels           bool                              `json:"excludeLabels,omitempty"`
+	ToServices              bool                              `json:"toServices,omitempty"`
+	Tier                    string                            `json:"tier,omitempty"`
+	BasePriority            float64                           `json:"basePriority,omitempty"`
+	PriorityStep            float64                           `json:"priorityStep,omitempty"`
+	ExecutorInstances       int                               `json:"executorInstances,omitempty"`
+	DriverCoreRequest       string                            `json:"driverCoreRequest,omitempty"`
+	DriverMemory            string                            `json:"driverMemory,omitempty"`
+	ExecutorCoreRequest     string                            `json:"executorCoreRequest,omitempty"`
+	ExecutorMemory          string                            `json:"executorMemory,omitempty"`
+	ActiveDeadlineSeconds   int64                             `json:"activeDeadlineSeconds,omitempty"`
+	TTLSecondsAfterFinished *int32                            `json:"ttlSecondsAfterFinished,omitempty"`
+	Status                  NetworkPolicyRecommendationStatus `json:"status,omitempty"`
+}
+
+type NetworkPolicyRecommendationStatus struct {
+	State                 string            `json:"state,omitempty"`
+	SparkApplication      string            `json:"sparkApplication,omitempty"`
+	CompletedStages       int               `json:"completedStages,omitempty"`
+	TotalStages           int               `json:"totalStages,omitempty"`
+	Progress              *JobProgress      `json:"progress,omitempty"`
+	InputEstimate         *JobInputEstimate `json:"inputEstimate,omitempty"`
+	RecommendationOutcome string            `json:"recommendationOutcome,omitempty"`
+	ErrorMsg              string            `json:"errorMsg,omitempty"`
+	StartTime             metav1.Time       `json:"startTime,omitempty"`
+	EndTime               metav1.Time       `json:"endTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type NetworkPolicyRecommendationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NetworkPolicyRecommendation `json:"items"`
+}
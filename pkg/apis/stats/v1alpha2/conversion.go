@@ -0,0 +1,218 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/conversion"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"antrea.io/theia/pkg/apis/stats/v1alpha1"
+)
+
+// readableSizeUnits are the units used by the formatReadableSize function of
+// ClickHouse, which formats the sizes in v1alpha1, and their Quantity suffixes.
+var readableSizeUnits = []struct {
+	unit   string
+	suffix string
+}{
+	{"B", ""},
+	{"KiB", "Ki"},
+	{"MiB", "Mi"},
+	{"GiB", "Gi"},
+	{"TiB", "Ti"},
+	{"PiB", "Pi"},
+	{"EiB", "Ei"},
+}
+
+// RegisterConversions adds the conversion functions between v1alpha1 and
+// v1alpha2 to the given scheme.
+func RegisterConversions(s *runtime.Scheme) error {
+	if err := s.AddConversionFunc((*v1alpha1.ClickHouseStats)(nil), (*ClickHouseStats)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_ClickHouseStats_To_v1alpha2_ClickHouseStats(a.(*v1alpha1.ClickHouseStats), b.(*ClickHouseStats), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddConversionFunc((*ClickHouseStats)(nil), (*v1alpha1.ClickHouseStats)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha2_ClickHouseStats_To_v1alpha1_ClickHouseStats(a.(*ClickHouseStats), b.(*v1alpha1.ClickHouseStats), scope)
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Convert_v1alpha1_ClickHouseStats_To_v1alpha2_ClickHouseStats parses the
+// values which v1alpha1 formats as strings. Sizes are only as precise as the
+// two decimals of their v1alpha1 representation.
+func Convert_v1alpha1_ClickHouseStats_To_v1alpha2_ClickHouseStats(in *v1alpha1.ClickHouseStats, out *ClickHouseStats, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.DiskInfos = nil
+	for _, diskInfo := range in.DiskInfos {
+		res := DiskInfo{Database: diskInfo.Database, Path: diskInfo.Path}
+		var err error
+		if res.Shard, err = parseShard(diskInfo.Shard); err != nil {
+			return err
+		}
+		if res.FreeSpace, err = parseReadableSize(diskInfo.FreeSpace); err != nil {
+			return fmt.Errorf("invalid freeSpace %q: %v", diskInfo.FreeSpace, err)
+		}
+		if res.TotalSpace, err = parseReadableSize(diskInfo.TotalSpace); err != nil {
+			return fmt.Errorf("invalid totalSpace %q: %v", diskInfo.TotalSpace, err)
+		}
+		if res.UsedPercentage, err = strconv.ParseFloat(strings.TrimSuffix(diskInfo.UsedPercentage, " %"), 64); err != nil {
+			return fmt.Errorf("invalid usedPercentage %q: %v", diskInfo.UsedPercentage, err)
+		}
+		out.DiskInfos = append(out.DiskInfos, res)
+	}
+	out.TableInfos = nil
+	for _, tableInfo := range in.TableInfos {
+		res := TableInfo{Database: tableInfo.Database, TableName: tableInfo.TableName}
+		var err error
+		if res.Shard, err = parseShard(tableInfo.Shard); err != nil {
+			return err
+		}
+		if res.TotalRows, err = strconv.ParseInt(tableInfo.TotalRows, 10, 64); err != nil {
+			return fmt.Errorf("invalid totalRows %q: %v", tableInfo.TotalRows, err)
+		}
+		if res.TotalBytes, err = parseReadableSize(tableInfo.TotalBytes); err != nil {
+			return fmt.Errorf("invalid totalBytes %q: %v", tableInfo.TotalBytes, err)
+		}
+		if res.TotalCols, err = strconv.ParseInt(tableInfo.TotalCols, 10, 64); err != nil {
+			return fmt.Errorf("invalid totalCols %q: %v", tableInfo.TotalCols, err)
+		}
+		out.TableInfos = append(out.TableInfos, res)
+	}
+	out.InsertRates = nil
+	for _, insertRate := range in.InsertRates {
+		res := InsertRate{}
+		var err error
+		if res.Shard, err = parseShard(insertRate.Shard); err != nil {
+			return err
+		}
+		// RowsPerSec is a truncated average, which may be formatted in
+		// scientific notation.
+		rowsPerSec, err := strconv.ParseFloat(insertRate.RowsPerSec, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rowsPerSec %q: %v", insertRate.RowsPerSec, err)
+		}
+		res.RowsPerSec = int64(rowsPerSec)
+		if res.BytesPerSec, err = parseReadableSize(insertRate.BytesPerSec); err != nil {
+			return fmt.Errorf("invalid bytesPerSec %q: %v", insertRate.BytesPerSec, err)
+		}
+		out.InsertRates = append(out.InsertRates, res)
+	}
+	out.StackTraces = nil
+	for _, stackTrace := range in.StackTraces {
+		res := StackTrace{TraceFunctions: stackTrace.TraceFunctions}
+		var err error
+		if res.Shard, err = parseShard(stackTrace.Shard); err != nil {
+			return err
+		}
+		if res.Count, err = strconv.ParseInt(stackTrace.Count, 10, 64); err != nil {
+			return fmt.Errorf("invalid count %q: %v", stackTrace.Count, err)
+		}
+		out.StackTraces = append(out.StackTraces, res)
+	}
+	out.ErrorMsg = in.ErrorMsg
+	return nil
+}
+
+func Convert_v1alpha2_ClickHouseStats_To_v1alpha1_ClickHouseStats(in *ClickHouseStats, out *v1alpha1.ClickHouseStats, s conversion.Scope) error {
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.DiskInfos = nil
+	for _, diskInfo := range in.DiskInfos {
+		out.DiskInfos = append(out.DiskInfos, v1alpha1.DiskInfo{
+			Shard:          formatShard(diskInfo.Shard),
+			Database:       diskInfo.Database,
+			Path:           diskInfo.Path,
+			FreeSpace:      formatReadableSize(diskInfo.FreeSpace),
+			TotalSpace:     formatReadableSize(diskInfo.TotalSpace),
+			UsedPercentage: strconv.FormatFloat(diskInfo.UsedPercentage, 'f', -1, 64) + " %",
+		})
+	}
+	out.TableInfos = nil
+	for _, tableInfo := range in.TableInfos {
+		out.TableInfos = append(out.TableInfos, v1alpha1.TableInfo{
+			Shard:      formatShard(tableInfo.Shard),
+			Database:   tableInfo.Database,
+			TableName:  tableInfo.TableName,
+			TotalRows:  strconv.FormatInt(tableInfo.TotalRows, 10),
+			TotalBytes: formatReadableSize(tableInfo.TotalBytes),
+			TotalCols:  strconv.FormatInt(tableInfo.TotalCols, 10),
+		})
+	}
+	out.InsertRates = nil
+	for _, insertRate := range in.InsertRates {
+		out.InsertRates = append(out.InsertRates, v1alpha1.InsertRate{
+			Shard:       formatShard(insertRate.Shard),
+			RowsPerSec:  strconv.FormatInt(insertRate.RowsPerSec, 10),
+			BytesPerSec: formatReadableSize(insertRate.BytesPerSec),
+		})
+	}
+	out.StackTraces = nil
+	for _, stackTrace := range in.StackTraces {
+		out.StackTraces = append(out.StackTraces, v1alpha1.StackTrace{
+			Shard:          formatShard(stackTrace.Shard),
+			TraceFunctions: stackTrace.TraceFunctions,
+			Count:          strconv.FormatInt(stackTrace.Count, 10),
+		})
+	}
+	out.ErrorMsg = in.ErrorMsg
+	return nil
+}
+
+func parseShard(shard string) (int32, error) {
+	s, err := strconv.ParseInt(shard, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid shard %q: %v", shard, err)
+	}
+	return int32(s), nil
+}
+
+func formatShard(shard int32) string {
+	return strconv.Itoa(int(shard))
+}
+
+// parseReadableSize parses a size formatted by formatReadableSize, e.g.
+// "12.30 GiB".
+func parseReadableSize(size string) (resource.Quantity, error) {
+	value, unit, found := strings.Cut(size, " ")
+	if !found {
+		return resource.Quantity{}, fmt.Errorf("size should be in the form <value> <unit>")
+	}
+	for _, u := range readableSizeUnits {
+		if u.unit == unit {
+			return resource.ParseQuantity(value + u.suffix)
+		}
+	}
+	return resource.Quantity{}, fmt.Errorf("unknown unit %q", unit)
+}
+
+// formatReadableSize formats a size the same way as the formatReadableSize
+// function of ClickHouse.
+func formatReadableSize(size resource.Quantity) string {
+	value := size.AsApproximateFloat64()
+	i := 0
+	for ; value >= 1024 && i < len(readableSizeUnits)-1; i++ {
+		value /= 1024
+	}
+	return fmt.Sprintf("%.2f %s", value, readableSizeUnits[i].unit)
+}
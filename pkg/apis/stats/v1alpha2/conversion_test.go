@@ -0,0 +1,171 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"antrea.io/theia/pkg/apis/stats/v1alpha1"
+)
+
+func TestConvertClickHouseStats(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		in          *v1alpha1.ClickHouseStats
+		expected    *ClickHouseStats
+		expectedErr string
+	}{
+		{
+			name: "disk infos",
+			in: &v1alpha1.ClickHouseStats{
+				DiskInfos: []v1alpha1.DiskInfo{{
+					Shard:          "1",
+					Database:       "default",
+					Path:           "/var/lib/clickhouse/",
+					FreeSpace:      "1.50 GiB",
+					TotalSpace:     "512.00 B",
+					UsedPercentage: "45.2 %",
+				}},
+			},
+			expected: &ClickHouseStats{
+				DiskInfos: []DiskInfo{{
+					Shard:          1,
+					Database:       "default",
+					Path:           "/var/lib/clickhouse/",
+					FreeSpace:      resource.MustParse("1.50Gi"),
+					TotalSpace:     resource.MustParse("512.00"),
+					UsedPercentage: 45.2,
+				}},
+			},
+		},
+		{
+			name: "table infos, insert rates and stack traces",
+			in: &v1alpha1.ClickHouseStats{
+				TableInfos: []v1alpha1.TableInfo{{
+					Shard:      "2",
+					Database:   "default",
+					TableName:  "flows",
+					TotalRows:  "230000",
+					TotalBytes: "12.30 MiB",
+					TotalCols:  "55",
+				}},
+				InsertRates: []v1alpha1.InsertRate{{
+					Shard:       "1",
+					RowsPerSec:  "1.2e+06",
+					BytesPerSec: "3.00 KiB",
+				}},
+				StackTraces: []v1alpha1.StackTrace{{
+					Shard:          "1",
+					TraceFunctions: "pthread_cond_wait",
+					Count:          "7",
+				}},
+				ErrorMsg: []string{"error"},
+			},
+			expected: &ClickHouseStats{
+				TableInfos: []TableInfo{{
+					Shard:      2,
+					Database:   "default",
+					TableName:  "flows",
+					TotalRows:  230000,
+					TotalBytes: resource.MustParse("12.30Mi"),
+					TotalCols:  55,
+				}},
+				InsertRates: []InsertRate{{
+					Shard:       1,
+					RowsPerSec:  1200000,
+					BytesPerSec: resource.MustParse("3.00Ki"),
+				}},
+				StackTraces: []StackTrace{{
+					Shard:          1,
+					TraceFunctions: "pthread_cond_wait",
+					Count:          7,
+				}},
+				ErrorMsg: []string{"error"},
+			},
+		},
+		{
+			name: "invalid size unit",
+			in: &v1alpha1.ClickHouseStats{
+				DiskInfos: []v1alpha1.DiskInfo{{
+					Shard:          "1",
+					FreeSpace:      "1.50 GB",
+					TotalSpace:     "2.00 GiB",
+					UsedPercentage: "25 %",
+				}},
+			},
+			expectedErr: `invalid freeSpace "1.50 GB": unknown unit "GB"`,
+		},
+		{
+			name: "invalid shard",
+			in: &v1alpha1.ClickHouseStats{
+				StackTraces: []v1alpha1.StackTrace{{
+					Shard: "first",
+					Count: "1",
+				}},
+			},
+			expectedErr: `invalid shard "first"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(ClickHouseStats)
+			err := Convert_v1alpha1_ClickHouseStats_To_v1alpha2_ClickHouseStats(tc.in, out, nil)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+		})
+	}
+}
+
+func TestConvertClickHouseStatsRoundTrip(t *testing.T) {
+	in := &v1alpha1.ClickHouseStats{
+		DiskInfos: []v1alpha1.DiskInfo{{
+			Shard:          "1",
+			Database:       "default",
+			Path:           "/var/lib/clickhouse/",
+			FreeSpace:      "1.50 GiB",
+			TotalSpace:     "8.00 GiB",
+			UsedPercentage: "81.25 %",
+		}},
+		TableInfos: []v1alpha1.TableInfo{{
+			Shard:      "1",
+			Database:   "default",
+			TableName:  "flows",
+			TotalRows:  "230000",
+			TotalBytes: "12.30 MiB",
+			TotalCols:  "55",
+		}},
+		InsertRates: []v1alpha1.InsertRate{{
+			Shard:       "1",
+			RowsPerSec:  "230",
+			BytesPerSec: "512.00 B",
+		}},
+	}
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	require.NoError(t, AddToScheme(scheme))
+	converted := new(ClickHouseStats)
+	require.NoError(t, scheme.Convert(in, converted, nil))
+	out := new(v1alpha1.ClickHouseStats)
+	require.NoError(t, scheme.Convert(converted, out, nil))
+	assert.Equal(t, in, out)
+}
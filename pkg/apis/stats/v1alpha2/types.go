@@ -0,0 +1,66 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha2
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ClickHouseStats struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	DiskInfos   []DiskInfo   `json:"diskInfos,omitempty"`
+	TableInfos  []TableInfo  `json:"tableInfos,omitempty"`
+	InsertRates []InsertRate `json:"insertRates,omitempty"`
+	StackTraces []StackTrace `json:"stackTraces,omitempty"`
+	ErrorMsg    []string     `json:"errorMsg,omitempty"`
+}
+
+type DiskInfo struct {
+	Shard      int32             `json:"shard,omitempty"`
+	Database   string            `json:"name,omitempty"`
+	Path       string            `json:"path,omitempty"`
+	FreeSpace  resource.Quantity `json:"freeSpace"`
+	TotalSpace resource.Quantity `json:"totalSpace"`
+	// UsedPercentage is the percentage of the disk space in use, in [0, 100].
+	UsedPercentage float64 `json:"usedPercentage"`
+}
+
+type TableInfo struct {
+	Shard      int32             `json:"shard,omitempty"`
+	Database   string            `json:"database,omitempty"`
+	TableName  string            `json:"tableName,omitempty"`
+	TotalRows  int64             `json:"totalRows"`
+	TotalBytes resource.Quantity `json:"totalBytes"`
+	TotalCols  int64             `json:"totalCols"`
+}
+
+type InsertRate struct {
+	Shard       int32             `json:"shard,omitempty"`
+	RowsPerSec  int64             `json:"rowsPerSec"`
+	BytesPerSec resource.Quantity `json:"bytesPerSec"`
+}
+
+type StackTrace struct {
+	Shard          int32  `json:"shard,omitempty"`
+	TraceFunctions string `json:"traceFunctions,omitempty"`
+	Count          int64  `json:"count"`
+}
@@ -35,10 +35,12 @@ import (
 	systeminstall "antrea.io/theia/pkg/apis/system/install"
 	system "antrea.io/theia/pkg/apis/system/v1alpha1"
 	"antrea.io/theia/pkg/apiserver/certificate"
+	"antrea.io/theia/pkg/apiserver/registry/intelligence/anomalysuppression"
 	"antrea.io/theia/pkg/apiserver/registry/intelligence/networkpolicyrecommendation"
 	throughputanomalydetector "antrea.io/theia/pkg/apiserver/registry/intelligence/throughputanomalydetector"
 	clickhouseStatus "antrea.io/theia/pkg/apiserver/registry/stats/clickhouse"
 	"antrea.io/theia/pkg/apiserver/registry/system/supportbundle"
+	"antrea.io/theia/pkg/apiserver/webhook"
 	"antrea.io/theia/pkg/querier"
 )
 
@@ -75,12 +77,15 @@ func init() {
 
 // ExtraConfig holds custom apiserver config.
 type ExtraConfig struct {
-	k8sClient                        kubernetes.Interface
-	kubeConfig                       *clientrest.Config
-	caCertController                 *certificate.CACertController
-	npRecommendationQuerier          querier.NPRecommendationQuerier
-	clickHouseStatQuerier            querier.ClickHouseStatQuerier
-	throughputAnomalyDetectorQuerier querier.ThroughputAnomalyDetectorQuerier
+	k8sClient                          kubernetes.Interface
+	kubeConfig                         *clientrest.Config
+	caCertController                   *certificate.CACertController
+	npRecommendationQuerier            querier.NPRecommendationQuerier
+	clickHouseStatQuerier              querier.ClickHouseStatQuerier
+	throughputAnomalyDetectorQuerier   querier.ThroughputAnomalyDetectorQuerier
+	anomalySuppressionQuerier          querier.AnomalySuppressionQuerier
+	npRecommendationValidator          webhook.JobValidator
+	throughputAnomalyDetectorValidator webhook.JobValidator
 }
 
 // Config defines the config for Theia manager apiserver.
@@ -95,6 +100,7 @@ type TheiaManagerAPIServer struct {
 	NPRecommendationQuerier          querier.NPRecommendationQuerier
 	ClickHouseStatusQuerier          querier.ClickHouseStatQuerier
 	ThroughputAnomalyDetectorQuerier querier.ThroughputAnomalyDetectorQuerier
+	AnomalySuppressionQuerier        querier.AnomalySuppressionQuerier
 }
 
 func (s *TheiaManagerAPIServer) Run(ctx context.Context) error {
@@ -114,16 +120,22 @@ func NewConfig(
 	npRecommendationQuerier querier.NPRecommendationQuerier,
 	clickHouseStatQuerier querier.ClickHouseStatQuerier,
 	throughputAnomalyDetectorQuerier querier.ThroughputAnomalyDetectorQuerier,
+	anomalySuppressionQuerier querier.AnomalySuppressionQuerier,
+	npRecommendationValidator webhook.JobValidator,
+	throughputAnomalyDetectorValidator webhook.JobValidator,
 ) *Config {
 	return &Config{
 		genericConfig: genericConfig,
 		extraConfig: ExtraConfig{
-			k8sClient:                        k8sClient,
-			kubeConfig:                       kubeConfig,
-			caCertController:                 caCertController,
-			npRecommendationQuerier:          npRecommendationQuerier,
-			clickHouseStatQuerier:            clickHouseStatQuerier,
-			throughputAnomalyDetectorQuerier: throughputAnomalyDetectorQuerier,
+			k8sClient:                          k8sClient,
+			kubeConfig:                         kubeConfig,
+			caCertController:                   caCertController,
+			npRecommendationQuerier:            npRecommendationQuerier,
+			clickHouseStatQuerier:              clickHouseStatQuerier,
+			throughputAnomalyDetectorQuerier:   throughputAnomalyDetectorQuerier,
+			anomalySuppressionQuerier:          anomalySuppressionQuerier,
+			npRecommendationValidator:          npRecommendationValidator,
+			throughputAnomalyDetectorValidator: throughputAnomalyDetectorValidator,
 		},
 	}
 }
@@ -132,17 +144,30 @@ func installAPIGroup(s *TheiaManagerAPIServer, c Config) error {
 	npRecommendationStorage := networkpolicyrecommendation.NewREST(s.NPRecommendationQuerier)
 	clickhouseStatusStorage := clickhouseStatus.NewREST(s.ClickHouseStatusQuerier)
 	throughputAnomalyDetectorStorage := throughputanomalydetector.NewREST(s.ThroughputAnomalyDetectorQuerier)
+	anomalySuppressionStorage := anomalysuppression.NewREST(s.AnomalySuppressionQuerier)
 
 	intelligenceGroup := genericapiserver.NewDefaultAPIGroupInfo(intelligence.GroupName, scheme, parameterCodec, Codecs)
 	v1alpha1Storage := map[string]rest.Storage{}
 	v1alpha1Storage["networkpolicyrecommendations"] = npRecommendationStorage
+	v1alpha1Storage["networkpolicyrecommendations/explain"] = networkpolicyrecommendation.NewExplainREST(npRecommendationStorage)
+	v1alpha1Storage["networkpolicyrecommendations/log"] = networkpolicyrecommendation.NewLogREST(npRecommendationStorage, c.extraConfig.k8sClient)
 	v1alpha1Storage["throughputanomalydetectors"] = throughputAnomalyDetectorStorage
+	v1alpha1Storage["throughputanomalydetectors/contributors"] = throughputanomalydetector.NewContributorsREST(throughputAnomalyDetectorStorage)
+	v1alpha1Storage["throughputanomalydetectors/log"] = throughputanomalydetector.NewLogREST(throughputAnomalyDetectorStorage, c.extraConfig.k8sClient)
+	v1alpha1Storage["anomalysuppressions"] = anomalySuppressionStorage
 	intelligenceGroup.VersionedResourcesStorageMap["v1alpha1"] = v1alpha1Storage
+	v1alpha2Storage := map[string]rest.Storage{}
+	v1alpha2Storage["networkpolicyrecommendations"] = networkpolicyrecommendation.NewV1alpha2REST(npRecommendationStorage)
+	v1alpha2Storage["throughputanomalydetectors"] = throughputanomalydetector.NewV1alpha2REST(throughputAnomalyDetectorStorage)
+	intelligenceGroup.VersionedResourcesStorageMap["v1alpha2"] = v1alpha2Storage
 
 	statsGroup := genericapiserver.NewDefaultAPIGroupInfo(apistats.GroupName, scheme, parameterCodec, Codecs)
 	statsStorage := map[string]rest.Storage{}
 	statsStorage["clickhouse"] = clickhouseStatusStorage
 	statsGroup.VersionedResourcesStorageMap["v1alpha1"] = statsStorage
+	statsV1alpha2Storage := map[string]rest.Storage{}
+	statsV1alpha2Storage["clickhouse"] = clickhouseStatus.NewV1alpha2REST(clickhouseStatusStorage)
+	statsGroup.VersionedResourcesStorageMap["v1alpha2"] = statsV1alpha2Storage
 
 	systemGroup := genericapiserver.NewDefaultAPIGroupInfo(system.GroupName, scheme, parameterCodec, Codecs)
 	systemStorage := map[string]rest.Storage{}
@@ -161,6 +186,14 @@ func installAPIGroup(s *TheiaManagerAPIServer, c Config) error {
 	return nil
 }
 
+func installHandlers(c Config, s *genericapiserver.GenericAPIServer) {
+	// Webhooks for the Theia CRDs, which are called by the kube-apiserver.
+	s.Handler.NonGoRestfulMux.HandleFunc("/mutate/networkpolicyrecommendation", webhook.HandleMutationNetworkPolicyRecommendation())
+	s.Handler.NonGoRestfulMux.HandleFunc("/validate/networkpolicyrecommendation", webhook.HandleValidationNetworkPolicyRecommendation(c.extraConfig.npRecommendationValidator))
+	s.Handler.NonGoRestfulMux.HandleFunc("/mutate/throughputanomalydetector", webhook.HandleMutationThroughputAnomalyDetector())
+	s.Handler.NonGoRestfulMux.HandleFunc("/validate/throughputanomalydetector", webhook.HandleValidationThroughputAnomalyDetector(c.extraConfig.throughputAnomalyDetectorValidator))
+}
+
 func (c Config) New() (*TheiaManagerAPIServer, error) {
 	completedServerCfg := c.genericConfig.Complete(nil)
 	s, err := completedServerCfg.New(Name, genericapiserver.NewEmptyDelegate())
@@ -173,10 +206,12 @@ func (c Config) New() (*TheiaManagerAPIServer, error) {
 		NPRecommendationQuerier:          c.extraConfig.npRecommendationQuerier,
 		ClickHouseStatusQuerier:          c.extraConfig.clickHouseStatQuerier,
 		ThroughputAnomalyDetectorQuerier: c.extraConfig.throughputAnomalyDetectorQuerier,
+		AnomalySuppressionQuerier:        c.extraConfig.anomalySuppressionQuerier,
 	}
 	if err := installAPIGroup(apiServer, c); err != nil {
 		return nil, err
 	}
+	installHandlers(c, s)
 	return apiServer, nil
 }
 
@@ -189,5 +224,11 @@ func DefaultCAConfig() *certificate.CAConfig {
 		MaxRotateDuration: time.Hour * (24 * 365),
 		ServiceName:       certificate.TheiaServiceName,
 		PairName:          Name,
+		MutationWebhookSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": "theia"},
+		},
+		ValidatingWebhookSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app": "theia"},
+		},
 	}
 }
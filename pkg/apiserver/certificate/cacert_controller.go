@@ -15,6 +15,7 @@
 package certificate
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"sync"
@@ -102,6 +103,14 @@ func (c *CACertController) syncCACert() error {
 		return err
 	}
 
+	if err := c.syncMutatingWebhooks(caCert); err != nil {
+		return err
+	}
+
+	if err := c.syncValidatingWebhooks(caCert); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -145,6 +154,72 @@ func (c *CACertController) syncConfigMap(caCert []byte) error {
 	return nil
 }
 
+// syncMutatingWebhooks updates the CABundle of the MutatingWebhookConfigurations
+// served by theia manager.
+func (c *CACertController) syncMutatingWebhooks(caCert []byte) error {
+	if c.caConfig.MutationWebhookSelector == nil {
+		return nil
+	}
+	klog.InfoS("Syncing CA certificate with MutatingWebhookConfigurations")
+	selector, err := metav1.LabelSelectorAsSelector(c.caConfig.MutationWebhookSelector)
+	if err != nil {
+		return fmt.Errorf("invalid mutating webhook selector: %v", err)
+	}
+	mWebhooks, err := c.client.AdmissionregistrationV1().MutatingWebhookConfigurations().List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return fmt.Errorf("error listing MutatingWebhookConfigurations: %v", err)
+	}
+	for i := range mWebhooks.Items {
+		mWebhook := &mWebhooks.Items[i]
+		updated := false
+		for j := range mWebhook.Webhooks {
+			if !bytes.Equal(mWebhook.Webhooks[j].ClientConfig.CABundle, caCert) {
+				mWebhook.Webhooks[j].ClientConfig.CABundle = caCert
+				updated = true
+			}
+		}
+		if updated {
+			if _, err := c.client.AdmissionregistrationV1().MutatingWebhookConfigurations().Update(context.TODO(), mWebhook, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("error updating MutatingWebhookConfiguration %s: %v", mWebhook.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// syncValidatingWebhooks updates the CABundle of the ValidatingWebhookConfigurations
+// served by theia manager.
+func (c *CACertController) syncValidatingWebhooks(caCert []byte) error {
+	if c.caConfig.ValidatingWebhookSelector == nil {
+		return nil
+	}
+	klog.InfoS("Syncing CA certificate with ValidatingWebhookConfigurations")
+	selector, err := metav1.LabelSelectorAsSelector(c.caConfig.ValidatingWebhookSelector)
+	if err != nil {
+		return fmt.Errorf("invalid validating webhook selector: %v", err)
+	}
+	vWebhooks, err := c.client.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return fmt.Errorf("error listing ValidatingWebhookConfigurations: %v", err)
+	}
+	for i := range vWebhooks.Items {
+		vWebhook := &vWebhooks.Items[i]
+		updated := false
+		for j := range vWebhook.Webhooks {
+			if !bytes.Equal(vWebhook.Webhooks[j].ClientConfig.CABundle, caCert) {
+				vWebhook.Webhooks[j].ClientConfig.CABundle = caCert
+				updated = true
+			}
+		}
+		if updated {
+			if _, err := c.client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Update(context.TODO(), vWebhook, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("error updating ValidatingWebhookConfiguration %s: %v", vWebhook.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
 // RunOnce runs a single sync step to ensure that we have a valid starting configuration.
 func (c *CACertController) RunOnce(ctx context.Context) error {
 	if controller, ok := c.caContentProvider.(dynamiccertificates.ControllerRunner); ok {
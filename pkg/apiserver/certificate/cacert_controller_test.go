@@ -0,0 +1,66 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certificate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admregv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/server/dynamiccertificates"
+	fakeclientset "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncCACertWebhooks(t *testing.T) {
+	theiaLabels := map[string]string{"app": "theia"}
+	mWebhook := &admregv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "theia-mutate", Labels: theiaLabels},
+		Webhooks:   []admregv1.MutatingWebhook{{Name: "m1.theia.antrea.io"}, {Name: "m2.theia.antrea.io"}},
+	}
+	vWebhook := &admregv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "theia-validate", Labels: theiaLabels},
+		Webhooks:   []admregv1.ValidatingWebhook{{Name: "v1.theia.antrea.io"}},
+	}
+	otherWebhook := &admregv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "other-validate"},
+		Webhooks:   []admregv1.ValidatingWebhook{{Name: "v.other.io"}},
+	}
+	clientset := fakeclientset.NewSimpleClientset(mWebhook, vWebhook, otherWebhook)
+	caContentProvider, err := dynamiccertificates.NewStaticCAContent("test", []byte(fakeCACert))
+	require.NoError(t, err)
+	selector := &metav1.LabelSelector{MatchLabels: theiaLabels}
+	c := newCACertController(caContentProvider, clientset, &CAConfig{
+		CAConfigMapName:           TheiaCAConfigMapName,
+		MutationWebhookSelector:   selector,
+		ValidatingWebhookSelector: selector,
+	})
+	require.NoError(t, c.syncCACert())
+
+	caCert := caContentProvider.CurrentCABundleContent()
+	gotMWebhook, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(context.TODO(), "theia-mutate", metav1.GetOptions{})
+	require.NoError(t, err)
+	for _, webhook := range gotMWebhook.Webhooks {
+		assert.Equal(t, caCert, webhook.ClientConfig.CABundle)
+	}
+	gotVWebhook, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(context.TODO(), "theia-validate", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, caCert, gotVWebhook.Webhooks[0].ClientConfig.CABundle)
+	gotOtherWebhook, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(context.TODO(), "other-validate", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, gotOtherWebhook.Webhooks[0].ClientConfig.CABundle)
+}
@@ -14,7 +14,11 @@
 
 package certificate
 
-import "time"
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 const (
 	TheiaCAConfigMapName = "theia-ca"
@@ -42,4 +46,10 @@ type CAConfig struct {
 	MaxRotateDuration time.Duration
 	ServiceName       string
 	PairName          string
+
+	// MutationWebhookSelector and ValidatingWebhookSelector select the
+	// webhook configurations served by theia manager, whose CA bundle is
+	// kept in sync with the CA certificate.
+	MutationWebhookSelector   *metav1.LabelSelector
+	ValidatingWebhookSelector *metav1.LabelSelector
 }
@@ -0,0 +1,168 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomalysuppression
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+	"antrea.io/theia/pkg/querier"
+	"antrea.io/theia/pkg/util/anomaly"
+)
+
+// REST implements rest.Storage for AnomalySuppression.
+type REST struct {
+	anomalySuppressionQuerier querier.AnomalySuppressionQuerier
+}
+
+var (
+	_ rest.Scoper          = &REST{}
+	_ rest.Getter          = &REST{}
+	_ rest.Lister          = &REST{}
+	_ rest.Creater         = &REST{}
+	_ rest.GracefulDeleter = &REST{}
+)
+
+const (
+	defaultNameSpace = "flow-visibility"
+)
+
+// NewREST returns a REST object that will work against API services.
+func NewREST(asq querier.AnomalySuppressionQuerier) *REST {
+	return &REST{anomalySuppressionQuerier: asq}
+}
+
+func (r *REST) New() runtime.Object {
+	return &intelligence.AnomalySuppression{}
+}
+
+func (r *REST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	suppression, err := r.anomalySuppressionQuerier.GetAnomalySuppression(defaultNameSpace, name)
+	if err != nil {
+		return nil, errors.NewNotFound(intelligence.Resource("anomalysuppressions"), name)
+	}
+	intelliSuppression := new(intelligence.AnomalySuppression)
+	copyAnomalySuppression(intelliSuppression, suppression)
+	return intelliSuppression, nil
+}
+
+func (r *REST) NewList() runtime.Object {
+	return &intelligence.AnomalySuppressionList{}
+}
+
+func (r *REST) Destroy() {
+}
+
+func (r *REST) List(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+	suppressions, err := r.anomalySuppressionQuerier.ListAnomalySuppression(defaultNameSpace)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("error when getting AnomalySuppressionsList: %v", err))
+	}
+	items := make([]intelligence.AnomalySuppression, 0, len(suppressions))
+	for _, suppression := range suppressions {
+		intelliSuppression := new(intelligence.AnomalySuppression)
+		copyAnomalySuppression(intelliSuppression, suppression)
+		items = append(items, *intelliSuppression)
+	}
+	list := &intelligence.AnomalySuppressionList{Items: items}
+	return list, nil
+}
+
+func (r *REST) NamespaceScoped() bool {
+	return false
+}
+
+func (r *REST) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	return rest.NewDefaultTableConvertor(intelligence.Resource("anomalysuppressions")).ConvertToTable(ctx, obj, tableOptions)
+}
+
+func (r *REST) Create(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	intelliSuppression, ok := obj.(*intelligence.AnomalySuppression)
+	if !ok {
+		return nil, errors.NewBadRequest(fmt.Sprintf("not an AnomalySuppression object: %T", obj))
+	}
+	existSuppression, _ := r.anomalySuppressionQuerier.GetAnomalySuppression(defaultNameSpace, intelliSuppression.Name)
+	if existSuppression != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("AnomalySuppression exists, name: %s", intelliSuppression.Name))
+	}
+	suppression := new(crdv1alpha1.AnomalySuppression)
+	suppression.Name = intelliSuppression.Name
+	suppression.Spec.AggType = intelliSuppression.AggType
+	suppression.Spec.SourceIP = intelliSuppression.SourceIP
+	suppression.Spec.DestinationIP = intelliSuppression.DestinationIP
+	suppression.Spec.PodNamespace = intelliSuppression.PodNamespace
+	suppression.Spec.PodLabels = intelliSuppression.PodLabels
+	suppression.Spec.PodName = intelliSuppression.PodName
+	suppression.Spec.Direction = intelliSuppression.Direction
+	suppression.Spec.DestinationServicePortName = intelliSuppression.DestinationServicePortName
+	suppression.Spec.SourceNodeName = intelliSuppression.SourceNodeName
+	suppression.Spec.DestinationNodeName = intelliSuppression.DestinationNodeName
+	suppression.Spec.StartTime = intelliSuppression.StartTime
+	suppression.Spec.EndTime = intelliSuppression.EndTime
+	suppression.Spec.DailyStartTime = intelliSuppression.DailyStartTime
+	suppression.Spec.DailyEndTime = intelliSuppression.DailyEndTime
+	suppression.Spec.ExpirationTime = intelliSuppression.ExpirationTime
+	suppression.Spec.Reason = intelliSuppression.Reason
+	if err := anomaly.ValidateSpec(&suppression.Spec); err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid AnomalySuppression: %v", err))
+	}
+	_, err := r.anomalySuppressionQuerier.CreateAnomalySuppression(defaultNameSpace, suppression)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("error when creating AnomalySuppression CR: %v", err))
+	}
+	return &metav1.Status{Status: metav1.StatusSuccess}, nil
+}
+
+func (r *REST) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+	_, err := r.anomalySuppressionQuerier.GetAnomalySuppression(defaultNameSpace, name)
+	if err != nil {
+		return nil, false, errors.NewBadRequest(fmt.Sprintf("AnomalySuppression doesn't exist, name: %s", name))
+	}
+	err = r.anomalySuppressionQuerier.DeleteAnomalySuppression(defaultNameSpace, name)
+	if err != nil {
+		return nil, false, err
+	}
+	return &metav1.Status{Status: metav1.StatusSuccess}, false, nil
+}
+
+// copyAnomalySuppression is used to copy AnomalySuppression from crd to intelligence
+func copyAnomalySuppression(intelli *intelligence.AnomalySuppression, crd *crdv1alpha1.AnomalySuppression) {
+	intelli.Name = crd.Name
+	intelli.CreationTimestamp = crd.CreationTimestamp
+	intelli.AggType = crd.Spec.AggType
+	intelli.SourceIP = crd.Spec.SourceIP
+	intelli.DestinationIP = crd.Spec.DestinationIP
+	intelli.PodNamespace = crd.Spec.PodNamespace
+	intelli.PodLabels = crd.Spec.PodLabels
+	intelli.PodName = crd.Spec.PodName
+	intelli.Direction = crd.Spec.Direction
+	intelli.DestinationServicePortName = crd.Spec.DestinationServicePortName
+	intelli.SourceNodeName = crd.Spec.SourceNodeName
+	intelli.DestinationNodeName = crd.Spec.DestinationNodeName
+	intelli.StartTime = crd.Spec.StartTime
+	intelli.EndTime = crd.Spec.EndTime
+	intelli.DailyStartTime = crd.Spec.DailyStartTime
+	intelli.DailyEndTime = crd.Spec.DailyEndTime
+	intelli.ExpirationTime = crd.Spec.ExpirationTime
+	intelli.Reason = crd.Spec.Reason
+}
@@ -0,0 +1,222 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomalysuppression
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+)
+
+var expirationTime = v1.NewTime(time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC))
+
+type fakeQuerier struct {
+	created *crdv1alpha1.AnomalySuppression
+}
+
+func TestREST_Get(t *testing.T) {
+	tests := []struct {
+		name            string
+		suppressionName string
+		expectErr       error
+		expectResult    *intelligence.AnomalySuppression
+	}{
+		{
+			name:            "Not Found case",
+			suppressionName: "non-existent-suppression",
+			expectErr:       errors.NewNotFound(intelligence.Resource("anomalysuppressions"), "non-existent-suppression"),
+			expectResult:    nil,
+		},
+		{
+			name:            "Successful Get case",
+			suppressionName: "backup",
+			expectErr:       nil,
+			expectResult: &intelligence.AnomalySuppression{
+				ObjectMeta:     v1.ObjectMeta{Name: "backup"},
+				PodNamespace:   "ns",
+				PodName:        "backup",
+				DailyStartTime: "02:00",
+				DailyEndTime:   "03:00",
+				ExpirationTime: expirationTime,
+				Reason:         "nightly backup",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewREST(&fakeQuerier{})
+			suppression, err := r.Get(context.TODO(), tt.suppressionName, &v1.GetOptions{})
+			assert.Equal(t, tt.expectErr, err)
+			if suppression != nil {
+				assert.Equal(t, tt.expectResult, suppression.(*intelligence.AnomalySuppression))
+			} else {
+				assert.Nil(t, tt.expectResult)
+			}
+		})
+	}
+}
+
+func TestREST_Delete(t *testing.T) {
+	tests := []struct {
+		name            string
+		suppressionName string
+		expectErr       error
+	}{
+		{
+			name:            "Job doesn't exist case",
+			suppressionName: "non-existent-suppression",
+			expectErr:       errors.NewBadRequest("AnomalySuppression doesn't exist, name: non-existent-suppression"),
+		},
+		{
+			name:            "Successful Delete case",
+			suppressionName: "backup",
+			expectErr:       nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewREST(&fakeQuerier{})
+			_, _, err := r.Delete(context.TODO(), tt.suppressionName, nil, &v1.DeleteOptions{})
+			assert.Equal(t, tt.expectErr, err)
+		})
+	}
+}
+
+func TestREST_Create(t *testing.T) {
+	tests := []struct {
+		name          string
+		obj           runtime.Object
+		expectErr     error
+		expectResult  runtime.Object
+		expectCreated *crdv1alpha1.AnomalySuppression
+	}{
+		{
+			name:         "Wrong object case",
+			obj:          &intelligence.ThroughputAnomalyDetector{},
+			expectErr:    errors.NewBadRequest(fmt.Sprintf("not an AnomalySuppression object: %T", &intelligence.ThroughputAnomalyDetector{})),
+			expectResult: nil,
+		},
+		{
+			name: "Suppression exists case",
+			obj: &intelligence.AnomalySuppression{
+				ObjectMeta: v1.ObjectMeta{Name: "backup"},
+			},
+			expectErr:    errors.NewBadRequest("AnomalySuppression exists, name: backup"),
+			expectResult: nil,
+		},
+		{
+			name: "Invalid suppression case",
+			obj: &intelligence.AnomalySuppression{
+				ObjectMeta:     v1.ObjectMeta{Name: "non-existent-suppression"},
+				PodName:        "backup",
+				DailyStartTime: "02:00",
+			},
+			expectErr:    errors.NewBadRequest("invalid AnomalySuppression: dailyStartTime and dailyEndTime should be specified together"),
+			expectResult: nil,
+		},
+		{
+			name: "Successful Create case",
+			obj: &intelligence.AnomalySuppression{
+				ObjectMeta:     v1.ObjectMeta{Name: "non-existent-suppression"},
+				AggType:        "pod",
+				PodNamespace:   "ns",
+				PodName:        "backup",
+				DailyStartTime: "02:00",
+				DailyEndTime:   "03:00",
+				ExpirationTime: expirationTime,
+				Reason:         "nightly backup",
+			},
+			expectErr:    nil,
+			expectResult: &v1.Status{Status: v1.StatusSuccess},
+			expectCreated: &crdv1alpha1.AnomalySuppression{
+				ObjectMeta: v1.ObjectMeta{Name: "non-existent-suppression"},
+				Spec: crdv1alpha1.AnomalySuppressionSpec{
+					AggType:        "pod",
+					PodNamespace:   "ns",
+					PodName:        "backup",
+					DailyStartTime: "02:00",
+					DailyEndTime:   "03:00",
+					ExpirationTime: expirationTime,
+					Reason:         "nightly backup",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			querier := &fakeQuerier{}
+			r := NewREST(querier)
+			result, err := r.Create(context.TODO(), tt.obj, nil, &v1.CreateOptions{})
+			assert.Equal(t, tt.expectErr, err)
+			assert.Equal(t, tt.expectResult, result)
+			assert.Equal(t, tt.expectCreated, querier.created)
+		})
+	}
+}
+
+func TestREST_List(t *testing.T) {
+	r := NewREST(&fakeQuerier{})
+	itemList, err := r.List(context.TODO(), &internalversion.ListOptions{})
+	assert.NoError(t, err)
+	suppressionList, ok := itemList.(*intelligence.AnomalySuppressionList)
+	assert.True(t, ok)
+	assert.ElementsMatch(t, []intelligence.AnomalySuppression{
+		{ObjectMeta: v1.ObjectMeta{Name: "suppression-1"}, PodName: "backup"},
+		{ObjectMeta: v1.ObjectMeta{Name: "suppression-2"}, SourceNodeName: "node-1"},
+	}, suppressionList.Items)
+}
+
+func (c *fakeQuerier) GetAnomalySuppression(namespace, name string) (*crdv1alpha1.AnomalySuppression, error) {
+	if name == "non-existent-suppression" {
+		return nil, fmt.Errorf("not found")
+	}
+	return &crdv1alpha1.AnomalySuppression{
+		ObjectMeta: v1.ObjectMeta{Name: name},
+		Spec: crdv1alpha1.AnomalySuppressionSpec{
+			PodNamespace:   "ns",
+			PodName:        "backup",
+			DailyStartTime: "02:00",
+			DailyEndTime:   "03:00",
+			ExpirationTime: expirationTime,
+			Reason:         "nightly backup",
+		},
+	}, nil
+}
+
+func (c *fakeQuerier) CreateAnomalySuppression(namespace string, anomalySuppression *crdv1alpha1.AnomalySuppression) (*crdv1alpha1.AnomalySuppression, error) {
+	c.created = anomalySuppression
+	return anomalySuppression, nil
+}
+
+func (c *fakeQuerier) DeleteAnomalySuppression(namespace, name string) error {
+	return nil
+}
+
+func (c *fakeQuerier) ListAnomalySuppression(namespace string) ([]*crdv1alpha1.AnomalySuppression, error) {
+	return []*crdv1alpha1.AnomalySuppression{
+		{ObjectMeta: v1.ObjectMeta{Name: "suppression-1"}, Spec: crdv1alpha1.AnomalySuppressionSpec{PodName: "backup"}},
+		{ObjectMeta: v1.ObjectMeta{Name: "suppression-2"}, Spec: crdv1alpha1.AnomalySuppressionSpec{SourceNodeName: "node-1"}},
+	}, nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package joblog
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/registry/rest"
+	"k8s.io/client-go/kubernetes"
+
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+)
+
+const (
+	// Labels set by the Spark Operator and Spark on the Pods of a Spark
+	// Application.
+	sparkAppNameLabel = "sparkoperator.k8s.io/app-name"
+	sparkRoleLabel    = "spark-role"
+	sparkExecIDLabel  = "spark-exec-id"
+
+	sparkDriverContainer   = "spark-kubernetes-driver"
+	sparkExecutorContainer = "spark-kubernetes-executor"
+)
+
+var (
+	_ rest.Storage           = &REST{}
+	_ rest.GetterWithOptions = &REST{}
+	_ rest.StorageMetadata   = &REST{}
+)
+
+// JobGetter returns the Namespace of the job with the given name and the ID
+// of its Spark Application, which is empty until the job is started.
+type JobGetter func(name string) (namespace string, sparkApplication string, err error)
+
+// REST implements rest.Storage for the log subresource of the analytics jobs.
+// It streams the container logs of the driver or of an executor of the Spark
+// Application running the job.
+type REST struct {
+	kubeClient kubernetes.Interface
+	resource   schema.GroupResource
+	getJob     JobGetter
+}
+
+// NewREST returns a REST object serving the log subresource of resource,
+// whose jobs are looked up with getJob.
+func NewREST(kubeClient kubernetes.Interface, resource schema.GroupResource, getJob JobGetter) *REST {
+	return &REST{
+		kubeClient: kubeClient,
+		resource:   resource,
+		getJob:     getJob,
+	}
+}
+
+func (r *REST) New() runtime.Object {
+	return &intelligence.JobLogOptions{}
+}
+
+func (r *REST) Destroy() {
+}
+
+func (r *REST) NewGetOptions() (runtime.Object, bool, string) {
+	return &intelligence.JobLogOptions{}, false, ""
+}
+
+func (r *REST) ProducesMIMETypes(_ string) []string {
+	return []string{"text/plain"}
+}
+
+func (r *REST) ProducesObject(_ string) interface{} {
+	return ""
+}
+
+func (r *REST) Get(ctx context.Context, name string, opts runtime.Object) (runtime.Object, error) {
+	logOptions, ok := opts.(*intelligence.JobLogOptions)
+	if !ok {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid options object: %#v", opts))
+	}
+	if logOptions.Executor < 0 {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid executor ID %d, it should be an integer >= 0", logOptions.Executor))
+	}
+	namespace, sparkApplication, err := r.getJob(name)
+	if err != nil {
+		return nil, errors.NewNotFound(r.resource, name)
+	}
+	if sparkApplication == "" {
+		return nil, errors.NewBadRequest(fmt.Sprintf("job %s has not started its Spark Application yet", name))
+	}
+	selector := labels.Set{sparkAppNameLabel: name, sparkRoleLabel: "driver"}
+	container := sparkDriverContainer
+	role := "driver"
+	if logOptions.Executor > 0 {
+		selector = labels.Set{sparkAppNameLabel: name, sparkRoleLabel: "executor", sparkExecIDLabel: fmt.Sprint(logOptions.Executor)}
+		container = sparkExecutorContainer
+		role = fmt.Sprintf("executor %d", logOptions.Executor)
+	}
+	pods, err := r.kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, errors.NewInternalError(fmt.Errorf("failed to list the Pods of job %s: %v", name, err))
+	}
+	if len(pods.Items) == 0 {
+		return nil, errors.NewBadRequest(fmt.Sprintf("no Pod found for the %s of job %s, its Spark Application may have been removed", role, name))
+	}
+	// A Spark Application may be retried; the latest Pod is the relevant one.
+	pod := pods.Items[0]
+	for _, p := range pods.Items[1:] {
+		if pod.CreationTimestamp.Before(&p.CreationTimestamp) {
+			pod = p
+		}
+	}
+	return &logStream{
+		kubeClient: r.kubeClient,
+		namespace:  namespace,
+		pod:        pod.Name,
+		options: &corev1.PodLogOptions{
+			Container: container,
+			Follow:    logOptions.Follow,
+		},
+	}, nil
+}
+
+var (
+	_ rest.ResourceStreamer = &logStream{}
+	_ runtime.Object        = &logStream{}
+)
+
+// logStream streams the logs of a container of a Spark Pod.
+type logStream struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	pod        string
+	options    *corev1.PodLogOptions
+}
+
+func (s *logStream) GetObjectKind() schema.ObjectKind {
+	return schema.EmptyObjectKind
+}
+
+func (s *logStream) DeepCopyObject() runtime.Object {
+	panic("logStream does not have DeepCopyObject")
+}
+
+func (s *logStream) InputStream(ctx context.Context, _, _ string) (stream io.ReadCloser, flush bool, mimeType string, err error) {
+	// stream will be closed by invoker, no need to close in this function.
+	stream, err = s.kubeClient.CoreV1().Pods(s.namespace).GetLogs(s.pod, s.options).Stream(ctx)
+	if err != nil {
+		return nil, false, "", fmt.Errorf("failed to get the logs of Pod %s: %v", s.pod, err)
+	}
+	return stream, s.options.Follow, "text/plain", nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package joblog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+)
+
+const (
+	testNamespace = "flow-visibility"
+	testJobName   = "pr-e292395c-3de1-11ed-b878-0242ac120002"
+)
+
+func newSparkPod(name string, labels map[string]string, created time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         testNamespace,
+			Labels:            labels,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+	}
+}
+
+func TestREST(t *testing.T) {
+	now := time.Now()
+	driverLabels := map[string]string{sparkAppNameLabel: testJobName, sparkRoleLabel: "driver"}
+	executorLabels := map[string]string{sparkAppNameLabel: testJobName, sparkRoleLabel: "executor", sparkExecIDLabel: "2"}
+	kubeClient := fake.NewSimpleClientset(
+		newSparkPod(testJobName+"-driver-old", driverLabels, now.Add(-time.Hour)),
+		newSparkPod(testJobName+"-driver", driverLabels, now),
+		newSparkPod(testJobName+"-exec-2", executorLabels, now),
+	)
+	jobs := map[string]string{
+		testJobName: "e292395c-3de1-11ed-b878-0242ac120002",
+		"pr-e292395c-3de1-11ed-b878-0242ac120003": "",
+	}
+	r := NewREST(kubeClient, intelligence.Resource("networkpolicyrecommendations"), func(name string) (string, string, error) {
+		sparkApplication, ok := jobs[name]
+		if !ok {
+			return "", "", fmt.Errorf("job %s not found", name)
+		}
+		return testNamespace, sparkApplication, nil
+	})
+
+	testCases := []struct {
+		name              string
+		jobName           string
+		options           *intelligence.JobLogOptions
+		expectedPod       string
+		expectedContainer string
+		expectedErr       func(error) bool
+		expectedErrorMsg  string
+	}{
+		{
+			name:              "driver logs of the latest driver Pod",
+			jobName:           testJobName,
+			options:           &intelligence.JobLogOptions{},
+			expectedPod:       testJobName + "-driver",
+			expectedContainer: sparkDriverContainer,
+		},
+		{
+			name:              "executor logs",
+			jobName:           testJobName,
+			options:           &intelligence.JobLogOptions{Executor: 2, Follow: true},
+			expectedPod:       testJobName + "-exec-2",
+			expectedContainer: sparkExecutorContainer,
+		},
+		{
+			name:             "executor Pod not found",
+			jobName:          testJobName,
+			options:          &intelligence.JobLogOptions{Executor: 3},
+			expectedErr:      errors.IsBadRequest,
+			expectedErrorMsg: "no Pod found for the executor 3 of job " + testJobName,
+		},
+		{
+			name:             "invalid executor",
+			jobName:          testJobName,
+			options:          &intelligence.JobLogOptions{Executor: -1},
+			expectedErr:      errors.IsBadRequest,
+			expectedErrorMsg: "invalid executor ID -1",
+		},
+		{
+			name:             "job not started",
+			jobName:          "pr-e292395c-3de1-11ed-b878-0242ac120003",
+			options:          &intelligence.JobLogOptions{},
+			expectedErr:      errors.IsBadRequest,
+			expectedErrorMsg: "has not started its Spark Application yet",
+		},
+		{
+			name:        "job not found",
+			jobName:     "pr-e292395c-3de1-11ed-b878-0242ac120004",
+			options:     &intelligence.JobLogOptions{},
+			expectedErr: errors.IsNotFound,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj, err := r.Get(context.TODO(), tc.jobName, tc.options)
+			if tc.expectedErr != nil {
+				require.Error(t, err)
+				assert.True(t, tc.expectedErr(err))
+				assert.Contains(t, err.Error(), tc.expectedErrorMsg)
+				return
+			}
+			require.NoError(t, err)
+			stream, ok := obj.(*logStream)
+			require.True(t, ok)
+			assert.Equal(t, tc.expectedPod, stream.pod)
+			assert.Equal(t, tc.expectedContainer, stream.options.Container)
+			reader, flush, mimeType, err := stream.InputStream(context.TODO(), "", "")
+			require.NoError(t, err)
+			defer reader.Close()
+			assert.Equal(t, tc.options.Follow, flush)
+			assert.Equal(t, "text/plain", mimeType)
+			logs, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			// The fake clientset returns the same logs for every Pod.
+			assert.Equal(t, "fake logs", string(logs))
+		})
+	}
+}
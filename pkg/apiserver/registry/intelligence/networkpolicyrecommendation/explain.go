@@ -0,0 +1,394 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicyrecommendation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+	policyutil "antrea.io/theia/pkg/util/policy"
+)
+
+const (
+	// Maximum number of sample flows returned for each recommended rule.
+	explainSampleFlowLimit = 5
+
+	explainStatsQuery = `
+	SELECT
+		COUNT(*),
+		MIN(flowStartSeconds),
+		MAX(flowEndSeconds),
+		SUM(octetDeltaCount + reverseOctetDeltaCount)
+	FROM flows WHERE %s;`
+	explainSampleQuery = `
+	SELECT
+		sourcePodNamespace,
+		sourcePodName,
+		sourceIP,
+		destinationPodNamespace,
+		destinationPodName,
+		destinationIP,
+		destinationServicePortName,
+		destinationTransportPort,
+		protocolIdentifier,
+		COUNT(*) AS flowCount
+	FROM flows WHERE %s
+	GROUP BY
+		sourcePodNamespace,
+		sourcePodName,
+		sourceIP,
+		destinationPodNamespace,
+		destinationPodName,
+		destinationIP,
+		destinationServicePortName,
+		destinationTransportPort,
+		protocolIdentifier
+	ORDER BY flowCount DESC
+	LIMIT %d;`
+)
+
+var (
+	_ rest.Storage = &ExplainREST{}
+	_ rest.Getter  = &ExplainREST{}
+
+	protocolNumbers = map[string]int{"TCP": 6, "UDP": 17, "SCTP": 132}
+	protocolNames   = map[int]string{6: "TCP", 17: "UDP", 132: "SCTP"}
+)
+
+// ExplainREST implements rest.Storage for the explain subresource of
+// NetworkPolicyRecommendation.
+type ExplainREST struct {
+	npRecommendation *REST
+}
+
+// NewExplainREST returns a REST object serving the explain subresource, which
+// shares the querier and the ClickHouse connection of the given REST.
+func NewExplainREST(r *REST) *ExplainREST {
+	return &ExplainREST{npRecommendation: r}
+}
+
+func (r *ExplainREST) New() runtime.Object {
+	return &intelligence.NetworkPolicyRecommendationExplanation{}
+}
+
+func (r *ExplainREST) Destroy() {
+}
+
+func (r *ExplainREST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	npReco, err := r.npRecommendation.npRecommendationQuerier.GetNetworkPolicyRecommendation(defaultNameSpace, name)
+	if err != nil {
+		return nil, errors.NewNotFound(intelligence.Resource("networkpolicyrecommendations"), name)
+	}
+	if npReco.Status.State != crdv1alpha1.NPRecommendationStateCompleted {
+		return nil, errors.NewBadRequest(fmt.Sprintf("NetworkPolicyRecommendation job %s is not completed, current state: %s", name, npReco.Status.State))
+	}
+	policies, err := r.npRecommendation.getRecommendedPolicies(npReco.Status.SparkApplication)
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	explanation := &intelligence.NetworkPolicyRecommendationExplanation{
+		ObjectMeta:    metav1.ObjectMeta{Name: name},
+		StartInterval: npReco.Spec.StartInterval,
+		EndInterval:   npReco.Spec.EndInterval,
+	}
+	explanation.Policies, err = r.explainPolicies(policies, npReco.Spec.StartInterval, npReco.Spec.EndInterval)
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	return explanation, nil
+}
+
+// flowFilter is a conjunction of conditions on the flows table, together with
+// the arguments bound to their placeholders.
+type flowFilter struct {
+	conditions []string
+	args       []interface{}
+}
+
+func (f *flowFilter) add(condition string, args ...interface{}) {
+	f.conditions = append(f.conditions, condition)
+	f.args = append(f.args, args...)
+}
+
+func (f *flowFilter) and(other flowFilter) {
+	f.conditions = append(f.conditions, other.conditions...)
+	f.args = append(f.args, other.args...)
+}
+
+// anyOf returns a filter matching the flows matched by any of the given
+// filters. An empty filter matches every flow, so does the disjunction.
+func anyOf(filters []flowFilter) flowFilter {
+	var result flowFilter
+	if len(filters) == 0 {
+		return result
+	}
+	var disjuncts []string
+	for _, f := range filters {
+		if len(f.conditions) == 0 {
+			return flowFilter{}
+		}
+		disjuncts = append(disjuncts, f.where())
+		result.args = append(result.args, f.args...)
+	}
+	if len(disjuncts) == 1 {
+		result.conditions = []string{disjuncts[0]}
+	} else {
+		result.conditions = []string{"(" + strings.Join(disjuncts, " OR ") + ")"}
+	}
+	return result
+}
+
+func (f flowFilter) where() string {
+	if len(f.conditions) == 0 {
+		return "1 = 1"
+	}
+	if len(f.conditions) == 1 {
+		return f.conditions[0]
+	}
+	return "(" + strings.Join(f.conditions, " AND ") + ")"
+}
+
+func (r *ExplainREST) explainPolicies(policyYamls []string, startInterval, endInterval metav1.Time) ([]intelligence.PolicyExplanation, error) {
+	var policies []*policyutil.Policy
+	// Services selected by the ClusterGroups recommended for Pod-to-Service
+	// flows, indexed by ClusterGroup name.
+	groupServices := make(map[string]string)
+	for _, policyYaml := range policyYamls {
+		policy, err := policyutil.Parse(policyYaml)
+		if err != nil {
+			return nil, err
+		}
+		if policy.Kind == "ClusterGroup" {
+			if ref := policy.Spec.ServiceReference; ref != nil {
+				groupServices[policy.Metadata.Name] = ref.Namespace + "/" + ref.Name
+			}
+			continue
+		}
+		policies = append(policies, policy)
+	}
+	var timeFilter flowFilter
+	if !startInterval.IsZero() {
+		timeFilter.add("flowStartSeconds >= ?", startInterval.UTC())
+	}
+	if !endInterval.IsZero() {
+		timeFilter.add("flowEndSeconds < ?", endInterval.UTC())
+	}
+	var explanations []intelligence.PolicyExplanation
+	for _, policy := range policies {
+		explanation := intelligence.PolicyExplanation{
+			Kind:      policy.Kind,
+			Name:      policy.Metadata.Name,
+			Namespace: policy.Metadata.Namespace,
+		}
+		appliedTo := policy.Spec.AppliedTo
+		var descriptions []string
+		for _, peer := range appliedTo {
+			descriptions = append(descriptions, policyutil.DescribePeer(peer, policy.Metadata.Namespace, groupServices))
+		}
+		explanation.AppliedTo = strings.Join(descriptions, "; ")
+		for _, direction := range []string{"Ingress", "Egress"} {
+			rules := policy.Spec.Ingress
+			if direction == "Egress" {
+				rules = policy.Spec.Egress
+			}
+			for i, rule := range rules {
+				ruleExplanation, err := r.explainRule(direction, i, rule, appliedTo, policy.Metadata.Namespace, groupServices, timeFilter)
+				if err != nil {
+					return nil, err
+				}
+				explanation.Rules = append(explanation.Rules, ruleExplanation)
+			}
+		}
+		explanations = append(explanations, explanation)
+	}
+	return explanations, nil
+}
+
+func (r *ExplainREST) explainRule(direction string, index int, rule policyutil.Rule, appliedTo []policyutil.Peer, namespace string, groupServices map[string]string, timeFilter flowFilter) (intelligence.RuleExplanation, error) {
+	explanation := intelligence.RuleExplanation{
+		Direction: direction,
+		Index:     index,
+		Action:    rule.Action,
+	}
+	appliedToSide, peerSide := "destination", "source"
+	peers := rule.From
+	if direction == "Egress" {
+		appliedToSide, peerSide = "source", "destination"
+		peers = rule.To
+	}
+	filter := timeFilter
+	var appliedToFilters []flowFilter
+	for _, peer := range appliedTo {
+		peerFilter, err := filterForPeer(appliedToSide, peer, namespace, groupServices)
+		if err != nil {
+			explanation.ErrorMsg = fmt.Sprintf("appliedTo can not be matched against flows: %v", err)
+			return explanation, nil
+		}
+		appliedToFilters = append(appliedToFilters, peerFilter)
+	}
+	filter.and(anyOf(appliedToFilters))
+
+	var peerFilters []flowFilter
+	var peerDescriptions []string
+	for _, peer := range peers {
+		peerFilter, err := filterForPeer(peerSide, peer, namespace, groupServices)
+		if err != nil {
+			explanation.ErrorMsg = fmt.Sprintf("rule peer can not be matched against flows: %v", err)
+			return explanation, nil
+		}
+		peerFilters = append(peerFilters, peerFilter)
+		peerDescriptions = append(peerDescriptions, policyutil.DescribePeer(peer, namespace, groupServices))
+	}
+	for _, svc := range rule.ToServices {
+		var svcFilter flowFilter
+		svcFilter.add("startsWith(destinationServicePortName, ?)", svc.Namespace+"/"+svc.Name+":")
+		peerFilters = append(peerFilters, svcFilter)
+		peerDescriptions = append(peerDescriptions, "service="+svc.Namespace+"/"+svc.Name)
+	}
+	filter.and(anyOf(peerFilters))
+	explanation.Peer = strings.Join(peerDescriptions, "; ")
+
+	var portFilters []flowFilter
+	for _, port := range rule.Ports {
+		var portFilter flowFilter
+		protocol := strings.ToUpper(port.Protocol)
+		if protocol == "" {
+			protocol = "TCP"
+		}
+		protocolNumber, ok := protocolNumbers[protocol]
+		if !ok {
+			explanation.ErrorMsg = fmt.Sprintf("protocol %s can not be matched against flows", port.Protocol)
+			return explanation, nil
+		}
+		portFilter.add("protocolIdentifier = ?", protocolNumber)
+		if port.Port != 0 {
+			portFilter.add("destinationTransportPort = ?", port.Port)
+			explanation.Ports = append(explanation.Ports, fmt.Sprintf("%s/%d", protocol, port.Port))
+		} else {
+			explanation.Ports = append(explanation.Ports, protocol)
+		}
+		portFilters = append(portFilters, portFilter)
+	}
+	filter.and(anyOf(portFilters))
+
+	if err := r.getRuleStats(&explanation, filter); err != nil {
+		return explanation, err
+	}
+	return explanation, nil
+}
+
+func (r *ExplainREST) getRuleStats(explanation *intelligence.RuleExplanation, filter flowFilter) error {
+	connect := r.npRecommendation.clickhouseConnect
+	query := fmt.Sprintf(explainStatsQuery, filter.where())
+	var count, bytes int64
+	var firstSeen, lastSeen time.Time
+	if err := connect.QueryRow(query, filter.args...).Scan(&count, &firstSeen, &lastSeen, &bytes); err != nil {
+		return fmt.Errorf("failed to get flow statistics of recommended rule: %v", err)
+	}
+	explanation.FlowCount = count
+	if count == 0 {
+		return nil
+	}
+	explanation.Bytes = bytes
+	explanation.FirstSeen = metav1.NewTime(firstSeen)
+	explanation.LastSeen = metav1.NewTime(lastSeen)
+
+	query = fmt.Sprintf(explainSampleQuery, filter.where(), explainSampleFlowLimit)
+	rows, err := connect.Query(query, filter.args...)
+	if err != nil {
+		return fmt.Errorf("failed to get sample flows of recommended rule: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sample intelligence.FlowSample
+		var protocolIdentifier int
+		err := rows.Scan(
+			&sample.SourcePodNamespace,
+			&sample.SourcePodName,
+			&sample.SourceIP,
+			&sample.DestinationPodNamespace,
+			&sample.DestinationPodName,
+			&sample.DestinationIP,
+			&sample.DestinationServicePortName,
+			&sample.DestinationTransportPort,
+			&protocolIdentifier,
+			&sample.FlowCount,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan sample flows of recommended rule: %v", err)
+		}
+		sample.Protocol = protocolNames[protocolIdentifier]
+		if sample.Protocol == "" {
+			sample.Protocol = fmt.Sprint(protocolIdentifier)
+		}
+		explanation.SampleFlows = append(explanation.SampleFlows, sample)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get sample flows of recommended rule: %v", err)
+	}
+	return nil
+}
+
+// filterForPeer translates a policy peer into conditions on the source or
+// destination columns of the flows table. Namespaces can only be matched by
+// name, as flow records do not carry Namespace labels.
+func filterForPeer(side string, peer policyutil.Peer, namespace string, groupServices map[string]string) (flowFilter, error) {
+	var filter flowFilter
+	if peer.Group != "" {
+		svc, ok := groupServices[peer.Group]
+		if !ok {
+			return filter, fmt.Errorf("ClusterGroup %s is not part of the recommendation", peer.Group)
+		}
+		filter.add("startsWith(destinationServicePortName, ?)", svc+":")
+		return filter, nil
+	}
+	if peer.IPBlock != nil {
+		filter.add(fmt.Sprintf("isIPAddressInRange(%sIP, ?)", side), peer.IPBlock.CIDR)
+		return filter, nil
+	}
+	if peer.PodSelector == nil && peer.NamespaceSelector == nil {
+		return filter, nil
+	}
+	if (peer.NamespaceSelector != nil && len(peer.NamespaceSelector.MatchExpressions) > 0) ||
+		(peer.PodSelector != nil && len(peer.PodSelector.MatchExpressions) > 0) {
+		return filter, fmt.Errorf("matchExpressions are not supported")
+	}
+	filter.add(fmt.Sprintf("%sPodName != ''", side))
+	if peer.NamespaceSelector != nil {
+		for _, key := range policyutil.SortedKeys(peer.NamespaceSelector.MatchLabels) {
+			if key != "kubernetes.io/metadata.name" && key != "name" {
+				return filter, fmt.Errorf("unsupported Namespace label %s", key)
+			}
+			filter.add(fmt.Sprintf("%sPodNamespace = ?", side), peer.NamespaceSelector.MatchLabels[key])
+		}
+	} else if namespace != "" {
+		filter.add(fmt.Sprintf("%sPodNamespace = ?", side), namespace)
+	}
+	if peer.PodSelector != nil {
+		for _, key := range policyutil.SortedKeys(peer.PodSelector.MatchLabels) {
+			filter.add(fmt.Sprintf("JSONExtractString(%sPodLabels, ?) = ?", side), key, peer.PodSelector.MatchLabels[key])
+		}
+	}
+	return filter, nil
+}
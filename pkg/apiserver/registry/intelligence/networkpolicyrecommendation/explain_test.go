@@ -0,0 +1,235 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicyrecommendation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+)
+
+var (
+	explainStartInterval = time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	explainEndInterval   = time.Date(2023, 3, 2, 0, 0, 0, 0, time.UTC)
+)
+
+const (
+	explainANP = `apiVersion: crd.antrea.io/v1alpha1
+kind: NetworkPolicy
+metadata:
+  name: recommend-allow-anp-abcde
+  namespace: db
+spec:
+  appliedTo:
+  - podSelector:
+      matchLabels:
+        app: postgres
+  egress: []
+  ingress:
+  - action: Allow
+    from:
+    - namespaceSelector:
+        matchLabels:
+          kubernetes.io/metadata.name: web
+      podSelector:
+        matchLabels:
+          app: foo
+    ports:
+    - port: 5432
+      protocol: TCP
+  priority: 5
+  tier: Application
+`
+	explainClusterGroup = `apiVersion: crd.antrea.io/v1alpha2
+kind: ClusterGroup
+metadata:
+  name: cg-db-postgres
+spec:
+  serviceReference:
+    name: postgres
+    namespace: db
+`
+	explainACNP = `apiVersion: crd.antrea.io/v1alpha1
+kind: ClusterNetworkPolicy
+metadata:
+  name: recommend-svc-allow-acnp-fghij
+spec:
+  appliedTo:
+  - namespaceSelector:
+      matchLabels:
+        kubernetes.io/metadata.name: web
+    podSelector:
+      matchLabels:
+        app: foo
+  egress:
+  - action: Allow
+    ports:
+    - port: 5432
+      protocol: TCP
+    to:
+    - group: cg-db-postgres
+  priority: 5
+  tier: Application
+`
+)
+
+func TestExplainREST_Get(t *testing.T) {
+	firstSeen := time.Date(2023, 3, 1, 8, 0, 0, 0, time.UTC)
+	lastSeen := time.Date(2023, 3, 1, 9, 30, 0, 0, time.UTC)
+	ingressWhere := "(flowStartSeconds >= ? AND flowEndSeconds < ? AND " +
+		"(destinationPodName != '' AND destinationPodNamespace = ? AND JSONExtractString(destinationPodLabels, ?) = ?) AND " +
+		"(sourcePodName != '' AND sourcePodNamespace = ? AND JSONExtractString(sourcePodLabels, ?) = ?) AND " +
+		"(protocolIdentifier = ? AND destinationTransportPort = ?))"
+	egressWhere := "(flowStartSeconds >= ? AND flowEndSeconds < ? AND " +
+		"(sourcePodName != '' AND sourcePodNamespace = ? AND JSONExtractString(sourcePodLabels, ?) = ?) AND " +
+		"startsWith(destinationServicePortName, ?) AND " +
+		"(protocolIdentifier = ? AND destinationTransportPort = ?))"
+
+	tests := []struct {
+		name         string
+		nprName      string
+		expectErr    error
+		expectResult *intelligence.NetworkPolicyRecommendationExplanation
+	}{
+		{
+			name:      "Not Found case",
+			nprName:   "non-existent-npr",
+			expectErr: errors.NewNotFound(intelligence.Resource("networkpolicyrecommendations"), "non-existent-npr"),
+		},
+		{
+			name:      "Not completed case",
+			nprName:   "running-npr",
+			expectErr: errors.NewBadRequest("NetworkPolicyRecommendation job running-npr is not completed, current state: RUNNING"),
+		},
+		{
+			name:    "Successful Explain case",
+			nprName: "explain-npr",
+			expectResult: &intelligence.NetworkPolicyRecommendationExplanation{
+				ObjectMeta:    v1.ObjectMeta{Name: "explain-npr"},
+				StartInterval: v1.NewTime(explainStartInterval),
+				EndInterval:   v1.NewTime(explainEndInterval),
+				Policies: []intelligence.PolicyExplanation{
+					{
+						Kind:      "NetworkPolicy",
+						Name:      "recommend-allow-anp-abcde",
+						Namespace: "db",
+						AppliedTo: "namespace=db,app=postgres",
+						Rules: []intelligence.RuleExplanation{
+							{
+								Direction: "Ingress",
+								Index:     0,
+								Action:    "Allow",
+								Peer:      "namespace=web,app=foo",
+								Ports:     []string{"TCP/5432"},
+								FlowCount: 3,
+								Bytes:     4096,
+								FirstSeen: v1.NewTime(firstSeen),
+								LastSeen:  v1.NewTime(lastSeen),
+								SampleFlows: []intelligence.FlowSample{
+									{
+										SourcePodNamespace:       "web",
+										SourcePodName:            "foo-7c5d8",
+										SourceIP:                 "10.10.1.5",
+										DestinationPodNamespace:  "db",
+										DestinationPodName:       "postgres-0",
+										DestinationIP:            "10.10.2.7",
+										DestinationTransportPort: 5432,
+										Protocol:                 "TCP",
+										FlowCount:                3,
+									},
+								},
+							},
+						},
+					},
+					{
+						Kind:      "ClusterNetworkPolicy",
+						Name:      "recommend-svc-allow-acnp-fghij",
+						AppliedTo: "namespace=web,app=foo",
+						Rules: []intelligence.RuleExplanation{
+							{
+								Direction: "Egress",
+								Index:     0,
+								Action:    "Allow",
+								Peer:      "service=db/postgres",
+								Ports:     []string{"TCP/5432"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "Query error case",
+			nprName:   "explain-npr",
+			expectErr: errors.NewInternalError(fmt.Errorf("failed to get flow statistics of recommended rule: error in database, please retry")),
+		},
+		{
+			name:      "Row error case",
+			nprName:   "explain-npr",
+			expectErr: errors.NewInternalError(fmt.Errorf("failed to get sample flows of recommended rule: connection reset")),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			defer db.Close()
+			mock.ExpectQuery("SELECT policy FROM recommendations WHERE id = (?);").WillReturnRows(
+				sqlmock.NewRows([]string{"policy"}).AddRow(explainANP).AddRow(explainClusterGroup).AddRow(explainACNP))
+			if tt.name == "Query error case" {
+				mock.ExpectQuery(fmt.Sprintf(explainStatsQuery, ingressWhere)).WillReturnError(fmt.Errorf("error in database, please retry"))
+			} else if tt.name == "Row error case" {
+				mock.ExpectQuery(fmt.Sprintf(explainStatsQuery, ingressWhere)).
+					WillReturnRows(sqlmock.NewRows([]string{"count", "firstSeen", "lastSeen", "bytes"}).AddRow(3, firstSeen, lastSeen, 4096))
+				mock.ExpectQuery(fmt.Sprintf(explainSampleQuery, ingressWhere, explainSampleFlowLimit)).
+					WillReturnRows(sqlmock.NewRows([]string{"sourcePodNamespace"}).AddRow("web").RowError(0, fmt.Errorf("connection reset")))
+			} else {
+				mock.ExpectQuery(fmt.Sprintf(explainStatsQuery, ingressWhere)).
+					WithArgs(explainStartInterval, explainEndInterval, "db", "app", "postgres", "web", "app", "foo", 6, 5432).
+					WillReturnRows(sqlmock.NewRows([]string{"count", "firstSeen", "lastSeen", "bytes"}).AddRow(3, firstSeen, lastSeen, 4096))
+				mock.ExpectQuery(fmt.Sprintf(explainSampleQuery, ingressWhere, explainSampleFlowLimit)).
+					WillReturnRows(sqlmock.NewRows([]string{
+						"sourcePodNamespace", "sourcePodName", "sourceIP",
+						"destinationPodNamespace", "destinationPodName", "destinationIP",
+						"destinationServicePortName", "destinationTransportPort", "protocolIdentifier", "flowCount",
+					}).AddRow("web", "foo-7c5d8", "10.10.1.5", "db", "postgres-0", "10.10.2.7", "", 5432, 6, 3))
+				mock.ExpectQuery(fmt.Sprintf(explainStatsQuery, egressWhere)).
+					WithArgs(explainStartInterval, explainEndInterval, "web", "app", "foo", "db/postgres:", 6, 5432).
+					WillReturnRows(sqlmock.NewRows([]string{"count", "firstSeen", "lastSeen", "bytes"}).AddRow(0, time.Unix(0, 0), time.Unix(0, 0), 0))
+			}
+
+			setupClickHouseConnection = func(client kubernetes.Interface) (connect *sql.DB, err error) {
+				return db, nil
+			}
+			r := NewExplainREST(NewREST(&fakeQuerier{}))
+			explanation, err := r.Get(context.TODO(), tt.nprName, &v1.GetOptions{})
+			assert.Equal(t, tt.expectErr, err)
+			if tt.expectResult != nil {
+				assert.Equal(t, tt.expectResult, explanation)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicyrecommendation
+
+import (
+	"k8s.io/client-go/kubernetes"
+
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+	"antrea.io/theia/pkg/apiserver/registry/intelligence/joblog"
+)
+
+// NewLogREST returns a REST object serving the log subresource, which streams
+// the logs of the Spark Pods running a NetworkPolicyRecommendation job.
+func NewLogREST(r *REST, kubeClient kubernetes.Interface) *joblog.REST {
+	return joblog.NewREST(kubeClient, intelligence.Resource("networkpolicyrecommendations"), func(name string) (string, string, error) {
+		npReco, err := r.npRecommendationQuerier.GetNetworkPolicyRecommendation(defaultNameSpace, name)
+		if err != nil {
+			return "", "", err
+		}
+		return npReco.Namespace, npReco.Status.SparkApplication, nil
+	})
+}
@@ -136,13 +136,30 @@ func (r *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 	job.Spec.StartInterval = npReco.StartInterval
 	job.Spec.EndInterval = npReco.EndInterval
 	job.Spec.NSAllowList = npReco.NSAllowList
+	job.Spec.TargetNamespaces = npReco.TargetNamespaces
+	job.Spec.TargetLabels = npReco.TargetLabels
 	job.Spec.ExcludeLabels = npReco.ExcludeLabels
 	job.Spec.ToServices = npReco.ToServices
+	job.Spec.Tier = npReco.Tier
+	job.Spec.BasePriority = npReco.BasePriority
+	job.Spec.PriorityStep = npReco.PriorityStep
 	job.Spec.ExecutorInstances = npReco.ExecutorInstances
 	job.Spec.DriverCoreRequest = npReco.DriverCoreRequest
 	job.Spec.DriverMemory = npReco.DriverMemory
 	job.Spec.ExecutorCoreRequest = npReco.ExecutorCoreRequest
 	job.Spec.ExecutorMemory = npReco.ExecutorMemory
+	job.Spec.ActiveDeadlineSeconds = npReco.ActiveDeadlineSeconds
+	job.Spec.TTLSecondsAfterFinished = npReco.TTLSecondsAfterFinished
+	// A dry run only estimates the input of the job, nothing is created.
+	if len(options.DryRun) > 0 {
+		estimate, err := r.npRecommendationQuerier.EstimateNetworkPolicyRecommendation(job)
+		if err != nil {
+			return nil, errors.NewBadRequest(fmt.Sprintf("error when estimating the input of NetworkPolicyRecommendation job: %v", err))
+		}
+		result := &intelligence.NetworkPolicyRecommendation{ObjectMeta: metav1.ObjectMeta{Name: npReco.Name}}
+		result.Status.InputEstimate = (*intelligence.JobInputEstimate)(estimate)
+		return result, nil
+	}
 	_, err := r.npRecommendationQuerier.CreateNetworkPolicyRecommendation(defaultNameSpace, job)
 	if err != nil {
 		return nil, errors.NewBadRequest(fmt.Sprintf("error when creating NetworkPolicyRecommendation CR: %v", err))
@@ -171,17 +188,26 @@ func (r *REST) copyNetworkPolicyRecommendation(intelli *intelligence.NetworkPoli
 	intelli.StartInterval = crd.Spec.StartInterval
 	intelli.EndInterval = crd.Spec.EndInterval
 	intelli.NSAllowList = crd.Spec.NSAllowList
+	intelli.TargetNamespaces = crd.Spec.TargetNamespaces
+	intelli.TargetLabels = crd.Spec.TargetLabels
 	intelli.ExcludeLabels = crd.Spec.ExcludeLabels
 	intelli.ToServices = crd.Spec.ToServices
+	intelli.Tier = crd.Spec.Tier
+	intelli.BasePriority = crd.Spec.BasePriority
+	intelli.PriorityStep = crd.Spec.PriorityStep
 	intelli.ExecutorInstances = crd.Spec.ExecutorInstances
 	intelli.DriverCoreRequest = crd.Spec.DriverCoreRequest
 	intelli.DriverMemory = crd.Spec.DriverMemory
 	intelli.ExecutorCoreRequest = crd.Spec.ExecutorCoreRequest
 	intelli.ExecutorMemory = crd.Spec.ExecutorMemory
+	intelli.ActiveDeadlineSeconds = crd.Spec.ActiveDeadlineSeconds
+	intelli.TTLSecondsAfterFinished = crd.Spec.TTLSecondsAfterFinished
 	intelli.Status.State = crd.Status.State
 	intelli.Status.SparkApplication = crd.Status.SparkApplication
 	intelli.Status.CompletedStages = crd.Status.CompletedStages
 	intelli.Status.TotalStages = crd.Status.TotalStages
+	intelli.Status.Progress = (*intelligence.JobProgress)(crd.Status.Progress.DeepCopy())
+	intelli.Status.InputEstimate = (*intelligence.JobInputEstimate)(crd.Status.InputEstimate.DeepCopy())
 	intelli.Status.ErrorMsg = crd.Status.ErrorMsg
 	intelli.Status.StartTime = crd.Status.StartTime
 	intelli.Status.EndTime = crd.Status.EndTime
@@ -189,27 +215,36 @@ func (r *REST) copyNetworkPolicyRecommendation(intelli *intelligence.NetworkPoli
 }
 
 func (r *REST) getRecommendationResult(id string) (result string, err error) {
+	policies, err := r.getRecommendedPolicies(id)
+	if err != nil {
+		return result, err
+	}
+	result = strings.Join(policies, "---\n")
+	return result, nil
+}
+
+// getRecommendedPolicies returns the YAML of every policy recommended by the
+// job with the given id.
+func (r *REST) getRecommendedPolicies(id string) (policies []string, err error) {
 	if r.clickhouseConnect == nil {
 		r.clickhouseConnect, err = setupClickHouseConnection(nil)
 		if err != nil {
-			return result, err
+			return nil, err
 		}
 	}
 	query := "SELECT policy FROM recommendations WHERE id = (?);"
 	rows, err := r.clickhouseConnect.Query(query, id)
 	if err != nil {
-		return result, fmt.Errorf("failed to get recommendation results with id %s: %v", id, err)
+		return nil, fmt.Errorf("failed to get recommendation results with id %s: %v", id, err)
 	}
 	defer rows.Close()
-	var policies []string
 	for rows.Next() {
 		var policyYaml string
 		err := rows.Scan(&policyYaml)
 		if err != nil {
-			return result, fmt.Errorf("failed to scan recommendation results: %v", err)
+			return nil, fmt.Errorf("failed to scan recommendation results: %v", err)
 		}
 		policies = append(policies, policyYaml)
 	}
-	result = strings.Join(policies, "---\n")
-	return result, nil
+	return policies, nil
 }
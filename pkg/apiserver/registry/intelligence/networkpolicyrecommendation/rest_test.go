@@ -153,6 +153,7 @@ func TestREST_Create(t *testing.T) {
 	tests := []struct {
 		name         string
 		obj          runtime.Object
+		dryRun       bool
 		expectErr    error
 		expectResult runtime.Object
 	}{
@@ -180,11 +181,30 @@ func TestREST_Create(t *testing.T) {
 			expectErr:    nil,
 			expectResult: &v1.Status{Status: v1.StatusSuccess},
 		},
+		{
+			name: "Dry run case",
+			obj: &intelligence.NetworkPolicyRecommendation{
+				TypeMeta:   v1.TypeMeta{},
+				ObjectMeta: v1.ObjectMeta{Name: "non-existent-npr"},
+			},
+			dryRun:    true,
+			expectErr: nil,
+			expectResult: &intelligence.NetworkPolicyRecommendation{
+				ObjectMeta: v1.ObjectMeta{Name: "non-existent-npr"},
+				Status: intelligence.NetworkPolicyRecommendationStatus{
+					InputEstimate: &intelligence.JobInputEstimate{Rows: 1000, Bytes: 2048},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := NewREST(&fakeQuerier{})
-			result, err := r.Create(context.TODO(), tt.obj, nil, &v1.CreateOptions{})
+			options := &v1.CreateOptions{}
+			if tt.dryRun {
+				options.DryRun = []string{v1.DryRunAll}
+			}
+			result, err := r.Create(context.TODO(), tt.obj, nil, options)
 			assert.Equal(t, err, tt.expectErr)
 			assert.Equal(t, tt.expectResult, result)
 		})
@@ -220,6 +240,27 @@ func (c *fakeQuerier) GetNetworkPolicyRecommendation(namespace, name string) (*c
 	if name == "non-existent-npr" {
 		return nil, fmt.Errorf("not found")
 	}
+	if name == "running-npr" {
+		return &crdv1alpha1.NetworkPolicyRecommendation{
+			Status: crdv1alpha1.NetworkPolicyRecommendationStatus{
+				State: crdv1alpha1.NPRecommendationStateRunning,
+			},
+		}, nil
+	}
+	if name == "explain-npr" {
+		return &crdv1alpha1.NetworkPolicyRecommendation{
+			Spec: crdv1alpha1.NetworkPolicyRecommendationSpec{
+				JobType:       "initial",
+				PolicyType:    "anp-deny-applied",
+				StartInterval: v1.NewTime(explainStartInterval),
+				EndInterval:   v1.NewTime(explainEndInterval),
+			},
+			Status: crdv1alpha1.NetworkPolicyRecommendationStatus{
+				State:            crdv1alpha1.NPRecommendationStateCompleted,
+				SparkApplication: "e8e3d1ff-3b1a-4b6b-8f4b-1a1c1a1c1a1c",
+			},
+		}, nil
+	}
 	return &crdv1alpha1.NetworkPolicyRecommendation{
 		Spec: crdv1alpha1.NetworkPolicyRecommendationSpec{
 			JobType: "NPR", PolicyType: "Allow",
@@ -234,6 +275,10 @@ func (c *fakeQuerier) CreateNetworkPolicyRecommendation(namespace string, networ
 	return nil, nil
 }
 
+func (c *fakeQuerier) EstimateNetworkPolicyRecommendation(job *crdv1alpha1.NetworkPolicyRecommendation) (*crdv1alpha1.JobInputEstimate, error) {
+	return &crdv1alpha1.JobInputEstimate{Rows: 1000, Bytes: 2048}, nil
+}
+
 func (c *fakeQuerier) DeleteNetworkPolicyRecommendation(namespace, name string) error {
 	return nil
 }
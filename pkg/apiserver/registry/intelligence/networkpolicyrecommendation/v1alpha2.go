@@ -0,0 +1,122 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicyrecommendation
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	"antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+	"antrea.io/theia/pkg/apis/intelligence/v1alpha2"
+)
+
+// V1alpha2REST implements rest.Storage for the v1alpha2 version of
+// networkpolicyrecommendation. It delegates to REST and converts the objects
+// between v1alpha1 and v1alpha2.
+type V1alpha2REST struct {
+	rest *REST
+}
+
+var (
+	_ rest.Creater         = &V1alpha2REST{}
+	_ rest.Getter          = &V1alpha2REST{}
+	_ rest.Lister          = &V1alpha2REST{}
+	_ rest.GracefulDeleter = &V1alpha2REST{}
+)
+
+// NewV1alpha2REST returns a V1alpha2REST object that will work against API
+// services.
+func NewV1alpha2REST(r *REST) *V1alpha2REST {
+	return &V1alpha2REST{rest: r}
+}
+
+func (r *V1alpha2REST) New() runtime.Object {
+	return &v1alpha2.NetworkPolicyRecommendation{}
+}
+
+func (r *V1alpha2REST) Destroy() {
+}
+
+func (r *V1alpha2REST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	obj, err := r.rest.Get(ctx, name, options)
+	if err != nil {
+		return nil, err
+	}
+	npr := new(v1alpha2.NetworkPolicyRecommendation)
+	if err := v1alpha2.Convert_v1alpha1_NetworkPolicyRecommendation_To_v1alpha2_NetworkPolicyRecommendation(obj.(*v1alpha1.NetworkPolicyRecommendation), npr, nil); err != nil {
+		return nil, errors.NewInternalError(fmt.Errorf("error when converting NetworkPolicyRecommendation %s: %v", name, err))
+	}
+	return npr, nil
+}
+
+func (r *V1alpha2REST) NewList() runtime.Object {
+	return &v1alpha2.NetworkPolicyRecommendationList{}
+}
+
+func (r *V1alpha2REST) List(ctx context.Context, options *internalversion.ListOptions) (runtime.Object, error) {
+	obj, err := r.rest.List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	list := new(v1alpha2.NetworkPolicyRecommendationList)
+	if err := v1alpha2.Convert_v1alpha1_NetworkPolicyRecommendationList_To_v1alpha2_NetworkPolicyRecommendationList(obj.(*v1alpha1.NetworkPolicyRecommendationList), list, nil); err != nil {
+		return nil, errors.NewInternalError(fmt.Errorf("error when converting NetworkPolicyRecommendationList: %v", err))
+	}
+	return list, nil
+}
+
+func (r *V1alpha2REST) NamespaceScoped() bool {
+	return false
+}
+
+func (r *V1alpha2REST) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	return rest.NewDefaultTableConvertor(v1alpha2.Resource("networkpolicyrecommendations")).ConvertToTable(ctx, obj, tableOptions)
+}
+
+func (r *V1alpha2REST) Create(ctx context.Context, obj runtime.Object, createValidation rest.ValidateObjectFunc, options *metav1.CreateOptions) (runtime.Object, error) {
+	newNPR, ok := obj.(*v1alpha2.NetworkPolicyRecommendation)
+	if !ok {
+		return nil, errors.NewBadRequest(fmt.Sprintf("not a NetworkPolicyRecommendation object: %T", obj))
+	}
+	npr := new(v1alpha1.NetworkPolicyRecommendation)
+	if err := v1alpha2.Convert_v1alpha2_NetworkPolicyRecommendation_To_v1alpha1_NetworkPolicyRecommendation(newNPR, npr, nil); err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("error when converting NetworkPolicyRecommendation %s: %v", newNPR.Name, err))
+	}
+	obj, err := r.rest.Create(ctx, npr, createValidation, options)
+	if err != nil {
+		return nil, err
+	}
+	// A dry run returns the estimated input of the job in a
+	// NetworkPolicyRecommendation, other results are a Status.
+	created, ok := obj.(*v1alpha1.NetworkPolicyRecommendation)
+	if !ok {
+		return obj, nil
+	}
+	result := new(v1alpha2.NetworkPolicyRecommendation)
+	if err := v1alpha2.Convert_v1alpha1_NetworkPolicyRecommendation_To_v1alpha2_NetworkPolicyRecommendation(created, result, nil); err != nil {
+		return nil, errors.NewInternalError(fmt.Errorf("error when converting NetworkPolicyRecommendation %s: %v", created.Name, err))
+	}
+	return result, nil
+}
+
+func (r *V1alpha2REST) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
+	return r.rest.Delete(ctx, name, deleteValidation, options)
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkpolicyrecommendation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	"antrea.io/theia/pkg/apis/intelligence/v1alpha2"
+)
+
+func TestV1alpha2REST_Get(t *testing.T) {
+	r := NewV1alpha2REST(NewREST(&fakeQuerier{}))
+	npr, err := r.Get(context.TODO(), "running-npr", &v1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &v1alpha2.NetworkPolicyRecommendation{
+		Status: v1alpha2.NetworkPolicyRecommendationStatus{
+			State: crdv1alpha1.NPRecommendationStateRunning,
+		},
+	}, npr)
+
+	_, err = r.Get(context.TODO(), "non-existent-npr", &v1.GetOptions{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestV1alpha2REST_Create(t *testing.T) {
+	r := NewV1alpha2REST(NewREST(&fakeQuerier{}))
+	result, err := r.Create(context.TODO(), &v1alpha2.NetworkPolicyRecommendation{
+		ObjectMeta: v1.ObjectMeta{Name: "non-existent-npr"},
+	}, nil, &v1.CreateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &v1.Status{Status: v1.StatusSuccess}, result)
+
+	result, err = r.Create(context.TODO(), &v1alpha2.NetworkPolicyRecommendation{
+		ObjectMeta: v1.ObjectMeta{Name: "non-existent-npr"},
+	}, nil, &v1.CreateOptions{DryRun: []string{v1.DryRunAll}})
+	require.NoError(t, err)
+	assert.Equal(t, &v1alpha2.NetworkPolicyRecommendation{
+		ObjectMeta: v1.ObjectMeta{Name: "non-existent-npr"},
+		Status: v1alpha2.NetworkPolicyRecommendationStatus{
+			InputEstimate: &v1alpha2.JobInputEstimate{Rows: 1000, Bytes: 2048},
+		},
+	}, result)
+
+	_, err = r.Create(context.TODO(), &crdv1alpha1.NetworkPolicyRecommendation{}, nil, &v1.CreateOptions{})
+	assert.ErrorContains(t, err, "not a NetworkPolicyRecommendation object")
+}
+
+func TestV1alpha2REST_List(t *testing.T) {
+	r := NewV1alpha2REST(NewREST(&fakeQuerier{}))
+	itemList, err := r.List(context.TODO(), &internalversion.ListOptions{})
+	require.NoError(t, err)
+	nprList, ok := itemList.(*v1alpha2.NetworkPolicyRecommendationList)
+	require.True(t, ok)
+	assert.ElementsMatch(t, []v1alpha2.NetworkPolicyRecommendation{
+		{ObjectMeta: v1.ObjectMeta{Name: "npr-1"}},
+		{ObjectMeta: v1.ObjectMeta{Name: "npr-2"}},
+	}, nprList.Items)
+}
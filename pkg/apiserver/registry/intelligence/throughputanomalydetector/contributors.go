@@ -0,0 +1,230 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomalydetector
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	"antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+)
+
+const (
+	// Flows ending within contributorsWindow before or after an anomaly are
+	// considered as contributing to it.
+	contributorsWindow = time.Minute
+	// Maximum number of contributing flows returned for each anomaly.
+	contributorsFlowLimit = 5
+	// Maximum number of anomalies for which contributing flows are queried.
+	contributorsAnomalyLimit = 50
+	// Anomaly of the single placeholder stat written by a job which found no
+	// anomaly.
+	noAnomalyDetected = "NO ANOMALY DETECTED"
+
+	contributorsQuery = `
+	SELECT
+		sourcePodNamespace,
+		sourcePodName,
+		sourceIP,
+		destinationPodNamespace,
+		destinationPodName,
+		destinationIP,
+		destinationServicePortName,
+		destinationTransportPort,
+		protocolIdentifier,
+		SUM(octetDeltaCount) AS bytes,
+		SUM(packetDeltaCount) AS packets,
+		COUNT(*) AS flowCount
+	FROM flows WHERE %s
+	GROUP BY
+		sourcePodNamespace,
+		sourcePodName,
+		sourceIP,
+		destinationPodNamespace,
+		destinationPodName,
+		destinationIP,
+		destinationServicePortName,
+		destinationTransportPort,
+		protocolIdentifier
+	ORDER BY bytes DESC
+	LIMIT %d;`
+)
+
+var (
+	_ rest.Storage = &ContributorsREST{}
+	_ rest.Getter  = &ContributorsREST{}
+
+	protocolNames = map[int]string{6: "TCP", 17: "UDP", 132: "SCTP"}
+)
+
+// ContributorsREST implements rest.Storage for the contributors subresource
+// of ThroughputAnomalyDetector.
+type ContributorsREST struct {
+	tad *REST
+}
+
+// NewContributorsREST returns a REST object serving the contributors
+// subresource, which shares the querier and the ClickHouse connection of the
+// given REST.
+func NewContributorsREST(r *REST) *ContributorsREST {
+	return &ContributorsREST{tad: r}
+}
+
+func (r *ContributorsREST) New() runtime.Object {
+	return &v1alpha1.ThroughputAnomalyDetectorContributors{}
+}
+
+func (r *ContributorsREST) Destroy() {
+}
+
+func (r *ContributorsREST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	tad, err := r.tad.ThroughputAnomalyDetectorQuerier.GetThroughputAnomalyDetector(defaultNameSpace, name)
+	if err != nil {
+		return nil, errors.NewNotFound(v1alpha1.Resource("throughputanomalydetectors"), name)
+	}
+	if tad.Status.State != crdv1alpha1.ThroughputAnomalyDetectorStateCompleted {
+		return nil, errors.NewBadRequest(fmt.Sprintf("ThroughputAnomalyDetector job %s is not completed, current state: %s", name, tad.Status.State))
+	}
+	result := new(v1alpha1.ThroughputAnomalyDetector)
+	r.tad.copyThroughputAnomalyDetector(result, tad)
+	if err := r.tad.getTADetectorResult(tad.Status.SparkApplication, result); err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	contributors := &v1alpha1.ThroughputAnomalyDetectorContributors{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+	}
+	for _, stats := range result.Stats {
+		// The normal values saved by the job have no contributors
+		if stats.Anomaly != "true" {
+			continue
+		}
+		if len(contributors.Anomalies) == contributorsAnomalyLimit {
+			break
+		}
+		anomaly, err := r.getAnomalyContributors(stats)
+		if err != nil {
+			return nil, errors.NewInternalError(err)
+		}
+		contributors.Anomalies = append(contributors.Anomalies, anomaly)
+	}
+	return contributors, nil
+}
+
+func (r *ContributorsREST) getAnomalyContributors(stats v1alpha1.ThroughputAnomalyDetectorStats) (v1alpha1.AnomalyContributors, error) {
+	anomaly := v1alpha1.AnomalyContributors{Anomaly: stats}
+	flowEnd, err := time.Parse(time.RFC3339, stats.FlowEndSeconds)
+	if err != nil {
+		anomaly.ErrorMsg = fmt.Sprintf("invalid anomaly time %q: %v", stats.FlowEndSeconds, err)
+		return anomaly, nil
+	}
+	anomaly.WindowStart = metav1.NewTime(flowEnd.Add(-contributorsWindow))
+	anomaly.WindowEnd = metav1.NewTime(flowEnd.Add(contributorsWindow))
+	conditions, args, err := contributorsFilter(stats)
+	if err != nil {
+		anomaly.ErrorMsg = err.Error()
+		return anomaly, nil
+	}
+	conditions = append([]string{"flowEndSeconds >= ?", "flowEndSeconds <= ?"}, conditions...)
+	args = append([]interface{}{anomaly.WindowStart.UTC(), anomaly.WindowEnd.UTC()}, args...)
+	query := fmt.Sprintf(contributorsQuery, strings.Join(conditions, " AND "), contributorsFlowLimit)
+	rows, err := r.tad.clickhouseConnect.Query(query, args...)
+	if err != nil {
+		return anomaly, fmt.Errorf("failed to get contributing flows of anomaly: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var flow v1alpha1.ContributingFlow
+		var protocolIdentifier int
+		err := rows.Scan(
+			&flow.SourcePodNamespace,
+			&flow.SourcePodName,
+			&flow.SourceIP,
+			&flow.DestinationPodNamespace,
+			&flow.DestinationPodName,
+			&flow.DestinationIP,
+			&flow.DestinationServicePortName,
+			&flow.DestinationTransportPort,
+			&protocolIdentifier,
+			&flow.Bytes,
+			&flow.Packets,
+			&flow.FlowCount,
+		)
+		if err != nil {
+			return anomaly, fmt.Errorf("failed to scan contributing flows of anomaly: %v", err)
+		}
+		flow.Protocol = protocolNames[protocolIdentifier]
+		if flow.Protocol == "" {
+			flow.Protocol = fmt.Sprint(protocolIdentifier)
+		}
+		anomaly.Flows = append(anomaly.Flows, flow)
+	}
+	if err := rows.Err(); err != nil {
+		return anomaly, fmt.Errorf("failed to get contributing flows of anomaly: %v", err)
+	}
+	return anomaly, nil
+}
+
+// contributorsFilter translates the throughput aggregated by an anomaly into
+// conditions on the flows table, together with the arguments bound to their
+// placeholders.
+func contributorsFilter(stats v1alpha1.ThroughputAnomalyDetectorStats) ([]string, []interface{}, error) {
+	// Inbound throughputs are aggregated over the destination of the flows
+	side := "source"
+	if stats.Direction == "inbound" {
+		side = "destination"
+	}
+	switch stats.AggType {
+	case "None":
+		sourcePort, err := strconv.Atoi(stats.SourceTransportPort)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid source port %q", stats.SourceTransportPort)
+		}
+		destinationPort, err := strconv.Atoi(stats.DestinationTransportPort)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid destination port %q", stats.DestinationTransportPort)
+		}
+		return []string{"sourceIP = ?", "sourceTransportPort = ?", "destinationIP = ?", "destinationTransportPort = ?"},
+			[]interface{}{stats.SourceIP, sourcePort, stats.DestinationIP, destinationPort}, nil
+	case "pod":
+		conditions := []string{fmt.Sprintf("%sPodNamespace = ?", side)}
+		args := []interface{}{stats.PodNamespace}
+		if stats.PodName != "" {
+			conditions = append(conditions, fmt.Sprintf("%sPodName = ?", side))
+			args = append(args, stats.PodName)
+		} else {
+			conditions = append(conditions, fmt.Sprintf("%sPodLabels = ?", side))
+			args = append(args, stats.PodLabels)
+		}
+		return conditions, args, nil
+	case "external":
+		return []string{"flowType = 3", "destinationIP = ?"}, []interface{}{stats.DestinationIP}, nil
+	case "svc":
+		return []string{"destinationServicePortName = ?"}, []interface{}{stats.DestinationServicePortName}, nil
+	case "node":
+		return []string{"sourceNodeName = ?", "destinationNodeName = ?"}, []interface{}{stats.SourceNodeName, stats.DestinationNodeName}, nil
+	case "namespace":
+		return []string{fmt.Sprintf("%sPodNamespace = ?", side)}, []interface{}{stats.PodNamespace}, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported aggregation type %q", stats.AggType)
+}
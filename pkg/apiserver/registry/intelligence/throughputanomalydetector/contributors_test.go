@@ -0,0 +1,200 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomalydetector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+)
+
+func TestContributorsREST_Get(t *testing.T) {
+	anomalyTime := time.Date(2023, 3, 1, 8, 0, 0, 0, time.UTC)
+	anomaly := v1alpha1.ThroughputAnomalyDetectorStats{
+		Id:                       "tad-1",
+		SourceIP:                 "10.10.0.1",
+		SourceTransportPort:      "40000",
+		DestinationIP:            "10.10.0.2",
+		DestinationTransportPort: "80",
+		FlowStartSeconds:         "2023-03-01T07:00:00Z",
+		FlowEndSeconds:           anomalyTime.Format(time.RFC3339),
+		Metric:                   "throughput",
+		Throughput:               "4000000000",
+		AggType:                  "None",
+		AlgoType:                 "EWMA",
+		AlgoCalc:                 "1000000000",
+		Anomaly:                  "true",
+	}
+	query := fmt.Sprintf(contributorsQuery, "flowEndSeconds >= ? AND flowEndSeconds <= ? AND sourceIP = ? AND sourceTransportPort = ? AND destinationIP = ? AND destinationTransportPort = ?", contributorsFlowLimit)
+
+	tests := []struct {
+		name         string
+		tadName      string
+		queryErr     error
+		rowErr       error
+		expectErr    error
+		expectResult *v1alpha1.ThroughputAnomalyDetectorContributors
+	}{
+		{
+			name:      "Not Found case",
+			tadName:   "non-existent-tad",
+			expectErr: errors.NewNotFound(v1alpha1.Resource("throughputanomalydetectors"), "non-existent-tad"),
+		},
+		{
+			name:      "Not completed case",
+			tadName:   "running-tad",
+			expectErr: errors.NewBadRequest("ThroughputAnomalyDetector job running-tad is not completed, current state: RUNNING"),
+		},
+		{
+			name:      "Query error case",
+			tadName:   "tad-1",
+			queryErr:  fmt.Errorf("error in database"),
+			expectErr: errors.NewInternalError(fmt.Errorf("failed to get contributing flows of anomaly: error in database")),
+		},
+		{
+			name:      "Row error case",
+			tadName:   "tad-1",
+			rowErr:    fmt.Errorf("connection reset"),
+			expectErr: errors.NewInternalError(fmt.Errorf("failed to get contributing flows of anomaly: connection reset")),
+		},
+		{
+			name:    "Successful Get case",
+			tadName: "tad-1",
+			expectResult: &v1alpha1.ThroughputAnomalyDetectorContributors{
+				ObjectMeta: v1.ObjectMeta{Name: "tad-1"},
+				Anomalies: []v1alpha1.AnomalyContributors{{
+					Anomaly:     anomaly,
+					WindowStart: v1.NewTime(anomalyTime.Add(-contributorsWindow)),
+					WindowEnd:   v1.NewTime(anomalyTime.Add(contributorsWindow)),
+					Flows: []v1alpha1.ContributingFlow{{
+						SourcePodNamespace:       "web",
+						SourcePodName:            "frontend",
+						SourceIP:                 "10.10.0.1",
+						DestinationPodNamespace:  "db",
+						DestinationPodName:       "postgres",
+						DestinationIP:            "10.10.0.2",
+						DestinationTransportPort: 80,
+						Protocol:                 "TCP",
+						Bytes:                    30000000000,
+						Packets:                  20000000,
+						FlowCount:                12,
+					}},
+				}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			defer db.Close()
+			mock.ExpectQuery(queryMap[tadQuery]).WillReturnRows(sqlmock.NewRows([]string{
+				"Id", "SourceIP", "SourceTransportPort", "DestinationIP", "DestinationTransportPort", "FlowStartSeconds", "FlowEndSeconds", "Metric", "Throughput", "AggType", "AlgoType", "AlgoParams", "AlgoCalc", "AlgoVerdicts", "Anomaly"}).
+				AddRow(anomaly.Id, anomaly.SourceIP, anomaly.SourceTransportPort, anomaly.DestinationIP, anomaly.DestinationTransportPort, anomaly.FlowStartSeconds, anomaly.FlowEndSeconds, anomaly.Metric, anomaly.Throughput, anomaly.AggType, anomaly.AlgoType, anomaly.AlgoParams, anomaly.AlgoCalc, anomaly.AlgoVerdicts, anomaly.Anomaly).
+				// A normal value saved by the job has no contributors
+				AddRow(anomaly.Id, anomaly.SourceIP, anomaly.SourceTransportPort, anomaly.DestinationIP, anomaly.DestinationTransportPort, anomaly.FlowStartSeconds, "2023-03-01T07:59:00Z", anomaly.Metric, "1000000000", anomaly.AggType, anomaly.AlgoType, anomaly.AlgoParams, anomaly.AlgoCalc, anomaly.AlgoVerdicts, "false"))
+			expectQuery := mock.ExpectQuery(query).WithArgs(anomalyTime.Add(-contributorsWindow), anomalyTime.Add(contributorsWindow), "10.10.0.1", 40000, "10.10.0.2", 80)
+			if tt.queryErr != nil {
+				expectQuery.WillReturnError(tt.queryErr)
+			} else if tt.rowErr != nil {
+				expectQuery.WillReturnRows(sqlmock.NewRows([]string{"sourcePodNamespace"}).AddRow("web").RowError(0, tt.rowErr))
+			} else {
+				expectQuery.WillReturnRows(sqlmock.NewRows([]string{
+					"sourcePodNamespace", "sourcePodName", "sourceIP", "destinationPodNamespace", "destinationPodName", "destinationIP", "destinationServicePortName", "destinationTransportPort", "protocolIdentifier", "bytes", "packets", "flowCount"}).
+					AddRow("web", "frontend", "10.10.0.1", "db", "postgres", "10.10.0.2", "", 80, 6, 30000000000, 20000000, 12))
+			}
+			setupClickHouseConnection = func(client kubernetes.Interface) (connect *sql.DB, err error) {
+				return db, nil
+			}
+			r := NewContributorsREST(NewREST(&fakeQuerier{}))
+			contributors, err := r.Get(context.TODO(), tt.tadName, &v1.GetOptions{})
+			assert.Equal(t, tt.expectErr, err)
+			if tt.expectResult != nil {
+				assert.Equal(t, tt.expectResult, contributors)
+			}
+		})
+	}
+}
+
+func TestContributorsFilter(t *testing.T) {
+	tests := []struct {
+		name             string
+		stats            v1alpha1.ThroughputAnomalyDetectorStats
+		expectConditions []string
+		expectArgs       []interface{}
+		expectErr        error
+	}{
+		{
+			name:             "Inbound Pod name",
+			stats:            v1alpha1.ThroughputAnomalyDetectorStats{AggType: "pod", PodNamespace: "db", PodName: "postgres", Direction: "inbound"},
+			expectConditions: []string{"destinationPodNamespace = ?", "destinationPodName = ?"},
+			expectArgs:       []interface{}{"db", "postgres"},
+		},
+		{
+			name:             "Outbound Pod labels",
+			stats:            v1alpha1.ThroughputAnomalyDetectorStats{AggType: "pod", PodNamespace: "web", PodLabels: `{"app":"frontend"}`, Direction: "outbound"},
+			expectConditions: []string{"sourcePodNamespace = ?", "sourcePodLabels = ?"},
+			expectArgs:       []interface{}{"web", `{"app":"frontend"}`},
+		},
+		{
+			name:             "External IP",
+			stats:            v1alpha1.ThroughputAnomalyDetectorStats{AggType: "external", DestinationIP: "8.8.8.8"},
+			expectConditions: []string{"flowType = 3", "destinationIP = ?"},
+			expectArgs:       []interface{}{"8.8.8.8"},
+		},
+		{
+			name:             "Service",
+			stats:            v1alpha1.ThroughputAnomalyDetectorStats{AggType: "svc", DestinationServicePortName: "db/postgres:5432"},
+			expectConditions: []string{"destinationServicePortName = ?"},
+			expectArgs:       []interface{}{"db/postgres:5432"},
+		},
+		{
+			name:             "Nodes",
+			stats:            v1alpha1.ThroughputAnomalyDetectorStats{AggType: "node", SourceNodeName: "node-1", DestinationNodeName: "node-2"},
+			expectConditions: []string{"sourceNodeName = ?", "destinationNodeName = ?"},
+			expectArgs:       []interface{}{"node-1", "node-2"},
+		},
+		{
+			name:             "Inbound Namespace",
+			stats:            v1alpha1.ThroughputAnomalyDetectorStats{AggType: "namespace", PodNamespace: "db", Direction: "inbound"},
+			expectConditions: []string{"destinationPodNamespace = ?"},
+			expectArgs:       []interface{}{"db"},
+		},
+		{
+			name:      "Invalid port",
+			stats:     v1alpha1.ThroughputAnomalyDetectorStats{AggType: "None", SourceTransportPort: "None"},
+			expectErr: fmt.Errorf("invalid source port \"None\""),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conditions, args, err := contributorsFilter(tt.stats)
+			assert.Equal(t, tt.expectErr, err)
+			assert.Equal(t, tt.expectConditions, conditions)
+			assert.Equal(t, tt.expectArgs, args)
+		})
+	}
+}
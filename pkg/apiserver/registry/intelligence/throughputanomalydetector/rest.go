@@ -18,6 +18,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
@@ -28,6 +29,7 @@ import (
 	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
 	"antrea.io/theia/pkg/apis/intelligence/v1alpha1"
 	"antrea.io/theia/pkg/querier"
+	"antrea.io/theia/pkg/util/anomaly"
 	"antrea.io/theia/pkg/util/clickhouse"
 )
 
@@ -38,6 +40,8 @@ const (
 	aggTadPodLabelQuery
 	aggTadPodNameQuery
 	aggTadSvcQuery
+	aggTadNodeQuery
+	aggTadNamespaceQuery
 )
 
 // REST implements rest.Storage for anomalydetector.
@@ -56,9 +60,28 @@ var (
 	setupClickHouseConnection = clickhouse.SetupConnection
 )
 
-var queryMap = map[int]string{
-	tadQuery: `
+// maxNormalPoints is the maximum number of normal values returned for a job
+// run with SaveNormalPoints. The most recent ones are returned, while all the
+// anomalies are.
+const maxNormalPoints = 10000
+
+// statsQuery returns the query of the results of a job with the given
+// columns. ORDER BY and LIMIT only apply to the second query of UNION ALL.
+func statsQuery(columns string) string {
+	return fmt.Sprintf(`
 	SELECT
+%[1]s
+	FROM tadetector WHERE id = (?) AND anomaly != 'false'
+	UNION ALL
+	SELECT
+%[1]s
+	FROM tadetector WHERE id = (?) AND anomaly = 'false'
+	ORDER BY flowEndSeconds DESC
+	LIMIT %[2]d;`, columns, maxNormalPoints)
+}
+
+var queryMap = map[int]string{
+	tadQuery: statsQuery(`
 		id,
 		sourceIP,
 		sourceTransportPort,
@@ -66,60 +89,92 @@ var queryMap = map[int]string{
 		destinationTransportPort,
 		flowStartSeconds,
 		flowEndSeconds,
+		metric,
 		throughput,
 		aggType,
 		algoType,
+		algoParams,
 		algoCalc,
-		anomaly
-	FROM tadetector WHERE id = (?);`,
-	aggTadExternalQuery: `
-	SELECT
+		algoVerdicts,
+		anomaly`),
+	aggTadExternalQuery: statsQuery(`
 		id,
 		destinationIP,
 		flowEndSeconds,
+		metric,
 		throughput,
 		aggType,
 		algoType,
+		algoParams,
 		algoCalc,
-		anomaly
-	FROM tadetector WHERE id = (?);`,
-	aggTadPodLabelQuery: `
-	SELECT
+		algoVerdicts,
+		anomaly`),
+	aggTadPodLabelQuery: statsQuery(`
 		id,
 		podNamespace,
 		podLabels,
 		direction,
 		flowEndSeconds,
+		metric,
 		throughput,
 		aggType,
 		algoType,
+		algoParams,
 		algoCalc,
-		anomaly
-	FROM tadetector WHERE id = (?);`,
-	aggTadPodNameQuery: `
-	SELECT
+		algoVerdicts,
+		anomaly`),
+	aggTadPodNameQuery: statsQuery(`
 		id,
 		podNamespace,
 		podName,
 		direction,
 		flowEndSeconds,
+		metric,
 		throughput,
 		aggType,
 		algoType,
+		algoParams,
 		algoCalc,
-		anomaly
-	FROM tadetector WHERE id = (?);`,
-	aggTadSvcQuery: `
-	SELECT
+		algoVerdicts,
+		anomaly`),
+	aggTadSvcQuery: statsQuery(`
 		id,
 		destinationServicePortName,
 		flowEndSeconds,
+		metric,
+		throughput,
+		aggType,
+		algoType,
+		algoParams,
+		algoCalc,
+		algoVerdicts,
+		anomaly`),
+	aggTadNodeQuery: statsQuery(`
+		id,
+		sourceNodeName,
+		destinationNodeName,
+		flowEndSeconds,
+		metric,
 		throughput,
 		aggType,
 		algoType,
+		algoParams,
 		algoCalc,
-		anomaly
-	FROM tadetector WHERE id = (?);`,
+		algoVerdicts,
+		anomaly`),
+	aggTadNamespaceQuery: statsQuery(`
+		id,
+		podNamespace,
+		direction,
+		flowEndSeconds,
+		metric,
+		throughput,
+		aggType,
+		algoType,
+		algoParams,
+		algoCalc,
+		algoVerdicts,
+		anomaly`),
 }
 
 // NewREST returns a REST object that will work against API services.
@@ -165,20 +220,85 @@ func (r *REST) copyThroughputAnomalyDetector(tad *v1alpha1.ThroughputAnomalyDete
 	tad.PodNameSpace = crd.Spec.PodNameSpace
 	tad.ExternalIP = crd.Spec.ExternalIP
 	tad.ServicePortName = crd.Spec.ServicePortName
+	tad.NodeName = crd.Spec.NodeName
+	tad.Metric = crd.Spec.Metric
+	tad.SaveNormalPoints = crd.Spec.SaveNormalPoints
+	tad.AlgoParams = (*v1alpha1.ThroughputAnomalyDetectorAlgoParams)(crd.Spec.AlgoParams.DeepCopy())
+	tad.FlowFilter = copyFlowFilterFromCRD(crd.Spec.FlowFilter)
 	tad.DriverCoreRequest = crd.Spec.DriverCoreRequest
 	tad.DriverMemory = crd.Spec.DriverMemory
 	tad.ExecutorCoreRequest = crd.Spec.ExecutorCoreRequest
 	tad.ExecutorMemory = crd.Spec.ExecutorMemory
+	tad.ActiveDeadlineSeconds = crd.Spec.ActiveDeadlineSeconds
+	tad.TTLSecondsAfterFinished = crd.Spec.TTLSecondsAfterFinished
 	tad.Status.State = crd.Status.State
 	tad.Status.SparkApplication = crd.Status.SparkApplication
 	tad.Status.CompletedStages = crd.Status.CompletedStages
 	tad.Status.TotalStages = crd.Status.TotalStages
+	tad.Status.Progress = (*v1alpha1.JobProgress)(crd.Status.Progress.DeepCopy())
+	tad.Status.InputEstimate = (*v1alpha1.JobInputEstimate)(crd.Status.InputEstimate.DeepCopy())
 	tad.Status.ErrorMsg = crd.Status.ErrorMsg
 	tad.Status.StartTime = crd.Status.StartTime
 	tad.Status.EndTime = crd.Status.EndTime
 	return nil
 }
 
+// copyFlowFilterFromCRD is used to copy the flow filter of a
+// ThroughputAnomalyDetector from crd to anomalydetector
+func copyFlowFilterFromCRD(crd *crdv1alpha1.ThroughputAnomalyDetectorFlowFilter) *v1alpha1.ThroughputAnomalyDetectorFlowFilter {
+	if crd == nil {
+		return nil
+	}
+	copyPeer := func(peer *crdv1alpha1.ThroughputAnomalyDetectorFlowPeer) *v1alpha1.ThroughputAnomalyDetectorFlowPeer {
+		if peer == nil {
+			return nil
+		}
+		return &v1alpha1.ThroughputAnomalyDetectorFlowPeer{
+			NamespaceSelector: peer.NamespaceSelector.DeepCopy(),
+			PodSelector:       peer.PodSelector.DeepCopy(),
+			CIDRs:             peer.CIDRs,
+		}
+	}
+	filter := &v1alpha1.ThroughputAnomalyDetectorFlowFilter{
+		Source:      copyPeer(crd.Source),
+		Destination: copyPeer(crd.Destination),
+		Protocols:   crd.Protocols,
+		FlowTypes:   crd.FlowTypes,
+	}
+	for _, port := range crd.Ports {
+		filter.Ports = append(filter.Ports, v1alpha1.ThroughputAnomalyDetectorPortRange(port))
+	}
+	return filter
+}
+
+// copyFlowFilterToCRD is used to copy the flow filter of a
+// ThroughputAnomalyDetector from anomalydetector to crd
+func copyFlowFilterToCRD(filter *v1alpha1.ThroughputAnomalyDetectorFlowFilter) *crdv1alpha1.ThroughputAnomalyDetectorFlowFilter {
+	if filter == nil {
+		return nil
+	}
+	copyPeer := func(peer *v1alpha1.ThroughputAnomalyDetectorFlowPeer) *crdv1alpha1.ThroughputAnomalyDetectorFlowPeer {
+		if peer == nil {
+			return nil
+		}
+		return &crdv1alpha1.ThroughputAnomalyDetectorFlowPeer{
+			NamespaceSelector: peer.NamespaceSelector.DeepCopy(),
+			PodSelector:       peer.PodSelector.DeepCopy(),
+			CIDRs:             peer.CIDRs,
+		}
+	}
+	crd := &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+		Source:      copyPeer(filter.Source),
+		Destination: copyPeer(filter.Destination),
+		Protocols:   filter.Protocols,
+		FlowTypes:   filter.FlowTypes,
+	}
+	for _, port := range filter.Ports {
+		crd.Ports = append(crd.Ports, crdv1alpha1.ThroughputAnomalyDetectorPortRange(port))
+	}
+	return crd
+}
+
 func (r *REST) NewList() runtime.Object {
 	return &v1alpha1.ThroughputAnomalyDetectorList{}
 }
@@ -233,12 +353,29 @@ func (r *REST) Create(ctx context.Context, obj runtime.Object, createValidation
 	job.Spec.DriverMemory = newTAD.DriverMemory
 	job.Spec.ExecutorCoreRequest = newTAD.ExecutorCoreRequest
 	job.Spec.ExecutorMemory = newTAD.ExecutorMemory
+	job.Spec.ActiveDeadlineSeconds = newTAD.ActiveDeadlineSeconds
+	job.Spec.TTLSecondsAfterFinished = newTAD.TTLSecondsAfterFinished
 	job.Spec.AggregatedFlow = newTAD.AggregatedFlow
 	job.Spec.PodLabel = newTAD.PodLabel
 	job.Spec.PodName = newTAD.PodName
 	job.Spec.PodNameSpace = newTAD.PodNameSpace
 	job.Spec.ExternalIP = newTAD.ExternalIP
 	job.Spec.ServicePortName = newTAD.ServicePortName
+	job.Spec.NodeName = newTAD.NodeName
+	job.Spec.Metric = newTAD.Metric
+	job.Spec.SaveNormalPoints = newTAD.SaveNormalPoints
+	job.Spec.AlgoParams = (*crdv1alpha1.ThroughputAnomalyDetectorAlgoParams)(newTAD.AlgoParams.DeepCopy())
+	job.Spec.FlowFilter = copyFlowFilterToCRD(newTAD.FlowFilter)
+	// A dry run only estimates the input of the job, nothing is created.
+	if len(options.DryRun) > 0 {
+		estimate, err := r.ThroughputAnomalyDetectorQuerier.EstimateThroughputAnomalyDetector(job)
+		if err != nil {
+			return nil, errors.NewBadRequest(fmt.Sprintf("error when estimating the input of ThroughputAnomalyDetection job: %v", err))
+		}
+		result := &v1alpha1.ThroughputAnomalyDetector{ObjectMeta: metav1.ObjectMeta{Name: newTAD.Name}}
+		result.Status.InputEstimate = (*v1alpha1.JobInputEstimate)(estimate)
+		return result, nil
+	}
 	_, err := r.ThroughputAnomalyDetectorQuerier.CreateThroughputAnomalyDetector(defaultNameSpace, job)
 	if err != nil {
 		return nil, errors.NewBadRequest(fmt.Sprintf("error when creating ThroughputAnomalyDetection job: %+v, err: %v", job, err))
@@ -260,6 +397,10 @@ func (r *REST) getTADetectorResult(id string, tad *v1alpha1.ThroughputAnomalyDet
 		}
 	case "svc":
 		query = aggTadSvcQuery
+	case "node":
+		query = aggTadNodeQuery
+	case "namespace":
+		query = aggTadNamespaceQuery
 	}
 	if r.clickhouseConnect == nil {
 		r.clickhouseConnect, err = setupClickHouseConnection(nil)
@@ -267,7 +408,7 @@ func (r *REST) getTADetectorResult(id string, tad *v1alpha1.ThroughputAnomalyDet
 			return err
 		}
 	}
-	rows, err := r.clickhouseConnect.Query(queryMap[query], id)
+	rows, err := r.clickhouseConnect.Query(queryMap[query], id, id)
 	if err != nil {
 		return fmt.Errorf("failed to get Throughput Anomaly Detector results with id %s: %v", id, err)
 	}
@@ -276,44 +417,109 @@ func (r *REST) getTADetectorResult(id string, tad *v1alpha1.ThroughputAnomalyDet
 		switch query {
 		case tadQuery:
 			res := v1alpha1.ThroughputAnomalyDetectorStats{}
-			err := rows.Scan(&res.Id, &res.SourceIP, &res.SourceTransportPort, &res.DestinationIP, &res.DestinationTransportPort, &res.FlowStartSeconds, &res.FlowEndSeconds, &res.Throughput, &res.AggType, &res.AlgoType, &res.AlgoCalc, &res.Anomaly)
+			err := rows.Scan(&res.Id, &res.SourceIP, &res.SourceTransportPort, &res.DestinationIP, &res.DestinationTransportPort, &res.FlowStartSeconds, &res.FlowEndSeconds, &res.Metric, &res.Throughput, &res.AggType, &res.AlgoType, &res.AlgoParams, &res.AlgoCalc, &res.AlgoVerdicts, &res.Anomaly)
 			if err != nil {
 				return fmt.Errorf("failed to scan Throughput Anomaly Detector results: %v", err)
 			}
 			tad.Stats = append(tad.Stats, res)
 		case aggTadExternalQuery:
 			res := v1alpha1.ThroughputAnomalyDetectorStats{}
-			err := rows.Scan(&res.Id, &res.DestinationIP, &res.FlowEndSeconds, &res.Throughput, &res.AggType, &res.AlgoType, &res.AlgoCalc, &res.Anomaly)
+			err := rows.Scan(&res.Id, &res.DestinationIP, &res.FlowEndSeconds, &res.Metric, &res.Throughput, &res.AggType, &res.AlgoType, &res.AlgoParams, &res.AlgoCalc, &res.AlgoVerdicts, &res.Anomaly)
 			if err != nil {
 				return fmt.Errorf("failed to scan Throughput Anomaly Detector External IP Aggregate results: %v", err)
 			}
 			tad.Stats = append(tad.Stats, res)
 		case aggTadPodLabelQuery:
 			res := v1alpha1.ThroughputAnomalyDetectorStats{}
-			err := rows.Scan(&res.Id, &res.PodNamespace, &res.PodLabels, &res.Direction, &res.FlowEndSeconds, &res.Throughput, &res.AggType, &res.AlgoType, &res.AlgoCalc, &res.Anomaly)
+			err := rows.Scan(&res.Id, &res.PodNamespace, &res.PodLabels, &res.Direction, &res.FlowEndSeconds, &res.Metric, &res.Throughput, &res.AggType, &res.AlgoType, &res.AlgoParams, &res.AlgoCalc, &res.AlgoVerdicts, &res.Anomaly)
 			if err != nil {
 				return fmt.Errorf("failed to scan Throughput Anomaly Detector Pod Aggregate results: %v", err)
 			}
 			tad.Stats = append(tad.Stats, res)
 		case aggTadPodNameQuery:
 			res := v1alpha1.ThroughputAnomalyDetectorStats{}
-			err := rows.Scan(&res.Id, &res.PodNamespace, &res.PodName, &res.Direction, &res.FlowEndSeconds, &res.Throughput, &res.AggType, &res.AlgoType, &res.AlgoCalc, &res.Anomaly)
+			err := rows.Scan(&res.Id, &res.PodNamespace, &res.PodName, &res.Direction, &res.FlowEndSeconds, &res.Metric, &res.Throughput, &res.AggType, &res.AlgoType, &res.AlgoParams, &res.AlgoCalc, &res.AlgoVerdicts, &res.Anomaly)
 			if err != nil {
 				return fmt.Errorf("failed to scan Throughput Anomaly Detector Pod Aggregate results: %v", err)
 			}
 			tad.Stats = append(tad.Stats, res)
 		case aggTadSvcQuery:
 			res := v1alpha1.ThroughputAnomalyDetectorStats{}
-			err := rows.Scan(&res.Id, &res.DestinationServicePortName, &res.FlowEndSeconds, &res.Throughput, &res.AggType, &res.AlgoType, &res.AlgoCalc, &res.Anomaly)
+			err := rows.Scan(&res.Id, &res.DestinationServicePortName, &res.FlowEndSeconds, &res.Metric, &res.Throughput, &res.AggType, &res.AlgoType, &res.AlgoParams, &res.AlgoCalc, &res.AlgoVerdicts, &res.Anomaly)
 			if err != nil {
 				return fmt.Errorf("failed to scan Throughput Anomaly Detector Service Aggregate results: %v", err)
 			}
 			tad.Stats = append(tad.Stats, res)
+		case aggTadNodeQuery:
+			res := v1alpha1.ThroughputAnomalyDetectorStats{}
+			err := rows.Scan(&res.Id, &res.SourceNodeName, &res.DestinationNodeName, &res.FlowEndSeconds, &res.Metric, &res.Throughput, &res.AggType, &res.AlgoType, &res.AlgoParams, &res.AlgoCalc, &res.AlgoVerdicts, &res.Anomaly)
+			if err != nil {
+				return fmt.Errorf("failed to scan Throughput Anomaly Detector Node Aggregate results: %v", err)
+			}
+			tad.Stats = append(tad.Stats, res)
+		case aggTadNamespaceQuery:
+			res := v1alpha1.ThroughputAnomalyDetectorStats{}
+			err := rows.Scan(&res.Id, &res.PodNamespace, &res.Direction, &res.FlowEndSeconds, &res.Metric, &res.Throughput, &res.AggType, &res.AlgoType, &res.AlgoParams, &res.AlgoCalc, &res.AlgoVerdicts, &res.Anomaly)
+			if err != nil {
+				return fmt.Errorf("failed to scan Throughput Anomaly Detector Namespace Aggregate results: %v", err)
+			}
+			tad.Stats = append(tad.Stats, res)
 		}
 	}
+	return r.filterSuppressedStats(tad)
+}
+
+// filterSuppressedStats removes the anomalies matched by AnomalySuppressions
+// from the results. A job whose anomalies are all suppressed is reported as
+// having found no anomaly.
+func (r *REST) filterSuppressedStats(tad *v1alpha1.ThroughputAnomalyDetector) error {
+	if len(tad.Stats) == 0 {
+		return nil
+	}
+	suppressions, err := r.ThroughputAnomalyDetectorQuerier.ListAnomalySuppression(defaultNameSpace)
+	if err != nil {
+		return fmt.Errorf("failed to list AnomalySuppressions: %v", err)
+	}
+	if len(suppressions) == 0 {
+		return nil
+	}
+	now := time.Now()
+	stats := make([]v1alpha1.ThroughputAnomalyDetectorStats, 0, len(tad.Stats))
+	for _, res := range tad.Stats {
+		if res.Anomaly != noAnomalyDetected && isSuppressedStats(res, suppressions, now) {
+			continue
+		}
+		stats = append(stats, res)
+	}
+	if len(stats) == 0 {
+		stats = append(stats, v1alpha1.ThroughputAnomalyDetectorStats{
+			Id:      tad.Stats[0].Id,
+			Anomaly: noAnomalyDetected,
+		})
+	}
+	tad.Stats = stats
 	return nil
 }
 
+func isSuppressedStats(stats v1alpha1.ThroughputAnomalyDetectorStats, suppressions []*crdv1alpha1.AnomalySuppression, now time.Time) bool {
+	// An anomaly whose time can't be parsed only matches the suppressions
+	// without any time condition.
+	anomalyTime, _ := time.Parse(time.RFC3339Nano, stats.FlowEndSeconds)
+	return anomaly.IsSuppressed(anomaly.Anomaly{
+		AggType:                    stats.AggType,
+		SourceIP:                   stats.SourceIP,
+		DestinationIP:              stats.DestinationIP,
+		PodNamespace:               stats.PodNamespace,
+		PodLabels:                  stats.PodLabels,
+		PodName:                    stats.PodName,
+		Direction:                  stats.Direction,
+		DestinationServicePortName: stats.DestinationServicePortName,
+		SourceNodeName:             stats.SourceNodeName,
+		DestinationNodeName:        stats.DestinationNodeName,
+		Time:                       anomalyTime,
+	}, suppressions, now)
+}
+
 func (r *REST) Delete(ctx context.Context, name string, deleteValidation rest.ValidateObjectFunc, options *metav1.DeleteOptions) (runtime.Object, bool, error) {
 	_, err := r.ThroughputAnomalyDetectorQuerier.GetThroughputAnomalyDetector(defaultNameSpace, name)
 	if err != nil {
@@ -33,7 +33,9 @@ import (
 	"antrea.io/theia/pkg/apis/intelligence/v1alpha1"
 )
 
-type fakeQuerier struct{}
+type fakeQuerier struct {
+	suppressions []*crdv1alpha1.AnomalySuppression
+}
 
 func TestREST_Get(t *testing.T) {
 
@@ -55,6 +57,9 @@ func TestREST_Get(t *testing.T) {
 			expectErr: nil,
 			expectResult: &v1alpha1.ThroughputAnomalyDetector{
 				Type: "TAD",
+				AlgoParams: &v1alpha1.ThroughputAnomalyDetectorAlgoParams{
+					Sensitivity: 2,
+				},
 				Status: v1alpha1.ThroughputAnomalyDetectorStatus{
 					State: crdv1alpha1.ThroughputAnomalyDetectorStateCompleted,
 				},
@@ -66,10 +71,13 @@ func TestREST_Get(t *testing.T) {
 					DestinationTransportPort: "mock_DestinationTransportPort",
 					FlowStartSeconds:         "mock_FlowStartSeconds",
 					FlowEndSeconds:           "mock_FlowEndSeconds",
+					Metric:                   "mock_Metric",
 					Throughput:               "mock_Throughput",
 					AggType:                  "mock_AggType",
 					AlgoType:                 "mock_AlgoType",
+					AlgoParams:               "mock_AlgoParams",
 					AlgoCalc:                 "mock_AlgoCalc",
+					AlgoVerdicts:             "mock_AlgoVerdicts",
 					Anomaly:                  "mock_Anomaly",
 				}},
 			},
@@ -80,6 +88,9 @@ func TestREST_Get(t *testing.T) {
 			expectErr: nil,
 			expectResult: &v1alpha1.ThroughputAnomalyDetector{
 				Type: "TAD",
+				AlgoParams: &v1alpha1.ThroughputAnomalyDetectorAlgoParams{
+					Sensitivity: 2,
+				},
 				Status: v1alpha1.ThroughputAnomalyDetectorStatus{
 					State:    crdv1alpha1.ThroughputAnomalyDetectorStateCompleted,
 					ErrorMsg: "Failed to get the result for completed Throughput Anomaly Detector with id , error: failed to get Throughput Anomaly Detector results with id : error in database, please retry",
@@ -92,9 +103,12 @@ func TestREST_Get(t *testing.T) {
 			expectErr: nil,
 			expectResult: &v1alpha1.ThroughputAnomalyDetector{
 				Type: "TAD",
+				AlgoParams: &v1alpha1.ThroughputAnomalyDetectorAlgoParams{
+					Sensitivity: 2,
+				},
 				Status: v1alpha1.ThroughputAnomalyDetectorStatus{
 					State:    crdv1alpha1.ThroughputAnomalyDetectorStateCompleted,
-					ErrorMsg: "Failed to get the result for completed Throughput Anomaly Detector with id , error: failed to scan Throughput Anomaly Detector results: sql: expected 1 destination arguments in Scan, not 12",
+					ErrorMsg: "Failed to get the result for completed Throughput Anomaly Detector with id , error: failed to scan Throughput Anomaly Detector results: sql: expected 1 destination arguments in Scan, not 15",
 				},
 			},
 		},
@@ -107,14 +121,14 @@ func TestREST_Get(t *testing.T) {
 			}
 			defer db.Close()
 			resultRows := sqlmock.NewRows([]string{
-				"Id", "SourceIP", "SourceTransportPort", "DestinationIP", "DestinationTransportPort", "FlowStartSeconds", "FlowEndSeconds", "Throughput", "AggType", "AlgoType", "AlgoCalc", "Anomaly"}).
-				AddRow("mock_Id", "mock_SourceIP", "mock_SourceTransportPort", "mock_DestinationIP", "mock_DestinationTransportPort", "mock_FlowStartSeconds", "mock_FlowEndSeconds", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoCalc", "mock_Anomaly")
+				"Id", "SourceIP", "SourceTransportPort", "DestinationIP", "DestinationTransportPort", "FlowStartSeconds", "FlowEndSeconds", "Metric", "Throughput", "AggType", "AlgoType", "AlgoParams", "AlgoCalc", "AlgoVerdicts", "Anomaly"}).
+				AddRow("mock_Id", "mock_SourceIP", "mock_SourceTransportPort", "mock_DestinationIP", "mock_DestinationTransportPort", "mock_FlowStartSeconds", "mock_FlowEndSeconds", "mock_Metric", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoParams", "mock_AlgoCalc", "mock_AlgoVerdicts", "mock_Anomaly")
 			if tt.name == "Unsuccessful Get case query error" {
 				mock.ExpectQuery(queryMap[tadQuery]).WillReturnError(fmt.Errorf("error in database, please retry"))
 			} else if tt.name == "Unsuccessful Get case rows error" {
 				mock.ExpectQuery(queryMap[tadQuery]).WillReturnRows(sqlmock.NewRows([]string{"Id"}).AddRow("mock_Id"))
 			} else {
-				mock.ExpectQuery(queryMap[tadQuery]).WillReturnRows(resultRows)
+				mock.ExpectQuery(queryMap[tadQuery]).WithArgs("", "").WillReturnRows(resultRows)
 			}
 
 			setupClickHouseConnection = func(client kubernetes.Interface) (connect *sql.DB, err error) {
@@ -163,6 +177,7 @@ func TestREST_Create(t *testing.T) {
 	tests := []struct {
 		name         string
 		obj          runtime.Object
+		dryRun       bool
 		expectErr    error
 		expectResult runtime.Object
 	}{
@@ -190,11 +205,30 @@ func TestREST_Create(t *testing.T) {
 			expectErr:    nil,
 			expectResult: &v1.Status{Status: v1.StatusSuccess},
 		},
+		{
+			name: "Dry run case",
+			obj: &v1alpha1.ThroughputAnomalyDetector{
+				TypeMeta:   v1.TypeMeta{},
+				ObjectMeta: v1.ObjectMeta{Name: "non-existent-tad"},
+			},
+			dryRun:    true,
+			expectErr: nil,
+			expectResult: &v1alpha1.ThroughputAnomalyDetector{
+				ObjectMeta: v1.ObjectMeta{Name: "non-existent-tad"},
+				Status: v1alpha1.ThroughputAnomalyDetectorStatus{
+					InputEstimate: &v1alpha1.JobInputEstimate{Rows: 1000, Bytes: 2048},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := NewREST(&fakeQuerier{})
-			result, err := r.Create(context.TODO(), tt.obj, nil, &v1.CreateOptions{})
+			options := &v1.CreateOptions{}
+			if tt.dryRun {
+				options.DryRun = []string{v1.DryRunAll}
+			}
+			result, err := r.Create(context.TODO(), tt.obj, nil, options)
 			assert.Equal(t, err, tt.expectErr)
 			assert.Equal(t, tt.expectResult, result)
 		})
@@ -240,8 +274,8 @@ func Test_getTadetectorResult(t *testing.T) {
 			id:    "tad-1",
 			query: tadQuery,
 			returnedRow: sqlmock.NewRows([]string{
-				"Id", "SourceIP", "SourceTransportPort", "DestinationIP", "DestinationTransportPort", "FlowStartSeconds", "FlowEndSeconds", "Throughput", "AggType", "AlgoType", "AlgoCalc", "Anomaly"}).
-				AddRow("mock_Id", "mock_SourceIP", "mock_SourceTransportPort", "mock_DestinationIP", "mock_DestinationTransportPort", "mock_FlowStartSeconds", "mock_FlowEndSeconds", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoCalc", "mock_Anomaly"),
+				"Id", "SourceIP", "SourceTransportPort", "DestinationIP", "DestinationTransportPort", "FlowStartSeconds", "FlowEndSeconds", "Metric", "Throughput", "AggType", "AlgoType", "AlgoParams", "AlgoCalc", "AlgoVerdicts", "Anomaly"}).
+				AddRow("mock_Id", "mock_SourceIP", "mock_SourceTransportPort", "mock_DestinationIP", "mock_DestinationTransportPort", "mock_FlowStartSeconds", "mock_FlowEndSeconds", "mock_Metric", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoParams", "mock_AlgoCalc", "mock_AlgoVerdicts", "mock_Anomaly"),
 			expectedResult: &v1alpha1.ThroughputAnomalyDetector{
 				Stats: []v1alpha1.ThroughputAnomalyDetectorStats{{
 					Id:                       "mock_Id",
@@ -251,10 +285,13 @@ func Test_getTadetectorResult(t *testing.T) {
 					DestinationTransportPort: "mock_DestinationTransportPort",
 					FlowStartSeconds:         "mock_FlowStartSeconds",
 					FlowEndSeconds:           "mock_FlowEndSeconds",
+					Metric:                   "mock_Metric",
 					Throughput:               "mock_Throughput",
 					AggType:                  "mock_AggType",
 					AlgoType:                 "mock_AlgoType",
+					AlgoParams:               "mock_AlgoParams",
 					AlgoCalc:                 "mock_AlgoCalc",
+					AlgoVerdicts:             "mock_AlgoVerdicts",
 					Anomaly:                  "mock_Anomaly",
 				}},
 			},
@@ -265,17 +302,20 @@ func Test_getTadetectorResult(t *testing.T) {
 			id:    "tad-2",
 			query: aggTadExternalQuery,
 			returnedRow: sqlmock.NewRows([]string{
-				"Id", "destinationIP", "FlowEndSeconds", "Throughput", "AggType", "AlgoType", "AlgoCalc", "Anomaly"}).
-				AddRow("mock_Id", "mock_destinationIP", "mock_FlowEndSeconds", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoCalc", "mock_Anomaly"),
+				"Id", "destinationIP", "FlowEndSeconds", "Metric", "Throughput", "AggType", "AlgoType", "AlgoParams", "AlgoCalc", "AlgoVerdicts", "Anomaly"}).
+				AddRow("mock_Id", "mock_destinationIP", "mock_FlowEndSeconds", "mock_Metric", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoParams", "mock_AlgoCalc", "mock_AlgoVerdicts", "mock_Anomaly"),
 			expectedResult: &v1alpha1.ThroughputAnomalyDetector{
 				Stats: []v1alpha1.ThroughputAnomalyDetectorStats{{
 					Id:             "mock_Id",
 					DestinationIP:  "mock_destinationIP",
 					FlowEndSeconds: "mock_FlowEndSeconds",
+					Metric:         "mock_Metric",
 					Throughput:     "mock_Throughput",
 					AggType:        "mock_AggType",
 					AlgoType:       "mock_AlgoType",
+					AlgoParams:     "mock_AlgoParams",
 					AlgoCalc:       "mock_AlgoCalc",
+					AlgoVerdicts:   "mock_AlgoVerdicts",
 					Anomaly:        "mock_Anomaly",
 				}},
 			},
@@ -286,8 +326,8 @@ func Test_getTadetectorResult(t *testing.T) {
 			id:    "tad-3",
 			query: aggTadPodLabelQuery,
 			returnedRow: sqlmock.NewRows([]string{
-				"Id", "PodNamespace", "PodLabels", "Direction", "FlowEndSeconds", "Throughput", "AggType", "AlgoType", "AlgoCalc", "Anomaly"}).
-				AddRow("mock_Id", "mock_PodNamespace", "mock_PodLabels", "mock_Direction", "mock_FlowEndSeconds", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoCalc", "mock_Anomaly"),
+				"Id", "PodNamespace", "PodLabels", "Direction", "FlowEndSeconds", "Metric", "Throughput", "AggType", "AlgoType", "AlgoParams", "AlgoCalc", "AlgoVerdicts", "Anomaly"}).
+				AddRow("mock_Id", "mock_PodNamespace", "mock_PodLabels", "mock_Direction", "mock_FlowEndSeconds", "mock_Metric", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoParams", "mock_AlgoCalc", "mock_AlgoVerdicts", "mock_Anomaly"),
 			expectedResult: &v1alpha1.ThroughputAnomalyDetector{
 				Stats: []v1alpha1.ThroughputAnomalyDetectorStats{{
 					Id:             "mock_Id",
@@ -295,10 +335,13 @@ func Test_getTadetectorResult(t *testing.T) {
 					PodLabels:      "mock_PodLabels",
 					Direction:      "mock_Direction",
 					FlowEndSeconds: "mock_FlowEndSeconds",
+					Metric:         "mock_Metric",
 					Throughput:     "mock_Throughput",
 					AggType:        "mock_AggType",
 					AlgoType:       "mock_AlgoType",
+					AlgoParams:     "mock_AlgoParams",
 					AlgoCalc:       "mock_AlgoCalc",
+					AlgoVerdicts:   "mock_AlgoVerdicts",
 					Anomaly:        "mock_Anomaly",
 				}},
 			},
@@ -309,8 +352,8 @@ func Test_getTadetectorResult(t *testing.T) {
 			id:    "tad-4",
 			query: aggTadPodNameQuery,
 			returnedRow: sqlmock.NewRows([]string{
-				"Id", "PodNamespace", "PodName", "Direction", "FlowEndSeconds", "Throughput", "AggType", "AlgoType", "AlgoCalc", "Anomaly"}).
-				AddRow("mock_Id", "mock_PodNamespace", "mock_PodName", "mock_Direction", "mock_FlowEndSeconds", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoCalc", "mock_Anomaly"),
+				"Id", "PodNamespace", "PodName", "Direction", "FlowEndSeconds", "Metric", "Throughput", "AggType", "AlgoType", "AlgoParams", "AlgoCalc", "AlgoVerdicts", "Anomaly"}).
+				AddRow("mock_Id", "mock_PodNamespace", "mock_PodName", "mock_Direction", "mock_FlowEndSeconds", "mock_Metric", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoParams", "mock_AlgoCalc", "mock_AlgoVerdicts", "mock_Anomaly"),
 			expectedResult: &v1alpha1.ThroughputAnomalyDetector{
 				Stats: []v1alpha1.ThroughputAnomalyDetectorStats{{
 					Id:             "mock_Id",
@@ -318,10 +361,13 @@ func Test_getTadetectorResult(t *testing.T) {
 					PodName:        "mock_PodName",
 					Direction:      "mock_Direction",
 					FlowEndSeconds: "mock_FlowEndSeconds",
+					Metric:         "mock_Metric",
 					Throughput:     "mock_Throughput",
 					AggType:        "mock_AggType",
 					AlgoType:       "mock_AlgoType",
+					AlgoParams:     "mock_AlgoParams",
 					AlgoCalc:       "mock_AlgoCalc",
+					AlgoVerdicts:   "mock_AlgoVerdicts",
 					Anomaly:        "mock_Anomaly",
 				}},
 			},
@@ -332,22 +378,75 @@ func Test_getTadetectorResult(t *testing.T) {
 			id:    "tad-5",
 			query: aggTadSvcQuery,
 			returnedRow: sqlmock.NewRows([]string{
-				"Id", "DestinationServicePortName", "FlowEndSeconds", "Throughput", "AggType", "AlgoType", "AlgoCalc", "Anomaly"}).
-				AddRow("mock_Id", "mock_DestinationServicePortName", "mock_FlowEndSeconds", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoCalc", "mock_Anomaly"),
+				"Id", "DestinationServicePortName", "FlowEndSeconds", "Metric", "Throughput", "AggType", "AlgoType", "AlgoParams", "AlgoCalc", "AlgoVerdicts", "Anomaly"}).
+				AddRow("mock_Id", "mock_DestinationServicePortName", "mock_FlowEndSeconds", "mock_Metric", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoParams", "mock_AlgoCalc", "mock_AlgoVerdicts", "mock_Anomaly"),
 			expectedResult: &v1alpha1.ThroughputAnomalyDetector{
 				Stats: []v1alpha1.ThroughputAnomalyDetectorStats{{
 					Id:                         "mock_Id",
 					DestinationServicePortName: "mock_DestinationServicePortName",
 					FlowEndSeconds:             "mock_FlowEndSeconds",
+					Metric:                     "mock_Metric",
 					Throughput:                 "mock_Throughput",
 					AggType:                    "mock_AggType",
 					AlgoType:                   "mock_AlgoType",
+					AlgoParams:                 "mock_AlgoParams",
 					AlgoCalc:                   "mock_AlgoCalc",
+					AlgoVerdicts:               "mock_AlgoVerdicts",
 					Anomaly:                    "mock_Anomaly",
 				}},
 			},
 			expecterr: nil,
 		},
+		{
+			name:  "Get aggtadquery node result",
+			id:    "tad-6",
+			query: aggTadNodeQuery,
+			returnedRow: sqlmock.NewRows([]string{
+				"Id", "SourceNodeName", "DestinationNodeName", "FlowEndSeconds", "Metric", "Throughput", "AggType", "AlgoType", "AlgoParams", "AlgoCalc", "AlgoVerdicts", "Anomaly"}).
+				AddRow("mock_Id", "mock_SourceNodeName", "mock_DestinationNodeName", "mock_FlowEndSeconds", "mock_Metric", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoParams", "mock_AlgoCalc", "mock_AlgoVerdicts", "mock_Anomaly"),
+			expectedResult: &v1alpha1.ThroughputAnomalyDetector{
+				Stats: []v1alpha1.ThroughputAnomalyDetectorStats{{
+					Id:                  "mock_Id",
+					SourceNodeName:      "mock_SourceNodeName",
+					DestinationNodeName: "mock_DestinationNodeName",
+					FlowEndSeconds:      "mock_FlowEndSeconds",
+					Metric:              "mock_Metric",
+					Throughput:          "mock_Throughput",
+					AggType:             "mock_AggType",
+					AlgoType:            "mock_AlgoType",
+					AlgoParams:          "mock_AlgoParams",
+					AlgoCalc:            "mock_AlgoCalc",
+					AlgoVerdicts:        "mock_AlgoVerdicts",
+					Anomaly:             "mock_Anomaly",
+				}},
+			},
+			expecterr: nil,
+		},
+		{
+			name:  "Get aggtadquery namespace result",
+			id:    "tad-7",
+			query: aggTadNamespaceQuery,
+			returnedRow: sqlmock.NewRows([]string{
+				"Id", "PodNamespace", "Direction", "FlowEndSeconds", "Metric", "Throughput", "AggType", "AlgoType", "AlgoParams", "AlgoCalc", "AlgoVerdicts", "Anomaly"}).
+				AddRow("mock_Id", "mock_PodNamespace", "mock_Direction", "mock_FlowEndSeconds", "mock_Metric", "mock_Throughput", "mock_AggType", "mock_AlgoType", "mock_AlgoParams", "mock_AlgoCalc", "mock_AlgoVerdicts", "mock_Anomaly"),
+			expectedResult: &v1alpha1.ThroughputAnomalyDetector{
+				Stats: []v1alpha1.ThroughputAnomalyDetectorStats{{
+					Id:             "mock_Id",
+					PodNamespace:   "mock_PodNamespace",
+					Direction:      "mock_Direction",
+					FlowEndSeconds: "mock_FlowEndSeconds",
+					Metric:         "mock_Metric",
+					Throughput:     "mock_Throughput",
+					AggType:        "mock_AggType",
+					AlgoType:       "mock_AlgoType",
+					AlgoParams:     "mock_AlgoParams",
+					AlgoCalc:       "mock_AlgoCalc",
+					AlgoVerdicts:   "mock_AlgoVerdicts",
+					Anomaly:        "mock_Anomaly",
+				}},
+			},
+			expecterr: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -370,6 +469,10 @@ func Test_getTadetectorResult(t *testing.T) {
 				tad.PodName = "mock_PodName"
 			case aggTadSvcQuery:
 				tad.AggregatedFlow = "svc"
+			case aggTadNodeQuery:
+				tad.AggregatedFlow = "node"
+			case aggTadNamespaceQuery:
+				tad.AggregatedFlow = "namespace"
 			}
 			err = r.getTADetectorResult(tt.id, &tad)
 			assert.Equal(t, tt.expecterr, err)
@@ -382,9 +485,19 @@ func (c *fakeQuerier) GetThroughputAnomalyDetector(namespace, name string) (*crd
 	if name == "non-existent-tad" {
 		return nil, fmt.Errorf("not found")
 	}
+	if name == "running-tad" {
+		return &crdv1alpha1.ThroughputAnomalyDetector{
+			Status: crdv1alpha1.ThroughputAnomalyDetectorStatus{
+				State: crdv1alpha1.ThroughputAnomalyDetectorStateRunning,
+			},
+		}, nil
+	}
 	return &crdv1alpha1.ThroughputAnomalyDetector{
 		Spec: crdv1alpha1.ThroughputAnomalyDetectorSpec{
 			JobType: "TAD",
+			AlgoParams: &crdv1alpha1.ThroughputAnomalyDetectorAlgoParams{
+				Sensitivity: 2,
+			},
 		},
 		Status: crdv1alpha1.ThroughputAnomalyDetectorStatus{
 			State: crdv1alpha1.ThroughputAnomalyDetectorStateCompleted,
@@ -396,6 +509,10 @@ func (c *fakeQuerier) CreateThroughputAnomalyDetector(namespace string, throughp
 	return nil, nil
 }
 
+func (c *fakeQuerier) EstimateThroughputAnomalyDetector(job *crdv1alpha1.ThroughputAnomalyDetector) (*crdv1alpha1.JobInputEstimate, error) {
+	return &crdv1alpha1.JobInputEstimate{Rows: 1000, Bytes: 2048}, nil
+}
+
 func (c *fakeQuerier) DeleteThroughputAnomalyDetector(namespace, name string) error {
 	return nil
 }
@@ -406,3 +523,74 @@ func (c *fakeQuerier) ListThroughputAnomalyDetector(namespace string) ([]*crdv1a
 		{ObjectMeta: v1.ObjectMeta{Name: "tad-2"}},
 	}, nil
 }
+
+func (c *fakeQuerier) ListAnomalySuppression(namespace string) ([]*crdv1alpha1.AnomalySuppression, error) {
+	return c.suppressions, nil
+}
+
+func TestREST_FilterSuppressedStats(t *testing.T) {
+	backup := v1alpha1.ThroughputAnomalyDetectorStats{Id: "tad-1", PodNamespace: "ns", PodName: "backup", FlowEndSeconds: "2023-03-01T02:30:00Z", AggType: "pod", Anomaly: "true"}
+	other := v1alpha1.ThroughputAnomalyDetectorStats{Id: "tad-1", PodNamespace: "ns", PodName: "web", FlowEndSeconds: "2023-03-01T02:30:00Z", AggType: "pod", Anomaly: "true"}
+	nightlyBackup := &crdv1alpha1.AnomalySuppression{
+		Spec: crdv1alpha1.AnomalySuppressionSpec{
+			PodNamespace:   "ns",
+			PodName:        "backup",
+			DailyStartTime: "02:00",
+			DailyEndTime:   "03:00",
+		},
+	}
+	tests := []struct {
+		name          string
+		suppressions  []*crdv1alpha1.AnomalySuppression
+		stats         []v1alpha1.ThroughputAnomalyDetectorStats
+		expectedStats []v1alpha1.ThroughputAnomalyDetectorStats
+	}{
+		{
+			name:          "no suppression",
+			stats:         []v1alpha1.ThroughputAnomalyDetectorStats{backup, other},
+			expectedStats: []v1alpha1.ThroughputAnomalyDetectorStats{backup, other},
+		},
+		{
+			name:          "suppressed anomaly",
+			suppressions:  []*crdv1alpha1.AnomalySuppression{nightlyBackup},
+			stats:         []v1alpha1.ThroughputAnomalyDetectorStats{backup, other},
+			expectedStats: []v1alpha1.ThroughputAnomalyDetectorStats{other},
+		},
+		{
+			name:          "all anomalies suppressed",
+			suppressions:  []*crdv1alpha1.AnomalySuppression{nightlyBackup},
+			stats:         []v1alpha1.ThroughputAnomalyDetectorStats{backup},
+			expectedStats: []v1alpha1.ThroughputAnomalyDetectorStats{{Id: "tad-1", Anomaly: noAnomalyDetected}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewREST(&fakeQuerier{suppressions: tt.suppressions})
+			tad := &v1alpha1.ThroughputAnomalyDetector{Stats: tt.stats}
+			assert.NoError(t, r.filterSuppressedStats(tad))
+			assert.Equal(t, tt.expectedStats, tad.Stats)
+		})
+	}
+}
+
+func TestCopyFlowFilter(t *testing.T) {
+	assert.Nil(t, copyFlowFilterFromCRD(nil))
+	assert.Nil(t, copyFlowFilterToCRD(nil))
+	filter := &v1alpha1.ThroughputAnomalyDetectorFlowFilter{
+		Source: &v1alpha1.ThroughputAnomalyDetectorFlowPeer{
+			NamespaceSelector: &v1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+		},
+		Destination: &v1alpha1.ThroughputAnomalyDetectorFlowPeer{
+			PodSelector: &v1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			CIDRs:       []string{"10.0.0.0/8"},
+		},
+		Ports:     []v1alpha1.ThroughputAnomalyDetectorPortRange{{Start: 80}, {Start: 8000, End: 8080}},
+		Protocols: []string{"TCP"},
+		FlowTypes: []string{"pod_to_external"},
+	}
+	crd := copyFlowFilterToCRD(filter)
+	assert.Equal(t, "prod", crd.Source.NamespaceSelector.MatchLabels["env"])
+	assert.Nil(t, crd.Source.PodSelector)
+	assert.Equal(t, []crdv1alpha1.ThroughputAnomalyDetectorPortRange{{Start: 80}, {Start: 8000, End: 8080}}, crd.Ports)
+	assert.Equal(t, filter, copyFlowFilterFromCRD(crd))
+}
@@ -0,0 +1,137 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomalydetector
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/internalversion"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	"antrea.io/theia/pkg/apis/intelligence/v1alpha2"
+)
+
+func TestV1alpha2REST_Get(t *testing.T) {
+	tests := []struct {
+		name         string
+		row          []driver.Value
+		expectErr    string
+		expectResult *v1alpha2.ThroughputAnomalyDetector
+	}{
+		{
+			name: "Successful Get case",
+			row:  []driver.Value{"tad-1", "10.10.1.25", "58076", "10.10.1.33", "5201", "2022-08-11T06:26:54Z", "2022-08-11T08:06:54Z", "throughput", "4.005703059e+09", "None", "EWMA", `{"ewmaAlpha": 0.5, "sensitivity": 2}`, "1.0001208441920074e+10", "", "true"},
+			expectResult: &v1alpha2.ThroughputAnomalyDetector{
+				Type: "TAD",
+				AlgoParams: &v1alpha2.ThroughputAnomalyDetectorAlgoParams{
+					Sensitivity: 2,
+				},
+				Status: v1alpha2.ThroughputAnomalyDetectorStatus{
+					State: crdv1alpha1.ThroughputAnomalyDetectorStateCompleted,
+				},
+				Stats: []v1alpha2.ThroughputAnomalyDetectorStats{{
+					Id:                       "tad-1",
+					SourceIP:                 "10.10.1.25",
+					SourceTransportPort:      58076,
+					DestinationIP:            "10.10.1.33",
+					DestinationTransportPort: 5201,
+					FlowStartSeconds:         v1.NewTime(time.Date(2022, 8, 11, 6, 26, 54, 0, time.UTC)),
+					FlowEndSeconds:           v1.NewTime(time.Date(2022, 8, 11, 8, 6, 54, 0, time.UTC)),
+					Metric:                   "throughput",
+					Throughput:               4.005703059e+09,
+					AggType:                  "None",
+					AlgoType:                 "EWMA",
+					AlgoParams: &v1alpha2.ThroughputAnomalyDetectorAlgoParams{
+						Sensitivity: 2,
+						EWMAAlpha:   0.5,
+					},
+					AlgoCalc: 1.0001208441920074e+10,
+					Anomaly:  true,
+				}},
+			},
+		},
+		{
+			name:      "Unsuccessful Get case conversion error",
+			row:       []driver.Value{"tad-1", "10.10.1.25", "port", "10.10.1.33", "5201", "2022-08-11T06:26:54Z", "2022-08-11T08:06:54Z", "throughput", "4.005703059e+09", "None", "EWMA", "", "1.0001208441920074e+10", "", "true"},
+			expectErr: `invalid sourceTransportPort "port"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			defer db.Close()
+			resultRows := sqlmock.NewRows([]string{
+				"Id", "SourceIP", "SourceTransportPort", "DestinationIP", "DestinationTransportPort", "FlowStartSeconds", "FlowEndSeconds", "Metric", "Throughput", "AggType", "AlgoType", "AlgoParams", "AlgoCalc", "AlgoVerdicts", "Anomaly"}).
+				AddRow(tt.row...)
+			mock.ExpectQuery(queryMap[tadQuery]).WillReturnRows(resultRows)
+			setupClickHouseConnection = func(client kubernetes.Interface) (connect *sql.DB, err error) {
+				return db, nil
+			}
+			r := NewV1alpha2REST(NewREST(&fakeQuerier{}))
+			tad, err := r.Get(context.TODO(), "tad-1", &v1.GetOptions{})
+			if tt.expectErr != "" {
+				assert.ErrorContains(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectResult, tad)
+		})
+	}
+}
+
+func TestV1alpha2REST_Create(t *testing.T) {
+	r := NewV1alpha2REST(NewREST(&fakeQuerier{}))
+	result, err := r.Create(context.TODO(), &v1alpha2.ThroughputAnomalyDetector{
+		ObjectMeta: v1.ObjectMeta{Name: "non-existent-tad"},
+	}, nil, &v1.CreateOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, &v1.Status{Status: v1.StatusSuccess}, result)
+
+	result, err = r.Create(context.TODO(), &v1alpha2.ThroughputAnomalyDetector{
+		ObjectMeta: v1.ObjectMeta{Name: "non-existent-tad"},
+	}, nil, &v1.CreateOptions{DryRun: []string{v1.DryRunAll}})
+	require.NoError(t, err)
+	assert.Equal(t, &v1alpha2.ThroughputAnomalyDetector{
+		ObjectMeta: v1.ObjectMeta{Name: "non-existent-tad"},
+		Status: v1alpha2.ThroughputAnomalyDetectorStatus{
+			InputEstimate: &v1alpha2.JobInputEstimate{Rows: 1000, Bytes: 2048},
+		},
+	}, result)
+
+	_, err = r.Create(context.TODO(), &crdv1alpha1.ThroughputAnomalyDetector{}, nil, &v1.CreateOptions{})
+	assert.ErrorContains(t, err, "not a ThroughputAnomalyDetector object")
+}
+
+func TestV1alpha2REST_List(t *testing.T) {
+	r := NewV1alpha2REST(NewREST(&fakeQuerier{}))
+	itemList, err := r.List(context.TODO(), &internalversion.ListOptions{})
+	require.NoError(t, err)
+	tadList, ok := itemList.(*v1alpha2.ThroughputAnomalyDetectorList)
+	require.True(t, ok)
+	assert.ElementsMatch(t, []v1alpha2.ThroughputAnomalyDetector{
+		{ObjectMeta: v1.ObjectMeta{Name: "tad-1"}},
+		{ObjectMeta: v1.ObjectMeta{Name: "tad-2"}},
+	}, tadList.Items)
+}
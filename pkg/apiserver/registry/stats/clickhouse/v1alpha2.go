@@ -0,0 +1,72 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/registry/rest"
+
+	"antrea.io/theia/pkg/apis/stats/v1alpha1"
+	"antrea.io/theia/pkg/apis/stats/v1alpha2"
+)
+
+// V1alpha2REST implements rest.Storage for the v1alpha2 version of
+// clickhouse. It delegates to REST and converts the ClickHouseStats to
+// v1alpha2.
+type V1alpha2REST struct {
+	rest *REST
+}
+
+var (
+	_ rest.Getter = &V1alpha2REST{}
+)
+
+// NewV1alpha2REST returns a V1alpha2REST object that will work against API
+// services.
+func NewV1alpha2REST(r *REST) *V1alpha2REST {
+	return &V1alpha2REST{rest: r}
+}
+
+func (r *V1alpha2REST) New() runtime.Object {
+	return &v1alpha2.ClickHouseStats{}
+}
+
+func (r *V1alpha2REST) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	obj, err := r.rest.Get(ctx, name, options)
+	if err != nil {
+		return nil, err
+	}
+	var status v1alpha2.ClickHouseStats
+	if err := v1alpha2.Convert_v1alpha1_ClickHouseStats_To_v1alpha2_ClickHouseStats(obj.(*v1alpha1.ClickHouseStats), &status, nil); err != nil {
+		return nil, errors.NewInternalError(fmt.Errorf("error when converting %s: %v", name, err))
+	}
+	return &status, nil
+}
+
+func (r *V1alpha2REST) Destroy() {
+}
+
+func (r *V1alpha2REST) NamespaceScoped() bool {
+	return false
+}
+
+func (r *V1alpha2REST) ConvertToTable(ctx context.Context, obj runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	return rest.NewDefaultTableConvertor(v1alpha2.Resource("clickhouse")).ConvertToTable(ctx, obj, tableOptions)
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+)
+
+const (
+	defaultNPRecommendationJobType    = "initial"
+	defaultNPRecommendationPolicyType = "anp-deny-applied"
+)
+
+// HandleMutationNetworkPolicyRecommendation returns the handler of the
+// mutating webhook of NetworkPolicyRecommendations, which applies defaults to
+// their spec.
+func HandleMutationNetworkPolicyRecommendation() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, mutateNetworkPolicyRecommendation)
+	}
+}
+
+// HandleValidationNetworkPolicyRecommendation returns the handler of the
+// validating webhook of NetworkPolicyRecommendations.
+func HandleValidationNetworkPolicyRecommendation(v JobValidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, func(request *admv1.AdmissionRequest) *admv1.AdmissionResponse {
+			return validateNetworkPolicyRecommendation(v, request)
+		})
+	}
+}
+
+func mutateNetworkPolicyRecommendation(request *admv1.AdmissionRequest) *admv1.AdmissionResponse {
+	var npr crdv1alpha1.NetworkPolicyRecommendation
+	if err := json.Unmarshal(request.Object.Raw, &npr); err != nil {
+		return denied(fmt.Errorf("failed to decode NetworkPolicyRecommendation: %v", err))
+	}
+	if request.Operation == admv1.Update {
+		var oldNPR crdv1alpha1.NetworkPolicyRecommendation
+		if err := json.Unmarshal(request.OldObject.Raw, &oldNPR); err != nil {
+			return denied(fmt.Errorf("failed to decode NetworkPolicyRecommendation: %v", err))
+		}
+		// Defaults could make the spec of a started job differ.
+		if jobStarted(oldNPR.Status.State) {
+			return allowed()
+		}
+	}
+	spec := npr.Spec.DeepCopy()
+	setNetworkPolicyRecommendationDefaults(spec)
+	if equality.Semantic.DeepEqual(spec, &npr.Spec) {
+		return allowed()
+	}
+	return patched(spec)
+}
+
+func setNetworkPolicyRecommendationDefaults(spec *crdv1alpha1.NetworkPolicyRecommendationSpec) {
+	if spec.JobType == "" {
+		spec.JobType = defaultNPRecommendationJobType
+	}
+	if spec.PolicyType == "" {
+		spec.PolicyType = defaultNPRecommendationPolicyType
+	}
+	if spec.ExecutorInstances == 0 {
+		spec.ExecutorInstances = defaultExecutorInstances
+	}
+	if spec.DriverCoreRequest == "" {
+		spec.DriverCoreRequest = defaultDriverCoreRequest
+	}
+	if spec.DriverMemory == "" {
+		spec.DriverMemory = defaultDriverMemory
+	}
+	if spec.ExecutorCoreRequest == "" {
+		spec.ExecutorCoreRequest = defaultExecutorCoreRequest
+	}
+	if spec.ExecutorMemory == "" {
+		spec.ExecutorMemory = defaultExecutorMemory
+	}
+}
+
+func validateNetworkPolicyRecommendation(v JobValidator, request *admv1.AdmissionRequest) *admv1.AdmissionResponse {
+	var npr crdv1alpha1.NetworkPolicyRecommendation
+	if err := json.Unmarshal(request.Object.Raw, &npr); err != nil {
+		return denied(fmt.Errorf("failed to decode NetworkPolicyRecommendation: %v", err))
+	}
+	var started, specUpdated bool
+	if request.Operation == admv1.Update {
+		var oldNPR crdv1alpha1.NetworkPolicyRecommendation
+		if err := json.Unmarshal(request.OldObject.Raw, &oldNPR); err != nil {
+			return denied(fmt.Errorf("failed to decode NetworkPolicyRecommendation: %v", err))
+		}
+		started = jobStarted(oldNPR.Status.State)
+		specUpdated = !equality.Semantic.DeepEqual(npr.Spec, oldNPR.Spec)
+	}
+	if err := validateJob(v, "NetworkPolicyRecommendation", request.Operation, &npr, started, specUpdated); err != nil {
+		return denied(err)
+	}
+	return allowed()
+}
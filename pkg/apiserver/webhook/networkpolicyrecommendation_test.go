@@ -0,0 +1,179 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+)
+
+func newNPRecommendation(spec crdv1alpha1.NetworkPolicyRecommendationSpec, state string) *crdv1alpha1.NetworkPolicyRecommendation {
+	return &crdv1alpha1.NetworkPolicyRecommendation{
+		ObjectMeta: metav1.ObjectMeta{Name: "pr-e292395c-3de1-11ed-b878-0242ac120002", Namespace: "flow-visibility"},
+		Spec:       spec,
+		Status:     crdv1alpha1.NetworkPolicyRecommendationStatus{State: state},
+	}
+}
+
+func TestMutateNetworkPolicyRecommendation(t *testing.T) {
+	fullSpec := crdv1alpha1.NetworkPolicyRecommendationSpec{
+		JobType:             "subsequent",
+		PolicyType:          "k8s-np",
+		ExecutorInstances:   2,
+		DriverCoreRequest:   "500m",
+		DriverMemory:        "1G",
+		ExecutorCoreRequest: "500m",
+		ExecutorMemory:      "1G",
+	}
+	testCases := []struct {
+		name         string
+		operation    admv1.Operation
+		npr          *crdv1alpha1.NetworkPolicyRecommendation
+		oldNPR       *crdv1alpha1.NetworkPolicyRecommendation
+		expectedSpec *crdv1alpha1.NetworkPolicyRecommendationSpec
+	}{
+		{
+			name:      "Create with empty spec",
+			operation: admv1.Create,
+			npr:       newNPRecommendation(crdv1alpha1.NetworkPolicyRecommendationSpec{Limit: 10}, ""),
+			expectedSpec: &crdv1alpha1.NetworkPolicyRecommendationSpec{
+				JobType:             "initial",
+				Limit:               10,
+				PolicyType:          "anp-deny-applied",
+				ExecutorInstances:   1,
+				DriverCoreRequest:   "200m",
+				DriverMemory:        "512M",
+				ExecutorCoreRequest: "200m",
+				ExecutorMemory:      "512M",
+			},
+		},
+		{
+			name:      "Create with full spec",
+			operation: admv1.Create,
+			npr:       newNPRecommendation(fullSpec, ""),
+		},
+		{
+			name:      "Update new job",
+			operation: admv1.Update,
+			npr:       newNPRecommendation(crdv1alpha1.NetworkPolicyRecommendationSpec{JobType: "subsequent", PolicyType: "k8s-np"}, "NEW"),
+			oldNPR:    newNPRecommendation(fullSpec, "NEW"),
+			expectedSpec: &crdv1alpha1.NetworkPolicyRecommendationSpec{
+				JobType:             "subsequent",
+				PolicyType:          "k8s-np",
+				ExecutorInstances:   1,
+				DriverCoreRequest:   "200m",
+				DriverMemory:        "512M",
+				ExecutorCoreRequest: "200m",
+				ExecutorMemory:      "512M",
+			},
+		},
+		{
+			name:      "Update started job",
+			operation: admv1.Update,
+			npr:       newNPRecommendation(crdv1alpha1.NetworkPolicyRecommendationSpec{}, "RUNNING"),
+			oldNPR:    newNPRecommendation(crdv1alpha1.NetworkPolicyRecommendationSpec{}, "RUNNING"),
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var oldObject interface{}
+			if tt.oldNPR != nil {
+				oldObject = tt.oldNPR
+			}
+			response := review(t, HandleMutationNetworkPolicyRecommendation(), tt.operation, tt.npr, oldObject)
+			assert.True(t, response.Allowed)
+			if tt.expectedSpec == nil {
+				assert.Nil(t, response.Patch)
+				return
+			}
+			var spec crdv1alpha1.NetworkPolicyRecommendationSpec
+			decodePatch(t, response, &spec)
+			assert.Equal(t, *tt.expectedSpec, spec)
+		})
+	}
+}
+
+func TestValidateNetworkPolicyRecommendation(t *testing.T) {
+	spec := crdv1alpha1.NetworkPolicyRecommendationSpec{JobType: "initial", PolicyType: "anp-deny-applied"}
+	updatedSpec := crdv1alpha1.NetworkPolicyRecommendationSpec{JobType: "initial", PolicyType: "k8s-np"}
+	testCases := []struct {
+		name        string
+		validator   JobValidator
+		operation   admv1.Operation
+		npr         *crdv1alpha1.NetworkPolicyRecommendation
+		oldNPR      *crdv1alpha1.NetworkPolicyRecommendation
+		expectedErr string
+	}{
+		{
+			name:      "Create valid job",
+			validator: &fakeJobValidator{},
+			operation: admv1.Create,
+			npr:       newNPRecommendation(spec, ""),
+		},
+		{
+			name:        "Create invalid job",
+			validator:   &fakeJobValidator{err: fmt.Errorf("invalid request: recommendation type should be 'initial' or 'subsequent'")},
+			operation:   admv1.Create,
+			npr:         newNPRecommendation(spec, ""),
+			expectedErr: "invalid request: recommendation type should be 'initial' or 'subsequent'",
+		},
+		{
+			name:      "Update status of started job",
+			validator: &fakeJobValidator{},
+			operation: admv1.Update,
+			npr:       newNPRecommendation(spec, "COMPLETED"),
+			oldNPR:    newNPRecommendation(spec, "RUNNING"),
+		},
+		{
+			name:      "Update spec of new job",
+			validator: &fakeJobValidator{},
+			operation: admv1.Update,
+			npr:       newNPRecommendation(updatedSpec, "NEW"),
+			oldNPR:    newNPRecommendation(spec, "NEW"),
+		},
+		{
+			name:        "Update spec of started job",
+			validator:   &fakeJobValidator{},
+			operation:   admv1.Update,
+			npr:         newNPRecommendation(updatedSpec, "RUNNING"),
+			oldNPR:      newNPRecommendation(spec, "RUNNING"),
+			expectedErr: "the spec of NetworkPolicyRecommendation pr-e292395c-3de1-11ed-b878-0242ac120002 cannot be updated once its job has started",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var oldObject interface{}
+			if tt.oldNPR != nil {
+				oldObject = tt.oldNPR
+			}
+			response := review(t, HandleValidationNetworkPolicyRecommendation(tt.validator), tt.operation, tt.npr, oldObject)
+			if tt.expectedErr == "" {
+				assert.True(t, response.Allowed)
+				return
+			}
+			assert.False(t, response.Allowed)
+			assert.Equal(t, tt.expectedErr, response.Result.Message)
+			assert.Equal(t, metav1.StatusReasonInvalid, response.Result.Reason)
+			assert.Equal(t, int32(http.StatusUnprocessableEntity), response.Result.Code)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+)
+
+// HandleMutationThroughputAnomalyDetector returns the handler of the
+// mutating webhook of ThroughputAnomalyDetectors, which applies defaults to
+// their spec.
+func HandleMutationThroughputAnomalyDetector() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, mutateThroughputAnomalyDetector)
+	}
+}
+
+// HandleValidationThroughputAnomalyDetector returns the handler of the
+// validating webhook of ThroughputAnomalyDetectors.
+func HandleValidationThroughputAnomalyDetector(v JobValidator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		serve(w, r, func(request *admv1.AdmissionRequest) *admv1.AdmissionResponse {
+			return validateThroughputAnomalyDetector(v, request)
+		})
+	}
+}
+
+func mutateThroughputAnomalyDetector(request *admv1.AdmissionRequest) *admv1.AdmissionResponse {
+	var tad crdv1alpha1.ThroughputAnomalyDetector
+	if err := json.Unmarshal(request.Object.Raw, &tad); err != nil {
+		return denied(fmt.Errorf("failed to decode ThroughputAnomalyDetector: %v", err))
+	}
+	if request.Operation == admv1.Update {
+		var oldTAD crdv1alpha1.ThroughputAnomalyDetector
+		if err := json.Unmarshal(request.OldObject.Raw, &oldTAD); err != nil {
+			return denied(fmt.Errorf("failed to decode ThroughputAnomalyDetector: %v", err))
+		}
+		// Defaults could make the spec of a started job differ.
+		if jobStarted(oldTAD.Status.State) {
+			return allowed()
+		}
+	}
+	spec := tad.Spec.DeepCopy()
+	setThroughputAnomalyDetectorDefaults(spec)
+	if equality.Semantic.DeepEqual(spec, &tad.Spec) {
+		return allowed()
+	}
+	return patched(spec)
+}
+
+func setThroughputAnomalyDetectorDefaults(spec *crdv1alpha1.ThroughputAnomalyDetectorSpec) {
+	if spec.ExecutorInstances == 0 {
+		spec.ExecutorInstances = defaultExecutorInstances
+	}
+	if spec.DriverCoreRequest == "" {
+		spec.DriverCoreRequest = defaultDriverCoreRequest
+	}
+	if spec.DriverMemory == "" {
+		spec.DriverMemory = defaultDriverMemory
+	}
+	if spec.ExecutorCoreRequest == "" {
+		spec.ExecutorCoreRequest = defaultExecutorCoreRequest
+	}
+	if spec.ExecutorMemory == "" {
+		spec.ExecutorMemory = defaultExecutorMemory
+	}
+}
+
+func validateThroughputAnomalyDetector(v JobValidator, request *admv1.AdmissionRequest) *admv1.AdmissionResponse {
+	var tad crdv1alpha1.ThroughputAnomalyDetector
+	if err := json.Unmarshal(request.Object.Raw, &tad); err != nil {
+		return denied(fmt.Errorf("failed to decode ThroughputAnomalyDetector: %v", err))
+	}
+	var started, specUpdated bool
+	if request.Operation == admv1.Update {
+		var oldTAD crdv1alpha1.ThroughputAnomalyDetector
+		if err := json.Unmarshal(request.OldObject.Raw, &oldTAD); err != nil {
+			return denied(fmt.Errorf("failed to decode ThroughputAnomalyDetector: %v", err))
+		}
+		started = jobStarted(oldTAD.Status.State)
+		specUpdated = !equality.Semantic.DeepEqual(tad.Spec, oldTAD.Spec)
+	}
+	if err := validateJob(v, "ThroughputAnomalyDetector", request.Operation, &tad, started, specUpdated); err != nil {
+		return denied(err)
+	}
+	return allowed()
+}
@@ -0,0 +1,175 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+)
+
+func newTADetector(spec crdv1alpha1.ThroughputAnomalyDetectorSpec, state string) *crdv1alpha1.ThroughputAnomalyDetector {
+	return &crdv1alpha1.ThroughputAnomalyDetector{
+		ObjectMeta: metav1.ObjectMeta{Name: "tad-e292395c-3de1-11ed-b878-0242ac120002", Namespace: "flow-visibility"},
+		Spec:       spec,
+		Status:     crdv1alpha1.ThroughputAnomalyDetectorStatus{State: state},
+	}
+}
+
+func TestMutateThroughputAnomalyDetector(t *testing.T) {
+	fullSpec := crdv1alpha1.ThroughputAnomalyDetectorSpec{
+		JobType:             "EWMA",
+		ExecutorInstances:   2,
+		DriverCoreRequest:   "500m",
+		DriverMemory:        "1G",
+		ExecutorCoreRequest: "500m",
+		ExecutorMemory:      "1G",
+	}
+	testCases := []struct {
+		name         string
+		operation    admv1.Operation
+		tad          *crdv1alpha1.ThroughputAnomalyDetector
+		oldTAD       *crdv1alpha1.ThroughputAnomalyDetector
+		expectedSpec *crdv1alpha1.ThroughputAnomalyDetectorSpec
+	}{
+		{
+			name:      "Create with empty spec",
+			operation: admv1.Create,
+			tad:       newTADetector(crdv1alpha1.ThroughputAnomalyDetectorSpec{JobType: "ARIMA"}, ""),
+			expectedSpec: &crdv1alpha1.ThroughputAnomalyDetectorSpec{
+				JobType:             "ARIMA",
+				ExecutorInstances:   1,
+				DriverCoreRequest:   "200m",
+				DriverMemory:        "512M",
+				ExecutorCoreRequest: "200m",
+				ExecutorMemory:      "512M",
+			},
+		},
+		{
+			name:      "Create with full spec",
+			operation: admv1.Create,
+			tad:       newTADetector(fullSpec, ""),
+		},
+		{
+			name:      "Update new job",
+			operation: admv1.Update,
+			tad:       newTADetector(crdv1alpha1.ThroughputAnomalyDetectorSpec{JobType: "DBSCAN"}, "NEW"),
+			oldTAD:    newTADetector(fullSpec, "NEW"),
+			expectedSpec: &crdv1alpha1.ThroughputAnomalyDetectorSpec{
+				JobType:             "DBSCAN",
+				ExecutorInstances:   1,
+				DriverCoreRequest:   "200m",
+				DriverMemory:        "512M",
+				ExecutorCoreRequest: "200m",
+				ExecutorMemory:      "512M",
+			},
+		},
+		{
+			name:      "Update started job",
+			operation: admv1.Update,
+			tad:       newTADetector(crdv1alpha1.ThroughputAnomalyDetectorSpec{}, "RUNNING"),
+			oldTAD:    newTADetector(crdv1alpha1.ThroughputAnomalyDetectorSpec{}, "RUNNING"),
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var oldObject interface{}
+			if tt.oldTAD != nil {
+				oldObject = tt.oldTAD
+			}
+			response := review(t, HandleMutationThroughputAnomalyDetector(), tt.operation, tt.tad, oldObject)
+			assert.True(t, response.Allowed)
+			if tt.expectedSpec == nil {
+				assert.Nil(t, response.Patch)
+				return
+			}
+			var spec crdv1alpha1.ThroughputAnomalyDetectorSpec
+			decodePatch(t, response, &spec)
+			assert.Equal(t, *tt.expectedSpec, spec)
+		})
+	}
+}
+
+func TestValidateThroughputAnomalyDetector(t *testing.T) {
+	spec := crdv1alpha1.ThroughputAnomalyDetectorSpec{JobType: "EWMA"}
+	updatedSpec := crdv1alpha1.ThroughputAnomalyDetectorSpec{JobType: "ARIMA"}
+	testCases := []struct {
+		name        string
+		validator   JobValidator
+		operation   admv1.Operation
+		tad         *crdv1alpha1.ThroughputAnomalyDetector
+		oldTAD      *crdv1alpha1.ThroughputAnomalyDetector
+		expectedErr string
+	}{
+		{
+			name:      "Create valid job",
+			validator: &fakeJobValidator{},
+			operation: admv1.Create,
+			tad:       newTADetector(spec, ""),
+		},
+		{
+			name:        "Create invalid job",
+			validator:   &fakeJobValidator{err: fmt.Errorf("invalid request: Throughput Anomaly Detector algorithm type should be 'EWMA' or 'ARIMA' or 'DBSCAN' or 'ENSEMBLE'")},
+			operation:   admv1.Create,
+			tad:         newTADetector(spec, ""),
+			expectedErr: "invalid request: Throughput Anomaly Detector algorithm type should be 'EWMA' or 'ARIMA' or 'DBSCAN' or 'ENSEMBLE'",
+		},
+		{
+			name:      "Update status of started job",
+			validator: &fakeJobValidator{},
+			operation: admv1.Update,
+			tad:       newTADetector(spec, "COMPLETED"),
+			oldTAD:    newTADetector(spec, "RUNNING"),
+		},
+		{
+			name:      "Update spec of new job",
+			validator: &fakeJobValidator{},
+			operation: admv1.Update,
+			tad:       newTADetector(updatedSpec, "NEW"),
+			oldTAD:    newTADetector(spec, "NEW"),
+		},
+		{
+			name:        "Update spec of started job",
+			validator:   &fakeJobValidator{},
+			operation:   admv1.Update,
+			tad:         newTADetector(updatedSpec, "RUNNING"),
+			oldTAD:      newTADetector(spec, "RUNNING"),
+			expectedErr: "the spec of ThroughputAnomalyDetector tad-e292395c-3de1-11ed-b878-0242ac120002 cannot be updated once its job has started",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var oldObject interface{}
+			if tt.oldTAD != nil {
+				oldObject = tt.oldTAD
+			}
+			response := review(t, HandleValidationThroughputAnomalyDetector(tt.validator), tt.operation, tt.tad, oldObject)
+			if tt.expectedErr == "" {
+				assert.True(t, response.Allowed)
+				return
+			}
+			assert.False(t, response.Allowed)
+			assert.Equal(t, tt.expectedErr, response.Result.Message)
+			assert.Equal(t, metav1.StatusReasonInvalid, response.Result.Reason)
+			assert.Equal(t, int32(http.StatusUnprocessableEntity), response.Result.Code)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook implements the admission webhooks of the Theia CRDs, which
+// are served by theia-manager.
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	admv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	controllerutil "antrea.io/theia/pkg/controller"
+)
+
+const (
+	// Default Spark resources of the jobs, which match the defaults of the
+	// theia CLI.
+	defaultExecutorInstances   = 1
+	defaultDriverCoreRequest   = "200m"
+	defaultDriverMemory        = "512M"
+	defaultExecutorCoreRequest = "200m"
+	defaultExecutorMemory      = "512M"
+)
+
+// JobValidator validates the name and the spec of analytics jobs. It is
+// implemented by the controllers of the jobs.
+type JobValidator interface {
+	// ValidateJob returns an error if a job cannot run because of its name
+	// or its spec.
+	ValidateJob(job metav1.Object) error
+}
+
+// admitFunc admits an AdmissionRequest and returns the AdmissionResponse.
+// Its UID is set by serve.
+type admitFunc func(request *admv1.AdmissionRequest) *admv1.AdmissionResponse
+
+// serve decodes the AdmissionReview of an admission webhook request and
+// replies with the AdmissionResponse returned by admit.
+func serve(w http.ResponseWriter, r *http.Request, admit admitFunc) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+		http.Error(w, fmt.Sprintf("invalid Content-Type %s, expected application/json", contentType), http.StatusUnsupportedMediaType)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	var review admv1.AdmissionReview
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, fmt.Sprintf("failed to decode AdmissionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	response := admit(review.Request)
+	response.UID = review.Request.UID
+	review.Response = response
+	review.Request = nil
+	respBytes, err := json.Marshal(review)
+	if err != nil {
+		klog.ErrorS(err, "Failed to encode AdmissionReview")
+		http.Error(w, fmt.Sprintf("failed to encode AdmissionReview: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(respBytes); err != nil {
+		klog.ErrorS(err, "Failed to write AdmissionReview response")
+	}
+}
+
+func allowed() *admv1.AdmissionResponse {
+	return &admv1.AdmissionResponse{Allowed: true}
+}
+
+func denied(err error) *admv1.AdmissionResponse {
+	return &admv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Message: err.Error(),
+			Code:    http.StatusUnprocessableEntity,
+		},
+	}
+}
+
+// patched returns an AdmissionResponse which replaces the spec of an object
+// with spec.
+func patched(spec interface{}) *admv1.AdmissionResponse {
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "add", "path": "/spec", "value": spec},
+	})
+	if err != nil {
+		return denied(fmt.Errorf("failed to encode patch: %v", err))
+	}
+	patchType := admv1.PatchTypeJSONPatch
+	return &admv1.AdmissionResponse{
+		Allowed:   true,
+		Patch:     patch,
+		PatchType: &patchType,
+	}
+}
+
+// jobStarted returns whether the job in the given state has started, after
+// which its spec must not change.
+func jobStarted(state string) bool {
+	return state != "" && state != controllerutil.JobStateNew
+}
+
+// validateJob validates a job which is created, or whose spec is updated. The
+// spec of a job cannot be updated once the job has started.
+func validateJob(v JobValidator, kind string, operation admv1.Operation, job metav1.Object, started, specUpdated bool) error {
+	switch operation {
+	case admv1.Create:
+		return v.ValidateJob(job)
+	case admv1.Update:
+		if !specUpdated {
+			return nil
+		}
+		if started {
+			return fmt.Errorf("the spec of %s %s cannot be updated once its job has started", kind, job.GetName())
+		}
+		return v.ValidateJob(job)
+	}
+	return nil
+}
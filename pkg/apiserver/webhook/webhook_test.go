@@ -0,0 +1,206 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const testUID = types.UID("0a8d2c1e-7f1b-4a53-9b4e-3c3b2f4e5d6a")
+
+type fakeJobValidator struct {
+	err error
+}
+
+func (v *fakeJobValidator) ValidateJob(job metav1.Object) error {
+	return v.err
+}
+
+// review sends an AdmissionReview with the given objects to handler and
+// returns the AdmissionResponse.
+func review(t *testing.T, handler http.HandlerFunc, operation admv1.Operation, object, oldObject interface{}) *admv1.AdmissionResponse {
+	request := &admv1.AdmissionRequest{
+		UID:       testUID,
+		Operation: operation,
+	}
+	raw, err := json.Marshal(object)
+	require.NoError(t, err)
+	request.Object = runtime.RawExtension{Raw: raw}
+	if oldObject != nil {
+		raw, err := json.Marshal(oldObject)
+		require.NoError(t, err)
+		request.OldObject = runtime.RawExtension{Raw: raw}
+	}
+	body, err := json.Marshal(admv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request:  request,
+	})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	handler(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var resp admv1.AdmissionReview
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Response)
+	assert.Nil(t, resp.Request)
+	assert.Equal(t, testUID, resp.Response.UID)
+	return resp.Response
+}
+
+// decodePatch returns the spec set by the JSONPatch of an AdmissionResponse.
+func decodePatch(t *testing.T, response *admv1.AdmissionResponse, spec interface{}) {
+	require.NotNil(t, response.PatchType)
+	assert.Equal(t, admv1.PatchTypeJSONPatch, *response.PatchType)
+	var patch []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value"`
+	}
+	require.NoError(t, json.Unmarshal(response.Patch, &patch))
+	require.Len(t, patch, 1)
+	assert.Equal(t, "add", patch[0].Op)
+	assert.Equal(t, "/spec", patch[0].Path)
+	require.NoError(t, json.Unmarshal(patch[0].Value, spec))
+}
+
+func TestServe(t *testing.T) {
+	admit := func(request *admv1.AdmissionRequest) *admv1.AdmissionResponse {
+		return allowed()
+	}
+	testCases := []struct {
+		name         string
+		contentType  string
+		body         string
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			name:         "Invalid Content-Type",
+			contentType:  "text/plain",
+			body:         "{}",
+			expectedCode: http.StatusUnsupportedMediaType,
+			expectedErr:  "invalid Content-Type text/plain",
+		},
+		{
+			name:         "Invalid body",
+			contentType:  "application/json",
+			body:         "invalid",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "failed to decode AdmissionReview",
+		},
+		{
+			name:         "Missing request",
+			contentType:  "application/json",
+			body:         "{}",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "failed to decode AdmissionReview",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			recorder := httptest.NewRecorder()
+			serve(recorder, req, admit)
+			assert.Equal(t, tt.expectedCode, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), tt.expectedErr)
+		})
+	}
+}
+
+func TestValidateJob(t *testing.T) {
+	job := &metav1.ObjectMeta{Name: "job-1"}
+	validationErr := fmt.Errorf("invalid spec")
+	testCases := []struct {
+		name        string
+		validator   JobValidator
+		operation   admv1.Operation
+		started     bool
+		specUpdated bool
+		expectedErr string
+	}{
+		{
+			name:      "Create",
+			validator: &fakeJobValidator{},
+			operation: admv1.Create,
+		},
+		{
+			name:        "Create with invalid spec",
+			validator:   &fakeJobValidator{err: validationErr},
+			operation:   admv1.Create,
+			expectedErr: "invalid spec",
+		},
+		{
+			name:      "Update without spec change",
+			validator: &fakeJobValidator{err: validationErr},
+			operation: admv1.Update,
+			started:   true,
+		},
+		{
+			name:        "Update spec of new job",
+			validator:   &fakeJobValidator{},
+			operation:   admv1.Update,
+			specUpdated: true,
+		},
+		{
+			name:        "Update spec of new job with invalid spec",
+			validator:   &fakeJobValidator{err: validationErr},
+			operation:   admv1.Update,
+			specUpdated: true,
+			expectedErr: "invalid spec",
+		},
+		{
+			name:        "Update spec of started job",
+			validator:   &fakeJobValidator{},
+			operation:   admv1.Update,
+			started:     true,
+			specUpdated: true,
+			expectedErr: "the spec of Job job-1 cannot be updated once its job has started",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateJob(tt.validator, "Job", tt.operation, job, tt.started, tt.specUpdated)
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestJobStarted(t *testing.T) {
+	assert.False(t, jobStarted(""))
+	assert.False(t, jobStarted("NEW"))
+	assert.True(t, jobStarted("SCHEDULED"))
+	assert.True(t, jobStarted("RUNNING"))
+	assert.True(t, jobStarted("COMPLETED"))
+}
@@ -26,6 +26,7 @@ import (
 
 type CrdV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	AnomalySuppressionsGetter
 	NetworkPolicyRecommendationsGetter
 	ThroughputAnomalyDetectorsGetter
 }
@@ -35,6 +36,10 @@ type CrdV1alpha1Client struct {
 	restClient rest.Interface
 }
 
+func (c *CrdV1alpha1Client) AnomalySuppressions(namespace string) AnomalySuppressionInterface {
+	return newAnomalySuppressions(c, namespace)
+}
+
 func (c *CrdV1alpha1Client) NetworkPolicyRecommendations(namespace string) NetworkPolicyRecommendationInterface {
 	return newNetworkPolicyRecommendations(c, namespace)
 }
@@ -26,6 +26,10 @@ type FakeCrdV1alpha1 struct {
 	*testing.Fake
 }
 
+func (c *FakeCrdV1alpha1) AnomalySuppressions(namespace string) v1alpha1.AnomalySuppressionInterface {
+	return &FakeAnomalySuppressions{c, namespace}
+}
+
 func (c *FakeCrdV1alpha1) NetworkPolicyRecommendations(namespace string) v1alpha1.NetworkPolicyRecommendationInterface {
 	return &FakeNetworkPolicyRecommendations{c, namespace}
 }
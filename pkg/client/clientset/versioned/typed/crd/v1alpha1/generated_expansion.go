@@ -16,6 +16,8 @@
 
 package v1alpha1
 
+type AnomalySuppressionExpansion interface{}
+
 type NetworkPolicyRecommendationExpansion interface{}
 
 type ThroughputAnomalyDetectorExpansion interface{}
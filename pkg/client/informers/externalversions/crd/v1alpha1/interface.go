@@ -22,6 +22,8 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// AnomalySuppressions returns a AnomalySuppressionInformer.
+	AnomalySuppressions() AnomalySuppressionInformer
 	// NetworkPolicyRecommendations returns a NetworkPolicyRecommendationInformer.
 	NetworkPolicyRecommendations() NetworkPolicyRecommendationInformer
 	// ThroughputAnomalyDetectors returns a ThroughputAnomalyDetectorInformer.
@@ -39,6 +41,11 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// AnomalySuppressions returns a AnomalySuppressionInformer.
+func (v *version) AnomalySuppressions() AnomalySuppressionInformer {
+	return &anomalySuppressionInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // NetworkPolicyRecommendations returns a NetworkPolicyRecommendationInformer.
 func (v *version) NetworkPolicyRecommendations() NetworkPolicyRecommendationInformer {
 	return &networkPolicyRecommendationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -51,6 +51,8 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=crd.theia.antrea.io, Version=v1alpha1
+	case v1alpha1.SchemeGroupVersion.WithResource("anomalysuppressions"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Crd().V1alpha1().AnomalySuppressions().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("networkpolicyrecommendations"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Crd().V1alpha1().NetworkPolicyRecommendations().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("throughputanomalydetectors"):
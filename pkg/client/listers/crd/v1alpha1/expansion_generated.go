@@ -16,6 +16,14 @@
 
 package v1alpha1
 
+// AnomalySuppressionListerExpansion allows custom methods to be added to
+// AnomalySuppressionLister.
+type AnomalySuppressionListerExpansion interface{}
+
+// AnomalySuppressionNamespaceListerExpansion allows custom methods to be added to
+// AnomalySuppressionNamespaceLister.
+type AnomalySuppressionNamespaceListerExpansion interface{}
+
 // NetworkPolicyRecommendationListerExpansion allows custom methods to be added to
 // NetworkPolicyRecommendationLister.
 type NetworkPolicyRecommendationListerExpansion interface{}
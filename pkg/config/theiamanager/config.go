@@ -17,6 +17,11 @@ package config
 type TheiaManagerConfig struct {
 	// apiServer contains APIServer related configuration options.
 	APIServer APIServerConfig `yaml:"apiServer,omitempty"`
+	// alerting contains options to push alerts for the anomalies found by
+	// Throughput Anomaly Detection jobs.
+	Alerting AlertingConfig `yaml:"alerting,omitempty"`
+	// jobs contains options of the analytics jobs run by theia-manager.
+	Jobs JobsConfig `yaml:"jobs,omitempty"`
 }
 
 type APIServerConfig struct {
@@ -35,3 +40,57 @@ type APIServerConfig struct {
 	// TLS min version.
 	TLSMinVersion string `yaml:"tlsMinVersion,omitempty"`
 }
+
+type AlertingConfig struct {
+	// AlertmanagerURL is the URL of an Alertmanager, for example
+	// "http://alertmanager.monitoring.svc:9093". Alerts are posted to its v2
+	// API. No alert is sent to Alertmanager if it is empty.
+	AlertmanagerURL string `yaml:"alertmanagerURL,omitempty"`
+	// WebhookURL is the URL of a generic webhook. Alerts are posted to it in
+	// the payload format of the Alertmanager webhook receiver. No alert is
+	// sent to a webhook if it is empty.
+	WebhookURL string `yaml:"webhookURL,omitempty"`
+	// DeduplicationInterval is the period during which an alert with the same
+	// labels is not sent again, for example when a job is re-run for the same
+	// time range. Defaults to 24h.
+	DeduplicationInterval string `yaml:"deduplicationInterval,omitempty"`
+}
+
+type JobsConfig struct {
+	// DefaultTimeout is the maximum duration of the NetworkPolicy
+	// Recommendation and Throughput Anomaly Detection jobs once started,
+	// unless they set their own activeDeadlineSeconds. A job which runs
+	// longer is stopped and marked as failed. Jobs never time out if it is 0.
+	// Defaults to 0.
+	DefaultTimeout string `yaml:"defaultTimeout,omitempty"`
+	// SuccessfulJobsHistoryLimit is the number of completed jobs retained for
+	// each job type. Older jobs are deleted together with their results.
+	// All completed jobs are retained if it is 0. Defaults to 0.
+	SuccessfulJobsHistoryLimit int `yaml:"successfulJobsHistoryLimit,omitempty"`
+	// FailedJobsHistoryLimit is the number of failed jobs retained for each
+	// job type. All failed jobs are retained if it is 0. Defaults to 0.
+	FailedJobsHistoryLimit int `yaml:"failedJobsHistoryLimit,omitempty"`
+	// InputBudget limits the flow records read by a job, which are estimated
+	// with ClickHouse before the job is started.
+	InputBudget JobInputBudgetConfig `yaml:"inputBudget,omitempty"`
+}
+
+type JobInputBudgetConfig struct {
+	// MaxRows is the maximum estimated number of flow records read by a job.
+	// It is not enforced if it is 0. Defaults to 0.
+	MaxRows int64 `yaml:"maxRows,omitempty"`
+	// MaxBytes is the maximum estimated uncompressed size of the flow records
+	// read by a job, as a quantity, for example "50Gi". It is not enforced if
+	// it is empty.
+	MaxBytes string `yaml:"maxBytes,omitempty"`
+	// Action is taken for a job exceeding the budget. It can be "Reject" to
+	// fail the job before it is started, or "ScaleUp" to run it with at least
+	// the executor settings below. Defaults to "Reject".
+	Action string `yaml:"action,omitempty"`
+	// ExecutorInstances is the minimum number of executors of a job exceeding
+	// the budget when action is "ScaleUp".
+	ExecutorInstances int `yaml:"executorInstances,omitempty"`
+	// ExecutorMemory is the minimum memory of each executor of a job
+	// exceeding the budget when action is "ScaleUp", for example "4G".
+	ExecutorMemory string `yaml:"executorMemory,omitempty"`
+}
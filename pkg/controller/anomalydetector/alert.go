@@ -0,0 +1,384 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomalydetector
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	managerconfig "antrea.io/theia/pkg/config/theiamanager"
+	"antrea.io/theia/pkg/util/anomaly"
+)
+
+const (
+	alertName              = "ThroughputAnomaly"
+	alertmanagerAlertsPath = "/api/v2/alerts"
+	alertRequestTimeout    = 10 * time.Second
+	// Alerts of a job are dropped after maxAlertRetries failed attempts.
+	maxAlertRetries = 5
+	// maxAlertsPerJob is the maximum number of anomalies of a job which are
+	// sent as alerts. The most recent anomalies are sent.
+	maxAlertsPerJob = 1000
+)
+
+var anomalyAlertQuery = fmt.Sprintf(`
+	SELECT
+		sourceIP,
+		sourceTransportPort,
+		destinationIP,
+		destinationTransportPort,
+		podNamespace,
+		podLabels,
+		podName,
+		destinationServicePortName,
+		direction,
+		sourceNodeName,
+		destinationNodeName,
+		flowEndSeconds,
+		metric,
+		throughput,
+		aggType,
+		algoType,
+		algoCalc
+	FROM tadetector WHERE id = (?) AND anomaly = 'true'
+	ORDER BY flowEndSeconds DESC
+	LIMIT %d;`, maxAlertsPerJob)
+
+// The fingerprints of the sent alerts are persisted in ClickHouse, so that
+// alerts are not sent again when Theia Manager restarts.
+var (
+	sentAlertsQuery = `
+	SELECT fingerprint, max(sentAt)
+	FROM tadetector_alerts
+	WHERE sentAt > toDateTime(?)
+	GROUP BY fingerprint;`
+	insertSentAlertQuery = `
+	INSERT INTO tadetector_alerts (fingerprint, sentAt) VALUES (?, ?);`
+)
+
+// Alert is an alert in the format of the Alertmanager v2 API.
+type Alert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    time.Time         `json:"startsAt"`
+}
+
+// webhookMessage follows the payload format of the Alertmanager webhook
+// receiver, so that receivers written for Alertmanager can consume it.
+type webhookMessage struct {
+	Version  string         `json:"version"`
+	Status   string         `json:"status"`
+	Receiver string         `json:"receiver"`
+	Alerts   []webhookAlert `json:"alerts"`
+}
+
+type webhookAlert struct {
+	Status string `json:"status"`
+	Alert
+	Fingerprint string `json:"fingerprint"`
+}
+
+// anomalyRecord is an anomalous row of the tadetector table.
+type anomalyRecord struct {
+	SourceIP                   string
+	SourceTransportPort        string
+	DestinationIP              string
+	DestinationTransportPort   string
+	PodNamespace               string
+	PodLabels                  string
+	PodName                    string
+	DestinationServicePortName string
+	Direction                  string
+	SourceNodeName             string
+	DestinationNodeName        string
+	FlowEndSeconds             string
+	Metric                     string
+	Throughput                 string
+	AggType                    string
+	AlgoType                   string
+	AlgoCalc                   string
+}
+
+// Alerter sends the alerts of detected throughput anomalies to an
+// Alertmanager and/or a generic webhook. Alerts with the same labels are
+// only sent once during the deduplication interval.
+type Alerter struct {
+	alertmanagerURL       string
+	webhookURL            string
+	deduplicationInterval time.Duration
+	client                *http.Client
+	sentAlertsMutex       sync.Mutex
+	// sentAlerts maps the fingerprint of the sent alerts to the time they
+	// were sent. It caches the fingerprints persisted in ClickHouse.
+	sentAlerts map[string]time.Time
+}
+
+// NewAlerter returns an Alerter for the given configuration, or nil if
+// neither an Alertmanager nor a webhook is configured.
+func NewAlerter(config managerconfig.AlertingConfig) (*Alerter, error) {
+	if config.AlertmanagerURL == "" && config.WebhookURL == "" {
+		return nil, nil
+	}
+	for _, u := range []string{config.AlertmanagerURL, config.WebhookURL} {
+		if u == "" {
+			continue
+		}
+		if _, err := url.ParseRequestURI(u); err != nil {
+			return nil, fmt.Errorf("invalid alerting URL %s: %v", u, err)
+		}
+	}
+	deduplicationInterval, err := time.ParseDuration(config.DeduplicationInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid alert deduplicationInterval %s: %v", config.DeduplicationInterval, err)
+	}
+	return &Alerter{
+		alertmanagerURL:       strings.TrimSuffix(config.AlertmanagerURL, "/"),
+		webhookURL:            config.WebhookURL,
+		deduplicationInterval: deduplicationInterval,
+		client:                &http.Client{Timeout: alertRequestTimeout},
+		sentAlerts:            make(map[string]time.Time),
+	}, nil
+}
+
+// Send sends the alerts which were not sent during the deduplication
+// interval. Alerts are only recorded as sent when all the configured
+// receivers accepted them, so that they are sent again on retry.
+func (a *Alerter) Send(db *sql.DB, alerts []Alert) error {
+	a.sentAlertsMutex.Lock()
+	defer a.sentAlertsMutex.Unlock()
+	now := time.Now()
+	for fingerprint, sentTime := range a.sentAlerts {
+		if now.Sub(sentTime) > a.deduplicationInterval {
+			delete(a.sentAlerts, fingerprint)
+		}
+	}
+	if err := a.loadSentAlerts(db, now); err != nil {
+		return fmt.Errorf("failed to get the alerts already sent: %v", err)
+	}
+	var newAlerts []Alert
+	var fingerprints []string
+	for _, alert := range alerts {
+		fingerprint := alertFingerprint(alert.Labels)
+		if _, ok := a.sentAlerts[fingerprint]; ok {
+			continue
+		}
+		newAlerts = append(newAlerts, alert)
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	if len(newAlerts) == 0 {
+		return nil
+	}
+	if a.alertmanagerURL != "" {
+		if err := a.post(a.alertmanagerURL+alertmanagerAlertsPath, newAlerts); err != nil {
+			return fmt.Errorf("failed to send alerts to Alertmanager: %v", err)
+		}
+	}
+	if a.webhookURL != "" {
+		message := webhookMessage{
+			Version:  "4",
+			Status:   "firing",
+			Receiver: "theia",
+		}
+		for i, alert := range newAlerts {
+			message.Alerts = append(message.Alerts, webhookAlert{
+				Status:      "firing",
+				Alert:       alert,
+				Fingerprint: fingerprints[i],
+			})
+		}
+		if err := a.post(a.webhookURL, message); err != nil {
+			return fmt.Errorf("failed to send alerts to webhook: %v", err)
+		}
+	}
+	for _, fingerprint := range fingerprints {
+		a.sentAlerts[fingerprint] = now
+	}
+	// The alerts have been sent, failing to persist them only means that
+	// they may be sent again after a restart.
+	if err := saveSentAlerts(db, fingerprints, now); err != nil {
+		klog.ErrorS(err, "Failed to persist the fingerprints of the sent alerts")
+	}
+	return nil
+}
+
+// loadSentAlerts adds the alerts persisted as sent during the deduplication
+// interval to sentAlerts.
+func (a *Alerter) loadSentAlerts(db *sql.DB, now time.Time) error {
+	rows, err := db.Query(sentAlertsQuery, now.Add(-a.deduplicationInterval).Unix())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var fingerprint string
+		var sentTime time.Time
+		if err := rows.Scan(&fingerprint, &sentTime); err != nil {
+			return err
+		}
+		if sentTime.After(a.sentAlerts[fingerprint]) {
+			a.sentAlerts[fingerprint] = sentTime
+		}
+	}
+	return rows.Err()
+}
+
+// saveSentAlerts persists the fingerprints of the sent alerts. Inserts are
+// only supported in a transaction by the ClickHouse driver.
+func saveSentAlerts(db *sql.DB, fingerprints []string, sentTime time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(insertSentAlertQuery)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+	for _, fingerprint := range fingerprints {
+		if _, err := stmt.Exec(fingerprint, sentTime); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (a *Alerter) post(endpoint string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.Post(endpoint, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+// alertFingerprint returns a hash of the sorted labels of an alert.
+func alertFingerprint(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		h.Write([]byte(key + "\xff" + labels[key] + "\xff"))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// isSuppressedRecord returns whether the anomaly is suppressed by any of the
+// AnomalySuppressions.
+func isSuppressedRecord(record anomalyRecord, suppressions []*crdv1alpha1.AnomalySuppression, now time.Time) bool {
+	if len(suppressions) == 0 {
+		return false
+	}
+	// An anomaly whose time can't be parsed only matches the suppressions
+	// without any time condition.
+	anomalyTime, _ := time.Parse(time.RFC3339Nano, record.FlowEndSeconds)
+	return anomaly.IsSuppressed(anomaly.Anomaly{
+		AggType:                    record.AggType,
+		SourceIP:                   record.SourceIP,
+		DestinationIP:              record.DestinationIP,
+		PodNamespace:               record.PodNamespace,
+		PodLabels:                  record.PodLabels,
+		PodName:                    record.PodName,
+		Direction:                  record.Direction,
+		DestinationServicePortName: record.DestinationServicePortName,
+		SourceNodeName:             record.SourceNodeName,
+		DestinationNodeName:        record.DestinationNodeName,
+		Time:                       anomalyTime,
+	}, suppressions, now)
+}
+
+// newAnomalyAlert builds the alert of an anomalous row. The labels identify
+// the anomaly independently of the job which found it, so that the same
+// anomaly found by repeated runs is deduplicated, while the job name is
+// given as an annotation.
+func newAnomalyAlert(tad *crdv1alpha1.ThroughputAnomalyDetector, record anomalyRecord) Alert {
+	// Results written before the metric was configurable are throughputs.
+	metric := record.Metric
+	if metric == "" {
+		metric = "throughput"
+	}
+	labels := map[string]string{
+		"alertname":        alertName,
+		"aggType":          record.AggType,
+		"algoType":         record.AlgoType,
+		"anomalyTimestamp": record.FlowEndSeconds,
+		"metric":           metric,
+	}
+	switch record.AggType {
+	case "pod":
+		labels["podNamespace"] = record.PodNamespace
+		labels["podName"] = record.PodName
+		labels["podLabels"] = record.PodLabels
+		labels["direction"] = record.Direction
+	case "external":
+		labels["externalIP"] = record.DestinationIP
+	case "svc":
+		labels["servicePortName"] = record.DestinationServicePortName
+	case "node":
+		labels["sourceNodeName"] = record.SourceNodeName
+		labels["destinationNodeName"] = record.DestinationNodeName
+	case "namespace":
+		labels["podNamespace"] = record.PodNamespace
+		labels["direction"] = record.Direction
+	default:
+		labels["sourceIP"] = record.SourceIP
+		labels["sourceTransportPort"] = record.SourceTransportPort
+		labels["destinationIP"] = record.DestinationIP
+		labels["destinationTransportPort"] = record.DestinationTransportPort
+	}
+	for key, value := range labels {
+		if value == "" {
+			delete(labels, key)
+		}
+	}
+	startsAt, err := time.Parse(time.RFC3339Nano, record.FlowEndSeconds)
+	if err != nil {
+		startsAt = time.Now()
+	}
+	return Alert{
+		Labels: labels,
+		Annotations: map[string]string{
+			"summary":                   fmt.Sprintf("%s anomaly detected by %s at %s", metric, record.AlgoType, record.FlowEndSeconds),
+			"throughput":                record.Throughput,
+			"algoCalc":                  record.AlgoCalc,
+			"throughputAnomalyDetector": tad.Name,
+		},
+		StartsAt: startsAt,
+	}
+}
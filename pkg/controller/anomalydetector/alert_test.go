@@ -0,0 +1,322 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomalydetector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	fakecrd "antrea.io/theia/pkg/client/clientset/versioned/fake"
+	crdinformers "antrea.io/theia/pkg/client/informers/externalversions"
+	managerconfig "antrea.io/theia/pkg/config/theiamanager"
+	controllerutil "antrea.io/theia/pkg/controller"
+	"antrea.io/theia/pkg/util/clickhouse"
+)
+
+type fakeAlertReceiver struct {
+	mutex    sync.Mutex
+	alerts   []Alert
+	messages []webhookMessage
+	status   int
+}
+
+func newFakeAlertReceiver(t *testing.T) (*fakeAlertReceiver, *httptest.Server) {
+	receiver := &fakeAlertReceiver{status: http.StatusOK}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receiver.mutex.Lock()
+		defer receiver.mutex.Unlock()
+		switch r.URL.Path {
+		case alertmanagerAlertsPath:
+			var alerts []Alert
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&alerts))
+			receiver.alerts = append(receiver.alerts, alerts...)
+		case "/webhook":
+			var message webhookMessage
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+			receiver.messages = append(receiver.messages, message)
+		}
+		w.WriteHeader(receiver.status)
+	}))
+	return receiver, server
+}
+
+func expectSentAlerts(mock sqlmock.Sqlmock, fingerprints ...string) {
+	rows := sqlmock.NewRows([]string{"fingerprint", "max(sentAt)"})
+	for _, fingerprint := range fingerprints {
+		rows.AddRow(fingerprint, time.Now())
+	}
+	mock.ExpectQuery(sentAlertsQuery).WithArgs(sqlmock.AnyArg()).WillReturnRows(rows)
+}
+
+func expectSaveSentAlerts(mock sqlmock.Sqlmock, fingerprints ...string) {
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare(insertSentAlertQuery)
+	for _, fingerprint := range fingerprints {
+		prepare.ExpectExec().WithArgs(fingerprint, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+}
+
+func TestNewAlerter(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		config      managerconfig.AlertingConfig
+		expectNil   bool
+		expectedErr string
+	}{
+		{
+			name:      "Alerting disabled",
+			config:    managerconfig.AlertingConfig{DeduplicationInterval: "24h"},
+			expectNil: true,
+		},
+		{
+			name:   "Alertmanager",
+			config: managerconfig.AlertingConfig{AlertmanagerURL: "http://alertmanager:9093", DeduplicationInterval: "24h"},
+		},
+		{
+			name:        "Invalid URL",
+			config:      managerconfig.AlertingConfig{WebhookURL: "webhook", DeduplicationInterval: "24h"},
+			expectedErr: "invalid alerting URL webhook",
+		},
+		{
+			name:        "Invalid deduplicationInterval",
+			config:      managerconfig.AlertingConfig{AlertmanagerURL: "http://alertmanager:9093", DeduplicationInterval: "1 day"},
+			expectedErr: "invalid alert deduplicationInterval 1 day",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			alerter, err := NewAlerter(tt.config)
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectNil, alerter == nil)
+		})
+	}
+}
+
+func TestAlerterSend(t *testing.T) {
+	receiver, server := newFakeAlertReceiver(t)
+	defer server.Close()
+	config := managerconfig.AlertingConfig{
+		AlertmanagerURL:       server.URL + "/",
+		WebhookURL:            server.URL + "/webhook",
+		DeduplicationInterval: "1h",
+	}
+	alerter, err := NewAlerter(config)
+	require.NoError(t, err)
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	alerts := []Alert{
+		{Labels: map[string]string{"alertname": alertName, "podName": "foo", "anomalyTimestamp": "2023-03-01T08:00:00Z"}},
+		{Labels: map[string]string{"alertname": alertName, "podName": "bar", "anomalyTimestamp": "2023-03-01T08:00:00Z"}},
+	}
+	fingerprints := []string{alertFingerprint(alerts[0].Labels), alertFingerprint(alerts[1].Labels)}
+
+	receiver.status = http.StatusInternalServerError
+	expectSentAlerts(mock)
+	assert.ErrorContains(t, alerter.Send(db, alerts), "failed to send alerts to Alertmanager")
+	receiver.status = http.StatusOK
+	expectSentAlerts(mock)
+	expectSaveSentAlerts(mock, fingerprints...)
+	assert.NoError(t, alerter.Send(db, alerts))
+	// Alerts already sent are not sent again, by the same job or a re-run.
+	expectSentAlerts(mock, fingerprints...)
+	assert.NoError(t, alerter.Send(db, alerts))
+	assert.Len(t, receiver.alerts, 4)
+	require.Len(t, receiver.messages, 1)
+	assert.Len(t, receiver.messages[0].Alerts, 2)
+	assert.Equal(t, "firing", receiver.messages[0].Alerts[0].Status)
+	assert.Equal(t, fingerprints[0], receiver.messages[0].Alerts[0].Fingerprint)
+
+	// A new anomaly is sent while the other ones are still deduplicated.
+	alerts = append(alerts, Alert{Labels: map[string]string{"alertname": alertName, "podName": "foo", "anomalyTimestamp": "2023-03-01T09:00:00Z"}})
+	fingerprints = append(fingerprints, alertFingerprint(alerts[2].Labels))
+	expectSentAlerts(mock, fingerprints[:2]...)
+	expectSaveSentAlerts(mock, fingerprints[2])
+	assert.NoError(t, alerter.Send(db, alerts))
+	assert.Len(t, receiver.alerts, 5)
+	require.Len(t, receiver.messages, 2)
+	assert.Len(t, receiver.messages[1].Alerts, 1)
+
+	// Alerts persisted as sent are not sent again after a restart.
+	alerter, err = NewAlerter(config)
+	require.NoError(t, err)
+	expectSentAlerts(mock, fingerprints...)
+	assert.NoError(t, alerter.Send(db, alerts))
+	assert.Len(t, receiver.alerts, 5)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAlerterSendPersistenceFailure(t *testing.T) {
+	receiver, server := newFakeAlertReceiver(t)
+	defer server.Close()
+	alerter, err := NewAlerter(managerconfig.AlertingConfig{
+		AlertmanagerURL:       server.URL,
+		DeduplicationInterval: "1h",
+	})
+	require.NoError(t, err)
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	alerts := []Alert{{Labels: map[string]string{"alertname": alertName, "podName": "foo", "anomalyTimestamp": "2023-03-01T08:00:00Z"}}}
+
+	// Alerts are not sent when the alerts already sent cannot be read.
+	mock.ExpectQuery(sentAlertsQuery).WithArgs(sqlmock.AnyArg()).WillReturnError(fmt.Errorf("connection refused"))
+	assert.ErrorContains(t, alerter.Send(db, alerts), "failed to get the alerts already sent")
+	assert.Empty(t, receiver.alerts)
+	// Failing to persist the sent alerts does not fail sending them.
+	expectSentAlerts(mock)
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("connection refused"))
+	assert.NoError(t, alerter.Send(db, alerts))
+	assert.Len(t, receiver.alerts, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSendTADetectorAlerts(t *testing.T) {
+	receiver, server := newFakeAlertReceiver(t)
+	defer server.Close()
+	alerter, err := NewAlerter(managerconfig.AlertingConfig{
+		AlertmanagerURL:       server.URL,
+		DeduplicationInterval: "1h",
+	})
+	require.NoError(t, err)
+
+	crdClient := fakecrd.NewSimpleClientset()
+	crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, informerDefaultResync)
+	taDetectorInformer := crdInformerFactory.Crd().V1alpha1().ThroughputAnomalyDetectors()
+	suppressionInformer := crdInformerFactory.Crd().V1alpha1().AnomalySuppressions()
+	t.Setenv("POD_NAMESPACE", testNamespace)
+	kubeClient := fake.NewSimpleClientset()
+	db, mock := clickhouse.CreateFakeClickHouse(t, kubeClient, testNamespace)
+	defer db.Close()
+	controller := NewAnomalyDetectorController(crdClient, kubeClient, nil, taDetectorInformer, suppressionInformer, alerter, 0, controllerutil.JobHistoryLimits{}, controllerutil.JobInputBudget{})
+	tad := &crdv1alpha1.ThroughputAnomalyDetector{
+		ObjectMeta: metav1.ObjectMeta{Name: tadName, Namespace: testNamespace},
+		Status: crdv1alpha1.ThroughputAnomalyDetectorStatus{
+			State:            crdv1alpha1.ThroughputAnomalyDetectorStateCompleted,
+			SparkApplication: tadName[4:],
+		},
+	}
+	require.NoError(t, taDetectorInformer.Informer().GetStore().Add(tad))
+
+	columns := []string{"sourceIP", "sourceTransportPort", "destinationIP", "destinationTransportPort", "podNamespace", "podLabels", "podName", "destinationServicePortName", "direction", "sourceNodeName", "destinationNodeName", "flowEndSeconds", "metric", "throughput", "aggType", "algoType", "algoCalc"}
+	mock.ExpectQuery(anomalyAlertQuery).WithArgs(tadName[4:]).WillReturnRows(
+		sqlmock.NewRows(columns).
+			AddRow("", "", "", "", "tad-ns", "", "tad-pod", "", "inbound", "", "", "2023-03-01T08:00:00Z", "throughput", "40000000", "pod", "EWMA", "10000000").
+			AddRow("", "", "", "", "", "", "", "tad-ns/tad-svc:http", "", "", "", "2023-03-01T08:01:00Z", "newConnections", "50000000", "svc", "EWMA", "10000000").
+			AddRow("", "", "", "", "", "", "", "", "", "node-1", "node-2", "2023-03-01T08:02:00Z", "", "60000000", "node", "EWMA", "10000000"))
+	expectSentAlerts(mock)
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare(insertSentAlertQuery)
+	for i := 0; i < 3; i++ {
+		prepare.ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	assert.NoError(t, controller.sendTADetectorAlerts(apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: tadName}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	require.Len(t, receiver.alerts, 3)
+	assert.Equal(t, map[string]string{
+		"alertname":        alertName,
+		"aggType":          "pod",
+		"algoType":         "EWMA",
+		"anomalyTimestamp": "2023-03-01T08:00:00Z",
+		"metric":           "throughput",
+		"podNamespace":     "tad-ns",
+		"podName":          "tad-pod",
+		"direction":        "inbound",
+	}, receiver.alerts[0].Labels)
+	assert.Equal(t, tadName, receiver.alerts[0].Annotations["throughputAnomalyDetector"])
+	assert.Equal(t, "tad-ns/tad-svc:http", receiver.alerts[1].Labels["servicePortName"])
+	assert.Equal(t, "newConnections anomaly detected by EWMA at 2023-03-01T08:01:00Z", receiver.alerts[1].Annotations["summary"])
+	assert.Equal(t, "2023-03-01T08:01:00Z", receiver.alerts[1].StartsAt.UTC().Format("2006-01-02T15:04:05Z"))
+	assert.Equal(t, "node-1", receiver.alerts[2].Labels["sourceNodeName"])
+	assert.Equal(t, "node-2", receiver.alerts[2].Labels["destinationNodeName"])
+	assert.Equal(t, "throughput", receiver.alerts[2].Labels["metric"])
+}
+
+func TestSendTADetectorAlertsSuppressed(t *testing.T) {
+	receiver, server := newFakeAlertReceiver(t)
+	defer server.Close()
+	alerter, err := NewAlerter(managerconfig.AlertingConfig{
+		AlertmanagerURL:       server.URL,
+		DeduplicationInterval: "1h",
+	})
+	require.NoError(t, err)
+
+	crdClient := fakecrd.NewSimpleClientset()
+	crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, informerDefaultResync)
+	taDetectorInformer := crdInformerFactory.Crd().V1alpha1().ThroughputAnomalyDetectors()
+	suppressionInformer := crdInformerFactory.Crd().V1alpha1().AnomalySuppressions()
+	t.Setenv("POD_NAMESPACE", testNamespace)
+	kubeClient := fake.NewSimpleClientset()
+	db, mock := clickhouse.CreateFakeClickHouse(t, kubeClient, testNamespace)
+	defer db.Close()
+	controller := NewAnomalyDetectorController(crdClient, kubeClient, nil, taDetectorInformer, suppressionInformer, alerter, 0, controllerutil.JobHistoryLimits{}, controllerutil.JobInputBudget{})
+	tad := &crdv1alpha1.ThroughputAnomalyDetector{
+		ObjectMeta: metav1.ObjectMeta{Name: tadName, Namespace: testNamespace},
+		Status: crdv1alpha1.ThroughputAnomalyDetectorStatus{
+			State:            crdv1alpha1.ThroughputAnomalyDetectorStateCompleted,
+			SparkApplication: tadName[4:],
+		},
+	}
+	require.NoError(t, taDetectorInformer.Informer().GetStore().Add(tad))
+	suppression := &crdv1alpha1.AnomalySuppression{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: testNamespace},
+		Spec: crdv1alpha1.AnomalySuppressionSpec{
+			PodNamespace:   "tad-ns",
+			PodName:        "backup",
+			DailyStartTime: "07:00",
+			DailyEndTime:   "09:00",
+		},
+	}
+	require.NoError(t, suppressionInformer.Informer().GetStore().Add(suppression))
+
+	columns := []string{"sourceIP", "sourceTransportPort", "destinationIP", "destinationTransportPort", "podNamespace", "podLabels", "podName", "destinationServicePortName", "direction", "sourceNodeName", "destinationNodeName", "flowEndSeconds", "metric", "throughput", "aggType", "algoType", "algoCalc"}
+	mock.ExpectQuery(anomalyAlertQuery).WithArgs(tadName[4:]).WillReturnRows(
+		sqlmock.NewRows(columns).
+			AddRow("", "", "", "", "tad-ns", "", "backup", "", "inbound", "", "", "2023-03-01T08:00:00Z", "throughput", "40000000", "pod", "EWMA", "10000000").
+			AddRow("", "", "", "", "tad-ns", "", "backup", "", "inbound", "", "", "2023-03-01T12:00:00Z", "throughput", "40000000", "pod", "EWMA", "10000000").
+			AddRow("", "", "", "", "tad-ns", "", "tad-pod", "", "inbound", "", "", "2023-03-01T08:00:00Z", "throughput", "40000000", "pod", "EWMA", "10000000"))
+	expectSentAlerts(mock)
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare(insertSentAlertQuery)
+	for i := 0; i < 2; i++ {
+		prepare.ExpectExec().WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	assert.NoError(t, controller.sendTADetectorAlerts(apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: tadName}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	require.Len(t, receiver.alerts, 2)
+	assert.Equal(t, "2023-03-01T12:00:00Z", receiver.alerts[0].Labels["anomalyTimestamp"])
+	assert.Equal(t, "tad-pod", receiver.alerts[1].Labels["podName"])
+}
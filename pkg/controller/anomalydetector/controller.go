@@ -16,12 +16,9 @@ package anomalydetector
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
-	"reflect"
-	"regexp"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -29,6 +26,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	apimachinerytypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
@@ -40,324 +38,279 @@ import (
 	"antrea.io/theia/pkg/client/listers/crd/v1alpha1"
 	controllerutil "antrea.io/theia/pkg/controller"
 	"antrea.io/theia/pkg/util"
-	"antrea.io/theia/pkg/util/clickhouse"
+	"antrea.io/theia/pkg/util/anomaly"
 	"antrea.io/theia/pkg/util/env"
-	sparkv1 "antrea.io/theia/third_party/sparkoperator/v1beta2"
 )
 
 const (
 	controllerName = "AnomalyDetectorController"
 	// Spark related parameters
 	sparkAppFile = "local:///opt/spark/work-dir/anomaly_detection.py"
+	// Number of algorithms run by the ENSEMBLE algorithm
+	ensembleAlgorithmCount = 3
 )
 
 var (
-	// Spark Application CRUD functions, for unit tests
-	CreateSparkApplication   = controllerutil.CreateSparkApplication
-	DeleteSparkApplication   = controllerutil.DeleteSparkApplication
-	GetSparkApplication      = controllerutil.GetSparkApplication
-	GetSparkMonitoringSvcDNS = controllerutil.GetSparkMonitoringSvcDNS
-	// For TAD in scheduled or running state, check its status periodically
-	anomalyDetectorResyncPeriod = 10 * time.Second
+	// For TAD in scheduled or running state, check its deadline and update its
+	// progress periodically. Its state is synced on Spark Application events.
+	anomalyDetectorResyncPeriod = 30 * time.Second
 	sparkAppLabelMap            = map[string]string{"app": "theia-tad"}
-	sparkAppLabel               = "app=theia-tad"
+	// Expired AnomalySuppressions are deleted periodically
+	suppressionExpiryCheckPeriod = time.Minute
 )
 
+// AnomalyDetectorController runs ThroughputAnomalyDetectors as anomaly
+// detection Spark jobs, alerts on the anomalies they find and removes the
+// expired AnomalySuppressions.
 type AnomalyDetectorController struct {
+	*controllerutil.JobController
 	crdClient  versioned.Interface
 	kubeClient kubernetes.Interface
 
-	anomalyDetectorInformer cache.SharedIndexInformer
-	anomalyDetectorLister   v1alpha1.ThroughputAnomalyDetectorLister
-	anomalyDetectorSynced   cache.InformerSynced
-	// queue maintains the Service objects that need to be synced.
-	queue                  workqueue.RateLimitingInterface
-	deletionQueue          workqueue.RateLimitingInterface
-	gcQueue                workqueue.RateLimitingInterface
-	periodicResyncSetMutex sync.Mutex
-	periodicResyncSet      map[apimachinerytypes.NamespacedName]struct{}
-	clickhouseConnect      *sql.DB
+	anomalyDetectorLister v1alpha1.ThroughputAnomalyDetectorLister
+	suppressionLister     v1alpha1.AnomalySuppressionLister
+	suppressionSynced     cache.InformerSynced
+	alertQueue            workqueue.RateLimitingInterface
+	// alerter is nil if alerting is not configured.
+	alerter *Alerter
 }
 
-type NamespacedId struct {
-	Namespace string
-	Id        string
-}
+var _ controllerutil.JobHandler = &AnomalyDetectorController{}
 
 func NewAnomalyDetectorController(
 	crdClient versioned.Interface,
 	kubeClient kubernetes.Interface,
+	dynamicClient dynamic.Interface,
 	taDetectorInformer crdv1a1informers.ThroughputAnomalyDetectorInformer,
+	suppressionInformer crdv1a1informers.AnomalySuppressionInformer,
+	alerter *Alerter,
+	defaultActiveDeadline time.Duration,
+	historyLimits controllerutil.JobHistoryLimits,
+	inputBudget controllerutil.JobInputBudget,
 ) *AnomalyDetectorController {
 	c := &AnomalyDetectorController{
-		crdClient:               crdClient,
-		kubeClient:              kubeClient,
-		queue:                   workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(controllerutil.MinRetryDelay, controllerutil.MaxRetryDelay), "taDetector"),
-		deletionQueue:           workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(controllerutil.MinRetryDelay, controllerutil.MaxRetryDelay), "taDetectorCleanup"),
-		gcQueue:                 workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(controllerutil.MinRetryDelay, controllerutil.MaxRetryDelay), "taDetectorGarbageCollection"),
-		anomalyDetectorInformer: taDetectorInformer.Informer(),
-		anomalyDetectorLister:   taDetectorInformer.Lister(),
-		anomalyDetectorSynced:   taDetectorInformer.Informer().HasSynced,
-		periodicResyncSet:       make(map[apimachinerytypes.NamespacedName]struct{}),
-	}
-
-	c.anomalyDetectorInformer.AddEventHandlerWithResyncPeriod(
-		cache.ResourceEventHandlerFuncs{
-			AddFunc:    c.addTADetector,
-			UpdateFunc: c.updateTADetector,
-			DeleteFunc: c.deleteTADetector,
-		},
-		controllerutil.ResyncPeriod,
-	)
-
+		crdClient:             crdClient,
+		kubeClient:            kubeClient,
+		alertQueue:            workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(controllerutil.MinRetryDelay, controllerutil.MaxRetryDelay), "taDetectorAlert"),
+		anomalyDetectorLister: taDetectorInformer.Lister(),
+		suppressionLister:     suppressionInformer.Lister(),
+		suppressionSynced:     suppressionInformer.Informer().HasSynced,
+		alerter:               alerter,
+	}
+	// The Spark Applications are read from the API server if no dynamic
+	// client is provided.
+	var sparkAppInformer cache.SharedIndexInformer
+	if dynamicClient != nil {
+		sparkAppInformer = controllerutil.NewSparkApplicationInformer(dynamicClient, env.GetTheiaNamespace(), sparkAppLabelMap, controllerutil.ResyncPeriod)
+	}
+	c.JobController = controllerutil.NewJobController(controllerName, c, kubeClient, taDetectorInformer.Informer(), sparkAppInformer, anomalyDetectorResyncPeriod, defaultActiveDeadline, historyLimits, inputBudget)
 	return c
 }
 
-func (c *AnomalyDetectorController) addTADetector(obj interface{}) {
-	newTAD, ok := obj.(*crdv1alpha1.ThroughputAnomalyDetector)
-	if !ok {
-		klog.ErrorS(nil, "fail to convert to Throughput Anomaly Detector", "object", obj)
-		return
-	}
-	klog.V(2).InfoS("Processing Throughput Anomaly Detector ADD event", "name", newTAD.Name, "labels", newTAD.Labels)
-	namespacedName := apimachinerytypes.NamespacedName{
-		Namespace: newTAD.Namespace,
-		Name:      newTAD.Name,
-	}
-	c.queue.Add(namespacedName)
-}
+// Run starts the alert and AnomalySuppression workers, then runs the
+// Throughput Anomaly Detector jobs until stopCh is closed.
+func (c *AnomalyDetectorController) Run(stopCh <-chan struct{}) {
+	defer c.alertQueue.ShutDown()
 
-func (c *AnomalyDetectorController) updateTADetector(_, new interface{}) {
-	newTAD, ok := new.(*crdv1alpha1.ThroughputAnomalyDetector)
-	if !ok {
-		klog.ErrorS(nil, "fail to convert to Throughput Anomaly Detector", "object", new)
+	if !cache.WaitForNamedCacheSync(controllerName, stopCh, c.suppressionSynced) {
 		return
 	}
-	klog.V(2).InfoS("Processing Throughput Anomaly Detector UPDATE event", "name", newTAD.Name, "labels", newTAD.Labels)
-	namespacedName := apimachinerytypes.NamespacedName{
-		Namespace: newTAD.Namespace,
-		Name:      newTAD.Name,
-	}
-	c.queue.Add(namespacedName)
-}
 
-func (c *AnomalyDetectorController) deleteTADetector(old interface{}) {
-	newTAD, ok := old.(*crdv1alpha1.ThroughputAnomalyDetector)
-	if !ok {
-		tombstone, ok := old.(cache.DeletedFinalStateUnknown)
-		if !ok {
-			klog.ErrorS(nil, "Error decoding object when deleting Throughput Anomaly Detector", "oldObject", old)
-			return
-		}
-		newTAD, ok = tombstone.Obj.(*crdv1alpha1.ThroughputAnomalyDetector)
-		if !ok {
-			klog.ErrorS(nil, "Error decoding object tombstone when deleting Throughput Anomaly Detector", "tombstone", tombstone.Obj)
-			return
-		}
-	}
-	klog.V(2).InfoS("Processing Throughput Anomaly Detector DELETE event", "name", newTAD.Name, "labels", newTAD.Labels)
-	// remove Throughput Anomaly Detector from periodic synchronization list in case it is deleted before completing
-	c.stopPeriodicSync(apimachinerytypes.NamespacedName{
-		Namespace: newTAD.Namespace,
-		Name:      newTAD.Name,
-	})
-	// Add SparkApplication and Namespace information to deletionQueue for cleanup
-	if newTAD.Status.SparkApplication != "" {
-		namespacedId := NamespacedId{
-			Namespace: newTAD.Namespace,
-			Id:        newTAD.Status.SparkApplication,
-		}
-		c.deletionQueue.Add(namespacedId)
-	}
-}
-
-// Run will create defaultWorkers workers (go routines) which will process the Service events from the
-// workqueue.
-func (c *AnomalyDetectorController) Run(stopCh <-chan struct{}) {
-	defer c.queue.ShutDown()
-	defer c.deletionQueue.ShutDown()
-	defer c.gcQueue.ShutDown()
+	go wait.Until(c.alertworker, time.Second, stopCh)
 
-	klog.InfoS("Starting controller", "name", controllerName)
-	defer klog.InfoS("Shutting down controller", "name", controllerName)
+	go wait.Until(c.removeExpiredSuppressions, suppressionExpiryCheckPeriod, stopCh)
 
-	if !cache.WaitForNamedCacheSync(controllerName, stopCh, c.anomalyDetectorSynced) {
-		return
-	}
+	c.JobController.Run(stopCh)
+}
 
-	c.gcQueue.Add(controllerutil.GcKey{
-		RemoveStaleDbEntries: true,
-		RemoveStaleSparkApp:  true,
-		AddResync:            true,
-	})
-	go c.gcworker(stopCh)
+func (c *AnomalyDetectorController) Kind() string {
+	return "ThroughputAnomalyDetector"
+}
 
-	go wait.Until(c.resyncTADetector, anomalyDetectorResyncPeriod, stopCh)
+func (c *AnomalyDetectorController) NamePrefix() string {
+	return "tad-"
+}
 
-	go wait.Until(c.deletionworker, time.Second, stopCh)
+func (c *AnomalyDetectorController) SparkAppLabels() map[string]string {
+	return sparkAppLabelMap
+}
 
-	for i := 0; i < controllerutil.DefaultWorkers; i++ {
-		go wait.Until(c.worker, time.Second, stopCh)
-	}
-	<-stopCh
+func (c *AnomalyDetectorController) ResultTables() (string, string) {
+	return "tadetector", "tadetector_local"
 }
 
-func (c *AnomalyDetectorController) gcworker(stopCh <-chan struct{}) {
-	wait.PollImmediateUntil(time.Second, func() (done bool, err error) {
-		return !c.processNextGcWorkItem(), nil
-	}, stopCh)
+func (c *AnomalyDetectorController) GetJob(namespace, name string) (metav1.Object, error) {
+	return c.GetThroughputAnomalyDetector(namespace, name)
 }
 
-func (c *AnomalyDetectorController) processNextGcWorkItem() bool {
-	obj, quit := c.gcQueue.Get()
-	if quit {
-		return false
+func (c *AnomalyDetectorController) ListJobs(namespace string) ([]metav1.Object, error) {
+	tadList, err := c.ListThroughputAnomalyDetector(namespace)
+	if err != nil {
+		return nil, err
 	}
-	defer c.gcQueue.Done(obj)
-
-	if key, ok := obj.(controllerutil.GcKey); !ok {
-		c.queue.Forget(obj)
-		klog.ErrorS(nil, "Expected gcKey in work queue", "got", obj)
-		return false
-	} else if updatedKey, err := c.handleStaleResources(key); err == nil {
-		c.gcQueue.Forget(key)
-		return false
-	} else {
-		klog.ErrorS(err, "Error handling stale resources, requeuing it")
-		c.gcQueue.AddRateLimited(updatedKey)
+	jobs := make([]metav1.Object, 0, len(tadList))
+	for _, tad := range tadList {
+		jobs = append(jobs, tad)
 	}
-	return true
+	return jobs, nil
 }
 
-// handleStaleResources handles the stale Spark Applications and database entries.
-func (c *AnomalyDetectorController) handleStaleResources(key controllerutil.GcKey) (updatedKey controllerutil.GcKey, err error) {
-	var errorList []error
-	if key.AddResync {
-		// Add scheduled/running TAD back to resync list
-		tadList, err := c.ListThroughputAnomalyDetector(env.GetTheiaNamespace())
-		if err != nil {
-			errorList = append(errorList, fmt.Errorf("failed to list ThroughputAnomalyDetection: %v", err))
-		} else {
-			for _, tad := range tadList {
-				if tad.Status.State == crdv1alpha1.ThroughputAnomalyDetectorStateScheduled || tad.Status.State == crdv1alpha1.ThroughputAnomalyDetectorStateRunning {
-					c.addPeriodicSync(apimachinerytypes.NamespacedName{
-						Namespace: tad.Namespace,
-						Name:      tad.Name,
-					})
-				}
-			}
-			key.AddResync = false
-		}
-	}
-	if key.RemoveStaleDbEntries {
-		err = controllerutil.HandleStaleDbEntries(
-			c.clickhouseConnect, c.kubeClient, "tadetector", "tadetector_local", c.IfTADexists, "tad-")
-		if err != nil {
-			errorList = append(errorList, err)
-		} else {
-			key.RemoveStaleDbEntries = false
-		}
-	}
+func (c *AnomalyDetectorController) GetJobStatus(job metav1.Object) controllerutil.JobStatus {
+	status := job.(*crdv1alpha1.ThroughputAnomalyDetector).Status
+	return controllerutil.JobStatus(status)
+}
 
-	if key.RemoveStaleSparkApp {
-		err = controllerutil.HandleStaleSparkApp(c.kubeClient, sparkAppLabel, c.IfTADexists)
-		if err != nil {
-			errorList = append(errorList, err)
-		} else {
-			key.RemoveStaleSparkApp = false
-		}
+func (c *AnomalyDetectorController) UpdateJobStatus(job metav1.Object, status controllerutil.JobStatus) error {
+	update := job.(*crdv1alpha1.ThroughputAnomalyDetector).DeepCopy()
+	update.Status = crdv1alpha1.ThroughputAnomalyDetectorStatus(status)
+	_, err := c.crdClient.CrdV1alpha1().ThroughputAnomalyDetectors(update.Namespace).UpdateStatus(context.TODO(), update, metav1.UpdateOptions{})
+	return err
+}
+
+func (c *AnomalyDetectorController) UpdateJobFinalizers(job metav1.Object, finalizers []string) (metav1.Object, error) {
+	update := job.(*crdv1alpha1.ThroughputAnomalyDetector).DeepCopy()
+	update.Finalizers = finalizers
+	updated, err := c.crdClient.CrdV1alpha1().ThroughputAnomalyDetectors(update.Namespace).Update(context.TODO(), update, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
 	}
+	return updated, nil
+}
 
-	if len(errorList) > 0 {
-		return key, fmt.Errorf("failed during garbage collection: %v", errorList)
-	} else {
-		return key, nil
+func (c *AnomalyDetectorController) DeleteJob(job metav1.Object) error {
+	return c.crdClient.CrdV1alpha1().ThroughputAnomalyDetectors(job.GetNamespace()).Delete(context.TODO(), job.GetName(), metav1.DeleteOptions{})
+}
+
+// JobCompleted queues the alerts for the anomalies found by a completed job.
+func (c *AnomalyDetectorController) JobCompleted(job metav1.Object) {
+	if c.alerter != nil {
+		c.alertQueue.Add(apimachinerytypes.NamespacedName{
+			Namespace: job.GetNamespace(),
+			Name:      job.GetName(),
+		})
 	}
 }
 
-func (c *AnomalyDetectorController) IfTADexists(namespace, id string) error {
-	_, err := c.GetThroughputAnomalyDetector(env.GetTheiaNamespace(), id)
-	if err != nil {
-		return err
+func (c *AnomalyDetectorController) ValidateJobName(name string) error {
+	if err := util.ParseADAlgorithmID(name); err != nil {
+		return controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: Throughput Anomaly Detector Querier job name is invalid: %s", err)}
 	}
 	return nil
 }
 
-func (c *AnomalyDetectorController) deletionworker() {
-	for c.processNextDeletionWorkItem() {
-	}
+func (c *AnomalyDetectorController) GetActiveDeadlineSeconds(job metav1.Object) int64 {
+	return job.(*crdv1alpha1.ThroughputAnomalyDetector).Spec.ActiveDeadlineSeconds
 }
 
-func (c *AnomalyDetectorController) processNextDeletionWorkItem() bool {
-	obj, quit := c.deletionQueue.Get()
-	if quit {
-		return false
+func (c *AnomalyDetectorController) GetTTLSecondsAfterFinished(job metav1.Object) *int32 {
+	return job.(*crdv1alpha1.ThroughputAnomalyDetector).Spec.TTLSecondsAfterFinished
+}
+
+// GetJobInput returns the flow records in the time window of a
+// ThroughputAnomalyDetector which are out of its ignored Namespaces, match its
+// aggregated flow type and pass its flow filter.
+func (c *AnomalyDetectorController) GetJobInput(job metav1.Object) (controllerutil.JobInput, error) {
+	tad := job.(*crdv1alpha1.ThroughputAnomalyDetector)
+	input := controllerutil.JobInput{
+		StartTime: tad.Spec.StartInterval,
+		EndTime:   tad.Spec.EndInterval,
 	}
-	defer c.deletionQueue.Done(obj)
-	if key, ok := obj.(NamespacedId); !ok {
-		c.queue.Forget(obj)
-		klog.ErrorS(nil, "Expected Spark Application namespaced id in work queue", "got", obj)
-		return true
-	} else if err := c.cleanupTADetector(key.Namespace, key.Id); err == nil {
-		// If no error occurs we forget this item so it does not get queued again until
-		// another change happens.
-		c.deletionQueue.Forget(key)
-	} else {
-		// Put the item back on the workqueue to handle any transient errors.
-		c.deletionQueue.AddRateLimited(key)
-		klog.ErrorS(err, "Error when cleaning Spark Application, requeuing", "key", key)
+	input.AddInCondition([]string{"sourcePodNamespace", "destinationPodNamespace"}, tad.Spec.NSIgnoreList, true)
+	switch tad.Spec.AggregatedFlow {
+	case "external":
+		input.Conditions = append(input.Conditions, "flowType = 3")
+		if tad.Spec.ExternalIP != "" {
+			input.AddInCondition([]string{"destinationIP"}, []string{tad.Spec.ExternalIP}, false)
+		}
+	case "svc":
+		if tad.Spec.ServicePortName != "" {
+			input.AddInCondition([]string{"destinationServicePortName"}, []string{tad.Spec.ServicePortName}, false)
+		} else {
+			input.Conditions = append(input.Conditions, "destinationServicePortName <> ''")
+		}
+	case "node":
+		if tad.Spec.NodeName != "" {
+			input.AddInCondition([]string{"sourceNodeName", "destinationNodeName"}, []string{tad.Spec.NodeName}, false)
+		}
+		input.Conditions = append(input.Conditions, "sourceNodeName <> '' AND destinationNodeName <> ''")
+	case "pod", "namespace":
+		// The job aggregates both the inbound and the outbound flow records
+		// of the Pods, which match the conditions on the destination or on
+		// the source side respectively.
+		inbound, inboundArgs := getPodSideConditions(tad, "destination")
+		outbound, outboundArgs := getPodSideConditions(tad, "source")
+		input.Conditions = append(input.Conditions, fmt.Sprintf("((%s) OR (%s))", inbound, outbound))
+		input.Args = append(append(input.Args, inboundArgs...), outboundArgs...)
+	}
+	if tad.Spec.FlowFilter != nil {
+		flowFilterExpr, err := getFlowFilterExpr(c.kubeClient, tad.Spec.FlowFilter)
+		if err != nil {
+			return input, err
+		}
+		if flowFilterExpr != "" {
+			input.Conditions = append(input.Conditions, "("+flowFilterExpr+")")
+		}
 	}
-	return true
+	return input, nil
 }
 
-// worker is a long-running function that will continually call the processNextWorkItem function in
-// order to read and process a message on the workqueue.
-func (c *AnomalyDetectorController) worker() {
-	for c.processNextWorkItem() {
+// getPodSideConditions returns the conditions of the pod and namespace
+// aggregated flow types on the Pods of one side of the flow records, and
+// their arguments.
+func getPodSideConditions(tad *crdv1alpha1.ThroughputAnomalyDetector, side string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	switch {
+	case tad.Spec.AggregatedFlow == "namespace":
+		conditions = append(conditions, side+"PodNamespace <> ''")
+	case tad.Spec.PodLabel != "":
+		conditions = append(conditions, "ilike("+side+"PodLabels, ?)")
+		args = append(args, "%"+tad.Spec.PodLabel+"%")
+	case tad.Spec.PodName != "":
+		conditions = append(conditions, side+"PodName = ?")
+		args = append(args, tad.Spec.PodName)
+	default:
+		// The Pod Namespace only narrows down a Pod label or name.
+		return side + "PodLabels <> ''", nil
 	}
+	if tad.Spec.PodNameSpace != "" {
+		conditions = append(conditions, side+"PodNamespace = ?")
+		args = append(args, tad.Spec.PodNameSpace)
+	}
+	return strings.Join(conditions, " AND "), args
 }
 
-func (c *AnomalyDetectorController) resyncTADetector() {
-	c.periodicResyncSetMutex.Lock()
-	tads := make([]apimachinerytypes.NamespacedName, 0, len(c.periodicResyncSet))
-	for tadNamespacedName := range c.periodicResyncSet {
-		tads = append(tads, tadNamespacedName)
-	}
-	c.periodicResyncSetMutex.Unlock()
-	for _, tadNamespacedName := range tads {
-		c.queue.Add(tadNamespacedName)
+func (c *AnomalyDetectorController) alertworker() {
+	for c.processNextAlertWorkItem() {
 	}
 }
 
-func (c *AnomalyDetectorController) processNextWorkItem() bool {
-	obj, quit := c.queue.Get()
+func (c *AnomalyDetectorController) processNextAlertWorkItem() bool {
+	obj, quit := c.alertQueue.Get()
 	if quit {
 		return false
 	}
-	defer c.queue.Done(obj)
+	defer c.alertQueue.Done(obj)
 	if key, ok := obj.(apimachinerytypes.NamespacedName); !ok {
-		c.queue.Forget(obj)
-		klog.ErrorS(nil, "Expected Throughput Anomaly Detector in work queue", "got", obj)
+		c.alertQueue.Forget(obj)
+		klog.ErrorS(nil, "Expected Throughput Anomaly Detector in alert work queue", "got", obj)
 		return true
-	} else if err := c.syncTADetector(key); err == nil {
-		// If no error occurs we forget this item so it does not get queued again until
-		// another change happens.
-		c.queue.Forget(key)
+	} else if err := c.sendTADetectorAlerts(key); err == nil {
+		c.alertQueue.Forget(key)
+	} else if c.alertQueue.NumRequeues(key) < maxAlertRetries {
+		c.alertQueue.AddRateLimited(key)
+		klog.ErrorS(err, "Error when sending Throughput Anomaly Detector alerts, requeuing", "key", key)
 	} else {
-		// Put the item back on the workqueue to handle any transient errors.
-		c.queue.AddRateLimited(key)
-		klog.ErrorS(err, "Error when syncing Throughput Anomaly Detector, requeuing", "key", key)
+		c.alertQueue.Forget(key)
+		klog.ErrorS(err, "Error when sending Throughput Anomaly Detector alerts, dropping", "key", key)
 	}
 	return true
 }
 
-func (c *AnomalyDetectorController) syncTADetector(key apimachinerytypes.NamespacedName) error {
-	startTime := time.Now()
-	defer func() {
-		klog.V(4).InfoS("Finished syncing Throughput Anomaly Detector", "key", key, "time", time.Since(startTime))
-	}()
-
-	newTAD, err := c.anomalyDetectorLister.ThroughputAnomalyDetectors(key.Namespace).Get(key.Name)
+// sendTADetectorAlerts sends an alert for every anomaly found by a completed
+// Throughput Anomaly Detector job.
+func (c *AnomalyDetectorController) sendTADetectorAlerts(key apimachinerytypes.NamespacedName) error {
+	tad, err := c.anomalyDetectorLister.ThroughputAnomalyDetectors(key.Namespace).Get(key.Name)
 	if err != nil {
 		// Throughput Anomaly Detector already deleted
 		if apimachineryerrors.IsNotFound(err) {
@@ -365,169 +318,135 @@ func (c *AnomalyDetectorController) syncTADetector(key apimachinerytypes.Namespa
 		}
 		return err
 	}
-	klog.V(4).Infof("Syncing Throughput Anomaly Detector", "newTAD", newTAD)
-
-	switch newTAD.Status.State {
-	case "", crdv1alpha1.ThroughputAnomalyDetectorStateNew:
-		err = c.startJob(newTAD)
-	case crdv1alpha1.ThroughputAnomalyDetectorStateScheduled:
-		_, err = c.checkSparkApplicationStatus(newTAD)
-	case crdv1alpha1.ThroughputAnomalyDetectorStateRunning:
-		err = c.updateProgress(newTAD)
-	case crdv1alpha1.ThroughputAnomalyDetectorStateCompleted:
-		if newTAD.Status.EndTime.IsZero() {
-			err = c.finishJob(newTAD)
-		}
+	connect, err := c.GetClickHouseConnection()
+	if err != nil {
+		return err
 	}
-	return err
-}
-
-func (c *AnomalyDetectorController) cleanupTADetector(namespace string, sparkApplicationId string) error {
-	// Delete the Spark Application if exists
-	DeleteSparkApplication(c.kubeClient, "tad-"+sparkApplicationId, namespace)
-	// Delete the result from the ClickHouse
-	if c.clickhouseConnect == nil {
-		var err error
-		c.clickhouseConnect, err = clickhouse.SetupConnection(c.kubeClient)
+	suppressions, err := c.ListAnomalySuppression(tad.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to list AnomalySuppressions: %v", err)
+	}
+	rows, err := connect.Query(anomalyAlertQuery, tad.Status.SparkApplication)
+	if err != nil {
+		return fmt.Errorf("failed to get Throughput Anomaly Detector results with id %s: %v", tad.Status.SparkApplication, err)
+	}
+	defer rows.Close()
+	var alerts []Alert
+	anomalies, suppressed := 0, 0
+	now := time.Now()
+	for rows.Next() {
+		anomalies++
+		var r anomalyRecord
+		err := rows.Scan(&r.SourceIP, &r.SourceTransportPort, &r.DestinationIP, &r.DestinationTransportPort, &r.PodNamespace, &r.PodLabels, &r.PodName, &r.DestinationServicePortName, &r.Direction, &r.SourceNodeName, &r.DestinationNodeName, &r.FlowEndSeconds, &r.Metric, &r.Throughput, &r.AggType, &r.AlgoType, &r.AlgoCalc)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to scan Throughput Anomaly Detector results: %v", err)
+		}
+		if isSuppressedRecord(r, suppressions, now) {
+			suppressed++
+			continue
 		}
+		alerts = append(alerts, newAnomalyAlert(tad, r))
 	}
-	query := "ALTER TABLE tadetector ON CLUSTER '{cluster}' DELETE WHERE id = (" + sparkApplicationId + ");"
-	return controllerutil.RunClickHouseQuery(c.clickhouseConnect, query, sparkApplicationId)
-}
-
-func (c *AnomalyDetectorController) finishJob(newTAD *crdv1alpha1.ThroughputAnomalyDetector) error {
-	namespacedName := apimachinerytypes.NamespacedName{
-		Name:      newTAD.Name,
-		Namespace: newTAD.Namespace,
-	}
-	// Stop periodical job
-	c.stopPeriodicSync(namespacedName)
-	if newTAD.Status.SparkApplication == "" {
-		return c.updateTADetectorStatus(
-			newTAD,
-			crdv1alpha1.ThroughputAnomalyDetectorStatus{
-				State:    crdv1alpha1.ThroughputAnomalyDetectorStateFailed,
-				ErrorMsg: "Spark Application should be started before updating results",
-			},
-		)
-	}
-	// Delete related SparkApplication CR
-	DeleteSparkApplication(c.kubeClient, "tad-"+newTAD.Status.SparkApplication, newTAD.Namespace)
-	return c.updateTADetectorStatus(
-		newTAD,
-		crdv1alpha1.ThroughputAnomalyDetectorStatus{
-			State:   crdv1alpha1.ThroughputAnomalyDetectorStateCompleted,
-			EndTime: metav1.NewTime(time.Now()),
-		})
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read Throughput Anomaly Detector results: %v", err)
+	}
+	if anomalies == maxAlertsPerJob {
+		klog.InfoS("Only the most recent anomalies of the Throughput Anomaly Detector are sent as alerts", "ThroughputAnomalyDetector", tad.Name, "maxAlerts", maxAlertsPerJob)
+	}
+	klog.V(2).InfoS("Sending Throughput Anomaly Detector alerts", "ThroughputAnomalyDetector", tad.Name, "anomalies", len(alerts), "suppressed", suppressed)
+	return c.alerter.Send(connect, alerts)
 }
 
-func (c *AnomalyDetectorController) updateProgress(newTAD *crdv1alpha1.ThroughputAnomalyDetector) error {
-	// Check the status before checking the progress in case the job is failed or completed
-	state, err := c.checkSparkApplicationStatus(newTAD)
-	if err != nil {
-		return err
+// getAlgoParamsArgs validates the parameters of the anomaly detection
+// algorithm and returns the matching arguments of the Spark job. The
+// ENSEMBLE algorithm runs all the other algorithms, so it accepts all their
+// parameters.
+func getAlgoParamsArgs(algo string, params *crdv1alpha1.ThroughputAnomalyDetectorAlgoParams) ([]string, error) {
+	var args []string
+	formatFloat := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
 	}
-	if state != crdv1alpha1.ThroughputAnomalyDetectorStateRunning {
-		return nil
+	ensemble := algo == "ENSEMBLE"
+	if params.Sensitivity != 0 {
+		if algo == "DBSCAN" {
+			return nil, fmt.Errorf("sensitivity is only supported by the EWMA, ARIMA and ENSEMBLE algorithms")
+		}
+		if params.Sensitivity < 0 {
+			return nil, fmt.Errorf("sensitivity should be a positive number")
+		}
+		args = append(args, "--sensitivity", formatFloat(params.Sensitivity))
 	}
-	endpoint := GetSparkMonitoringSvcDNS(newTAD.Status.SparkApplication, newTAD.Namespace, controllerutil.SparkPort)
-	completedStages, totalStages, err := controllerutil.GetSparkAppProgress(endpoint)
-	if err != nil {
-		// The Spark Monitoring Service may not start or closed at this point due to the async
-		// between Spark operator and this controller.
-		// As we periodically check the progress, we do not need to requeue this failure.
-		klog.V(4).ErrorS(err, "Failed to get the progress of the Throughput Anomaly Detector job")
-		return nil
-	}
-	klog.V(4).InfoS("Got Spark Application progress", "completedStages", completedStages, "totalStages", totalStages, "ThroughputAnomalyDetector", newTAD.Name)
-	return c.updateTADetectorStatus(
-		newTAD,
-		crdv1alpha1.ThroughputAnomalyDetectorStatus{
-			State:           crdv1alpha1.ThroughputAnomalyDetectorStateRunning,
-			CompletedStages: completedStages,
-			TotalStages:     totalStages,
-		},
-	)
-}
-
-func (c *AnomalyDetectorController) checkSparkApplicationStatus(newTAD *crdv1alpha1.ThroughputAnomalyDetector) (string, error) {
-	if newTAD.Status.SparkApplication == "" {
-		return "", c.updateTADetectorStatus(
-			newTAD,
-			crdv1alpha1.ThroughputAnomalyDetectorStatus{
-				State:    crdv1alpha1.ThroughputAnomalyDetectorStateFailed,
-				ErrorMsg: "Spark Application should be started before status checking",
-			},
-		)
-	}
-
-	state, errorMessage, err := getTADetectorStatus(c.kubeClient, newTAD.Status.SparkApplication, newTAD.Namespace)
-	if err != nil {
-		return state, err
-	}
-	klog.V(4).InfoS("Got Spark Application state", "state", state, "ThroughputAnomalyDetector", newTAD.Name)
-	if state == "RUNNING" {
-		return state, c.updateTADetectorStatus(
-			newTAD,
-			crdv1alpha1.ThroughputAnomalyDetectorStatus{
-				State:    crdv1alpha1.ThroughputAnomalyDetectorStateRunning,
-				ErrorMsg: errorMessage,
-			},
-		)
-	} else if state == "COMPLETED" {
-		return state, c.updateTADetectorStatus(
-			newTAD,
-			crdv1alpha1.ThroughputAnomalyDetectorStatus{
-				State:    crdv1alpha1.ThroughputAnomalyDetectorStateCompleted,
-				ErrorMsg: errorMessage,
-			},
-		)
-	} else if state == "FAILED" || state == "SUBMISSION_FAILED" || state == "FAILING" || state == "INVALIDATING" {
-		return state, c.updateTADetectorStatus(
-			newTAD,
-			crdv1alpha1.ThroughputAnomalyDetectorStatus{
-				State:    crdv1alpha1.ThroughputAnomalyDetectorStateFailed,
-				ErrorMsg: fmt.Sprintf("Throughput Anomaly Detector job failed, state: %s, error message: %v", state, errorMessage),
-			},
-		)
-	}
-	return state, nil
-}
-
-func (c *AnomalyDetectorController) startJob(newTAD *crdv1alpha1.ThroughputAnomalyDetector) error {
-	// Validate Cluster readiness
-	if err := controllerutil.ValidateCluster(c.kubeClient, newTAD.Namespace); err != nil {
-		return err
+	if params.EWMAAlpha != 0 {
+		if algo != "EWMA" && !ensemble {
+			return nil, fmt.Errorf("ewmaAlpha is only supported by the EWMA and ENSEMBLE algorithms")
+		}
+		if params.EWMAAlpha < 0 || params.EWMAAlpha > 1 {
+			return nil, fmt.Errorf("ewmaAlpha should be a number in (0, 1]")
+		}
+		args = append(args, "--ewma-alpha", formatFloat(params.EWMAAlpha))
 	}
-	err := c.startSparkApplication(newTAD)
-	// Mark the ThroughputAnomalyDetector as failed and not retry if it failed due to illegal arguments in request
-	if err != nil && reflect.TypeOf(err) == reflect.TypeOf(illeagelArguementError{}) {
-		return c.updateTADetectorStatus(
-			newTAD,
-			crdv1alpha1.ThroughputAnomalyDetectorStatus{
-				State:    crdv1alpha1.ThroughputAnomalyDetectorStateFailed,
-				ErrorMsg: fmt.Sprintf("error in creating AnomalyDetector: %v", err),
-			},
-		)
-	}
-	// Schedule periodical resync for successful starting
-	if err == nil {
-		c.addPeriodicSync(apimachinerytypes.NamespacedName{
-			Name:      newTAD.Name,
-			Namespace: newTAD.Namespace,
-		})
+	if len(params.ARIMAOrder) > 0 {
+		if algo != "ARIMA" && !ensemble {
+			return nil, fmt.Errorf("arimaOrder is only supported by the ARIMA and ENSEMBLE algorithms")
+		}
+		if len(params.ARIMAOrder) != 3 {
+			return nil, fmt.Errorf("arimaOrder should have 3 elements (p, d, q)")
+		}
+		order := make([]string, len(params.ARIMAOrder))
+		for i, value := range params.ARIMAOrder {
+			if value < 0 {
+				return nil, fmt.Errorf("arimaOrder should only have non-negative elements")
+			}
+			order[i] = strconv.Itoa(value)
+		}
+		args = append(args, "--arima-order", strings.Join(order, ","))
 	}
-	return err
+	if params.DBSCANEps != 0 {
+		if algo != "DBSCAN" && !ensemble {
+			return nil, fmt.Errorf("dbscanEps is only supported by the DBSCAN and ENSEMBLE algorithms")
+		}
+		if params.DBSCANEps < 0 {
+			return nil, fmt.Errorf("dbscanEps should be a positive number")
+		}
+		args = append(args, "--dbscan-eps", formatFloat(params.DBSCANEps))
+	}
+	if params.DBSCANMinSamples != 0 {
+		if algo != "DBSCAN" && !ensemble {
+			return nil, fmt.Errorf("dbscanMinSamples is only supported by the DBSCAN and ENSEMBLE algorithms")
+		}
+		if params.DBSCANMinSamples < 0 {
+			return nil, fmt.Errorf("dbscanMinSamples should be a positive integer")
+		}
+		args = append(args, "--dbscan-min-samples", strconv.Itoa(params.DBSCANMinSamples))
+	}
+	if params.Quorum != 0 {
+		if !ensemble {
+			return nil, fmt.Errorf("quorum is only supported by the ENSEMBLE algorithm")
+		}
+		if params.Quorum < 1 || params.Quorum > ensembleAlgorithmCount {
+			return nil, fmt.Errorf("quorum should be an integer between 1 and %d", ensembleAlgorithmCount)
+		}
+		args = append(args, "--quorum", strconv.Itoa(params.Quorum))
+	}
+	return args, nil
 }
 
-func (c *AnomalyDetectorController) startSparkApplication(newTAD *crdv1alpha1.ThroughputAnomalyDetector) error {
+// GetSparkJob validates the spec of a ThroughputAnomalyDetector and returns
+// the anomaly detection Spark job.
+func (c *AnomalyDetectorController) GetSparkJob(job metav1.Object) (*controllerutil.SparkJob, error) {
+	newTAD := job.(*crdv1alpha1.ThroughputAnomalyDetector)
 	var newTADJobArgs []string
-	if newTAD.Spec.JobType != "EWMA" && newTAD.Spec.JobType != "ARIMA" && newTAD.Spec.JobType != "DBSCAN" {
-		return illeagelArguementError{fmt.Errorf("invalid request: Throughput Anomaly Detector algorithm type should be 'EWMA' or 'ARIMA' or 'DBSCAN'")}
+	if newTAD.Spec.JobType != "EWMA" && newTAD.Spec.JobType != "ARIMA" && newTAD.Spec.JobType != "DBSCAN" && newTAD.Spec.JobType != "ENSEMBLE" {
+		return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: Throughput Anomaly Detector algorithm type should be 'EWMA' or 'ARIMA' or 'DBSCAN' or 'ENSEMBLE'")}
 	}
 	newTADJobArgs = append(newTADJobArgs, "--algo", newTAD.Spec.JobType)
+	if newTAD.Spec.AlgoParams != nil {
+		algoParamsArgs, err := getAlgoParamsArgs(newTAD.Spec.JobType, newTAD.Spec.AlgoParams)
+		if err != nil {
+			return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: %v", err)}
+		}
+		newTADJobArgs = append(newTADJobArgs, algoParamsArgs...)
+	}
 
 	if !newTAD.Spec.StartInterval.IsZero() {
 		newTADJobArgs = append(newTADJobArgs, "--start_time", newTAD.Spec.StartInterval.Format(controllerutil.InputTimeFormat))
@@ -535,7 +454,7 @@ func (c *AnomalyDetectorController) startSparkApplication(newTAD *crdv1alpha1.Th
 	if !newTAD.Spec.EndInterval.IsZero() {
 		endAfterStart := newTAD.Spec.EndInterval.After(newTAD.Spec.StartInterval.Time)
 		if !endAfterStart {
-			return illeagelArguementError{fmt.Errorf("invalid request: EndInterval should be after StartInterval")}
+			return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: EndInterval should be after StartInterval")}
 		}
 		newTADJobArgs = append(newTADJobArgs, "--end_time", newTAD.Spec.EndInterval.Format(controllerutil.InputTimeFormat))
 	}
@@ -558,7 +477,7 @@ func (c *AnomalyDetectorController) startSparkApplication(newTAD *crdv1alpha1.Th
 			}
 			if newTAD.Spec.PodNameSpace != "" {
 				if newTAD.Spec.PodName == "" && newTAD.Spec.PodLabel == "" {
-					return illeagelArguementError{fmt.Errorf("invalid request: 'pod-namespace' argument can not be used alone, should be specified along pod-label or pod-name")}
+					return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: 'pod-namespace' argument can not be used alone, should be specified along pod-label or pod-name")}
 				} else {
 					newTADJobArgs = append(newTADJobArgs, "--pod-namespace", newTAD.Spec.PodNameSpace)
 				}
@@ -573,165 +492,62 @@ func (c *AnomalyDetectorController) startSparkApplication(newTAD *crdv1alpha1.Th
 			if newTAD.Spec.ServicePortName != "" {
 				newTADJobArgs = append(newTADJobArgs, "--svc-port-name", newTAD.Spec.ServicePortName)
 			}
+		case "node":
+			newTADJobArgs = append(newTADJobArgs, "--agg-flow", newTAD.Spec.AggregatedFlow)
+			if newTAD.Spec.NodeName != "" {
+				newTADJobArgs = append(newTADJobArgs, "--node-name", newTAD.Spec.NodeName)
+			}
+		case "namespace":
+			newTADJobArgs = append(newTADJobArgs, "--agg-flow", newTAD.Spec.AggregatedFlow)
+			if newTAD.Spec.PodNameSpace != "" {
+				newTADJobArgs = append(newTADJobArgs, "--pod-namespace", newTAD.Spec.PodNameSpace)
+			}
 		default:
-			return illeagelArguementError{fmt.Errorf("invalid request: Throughput Anomaly Detector aggregated flow type should be 'pod' or 'external' or 'svc'")}
+			return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: Throughput Anomaly Detector aggregated flow type should be 'pod' or 'external' or 'svc' or 'node' or 'namespace'")}
 		}
 	}
 
-	sparkResourceArgs := struct {
-		executorInstances   int32
-		driverCoreRequest   string
-		driverMemory        string
-		executorCoreRequest string
-		executorMemory      string
-	}{}
-
-	if newTAD.Spec.ExecutorInstances < 0 {
-		return illeagelArguementError{fmt.Errorf("invalid request: ExecutorInstances should be an integer >= 0")}
-	}
-	sparkResourceArgs.executorInstances = int32(newTAD.Spec.ExecutorInstances)
-
-	matchResult, err := regexp.MatchString(controllerutil.K8sQuantitiesReg, newTAD.Spec.DriverCoreRequest)
-	if err != nil || !matchResult {
-		return illeagelArguementError{fmt.Errorf("invalid request: DriverCoreRequest should conform to the Kubernetes resource quantity convention")}
-	}
-	sparkResourceArgs.driverCoreRequest = newTAD.Spec.DriverCoreRequest
-
-	matchResult, err = regexp.MatchString(controllerutil.K8sQuantitiesReg, newTAD.Spec.DriverMemory)
-	if err != nil || !matchResult {
-		return illeagelArguementError{fmt.Errorf("invalid request: DriverMemory should conform to the Kubernetes resource quantity convention")}
-	}
-	sparkResourceArgs.driverMemory = newTAD.Spec.DriverMemory
-
-	matchResult, err = regexp.MatchString(controllerutil.K8sQuantitiesReg, newTAD.Spec.ExecutorCoreRequest)
-	if err != nil || !matchResult {
-		return illeagelArguementError{fmt.Errorf("invalid request: ExecutorCoreRequest should conform to the Kubernetes resource quantity convention")}
-	}
-	sparkResourceArgs.executorCoreRequest = newTAD.Spec.ExecutorCoreRequest
-
-	matchResult, err = regexp.MatchString(controllerutil.K8sQuantitiesReg, newTAD.Spec.ExecutorMemory)
-	if err != nil || !matchResult {
-		return illeagelArguementError{fmt.Errorf("invalid request: ExecutorMemory should conform to the Kubernetes resource quantity convention")}
+	if newTAD.Spec.Metric != "" {
+		switch newTAD.Spec.Metric {
+		case "throughput", "reverseThroughput", "packetRate", "octetDelta":
+		case "newConnections":
+			// Every connection is new in its first flow record only, so new
+			// connections are only meaningful for aggregated flows.
+			if newTAD.Spec.AggregatedFlow == "" {
+				return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: Throughput Anomaly Detector metric 'newConnections' requires an aggregated flow type")}
+			}
+		default:
+			return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: Throughput Anomaly Detector metric should be 'throughput' or 'reverseThroughput' or 'packetRate' or 'octetDelta' or 'newConnections'")}
+		}
+		newTADJobArgs = append(newTADJobArgs, "--metric", newTAD.Spec.Metric)
 	}
-	sparkResourceArgs.executorMemory = newTAD.Spec.ExecutorMemory
 
-	err = util.ParseADAlgorithmID(newTAD.Name)
-	if err != nil {
-		return illeagelArguementError{fmt.Errorf("invalid request: Throughput Anomaly Detector Querier job name is invalid: %s", err)}
-	}
-	taDetectorID := newTAD.Name[4:]
-	newTADJobArgs = append(newTADJobArgs, "--id", taDetectorID)
-	taDetectorApplication := &sparkv1.SparkApplication{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "sparkoperator.k8s.io/v1beta2",
-			Kind:       "SparkApplication",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      newTAD.Name,
-			Namespace: newTAD.Namespace,
-			Labels:    sparkAppLabelMap,
-		},
-		Spec: sparkv1.SparkApplicationSpec{
-			Type:                "Python",
-			SparkVersion:        controllerutil.SparkVersion,
-			Mode:                "cluster",
-			Image:               controllerutil.ConstStrToPointer(controllerutil.SparkImage),
-			ImagePullPolicy:     controllerutil.ConstStrToPointer(controllerutil.SparkImagePullPolicy),
-			MainApplicationFile: controllerutil.ConstStrToPointer(sparkAppFile),
-			Arguments:           newTADJobArgs,
-			Driver: sparkv1.DriverSpec{
-				CoreRequest: &newTAD.Spec.DriverCoreRequest,
-				SparkPodSpec: sparkv1.SparkPodSpec{
-					Memory: &newTAD.Spec.DriverMemory,
-					Labels: map[string]string{
-						"version": controllerutil.SparkVersion,
-					},
-					EnvSecretKeyRefs: map[string]sparkv1.NameKey{
-						"CH_USERNAME": {
-							Name: "clickhouse-secret",
-							Key:  "username",
-						},
-						"CH_PASSWORD": {
-							Name: "clickhouse-secret",
-							Key:  "password",
-						},
-					},
-					ServiceAccount: controllerutil.ConstStrToPointer(controllerutil.SparkServiceAccount),
-				},
-			},
-			Executor: sparkv1.ExecutorSpec{
-				CoreRequest: &newTAD.Spec.ExecutorCoreRequest,
-				SparkPodSpec: sparkv1.SparkPodSpec{
-					Memory: &newTAD.Spec.ExecutorMemory,
-					Labels: map[string]string{
-						"version": controllerutil.SparkVersion,
-					},
-					EnvSecretKeyRefs: map[string]sparkv1.NameKey{
-						"CH_USERNAME": {
-							Name: "clickhouse-secret",
-							Key:  "username",
-						},
-						"CH_PASSWORD": {
-							Name: "clickhouse-secret",
-							Key:  "password",
-						},
-					},
-				},
-				Instances: &sparkResourceArgs.executorInstances,
-			},
-		},
-	}
-	err = CreateSparkApplication(c.kubeClient, newTAD.Namespace, taDetectorApplication)
-	if err != nil {
-		return fmt.Errorf("failed to create Spark Application: %v", err)
+	if newTAD.Spec.SaveNormalPoints {
+		newTADJobArgs = append(newTADJobArgs, "--save-normal-points")
 	}
-	klog.V(2).InfoS("Start SparkApplication", "id", taDetectorID, "ThroughputAnomalyDetector", newTAD.Name)
 
-	return c.updateTADetectorStatus(
-		newTAD,
-		crdv1alpha1.ThroughputAnomalyDetectorStatus{
-			State:            crdv1alpha1.ThroughputAnomalyDetectorStateScheduled,
-			SparkApplication: taDetectorID,
-			StartTime:        metav1.NewTime(time.Now()),
-		},
-	)
-}
-
-func (c *AnomalyDetectorController) updateTADetectorStatus(newTAD *crdv1alpha1.ThroughputAnomalyDetector, status crdv1alpha1.ThroughputAnomalyDetectorStatus) error {
-	update := newTAD.DeepCopy()
-	update.Status.State = status.State
-	if status.SparkApplication != "" {
-		update.Status.SparkApplication = status.SparkApplication
-	}
-	if status.CompletedStages != 0 {
-		update.Status.CompletedStages = status.CompletedStages
-	}
-	if status.TotalStages != 0 {
-		update.Status.TotalStages = status.TotalStages
-	}
-	if status.ErrorMsg != "" {
-		update.Status.ErrorMsg = status.ErrorMsg
-	}
-	if !status.StartTime.IsZero() {
-		update.Status.StartTime = status.StartTime
-	}
-	if !status.EndTime.IsZero() {
-		update.Status.EndTime = status.EndTime
+	if newTAD.Spec.FlowFilter != nil {
+		if err := validateFlowFilter(newTAD.Spec.FlowFilter); err != nil {
+			return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: %v", err)}
+		}
+		flowFilterExpr, err := getFlowFilterExpr(c.kubeClient, newTAD.Spec.FlowFilter)
+		if err != nil {
+			return nil, err
+		}
+		if flowFilterExpr != "" {
+			newTADJobArgs = append(newTADJobArgs, "--flow-filter", flowFilterExpr)
+		}
 	}
-	_, err := c.crdClient.CrdV1alpha1().ThroughputAnomalyDetectors(newTAD.Namespace).UpdateStatus(context.TODO(), update, metav1.UpdateOptions{})
-	return err
-}
-
-func (c *AnomalyDetectorController) addPeriodicSync(key apimachinerytypes.NamespacedName) {
-	c.periodicResyncSetMutex.Lock()
-	defer c.periodicResyncSetMutex.Unlock()
-	c.periodicResyncSet[key] = struct{}{}
-}
 
-func (c *AnomalyDetectorController) stopPeriodicSync(key apimachinerytypes.NamespacedName) {
-	c.periodicResyncSetMutex.Lock()
-	defer c.periodicResyncSetMutex.Unlock()
-	delete(c.periodicResyncSet, key)
+	return &controllerutil.SparkJob{
+		MainApplicationFile: sparkAppFile,
+		Arguments:           newTADJobArgs,
+		ExecutorInstances:   newTAD.Spec.ExecutorInstances,
+		DriverCoreRequest:   newTAD.Spec.DriverCoreRequest,
+		DriverMemory:        newTAD.Spec.DriverMemory,
+		ExecutorCoreRequest: newTAD.Spec.ExecutorCoreRequest,
+		ExecutorMemory:      newTAD.Spec.ExecutorMemory,
+	}, nil
 }
 
 func (c *AnomalyDetectorController) GetThroughputAnomalyDetector(namespace, name string) (*crdv1alpha1.ThroughputAnomalyDetector, error) {
@@ -750,17 +566,44 @@ func (c *AnomalyDetectorController) CreateThroughputAnomalyDetector(namespace st
 	return c.crdClient.CrdV1alpha1().ThroughputAnomalyDetectors(namespace).Create(context.TODO(), ThroughputAnomalyDetector, metav1.CreateOptions{})
 }
 
-func getTADetectorStatus(client kubernetes.Interface, id string, namespace string) (state string, errorMessage string, err error) {
-	sparkApplication, err := GetSparkApplication(client, "tad-"+id, namespace)
-	if err != nil {
-		return state, errorMessage, err
-	}
-	state = strings.TrimSpace(string(sparkApplication.Status.AppState.State))
-	errorMessage = strings.TrimSpace(string(sparkApplication.Status.AppState.ErrorMessage))
+func (c *AnomalyDetectorController) EstimateThroughputAnomalyDetector(ThroughputAnomalyDetector *crdv1alpha1.ThroughputAnomalyDetector) (*crdv1alpha1.JobInputEstimate, error) {
+	return c.EstimateJobInput(ThroughputAnomalyDetector)
+}
+
+func (c *AnomalyDetectorController) GetAnomalySuppression(namespace, name string) (*crdv1alpha1.AnomalySuppression, error) {
+	return c.suppressionLister.AnomalySuppressions(namespace).Get(name)
+}
 
-	return state, errorMessage, nil
+func (c *AnomalyDetectorController) ListAnomalySuppression(namespace string) ([]*crdv1alpha1.AnomalySuppression, error) {
+	return c.suppressionLister.AnomalySuppressions(namespace).List(labels.Everything())
 }
 
-type illeagelArguementError struct {
-	error
+func (c *AnomalyDetectorController) DeleteAnomalySuppression(namespace, name string) error {
+	return c.crdClient.CrdV1alpha1().AnomalySuppressions(namespace).Delete(context.TODO(), name, metav1.DeleteOptions{})
+}
+
+func (c *AnomalyDetectorController) CreateAnomalySuppression(namespace string, anomalySuppression *crdv1alpha1.AnomalySuppression) (*crdv1alpha1.AnomalySuppression, error) {
+	return c.crdClient.CrdV1alpha1().AnomalySuppressions(namespace).Create(context.TODO(), anomalySuppression, metav1.CreateOptions{})
+}
+
+// removeExpiredSuppressions deletes the AnomalySuppressions whose expiration
+// time has passed.
+func (c *AnomalyDetectorController) removeExpiredSuppressions() {
+	suppressions, err := c.suppressionLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list AnomalySuppressions")
+		return
+	}
+	now := time.Now()
+	for _, suppression := range suppressions {
+		if !anomaly.IsExpired(suppression, now) {
+			continue
+		}
+		err := c.DeleteAnomalySuppression(suppression.Namespace, suppression.Name)
+		if err != nil && !apimachineryerrors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to delete expired AnomalySuppression", "namespace", suppression.Namespace, "name", suppression.Name)
+			continue
+		}
+		klog.V(2).InfoS("Deleted expired AnomalySuppression", "namespace", suppression.Namespace, "name", suppression.Name)
+	}
 }
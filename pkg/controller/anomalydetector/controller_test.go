@@ -17,6 +17,7 @@ package anomalydetector
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -29,8 +30,11 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	apimachinerytypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
@@ -46,7 +50,19 @@ import (
 	"antrea.io/theia/third_party/sparkoperator/v1beta2"
 )
 
-const informerDefaultResync = 30 * time.Second
+const (
+	informerDefaultResync = 30 * time.Second
+	estimateQuery         = `
+SELECT
+	count() AS Rows,
+	toInt64(count() * (
+		SELECT sum(data_uncompressed_bytes) / greatest(sum(rows), 1)
+		FROM cluster('{cluster}', system.parts)
+		WHERE active AND database = 'default' AND table = 'flows_local'
+	)) AS Bytes
+FROM flows
+WHERE `
+)
 
 var (
 	testNamespace = "controller-test"
@@ -58,6 +74,7 @@ type fakeController struct {
 	crdClient          versioned.Interface
 	kubeClient         kubernetes.Interface
 	crdInformerFactory crdinformers.SharedInformerFactory
+	mock               sqlmock.Sqlmock
 }
 
 func newFakeController(t *testing.T) (*fakeController, *sql.DB) {
@@ -68,16 +85,36 @@ func newFakeController(t *testing.T) (*fakeController, *sql.DB) {
 
 	crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, informerDefaultResync)
 	taDetectorInformer := crdInformerFactory.Crd().V1alpha1().ThroughputAnomalyDetectors()
+	suppressionInformer := crdInformerFactory.Crd().V1alpha1().AnomalySuppressions()
 
-	tadController := NewAnomalyDetectorController(crdClient, kubeClient, taDetectorInformer)
+	tadController := NewAnomalyDetectorController(crdClient, kubeClient, nil, taDetectorInformer, suppressionInformer, nil, 0, controllerUtil.JobHistoryLimits{}, controllerUtil.JobInputBudget{})
 
 	mock.ExpectQuery("SELECT DISTINCT id FROM tadetector;").WillReturnRows(sqlmock.NewRows([]string{}))
 	mock.ExpectExec("ALTER TABLE tadetector_local ON CLUSTER '{cluster}' DELETE WHERE id = (?);").WithArgs(tadName[4:]).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT name FROM system.users WHERE startsWith(name, ?);").WithArgs(controllerUtil.JobUserPrefix + "tad_").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	// The ClickHouse user of a job is created when it starts, and dropped
+	// both when it finishes and when it is deleted.
+	mock.MatchExpectationsInOrder(false)
+	userName := controllerUtil.JobUserPrefix + strings.ReplaceAll(tadName, "-", "_")
+	for i := 0; i < 4; i++ {
+		mock.ExpectExec("CREATE USER OR REPLACE " + userName + " ON CLUSTER '{cluster}' IDENTIFIED WITH sha256_password BY ?;").WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+		for _, table := range []string{"flows", "flows_local", "tadetector", "tadetector_local"} {
+			privileges := "SELECT"
+			if !strings.HasPrefix(table, "flows") {
+				privileges = "SELECT, INSERT"
+			}
+			mock.ExpectExec(fmt.Sprintf("GRANT ON CLUSTER '{cluster}' %s ON default.%s TO %s;", privileges, table, userName)).WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		for j := 0; j < 2; j++ {
+			mock.ExpectExec("DROP USER IF EXISTS " + userName + " ON CLUSTER '{cluster}';").WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+	}
 	return &fakeController{
 		tadController,
 		crdClient,
 		kubeClient,
 		crdInformerFactory,
+		mock,
 	}, db
 }
 
@@ -113,7 +150,15 @@ func createFakeSparkApplicationService(kubeClient kubernetes.Interface, id strin
 				{"status": "COMPLETE"},
 				{"status": "SKIPPED"},
 				{"status": "PENDING"},
-				{"status": "ACTIVE"},
+				{"status": "ACTIVE", "name": "groupBy", "numTasks": 10, "numCompleteTasks": 4},
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(responses)
+		case fmt.Sprintf("/api/v1/applications/%s/allexecutors", id):
+			responses := []map[string]interface{}{
+				{"id": "driver", "isActive": true},
+				{"id": "1", "isActive": true},
 			}
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusOK)
@@ -121,7 +166,7 @@ func createFakeSparkApplicationService(kubeClient kubernetes.Interface, id strin
 		}
 	}))
 
-	GetSparkMonitoringSvcDNS = func(id, namespace string, sparkPort int) string {
+	controllerUtil.GetSparkMonitoringSvcDNS = func(id, namespace string, sparkPort int) string {
 		return testServer.URL
 	}
 	return nil
@@ -177,7 +222,11 @@ func (f *fakeSparkApplicationClient) get(client kubernetes.Interface, name, name
 	}
 	f.mapMutex.Lock()
 	defer f.mapMutex.Unlock()
-	return *f.sparkApplications[namespacedName], nil
+	sa, ok := f.sparkApplications[namespacedName]
+	if !ok {
+		return sparkApp, apimachineryerrors.NewNotFound(schema.GroupResource{Resource: "sparkapplications"}, name)
+	}
+	return *sa, nil
 }
 
 func (f *fakeSparkApplicationClient) step(name, namespace string) {
@@ -206,10 +255,10 @@ func TestTADetection(t *testing.T) {
 	fakeSAClient := fakeSparkApplicationClient{
 		sparkApplications: make(map[apimachinerytypes.NamespacedName]*v1beta2.SparkApplication),
 	}
-	CreateSparkApplication = fakeSAClient.create
-	DeleteSparkApplication = fakeSAClient.delete
+	controllerUtil.CreateSparkApplication = fakeSAClient.create
+	controllerUtil.DeleteSparkApplication = fakeSAClient.delete
 	controllerUtil.ListSparkApplication = fakeSAClient.list
-	GetSparkApplication = fakeSAClient.get
+	controllerUtil.GetSparkApplication = fakeSAClient.get
 	os.Setenv("POD_NAMESPACE", testNamespace)
 	defer os.Unsetenv("POD_NAMESPACE")
 
@@ -227,9 +276,13 @@ func TestTADetection(t *testing.T) {
 
 	go tadController.Run(stopCh)
 
+	nsIgnoreCondition := "(sourcePodNamespace NOT IN (?, ?) AND destinationPodNamespace NOT IN (?, ?))"
+	nsIgnoreArgs := []driver.Value{"kube-system", "flow-visibility", "kube-system", "flow-visibility"}
 	tadtestCases := []struct {
-		name string
-		tad  *crdv1alpha1.ThroughputAnomalyDetector
+		name            string
+		tad             *crdv1alpha1.ThroughputAnomalyDetector
+		inputConditions string
+		inputArgs       []driver.Value
 	}{
 		{
 			name: "NormalAnomalyDetector agg_type external",
@@ -250,6 +303,8 @@ func TestTADetection(t *testing.T) {
 				},
 				Status: crdv1alpha1.ThroughputAnomalyDetectorStatus{},
 			},
+			inputConditions: nsIgnoreCondition + " AND flowType = 3 AND (destinationIP IN (?))",
+			inputArgs:       append(nsIgnoreArgs, "10.0.0.1"),
 		},
 		{
 			name: "NormalAnomalyDetector agg_type svc",
@@ -270,10 +325,59 @@ func TestTADetection(t *testing.T) {
 				},
 				Status: crdv1alpha1.ThroughputAnomalyDetectorStatus{},
 			},
+			inputConditions: nsIgnoreCondition + " AND (destinationServicePortName IN (?))",
+			inputArgs:       append(nsIgnoreArgs, "TestServicePortName"),
+		},
+		{
+			name: "NormalAnomalyDetector agg_type node",
+			tad: &crdv1alpha1.ThroughputAnomalyDetector{
+				ObjectMeta: metav1.ObjectMeta{Name: tadName, Namespace: testNamespace},
+				Spec: crdv1alpha1.ThroughputAnomalyDetectorSpec{
+					JobType:             "ARIMA",
+					ExecutorInstances:   1,
+					DriverCoreRequest:   "200m",
+					DriverMemory:        "512M",
+					ExecutorCoreRequest: "200m",
+					ExecutorMemory:      "512M",
+					StartInterval:       metav1.NewTime(time.Now()),
+					EndInterval:         metav1.NewTime(time.Now().Add(time.Second * 100)),
+					NSIgnoreList:        []string{"kube-system", "flow-visibility"},
+					AggregatedFlow:      "node",
+					NodeName:            "TestNodeName",
+				},
+				Status: crdv1alpha1.ThroughputAnomalyDetectorStatus{},
+			},
+			inputConditions: nsIgnoreCondition + " AND (sourceNodeName IN (?) OR destinationNodeName IN (?)) AND sourceNodeName <> '' AND destinationNodeName <> ''",
+			inputArgs:       append(nsIgnoreArgs, "TestNodeName", "TestNodeName"),
+		},
+		{
+			name: "NormalAnomalyDetector agg_type namespace",
+			tad: &crdv1alpha1.ThroughputAnomalyDetector{
+				ObjectMeta: metav1.ObjectMeta{Name: tadName, Namespace: testNamespace},
+				Spec: crdv1alpha1.ThroughputAnomalyDetectorSpec{
+					JobType:             "ARIMA",
+					ExecutorInstances:   1,
+					DriverCoreRequest:   "200m",
+					DriverMemory:        "512M",
+					ExecutorCoreRequest: "200m",
+					ExecutorMemory:      "512M",
+					StartInterval:       metav1.NewTime(time.Now()),
+					EndInterval:         metav1.NewTime(time.Now().Add(time.Second * 100)),
+					NSIgnoreList:        []string{"kube-system", "flow-visibility"},
+					AggregatedFlow:      "namespace",
+					PodNameSpace:        "TestPodNamespace",
+				},
+				Status: crdv1alpha1.ThroughputAnomalyDetectorStatus{},
+			},
+			inputConditions: nsIgnoreCondition + " AND ((destinationPodNamespace <> '') OR (sourcePodNamespace <> ''))",
+			inputArgs:       nsIgnoreArgs,
 		},
 	}
 	for _, tt := range tadtestCases {
 		t.Run(tt.name, func(t *testing.T) {
+			// The input of the job is estimated before it starts.
+			inputArgs := append(tt.inputArgs, tt.tad.Spec.StartInterval.UTC().Format(controllerUtil.InputTimeFormat), tt.tad.Spec.EndInterval.UTC().Format(controllerUtil.InputTimeFormat))
+			tadController.mock.ExpectQuery(estimateQuery + tt.inputConditions + " AND flowStartSeconds >= ? AND flowEndSeconds < ?").WithArgs(inputArgs...).WillReturnRows(sqlmock.NewRows([]string{"Rows", "Bytes"}).AddRow(100, 20000))
 			tad, err := tadController.CreateThroughputAnomalyDetector(testNamespace, tt.tad)
 			assert.NoError(t, err)
 
@@ -303,6 +407,10 @@ func TestTADetection(t *testing.T) {
 			assert.Equal(t, crdv1alpha1.ThroughputAnomalyDetectorStateCompleted, tad.Status.State)
 			assert.Equal(t, 3, tad.Status.CompletedStages)
 			assert.Equal(t, 5, tad.Status.TotalStages)
+			if assert.NotNil(t, tad.Status.Progress) {
+				assert.Equal(t, "groupBy", tad.Status.Progress.CurrentStage)
+				assert.Equal(t, 1, tad.Status.Progress.ActiveExecutors)
+			}
 			assert.True(t, tad.Status.StartTime.Before(&tad.Status.EndTime))
 
 			tadList, err := tadController.ListThroughputAnomalyDetector(testNamespace)
@@ -331,7 +439,7 @@ func TestTADetection(t *testing.T) {
 					JobType: "nonexistent-job-type",
 				},
 			},
-			expectedErrorMsg: "invalid request: Throughput Anomaly Detector algorithm type should be 'EWMA' or 'ARIMA' or 'DBSCAN'",
+			expectedErrorMsg: "invalid request: Throughput Anomaly Detector algorithm type should be 'EWMA' or 'ARIMA' or 'DBSCAN' or 'ENSEMBLE'",
 		},
 		{
 			name:    "invalid EndInterval",
@@ -441,7 +549,45 @@ func TestTADetection(t *testing.T) {
 					AggregatedFlow: "nonexistent-agg-flow",
 				},
 			},
-			expectedErrorMsg: "invalid request: Throughput Anomaly Detector aggregated flow type should be 'pod' or 'external' or 'svc'",
+			expectedErrorMsg: "invalid request: Throughput Anomaly Detector aggregated flow type should be 'pod' or 'external' or 'svc' or 'node' or 'namespace'",
+		},
+		{
+			name:    "invalid Metric",
+			tadName: "tad-invalid-metric",
+			tad: &crdv1alpha1.ThroughputAnomalyDetector{
+				ObjectMeta: metav1.ObjectMeta{Name: "tad-invalid-metric", Namespace: testNamespace},
+				Spec: crdv1alpha1.ThroughputAnomalyDetectorSpec{
+					JobType: "ARIMA",
+					Metric:  "nonexistent-metric",
+				},
+			},
+			expectedErrorMsg: "invalid request: Throughput Anomaly Detector metric should be 'throughput' or 'reverseThroughput' or 'packetRate' or 'octetDelta' or 'newConnections'",
+		},
+		{
+			name:    "invalid Metric newConnections without Aggregatedflow",
+			tadName: "tad-invalid-metric-new-connections",
+			tad: &crdv1alpha1.ThroughputAnomalyDetector{
+				ObjectMeta: metav1.ObjectMeta{Name: "tad-invalid-metric-new-connections", Namespace: testNamespace},
+				Spec: crdv1alpha1.ThroughputAnomalyDetectorSpec{
+					JobType: "ARIMA",
+					Metric:  "newConnections",
+				},
+			},
+			expectedErrorMsg: "invalid request: Throughput Anomaly Detector metric 'newConnections' requires an aggregated flow type",
+		},
+		{
+			name:    "invalid FlowFilter",
+			tadName: "tad-invalid-flow-filter",
+			tad: &crdv1alpha1.ThroughputAnomalyDetector{
+				ObjectMeta: metav1.ObjectMeta{Name: "tad-invalid-flow-filter", Namespace: testNamespace},
+				Spec: crdv1alpha1.ThroughputAnomalyDetectorSpec{
+					JobType: "ARIMA",
+					FlowFilter: &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+						Protocols: []string{"GRE"},
+					},
+				},
+			},
+			expectedErrorMsg: "invalid request: flowFilter.protocols[0]: Unsupported value: \"GRE\"",
 		},
 	}
 	for _, tc := range testCases {
@@ -464,3 +610,212 @@ func TestTADetection(t *testing.T) {
 		})
 	}
 }
+
+func TestGetAlgoParamsArgs(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		algo         string
+		params       crdv1alpha1.ThroughputAnomalyDetectorAlgoParams
+		expectedArgs []string
+		expectedErr  string
+	}{
+		{
+			name:         "EWMA parameters",
+			algo:         "EWMA",
+			params:       crdv1alpha1.ThroughputAnomalyDetectorAlgoParams{Sensitivity: 2.5, EWMAAlpha: 0.3},
+			expectedArgs: []string{"--sensitivity", "2.5", "--ewma-alpha", "0.3"},
+		},
+		{
+			name:         "ARIMA parameters",
+			algo:         "ARIMA",
+			params:       crdv1alpha1.ThroughputAnomalyDetectorAlgoParams{ARIMAOrder: []int{2, 1, 0}},
+			expectedArgs: []string{"--arima-order", "2,1,0"},
+		},
+		{
+			name:         "DBSCAN parameters",
+			algo:         "DBSCAN",
+			params:       crdv1alpha1.ThroughputAnomalyDetectorAlgoParams{DBSCANEps: 1e8, DBSCANMinSamples: 6},
+			expectedArgs: []string{"--dbscan-eps", "100000000", "--dbscan-min-samples", "6"},
+		},
+		{
+			name:        "sensitivity with DBSCAN",
+			algo:        "DBSCAN",
+			params:      crdv1alpha1.ThroughputAnomalyDetectorAlgoParams{Sensitivity: 2},
+			expectedErr: "sensitivity is only supported by the EWMA, ARIMA and ENSEMBLE algorithms",
+		},
+		{
+			name:        "invalid ewmaAlpha",
+			algo:        "EWMA",
+			params:      crdv1alpha1.ThroughputAnomalyDetectorAlgoParams{EWMAAlpha: 1.5},
+			expectedErr: "ewmaAlpha should be a number in (0, 1]",
+		},
+		{
+			name:        "ewmaAlpha with ARIMA",
+			algo:        "ARIMA",
+			params:      crdv1alpha1.ThroughputAnomalyDetectorAlgoParams{EWMAAlpha: 0.5},
+			expectedErr: "ewmaAlpha is only supported by the EWMA and ENSEMBLE algorithms",
+		},
+		{
+			name: "ENSEMBLE parameters",
+			algo: "ENSEMBLE",
+			params: crdv1alpha1.ThroughputAnomalyDetectorAlgoParams{
+				Sensitivity: 2,
+				EWMAAlpha:   0.3,
+				ARIMAOrder:  []int{2, 1, 0},
+				DBSCANEps:   1e8,
+				Quorum:      3,
+			},
+			expectedArgs: []string{"--sensitivity", "2", "--ewma-alpha", "0.3", "--arima-order", "2,1,0", "--dbscan-eps", "100000000", "--quorum", "3"},
+		},
+		{
+			name:        "quorum with EWMA",
+			algo:        "EWMA",
+			params:      crdv1alpha1.ThroughputAnomalyDetectorAlgoParams{Quorum: 2},
+			expectedErr: "quorum is only supported by the ENSEMBLE algorithm",
+		},
+		{
+			name:        "invalid quorum",
+			algo:        "ENSEMBLE",
+			params:      crdv1alpha1.ThroughputAnomalyDetectorAlgoParams{Quorum: 4},
+			expectedErr: "quorum should be an integer between 1 and 3",
+		},
+		{
+			name:        "invalid arimaOrder length",
+			algo:        "ARIMA",
+			params:      crdv1alpha1.ThroughputAnomalyDetectorAlgoParams{ARIMAOrder: []int{1, 1}},
+			expectedErr: "arimaOrder should have 3 elements (p, d, q)",
+		},
+		{
+			name:        "negative dbscanMinSamples",
+			algo:        "DBSCAN",
+			params:      crdv1alpha1.ThroughputAnomalyDetectorAlgoParams{DBSCANMinSamples: -1},
+			expectedErr: "dbscanMinSamples should be a positive integer",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := getAlgoParamsArgs(tt.algo, &tt.params)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+func TestRemoveExpiredSuppressions(t *testing.T) {
+	crdClient := fakecrd.NewSimpleClientset()
+	crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, informerDefaultResync)
+	taDetectorInformer := crdInformerFactory.Crd().V1alpha1().ThroughputAnomalyDetectors()
+	suppressionInformer := crdInformerFactory.Crd().V1alpha1().AnomalySuppressions()
+	tadController := NewAnomalyDetectorController(crdClient, fake.NewSimpleClientset(), nil, taDetectorInformer, suppressionInformer, nil, 0, controllerUtil.JobHistoryLimits{}, controllerUtil.JobInputBudget{})
+
+	now := time.Now()
+	for _, suppression := range []*crdv1alpha1.AnomalySuppression{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "expired", Namespace: testNamespace},
+			Spec:       crdv1alpha1.AnomalySuppressionSpec{PodName: "backup", ExpirationTime: metav1.NewTime(now.Add(-time.Minute))},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "active", Namespace: testNamespace},
+			Spec:       crdv1alpha1.AnomalySuppressionSpec{PodName: "backup", ExpirationTime: metav1.NewTime(now.Add(time.Hour))},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "permanent", Namespace: testNamespace},
+			Spec:       crdv1alpha1.AnomalySuppressionSpec{PodName: "backup"},
+		},
+	} {
+		_, err := crdClient.CrdV1alpha1().AnomalySuppressions(testNamespace).Create(context.TODO(), suppression, metav1.CreateOptions{})
+		assert.NoError(t, err)
+		assert.NoError(t, suppressionInformer.Informer().GetStore().Add(suppression))
+	}
+
+	tadController.removeExpiredSuppressions()
+	list, err := crdClient.CrdV1alpha1().AnomalySuppressions(testNamespace).List(context.TODO(), metav1.ListOptions{})
+	assert.NoError(t, err)
+	var names []string
+	for _, suppression := range list.Items {
+		names = append(names, suppression.Name)
+	}
+	assert.ElementsMatch(t, []string{"active", "permanent"}, names)
+}
+
+func TestEstimateJobInput(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", testNamespace)
+	budget := controllerUtil.JobInputBudget{MaxRows: 1000, Action: controllerUtil.JobInputBudgetActionReject}
+	testCases := []struct {
+		name               string
+		spec               crdv1alpha1.ThroughputAnomalyDetectorSpec
+		expectedConditions string
+		expectedArgs       []driver.Value
+		rows               int64
+		withinBudget       bool
+	}{
+		{
+			name:               "All Pods",
+			spec:               crdv1alpha1.ThroughputAnomalyDetectorSpec{AggregatedFlow: "pod"},
+			expectedConditions: "((destinationPodLabels <> '') OR (sourcePodLabels <> ''))",
+			rows:               1500,
+		},
+		{
+			name:               "Pod name and Namespace",
+			spec:               crdv1alpha1.ThroughputAnomalyDetectorSpec{AggregatedFlow: "pod", PodName: "backup", PodNameSpace: "ns1"},
+			expectedConditions: "((destinationPodName = ? AND destinationPodNamespace = ?) OR (sourcePodName = ? AND sourcePodNamespace = ?))",
+			expectedArgs:       []driver.Value{"backup", "ns1", "backup", "ns1"},
+			rows:               200,
+			withinBudget:       true,
+		},
+		{
+			name:               "Pod label",
+			spec:               crdv1alpha1.ThroughputAnomalyDetectorSpec{AggregatedFlow: "pod", PodLabel: "app=backup"},
+			expectedConditions: "((ilike(destinationPodLabels, ?)) OR (ilike(sourcePodLabels, ?)))",
+			expectedArgs:       []driver.Value{"%app=backup%", "%app=backup%"},
+			rows:               400,
+			withinBudget:       true,
+		},
+		{
+			name: "Namespace with flow filter",
+			spec: crdv1alpha1.ThroughputAnomalyDetectorSpec{
+				NSIgnoreList:   []string{"kube-system"},
+				AggregatedFlow: "namespace",
+				FlowFilter: &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+					Ports:     []crdv1alpha1.ThroughputAnomalyDetectorPortRange{{Start: 443}},
+					Protocols: []string{"TCP"},
+				},
+			},
+			expectedConditions: "(sourcePodNamespace NOT IN (?) AND destinationPodNamespace NOT IN (?)) AND ((destinationPodNamespace <> '') OR (sourcePodNamespace <> '')) AND (destinationTransportPort = 443 AND protocolIdentifier IN (6))",
+			expectedArgs:       []driver.Value{"kube-system", "kube-system"},
+			rows:               600,
+			withinBudget:       true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			db, mock := clickhouse.CreateFakeClickHouse(t, kubeClient, testNamespace)
+			defer db.Close()
+			crdClient := fakecrd.NewSimpleClientset()
+			crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, informerDefaultResync)
+			tadController := NewAnomalyDetectorController(crdClient, kubeClient, nil, crdInformerFactory.Crd().V1alpha1().ThroughputAnomalyDetectors(), crdInformerFactory.Crd().V1alpha1().AnomalySuppressions(), nil, 0, controllerUtil.JobHistoryLimits{}, budget)
+
+			spec := tc.spec
+			spec.JobType = "ARIMA"
+			spec.ExecutorInstances = 1
+			spec.DriverCoreRequest = "200m"
+			spec.DriverMemory = "512M"
+			spec.ExecutorCoreRequest = "200m"
+			spec.ExecutorMemory = "512M"
+			tad := &crdv1alpha1.ThroughputAnomalyDetector{
+				ObjectMeta: metav1.ObjectMeta{Name: tadName, Namespace: testNamespace},
+				Spec:       spec,
+			}
+			mock.ExpectQuery(estimateQuery + tc.expectedConditions).WithArgs(tc.expectedArgs...).WillReturnRows(sqlmock.NewRows([]string{"Rows", "Bytes"}).AddRow(tc.rows, tc.rows*200))
+			estimate, err := tadController.EstimateJobInput(tad)
+			require.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+			assert.Equal(t, &crdv1alpha1.JobInputEstimate{Rows: tc.rows, Bytes: tc.rows * 200}, estimate)
+			assert.Equal(t, tc.withinBudget, estimate.Rows <= budget.MaxRows)
+		})
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomalydetector
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	controllerutil "antrea.io/theia/pkg/controller"
+)
+
+var (
+	// protocolNumbers maps the protocols supported by flow filters to their
+	// IANA numbers, as stored in the protocolIdentifier column.
+	protocolNumbers = map[string]int{
+		"ICMP": 1,
+		"TCP":  6,
+		"UDP":  17,
+		"SCTP": 132,
+	}
+	// flowTypeConditions maps the flow types supported by flow filters to
+	// the matching conditions on the flows table. flowType 1 and 2 are the
+	// types of intra-Node and inter-Node flows between Pods, which go to a
+	// Service when they have a destination Service port name. flowType 3
+	// is the type of flows to external destinations, while flows from
+	// external sources (flowType 4) match no flow type.
+	flowTypeConditions = map[string]string{
+		"pod_to_pod":      "(flowType IN (1, 2) AND destinationServicePortName = '')",
+		"pod_to_svc":      "(flowType IN (1, 2) AND destinationServicePortName <> '')",
+		"pod_to_external": "flowType = 3",
+	}
+)
+
+// validateFlowFilter validates the flow filter of a
+// ThroughputAnomalyDetector. The values which are translated into the SQL
+// query of the job are all validated here, so that they can be quoted safely.
+func validateFlowFilter(filter *crdv1alpha1.ThroughputAnomalyDetectorFlowFilter) error {
+	var allErrs field.ErrorList
+	fldPath := field.NewPath("flowFilter")
+	allErrs = append(allErrs, validateFlowPeer(filter.Source, fldPath.Child("source"))...)
+	allErrs = append(allErrs, validateFlowPeer(filter.Destination, fldPath.Child("destination"))...)
+	for i, port := range filter.Ports {
+		if port.Start < 1 || port.Start > 65535 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("ports").Index(i).Child("start"), port.Start, "must be between 1 and 65535"))
+		}
+		if port.End != 0 && (port.End < port.Start || port.End > 65535) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("ports").Index(i).Child("end"), port.End, "must be between start and 65535"))
+		}
+	}
+	for i, protocol := range filter.Protocols {
+		if _, ok := protocolNumbers[protocol]; !ok {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("protocols").Index(i), protocol, []string{"TCP", "UDP", "SCTP", "ICMP"}))
+		}
+	}
+	for i, flowType := range filter.FlowTypes {
+		if _, ok := flowTypeConditions[flowType]; !ok {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("flowTypes").Index(i), flowType, []string{"pod_to_pod", "pod_to_svc", "pod_to_external"}))
+		}
+	}
+	return allErrs.ToAggregate()
+}
+
+func validateFlowPeer(peer *crdv1alpha1.ThroughputAnomalyDetectorFlowPeer, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if peer == nil {
+		return allErrs
+	}
+	allErrs = append(allErrs, metav1validation.ValidateLabelSelector(peer.NamespaceSelector, metav1validation.LabelSelectorValidationOptions{}, fldPath.Child("namespaceSelector"))...)
+	allErrs = append(allErrs, metav1validation.ValidateLabelSelector(peer.PodSelector, metav1validation.LabelSelectorValidationOptions{}, fldPath.Child("podSelector"))...)
+	for i, cidr := range peer.CIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("cidrs").Index(i), cidr, "must be a valid CIDR"))
+		}
+	}
+	return allErrs
+}
+
+// getFlowFilterExpr translates a validated flow filter into a ClickHouse
+// boolean expression on the flows table. Namespace selectors are evaluated
+// against the Namespaces existing when the function is called.
+func getFlowFilterExpr(kubeClient kubernetes.Interface, filter *crdv1alpha1.ThroughputAnomalyDetectorFlowFilter) (string, error) {
+	var conditions []string
+	for _, peer := range []struct {
+		prefix string
+		peer   *crdv1alpha1.ThroughputAnomalyDetectorFlowPeer
+	}{
+		{"source", filter.Source},
+		{"destination", filter.Destination},
+	} {
+		if peer.peer == nil {
+			continue
+		}
+		peerConditions, err := getFlowPeerConditions(kubeClient, peer.peer, peer.prefix)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, peerConditions...)
+	}
+	if len(filter.Ports) > 0 {
+		var portConditions []string
+		for _, port := range filter.Ports {
+			if port.End == 0 || port.End == port.Start {
+				portConditions = append(portConditions, fmt.Sprintf("destinationTransportPort = %d", port.Start))
+			} else {
+				portConditions = append(portConditions, fmt.Sprintf("destinationTransportPort BETWEEN %d AND %d", port.Start, port.End))
+			}
+		}
+		conditions = append(conditions, anyOf(portConditions))
+	}
+	if len(filter.Protocols) > 0 {
+		var protocols []string
+		for _, protocol := range filter.Protocols {
+			protocols = append(protocols, fmt.Sprint(protocolNumbers[protocol]))
+		}
+		conditions = append(conditions, fmt.Sprintf("protocolIdentifier IN (%s)", strings.Join(protocols, ", ")))
+	}
+	if len(filter.FlowTypes) > 0 {
+		var flowTypes []string
+		for _, flowType := range filter.FlowTypes {
+			flowTypes = append(flowTypes, flowTypeConditions[flowType])
+		}
+		conditions = append(conditions, anyOf(flowTypes))
+	}
+	return strings.Join(conditions, " AND "), nil
+}
+
+func getFlowPeerConditions(kubeClient kubernetes.Interface, peer *crdv1alpha1.ThroughputAnomalyDetectorFlowPeer, prefix string) ([]string, error) {
+	var conditions []string
+	if peer.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
+		if err != nil {
+			return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: invalid namespaceSelector: %v", err)}
+		}
+		namespaceList, err := kubeClient.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Namespaces: %v", err)
+		}
+		if len(namespaceList.Items) == 0 {
+			return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: namespaceSelector %q matches no Namespace", selector.String())}
+		}
+		var namespaces []string
+		for _, namespace := range namespaceList.Items {
+			namespaces = append(namespaces, quoteString(namespace.Name))
+		}
+		sort.Strings(namespaces)
+		conditions = append(conditions, fmt.Sprintf("%sPodNamespace IN (%s)", prefix, strings.Join(namespaces, ", ")))
+	}
+	if peer.PodSelector != nil {
+		conditions = append(conditions, getPodSelectorConditions(peer.PodSelector, prefix+"PodLabels")...)
+	}
+	if len(peer.CIDRs) > 0 {
+		var cidrConditions []string
+		for _, cidr := range peer.CIDRs {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: invalid CIDR %q: %v", cidr, err)}
+			}
+			cidrConditions = append(cidrConditions, fmt.Sprintf("isIPAddressInRange(%sIP, %s)", prefix, quoteString(ipNet.String())))
+		}
+		conditions = append(conditions, anyOf(cidrConditions))
+	}
+	return conditions, nil
+}
+
+// getPodSelectorConditions translates a Pod label selector into conditions
+// on the given column, which stores the Pod labels in JSON format. A Pod
+// without labels never matches a non-empty selector.
+func getPodSelectorConditions(selector *metav1.LabelSelector, column string) []string {
+	var conditions []string
+	keys := make([]string, 0, len(selector.MatchLabels))
+	for key := range selector.MatchLabels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		conditions = append(conditions, fmt.Sprintf("JSONExtractString(%s, %s) = %s", column, quoteString(key), quoteString(selector.MatchLabels[key])))
+	}
+	for _, expr := range selector.MatchExpressions {
+		has := fmt.Sprintf("JSONHas(%s, %s)", column, quoteString(expr.Key))
+		var values []string
+		for _, value := range expr.Values {
+			values = append(values, quoteString(value))
+		}
+		value := fmt.Sprintf("JSONExtractString(%s, %s)", column, quoteString(expr.Key))
+		switch expr.Operator {
+		case metav1.LabelSelectorOpIn:
+			conditions = append(conditions, fmt.Sprintf("(%s AND %s IN (%s))", has, value, strings.Join(values, ", ")))
+		case metav1.LabelSelectorOpNotIn:
+			conditions = append(conditions, fmt.Sprintf("(NOT %s OR %s NOT IN (%s))", has, value, strings.Join(values, ", ")))
+		case metav1.LabelSelectorOpExists:
+			conditions = append(conditions, has)
+		case metav1.LabelSelectorOpDoesNotExist:
+			conditions = append(conditions, "NOT "+has)
+		}
+	}
+	return conditions
+}
+
+func anyOf(conditions []string) string {
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")"
+}
+
+// quoteString quotes a string as a ClickHouse string literal.
+func quoteString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,204 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomalydetector
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	controllerutil "antrea.io/theia/pkg/controller"
+)
+
+func TestValidateFlowFilter(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		filter      *crdv1alpha1.ThroughputAnomalyDetectorFlowFilter
+		expectedErr string
+	}{
+		{
+			name: "valid filter",
+			filter: &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+				Source: &crdv1alpha1.ThroughputAnomalyDetectorFlowPeer{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+					PodSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"web", "api"}},
+					}},
+				},
+				Destination: &crdv1alpha1.ThroughputAnomalyDetectorFlowPeer{
+					CIDRs: []string{"0.0.0.0/0", "::/0"},
+				},
+				Ports:     []crdv1alpha1.ThroughputAnomalyDetectorPortRange{{Start: 443}, {Start: 8000, End: 8080}},
+				Protocols: []string{"TCP", "UDP"},
+				FlowTypes: []string{"pod_to_external"},
+			},
+		},
+		{
+			name: "invalid pod selector",
+			filter: &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+				Source: &crdv1alpha1.ThroughputAnomalyDetectorFlowPeer{
+					PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web' OR 1=1 --"}},
+				},
+			},
+			expectedErr: "flowFilter.source.podSelector.matchLabels: Invalid value",
+		},
+		{
+			name: "invalid CIDR",
+			filter: &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+				Destination: &crdv1alpha1.ThroughputAnomalyDetectorFlowPeer{
+					CIDRs: []string{"10.0.0.1"},
+				},
+			},
+			expectedErr: "flowFilter.destination.cidrs[0]: Invalid value: \"10.0.0.1\": must be a valid CIDR",
+		},
+		{
+			name: "invalid port range",
+			filter: &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+				Ports: []crdv1alpha1.ThroughputAnomalyDetectorPortRange{{Start: 8080, End: 8000}},
+			},
+			expectedErr: "flowFilter.ports[0].end: Invalid value: 8000: must be between start and 65535",
+		},
+		{
+			name: "invalid port",
+			filter: &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+				Ports: []crdv1alpha1.ThroughputAnomalyDetectorPortRange{{Start: 0}},
+			},
+			expectedErr: "flowFilter.ports[0].start: Invalid value: 0: must be between 1 and 65535",
+		},
+		{
+			name: "invalid flow type",
+			filter: &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+				FlowTypes: []string{"external"},
+			},
+			expectedErr: "flowFilter.flowTypes[0]: Unsupported value: \"external\"",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFlowFilter(tt.filter)
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestGetFlowFilterExpr(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod-web", Labels: map[string]string{"env": "prod"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod-db", Labels: map[string]string{"env": "prod"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dev", Labels: map[string]string{"env": "dev"}}},
+	)
+	for _, tt := range []struct {
+		name         string
+		filter       *crdv1alpha1.ThroughputAnomalyDetectorFlowFilter
+		expectedExpr string
+		expectedErr  string
+	}{
+		{
+			name:         "empty filter",
+			filter:       &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{},
+			expectedExpr: "",
+		},
+		{
+			name: "egress to the internet from prod Namespaces",
+			filter: &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+				Source: &crdv1alpha1.ThroughputAnomalyDetectorFlowPeer{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+				},
+				FlowTypes: []string{"pod_to_external"},
+			},
+			expectedExpr: "sourcePodNamespace IN ('prod-db', 'prod-web') AND flowType = 3",
+		},
+		{
+			name: "pod selector",
+			filter: &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+				Destination: &crdv1alpha1.ThroughputAnomalyDetectorFlowPeer{
+					PodSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"tier": "backend", "app": "db"},
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "version", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"v1"}},
+							{Key: "canary", Operator: metav1.LabelSelectorOpDoesNotExist},
+						},
+					},
+				},
+			},
+			expectedExpr: "JSONExtractString(destinationPodLabels, 'app') = 'db' AND " +
+				"JSONExtractString(destinationPodLabels, 'tier') = 'backend' AND " +
+				"(NOT JSONHas(destinationPodLabels, 'version') OR JSONExtractString(destinationPodLabels, 'version') NOT IN ('v1')) AND " +
+				"NOT JSONHas(destinationPodLabels, 'canary')",
+		},
+		{
+			name: "CIDRs, ports, protocols and flow types",
+			filter: &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+				Source: &crdv1alpha1.ThroughputAnomalyDetectorFlowPeer{
+					CIDRs: []string{"10.10.1.5/16"},
+				},
+				Destination: &crdv1alpha1.ThroughputAnomalyDetectorFlowPeer{
+					CIDRs: []string{"192.168.0.0/24", "fd00::/8"},
+				},
+				Ports:     []crdv1alpha1.ThroughputAnomalyDetectorPortRange{{Start: 53}, {Start: 8000, End: 8080}},
+				Protocols: []string{"TCP", "UDP"},
+				FlowTypes: []string{"pod_to_pod", "pod_to_svc"},
+			},
+			expectedExpr: "isIPAddressInRange(sourceIP, '10.10.0.0/16') AND " +
+				"(isIPAddressInRange(destinationIP, '192.168.0.0/24') OR isIPAddressInRange(destinationIP, 'fd00::/8')) AND " +
+				"(destinationTransportPort = 53 OR destinationTransportPort BETWEEN 8000 AND 8080) AND " +
+				"protocolIdentifier IN (6, 17) AND " +
+				"((flowType IN (1, 2) AND destinationServicePortName = '') OR (flowType IN (1, 2) AND destinationServicePortName <> ''))",
+		},
+		{
+			name: "flows from external sources match no flow type",
+			filter: &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+				FlowTypes: []string{"pod_to_pod", "pod_to_svc", "pod_to_external"},
+			},
+			expectedExpr: "((flowType IN (1, 2) AND destinationServicePortName = '') OR " +
+				"(flowType IN (1, 2) AND destinationServicePortName <> '') OR flowType = 3)",
+		},
+		{
+			name: "namespace selector matching no Namespace",
+			filter: &crdv1alpha1.ThroughputAnomalyDetectorFlowFilter{
+				Destination: &crdv1alpha1.ThroughputAnomalyDetectorFlowPeer{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"env": "staging"}},
+				},
+			},
+			expectedErr: "invalid request: namespaceSelector \"env=staging\" matches no Namespace",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, validateFlowFilter(tt.filter))
+			expr, err := getFlowFilterExpr(kubeClient, tt.filter)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Equal(t, reflect.TypeOf(controllerutil.IllegalArgumentError{}), reflect.TypeOf(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedExpr, expr)
+		})
+	}
+}
+
+func TestQuoteString(t *testing.T) {
+	assert.Equal(t, `'prod'`, quoteString("prod"))
+	assert.Equal(t, `'it\'s \\ quoted'`, quoteString(`it's \ quoted`))
+}
@@ -0,0 +1,1158 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	"antrea.io/theia/pkg/util/clickhouse"
+	"antrea.io/theia/pkg/util/env"
+	sparkv1 "antrea.io/theia/third_party/sparkoperator/v1beta2"
+)
+
+// States of the analytics jobs. They match the states of all the job CRDs.
+const (
+	JobStateNew       string = "NEW"
+	JobStateScheduled string = "SCHEDULED"
+	JobStateRunning   string = "RUNNING"
+	JobStateCompleted string = "COMPLETED"
+	JobStateFailed    string = "FAILED"
+)
+
+const (
+	// JobCleanupFinalizer is added to every job, so that its Spark
+	// Application and its results in ClickHouse are removed before the job
+	// itself is deleted, even if theia-manager restarts in-between.
+	JobCleanupFinalizer = "crd.theia.antrea.io/job-cleanup"
+	// StaleResourceSweepPeriod is the period of the removal of the Spark
+	// Applications and the ClickHouse results which have no matching job,
+	// e.g. because their job was deleted before it had a finalizer.
+	StaleResourceSweepPeriod = 30 * time.Minute
+	// JobReasonDeadlineExceeded prefixes the error message of the jobs which
+	// were stopped because they were active longer than their deadline.
+	JobReasonDeadlineExceeded = "DeadlineExceeded"
+)
+
+// JobStatus is the status shared by all the analytics job CRDs.
+type JobStatus struct {
+	State            string
+	SparkApplication string
+	CompletedStages  int
+	TotalStages      int
+	Progress         *crdv1alpha1.JobProgress
+	InputEstimate    *crdv1alpha1.JobInputEstimate
+	ErrorMsg         string
+	StartTime        metav1.Time
+	EndTime          metav1.Time
+}
+
+// SparkJob describes the Spark Application running an analytics job.
+type SparkJob struct {
+	// MainApplicationFile is the Python file run by the Spark Application.
+	MainApplicationFile string
+	// Arguments are the arguments of the Python file, without the job ID.
+	Arguments           []string
+	ExecutorInstances   int
+	DriverCoreRequest   string
+	DriverMemory        string
+	ExecutorCoreRequest string
+	ExecutorMemory      string
+}
+
+// JobHistoryLimits are the numbers of finished jobs of a kind which are
+// retained. The oldest jobs beyond the limits are deleted with their results.
+// All the jobs are retained if a limit is 0.
+type JobHistoryLimits struct {
+	// Successful is the number of completed jobs to retain.
+	Successful int
+	// Failed is the number of failed jobs to retain.
+	Failed int
+}
+
+// IllegalArgumentError is returned by a JobHandler when a job cannot run
+// because of its spec. The job is marked as failed and is not retried.
+type IllegalArgumentError struct {
+	Err error
+}
+
+func (e IllegalArgumentError) Error() string {
+	return e.Err.Error()
+}
+
+// JobHandler implements the parts of an analytics job which are specific to
+// its kind. A JobController runs the jobs of a JobHandler as Spark
+// Applications and tracks them until they complete.
+type JobHandler interface {
+	// Kind returns the kind of the job resources, e.g. "ThroughputAnomalyDetector".
+	Kind() string
+	// NamePrefix returns the prefix of the job names. The rest of a job name
+	// is the job ID, which identifies the job results in ClickHouse.
+	NamePrefix() string
+	// SparkAppLabels returns the labels of the Spark Applications created for
+	// the jobs.
+	SparkAppLabels() map[string]string
+	// ResultTables returns the ClickHouse table storing the job results and
+	// its local table, from which the results of deleted jobs are removed.
+	ResultTables() (table string, localTable string)
+	// GetJob returns the job with the given Namespace and name.
+	GetJob(namespace, name string) (metav1.Object, error)
+	// ListJobs returns the jobs in the given Namespace.
+	ListJobs(namespace string) ([]metav1.Object, error)
+	// GetJobStatus returns the status of a job.
+	GetJobStatus(job metav1.Object) JobStatus
+	// UpdateJobStatus replaces the status of a job.
+	UpdateJobStatus(job metav1.Object, status JobStatus) error
+	// UpdateJobFinalizers replaces the finalizers of a job and returns the
+	// updated job.
+	UpdateJobFinalizers(job metav1.Object, finalizers []string) (metav1.Object, error)
+	// DeleteJob deletes a job. Its resources are cleaned up before it is
+	// removed thanks to its finalizer.
+	DeleteJob(job metav1.Object) error
+	// GetSparkJob validates the spec of a job and returns the Spark job
+	// running it. It returns an IllegalArgumentError if the spec is invalid.
+	GetSparkJob(job metav1.Object) (*SparkJob, error)
+	// ValidateJobName returns an IllegalArgumentError if a job name is not
+	// made of NamePrefix and a valid job ID.
+	ValidateJobName(name string) error
+	// GetActiveDeadlineSeconds returns the maximum duration of a job once it
+	// is started, in seconds. 0 means that the default of the JobController
+	// applies.
+	GetActiveDeadlineSeconds(job metav1.Object) int64
+	// GetTTLSecondsAfterFinished returns the duration after which a finished
+	// job is deleted, in seconds. A job is retained if it returns nil.
+	GetTTLSecondsAfterFinished(job metav1.Object) *int32
+	// GetJobInput returns the flow records read by a job, whose size is
+	// estimated before its Spark Application is created.
+	GetJobInput(job metav1.Object) (JobInput, error)
+	// JobCompleted is called once the results of a job are available.
+	JobCompleted(job metav1.Object)
+}
+
+// JobController reconciles the analytics jobs of a JobHandler. It starts a
+// Spark Application for every new job, tracks its progress and cleans up the
+// Spark Application and the ClickHouse results before the job is deleted.
+type JobController struct {
+	name       string
+	handler    JobHandler
+	kubeClient kubernetes.Interface
+
+	jobSynced cache.InformerSynced
+	// sparkAppInformer watches the Spark Applications of the jobs, so that
+	// their state transitions are synced immediately. The Spark Applications
+	// are read from the API server if it is nil.
+	sparkAppInformer cache.SharedIndexInformer
+	// progressRateLimiter limits the requests to the monitoring Services of
+	// the Spark Applications.
+	progressRateLimiter flowcontrol.RateLimiter
+	// queue maintains the jobs that need to be synced.
+	queue                  workqueue.RateLimitingInterface
+	gcQueue                workqueue.RateLimitingInterface
+	resyncPeriod           time.Duration
+	periodicResyncSetMutex sync.Mutex
+	periodicResyncSet      map[apimachinerytypes.NamespacedName]struct{}
+	// clickhouseConnectMutex guards the set up of clickhouseConnect, which is
+	// shared by the workers.
+	clickhouseConnectMutex sync.Mutex
+	clickhouseConnect      *sql.DB
+	// defaultActiveDeadline is the maximum duration of the jobs which don't
+	// set their own. Jobs never time out if it is 0.
+	defaultActiveDeadline time.Duration
+	historyLimits         JobHistoryLimits
+	inputBudget           JobInputBudget
+}
+
+// NewJobController returns a JobController named name which reconciles the
+// jobs of handler watched by jobInformer. Jobs are synced when their Spark
+// Application watched by sparkAppInformer changes, if it is not nil. Scheduled
+// and running jobs are also checked every resyncPeriod, which updates the
+// progress of running jobs, and stopped once they are active longer than
+// their deadline, which is defaultActiveDeadline unless they set one. Finished
+// jobs are deleted once their TTL expires or beyond historyLimits. Jobs whose
+// estimated input exceeds inputBudget are rejected or scaled up.
+func NewJobController(
+	name string,
+	handler JobHandler,
+	kubeClient kubernetes.Interface,
+	jobInformer cache.SharedIndexInformer,
+	sparkAppInformer cache.SharedIndexInformer,
+	resyncPeriod time.Duration,
+	defaultActiveDeadline time.Duration,
+	historyLimits JobHistoryLimits,
+	inputBudget JobInputBudget,
+) *JobController {
+	queueName := strings.ToLower(handler.Kind()[:1]) + handler.Kind()[1:]
+	c := &JobController{
+		name:                  name,
+		handler:               handler,
+		kubeClient:            kubeClient,
+		jobSynced:             jobInformer.HasSynced,
+		sparkAppInformer:      sparkAppInformer,
+		progressRateLimiter:   flowcontrol.NewTokenBucketRateLimiter(SparkProgressQPS, SparkProgressBurst),
+		queue:                 workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(MinRetryDelay, MaxRetryDelay), queueName),
+		gcQueue:               workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(MinRetryDelay, MaxRetryDelay), queueName+"GarbageCollection"),
+		resyncPeriod:          resyncPeriod,
+		periodicResyncSet:     make(map[apimachinerytypes.NamespacedName]struct{}),
+		defaultActiveDeadline: defaultActiveDeadline,
+		historyLimits:         historyLimits,
+		inputBudget:           inputBudget,
+	}
+
+	jobInformer.AddEventHandlerWithResyncPeriod(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.addJob,
+			UpdateFunc: c.updateJob,
+			DeleteFunc: c.deleteJob,
+		},
+		ResyncPeriod,
+	)
+	if sparkAppInformer != nil {
+		sparkAppInformer.AddEventHandlerWithResyncPeriod(
+			cache.ResourceEventHandlerFuncs{
+				AddFunc:    c.enqueueSparkApplicationOwner,
+				UpdateFunc: func(_, new interface{}) { c.enqueueSparkApplicationOwner(new) },
+				DeleteFunc: c.enqueueSparkApplicationOwner,
+			},
+			ResyncPeriod,
+		)
+	}
+
+	return c
+}
+
+func (c *JobController) addJob(obj interface{}) {
+	job, ok := obj.(metav1.Object)
+	if !ok {
+		klog.ErrorS(nil, "fail to convert to job", "kind", c.handler.Kind(), "object", obj)
+		return
+	}
+	klog.V(2).InfoS("Processing job ADD event", "kind", c.handler.Kind(), "name", job.GetName(), "labels", job.GetLabels())
+	c.queue.Add(apimachinerytypes.NamespacedName{
+		Namespace: job.GetNamespace(),
+		Name:      job.GetName(),
+	})
+}
+
+func (c *JobController) updateJob(_, new interface{}) {
+	job, ok := new.(metav1.Object)
+	if !ok {
+		klog.ErrorS(nil, "fail to convert to job", "kind", c.handler.Kind(), "object", new)
+		return
+	}
+	klog.V(2).InfoS("Processing job UPDATE event", "kind", c.handler.Kind(), "name", job.GetName(), "labels", job.GetLabels())
+	c.queue.Add(apimachinerytypes.NamespacedName{
+		Namespace: job.GetNamespace(),
+		Name:      job.GetName(),
+	})
+}
+
+func (c *JobController) deleteJob(old interface{}) {
+	job, ok := old.(metav1.Object)
+	if !ok {
+		tombstone, ok := old.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			klog.ErrorS(nil, "Error decoding object when deleting job", "kind", c.handler.Kind(), "oldObject", old)
+			return
+		}
+		job, ok = tombstone.Obj.(metav1.Object)
+		if !ok {
+			klog.ErrorS(nil, "Error decoding object tombstone when deleting job", "kind", c.handler.Kind(), "tombstone", tombstone.Obj)
+			return
+		}
+	}
+	klog.V(2).InfoS("Processing job DELETE event", "kind", c.handler.Kind(), "name", job.GetName(), "labels", job.GetLabels())
+	// The Spark Application and the results of the job have been cleaned up
+	// before its finalizer was removed. The job may still be in the periodic
+	// synchronization list if it was deleted without the finalizer.
+	c.stopPeriodicSync(apimachinerytypes.NamespacedName{
+		Namespace: job.GetNamespace(),
+		Name:      job.GetName(),
+	})
+}
+
+// enqueueSparkApplicationOwner syncs the job owning a Spark Application after
+// the Spark Application is changed.
+func (c *JobController) enqueueSparkApplicationOwner(obj interface{}) {
+	sparkApp, ok := obj.(metav1.Object)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			klog.ErrorS(nil, "Error decoding Spark Application", "object", obj)
+			return
+		}
+		sparkApp, ok = tombstone.Obj.(metav1.Object)
+		if !ok {
+			klog.ErrorS(nil, "Error decoding Spark Application tombstone", "tombstone", tombstone.Obj)
+			return
+		}
+	}
+	owner := metav1.GetControllerOf(sparkApp)
+	if owner == nil || owner.Kind != c.handler.Kind() {
+		return
+	}
+	klog.V(4).InfoS("Processing Spark Application event", "name", sparkApp.GetName(), c.handler.Kind(), owner.Name)
+	c.queue.Add(apimachinerytypes.NamespacedName{
+		Namespace: sparkApp.GetNamespace(),
+		Name:      owner.Name,
+	})
+}
+
+// Run will create defaultWorkers workers (go routines) which will process the job events from the
+// workqueue.
+func (c *JobController) Run(stopCh <-chan struct{}) {
+	defer c.queue.ShutDown()
+	defer c.gcQueue.ShutDown()
+
+	klog.InfoS("Starting controller", "name", c.name)
+	defer klog.InfoS("Shutting down controller", "name", c.name)
+
+	cacheSyncs := []cache.InformerSynced{c.jobSynced}
+	if c.sparkAppInformer != nil {
+		go c.sparkAppInformer.Run(stopCh)
+		cacheSyncs = append(cacheSyncs, c.sparkAppInformer.HasSynced)
+	}
+	if !cache.WaitForNamedCacheSync(c.name, stopCh, cacheSyncs...) {
+		return
+	}
+
+	c.gcQueue.Add(GcKey{
+		AddResync: true,
+	})
+	go wait.Until(c.sweepStaleResources, StaleResourceSweepPeriod, stopCh)
+	go wait.Until(c.gcworker, time.Second, stopCh)
+
+	go wait.Until(c.resyncJobs, c.resyncPeriod, stopCh)
+
+	for i := 0; i < DefaultWorkers; i++ {
+		go wait.Until(c.worker, time.Second, stopCh)
+	}
+	<-stopCh
+}
+
+func (c *JobController) ifJobExists(namespace, name string) error {
+	_, err := c.handler.GetJob(env.GetTheiaNamespace(), name)
+	return err
+}
+
+// handleStaleResources handles the stale Spark Applications and database entries.
+// It will delete the dangling resources without a matching job and add the
+// running jobs back to the periodical watch list.
+func (c *JobController) handleStaleResources(key GcKey) (updatedKey GcKey, err error) {
+	var errorList []error
+	if key.AddResync {
+		// Add scheduled/running jobs back to resync list
+		jobs, err := c.handler.ListJobs(env.GetTheiaNamespace())
+		if err != nil {
+			errorList = append(errorList, fmt.Errorf("failed to list %ss: %v", c.handler.Kind(), err))
+		} else {
+			for _, job := range jobs {
+				state := c.handler.GetJobStatus(job).State
+				if state == JobStateScheduled || state == JobStateRunning {
+					c.addPeriodicSync(apimachinerytypes.NamespacedName{
+						Namespace: job.GetNamespace(),
+						Name:      job.GetName(),
+					})
+				}
+			}
+			key.AddResync = false
+		}
+	}
+	if key.RemoveStaleDbEntries {
+		// The connection of the controller is shared, instead of setting up
+		// a new one at every sweep.
+		connect, err := c.GetClickHouseConnection()
+		if err == nil {
+			table, localTable := c.handler.ResultTables()
+			err = HandleStaleDbEntries(connect, c.kubeClient, table, localTable, c.ifJobExists, c.handler.NamePrefix())
+		}
+		if err != nil {
+			errorList = append(errorList, err)
+		} else {
+			key.RemoveStaleDbEntries = false
+		}
+	}
+
+	if key.PruneJobHistory {
+		if err = c.pruneJobHistory(); err != nil {
+			errorList = append(errorList, err)
+		} else {
+			key.PruneJobHistory = false
+		}
+	}
+
+	if key.RemoveStaleCredentials {
+		if err = c.removeStaleJobCredentials(); err != nil {
+			errorList = append(errorList, err)
+		} else {
+			key.RemoveStaleCredentials = false
+		}
+	}
+
+	if key.RemoveStaleSparkApp {
+		err = HandleStaleSparkApp(c.kubeClient, labels.SelectorFromSet(c.handler.SparkAppLabels()).String(), c.ifJobExists)
+		if err != nil {
+			errorList = append(errorList, err)
+		} else {
+			key.RemoveStaleSparkApp = false
+		}
+	}
+
+	if len(errorList) > 0 {
+		return key, fmt.Errorf("failed during garbage collection: %v", errorList)
+	}
+	return key, nil
+}
+
+// sweepStaleResources schedules the removal of the Spark Applications and
+// the ClickHouse results which have no matching job.
+func (c *JobController) sweepStaleResources() {
+	c.gcQueue.Add(GcKey{
+		RemoveStaleDbEntries:   true,
+		RemoveStaleSparkApp:    true,
+		RemoveStaleCredentials: true,
+		PruneJobHistory:        c.historyLimits.Successful > 0 || c.historyLimits.Failed > 0,
+	})
+}
+
+func (c *JobController) gcworker() {
+	for c.processNextGcWorkItem() {
+	}
+}
+
+func (c *JobController) processNextGcWorkItem() bool {
+	obj, quit := c.gcQueue.Get()
+	if quit {
+		return false
+	}
+	defer c.gcQueue.Done(obj)
+
+	if key, ok := obj.(GcKey); !ok {
+		c.gcQueue.Forget(obj)
+		klog.ErrorS(nil, "Expected gcKey in work queue", "got", obj)
+	} else if updatedKey, err := c.handleStaleResources(key); err == nil {
+		c.gcQueue.Forget(key)
+	} else {
+		klog.ErrorS(err, "Error handling stale resources, requeuing it")
+		c.gcQueue.AddRateLimited(updatedKey)
+	}
+	return true
+}
+
+// worker is a long-running function that will continually call the processNextWorkItem function in
+// order to read and process a message on the workqueue.
+func (c *JobController) worker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *JobController) resyncJobs() {
+	c.periodicResyncSetMutex.Lock()
+	jobs := make([]apimachinerytypes.NamespacedName, 0, len(c.periodicResyncSet))
+	for jobNamespacedName := range c.periodicResyncSet {
+		jobs = append(jobs, jobNamespacedName)
+	}
+	c.periodicResyncSetMutex.Unlock()
+	for _, jobNamespacedName := range jobs {
+		c.queue.Add(jobNamespacedName)
+	}
+}
+
+func (c *JobController) processNextWorkItem() bool {
+	obj, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(obj)
+	if key, ok := obj.(apimachinerytypes.NamespacedName); !ok {
+		c.queue.Forget(obj)
+		klog.ErrorS(nil, "Expected job in work queue", "kind", c.handler.Kind(), "got", obj)
+		return true
+	} else if err := c.syncJob(key); err == nil {
+		// If no error occurs we forget this item so it does not get queued again until
+		// another change happens.
+		c.queue.Forget(key)
+	} else {
+		// Put the item back on the workqueue to handle any transient errors.
+		c.queue.AddRateLimited(key)
+		klog.ErrorS(err, "Error when syncing job, requeuing", "kind", c.handler.Kind(), "key", key)
+	}
+	return true
+}
+
+func (c *JobController) syncJob(key apimachinerytypes.NamespacedName) error {
+	startTime := time.Now()
+	defer func() {
+		klog.V(4).InfoS("Finished syncing job", "kind", c.handler.Kind(), "key", key, "time", time.Since(startTime))
+	}()
+
+	job, err := c.handler.GetJob(key.Namespace, key.Name)
+	if err != nil {
+		// Job already deleted
+		if apimachineryerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if job.GetDeletionTimestamp() != nil {
+		return c.cleanupJob(job)
+	}
+	if !hasJobCleanupFinalizer(job) {
+		finalizers := append(append([]string{}, job.GetFinalizers()...), JobCleanupFinalizer)
+		job, err = c.handler.UpdateJobFinalizers(job, finalizers)
+		if err != nil {
+			return fmt.Errorf("failed to add finalizer to %s %s: %v", c.handler.Kind(), key.Name, err)
+		}
+	}
+	status := c.handler.GetJobStatus(job)
+	klog.V(4).InfoS("Syncing job", "kind", c.handler.Kind(), "name", key.Name, "state", status.State)
+
+	switch status.State {
+	case "", JobStateNew:
+		err = c.startJob(job)
+	case JobStateScheduled, JobStateRunning:
+		if deadline := c.activeDeadline(job); deadlineExceeded(status.StartTime, deadline, time.Now()) {
+			err = c.stopJob(job, deadline)
+		} else if status.State == JobStateScheduled {
+			_, err = c.checkSparkApplicationStatus(job)
+		} else {
+			err = c.updateProgress(job)
+		}
+	case JobStateCompleted:
+		if status.EndTime.IsZero() {
+			err = c.finishJob(job)
+		} else {
+			err = c.retainFinishedJob(job, status)
+		}
+	case JobStateFailed:
+		err = c.retainFinishedJob(job, status)
+	}
+	return err
+}
+
+func hasJobCleanupFinalizer(job metav1.Object) bool {
+	for _, finalizer := range job.GetFinalizers() {
+		if finalizer == JobCleanupFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanupJob removes the Spark Application and the results of a job which is
+// being deleted, then removes its finalizer to let the deletion complete.
+func (c *JobController) cleanupJob(job metav1.Object) error {
+	if !hasJobCleanupFinalizer(job) {
+		return nil
+	}
+	// remove the job from periodic synchronization list in case it is deleted before completing
+	c.stopPeriodicSync(apimachinerytypes.NamespacedName{
+		Namespace: job.GetNamespace(),
+		Name:      job.GetName(),
+	})
+	if id := c.handler.GetJobStatus(job).SparkApplication; id != "" {
+		if err := c.cleanupSparkApplication(job.GetNamespace(), id); err != nil {
+			return fmt.Errorf("failed to clean up %s %s: %v", c.handler.Kind(), job.GetName(), err)
+		}
+	}
+	var finalizers []string
+	for _, finalizer := range job.GetFinalizers() {
+		if finalizer != JobCleanupFinalizer {
+			finalizers = append(finalizers, finalizer)
+		}
+	}
+	if _, err := c.handler.UpdateJobFinalizers(job, finalizers); err != nil {
+		return fmt.Errorf("failed to remove finalizer from %s %s: %v", c.handler.Kind(), job.GetName(), err)
+	}
+	klog.V(2).InfoS("Cleaned up job", "kind", c.handler.Kind(), "name", job.GetName())
+	return nil
+}
+
+func (c *JobController) cleanupSparkApplication(namespace string, sparkApplicationId string) error {
+	// Delete the Spark Application if exists
+	DeleteSparkApplication(c.kubeClient, c.handler.NamePrefix()+sparkApplicationId, namespace)
+	if err := c.revokeJobCredentials(namespace, c.handler.NamePrefix()+sparkApplicationId); err != nil {
+		return err
+	}
+	// Delete the result from the ClickHouse
+	connect, err := c.GetClickHouseConnection()
+	if err != nil {
+		return err
+	}
+	_, localTable := c.handler.ResultTables()
+	query := "ALTER TABLE " + localTable + " ON CLUSTER '{cluster}' DELETE WHERE id = (" + sparkApplicationId + ");"
+	return RunClickHouseQuery(connect, query, sparkApplicationId)
+}
+
+// GetClickHouseConnection returns the connection to ClickHouse, which is set
+// up on first use. Job handlers share it with the JobController.
+func (c *JobController) GetClickHouseConnection() (*sql.DB, error) {
+	c.clickhouseConnectMutex.Lock()
+	defer c.clickhouseConnectMutex.Unlock()
+	if c.clickhouseConnect == nil {
+		connect, err := clickhouse.SetupConnection(c.kubeClient)
+		if err != nil {
+			return nil, err
+		}
+		c.clickhouseConnect = connect
+	}
+	return c.clickhouseConnect, nil
+}
+
+// retainFinishedJob deletes a finished job once its TTL expires, and
+// schedules the enforcement of the history limits.
+func (c *JobController) retainFinishedJob(job metav1.Object, status JobStatus) error {
+	if status.EndTime.IsZero() {
+		if status.SparkApplication != "" {
+			if err := c.revokeJobCredentials(job.GetNamespace(), job.GetName()); err != nil {
+				return err
+			}
+		}
+		// Failed jobs have no end time until they are retained, which starts
+		// their TTL.
+		return c.updateJobStatus(job, JobStatus{
+			State:   status.State,
+			EndTime: metav1.NewTime(time.Now()),
+		})
+	}
+	if c.historyLimits.Successful > 0 || c.historyLimits.Failed > 0 {
+		c.gcQueue.Add(GcKey{PruneJobHistory: true})
+	}
+	ttl := c.handler.GetTTLSecondsAfterFinished(job)
+	if ttl == nil {
+		return nil
+	}
+	if remaining := time.Until(status.EndTime.Add(time.Duration(*ttl) * time.Second)); remaining > 0 {
+		c.queue.AddAfter(apimachinerytypes.NamespacedName{
+			Namespace: job.GetNamespace(),
+			Name:      job.GetName(),
+		}, remaining)
+		return nil
+	}
+	klog.InfoS("Deleting job whose TTL expired", "kind", c.handler.Kind(), "name", job.GetName())
+	if err := c.handler.DeleteJob(job); err != nil && !apimachineryerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %s %s: %v", c.handler.Kind(), job.GetName(), err)
+	}
+	return nil
+}
+
+// pruneJobHistory deletes the oldest finished jobs beyond the history limits.
+func (c *JobController) pruneJobHistory() error {
+	jobs, err := c.handler.ListJobs(env.GetTheiaNamespace())
+	if err != nil {
+		return fmt.Errorf("failed to list %ss: %v", c.handler.Kind(), err)
+	}
+	var succeeded, failed []metav1.Object
+	for _, job := range jobs {
+		if job.GetDeletionTimestamp() != nil {
+			continue
+		}
+		status := c.handler.GetJobStatus(job)
+		if status.EndTime.IsZero() {
+			continue
+		}
+		switch status.State {
+		case JobStateCompleted:
+			succeeded = append(succeeded, job)
+		case JobStateFailed:
+			failed = append(failed, job)
+		}
+	}
+	var errorList []error
+	for _, history := range []struct {
+		jobs  []metav1.Object
+		limit int
+	}{
+		{succeeded, c.historyLimits.Successful},
+		{failed, c.historyLimits.Failed},
+	} {
+		if history.limit <= 0 || len(history.jobs) <= history.limit {
+			continue
+		}
+		// Retain the most recently finished jobs.
+		sort.Slice(history.jobs, func(i, j int) bool {
+			endTimeI := c.handler.GetJobStatus(history.jobs[i]).EndTime
+			endTimeJ := c.handler.GetJobStatus(history.jobs[j]).EndTime
+			return endTimeJ.Before(&endTimeI)
+		})
+		for _, job := range history.jobs[history.limit:] {
+			klog.InfoS("Deleting job beyond the history limit", "kind", c.handler.Kind(), "name", job.GetName(), "state", c.handler.GetJobStatus(job).State)
+			if err := c.handler.DeleteJob(job); err != nil && !apimachineryerrors.IsNotFound(err) {
+				errorList = append(errorList, fmt.Errorf("failed to delete %s %s: %v", c.handler.Kind(), job.GetName(), err))
+			}
+		}
+	}
+	if len(errorList) > 0 {
+		return fmt.Errorf("failed to prune job history: %v", errorList)
+	}
+	return nil
+}
+
+// activeDeadline returns the maximum duration of a job once it is started.
+func (c *JobController) activeDeadline(job metav1.Object) time.Duration {
+	if seconds := c.handler.GetActiveDeadlineSeconds(job); seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return c.defaultActiveDeadline
+}
+
+// deadlineExceeded returns whether a job started at startTime is active
+// longer than deadline. A job has no deadline if deadline is 0.
+func deadlineExceeded(startTime metav1.Time, deadline time.Duration, now time.Time) bool {
+	if deadline <= 0 || startTime.IsZero() {
+		return false
+	}
+	return now.Sub(startTime.Time) > deadline
+}
+
+// stopJob stops a job which is active longer than its deadline. Its Spark
+// Application is deleted to release the resources of the cluster, and the job
+// is marked as failed.
+func (c *JobController) stopJob(job metav1.Object, deadline time.Duration) error {
+	c.stopPeriodicSync(apimachinerytypes.NamespacedName{
+		Name:      job.GetName(),
+		Namespace: job.GetNamespace(),
+	})
+	if id := c.handler.GetJobStatus(job).SparkApplication; id != "" {
+		DeleteSparkApplication(c.kubeClient, c.handler.NamePrefix()+id, job.GetNamespace())
+		if err := c.revokeJobCredentials(job.GetNamespace(), job.GetName()); err != nil {
+			return err
+		}
+	}
+	klog.InfoS("Stopped job active longer than its deadline", "kind", c.handler.Kind(), "name", job.GetName(), "deadline", deadline)
+	return c.updateJobStatus(job, JobStatus{
+		State:    JobStateFailed,
+		ErrorMsg: fmt.Sprintf("%s: %s job was active longer than its deadline of %v", JobReasonDeadlineExceeded, c.handler.Kind(), deadline),
+		EndTime:  metav1.NewTime(time.Now()),
+	})
+}
+
+func (c *JobController) finishJob(job metav1.Object) error {
+	// Stop periodical job
+	c.stopPeriodicSync(apimachinerytypes.NamespacedName{
+		Name:      job.GetName(),
+		Namespace: job.GetNamespace(),
+	})
+	id := c.handler.GetJobStatus(job).SparkApplication
+	if id == "" {
+		return c.updateJobStatus(job, JobStatus{
+			State:    JobStateFailed,
+			ErrorMsg: "Spark Application should be started before updating results",
+		})
+	}
+	// Delete related SparkApplication CR
+	DeleteSparkApplication(c.kubeClient, c.handler.NamePrefix()+id, job.GetNamespace())
+	if err := c.revokeJobCredentials(job.GetNamespace(), job.GetName()); err != nil {
+		return err
+	}
+	err := c.updateJobStatus(job, JobStatus{
+		State:   JobStateCompleted,
+		EndTime: metav1.NewTime(time.Now()),
+	})
+	if err == nil {
+		c.handler.JobCompleted(job)
+	}
+	return err
+}
+
+func (c *JobController) updateProgress(job metav1.Object) error {
+	// Check the status before checking the progress in case the job is failed or completed
+	state, err := c.checkSparkApplicationStatus(job)
+	if err != nil {
+		return err
+	}
+	if state != JobStateRunning {
+		return nil
+	}
+	if !c.progressRateLimiter.TryAccept() {
+		// The progress is updated again at the next periodic sync.
+		klog.V(4).InfoS("Skipped updating the progress of the job", "kind", c.handler.Kind(), "name", job.GetName())
+		return nil
+	}
+	id := c.handler.GetJobStatus(job).SparkApplication
+	endpoint := GetSparkMonitoringSvcDNS(c.handler.NamePrefix()+id, job.GetNamespace(), SparkPort)
+	progress, err := GetSparkAppProgress(endpoint)
+	if err != nil {
+		// The Spark Monitoring Service may not start or closed at this point due to the async
+		// between Spark operator and this controller.
+		// As we periodically check the progress, we do not need to requeue this failure.
+		klog.V(4).ErrorS(err, "Failed to get the progress of the job", "kind", c.handler.Kind())
+		return nil
+	}
+	klog.V(4).InfoS("Got Spark Application progress", "completedStages", progress.CompletedStages, "totalStages", progress.TotalStages,
+		"currentStage", progress.CurrentStage, "completedTasks", progress.CompletedTasks, "totalTasks", progress.TotalTasks,
+		"activeExecutors", progress.ActiveExecutors, "failedExecutors", progress.FailedExecutors, c.handler.Kind(), job.GetName())
+	return c.updateJobStatus(job, JobStatus{
+		State:           JobStateRunning,
+		CompletedStages: progress.CompletedStages,
+		TotalStages:     progress.TotalStages,
+		Progress: &crdv1alpha1.JobProgress{
+			CurrentStage:     progress.CurrentStage,
+			CompletedTasks:   progress.CompletedTasks,
+			TotalTasks:       progress.TotalTasks,
+			ActiveExecutors:  progress.ActiveExecutors,
+			FailedExecutors:  progress.FailedExecutors,
+			EstimatedEndTime: estimateEndTime(c.handler.GetJobStatus(job).StartTime, progress, time.Now()),
+		},
+	})
+}
+
+// estimateEndTime extrapolates the time elapsed since startTime with the
+// fraction of the job which is completed. The tasks of the current stage
+// count as a fraction of a stage. It returns a zero time when nothing is
+// completed yet.
+func estimateEndTime(startTime metav1.Time, progress SparkAppProgress, now time.Time) metav1.Time {
+	if startTime.IsZero() || progress.TotalStages == 0 {
+		return metav1.Time{}
+	}
+	completed := float64(progress.CompletedStages)
+	if progress.TotalTasks > 0 {
+		completed += float64(progress.CompletedTasks) / float64(progress.TotalTasks)
+	}
+	if completed == 0 {
+		return metav1.Time{}
+	}
+	elapsed := now.Sub(startTime.Time)
+	remaining := time.Duration(float64(elapsed) * (float64(progress.TotalStages) - completed) / completed)
+	return metav1.NewTime(now.Add(remaining).Truncate(time.Second))
+}
+
+func (c *JobController) checkSparkApplicationStatus(job metav1.Object) (string, error) {
+	id := c.handler.GetJobStatus(job).SparkApplication
+	if id == "" {
+		return "", c.updateJobStatus(job, JobStatus{
+			State:    JobStateFailed,
+			ErrorMsg: "Spark Application should be started before status checking",
+		})
+	}
+
+	sparkApplication, err := c.getSparkApplication(c.handler.NamePrefix()+id, job.GetNamespace())
+	if err != nil {
+		if apimachineryerrors.IsNotFound(err) {
+			// The Spark Application was deleted out of band, the job would
+			// never finish otherwise.
+			return "", c.updateJobStatus(job, JobStatus{
+				State:    JobStateFailed,
+				ErrorMsg: fmt.Sprintf("%s job failed, Spark Application %s not found", c.handler.Kind(), c.handler.NamePrefix()+id),
+			})
+		}
+		return "", err
+	}
+	state := strings.TrimSpace(string(sparkApplication.Status.AppState.State))
+	errorMessage := strings.TrimSpace(sparkApplication.Status.AppState.ErrorMessage)
+	klog.V(4).InfoS("Got Spark Application state", "state", state, c.handler.Kind(), job.GetName())
+	switch state {
+	case "RUNNING":
+		return state, c.updateJobStatus(job, JobStatus{
+			State:    JobStateRunning,
+			ErrorMsg: errorMessage,
+		})
+	case "COMPLETED":
+		return state, c.updateJobStatus(job, JobStatus{
+			State:    JobStateCompleted,
+			ErrorMsg: errorMessage,
+		})
+	case "FAILED", "SUBMISSION_FAILED", "FAILING", "INVALIDATING":
+		return state, c.updateJobStatus(job, JobStatus{
+			State:    JobStateFailed,
+			ErrorMsg: fmt.Sprintf("%s job failed, state: %s, error message: %v", c.handler.Kind(), state, errorMessage),
+		})
+	}
+	return state, nil
+}
+
+// getSparkApplication returns a Spark Application from the cache of the
+// informer, or from the API server if there is no informer or the informer
+// has not observed it.
+func (c *JobController) getSparkApplication(name, namespace string) (sparkv1.SparkApplication, error) {
+	if c.sparkAppInformer == nil {
+		return GetSparkApplication(c.kubeClient, name, namespace)
+	}
+	obj, exists, err := c.sparkAppInformer.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil {
+		return sparkv1.SparkApplication{}, err
+	}
+	if !exists {
+		// A Spark Application just created may not be observed yet, only the
+		// API server tells whether it was deleted.
+		return GetSparkApplication(c.kubeClient, name, namespace)
+	}
+	return sparkApplicationFromUnstructured(obj)
+}
+
+func (c *JobController) startJob(job metav1.Object) error {
+	// Validate Cluster readiness
+	if err := ValidateCluster(c.kubeClient, job.GetNamespace()); err != nil {
+		return err
+	}
+	err := c.startSparkApplication(job)
+	// Mark the job as failed and not retry if it failed due to illegal arguments in request
+	if _, ok := err.(IllegalArgumentError); ok {
+		return c.updateJobStatus(job, JobStatus{
+			State:    JobStateFailed,
+			ErrorMsg: fmt.Sprintf("error in creating %s: %v", c.handler.Kind(), err),
+		})
+	}
+	// Mark the job as failed and not retry if its input is too large
+	if budgetErr, ok := err.(InputBudgetExceededError); ok {
+		klog.InfoS("Rejected job exceeding the input budget", "kind", c.handler.Kind(), "name", job.GetName(), "reason", budgetErr.Reason)
+		return c.updateJobStatus(job, JobStatus{
+			State:         JobStateFailed,
+			ErrorMsg:      budgetErr.Error(),
+			InputEstimate: budgetErr.Estimate,
+		})
+	}
+	// Schedule periodical resync for successful starting
+	if err == nil {
+		c.addPeriodicSync(apimachinerytypes.NamespacedName{
+			Name:      job.GetName(),
+			Namespace: job.GetNamespace(),
+		})
+	}
+	return err
+}
+
+func validateSparkJob(sparkJob *SparkJob) error {
+	if sparkJob.ExecutorInstances < 0 {
+		return IllegalArgumentError{Err: fmt.Errorf("invalid request: ExecutorInstances should be an integer >= 0")}
+	}
+	for _, quantity := range []struct {
+		name  string
+		value string
+	}{
+		{"DriverCoreRequest", sparkJob.DriverCoreRequest},
+		{"DriverMemory", sparkJob.DriverMemory},
+		{"ExecutorCoreRequest", sparkJob.ExecutorCoreRequest},
+		{"ExecutorMemory", sparkJob.ExecutorMemory},
+	} {
+		matchResult, err := regexp.MatchString(K8sQuantitiesReg, quantity.value)
+		if err != nil || !matchResult {
+			return IllegalArgumentError{Err: fmt.Errorf("invalid request: %s should conform to the Kubernetes resource quantity convention", quantity.name)}
+		}
+	}
+	return nil
+}
+
+func newSparkApplication(name, namespace string, owner *metav1.OwnerReference, labels map[string]string, sparkJob *SparkJob, arguments []string, credentialsSecret string) *sparkv1.SparkApplication {
+	executorInstances := int32(sparkJob.ExecutorInstances)
+	clickHouseSecretRefs := map[string]sparkv1.NameKey{
+		"CH_USERNAME": {
+			Name: credentialsSecret,
+			Key:  "username",
+		},
+		"CH_PASSWORD": {
+			Name: credentialsSecret,
+			Key:  "password",
+		},
+	}
+	return &sparkv1.SparkApplication{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "sparkoperator.k8s.io/v1beta2",
+			Kind:       "SparkApplication",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			Labels:          labels,
+			OwnerReferences: []metav1.OwnerReference{*owner},
+		},
+		Spec: sparkv1.SparkApplicationSpec{
+			Type:                "Python",
+			SparkVersion:        SparkVersion,
+			Mode:                "cluster",
+			Image:               ConstStrToPointer(SparkImage),
+			ImagePullPolicy:     ConstStrToPointer(SparkImagePullPolicy),
+			MainApplicationFile: ConstStrToPointer(sparkJob.MainApplicationFile),
+			Arguments:           arguments,
+			Driver: sparkv1.DriverSpec{
+				CoreRequest: &sparkJob.DriverCoreRequest,
+				SparkPodSpec: sparkv1.SparkPodSpec{
+					Memory: &sparkJob.DriverMemory,
+					Labels: map[string]string{
+						"version": SparkVersion,
+					},
+					EnvSecretKeyRefs: clickHouseSecretRefs,
+					ServiceAccount:   ConstStrToPointer(SparkServiceAccount),
+				},
+			},
+			Executor: sparkv1.ExecutorSpec{
+				CoreRequest: &sparkJob.ExecutorCoreRequest,
+				SparkPodSpec: sparkv1.SparkPodSpec{
+					Memory: &sparkJob.ExecutorMemory,
+					Labels: map[string]string{
+						"version": SparkVersion,
+					},
+					EnvSecretKeyRefs: clickHouseSecretRefs,
+				},
+				Instances: &executorInstances,
+			},
+		},
+	}
+}
+
+// ValidateJob returns an IllegalArgumentError if a job cannot run because of
+// its name or its spec. It lets the admission webhook reject the jobs which
+// would fail when starting.
+func (c *JobController) ValidateJob(job metav1.Object) error {
+	_, err := c.getSparkJob(job)
+	return err
+}
+
+func (c *JobController) getSparkJob(job metav1.Object) (*SparkJob, error) {
+	sparkJob, err := c.handler.GetSparkJob(job)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSparkJob(sparkJob); err != nil {
+		return nil, err
+	}
+	if err := c.handler.ValidateJobName(job.GetName()); err != nil {
+		return nil, err
+	}
+	if c.handler.GetActiveDeadlineSeconds(job) < 0 {
+		return nil, IllegalArgumentError{Err: fmt.Errorf("invalid request: ActiveDeadlineSeconds should be an integer >= 0")}
+	}
+	return sparkJob, nil
+}
+
+func (c *JobController) startSparkApplication(job metav1.Object) error {
+	sparkJob, err := c.getSparkJob(job)
+	if err != nil {
+		return err
+	}
+	id := job.GetName()[len(c.handler.NamePrefix()):]
+	// The Spark Application exists already if the job was started before but
+	// its status could not be updated. It keeps running with the credentials
+	// it was created with.
+	sparkApp, err := c.getSparkApplication(job.GetName(), job.GetNamespace())
+	if err == nil {
+		return c.adoptSparkApplication(job, id, &sparkApp)
+	}
+	if !apimachineryerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get Spark Application: %v", err)
+	}
+	estimate, err := c.checkInputBudget(job, sparkJob)
+	if err != nil {
+		return err
+	}
+	arguments := append(append([]string{}, sparkJob.Arguments...), "--id", id)
+	// The Spark Application is garbage collected by Kubernetes if its job is
+	// ever deleted without being cleaned up.
+	owner := metav1.NewControllerRef(job, crdv1alpha1.SchemeGroupVersion.WithKind(c.handler.Kind()))
+	credentialsSecret, err := c.createJobCredentials(job, owner)
+	if err != nil {
+		return err
+	}
+	sparkApplication := newSparkApplication(job.GetName(), job.GetNamespace(), owner, c.handler.SparkAppLabels(), sparkJob, arguments, credentialsSecret)
+	err = CreateSparkApplication(c.kubeClient, job.GetNamespace(), sparkApplication)
+	if apimachineryerrors.IsAlreadyExists(err) {
+		return c.adoptSparkApplication(job, id, sparkApplication)
+	}
+	if err != nil {
+		if revokeErr := c.revokeJobCredentials(job.GetNamespace(), job.GetName()); revokeErr != nil {
+			klog.ErrorS(revokeErr, "Failed to revoke ClickHouse credentials of the job", "kind", c.handler.Kind(), "name", job.GetName())
+		}
+		return fmt.Errorf("failed to create Spark Application: %v", err)
+	}
+	klog.V(2).InfoS("Start SparkApplication", "id", id, c.handler.Kind(), job.GetName())
+
+	return c.updateJobStatus(job, JobStatus{
+		State:            JobStateScheduled,
+		SparkApplication: id,
+		InputEstimate:    estimate,
+		StartTime:        metav1.NewTime(time.Now()),
+	})
+}
+
+// adoptSparkApplication marks a job as scheduled with the Spark Application
+// which was already created for it. The credentials of the job are neither
+// rotated nor revoked, as the Spark Application uses them.
+func (c *JobController) adoptSparkApplication(job metav1.Object, id string, sparkApp *sparkv1.SparkApplication) error {
+	if owner := metav1.GetControllerOf(sparkApp); owner != nil && owner.UID != job.GetUID() {
+		// The Spark Application of a deleted job with the same name is not
+		// garbage collected yet.
+		return fmt.Errorf("the Spark Application %s is owned by another %s", sparkApp.Name, c.handler.Kind())
+	}
+	klog.V(2).InfoS("Adopt existing SparkApplication", "id", id, c.handler.Kind(), job.GetName())
+	startTime := sparkApp.CreationTimestamp
+	if startTime.IsZero() {
+		startTime = metav1.NewTime(time.Now())
+	}
+	return c.updateJobStatus(job, JobStatus{
+		State:            JobStateScheduled,
+		SparkApplication: id,
+		StartTime:        startTime,
+	})
+}
+
+// updateJobStatus sets the state of a job and overrides the other fields of
+// its status which are set in status.
+func (c *JobController) updateJobStatus(job metav1.Object, status JobStatus) error {
+	update := c.handler.GetJobStatus(job)
+	update.State = status.State
+	if status.SparkApplication != "" {
+		update.SparkApplication = status.SparkApplication
+	}
+	if status.CompletedStages != 0 {
+		update.CompletedStages = status.CompletedStages
+	}
+	if status.TotalStages != 0 {
+		update.TotalStages = status.TotalStages
+	}
+	if status.Progress != nil {
+		update.Progress = status.Progress
+	}
+	if status.InputEstimate != nil {
+		update.InputEstimate = status.InputEstimate
+	}
+	if status.ErrorMsg != "" {
+		update.ErrorMsg = status.ErrorMsg
+	}
+	if !status.StartTime.IsZero() {
+		update.StartTime = status.StartTime
+	}
+	if !status.EndTime.IsZero() {
+		update.EndTime = status.EndTime
+	}
+	return c.handler.UpdateJobStatus(job, update)
+}
+
+func (c *JobController) addPeriodicSync(key apimachinerytypes.NamespacedName) {
+	c.periodicResyncSetMutex.Lock()
+	defer c.periodicResyncSetMutex.Unlock()
+	c.periodicResyncSet[key] = struct{}{}
+}
+
+func (c *JobController) stopPeriodicSync(key apimachinerytypes.NamespacedName) {
+	c.periodicResyncSetMutex.Lock()
+	defer c.periodicResyncSetMutex.Unlock()
+	delete(c.periodicResyncSet, key)
+}
@@ -0,0 +1,917 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	"antrea.io/theia/pkg/util/clickhouse"
+	sparkv1 "antrea.io/theia/third_party/sparkoperator/v1beta2"
+)
+
+const testJobName = "test-1234abcd-1234-abcd-12ab-12345678abcd"
+
+// testJobHandler keeps its jobs and their status in memory.
+type testJobHandler struct {
+	jobs                  map[string]*metav1.ObjectMeta
+	status                map[string]JobStatus
+	sparkJob              SparkJob
+	activeDeadlineSeconds int64
+	ttl                   *int32
+	input                 JobInput
+	completed             []string
+	deleted               []string
+}
+
+func newTestJobHandler(sparkJob SparkJob, jobs ...string) *testJobHandler {
+	h := &testJobHandler{
+		jobs:     map[string]*metav1.ObjectMeta{},
+		status:   map[string]JobStatus{},
+		sparkJob: sparkJob,
+	}
+	for _, name := range jobs {
+		h.jobs[name] = &metav1.ObjectMeta{Name: name, Namespace: testNamespace}
+	}
+	return h
+}
+
+func (h *testJobHandler) Kind() string {
+	return "TestJob"
+}
+
+func (h *testJobHandler) NamePrefix() string {
+	return "test-"
+}
+
+func (h *testJobHandler) SparkAppLabels() map[string]string {
+	return map[string]string{"app": "theia-test"}
+}
+
+func (h *testJobHandler) ResultTables() (string, string) {
+	return "test", "test_local"
+}
+
+func (h *testJobHandler) GetJob(namespace, name string) (metav1.Object, error) {
+	job, ok := h.jobs[name]
+	if !ok || job.Namespace != namespace {
+		return nil, apimachineryerrors.NewNotFound(schema.GroupResource{Resource: "testjobs"}, name)
+	}
+	return job, nil
+}
+
+func (h *testJobHandler) ListJobs(namespace string) ([]metav1.Object, error) {
+	var jobs []metav1.Object
+	for _, job := range h.jobs {
+		if job.Namespace == namespace {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+func (h *testJobHandler) GetJobStatus(job metav1.Object) JobStatus {
+	return h.status[job.GetName()]
+}
+
+func (h *testJobHandler) UpdateJobStatus(job metav1.Object, status JobStatus) error {
+	h.status[job.GetName()] = status
+	return nil
+}
+
+func (h *testJobHandler) UpdateJobFinalizers(job metav1.Object, finalizers []string) (metav1.Object, error) {
+	job.SetFinalizers(finalizers)
+	return job, nil
+}
+
+func (h *testJobHandler) DeleteJob(job metav1.Object) error {
+	if _, ok := h.jobs[job.GetName()]; !ok {
+		return apimachineryerrors.NewNotFound(schema.GroupResource{Resource: "testjobs"}, job.GetName())
+	}
+	delete(h.jobs, job.GetName())
+	h.deleted = append(h.deleted, job.GetName())
+	return nil
+}
+
+func (h *testJobHandler) GetSparkJob(job metav1.Object) (*SparkJob, error) {
+	sparkJob := h.sparkJob
+	return &sparkJob, nil
+}
+
+func (h *testJobHandler) ValidateJobName(name string) error {
+	if len(name) <= len(h.NamePrefix()) || name[:len(h.NamePrefix())] != h.NamePrefix() {
+		return IllegalArgumentError{Err: fmt.Errorf("invalid request: job name %s is invalid", name)}
+	}
+	return nil
+}
+
+func (h *testJobHandler) GetActiveDeadlineSeconds(job metav1.Object) int64 {
+	return h.activeDeadlineSeconds
+}
+
+func (h *testJobHandler) GetTTLSecondsAfterFinished(job metav1.Object) *int32 {
+	return h.ttl
+}
+
+func (h *testJobHandler) GetJobInput(job metav1.Object) (JobInput, error) {
+	return h.input, nil
+}
+
+func (h *testJobHandler) JobCompleted(job metav1.Object) {
+	h.completed = append(h.completed, job.GetName())
+}
+
+func newTestJobController(handler JobHandler, kubeClient kubernetes.Interface) *JobController {
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &metav1.PartialObjectMetadata{}, 0, cache.Indexers{})
+	return NewJobController("TestJobController", handler, kubeClient, informer, nil, 0, 0, JobHistoryLimits{}, JobInputBudget{})
+}
+
+// getNoSparkApplication fakes GetSparkApplication before the Spark
+// Application of a job is created.
+func getNoSparkApplication(client kubernetes.Interface, name, namespace string) (sparkv1.SparkApplication, error) {
+	return sparkv1.SparkApplication{}, apimachineryerrors.NewNotFound(schema.GroupResource{Resource: "sparkapplications"}, name)
+}
+
+func createRunningPod(t *testing.T, client kubernetes.Interface, name string, labels map[string]string) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: labels},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	_, err := client.CoreV1().Pods(testNamespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+	require.NoError(t, err)
+}
+
+func TestJobControllerStartJob(t *testing.T) {
+	validSparkJob := SparkJob{
+		MainApplicationFile: "local:///opt/spark/work-dir/test_job.py",
+		Arguments:           []string{"--algo", "test"},
+		ExecutorInstances:   1,
+		DriverCoreRequest:   "200m",
+		DriverMemory:        "512M",
+		ExecutorCoreRequest: "200m",
+		ExecutorMemory:      "512M",
+	}
+	testCases := []struct {
+		name             string
+		jobName          string
+		sparkJob         func(SparkJob) SparkJob
+		sparkAppExists   bool
+		createErr        error
+		expectedState    string
+		expectedErrorMsg string
+	}{
+		{
+			name:          "Spark Application created",
+			jobName:       testJobName,
+			sparkJob:      func(j SparkJob) SparkJob { return j },
+			expectedState: JobStateScheduled,
+		},
+		{
+			name:           "Spark Application already created",
+			jobName:        testJobName,
+			sparkJob:       func(j SparkJob) SparkJob { return j },
+			sparkAppExists: true,
+			expectedState:  JobStateScheduled,
+		},
+		{
+			name:          "Spark Application created concurrently",
+			jobName:       testJobName,
+			sparkJob:      func(j SparkJob) SparkJob { return j },
+			createErr:     apimachineryerrors.NewAlreadyExists(schema.GroupResource{Resource: "sparkapplications"}, testJobName),
+			expectedState: JobStateScheduled,
+		},
+		{
+			name:    "invalid ExecutorInstances",
+			jobName: testJobName,
+			sparkJob: func(j SparkJob) SparkJob {
+				j.ExecutorInstances = -1
+				return j
+			},
+			expectedState:    JobStateFailed,
+			expectedErrorMsg: "error in creating TestJob: invalid request: ExecutorInstances should be an integer >= 0",
+		},
+		{
+			name:    "invalid DriverMemory",
+			jobName: testJobName,
+			sparkJob: func(j SparkJob) SparkJob {
+				j.DriverMemory = "512A"
+				return j
+			},
+			expectedState:    JobStateFailed,
+			expectedErrorMsg: "error in creating TestJob: invalid request: DriverMemory should conform to the Kubernetes resource quantity convention",
+		},
+		{
+			name:             "invalid job name",
+			jobName:          "job-1234",
+			sparkJob:         func(j SparkJob) SparkJob { return j },
+			expectedState:    JobStateFailed,
+			expectedErrorMsg: "error in creating TestJob: invalid request: job name job-1234 is invalid",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			createRunningPod(t, kubeClient, "clickhouse", map[string]string{"app": "clickhouse"})
+			createRunningPod(t, kubeClient, "spark-operator", map[string]string{"app.kubernetes.io/name": "spark-operator"})
+			creationTimestamp := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+			GetSparkApplication = func(client kubernetes.Interface, name, namespace string) (sparkv1.SparkApplication, error) {
+				if !tc.sparkAppExists {
+					return getNoSparkApplication(client, name, namespace)
+				}
+				return sparkv1.SparkApplication{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, CreationTimestamp: creationTimestamp}}, nil
+			}
+			var created *sparkv1.SparkApplication
+			CreateSparkApplication = func(client kubernetes.Interface, namespace string, sparkApplication *sparkv1.SparkApplication) error {
+				created = sparkApplication
+				return tc.createErr
+			}
+			defer func() {
+				GetSparkApplication = getSparkApplication
+				CreateSparkApplication = createSparkApplication
+			}()
+
+			handler := newTestJobHandler(tc.sparkJob(validSparkJob), tc.jobName)
+			c := newTestJobController(handler, kubeClient)
+			mock := newTestClickHouse(t, c)
+			if tc.expectedState == JobStateScheduled && !tc.sparkAppExists {
+				query, _ := handler.input.query()
+				mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"Rows", "Bytes"}).AddRow(1500, 300000))
+				expectCreateJobCredentials(mock, tc.jobName)
+			}
+			require.NoError(t, c.syncJob(apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: tc.jobName}))
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			assert.Contains(t, handler.jobs[tc.jobName].Finalizers, JobCleanupFinalizer)
+			status := handler.status[tc.jobName]
+			assert.Equal(t, tc.expectedState, status.State)
+			assert.Equal(t, tc.expectedErrorMsg, status.ErrorMsg)
+			if tc.expectedState != JobStateScheduled {
+				assert.Nil(t, created)
+				assert.Empty(t, c.periodicResyncSet)
+				return
+			}
+			id := tc.jobName[len("test-"):]
+			assert.Equal(t, id, status.SparkApplication)
+			assert.False(t, status.StartTime.IsZero())
+			assert.Contains(t, c.periodicResyncSet, apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: tc.jobName})
+			secretName := jobCredentialsSecretName(tc.jobName)
+			_, err := kubeClient.CoreV1().Secrets(testNamespace).Get(context.TODO(), secretName, metav1.GetOptions{})
+			if tc.sparkAppExists {
+				// The existing Spark Application is adopted without creating
+				// new credentials.
+				assert.Nil(t, created)
+				assert.Equal(t, creationTimestamp, status.StartTime)
+				assert.True(t, apimachineryerrors.IsNotFound(err))
+				return
+			}
+			// The credentials are not revoked when the Spark Application was
+			// created concurrently.
+			assert.NoError(t, err)
+			require.NotNil(t, created)
+			assert.Equal(t, tc.jobName, created.Name)
+			assert.Equal(t, map[string]string{"app": "theia-test"}, created.Labels)
+			require.Len(t, created.OwnerReferences, 1)
+			assert.Equal(t, "TestJob", created.OwnerReferences[0].Kind)
+			assert.Equal(t, tc.jobName, created.OwnerReferences[0].Name)
+			assert.Equal(t, validSparkJob.MainApplicationFile, *created.Spec.MainApplicationFile)
+			assert.Equal(t, []string{"--algo", "test", "--id", id}, created.Spec.Arguments)
+			assert.Equal(t, int32(1), *created.Spec.Executor.Instances)
+			assert.Equal(t, SparkServiceAccount, *created.Spec.Driver.ServiceAccount)
+			for _, refs := range []map[string]sparkv1.NameKey{created.Spec.Driver.EnvSecretKeyRefs, created.Spec.Executor.EnvSecretKeyRefs} {
+				assert.Equal(t, sparkv1.NameKey{Name: secretName, Key: "username"}, refs["CH_USERNAME"])
+				assert.Equal(t, sparkv1.NameKey{Name: secretName, Key: "password"}, refs["CH_PASSWORD"])
+			}
+		})
+	}
+}
+
+func TestJobControllerValidateJob(t *testing.T) {
+	validSparkJob := SparkJob{
+		MainApplicationFile: "local:///opt/spark/work-dir/test_job.py",
+		ExecutorInstances:   1,
+		DriverCoreRequest:   "200m",
+		DriverMemory:        "512M",
+		ExecutorCoreRequest: "200m",
+		ExecutorMemory:      "512M",
+	}
+	invalidSparkJob := validSparkJob
+	invalidSparkJob.ExecutorMemory = "512A"
+	testCases := []struct {
+		name                  string
+		jobName               string
+		sparkJob              SparkJob
+		activeDeadlineSeconds int64
+		expectedErrorMsg      string
+	}{
+		{
+			name:     "valid job",
+			jobName:  testJobName,
+			sparkJob: validSparkJob,
+		},
+		{
+			name:             "invalid spec",
+			jobName:          testJobName,
+			sparkJob:         invalidSparkJob,
+			expectedErrorMsg: "invalid request: ExecutorMemory should conform to the Kubernetes resource quantity convention",
+		},
+		{
+			name:             "invalid name",
+			jobName:          "1234abcd-1234-abcd-12ab-12345678abcd",
+			sparkJob:         validSparkJob,
+			expectedErrorMsg: "invalid request: job name 1234abcd-1234-abcd-12ab-12345678abcd is invalid",
+		},
+		{
+			name:                  "invalid active deadline",
+			jobName:               testJobName,
+			sparkJob:              validSparkJob,
+			activeDeadlineSeconds: -1,
+			expectedErrorMsg:      "invalid request: ActiveDeadlineSeconds should be an integer >= 0",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newTestJobHandler(tc.sparkJob, tc.jobName)
+			handler.activeDeadlineSeconds = tc.activeDeadlineSeconds
+			c := newTestJobController(handler, fake.NewSimpleClientset())
+			err := c.ValidateJob(handler.jobs[tc.jobName])
+			if tc.expectedErrorMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.expectedErrorMsg)
+			assert.ErrorAs(t, err, &IllegalArgumentError{})
+		})
+	}
+}
+
+func TestJobControllerCheckSparkApplicationStatus(t *testing.T) {
+	testCases := []struct {
+		name             string
+		sparkAppState    sparkv1.ApplicationStateType
+		expectedState    string
+		expectedErrorMsg string
+		expectCompleted  bool
+	}{
+		{
+			name:          "Spark Application running",
+			sparkAppState: sparkv1.RunningState,
+			expectedState: JobStateRunning,
+		},
+		{
+			name:            "Spark Application completed",
+			sparkAppState:   sparkv1.CompletedState,
+			expectedState:   JobStateCompleted,
+			expectCompleted: true,
+		},
+		{
+			name:             "Spark Application failed",
+			sparkAppState:    sparkv1.FailedState,
+			expectedState:    JobStateFailed,
+			expectedErrorMsg: "TestJob job failed, state: FAILED, error message: driver OOMKilled",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			id := testJobName[len("test-"):]
+			GetSparkApplication = func(client kubernetes.Interface, name, namespace string) (sparkv1.SparkApplication, error) {
+				assert.Equal(t, testJobName, name)
+				sparkApp := sparkv1.SparkApplication{}
+				sparkApp.Status.AppState.State = tc.sparkAppState
+				if tc.sparkAppState == sparkv1.FailedState {
+					sparkApp.Status.AppState.ErrorMessage = "driver OOMKilled"
+				}
+				return sparkApp, nil
+			}
+			var deleted []string
+			DeleteSparkApplication = func(client kubernetes.Interface, name, namespace string) {
+				deleted = append(deleted, name)
+			}
+			defer func() {
+				GetSparkApplication = getSparkApplication
+				DeleteSparkApplication = deleteSparkApplication
+			}()
+
+			handler := newTestJobHandler(SparkJob{}, testJobName)
+			handler.status[testJobName] = JobStatus{State: JobStateScheduled, SparkApplication: id}
+			c := newTestJobController(handler, fake.NewSimpleClientset())
+			mock := newTestClickHouse(t, c)
+			key := apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: testJobName}
+			c.addPeriodicSync(key)
+			require.NoError(t, c.syncJob(key))
+			status := handler.status[testJobName]
+			assert.Equal(t, tc.expectedState, status.State)
+			assert.Equal(t, tc.expectedErrorMsg, status.ErrorMsg)
+			assert.Equal(t, id, status.SparkApplication)
+
+			if tc.expectCompleted {
+				// The next sync of a completed job releases its Spark Application
+				// and its ClickHouse credentials.
+				expectRevokeJobCredentials(mock, testJobName)
+				require.NoError(t, c.syncJob(key))
+				endTime := handler.status[testJobName].EndTime
+				assert.False(t, endTime.IsZero())
+				assert.Equal(t, []string{testJobName}, deleted)
+				assert.Equal(t, []string{testJobName}, handler.completed)
+				assert.NotContains(t, c.periodicResyncSet, key)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestJobControllerActiveDeadline(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		state                 string
+		startTime             time.Time
+		defaultActiveDeadline time.Duration
+		activeDeadlineSeconds int64
+		expectedState         string
+		expectedErrorMsg      string
+	}{
+		{
+			name:                  "scheduled job exceeds default deadline",
+			state:                 JobStateScheduled,
+			startTime:             time.Now().Add(-2 * time.Hour),
+			defaultActiveDeadline: time.Hour,
+			expectedState:         JobStateFailed,
+			expectedErrorMsg:      "DeadlineExceeded: TestJob job was active longer than its deadline of 1h0m0s",
+		},
+		{
+			name:                  "running job exceeds its deadline",
+			state:                 JobStateRunning,
+			startTime:             time.Now().Add(-2 * time.Hour),
+			defaultActiveDeadline: 24 * time.Hour,
+			activeDeadlineSeconds: 1800,
+			expectedState:         JobStateFailed,
+			expectedErrorMsg:      "DeadlineExceeded: TestJob job was active longer than its deadline of 30m0s",
+		},
+		{
+			name:                  "job deadline overrides default deadline",
+			state:                 JobStateScheduled,
+			startTime:             time.Now().Add(-2 * time.Hour),
+			defaultActiveDeadline: time.Hour,
+			activeDeadlineSeconds: 3 * 3600,
+			expectedState:         JobStateRunning,
+		},
+		{
+			name:          "no deadline",
+			state:         JobStateScheduled,
+			startTime:     time.Now().Add(-48 * time.Hour),
+			expectedState: JobStateRunning,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			id := testJobName[len("test-"):]
+			GetSparkApplication = func(client kubernetes.Interface, name, namespace string) (sparkv1.SparkApplication, error) {
+				sparkApp := sparkv1.SparkApplication{}
+				sparkApp.Status.AppState.State = sparkv1.RunningState
+				return sparkApp, nil
+			}
+			var deleted []string
+			DeleteSparkApplication = func(client kubernetes.Interface, name, namespace string) {
+				deleted = append(deleted, name)
+			}
+			defer func() {
+				GetSparkApplication = getSparkApplication
+				DeleteSparkApplication = deleteSparkApplication
+			}()
+
+			handler := newTestJobHandler(SparkJob{}, testJobName)
+			handler.activeDeadlineSeconds = tc.activeDeadlineSeconds
+			handler.status[testJobName] = JobStatus{State: tc.state, SparkApplication: id, StartTime: metav1.NewTime(tc.startTime)}
+			c := newTestJobController(handler, fake.NewSimpleClientset())
+			c.defaultActiveDeadline = tc.defaultActiveDeadline
+			mock := newTestClickHouse(t, c)
+			if tc.expectedState == JobStateFailed {
+				expectRevokeJobCredentials(mock, testJobName)
+			}
+			key := apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: testJobName}
+			c.addPeriodicSync(key)
+			require.NoError(t, c.syncJob(key))
+			assert.NoError(t, mock.ExpectationsWereMet())
+			status := handler.status[testJobName]
+			assert.Equal(t, tc.expectedState, status.State)
+			assert.Equal(t, tc.expectedErrorMsg, status.ErrorMsg)
+			if tc.expectedState == JobStateFailed {
+				assert.False(t, status.EndTime.IsZero())
+				assert.Equal(t, []string{testJobName}, deleted)
+				assert.NotContains(t, c.periodicResyncSet, key)
+			} else {
+				assert.Empty(t, deleted)
+				assert.Contains(t, c.periodicResyncSet, key)
+			}
+		})
+	}
+}
+
+func TestJobControllerSparkApplicationInformer(t *testing.T) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		SparkApplicationResource: "SparkApplicationList",
+	})
+	handler := newTestJobHandler(SparkJob{}, testJobName)
+	id := testJobName[len("test-"):]
+	handler.status[testJobName] = JobStatus{State: JobStateScheduled, SparkApplication: id}
+	sparkAppInformer := NewSparkApplicationInformer(dynamicClient, testNamespace, handler.SparkAppLabels(), 0)
+	informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &metav1.PartialObjectMetadata{}, 0, cache.Indexers{})
+	c := NewJobController("TestJobController", handler, fake.NewSimpleClientset(), informer, sparkAppInformer, 0, 0, JobHistoryLimits{}, JobInputBudget{})
+	key := apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: testJobName}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go sparkAppInformer.Run(stopCh)
+	require.True(t, cache.WaitForCacheSync(stopCh, sparkAppInformer.HasSynced))
+	// The Spark Applications observed by the informer are read from its cache.
+	GetSparkApplication = func(client kubernetes.Interface, name, namespace string) (sparkv1.SparkApplication, error) {
+		t.Errorf("Spark Application %s should be read from the informer cache", name)
+		return sparkv1.SparkApplication{}, nil
+	}
+	defer func() {
+		GetSparkApplication = getSparkApplication
+	}()
+
+	job, err := handler.GetJob(testNamespace, testJobName)
+	require.NoError(t, err)
+	owner := metav1.NewControllerRef(job, crdv1alpha1.SchemeGroupVersion.WithKind(handler.Kind()))
+	sparkApp := newSparkApplication(testJobName, testNamespace, owner, handler.SparkAppLabels(), &SparkJob{}, nil, jobCredentialsSecretName(testJobName))
+	sparkApp.Status.AppState.State = sparkv1.RunningState
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(sparkApp)
+	require.NoError(t, err)
+	_, err = dynamicClient.Resource(SparkApplicationResource).Namespace(testNamespace).Create(context.TODO(), &unstructured.Unstructured{Object: content}, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	// The Spark Application event enqueues its job.
+	require.Eventually(t, func() bool { return c.queue.Len() == 1 }, 5*time.Second, 10*time.Millisecond)
+	item, _ := c.queue.Get()
+	assert.Equal(t, key, item)
+	c.queue.Done(item)
+
+	require.NoError(t, c.syncJob(key))
+	assert.Equal(t, JobStateRunning, handler.status[testJobName].State)
+}
+
+func TestJobControllerSparkApplicationNotObserved(t *testing.T) {
+	testCases := []struct {
+		name             string
+		sparkAppExists   bool
+		expectedState    string
+		expectedErrorMsg string
+	}{
+		{
+			name:           "Spark Application not observed yet",
+			sparkAppExists: true,
+			expectedState:  JobStateRunning,
+		},
+		{
+			name:             "Spark Application deleted",
+			expectedState:    JobStateFailed,
+			expectedErrorMsg: "TestJob job failed, Spark Application " + testJobName + " not found",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// The API server is checked when the informer cache misses the
+			// Spark Application.
+			GetSparkApplication = func(client kubernetes.Interface, name, namespace string) (sparkv1.SparkApplication, error) {
+				assert.Equal(t, testJobName, name)
+				if !tc.sparkAppExists {
+					return sparkv1.SparkApplication{}, apimachineryerrors.NewNotFound(SparkApplicationResource.GroupResource(), name)
+				}
+				sparkApp := sparkv1.SparkApplication{}
+				sparkApp.Status.AppState.State = sparkv1.RunningState
+				return sparkApp, nil
+			}
+			defer func() {
+				GetSparkApplication = getSparkApplication
+			}()
+
+			handler := newTestJobHandler(SparkJob{}, testJobName)
+			id := testJobName[len("test-"):]
+			handler.status[testJobName] = JobStatus{State: JobStateScheduled, SparkApplication: id}
+			sparkAppInformer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &unstructured.Unstructured{}, 0, cache.Indexers{})
+			informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &metav1.PartialObjectMetadata{}, 0, cache.Indexers{})
+			c := NewJobController("TestJobController", handler, fake.NewSimpleClientset(), informer, sparkAppInformer, 0, 0, JobHistoryLimits{}, JobInputBudget{})
+			require.NoError(t, c.syncJob(apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: testJobName}))
+			status := handler.status[testJobName]
+			assert.Equal(t, tc.expectedState, status.State)
+			assert.Equal(t, tc.expectedErrorMsg, status.ErrorMsg)
+		})
+	}
+}
+
+func TestJobControllerProgressRateLimit(t *testing.T) {
+	GetSparkApplication = func(client kubernetes.Interface, name, namespace string) (sparkv1.SparkApplication, error) {
+		sparkApp := sparkv1.SparkApplication{}
+		sparkApp.Status.AppState.State = sparkv1.RunningState
+		return sparkApp, nil
+	}
+	GetSparkMonitoringSvcDNS = func(name, namespace string, sparkPort int) string {
+		t.Errorf("Monitoring Service of Spark Application %s should not be queried", name)
+		return ""
+	}
+	defer func() {
+		GetSparkApplication = getSparkApplication
+		GetSparkMonitoringSvcDNS = getSparkMonitoringSvcDNS
+	}()
+
+	handler := newTestJobHandler(SparkJob{}, testJobName)
+	handler.status[testJobName] = JobStatus{State: JobStateRunning, SparkApplication: testJobName[len("test-"):]}
+	c := newTestJobController(handler, fake.NewSimpleClientset())
+	c.progressRateLimiter = flowcontrol.NewFakeNeverRateLimiter()
+	require.NoError(t, c.syncJob(apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: testJobName}))
+	status := handler.status[testJobName]
+	assert.Equal(t, JobStateRunning, status.State)
+	assert.Nil(t, status.Progress)
+}
+
+func TestJobControllerRetainFinishedJob(t *testing.T) {
+	ttl := int32(3600)
+	testCases := []struct {
+		name            string
+		state           string
+		endTime         time.Time
+		ttl             *int32
+		expectedDeleted bool
+	}{
+		{
+			name:    "completed job without TTL",
+			state:   JobStateCompleted,
+			endTime: time.Now().Add(-48 * time.Hour),
+		},
+		{
+			name:            "completed job with expired TTL",
+			state:           JobStateCompleted,
+			endTime:         time.Now().Add(-2 * time.Hour),
+			ttl:             &ttl,
+			expectedDeleted: true,
+		},
+		{
+			name:            "failed job with expired TTL",
+			state:           JobStateFailed,
+			endTime:         time.Now().Add(-2 * time.Hour),
+			ttl:             &ttl,
+			expectedDeleted: true,
+		},
+		{
+			name:    "completed job with unexpired TTL",
+			state:   JobStateCompleted,
+			endTime: time.Now().Add(-time.Minute),
+			ttl:     &ttl,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := newTestJobHandler(SparkJob{}, testJobName)
+			handler.ttl = tc.ttl
+			handler.status[testJobName] = JobStatus{State: tc.state, EndTime: metav1.NewTime(tc.endTime)}
+			c := newTestJobController(handler, fake.NewSimpleClientset())
+			key := apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: testJobName}
+			require.NoError(t, c.syncJob(key))
+			if tc.expectedDeleted {
+				assert.Equal(t, []string{testJobName}, handler.deleted)
+			} else {
+				assert.Empty(t, handler.deleted)
+			}
+		})
+	}
+}
+
+func TestJobControllerRetainFailedJobWithoutEndTime(t *testing.T) {
+	handler := newTestJobHandler(SparkJob{}, testJobName)
+	handler.status[testJobName] = JobStatus{State: JobStateFailed, ErrorMsg: "driver OOMKilled"}
+	c := newTestJobController(handler, fake.NewSimpleClientset())
+	require.NoError(t, c.syncJob(apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: testJobName}))
+	status := handler.status[testJobName]
+	assert.Equal(t, JobStateFailed, status.State)
+	assert.Equal(t, "driver OOMKilled", status.ErrorMsg)
+	assert.False(t, status.EndTime.IsZero())
+}
+
+func TestJobControllerPruneJobHistory(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", testNamespace)
+	now := time.Now()
+	jobs := []struct {
+		name    string
+		state   string
+		endTime time.Time
+	}{
+		{"test-completed-1", JobStateCompleted, now.Add(-3 * time.Hour)},
+		{"test-completed-2", JobStateCompleted, now.Add(-time.Hour)},
+		{"test-completed-3", JobStateCompleted, now.Add(-2 * time.Hour)},
+		{"test-failed-1", JobStateFailed, now.Add(-2 * time.Hour)},
+		{"test-failed-2", JobStateFailed, now.Add(-time.Hour)},
+		{"test-running", JobStateRunning, time.Time{}},
+	}
+	handler := newTestJobHandler(SparkJob{})
+	for _, job := range jobs {
+		handler.jobs[job.name] = &metav1.ObjectMeta{Name: job.name, Namespace: testNamespace}
+		handler.status[job.name] = JobStatus{State: job.state, EndTime: metav1.NewTime(job.endTime)}
+	}
+	c := newTestJobController(handler, fake.NewSimpleClientset())
+	c.historyLimits = JobHistoryLimits{Successful: 2, Failed: 1}
+	_, err := c.handleStaleResources(GcKey{PruneJobHistory: true})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"test-completed-1", "test-failed-1"}, handler.deleted)
+	assert.Contains(t, handler.jobs, "test-running")
+}
+
+func TestJobControllerRemoveStaleDbEntries(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", testNamespace)
+	kubeClient := fake.NewSimpleClientset()
+	db, _ := clickhouse.CreateFakeClickHouse(t, kubeClient, testNamespace)
+	defer db.Close()
+	var sweptConnect *sql.DB
+	getSparkJobIds = func(connect *sql.DB, tableName string) ([]string, error) {
+		sweptConnect = connect
+		return nil, nil
+	}
+	defer func() {
+		getSparkJobIds = GetSparkJobIds
+	}()
+	c := newTestJobController(newTestJobHandler(SparkJob{}), kubeClient)
+	key, err := c.handleStaleResources(GcKey{RemoveStaleDbEntries: true})
+	require.NoError(t, err)
+	assert.False(t, key.RemoveStaleDbEntries)
+	assert.Equal(t, db, sweptConnect)
+	// The connection set up by the sweep is kept for the next ones.
+	assert.Equal(t, db, c.clickhouseConnect)
+}
+
+func TestJobControllerGetClickHouseConnectionConcurrently(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", testNamespace)
+	kubeClient := fake.NewSimpleClientset()
+	db, _ := clickhouse.CreateFakeClickHouse(t, kubeClient, testNamespace)
+	defer db.Close()
+	c := newTestJobController(newTestJobHandler(SparkJob{}), kubeClient)
+	connects := make([]*sql.DB, 4)
+	var wg sync.WaitGroup
+	for i := range connects {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			connect, err := c.GetClickHouseConnection()
+			assert.NoError(t, err)
+			connects[i] = connect
+		}(i)
+	}
+	wg.Wait()
+	for _, connect := range connects {
+		assert.Equal(t, db, connect)
+	}
+	assert.Equal(t, db, c.clickhouseConnect)
+}
+
+func TestJobControllerCleanupJob(t *testing.T) {
+	id := testJobName[len("test-"):]
+	query := "ALTER TABLE test_local ON CLUSTER '{cluster}' DELETE WHERE id = (" + id + ");"
+	testCases := []struct {
+		name               string
+		sparkApplication   string
+		queryErr           error
+		expectedDeleted    []string
+		expectedFinalizers []string
+		expectedErrorMsg   string
+	}{
+		{
+			name:               "job never started",
+			expectedFinalizers: []string{"other"},
+		},
+		{
+			name:               "Spark Application and results removed",
+			sparkApplication:   id,
+			expectedDeleted:    []string{testJobName},
+			expectedFinalizers: []string{"other"},
+		},
+		{
+			name:               "failed to remove results",
+			sparkApplication:   id,
+			queryErr:           fmt.Errorf("connection refused"),
+			expectedDeleted:    []string{testJobName},
+			expectedFinalizers: []string{"other", JobCleanupFinalizer},
+			expectedErrorMsg:   "failed to clean up TestJob " + testJobName,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var deleted []string
+			DeleteSparkApplication = func(client kubernetes.Interface, name, namespace string) {
+				deleted = append(deleted, name)
+			}
+			defer func() {
+				DeleteSparkApplication = deleteSparkApplication
+			}()
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			defer db.Close()
+			if tc.sparkApplication != "" {
+				expectRevokeJobCredentials(mock, testJobName)
+				if tc.queryErr != nil {
+					mock.ExpectExec(query).WillReturnError(tc.queryErr)
+				} else {
+					mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+			}
+
+			handler := newTestJobHandler(SparkJob{}, testJobName)
+			deletionTimestamp := metav1.Now()
+			handler.jobs[testJobName].DeletionTimestamp = &deletionTimestamp
+			handler.jobs[testJobName].Finalizers = []string{"other", JobCleanupFinalizer}
+			handler.status[testJobName] = JobStatus{State: JobStateRunning, SparkApplication: tc.sparkApplication}
+			c := newTestJobController(handler, fake.NewSimpleClientset())
+			c.clickhouseConnect = db
+			key := apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: testJobName}
+			c.addPeriodicSync(key)
+
+			err = c.syncJob(key)
+			if tc.expectedErrorMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedErrorMsg)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedDeleted, deleted)
+			assert.Equal(t, tc.expectedFinalizers, handler.jobs[testJobName].Finalizers)
+			assert.NotContains(t, c.periodicResyncSet, key)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestEstimateEndTime(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	startTime := metav1.NewTime(now.Add(-10 * time.Minute))
+	testCases := []struct {
+		name            string
+		startTime       metav1.Time
+		progress        SparkAppProgress
+		expectedEndTime metav1.Time
+	}{
+		{
+			name:            "job not started",
+			progress:        SparkAppProgress{CompletedStages: 1, TotalStages: 2},
+			expectedEndTime: metav1.Time{},
+		},
+		{
+			name:            "stages not determined",
+			startTime:       startTime,
+			expectedEndTime: metav1.Time{},
+		},
+		{
+			name:            "nothing completed",
+			startTime:       startTime,
+			progress:        SparkAppProgress{TotalStages: 4, TotalTasks: 10},
+			expectedEndTime: metav1.Time{},
+		},
+		{
+			name:            "completed stages",
+			startTime:       startTime,
+			progress:        SparkAppProgress{CompletedStages: 2, TotalStages: 4},
+			expectedEndTime: metav1.NewTime(now.Add(10 * time.Minute)),
+		},
+		{
+			name:            "completed stages and tasks",
+			startTime:       startTime,
+			progress:        SparkAppProgress{CompletedStages: 1, TotalStages: 5, CompletedTasks: 5, TotalTasks: 20},
+			expectedEndTime: metav1.NewTime(now.Add(30 * time.Minute)),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedEndTime, estimateEndTime(tc.startTime, tc.progress, now))
+		})
+	}
+}
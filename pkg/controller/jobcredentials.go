@@ -0,0 +1,214 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"antrea.io/theia/pkg/util/env"
+)
+
+const (
+	// JobUserPrefix prefixes the names of the ClickHouse users created for
+	// the jobs, so that the stale ones can be found.
+	JobUserPrefix = "theia_job_"
+	// jobCredentialsSecretSuffix suffixes the name of a job to get the name
+	// of the Secret holding its ClickHouse credentials.
+	jobCredentialsSecretSuffix = "-clickhouse"
+	// The flows table read by the jobs and its local table.
+	flowTable      = "flows"
+	flowLocalTable = "flows_local"
+)
+
+// generateJobPassword returns the password of the ClickHouse user of a job.
+// It is a variable for unit tests.
+var generateJobPassword = generateRandomJobPassword
+
+func generateRandomJobPassword() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// jobUserName returns the name of the ClickHouse user of a job.
+func jobUserName(jobName string) string {
+	return JobUserPrefix + strings.ReplaceAll(jobName, "-", "_")
+}
+
+// jobNameFromUserName returns the name of the job of a ClickHouse user.
+func jobNameFromUserName(userName string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(userName, JobUserPrefix), "_", "-")
+}
+
+// jobCredentialsSecretName returns the name of the Secret holding the
+// ClickHouse credentials of a job.
+func jobCredentialsSecretName(jobName string) string {
+	return jobName + jobCredentialsSecretSuffix
+}
+
+// jobGrantQueries returns the queries granting privileges to the ClickHouse
+// user of a job. The user can read the flows and write the results of the
+// job, but not modify any other table. It can also read the results table, as
+// Spark checks that the table exists before writing to it.
+func (c *JobController) jobGrantQueries(userName string) []string {
+	table, localTable := c.handler.ResultTables()
+	var queries []string
+	for _, grant := range []struct {
+		privileges string
+		table      string
+	}{
+		{"SELECT", flowTable},
+		{"SELECT", flowLocalTable},
+		{"SELECT, INSERT", table},
+		{"SELECT, INSERT", localTable},
+	} {
+		queries = append(queries, fmt.Sprintf("GRANT ON CLUSTER '{cluster}' %s ON default.%s TO %s;", grant.privileges, grant.table, userName))
+	}
+	return queries
+}
+
+// createJobCredentials creates a ClickHouse user dedicated to a job and a
+// Secret owned by the job holding its credentials, and returns the name of
+// the Secret. The Spark Application of the job reads the credentials from
+// the Secret, instead of using the shared ClickHouse account.
+func (c *JobController) createJobCredentials(job metav1.Object, owner *metav1.OwnerReference) (string, error) {
+	connect, err := c.GetClickHouseConnection()
+	if err != nil {
+		return "", err
+	}
+	password, err := generateJobPassword()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate the ClickHouse password of the job: %v", err)
+	}
+	userName := jobUserName(job.GetName())
+	query := fmt.Sprintf("CREATE USER OR REPLACE %s ON CLUSTER '{cluster}' IDENTIFIED WITH sha256_password BY ?", userName)
+	args := []interface{}{password}
+	// The user expires once the job exceeds its deadline, in case its
+	// credentials are not revoked when it is stopped.
+	if deadline := c.activeDeadline(job); deadline > 0 {
+		query += " VALID UNTIL ?"
+		args = append(args, time.Now().Add(deadline).UTC().Format(time.RFC3339))
+	}
+	if _, err := connect.Exec(query+";", args...); err != nil {
+		return "", fmt.Errorf("failed to create the ClickHouse user of the job: %v", err)
+	}
+	for _, query := range c.jobGrantQueries(userName) {
+		if _, err := connect.Exec(query); err != nil {
+			return "", fmt.Errorf("failed to create the ClickHouse user of the job: %v", err)
+		}
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            jobCredentialsSecretName(job.GetName()),
+			Namespace:       job.GetNamespace(),
+			Labels:          c.handler.SparkAppLabels(),
+			OwnerReferences: []metav1.OwnerReference{*owner},
+		},
+		Data: map[string][]byte{
+			"username": []byte(userName),
+			"password": []byte(password),
+		},
+	}
+	secrets := c.kubeClient.CoreV1().Secrets(job.GetNamespace())
+	_, err = secrets.Create(context.TODO(), secret, metav1.CreateOptions{})
+	if apimachineryerrors.IsAlreadyExists(err) {
+		// The user was re-created with a new password after a failed attempt.
+		_, err = secrets.Update(context.TODO(), secret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create the ClickHouse credentials Secret of the job: %v", err)
+	}
+	klog.V(2).InfoS("Created ClickHouse credentials of the job", "kind", c.handler.Kind(), "name", job.GetName(), "user", userName)
+	return secret.Name, nil
+}
+
+// revokeJobCredentials drops the ClickHouse user of a job and deletes the
+// Secret holding its credentials, once the job no longer runs.
+func (c *JobController) revokeJobCredentials(namespace, jobName string) error {
+	connect, err := c.GetClickHouseConnection()
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf("DROP USER IF EXISTS %s ON CLUSTER '{cluster}';", jobUserName(jobName))
+	if _, err := connect.Exec(query); err != nil {
+		return fmt.Errorf("failed to drop the ClickHouse user of %s %s: %v", c.handler.Kind(), jobName, err)
+	}
+	err = c.kubeClient.CoreV1().Secrets(namespace).Delete(context.TODO(), jobCredentialsSecretName(jobName), metav1.DeleteOptions{})
+	if err != nil && !apimachineryerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the ClickHouse credentials Secret of %s %s: %v", c.handler.Kind(), jobName, err)
+	}
+	klog.V(2).InfoS("Revoked ClickHouse credentials of the job", "kind", c.handler.Kind(), "name", jobName)
+	return nil
+}
+
+// removeStaleJobCredentials revokes the credentials of the jobs which were
+// deleted or finished without revoking them, e.g. because theia-manager was
+// restarted in-between.
+func (c *JobController) removeStaleJobCredentials() error {
+	connect, err := c.GetClickHouseConnection()
+	if err != nil {
+		return err
+	}
+	prefix := jobUserName(c.handler.NamePrefix())
+	rows, err := connect.Query("SELECT name FROM system.users WHERE startsWith(name, ?);", prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list the ClickHouse users of the jobs: %v", err)
+	}
+	defer rows.Close()
+	var jobNames []string
+	for rows.Next() {
+		var userName string
+		if err := rows.Scan(&userName); err != nil {
+			return fmt.Errorf("failed to scan the ClickHouse users of the jobs: %v", err)
+		}
+		jobNames = append(jobNames, jobNameFromUserName(userName))
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list the ClickHouse users of the jobs: %v", err)
+	}
+	var errorList []error
+	for _, jobName := range jobNames {
+		job, err := c.handler.GetJob(env.GetTheiaNamespace(), jobName)
+		if err != nil && !apimachineryerrors.IsNotFound(err) {
+			errorList = append(errorList, err)
+			continue
+		}
+		if err == nil {
+			state := c.handler.GetJobStatus(job).State
+			if state != JobStateCompleted && state != JobStateFailed {
+				continue
+			}
+		}
+		if err := c.revokeJobCredentials(env.GetTheiaNamespace(), jobName); err != nil {
+			errorList = append(errorList, err)
+		}
+	}
+	if len(errorList) > 0 {
+		return fmt.Errorf("failed to remove all stale ClickHouse credentials: %v", errorList)
+	}
+	return nil
+}
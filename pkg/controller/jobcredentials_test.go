@@ -0,0 +1,188 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+)
+
+const testJobPassword = "0123456789abcdef"
+
+// newTestClickHouse sets up a mock ClickHouse connection for c, and makes the
+// ClickHouse users of the jobs use testJobPassword.
+func newTestClickHouse(t *testing.T, c *JobController) sqlmock.Sqlmock {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	c.clickhouseConnect = db
+	generateJobPassword = func() (string, error) {
+		return testJobPassword, nil
+	}
+	t.Cleanup(func() {
+		db.Close()
+		generateJobPassword = generateRandomJobPassword
+	})
+	return mock
+}
+
+func expectCreateJobCredentials(mock sqlmock.Sqlmock, jobName string) {
+	userName := jobUserName(jobName)
+	mock.ExpectExec("CREATE USER OR REPLACE " + userName + " ON CLUSTER '{cluster}' IDENTIFIED WITH sha256_password BY ?;").WithArgs(testJobPassword).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectGrantJobPrivileges(mock, userName)
+}
+
+func expectGrantJobPrivileges(mock sqlmock.Sqlmock, userName string) {
+	for _, query := range []string{
+		"GRANT ON CLUSTER '{cluster}' SELECT ON default.flows TO " + userName + ";",
+		"GRANT ON CLUSTER '{cluster}' SELECT ON default.flows_local TO " + userName + ";",
+		"GRANT ON CLUSTER '{cluster}' SELECT, INSERT ON default.test TO " + userName + ";",
+		"GRANT ON CLUSTER '{cluster}' SELECT, INSERT ON default.test_local TO " + userName + ";",
+	} {
+		mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+}
+
+func expectRevokeJobCredentials(mock sqlmock.Sqlmock, jobName string) {
+	mock.ExpectExec("DROP USER IF EXISTS " + jobUserName(jobName) + " ON CLUSTER '{cluster}';").WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestJobUserName(t *testing.T) {
+	userName := jobUserName(testJobName)
+	assert.Equal(t, "theia_job_test_1234abcd_1234_abcd_12ab_12345678abcd", userName)
+	assert.Equal(t, testJobName, jobNameFromUserName(userName))
+}
+
+func TestJobControllerCreateJobCredentials(t *testing.T) {
+	for _, secretExists := range []bool{false, true} {
+		t.Run(fmt.Sprintf("secretExists=%t", secretExists), func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			secretName := jobCredentialsSecretName(testJobName)
+			if secretExists {
+				_, err := kubeClient.CoreV1().Secrets(testNamespace).Create(context.TODO(), &v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: testNamespace},
+					Data:       map[string][]byte{"password": []byte("stale")},
+				}, metav1.CreateOptions{})
+				require.NoError(t, err)
+			}
+			handler := newTestJobHandler(SparkJob{}, testJobName)
+			c := newTestJobController(handler, kubeClient)
+			mock := newTestClickHouse(t, c)
+			expectCreateJobCredentials(mock, testJobName)
+
+			job := handler.jobs[testJobName]
+			owner := metav1.NewControllerRef(job, crdv1alpha1.SchemeGroupVersion.WithKind(handler.Kind()))
+			name, err := c.createJobCredentials(job, owner)
+			require.NoError(t, err)
+			assert.Equal(t, testJobName+"-clickhouse", name)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			secret, err := kubeClient.CoreV1().Secrets(testNamespace).Get(context.TODO(), name, metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, []byte(jobUserName(testJobName)), secret.Data["username"])
+			assert.Equal(t, []byte(testJobPassword), secret.Data["password"])
+			assert.Equal(t, handler.SparkAppLabels(), secret.Labels)
+			assert.Equal(t, []metav1.OwnerReference{*owner}, secret.OwnerReferences)
+		})
+	}
+}
+
+// validUntilArg matches the expiration time of a ClickHouse user between min
+// and max.
+type validUntilArg struct {
+	min, max time.Time
+}
+
+func (a validUntilArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+	validUntil, err := time.Parse(time.RFC3339, s)
+	return err == nil && !validUntil.Before(a.min) && !validUntil.After(a.max)
+}
+
+func TestJobControllerCreateJobCredentialsValidUntil(t *testing.T) {
+	handler := newTestJobHandler(SparkJob{}, testJobName)
+	handler.activeDeadlineSeconds = 3600
+	c := newTestJobController(handler, fake.NewSimpleClientset())
+	mock := newTestClickHouse(t, c)
+	now := time.Now().Truncate(time.Second)
+	userName := jobUserName(testJobName)
+	mock.ExpectExec("CREATE USER OR REPLACE "+userName+" ON CLUSTER '{cluster}' IDENTIFIED WITH sha256_password BY ? VALID UNTIL ?;").
+		WithArgs(testJobPassword, validUntilArg{min: now.Add(time.Hour), max: now.Add(time.Hour + time.Minute)}).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectGrantJobPrivileges(mock, userName)
+
+	job := handler.jobs[testJobName]
+	owner := metav1.NewControllerRef(job, crdv1alpha1.SchemeGroupVersion.WithKind(handler.Kind()))
+	_, err := c.createJobCredentials(job, owner)
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobControllerRevokeFailedJobCredentials(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	secretName := jobCredentialsSecretName(testJobName)
+	_, err := kubeClient.CoreV1().Secrets(testNamespace).Create(context.TODO(), &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: secretName, Namespace: testNamespace},
+	}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	handler := newTestJobHandler(SparkJob{}, testJobName)
+	handler.status[testJobName] = JobStatus{State: JobStateFailed, SparkApplication: testJobName[len("test-"):]}
+	c := newTestJobController(handler, kubeClient)
+	mock := newTestClickHouse(t, c)
+	expectRevokeJobCredentials(mock, testJobName)
+
+	require.NoError(t, c.syncJob(apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: testJobName}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	endTime := handler.status[testJobName].EndTime
+	assert.False(t, endTime.IsZero())
+	_, err = kubeClient.CoreV1().Secrets(testNamespace).Get(context.TODO(), secretName, metav1.GetOptions{})
+	assert.True(t, apimachineryerrors.IsNotFound(err))
+}
+
+func TestJobControllerRemoveStaleJobCredentials(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", testNamespace)
+	handler := newTestJobHandler(SparkJob{}, "test-running", "test-completed")
+	handler.status["test-running"] = JobStatus{State: JobStateRunning}
+	handler.status["test-completed"] = JobStatus{State: JobStateCompleted}
+	c := newTestJobController(handler, fake.NewSimpleClientset())
+	mock := newTestClickHouse(t, c)
+	mock.ExpectQuery("SELECT name FROM system.users WHERE startsWith(name, ?);").WithArgs("theia_job_test_").WillReturnRows(
+		sqlmock.NewRows([]string{"name"}).
+			AddRow(jobUserName("test-running")).
+			AddRow(jobUserName("test-completed")).
+			AddRow(jobUserName("test-deleted")))
+	expectRevokeJobCredentials(mock, "test-completed")
+	expectRevokeJobCredentials(mock, "test-deleted")
+
+	_, err := c.handleStaleResources(GcKey{RemoveStaleCredentials: true})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,220 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+)
+
+const (
+	// JobInputBudgetActionReject fails the jobs whose estimated input
+	// exceeds the budget, before their Spark Application is created.
+	JobInputBudgetActionReject = "Reject"
+	// JobInputBudgetActionScaleUp runs the jobs whose estimated input
+	// exceeds the budget with the executor settings of the budget.
+	JobInputBudgetActionScaleUp = "ScaleUp"
+	// JobReasonInputBudgetExceeded prefixes the error message of the jobs
+	// which were rejected because their estimated input exceeds the budget.
+	JobReasonInputBudgetExceeded = "InputBudgetExceeded"
+)
+
+// jobInputEstimateQuery counts the flow records read by a job. Their size is
+// extrapolated from the average uncompressed size of the records of the flows
+// table, which ClickHouse keeps in the metadata of the table parts.
+const jobInputEstimateQuery = `
+SELECT
+	count() AS Rows,
+	toInt64(count() * (
+		SELECT sum(data_uncompressed_bytes) / greatest(sum(rows), 1)
+		FROM cluster('{cluster}', system.parts)
+		WHERE active AND database = 'default' AND table = 'flows_local'
+	)) AS Bytes
+FROM flows`
+
+// JobInput selects the flow records read by a job.
+type JobInput struct {
+	// StartTime and EndTime bound the time window of the flow records, when
+	// they are not zero.
+	StartTime metav1.Time
+	EndTime   metav1.Time
+	// Conditions are SQL conditions on the flows table matched by the flow
+	// records, with a placeholder for each value of Args.
+	Conditions []string
+	Args       []interface{}
+}
+
+// AddInCondition adds a condition matching the flow records in which any of
+// columns has one of values or, if not is true, none of columns has any of
+// values.
+func (i *JobInput) AddInCondition(columns []string, values []string, not bool) {
+	if len(columns) == 0 || len(values) == 0 {
+		return
+	}
+	operator, separator := "IN", " OR "
+	if not {
+		operator, separator = "NOT IN", " AND "
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+	conditions := make([]string, 0, len(columns))
+	for _, column := range columns {
+		conditions = append(conditions, fmt.Sprintf("%s %s (%s)", column, operator, placeholders))
+		for _, value := range values {
+			i.Args = append(i.Args, value)
+		}
+	}
+	i.Conditions = append(i.Conditions, "("+strings.Join(conditions, separator)+")")
+}
+
+// query returns the query estimating the input and its arguments.
+func (i *JobInput) query() (string, []interface{}) {
+	conditions := append([]string{}, i.Conditions...)
+	args := append([]interface{}{}, i.Args...)
+	if !i.StartTime.IsZero() {
+		conditions = append(conditions, "flowStartSeconds >= ?")
+		args = append(args, i.StartTime.UTC().Format(InputTimeFormat))
+	}
+	if !i.EndTime.IsZero() {
+		conditions = append(conditions, "flowEndSeconds < ?")
+		args = append(args, i.EndTime.UTC().Format(InputTimeFormat))
+	}
+	query := jobInputEstimateQuery
+	if len(conditions) > 0 {
+		query += "\nWHERE " + strings.Join(conditions, " AND ")
+	}
+	return query, args
+}
+
+// JobInputBudget limits the estimated input of the jobs. A limit of 0 means
+// that it is not enforced.
+type JobInputBudget struct {
+	MaxRows  int64
+	MaxBytes int64
+	// Action is JobInputBudgetActionReject or JobInputBudgetActionScaleUp.
+	Action string
+	// ExecutorInstances and ExecutorMemory replace the settings of the jobs
+	// exceeding the budget which request less, when Action is
+	// JobInputBudgetActionScaleUp.
+	ExecutorInstances int
+	ExecutorMemory    string
+}
+
+// enabled returns whether any limit is enforced.
+func (b JobInputBudget) enabled() bool {
+	return b.MaxRows > 0 || b.MaxBytes > 0
+}
+
+// exceededBy returns why estimate exceeds the budget, or an empty string if
+// it does not.
+func (b JobInputBudget) exceededBy(estimate *crdv1alpha1.JobInputEstimate) string {
+	if estimate == nil {
+		return ""
+	}
+	if b.MaxRows > 0 && estimate.Rows > b.MaxRows {
+		return fmt.Sprintf("the estimated input of %d flow records exceeds the budget of %d", estimate.Rows, b.MaxRows)
+	}
+	if b.MaxBytes > 0 && estimate.Bytes > b.MaxBytes {
+		return fmt.Sprintf("the estimated input of %s exceeds the budget of %s",
+			resource.NewQuantity(estimate.Bytes, resource.BinarySI), resource.NewQuantity(b.MaxBytes, resource.BinarySI))
+	}
+	return ""
+}
+
+// scaleUp raises the executor settings of sparkJob to the ones of the budget.
+func (b JobInputBudget) scaleUp(sparkJob *SparkJob) {
+	if b.ExecutorInstances > sparkJob.ExecutorInstances {
+		sparkJob.ExecutorInstances = b.ExecutorInstances
+	}
+	if b.ExecutorMemory == "" {
+		return
+	}
+	budgetMemory, err := resource.ParseQuantity(b.ExecutorMemory)
+	if err != nil {
+		klog.ErrorS(err, "Invalid executor memory of the input budget", "executorMemory", b.ExecutorMemory)
+		return
+	}
+	jobMemory, err := resource.ParseQuantity(sparkJob.ExecutorMemory)
+	if err != nil || budgetMemory.Cmp(jobMemory) > 0 {
+		sparkJob.ExecutorMemory = b.ExecutorMemory
+	}
+}
+
+// InputBudgetExceededError is returned when a job is rejected because its
+// estimated input exceeds the budget.
+type InputBudgetExceededError struct {
+	Estimate *crdv1alpha1.JobInputEstimate
+	Reason   string
+}
+
+func (e InputBudgetExceededError) Error() string {
+	return fmt.Sprintf("%s: %s", JobReasonInputBudgetExceeded, e.Reason)
+}
+
+// EstimateJobInput validates the spec of a job and estimates the flow records
+// it reads with ClickHouse, without starting it.
+func (c *JobController) EstimateJobInput(job metav1.Object) (*crdv1alpha1.JobInputEstimate, error) {
+	if _, err := c.getSparkJob(job); err != nil {
+		return nil, err
+	}
+	return c.estimateJobInput(job)
+}
+
+func (c *JobController) estimateJobInput(job metav1.Object) (*crdv1alpha1.JobInputEstimate, error) {
+	connect, err := c.GetClickHouseConnection()
+	if err != nil {
+		return nil, err
+	}
+	input, err := c.handler.GetJobInput(job)
+	if err != nil {
+		return nil, err
+	}
+	query, args := input.query()
+	estimate := &crdv1alpha1.JobInputEstimate{}
+	if err := connect.QueryRow(query, args...).Scan(&estimate.Rows, &estimate.Bytes); err != nil {
+		return nil, fmt.Errorf("failed to estimate the input of the job: %v", err)
+	}
+	return estimate, nil
+}
+
+// checkInputBudget estimates the input of a job before its Spark Application
+// is created, and enforces the input budget on sparkJob. The estimate is nil
+// if it fails while the budget is not enforced.
+func (c *JobController) checkInputBudget(job metav1.Object, sparkJob *SparkJob) (*crdv1alpha1.JobInputEstimate, error) {
+	estimate, err := c.estimateJobInput(job)
+	if err != nil {
+		if !c.inputBudget.enabled() {
+			klog.ErrorS(err, "Failed to estimate the input of the job", "kind", c.handler.Kind(), "name", job.GetName())
+			return nil, nil
+		}
+		return nil, err
+	}
+	klog.V(2).InfoS("Estimated the input of the job", "kind", c.handler.Kind(), "name", job.GetName(), "rows", estimate.Rows, "bytes", estimate.Bytes)
+	reason := c.inputBudget.exceededBy(estimate)
+	if reason == "" {
+		return estimate, nil
+	}
+	if c.inputBudget.Action != JobInputBudgetActionScaleUp {
+		return estimate, InputBudgetExceededError{Estimate: estimate, Reason: reason}
+	}
+	klog.InfoS("Scaling up the executors of the job", "kind", c.handler.Kind(), "name", job.GetName(), "reason", reason)
+	c.inputBudget.scaleUp(sparkJob)
+	return estimate, nil
+}
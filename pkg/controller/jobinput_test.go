@@ -0,0 +1,205 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apimachinerytypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	sparkv1 "antrea.io/theia/third_party/sparkoperator/v1beta2"
+)
+
+func TestJobInputQuery(t *testing.T) {
+	startTime := metav1.NewTime(time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC))
+	endTime := metav1.NewTime(time.Date(2023, 1, 3, 15, 4, 5, 0, time.UTC))
+	testCases := []struct {
+		name              string
+		input             func() JobInput
+		expectedCondition string
+		expectedArgs      []interface{}
+	}{
+		{
+			name:         "no filter",
+			input:        func() JobInput { return JobInput{} },
+			expectedArgs: []interface{}{},
+		},
+		{
+			name: "time window",
+			input: func() JobInput {
+				return JobInput{StartTime: startTime, EndTime: endTime}
+			},
+			expectedCondition: "\nWHERE flowStartSeconds >= ? AND flowEndSeconds < ?",
+			expectedArgs:      []interface{}{"2023-01-02 15:04:05", "2023-01-03 15:04:05"},
+		},
+		{
+			name: "Namespaces in any column",
+			input: func() JobInput {
+				input := JobInput{EndTime: endTime}
+				input.AddInCondition([]string{"sourcePodNamespace", "destinationPodNamespace"}, []string{"ns1", "ns2"}, false)
+				return input
+			},
+			expectedCondition: "\nWHERE (sourcePodNamespace IN (?, ?) OR destinationPodNamespace IN (?, ?)) AND flowEndSeconds < ?",
+			expectedArgs:      []interface{}{"ns1", "ns2", "ns1", "ns2", "2023-01-03 15:04:05"},
+		},
+		{
+			name: "Namespaces in no column",
+			input: func() JobInput {
+				input := JobInput{Conditions: []string{"flowType = 3"}}
+				input.AddInCondition([]string{"sourcePodNamespace", "destinationPodNamespace"}, []string{"kube-system"}, true)
+				input.AddInCondition([]string{"destinationIP"}, nil, false)
+				return input
+			},
+			expectedCondition: "\nWHERE flowType = 3 AND (sourcePodNamespace NOT IN (?) AND destinationPodNamespace NOT IN (?))",
+			expectedArgs:      []interface{}{"kube-system", "kube-system"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := tc.input()
+			query, args := input.query()
+			assert.Equal(t, jobInputEstimateQuery+tc.expectedCondition, query)
+			assert.Equal(t, tc.expectedArgs, args)
+		})
+	}
+}
+
+func TestJobInputBudgetExceededBy(t *testing.T) {
+	budget := JobInputBudget{MaxRows: 1000, MaxBytes: 1 << 30}
+	assert.Empty(t, budget.exceededBy(nil))
+	assert.Empty(t, budget.exceededBy(&crdv1alpha1.JobInputEstimate{Rows: 1000, Bytes: 1 << 30}))
+	assert.Equal(t, "the estimated input of 1001 flow records exceeds the budget of 1000",
+		budget.exceededBy(&crdv1alpha1.JobInputEstimate{Rows: 1001}))
+	assert.Equal(t, "the estimated input of 2Gi exceeds the budget of 1Gi",
+		budget.exceededBy(&crdv1alpha1.JobInputEstimate{Rows: 10, Bytes: 2 << 30}))
+	assert.Empty(t, JobInputBudget{}.exceededBy(&crdv1alpha1.JobInputEstimate{Rows: 1 << 40, Bytes: 1 << 50}))
+}
+
+func TestJobInputBudgetScaleUp(t *testing.T) {
+	budget := JobInputBudget{ExecutorInstances: 4, ExecutorMemory: "4G"}
+	sparkJob := SparkJob{ExecutorInstances: 1, ExecutorMemory: "512M"}
+	budget.scaleUp(&sparkJob)
+	assert.Equal(t, 4, sparkJob.ExecutorInstances)
+	assert.Equal(t, "4G", sparkJob.ExecutorMemory)
+
+	// Larger settings of the job are kept.
+	sparkJob = SparkJob{ExecutorInstances: 8, ExecutorMemory: "16G"}
+	budget.scaleUp(&sparkJob)
+	assert.Equal(t, 8, sparkJob.ExecutorInstances)
+	assert.Equal(t, "16G", sparkJob.ExecutorMemory)
+}
+
+func TestJobControllerInputBudget(t *testing.T) {
+	testCases := []struct {
+		name                      string
+		budget                    JobInputBudget
+		estimateErr               error
+		expectedState             string
+		expectedErrorMsg          string
+		expectedEstimate          *crdv1alpha1.JobInputEstimate
+		expectedExecutorInstances int32
+	}{
+		{
+			name:                      "estimate within the budget",
+			budget:                    JobInputBudget{MaxRows: 2000},
+			expectedState:             JobStateScheduled,
+			expectedEstimate:          &crdv1alpha1.JobInputEstimate{Rows: 1500, Bytes: 300000},
+			expectedExecutorInstances: 1,
+		},
+		{
+			name:             "estimate exceeding the budget rejected",
+			budget:           JobInputBudget{MaxRows: 1000, Action: JobInputBudgetActionReject},
+			expectedState:    JobStateFailed,
+			expectedErrorMsg: "InputBudgetExceeded: the estimated input of 1500 flow records exceeds the budget of 1000",
+			expectedEstimate: &crdv1alpha1.JobInputEstimate{Rows: 1500, Bytes: 300000},
+		},
+		{
+			name:                      "estimate exceeding the budget scaled up",
+			budget:                    JobInputBudget{MaxBytes: 1000, Action: JobInputBudgetActionScaleUp, ExecutorInstances: 3},
+			expectedState:             JobStateScheduled,
+			expectedEstimate:          &crdv1alpha1.JobInputEstimate{Rows: 1500, Bytes: 300000},
+			expectedExecutorInstances: 3,
+		},
+		{
+			name:                      "failed estimate without budget",
+			estimateErr:               fmt.Errorf("table flows does not exist"),
+			expectedState:             JobStateScheduled,
+			expectedExecutorInstances: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			createRunningPod(t, kubeClient, "clickhouse", map[string]string{"app": "clickhouse"})
+			createRunningPod(t, kubeClient, "spark-operator", map[string]string{"app.kubernetes.io/name": "spark-operator"})
+			GetSparkApplication = getNoSparkApplication
+			var created *sparkv1.SparkApplication
+			CreateSparkApplication = func(client kubernetes.Interface, namespace string, sparkApplication *sparkv1.SparkApplication) error {
+				created = sparkApplication
+				return nil
+			}
+			defer func() {
+				GetSparkApplication = getSparkApplication
+				CreateSparkApplication = createSparkApplication
+			}()
+			handler := newTestJobHandler(SparkJob{
+				MainApplicationFile: "local:///opt/spark/work-dir/test_job.py",
+				ExecutorInstances:   1,
+				DriverCoreRequest:   "200m",
+				DriverMemory:        "512M",
+				ExecutorCoreRequest: "200m",
+				ExecutorMemory:      "512M",
+			}, testJobName)
+			handler.input.AddInCondition([]string{"sourcePodNamespace"}, []string{"ns1"}, false)
+			handler.input.AddInCondition([]string{"destinationPodNamespace"}, []string{"ns1"}, true)
+			c := newTestJobController(handler, kubeClient)
+			c.inputBudget = tc.budget
+			mock := newTestClickHouse(t, c)
+			query, _ := handler.input.query()
+			expectedQuery := mock.ExpectQuery(query).WithArgs("ns1", "ns1")
+			if tc.estimateErr != nil {
+				expectedQuery.WillReturnError(tc.estimateErr)
+			} else {
+				expectedQuery.WillReturnRows(sqlmock.NewRows([]string{"Rows", "Bytes"}).AddRow(1500, 300000))
+			}
+			if tc.expectedState == JobStateScheduled {
+				expectCreateJobCredentials(mock, testJobName)
+			}
+			require.NoError(t, c.syncJob(apimachinerytypes.NamespacedName{Namespace: testNamespace, Name: testJobName}))
+			require.NoError(t, mock.ExpectationsWereMet())
+
+			status := handler.status[testJobName]
+			assert.Equal(t, tc.expectedState, status.State)
+			assert.Equal(t, tc.expectedErrorMsg, status.ErrorMsg)
+			assert.Equal(t, tc.expectedEstimate, status.InputEstimate)
+			if tc.expectedState != JobStateScheduled {
+				assert.Nil(t, created)
+				assert.Empty(t, c.periodicResyncSet)
+				return
+			}
+			require.NotNil(t, created)
+			assert.Equal(t, tc.expectedExecutorInstances, *created.Spec.Executor.Instances)
+		})
+	}
+}
@@ -16,24 +16,19 @@ package networkpolicyrecommendation
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
 	"fmt"
-	"reflect"
-	"regexp"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
-	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	apimachinerytypes "k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/client-go/util/workqueue"
-	"k8s.io/klog/v2"
 
 	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
 	"antrea.io/theia/pkg/client/clientset/versioned"
@@ -41,9 +36,7 @@ import (
 	"antrea.io/theia/pkg/client/listers/crd/v1alpha1"
 	controllerutil "antrea.io/theia/pkg/controller"
 	"antrea.io/theia/pkg/util"
-	"antrea.io/theia/pkg/util/clickhouse"
 	"antrea.io/theia/pkg/util/env"
-	sparkv1 "antrea.io/theia/third_party/sparkoperator/v1beta2"
 )
 
 const (
@@ -53,488 +46,175 @@ const (
 )
 
 var (
-	// Spark Application CRUD functions, for unit tests
-	CreateSparkApplication   = controllerutil.CreateSparkApplication
-	DeleteSparkApplication   = controllerutil.DeleteSparkApplication
-	ListSparkApplication     = controllerutil.ListSparkApplicationWithLabel
-	GetSparkApplication      = controllerutil.GetSparkApplication
-	GetSparkMonitoringSvcDNS = controllerutil.GetSparkMonitoringSvcDNS
-	// For NPR in scheduled or running state, check its status periodically
-	npRecommendationResyncPeriod = 10 * time.Second
+	// For NPR in scheduled or running state, check its deadline and update its
+	// progress periodically. Its state is synced on Spark Application events.
+	npRecommendationResyncPeriod = 30 * time.Second
 	sparkAppLabelMap             = map[string]string{"app": "theia-npr"}
-	sparkAppLabel                = "app=theia-npr"
 )
 
+// NPRecommendationController runs NetworkPolicyRecommendations as policy
+// recommendation Spark jobs.
 type NPRecommendationController struct {
+	*controllerutil.JobController
 	crdClient  versioned.Interface
 	kubeClient kubernetes.Interface
 
-	npRecommendationInformer cache.SharedIndexInformer
-	npRecommendationLister   v1alpha1.NetworkPolicyRecommendationLister
-	npRecommendationSynced   cache.InformerSynced
-	// queue maintains the Service objects that need to be synced.
-	queue                  workqueue.RateLimitingInterface
-	deletionQueue          workqueue.RateLimitingInterface
-	gcQueue                workqueue.RateLimitingInterface
-	periodicResyncSetMutex sync.Mutex
-	periodicResyncSet      map[apimachinerytypes.NamespacedName]struct{}
-	clickhouseConnect      *sql.DB
+	npRecommendationLister v1alpha1.NetworkPolicyRecommendationLister
 }
 
-type NamespacedId struct {
-	Namespace string
-	Id        string
-}
+var _ controllerutil.JobHandler = &NPRecommendationController{}
 
 func NewNPRecommendationController(
 	crdClient versioned.Interface,
 	kubeClient kubernetes.Interface,
+	dynamicClient dynamic.Interface,
 	npRecommendationInformer crdv1a1informers.NetworkPolicyRecommendationInformer,
+	defaultActiveDeadline time.Duration,
+	historyLimits controllerutil.JobHistoryLimits,
+	inputBudget controllerutil.JobInputBudget,
 ) *NPRecommendationController {
 	c := &NPRecommendationController{
-		crdClient:                crdClient,
-		kubeClient:               kubeClient,
-		queue:                    workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(controllerutil.MinRetryDelay, controllerutil.MaxRetryDelay), "npRecommendation"),
-		deletionQueue:            workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(controllerutil.MinRetryDelay, controllerutil.MaxRetryDelay), "npRecommendationCleanup"),
-		gcQueue:                  workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(controllerutil.MinRetryDelay, controllerutil.MaxRetryDelay), "npRecommendationGarbageCollection"),
-		npRecommendationInformer: npRecommendationInformer.Informer(),
-		npRecommendationLister:   npRecommendationInformer.Lister(),
-		npRecommendationSynced:   npRecommendationInformer.Informer().HasSynced,
-		periodicResyncSet:        make(map[apimachinerytypes.NamespacedName]struct{}),
+		crdClient:              crdClient,
+		kubeClient:             kubeClient,
+		npRecommendationLister: npRecommendationInformer.Lister(),
 	}
-
-	c.npRecommendationInformer.AddEventHandlerWithResyncPeriod(
-		cache.ResourceEventHandlerFuncs{
-			AddFunc:    c.addNPRecommendation,
-			UpdateFunc: c.updateNPRecommendation,
-			DeleteFunc: c.deleteNPRecommendation,
-		},
-		controllerutil.ResyncPeriod,
-	)
-
+	// The Spark Applications are read from the API server if no dynamic
+	// client is provided.
+	var sparkAppInformer cache.SharedIndexInformer
+	if dynamicClient != nil {
+		sparkAppInformer = controllerutil.NewSparkApplicationInformer(dynamicClient, env.GetTheiaNamespace(), sparkAppLabelMap, controllerutil.ResyncPeriod)
+	}
+	c.JobController = controllerutil.NewJobController(controllerName, c, kubeClient, npRecommendationInformer.Informer(), sparkAppInformer, npRecommendationResyncPeriod, defaultActiveDeadline, historyLimits, inputBudget)
 	return c
 }
 
-func (c *NPRecommendationController) addNPRecommendation(obj interface{}) {
-	npReco, ok := obj.(*crdv1alpha1.NetworkPolicyRecommendation)
-	if !ok {
-		klog.ErrorS(nil, "fail to convert to NetworkPolicyRecommendation", "object", obj)
-		return
-	}
-	klog.V(2).InfoS("Processing NP Recommendation ADD event", "name", npReco.Name, "labels", npReco.Labels)
-	namespacedName := apimachinerytypes.NamespacedName{
-		Namespace: npReco.Namespace,
-		Name:      npReco.Name,
-	}
-	c.queue.Add(namespacedName)
+func (c *NPRecommendationController) Kind() string {
+	return "NetworkPolicyRecommendation"
 }
 
-func (c *NPRecommendationController) updateNPRecommendation(_, new interface{}) {
-	npReco, ok := new.(*crdv1alpha1.NetworkPolicyRecommendation)
-	if !ok {
-		klog.ErrorS(nil, "fail to convert to NetworkPolicyRecommendation", "object", new)
-		return
-	}
-	klog.V(2).InfoS("Processing NP Recommendation UPDATE event", "name", npReco.Name, "labels", npReco.Labels)
-	namespacedName := apimachinerytypes.NamespacedName{
-		Namespace: npReco.Namespace,
-		Name:      npReco.Name,
-	}
-	c.queue.Add(namespacedName)
+func (c *NPRecommendationController) NamePrefix() string {
+	return "pr-"
 }
 
-func (c *NPRecommendationController) deleteNPRecommendation(old interface{}) {
-	npReco, ok := old.(*crdv1alpha1.NetworkPolicyRecommendation)
-	if !ok {
-		tombstone, ok := old.(cache.DeletedFinalStateUnknown)
-		if !ok {
-			klog.ErrorS(nil, "Error decoding object when deleting NP Recommendation", "oldObject", old)
-			return
-		}
-		npReco, ok = tombstone.Obj.(*crdv1alpha1.NetworkPolicyRecommendation)
-		if !ok {
-			klog.ErrorS(nil, "Error decoding object tombstone when deleting NP Recommendation", "tombstone", tombstone.Obj)
-			return
-		}
-	}
-	klog.V(2).InfoS("Processing NP Recommendation DELETE event", "name", npReco.Name, "labels", npReco.Labels)
-	// remove NPRecommendation from periodic synchronization list in case it is deleted before completing
-	c.stopPeriodicSync(apimachinerytypes.NamespacedName{
-		Namespace: npReco.Namespace,
-		Name:      npReco.Name,
-	})
-	// Add SparkApplication and Namespace information to deletionQueue for cleanup
-	if npReco.Status.SparkApplication != "" {
-		namespacedId := NamespacedId{
-			Namespace: npReco.Namespace,
-			Id:        npReco.Status.SparkApplication,
-		}
-		c.deletionQueue.Add(namespacedId)
-	}
+func (c *NPRecommendationController) SparkAppLabels() map[string]string {
+	return sparkAppLabelMap
 }
 
-// Run will create defaultWorkers workers (go routines) which will process the Service events from the
-// workqueue.
-func (c *NPRecommendationController) Run(stopCh <-chan struct{}) {
-	defer c.queue.ShutDown()
-	defer c.deletionQueue.ShutDown()
-	defer c.gcQueue.ShutDown()
-
-	klog.InfoS("Starting controller", "name", controllerName)
-	defer klog.InfoS("Shutting down controller", "name", controllerName)
-
-	if !cache.WaitForNamedCacheSync(controllerName, stopCh, c.npRecommendationSynced) {
-		return
-	}
-
-	c.gcQueue.Add(controllerutil.GcKey{
-		RemoveStaleDbEntries: true,
-		RemoveStaleSparkApp:  true,
-		AddResync:            true,
-	})
-	go c.gcworker(stopCh)
-
-	go wait.Until(c.resyncNPRecommendation, npRecommendationResyncPeriod, stopCh)
-
-	go wait.Until(c.deletionworker, time.Second, stopCh)
-
-	for i := 0; i < controllerutil.DefaultWorkers; i++ {
-		go wait.Until(c.worker, time.Second, stopCh)
-	}
-	<-stopCh
+func (c *NPRecommendationController) ResultTables() (string, string) {
+	return "recommendations", "recommendations_local"
 }
 
-func (c *NPRecommendationController) IfNPRexists(namespace, id string) error {
-	_, err := c.GetNetworkPolicyRecommendation(env.GetTheiaNamespace(), id)
-	if err != nil {
-		return err
-	}
-	return nil
+func (c *NPRecommendationController) GetJob(namespace, name string) (metav1.Object, error) {
+	return c.GetNetworkPolicyRecommendation(namespace, name)
 }
 
-// handleStaleResources handles the stale Spark Applications and database entries.
-// It will delete the dangling resources without a matching NetworkPolicyRecommendation
-// and add the running NetworkPolicyRecommendation back to the periodical watch list.
-func (c *NPRecommendationController) handleStaleResources(key controllerutil.GcKey) (updatedKey controllerutil.GcKey, err error) {
-	var errorList []error
-	if key.AddResync {
-		// Add scheduled/running NPR back to resycn list
-		nprList, err := c.ListNetworkPolicyRecommendation(env.GetTheiaNamespace())
-		if err != nil {
-			errorList = append(errorList, fmt.Errorf("failed to list NetworkPolicyRecommendations: %v", err))
-		} else {
-			for _, npr := range nprList {
-				if npr.Status.State == crdv1alpha1.NPRecommendationStateScheduled || npr.Status.State == crdv1alpha1.NPRecommendationStateRunning {
-					c.addPeriodicSync(apimachinerytypes.NamespacedName{
-						Namespace: npr.Namespace,
-						Name:      npr.Name,
-					})
-				}
-			}
-			key.AddResync = false
-		}
-	}
-	if key.RemoveStaleDbEntries {
-		err = controllerutil.HandleStaleDbEntries(
-			c.clickhouseConnect, c.kubeClient, "recommendations", "recommendations_local", c.IfNPRexists, "pr-")
-		if err != nil {
-			errorList = append(errorList, err)
-		} else {
-			key.RemoveStaleDbEntries = false
-		}
-	}
-
-	if key.RemoveStaleSparkApp {
-		err = controllerutil.HandleStaleSparkApp(c.kubeClient, sparkAppLabel, c.IfNPRexists)
-		if err != nil {
-			errorList = append(errorList, err)
-		} else {
-			key.RemoveStaleSparkApp = false
-		}
-	}
-
-	if len(errorList) > 0 {
-		return key, fmt.Errorf("failed during garbage collection: %v", errorList)
-	} else {
-		return key, nil
-	}
-}
-
-func (c *NPRecommendationController) gcworker(stopCh <-chan struct{}) {
-	wait.PollImmediateUntil(time.Second, func() (done bool, err error) {
-		return !c.processNextGcWorkItem(), nil
-	}, stopCh)
-}
-
-func (c *NPRecommendationController) processNextGcWorkItem() bool {
-	obj, quit := c.gcQueue.Get()
-	if quit {
-		return false
+func (c *NPRecommendationController) ListJobs(namespace string) ([]metav1.Object, error) {
+	nprList, err := c.ListNetworkPolicyRecommendation(namespace)
+	if err != nil {
+		return nil, err
 	}
-	defer c.gcQueue.Done(obj)
-
-	if key, ok := obj.(controllerutil.GcKey); !ok {
-		c.queue.Forget(obj)
-		klog.ErrorS(nil, "Expected gcKey in work queue", "got", obj)
-		return false
-	} else if updatedKey, err := c.handleStaleResources(key); err == nil {
-		c.gcQueue.Forget(key)
-		return false
-	} else {
-		klog.ErrorS(err, "Error handling stale resources, requeuing it")
-		c.gcQueue.AddRateLimited(updatedKey)
+	jobs := make([]metav1.Object, 0, len(nprList))
+	for _, npr := range nprList {
+		jobs = append(jobs, npr)
 	}
-	return true
+	return jobs, nil
 }
 
-func (c *NPRecommendationController) deletionworker() {
-	for c.processNextDeletionWorkItem() {
-	}
-}
-
-func (c *NPRecommendationController) processNextDeletionWorkItem() bool {
-	obj, quit := c.deletionQueue.Get()
-	if quit {
-		return false
-	}
-	defer c.deletionQueue.Done(obj)
-	if key, ok := obj.(NamespacedId); !ok {
-		c.queue.Forget(obj)
-		klog.ErrorS(nil, "Expected Spark Application namespaced id in work queue", "got", obj)
-		return true
-	} else if err := c.cleanupNPRecommendation(key.Namespace, key.Id); err == nil {
-		// If no error occurs we forget this item so it does not get queued again until
-		// another change happens.
-		c.deletionQueue.Forget(key)
-	} else {
-		// Put the item back on the workqueue to handle any transient errors.
-		c.deletionQueue.AddRateLimited(key)
-		klog.ErrorS(err, "Error when cleaning Spark Application, requeuing", "key", key)
-	}
-	return true
+func (c *NPRecommendationController) GetJobStatus(job metav1.Object) controllerutil.JobStatus {
+	status := job.(*crdv1alpha1.NetworkPolicyRecommendation).Status
+	return controllerutil.JobStatus(status)
 }
 
-// worker is a long-running function that will continually call the processNextWorkItem function in
-// order to read and process a message on the workqueue.
-func (c *NPRecommendationController) worker() {
-	for c.processNextWorkItem() {
-	}
+func (c *NPRecommendationController) UpdateJobStatus(job metav1.Object, status controllerutil.JobStatus) error {
+	update := job.(*crdv1alpha1.NetworkPolicyRecommendation).DeepCopy()
+	update.Status = crdv1alpha1.NetworkPolicyRecommendationStatus(status)
+	_, err := c.crdClient.CrdV1alpha1().NetworkPolicyRecommendations(update.Namespace).UpdateStatus(context.TODO(), update, metav1.UpdateOptions{})
+	return err
 }
 
-func (c *NPRecommendationController) resyncNPRecommendation() {
-	c.periodicResyncSetMutex.Lock()
-	nprs := make([]apimachinerytypes.NamespacedName, 0, len(c.periodicResyncSet))
-	for nprNamespacedName := range c.periodicResyncSet {
-		nprs = append(nprs, nprNamespacedName)
-	}
-	c.periodicResyncSetMutex.Unlock()
-	for _, nprNamespacedName := range nprs {
-		c.queue.Add(nprNamespacedName)
+func (c *NPRecommendationController) UpdateJobFinalizers(job metav1.Object, finalizers []string) (metav1.Object, error) {
+	update := job.(*crdv1alpha1.NetworkPolicyRecommendation).DeepCopy()
+	update.Finalizers = finalizers
+	updated, err := c.crdClient.CrdV1alpha1().NetworkPolicyRecommendations(update.Namespace).Update(context.TODO(), update, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
 	}
+	return updated, nil
 }
 
-func (c *NPRecommendationController) processNextWorkItem() bool {
-	obj, quit := c.queue.Get()
-	if quit {
-		return false
-	}
-	defer c.queue.Done(obj)
-	if key, ok := obj.(apimachinerytypes.NamespacedName); !ok {
-		c.queue.Forget(obj)
-		klog.ErrorS(nil, "Expected NP Recommendation in work queue", "got", obj)
-		return true
-	} else if err := c.syncNPRecommendation(key); err == nil {
-		// If no error occurs we forget this item so it does not get queued again until
-		// another change happens.
-		c.queue.Forget(key)
-	} else {
-		// Put the item back on the workqueue to handle any transient errors.
-		c.queue.AddRateLimited(key)
-		klog.ErrorS(err, "Error when syncing NP Recommendation, requeuing", "key", key)
-	}
-	return true
+func (c *NPRecommendationController) DeleteJob(job metav1.Object) error {
+	return c.crdClient.CrdV1alpha1().NetworkPolicyRecommendations(job.GetNamespace()).Delete(context.TODO(), job.GetName(), metav1.DeleteOptions{})
 }
 
-func (c *NPRecommendationController) syncNPRecommendation(key apimachinerytypes.NamespacedName) error {
-	startTime := time.Now()
-	defer func() {
-		klog.V(4).InfoS("Finished syncing NP Recommendation", "key", key, "time", time.Since(startTime))
-	}()
+func (c *NPRecommendationController) JobCompleted(job metav1.Object) {}
 
-	npReco, err := c.npRecommendationLister.NetworkPolicyRecommendations(key.Namespace).Get(key.Name)
-	if err != nil {
-		// NetworkPolicyRecommendation already deleted
-		if apimachineryerrors.IsNotFound(err) {
-			return nil
-		}
-		return err
+func (c *NPRecommendationController) ValidateJobName(name string) error {
+	if err := util.ParseRecommendationName(name); err != nil {
+		return controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: Policy recommendation job name is invalid: %s", err)}
 	}
-
-	klog.V(4).Infof("Syncing NP Recommendation", "npReco", npReco)
-
-	switch npReco.Status.State {
-	case "", crdv1alpha1.NPRecommendationStateNew:
-		err = c.startJob(npReco)
-	case crdv1alpha1.NPRecommendationStateScheduled:
-		_, err = c.checkSparkApplicationStatus(npReco)
-	case crdv1alpha1.NPRecommendationStateRunning:
-		err = c.updateProgress(npReco)
-	case crdv1alpha1.NPRecommendationStateCompleted:
-		if npReco.Status.EndTime.IsZero() {
-			err = c.finishJob(npReco)
-		}
-	}
-	return err
-}
-
-func (c *NPRecommendationController) cleanupNPRecommendation(namespace string, sparkApplicationId string) error {
-	// Delete the Spark Application if exists
-	DeleteSparkApplication(c.kubeClient, "pr-"+sparkApplicationId, namespace)
-	// Delete the result from the ClickHouse
-	if c.clickhouseConnect == nil {
-		var err error
-		c.clickhouseConnect, err = clickhouse.SetupConnection(c.kubeClient)
-		if err != nil {
-			return err
-		}
-	}
-	query := "ALTER TABLE recommendations_local ON CLUSTER '{cluster}' DELETE WHERE id = (" + sparkApplicationId + ");"
-	return controllerutil.RunClickHouseQuery(c.clickhouseConnect, query, sparkApplicationId)
+	return nil
 }
 
-func (c *NPRecommendationController) finishJob(npReco *crdv1alpha1.NetworkPolicyRecommendation) error {
-	namespacedName := apimachinerytypes.NamespacedName{
-		Name:      npReco.Name,
-		Namespace: npReco.Namespace,
-	}
-	// Stop periodical job
-	c.stopPeriodicSync(namespacedName)
-	if npReco.Status.SparkApplication == "" {
-		return c.updateNPRecommendationStatus(
-			npReco,
-			crdv1alpha1.NetworkPolicyRecommendationStatus{
-				State:    crdv1alpha1.NPRecommendationStateFailed,
-				ErrorMsg: "Spark Application should be started before updating results",
-			},
-		)
-	}
-	// Delete related SparkApplication CR
-	DeleteSparkApplication(c.kubeClient, "pr-"+npReco.Status.SparkApplication, npReco.Namespace)
-	return c.updateNPRecommendationStatus(npReco, crdv1alpha1.NetworkPolicyRecommendationStatus{
-		State:   crdv1alpha1.NPRecommendationStateCompleted,
-		EndTime: metav1.NewTime(time.Now()),
-	})
+func (c *NPRecommendationController) GetActiveDeadlineSeconds(job metav1.Object) int64 {
+	return job.(*crdv1alpha1.NetworkPolicyRecommendation).Spec.ActiveDeadlineSeconds
 }
 
-func (c *NPRecommendationController) updateProgress(npReco *crdv1alpha1.NetworkPolicyRecommendation) error {
-	// Check the status before checking the progress in case the job is failed or completed
-	state, err := c.checkSparkApplicationStatus(npReco)
-	if err != nil {
-		return err
-	}
-	if state != crdv1alpha1.NPRecommendationStateRunning {
-		return nil
-	}
-	endpoint := GetSparkMonitoringSvcDNS(npReco.Status.SparkApplication, npReco.Namespace, controllerutil.SparkPort)
-	completedStages, totalStages, err := controllerutil.GetSparkAppProgress(endpoint)
-	if err != nil {
-		// The Spark Monitoring Service may not start or closed at this point due to the async
-		// between Spark operator and this controller.
-		// As we periodically check the progress, we do not need to requeue this failure.
-		klog.V(4).ErrorS(err, "Failed to get the progress of the policy recommendation job")
-		return nil
-	}
-	klog.V(4).InfoS("Got Spark Application progress", "completedStages", completedStages, "totalStages", totalStages, "NetworkRecommendationPolicy", npReco.Name)
-	return c.updateNPRecommendationStatus(
-		npReco,
-		crdv1alpha1.NetworkPolicyRecommendationStatus{
-			State:           crdv1alpha1.NPRecommendationStateRunning,
-			CompletedStages: completedStages,
-			TotalStages:     totalStages,
-		},
-	)
+func (c *NPRecommendationController) GetTTLSecondsAfterFinished(job metav1.Object) *int32 {
+	return job.(*crdv1alpha1.NetworkPolicyRecommendation).Spec.TTLSecondsAfterFinished
 }
 
-func (c *NPRecommendationController) checkSparkApplicationStatus(npReco *crdv1alpha1.NetworkPolicyRecommendation) (string, error) {
-	if npReco.Status.SparkApplication == "" {
-		return "", c.updateNPRecommendationStatus(
-			npReco,
-			crdv1alpha1.NetworkPolicyRecommendationStatus{
-				State:    crdv1alpha1.NPRecommendationStateFailed,
-				ErrorMsg: "Spark Application should be started before status checking",
-			},
-		)
-	}
-
-	state, errorMessage, err := getPolicyRecommendationStatus(c.kubeClient, npReco.Status.SparkApplication, npReco.Namespace)
-	if err != nil {
-		return state, err
-	}
-	klog.V(4).InfoS("Got Spark Application state", "state", state, "NetworkRecommendationPolicy", npReco.Name)
-	if state == "RUNNING" {
-		return state, c.updateNPRecommendationStatus(
-			npReco,
-			crdv1alpha1.NetworkPolicyRecommendationStatus{
-				State:    crdv1alpha1.NPRecommendationStateRunning,
-				ErrorMsg: errorMessage,
-			},
-		)
-	} else if state == "COMPLETED" {
-		return state, c.updateNPRecommendationStatus(
-			npReco,
-			crdv1alpha1.NetworkPolicyRecommendationStatus{
-				State:    crdv1alpha1.NPRecommendationStateCompleted,
-				ErrorMsg: errorMessage,
-			},
-		)
-	} else if state == "FAILED" || state == "SUBMISSION_FAILED" || state == "FAILING" || state == "INVALIDATING" {
-		return state, c.updateNPRecommendationStatus(
-			npReco,
-			crdv1alpha1.NetworkPolicyRecommendationStatus{
-				State:    crdv1alpha1.NPRecommendationStateFailed,
-				ErrorMsg: fmt.Sprintf("policy recommendation job failed, state: %s, error message: %v", state, errorMessage),
-			},
-		)
+// GetJobInput returns the flow records in the time window of a
+// NetworkPolicyRecommendation which are from or to its target workloads, if
+// any. NSAllowList is left out, as its Namespaces only add policies and do not
+// narrow down the flow records read by the job.
+func (c *NPRecommendationController) GetJobInput(job metav1.Object) (controllerutil.JobInput, error) {
+	npReco := job.(*crdv1alpha1.NetworkPolicyRecommendation)
+	input := controllerutil.JobInput{
+		StartTime: npReco.Spec.StartInterval,
+		EndTime:   npReco.Spec.EndInterval,
 	}
-	return state, nil
-}
-
-func (c *NPRecommendationController) startJob(npReco *crdv1alpha1.NetworkPolicyRecommendation) error {
-	// Validate Cluster readiness
-	if err := controllerutil.ValidateCluster(c.kubeClient, npReco.Namespace); err != nil {
-		return err
+	if len(npReco.Spec.TargetNamespaces) == 0 && len(npReco.Spec.TargetLabels) == 0 {
+		return input, nil
 	}
-	err := c.startSparkApplication(npReco)
-	// Mark the NetworkPolicyRecommendation as failed and not retry if it failed due to illegal arguments in request
-	if err != nil && reflect.TypeOf(err) == reflect.TypeOf(illeagelArguementError{}) {
-		return c.updateNPRecommendationStatus(
-			npReco,
-			crdv1alpha1.NetworkPolicyRecommendationStatus{
-				State:    crdv1alpha1.NPRecommendationStateFailed,
-				ErrorMsg: fmt.Sprintf("error in creating NetworkPolicyRecommendation: %v", err),
-			},
-		)
+	// Like the job, the flow records match when the target workloads are on
+	// either side of them.
+	keys := make([]string, 0, len(npReco.Spec.TargetLabels))
+	for key := range npReco.Spec.TargetLabels {
+		keys = append(keys, key)
 	}
-	// Schedule periodical resync for successful starting
-	if err == nil {
-		c.addPeriodicSync(apimachinerytypes.NamespacedName{
-			Name:      npReco.Name,
-			Namespace: npReco.Namespace,
-		})
+	sort.Strings(keys)
+	var sideConditions []string
+	for _, side := range []string{"source", "destination"} {
+		var conditions []string
+		if len(npReco.Spec.TargetNamespaces) > 0 {
+			placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(npReco.Spec.TargetNamespaces)), ", ")
+			conditions = append(conditions, fmt.Sprintf("%sPodNamespace IN (%s)", side, placeholders))
+			for _, namespace := range npReco.Spec.TargetNamespaces {
+				input.Args = append(input.Args, namespace)
+			}
+		}
+		for _, key := range keys {
+			conditions = append(conditions, fmt.Sprintf("JSONExtractString(%sPodLabels, ?) = ?", side))
+			input.Args = append(input.Args, key, npReco.Spec.TargetLabels[key])
+		}
+		sideConditions = append(sideConditions, "("+strings.Join(conditions, " AND ")+")")
 	}
-	return err
+	input.Conditions = append(input.Conditions, "("+strings.Join(sideConditions, " OR ")+")")
+	return input, nil
 }
 
-func (c *NPRecommendationController) startSparkApplication(npReco *crdv1alpha1.NetworkPolicyRecommendation) error {
+// GetSparkJob validates the spec of a NetworkPolicyRecommendation and returns
+// the policy recommendation Spark job.
+func (c *NPRecommendationController) GetSparkJob(job metav1.Object) (*controllerutil.SparkJob, error) {
+	npReco := job.(*crdv1alpha1.NetworkPolicyRecommendation)
 	var recoJobArgs []string
 	if npReco.Spec.JobType != "initial" && npReco.Spec.JobType != "subsequent" {
-		return illeagelArguementError{
-			fmt.Errorf("invalid request: recommendation type should be 'initial' or 'subsequent'")}
+		return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: recommendation type should be 'initial' or 'subsequent'")}
 	}
 	recoJobArgs = append(recoJobArgs, "--type", npReco.Spec.JobType)
 
 	if npReco.Spec.Limit < 0 {
-		return illeagelArguementError{fmt.Errorf("invalid request: limit should be an integer >= 0")}
+		return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: limit should be an integer >= 0")}
 	}
 	recoJobArgs = append(recoJobArgs, "--limit", strconv.Itoa(npReco.Spec.Limit))
 
@@ -545,8 +225,10 @@ func (c *NPRecommendationController) startSparkApplication(npReco *crdv1alpha1.N
 		policyTypeArg = 2
 	} else if npReco.Spec.PolicyType == "k8s-np" {
 		policyTypeArg = 3
+	} else if npReco.Spec.PolicyType == "admin-np" {
+		policyTypeArg = 4
 	} else {
-		return illeagelArguementError{fmt.Errorf("invalid request: type of generated NetworkPolicy should be anp-deny-applied or anp-deny-all or k8s-np")}
+		return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: type of generated NetworkPolicy should be anp-deny-applied or anp-deny-all or k8s-np or admin-np")}
 	}
 	recoJobArgs = append(recoJobArgs, "--option", strconv.Itoa(policyTypeArg))
 
@@ -556,7 +238,7 @@ func (c *NPRecommendationController) startSparkApplication(npReco *crdv1alpha1.N
 	if !npReco.Spec.EndInterval.IsZero() {
 		endAfterStart := npReco.Spec.EndInterval.After(npReco.Spec.StartInterval.Time)
 		if !endAfterStart {
-			return illeagelArguementError{fmt.Errorf("invalid request: EndInterval should be after StartInterval")}
+			return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: EndInterval should be after StartInterval")}
 		}
 		recoJobArgs = append(recoJobArgs, "--end_time", npReco.Spec.EndInterval.Format(controllerutil.InputTimeFormat))
 	}
@@ -567,163 +249,63 @@ func (c *NPRecommendationController) startSparkApplication(npReco *crdv1alpha1.N
 		recoJobArgs = append(recoJobArgs, "--ns_allow_list", nsAllowListStr)
 	}
 
-	recoJobArgs = append(recoJobArgs, "--rm_labels", strconv.FormatBool(npReco.Spec.ExcludeLabels))
-	recoJobArgs = append(recoJobArgs, "--to_services", strconv.FormatBool(npReco.Spec.ToServices))
-
-	sparkResourceArgs := struct {
-		executorInstances   int32
-		driverCoreRequest   string
-		driverMemory        string
-		executorCoreRequest string
-		executorMemory      string
-	}{}
-
-	if npReco.Spec.ExecutorInstances < 0 {
-		return illeagelArguementError{fmt.Errorf("invalid request: ExecutorInstances should be an integer >= 0")}
-	}
-	sparkResourceArgs.executorInstances = int32(npReco.Spec.ExecutorInstances)
-
-	matchResult, err := regexp.MatchString(controllerutil.K8sQuantitiesReg, npReco.Spec.DriverCoreRequest)
-	if err != nil || !matchResult {
-		return illeagelArguementError{fmt.Errorf("invalid request: DriverCoreRequest should conform to the Kubernetes resource quantity convention")}
-	}
-	sparkResourceArgs.driverCoreRequest = npReco.Spec.DriverCoreRequest
-
-	matchResult, err = regexp.MatchString(controllerutil.K8sQuantitiesReg, npReco.Spec.DriverMemory)
-	if err != nil || !matchResult {
-		return illeagelArguementError{fmt.Errorf("invalid request: DriverMemory should conform to the Kubernetes resource quantity convention")}
-	}
-	sparkResourceArgs.driverMemory = npReco.Spec.DriverMemory
-
-	matchResult, err = regexp.MatchString(controllerutil.K8sQuantitiesReg, npReco.Spec.ExecutorCoreRequest)
-	if err != nil || !matchResult {
-		return illeagelArguementError{fmt.Errorf("invalid request: ExecutorCoreRequest should conform to the Kubernetes resource quantity convention")}
+	if len(npReco.Spec.TargetNamespaces) > 0 {
+		for _, ns := range npReco.Spec.TargetNamespaces {
+			if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+				return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: TargetNamespaces contains invalid Namespace name %s: %s", ns, strings.Join(errs, "; "))}
+			}
+		}
+		targetNamespaces, _ := json.Marshal(npReco.Spec.TargetNamespaces)
+		recoJobArgs = append(recoJobArgs, "--target_ns_list", string(targetNamespaces))
 	}
-	sparkResourceArgs.executorCoreRequest = npReco.Spec.ExecutorCoreRequest
 
-	matchResult, err = regexp.MatchString(controllerutil.K8sQuantitiesReg, npReco.Spec.ExecutorMemory)
-	if err != nil || !matchResult {
-		return illeagelArguementError{fmt.Errorf("invalid request: ExecutorMemory should conform to the Kubernetes resource quantity convention")}
+	if len(npReco.Spec.TargetLabels) > 0 {
+		for key, value := range npReco.Spec.TargetLabels {
+			if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+				return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: TargetLabels contains invalid label key %s: %s", key, strings.Join(errs, "; "))}
+			}
+			if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+				return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: TargetLabels contains invalid label value %s: %s", value, strings.Join(errs, "; "))}
+			}
+		}
+		targetLabels, _ := json.Marshal(npReco.Spec.TargetLabels)
+		recoJobArgs = append(recoJobArgs, "--target_labels", string(targetLabels))
 	}
-	sparkResourceArgs.executorMemory = npReco.Spec.ExecutorMemory
 
-	err = util.ParseRecommendationName(npReco.Name)
-	if err != nil {
-		return illeagelArguementError{fmt.Errorf("invalid request: Policy recommendation job name is invalid: %s", err)}
-	}
-	recommendationID := npReco.Name[3:]
-	recoJobArgs = append(recoJobArgs, "--id", recommendationID)
-	recommendationApplication := &sparkv1.SparkApplication{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "sparkoperator.k8s.io/v1beta2",
-			Kind:       "SparkApplication",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      npReco.Name,
-			Namespace: npReco.Namespace,
-			Labels:    sparkAppLabelMap,
-		},
-		Spec: sparkv1.SparkApplicationSpec{
-			Type:                "Python",
-			SparkVersion:        controllerutil.SparkVersion,
-			Mode:                "cluster",
-			Image:               controllerutil.ConstStrToPointer(controllerutil.SparkImage),
-			ImagePullPolicy:     controllerutil.ConstStrToPointer(controllerutil.SparkImagePullPolicy),
-			MainApplicationFile: controllerutil.ConstStrToPointer(sparkAppFile),
-			Arguments:           recoJobArgs,
-			Driver: sparkv1.DriverSpec{
-				CoreRequest: &npReco.Spec.DriverCoreRequest,
-				SparkPodSpec: sparkv1.SparkPodSpec{
-					Memory: &npReco.Spec.DriverMemory,
-					Labels: map[string]string{
-						"version": controllerutil.SparkVersion,
-					},
-					EnvSecretKeyRefs: map[string]sparkv1.NameKey{
-						"CH_USERNAME": {
-							Name: "clickhouse-secret",
-							Key:  "username",
-						},
-						"CH_PASSWORD": {
-							Name: "clickhouse-secret",
-							Key:  "password",
-						},
-					},
-					ServiceAccount: controllerutil.ConstStrToPointer(controllerutil.SparkServiceAccount),
-				},
-			},
-			Executor: sparkv1.ExecutorSpec{
-				CoreRequest: &npReco.Spec.ExecutorCoreRequest,
-				SparkPodSpec: sparkv1.SparkPodSpec{
-					Memory: &npReco.Spec.ExecutorMemory,
-					Labels: map[string]string{
-						"version": controllerutil.SparkVersion,
-					},
-					EnvSecretKeyRefs: map[string]sparkv1.NameKey{
-						"CH_USERNAME": {
-							Name: "clickhouse-secret",
-							Key:  "username",
-						},
-						"CH_PASSWORD": {
-							Name: "clickhouse-secret",
-							Key:  "password",
-						},
-					},
-				},
-				Instances: &sparkResourceArgs.executorInstances,
-			},
-		},
-	}
-	err = CreateSparkApplication(c.kubeClient, npReco.Namespace, recommendationApplication)
-	if err != nil {
-		return fmt.Errorf("failed to create Spark Application: %v", err)
-	}
-	klog.V(2).InfoS("Start SparkApplication", "id", recommendationID, "NetworkPolicyRecommendation", npReco.Name)
-
-	return c.updateNPRecommendationStatus(
-		npReco,
-		crdv1alpha1.NetworkPolicyRecommendationStatus{
-			State:            crdv1alpha1.NPRecommendationStateScheduled,
-			SparkApplication: recommendationID,
-			StartTime:        metav1.NewTime(time.Now()),
-		},
-	)
-}
+	recoJobArgs = append(recoJobArgs, "--rm_labels", strconv.FormatBool(npReco.Spec.ExcludeLabels))
+	recoJobArgs = append(recoJobArgs, "--to_services", strconv.FormatBool(npReco.Spec.ToServices))
 
-func (c *NPRecommendationController) updateNPRecommendationStatus(npReco *crdv1alpha1.NetworkPolicyRecommendation, status crdv1alpha1.NetworkPolicyRecommendationStatus) error {
-	update := npReco.DeepCopy()
-	update.Status.State = status.State
-	if status.SparkApplication != "" {
-		update.Status.SparkApplication = status.SparkApplication
-	}
-	if status.CompletedStages != 0 {
-		update.Status.CompletedStages = status.CompletedStages
-	}
-	if status.TotalStages != 0 {
-		update.Status.TotalStages = status.TotalStages
-	}
-	if status.ErrorMsg != "" {
-		update.Status.ErrorMsg = status.ErrorMsg
+	if npReco.Spec.Tier != "" {
+		if errs := validation.IsDNS1123Label(strings.ToLower(npReco.Spec.Tier)); len(errs) > 0 {
+			return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: Tier %s is not a valid Tier name: %s", npReco.Spec.Tier, strings.Join(errs, "; "))}
+		}
+		if strings.EqualFold(npReco.Spec.Tier, "baseline") {
+			return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: Tier should not be Baseline, which is reserved for the recommended deny policies")}
+		}
+		recoJobArgs = append(recoJobArgs, "--tier", npReco.Spec.Tier)
 	}
-	if !status.StartTime.IsZero() {
-		update.Status.StartTime = status.StartTime
+	if npReco.Spec.BasePriority != 0 {
+		if npReco.Spec.BasePriority < 1 || npReco.Spec.BasePriority > 10000 {
+			return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: BasePriority should be a number between 1 and 10000")}
+		}
+		recoJobArgs = append(recoJobArgs, "--base_priority", strconv.FormatFloat(npReco.Spec.BasePriority, 'f', -1, 64))
 	}
-	if !status.EndTime.IsZero() {
-		update.Status.EndTime = status.EndTime
+	if npReco.Spec.PriorityStep != 0 {
+		if npReco.Spec.PriorityStep < 0 {
+			return nil, controllerutil.IllegalArgumentError{Err: fmt.Errorf("invalid request: PriorityStep should be a number >= 0")}
+		}
+		recoJobArgs = append(recoJobArgs, "--priority_step", strconv.FormatFloat(npReco.Spec.PriorityStep, 'f', -1, 64))
 	}
-	_, err := c.crdClient.CrdV1alpha1().NetworkPolicyRecommendations(npReco.Namespace).UpdateStatus(context.TODO(), update, metav1.UpdateOptions{})
-	return err
-}
 
-func (c *NPRecommendationController) addPeriodicSync(key apimachinerytypes.NamespacedName) {
-	c.periodicResyncSetMutex.Lock()
-	defer c.periodicResyncSetMutex.Unlock()
-	c.periodicResyncSet[key] = struct{}{}
-}
-
-func (c *NPRecommendationController) stopPeriodicSync(key apimachinerytypes.NamespacedName) {
-	c.periodicResyncSetMutex.Lock()
-	defer c.periodicResyncSetMutex.Unlock()
-	delete(c.periodicResyncSet, key)
+	return &controllerutil.SparkJob{
+		MainApplicationFile: sparkAppFile,
+		Arguments:           recoJobArgs,
+		ExecutorInstances:   npReco.Spec.ExecutorInstances,
+		DriverCoreRequest:   npReco.Spec.DriverCoreRequest,
+		DriverMemory:        npReco.Spec.DriverMemory,
+		ExecutorCoreRequest: npReco.Spec.ExecutorCoreRequest,
+		ExecutorMemory:      npReco.Spec.ExecutorMemory,
+	}, nil
 }
 
 func (c *NPRecommendationController) GetNetworkPolicyRecommendation(namespace, name string) (*crdv1alpha1.NetworkPolicyRecommendation, error) {
@@ -742,17 +324,6 @@ func (c *NPRecommendationController) CreateNetworkPolicyRecommendation(namespace
 	return c.crdClient.CrdV1alpha1().NetworkPolicyRecommendations(namespace).Create(context.TODO(), networkPolicyRecommendation, metav1.CreateOptions{})
 }
 
-func getPolicyRecommendationStatus(client kubernetes.Interface, id string, namespace string) (state string, errorMessage string, err error) {
-	sparkApplication, err := GetSparkApplication(client, "pr-"+id, namespace)
-	if err != nil {
-		return state, errorMessage, err
-	}
-	state = strings.TrimSpace(string(sparkApplication.Status.AppState.State))
-	errorMessage = strings.TrimSpace(string(sparkApplication.Status.AppState.ErrorMessage))
-
-	return state, errorMessage, nil
-}
-
-type illeagelArguementError struct {
-	error
+func (c *NPRecommendationController) EstimateNetworkPolicyRecommendation(networkPolicyRecommendation *crdv1alpha1.NetworkPolicyRecommendation) (*crdv1alpha1.JobInputEstimate, error) {
+	return c.EstimateJobInput(networkPolicyRecommendation)
 }
@@ -17,6 +17,7 @@ package networkpolicyrecommendation
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -29,8 +30,11 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
+	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	apimachinerytypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
@@ -46,7 +50,18 @@ import (
 	"antrea.io/theia/third_party/sparkoperator/v1beta2"
 )
 
-const informerDefaultResync = 30 * time.Second
+const (
+	informerDefaultResync = 30 * time.Second
+	estimateQuery         = `
+SELECT
+	count() AS Rows,
+	toInt64(count() * (
+		SELECT sum(data_uncompressed_bytes) / greatest(sum(rows), 1)
+		FROM cluster('{cluster}', system.parts)
+		WHERE active AND database = 'default' AND table = 'flows_local'
+	)) AS Bytes
+FROM flows`
+)
 
 var (
 	testNamespace = "controller-test"
@@ -58,6 +73,7 @@ type fakeController struct {
 	crdClient          versioned.Interface
 	kubeClient         kubernetes.Interface
 	crdInformerFactory crdinformers.SharedInformerFactory
+	mock               sqlmock.Sqlmock
 }
 
 func newFakeController(t *testing.T) (*fakeController, *sql.DB) {
@@ -70,15 +86,32 @@ func newFakeController(t *testing.T) (*fakeController, *sql.DB) {
 	crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, informerDefaultResync)
 	npRecommendationInformer := crdInformerFactory.Crd().V1alpha1().NetworkPolicyRecommendations()
 
-	nprController := NewNPRecommendationController(crdClient, kubeClient, npRecommendationInformer)
+	nprController := NewNPRecommendationController(crdClient, kubeClient, nil, npRecommendationInformer, 0, controllerutil.JobHistoryLimits{}, controllerutil.JobInputBudget{})
 
 	mock.ExpectQuery("SELECT DISTINCT id FROM recommendations;").WillReturnRows(sqlmock.NewRows([]string{}))
 	mock.ExpectExec("ALTER TABLE recommendations_local ON CLUSTER '{cluster}' DELETE WHERE id = (?);").WithArgs(prName[3:]).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT name FROM system.users WHERE startsWith(name, ?);").WithArgs(controllerutil.JobUserPrefix + "pr_").WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	// The ClickHouse user of a job is created when it starts, and dropped
+	// both when it finishes and when it is deleted.
+	mock.MatchExpectationsInOrder(false)
+	userName := controllerutil.JobUserPrefix + strings.ReplaceAll(prName, "-", "_")
+	mock.ExpectExec("CREATE USER OR REPLACE " + userName + " ON CLUSTER '{cluster}' IDENTIFIED WITH sha256_password BY ?;").WithArgs(sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, table := range []string{"flows", "flows_local", "recommendations", "recommendations_local"} {
+		privileges := "SELECT"
+		if !strings.HasPrefix(table, "flows") {
+			privileges = "SELECT, INSERT"
+		}
+		mock.ExpectExec(fmt.Sprintf("GRANT ON CLUSTER '{cluster}' %s ON default.%s TO %s;", privileges, table, userName)).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	for i := 0; i < 2; i++ {
+		mock.ExpectExec("DROP USER IF EXISTS " + userName + " ON CLUSTER '{cluster}';").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
 	return &fakeController{
 		nprController,
 		crdClient,
 		kubeClient,
 		crdInformerFactory,
+		mock,
 	}, db
 }
 
@@ -114,7 +147,15 @@ func createFakeSparkApplicationService(kubeClient kubernetes.Interface, id strin
 				{"status": "COMPLETE"},
 				{"status": "SKIPPED"},
 				{"status": "PENDING"},
-				{"status": "ACTIVE"},
+				{"status": "ACTIVE", "name": "groupBy", "numTasks": 10, "numCompleteTasks": 4},
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(responses)
+		case fmt.Sprintf("/api/v1/applications/%s/allexecutors", id):
+			responses := []map[string]interface{}{
+				{"id": "driver", "isActive": true},
+				{"id": "1", "isActive": true},
 			}
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusOK)
@@ -122,7 +163,7 @@ func createFakeSparkApplicationService(kubeClient kubernetes.Interface, id strin
 		}
 	}))
 
-	GetSparkMonitoringSvcDNS = func(id, namespace string, sparkPort int) string {
+	controllerutil.GetSparkMonitoringSvcDNS = func(id, namespace string, sparkPort int) string {
 		return testServer.URL
 	}
 	return nil
@@ -178,7 +219,11 @@ func (f *fakeSparkApplicationClient) get(client kubernetes.Interface, name, name
 	}
 	f.mapMutex.Lock()
 	defer f.mapMutex.Unlock()
-	return *f.sparkApplications[namespacedName], nil
+	sa, ok := f.sparkApplications[namespacedName]
+	if !ok {
+		return sparkApp, apimachineryerrors.NewNotFound(schema.GroupResource{Resource: "sparkapplications"}, name)
+	}
+	return *sa, nil
 }
 
 func (f *fakeSparkApplicationClient) step(name, namespace string) {
@@ -207,10 +252,10 @@ func TestNPRecommendation(t *testing.T) {
 	fakeSAClient := fakeSparkApplicationClient{
 		sparkApplications: make(map[apimachinerytypes.NamespacedName]*v1beta2.SparkApplication),
 	}
-	CreateSparkApplication = fakeSAClient.create
-	DeleteSparkApplication = fakeSAClient.delete
+	controllerutil.CreateSparkApplication = fakeSAClient.create
+	controllerutil.DeleteSparkApplication = fakeSAClient.delete
 	controllerutil.ListSparkApplication = fakeSAClient.list
-	GetSparkApplication = fakeSAClient.get
+	controllerutil.GetSparkApplication = fakeSAClient.get
 	os.Setenv("POD_NAMESPACE", testNamespace)
 	defer os.Unsetenv("POD_NAMESPACE")
 
@@ -249,6 +294,8 @@ func TestNPRecommendation(t *testing.T) {
 			Status: crdv1alpha1.NetworkPolicyRecommendationStatus{},
 		}
 
+		// The input of the job is estimated before it starts.
+		nprController.mock.ExpectQuery(estimateQuery+"\nWHERE flowStartSeconds >= ? AND flowEndSeconds < ?").WithArgs(npr.Spec.StartInterval.UTC().Format(controllerutil.InputTimeFormat), npr.Spec.EndInterval.UTC().Format(controllerutil.InputTimeFormat)).WillReturnRows(sqlmock.NewRows([]string{"Rows", "Bytes"}).AddRow(100, 20000))
 		npr, err := nprController.CreateNetworkPolicyRecommendation(testNamespace, npr)
 		assert.NoError(t, err)
 
@@ -278,6 +325,10 @@ func TestNPRecommendation(t *testing.T) {
 		assert.Equal(t, crdv1alpha1.NPRecommendationStateCompleted, npr.Status.State)
 		assert.Equal(t, 3, npr.Status.CompletedStages)
 		assert.Equal(t, 5, npr.Status.TotalStages)
+		if assert.NotNil(t, npr.Status.Progress) {
+			assert.Equal(t, "groupBy", npr.Status.Progress.CurrentStage)
+			assert.Equal(t, 1, npr.Status.Progress.ActiveExecutors)
+		}
 		assert.True(t, npr.Status.StartTime.Before(&npr.Status.EndTime))
 
 		nprList, err := nprController.ListNetworkPolicyRecommendation(testNamespace)
@@ -328,7 +379,7 @@ func TestNPRecommendation(t *testing.T) {
 					PolicyType: "nonexistent-policy-type",
 				},
 			},
-			expectedErrorMsg: "invalid request: type of generated NetworkPolicy should be anp-deny-applied or anp-deny-all or k8s-np",
+			expectedErrorMsg: "invalid request: type of generated NetworkPolicy should be anp-deny-applied or anp-deny-all or k8s-np or admin-np",
 		},
 		{
 			name:    "invalid EndInterval",
@@ -344,6 +395,71 @@ func TestNPRecommendation(t *testing.T) {
 			},
 			expectedErrorMsg: "invalid request: EndInterval should be after StartInterval",
 		},
+		{
+			name:    "invalid TargetNamespaces",
+			nprName: "npr-invalid-target-namespaces",
+			npr: &crdv1alpha1.NetworkPolicyRecommendation{
+				ObjectMeta: metav1.ObjectMeta{Name: "npr-invalid-target-namespaces", Namespace: testNamespace},
+				Spec: crdv1alpha1.NetworkPolicyRecommendationSpec{
+					JobType:          "initial",
+					PolicyType:       "anp-deny-applied",
+					TargetNamespaces: []string{"Payments"},
+				},
+			},
+			expectedErrorMsg: "invalid request: TargetNamespaces contains invalid Namespace name Payments",
+		},
+		{
+			name:    "invalid TargetLabels",
+			nprName: "npr-invalid-target-labels",
+			npr: &crdv1alpha1.NetworkPolicyRecommendation{
+				ObjectMeta: metav1.ObjectMeta{Name: "npr-invalid-target-labels", Namespace: testNamespace},
+				Spec: crdv1alpha1.NetworkPolicyRecommendationSpec{
+					JobType:      "initial",
+					PolicyType:   "anp-deny-applied",
+					TargetLabels: map[string]string{"tier": "back end"},
+				},
+			},
+			expectedErrorMsg: "invalid request: TargetLabels contains invalid label value back end",
+		},
+		{
+			name:    "invalid Tier",
+			nprName: "npr-invalid-tier",
+			npr: &crdv1alpha1.NetworkPolicyRecommendation{
+				ObjectMeta: metav1.ObjectMeta{Name: "npr-invalid-tier", Namespace: testNamespace},
+				Spec: crdv1alpha1.NetworkPolicyRecommendationSpec{
+					JobType:    "initial",
+					PolicyType: "anp-deny-applied",
+					Tier:       "Baseline",
+				},
+			},
+			expectedErrorMsg: "invalid request: Tier should not be Baseline",
+		},
+		{
+			name:    "invalid BasePriority",
+			nprName: "npr-invalid-base-priority",
+			npr: &crdv1alpha1.NetworkPolicyRecommendation{
+				ObjectMeta: metav1.ObjectMeta{Name: "npr-invalid-base-priority", Namespace: testNamespace},
+				Spec: crdv1alpha1.NetworkPolicyRecommendationSpec{
+					JobType:      "initial",
+					PolicyType:   "anp-deny-applied",
+					BasePriority: 10001,
+				},
+			},
+			expectedErrorMsg: "invalid request: BasePriority should be a number between 1 and 10000",
+		},
+		{
+			name:    "invalid PriorityStep",
+			nprName: "npr-invalid-priority-step",
+			npr: &crdv1alpha1.NetworkPolicyRecommendation{
+				ObjectMeta: metav1.ObjectMeta{Name: "npr-invalid-priority-step", Namespace: testNamespace},
+				Spec: crdv1alpha1.NetworkPolicyRecommendationSpec{
+					JobType:      "initial",
+					PolicyType:   "anp-deny-applied",
+					PriorityStep: -1,
+				},
+			},
+			expectedErrorMsg: "invalid request: PriorityStep should be a number >= 0",
+		},
 		{
 			name:    "invalid ExecutorInstances",
 			nprName: "npr-invalid-executor-instances",
@@ -440,3 +556,75 @@ func TestNPRecommendation(t *testing.T) {
 		})
 	}
 }
+
+func TestEstimateJobInput(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", testNamespace)
+	budget := controllerutil.JobInputBudget{MaxRows: 1000, Action: controllerutil.JobInputBudgetActionReject}
+	testCases := []struct {
+		name               string
+		targetNamespaces   []string
+		targetLabels       map[string]string
+		expectedConditions string
+		expectedArgs       []driver.Value
+		rows               int64
+		withinBudget       bool
+	}{
+		{
+			name: "All workloads",
+			rows: 1500,
+		},
+		{
+			name:               "Target Namespaces",
+			targetNamespaces:   []string{"ns1", "ns2"},
+			expectedConditions: "\nWHERE ((sourcePodNamespace IN (?, ?)) OR (destinationPodNamespace IN (?, ?)))",
+			expectedArgs:       []driver.Value{"ns1", "ns2", "ns1", "ns2"},
+			rows:               800,
+			withinBudget:       true,
+		},
+		{
+			name:               "Target Namespace and labels",
+			targetNamespaces:   []string{"ns1"},
+			targetLabels:       map[string]string{"tier": "db", "app": "backup"},
+			expectedConditions: "\nWHERE ((sourcePodNamespace IN (?) AND JSONExtractString(sourcePodLabels, ?) = ? AND JSONExtractString(sourcePodLabels, ?) = ?) OR (destinationPodNamespace IN (?) AND JSONExtractString(destinationPodLabels, ?) = ? AND JSONExtractString(destinationPodLabels, ?) = ?))",
+			expectedArgs:       []driver.Value{"ns1", "app", "backup", "tier", "db", "ns1", "app", "backup", "tier", "db"},
+			rows:               100,
+			withinBudget:       true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeClient := fake.NewSimpleClientset()
+			db, mock := clickhouse.CreateFakeClickHouse(t, kubeClient, testNamespace)
+			defer db.Close()
+			crdClient := fakecrd.NewSimpleClientset()
+			crdInformerFactory := crdinformers.NewSharedInformerFactory(crdClient, informerDefaultResync)
+			nprController := NewNPRecommendationController(crdClient, kubeClient, nil, crdInformerFactory.Crd().V1alpha1().NetworkPolicyRecommendations(), 0, controllerutil.JobHistoryLimits{}, budget)
+
+			npr := &crdv1alpha1.NetworkPolicyRecommendation{
+				ObjectMeta: metav1.ObjectMeta{Name: prName, Namespace: testNamespace},
+				Spec: crdv1alpha1.NetworkPolicyRecommendationSpec{
+					JobType:             "initial",
+					PolicyType:          "anp-deny-applied",
+					ExecutorInstances:   1,
+					DriverCoreRequest:   "200m",
+					DriverMemory:        "512M",
+					ExecutorCoreRequest: "200m",
+					ExecutorMemory:      "512M",
+					NSAllowList:         []string{"kube-system"},
+					TargetNamespaces:    tc.targetNamespaces,
+					TargetLabels:        tc.targetLabels,
+				},
+			}
+			expectedQuery := mock.ExpectQuery(estimateQuery + tc.expectedConditions)
+			if len(tc.expectedArgs) > 0 {
+				expectedQuery.WithArgs(tc.expectedArgs...)
+			}
+			expectedQuery.WillReturnRows(sqlmock.NewRows([]string{"Rows", "Bytes"}).AddRow(tc.rows, tc.rows*200))
+			estimate, err := nprController.EstimateJobInput(npr)
+			require.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+			assert.Equal(t, &crdv1alpha1.JobInputEstimate{Rows: tc.rows, Bytes: tc.rows * 200}, estimate)
+			assert.Equal(t, tc.withinBudget, estimate.Rows <= budget.MaxRows)
+		})
+	}
+}
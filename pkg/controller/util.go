@@ -25,8 +25,15 @@ import (
 
 	apimachineryerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/cache"
 
 	"antrea.io/theia/pkg/util/clickhouse"
 	"antrea.io/theia/pkg/util/env"
@@ -51,17 +58,38 @@ const (
 	SparkServiceAccount  = "theia-spark"
 	SparkVersion         = "3.1.1"
 	SparkPort            = 4040
+	// Rate limit of the requests to the monitoring Services of the Spark
+	// Applications, shared by all the jobs of a controller.
+	SparkProgressQPS   = 2
+	SparkProgressBurst = 5
 )
 
+// SparkApplicationResource is the resource of the Spark Applications managed
+// by the Spark Operator.
+var SparkApplicationResource = schema.GroupVersionResource{
+	Group:    "sparkoperator.k8s.io",
+	Version:  "v1beta2",
+	Resource: "sparkapplications",
+}
+
 type GcKey struct {
 	RemoveStaleDbEntries bool
 	RemoveStaleSparkApp  bool
 	AddResync            bool
+	PruneJobHistory      bool
+	// RemoveStaleCredentials revokes the ClickHouse credentials of the jobs
+	// which are deleted or finished.
+	RemoveStaleCredentials bool
 }
 
 var (
-	ListSparkApplication = ListSparkApplicationWithLabel
-	getSparkJobIds       = GetSparkJobIds
+	// Spark Application CRUD functions, for unit tests
+	CreateSparkApplication   = createSparkApplication
+	DeleteSparkApplication   = deleteSparkApplication
+	GetSparkApplication      = getSparkApplication
+	ListSparkApplication     = ListSparkApplicationWithLabel
+	GetSparkMonitoringSvcDNS = getSparkMonitoringSvcDNS
+	getSparkJobIds           = GetSparkJobIds
 )
 
 func ConstStrToPointer(constStr string) *string {
@@ -126,36 +154,90 @@ func GetResponseFromSparkMonitoringSvc(url string) ([]byte, error) {
 	return body, nil
 }
 
-func GetSparkAppProgress(baseUrl string) (completedStages int, totalStages int, err error) {
+// SparkAppProgress is the progress of a Spark Application, as reported by
+// the Spark monitoring API.
+type SparkAppProgress struct {
+	CompletedStages int
+	TotalStages     int
+	// CurrentStage is the name of the active stage with the lowest ID.
+	CurrentStage    string
+	CompletedTasks  int
+	TotalTasks      int
+	ActiveExecutors int
+	// FailedExecutors counts the executors which are not active anymore.
+	// The driver is not counted as an executor.
+	FailedExecutors int
+}
+
+type sparkStage struct {
+	StageID          int    `json:"stageId"`
+	Status           string `json:"status"`
+	Name             string `json:"name"`
+	NumTasks         int    `json:"numTasks"`
+	NumCompleteTasks int    `json:"numCompleteTasks"`
+}
+
+type sparkExecutor struct {
+	ID       string `json:"id"`
+	IsActive bool   `json:"isActive"`
+}
+
+func GetSparkAppProgress(baseUrl string) (progress SparkAppProgress, err error) {
 	// Get the id of current Spark application
 	url := fmt.Sprintf("%s/api/v1/applications", baseUrl)
 	response, err := GetResponseFromSparkMonitoringSvc(url)
 	if err != nil {
-		return completedStages, totalStages, fmt.Errorf("failed to get response from the Spark Monitoring Service: %v", err)
+		return progress, fmt.Errorf("failed to get response from the Spark Monitoring Service: %v", err)
 	}
 	var getAppsResult []map[string]interface{}
 	json.Unmarshal([]byte(response), &getAppsResult)
 	if len(getAppsResult) != 1 {
-		return completedStages, totalStages, fmt.Errorf("wrong Spark Application number, expected 1, got %d", len(getAppsResult))
+		return progress, fmt.Errorf("wrong Spark Application number, expected 1, got %d", len(getAppsResult))
 	}
 	sparkAppID := getAppsResult[0]["id"]
 	// Check the percentage of completed stages
 	url = fmt.Sprintf("%s/api/v1/applications/%s/stages", baseUrl, sparkAppID)
 	response, err = GetResponseFromSparkMonitoringSvc(url)
 	if err != nil {
-		return completedStages, totalStages, fmt.Errorf("failed to get response from the Spark Monitoring Service: %v", err)
+		return progress, fmt.Errorf("failed to get response from the Spark Monitoring Service: %v", err)
 	}
-	var getStagesResult []map[string]interface{}
+	var getStagesResult []sparkStage
 	json.Unmarshal([]byte(response), &getStagesResult)
 	// totalStages can be 0 when the SparkApplication just starts and the stages have not be determined
-	totalStages = len(getStagesResult)
-	completedStages = 0
+	progress.TotalStages = len(getStagesResult)
+	currentStageID := -1
 	for _, stage := range getStagesResult {
-		if stage["status"] == "COMPLETE" || stage["status"] == "SKIPPED" {
-			completedStages++
+		switch stage.Status {
+		case "COMPLETE", "SKIPPED":
+			progress.CompletedStages++
+		case "ACTIVE":
+			if currentStageID == -1 || stage.StageID < currentStageID {
+				currentStageID = stage.StageID
+				progress.CurrentStage = stage.Name
+				progress.CompletedTasks = stage.NumCompleteTasks
+				progress.TotalTasks = stage.NumTasks
+			}
+		}
+	}
+	// Check the health of the executors, including the removed ones
+	url = fmt.Sprintf("%s/api/v1/applications/%s/allexecutors", baseUrl, sparkAppID)
+	response, err = GetResponseFromSparkMonitoringSvc(url)
+	if err != nil {
+		return progress, fmt.Errorf("failed to get response from the Spark Monitoring Service: %v", err)
+	}
+	var getExecutorsResult []sparkExecutor
+	json.Unmarshal([]byte(response), &getExecutorsResult)
+	for _, executor := range getExecutorsResult {
+		if executor.ID == "driver" {
+			continue
+		}
+		if executor.IsActive {
+			progress.ActiveExecutors++
+		} else {
+			progress.FailedExecutors++
 		}
 	}
-	return completedStages, totalStages, nil
+	return progress, nil
 }
 
 func RunClickHouseQuery(connect *sql.DB, query string, id string) (err error) {
@@ -185,7 +267,7 @@ func GetSparkJobIds(connect *sql.DB, tableName string) ([]string, error) {
 	return idList, nil
 }
 
-func GetSparkApplication(client kubernetes.Interface, name string, namespace string) (sparkApp sparkv1.SparkApplication, err error) {
+func getSparkApplication(client kubernetes.Interface, name string, namespace string) (sparkApp sparkv1.SparkApplication, err error) {
 	err = client.CoreV1().RESTClient().Get().
 		AbsPath("/apis/sparkoperator.k8s.io/v1beta2").
 		Namespace(namespace).
@@ -211,7 +293,7 @@ func ListSparkApplicationWithLabel(client kubernetes.Interface, label string) (*
 	return sparkApplicationList, err
 }
 
-func DeleteSparkApplication(client kubernetes.Interface, name string, namespace string) {
+func deleteSparkApplication(client kubernetes.Interface, name string, namespace string) {
 	client.CoreV1().RESTClient().Delete().
 		AbsPath("/apis/sparkoperator.k8s.io/v1beta2").
 		Namespace(namespace).
@@ -220,7 +302,7 @@ func DeleteSparkApplication(client kubernetes.Interface, name string, namespace
 		Do(context.TODO())
 }
 
-func CreateSparkApplication(client kubernetes.Interface, namespace string, sparkApplication *sparkv1.SparkApplication) error {
+func createSparkApplication(client kubernetes.Interface, namespace string, sparkApplication *sparkv1.SparkApplication) error {
 	response := &sparkv1.SparkApplication{}
 	return client.CoreV1().RESTClient().
 		Post().
@@ -232,8 +314,36 @@ func CreateSparkApplication(client kubernetes.Interface, namespace string, spark
 		Into(response)
 }
 
-func GetSparkMonitoringSvcDNS(id string, namespace string, sparkPort int) string {
-	return fmt.Sprintf("http://pr-%s-ui-svc.%s.svc:%d", id, namespace, sparkPort)
+// NewSparkApplicationInformer returns an informer watching the Spark
+// Applications in namespace which have all the given labels.
+func NewSparkApplicationInformer(client dynamic.Interface, namespace string, sparkAppLabels map[string]string, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return dynamicinformer.NewFilteredDynamicInformer(
+		client,
+		SparkApplicationResource,
+		namespace,
+		resyncPeriod,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		func(options *metav1.ListOptions) {
+			options.LabelSelector = labels.SelectorFromSet(sparkAppLabels).String()
+		},
+	).Informer()
+}
+
+// sparkApplicationFromUnstructured converts a Spark Application stored by a
+// dynamic informer.
+func sparkApplicationFromUnstructured(obj interface{}) (sparkApp sparkv1.SparkApplication, err error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return sparkApp, fmt.Errorf("unexpected object type %T", obj)
+	}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &sparkApp)
+	return sparkApp, err
+}
+
+// getSparkMonitoringSvcDNS returns the endpoint of the monitoring Service of
+// the Spark Application with the given name.
+func getSparkMonitoringSvcDNS(name string, namespace string, sparkPort int) string {
+	return fmt.Sprintf("http://%s-ui-svc.%s.svc:%d", name, namespace, sparkPort)
 }
 
 func HandleStaleDbEntries(clickhouseConnect *sql.DB, client kubernetes.Interface, job, tableName string, ifResourceExists func(string, string) error, idPrefix string) error {
@@ -192,8 +192,21 @@ func TestGetSparkAppProgress(t *testing.T) {
 					json.NewEncoder(w).Encode(responses)
 				case "/api/v1/applications/spark-application-id/stages":
 					responses := []map[string]interface{}{
-						{"status": "COMPLETE"},
-						{"status": "SKIPPED"},
+						{"stageId": 4, "status": "PENDING", "name": "collect"},
+						{"stageId": 3, "status": "ACTIVE", "name": "count", "numTasks": 8, "numCompleteTasks": 1},
+						{"stageId": 2, "status": "ACTIVE", "name": "groupBy", "numTasks": 10, "numCompleteTasks": 4},
+						{"stageId": 1, "status": "COMPLETE"},
+						{"stageId": 0, "status": "SKIPPED"},
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(responses)
+				case "/api/v1/applications/spark-application-id/allexecutors":
+					responses := []map[string]interface{}{
+						{"id": "driver", "isActive": true},
+						{"id": "1", "isActive": false},
+						{"id": "2", "isActive": true},
+						{"id": "3", "isActive": true},
 					}
 					w.Header().Set("Content-Type", "application/json")
 					w.WriteHeader(http.StatusOK)
@@ -206,21 +219,27 @@ func TestGetSparkAppProgress(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			var (
-				err             error
-				completedStages int
-				totalStages     int
+				err      error
+				progress SparkAppProgress
 			)
 			if tc.testServer != nil {
 				defer tc.testServer.Close()
-				completedStages, totalStages, err = GetSparkAppProgress(tc.testServer.URL)
+				progress, err = GetSparkAppProgress(tc.testServer.URL)
 			} else {
-				_, _, err = GetSparkAppProgress("http://127.0.0.1")
+				_, err = GetSparkAppProgress("http://127.0.0.1")
 			}
 			if err != nil {
 				assert.Contains(t, err.Error(), tc.expectedErrorMsg)
 			} else {
-				assert.Equal(t, 2, completedStages)
-				assert.Equal(t, 2, totalStages)
+				assert.Equal(t, SparkAppProgress{
+					CompletedStages: 2,
+					TotalStages:     5,
+					CurrentStage:    "groupBy",
+					CompletedTasks:  4,
+					TotalTasks:      10,
+					ActiveExecutors: 2,
+					FailedExecutors: 1,
+				}, progress)
 			}
 		})
 	}
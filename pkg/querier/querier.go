@@ -24,6 +24,9 @@ type NPRecommendationQuerier interface {
 	ListNetworkPolicyRecommendation(namespace string) ([]*v1alpha1.NetworkPolicyRecommendation, error)
 	DeleteNetworkPolicyRecommendation(namespace, name string) error
 	CreateNetworkPolicyRecommendation(namespace string, networkPolicyRecommendation *v1alpha1.NetworkPolicyRecommendation) (*v1alpha1.NetworkPolicyRecommendation, error)
+	// EstimateNetworkPolicyRecommendation returns the estimated input size of
+	// a job without creating it.
+	EstimateNetworkPolicyRecommendation(networkPolicyRecommendation *v1alpha1.NetworkPolicyRecommendation) (*v1alpha1.JobInputEstimate, error)
 }
 
 type ClickHouseStatQuerier interface {
@@ -38,4 +41,17 @@ type ThroughputAnomalyDetectorQuerier interface {
 	ListThroughputAnomalyDetector(namespace string) ([]*v1alpha1.ThroughputAnomalyDetector, error)
 	DeleteThroughputAnomalyDetector(namespace, name string) error
 	CreateThroughputAnomalyDetector(namespace string, anomalydetector *v1alpha1.ThroughputAnomalyDetector) (*v1alpha1.ThroughputAnomalyDetector, error)
+	// EstimateThroughputAnomalyDetector returns the estimated input size of
+	// a job without creating it.
+	EstimateThroughputAnomalyDetector(anomalydetector *v1alpha1.ThroughputAnomalyDetector) (*v1alpha1.JobInputEstimate, error)
+	// ListAnomalySuppression is used to filter the suppressed anomalies out
+	// of the results of ThroughputAnomalyDetectors.
+	ListAnomalySuppression(namespace string) ([]*v1alpha1.AnomalySuppression, error)
+}
+
+type AnomalySuppressionQuerier interface {
+	GetAnomalySuppression(namespace, name string) (*v1alpha1.AnomalySuppression, error)
+	ListAnomalySuppression(namespace string) ([]*v1alpha1.AnomalySuppression, error)
+	DeleteAnomalySuppression(namespace, name string) error
+	CreateAnomalySuppression(namespace string, anomalySuppression *v1alpha1.AnomalySuppression) (*v1alpha1.AnomalySuppression, error)
 }
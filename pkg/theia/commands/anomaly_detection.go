@@ -26,9 +26,9 @@ var throughputanomalyDetectionCmd = &cobra.Command{
 	Aliases: []string{"tad"},
 	Short:   "Commands of Theia throughput anomaly detection feature",
 	Long: `Command group of Theia throughput anomaly detection feature.
-	Must specify a subcommand like run, list, delete, status or retrieve`,
+	Must specify a subcommand like run, list, delete, status, retrieve or suppression`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Error: Must also specify a subcommand like run, list, delete, status or retrieve")
+		fmt.Println("Error: Must also specify a subcommand like run, list, delete, status, retrieve or suppression")
 	},
 }
 
@@ -0,0 +1,194 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+)
+
+const (
+	// plotWidth is the number of columns of the sparklines. The time range of
+	// an entity is split in as many buckets.
+	plotWidth = 60
+	// plotAnomalyMarker marks the buckets holding an anomalous value.
+	plotAnomalyMarker = '^'
+)
+
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+// plotSample is a stat of a throughput anomaly detection job with its values
+// parsed.
+type plotSample struct {
+	time      time.Time
+	value     float64
+	baseline  float64
+	anomalous bool
+}
+
+// plotBucket holds the sample with the highest value among those falling in
+// a column of the sparklines.
+type plotBucket struct {
+	sample    *plotSample
+	anomalous bool
+}
+
+// plotAnomalies renders, for each entity found in the stats, sparklines of
+// the analysed metric and of the baseline calculated by the algorithm over
+// time, with a marker under the anomalous values. Both sparklines share the
+// same scale. Stats whose time or values can't be parsed are skipped.
+func plotAnomalies(stats []intelligence.ThroughputAnomalyDetectorStats) string {
+	var entities []string
+	samples := map[string][]plotSample{}
+	for _, stat := range stats {
+		flowEnd, err := time.Parse(time.RFC3339, stat.FlowEndSeconds)
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(stat.Throughput, 64)
+		if err != nil {
+			continue
+		}
+		baseline, err := strconv.ParseFloat(stat.AlgoCalc, 64)
+		if err != nil {
+			baseline = math.NaN()
+		}
+		entity := describeAnomaly(stat)
+		if _, ok := samples[entity]; !ok {
+			entities = append(entities, entity)
+		}
+		samples[entity] = append(samples[entity], plotSample{
+			time:      flowEnd,
+			value:     value,
+			baseline:  baseline,
+			anomalous: stat.Anomaly == "true",
+		})
+	}
+	metric := "throughput"
+	if len(stats) > 0 && stats[0].Metric != "" {
+		metric = stats[0].Metric
+	}
+	sort.Strings(entities)
+	var b strings.Builder
+	for i, entity := range entities {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		writeEntityPlot(&b, entity, metric, samples[entity])
+	}
+	return b.String()
+}
+
+func writeEntityPlot(b *strings.Builder, entity, metric string, samples []plotSample) {
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].time.Before(samples[j].time)
+	})
+	buckets := bucketSamples(samples)
+	low, high := math.Inf(1), math.Inf(-1)
+	anomalies := 0
+	for _, sample := range samples {
+		for _, v := range []float64{sample.value, sample.baseline} {
+			if !math.IsNaN(v) {
+				low = math.Min(low, v)
+				high = math.Max(high, v)
+			}
+		}
+		if sample.anomalous {
+			anomalies++
+		}
+	}
+	labelWidth := len(metric)
+	if labelWidth < len("baseline") {
+		labelWidth = len("baseline")
+	}
+	fmt.Fprintf(b, "%s: %s from %s to %s, %d anomalous\n", entity, metric, formatPlotValue(low), formatPlotValue(high), anomalies)
+	writeRow := func(label, row string) {
+		fmt.Fprintln(b, strings.TrimRight(fmt.Sprintf("  %-*s %s", labelWidth, label, row), " "))
+	}
+	writeRow(metric, sparkline(buckets, low, high, func(s *plotSample) float64 { return s.value }))
+	writeRow("baseline", sparkline(buckets, low, high, func(s *plotSample) float64 { return s.baseline }))
+	markers := make([]rune, len(buckets))
+	for i, bucket := range buckets {
+		markers[i] = ' '
+		if bucket.anomalous {
+			markers[i] = plotAnomalyMarker
+		}
+	}
+	writeRow("anomaly", string(markers))
+	writeRow("time", fmt.Sprintf("%s - %s", samples[0].time.UTC().Format(time.RFC3339), samples[len(samples)-1].time.UTC().Format(time.RFC3339)))
+}
+
+// bucketSamples splits the time range of the samples, which are sorted by
+// time, in at most plotWidth columns, so that the gaps between samples are
+// kept in the sparklines.
+func bucketSamples(samples []plotSample) []plotBucket {
+	start, end := samples[0].time, samples[len(samples)-1].time
+	span := end.Sub(start)
+	width := plotWidth
+	if len(samples) < width {
+		// Don't stretch a few samples over the whole width.
+		width = len(samples)
+	}
+	if span == 0 {
+		width = 1
+	}
+	buckets := make([]plotBucket, width)
+	for i := range samples {
+		sample := &samples[i]
+		index := 0
+		if span > 0 {
+			index = int(float64(sample.time.Sub(start)) / float64(span) * float64(width-1))
+		}
+		bucket := &buckets[index]
+		if bucket.sample == nil || sample.value > bucket.sample.value {
+			bucket.sample = sample
+		}
+		bucket.anomalous = bucket.anomalous || sample.anomalous
+	}
+	return buckets
+}
+
+func sparkline(buckets []plotBucket, low, high float64, value func(*plotSample) float64) string {
+	line := make([]rune, len(buckets))
+	for i, bucket := range buckets {
+		line[i] = ' '
+		if bucket.sample == nil {
+			continue
+		}
+		v := value(bucket.sample)
+		if math.IsNaN(v) {
+			continue
+		}
+		level := len(sparklineLevels) - 1
+		if high > low {
+			level = int((v - low) / (high - low) * float64(len(sparklineLevels)-1))
+		}
+		line[i] = sparklineLevels[level]
+	}
+	return string(line)
+}
+
+func formatPlotValue(v float64) string {
+	if math.IsInf(v, 0) {
+		return "N/A"
+	}
+	return strconv.FormatFloat(v, 'g', 4, 64)
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+)
+
+func TestPlotAnomalies(t *testing.T) {
+	podStat := func(flowEnd, throughput, algoCalc, anomaly string) intelligence.ThroughputAnomalyDetectorStats {
+		return intelligence.ThroughputAnomalyDetectorStats{
+			PodNamespace:   "ns",
+			PodName:        "backup",
+			Direction:      "inbound",
+			AggType:        "pod",
+			FlowEndSeconds: flowEnd,
+			Throughput:     throughput,
+			AlgoCalc:       algoCalc,
+			Anomaly:        anomaly,
+		}
+	}
+	for _, tt := range []struct {
+		name     string
+		stats    []intelligence.ThroughputAnomalyDetectorStats
+		expected string
+	}{
+		{
+			name: "single entity",
+			stats: []intelligence.ThroughputAnomalyDetectorStats{
+				podStat("2023-03-01T08:04:00Z", "800", "100", "true"),
+				podStat("2023-03-01T08:00:00Z", "100", "100", "false"),
+				podStat("2023-03-01T08:02:00Z", "200", "100", "false"),
+			},
+			expected: `inbound Pod ns/backup: throughput from 100 to 800, 1 anomalous
+  throughput ▁▂█
+  baseline   ▁▁▁
+  anomaly      ^
+  time       2023-03-01T08:00:00Z - 2023-03-01T08:04:00Z
+`,
+		},
+		{
+			name: "multiple entities and metric",
+			stats: []intelligence.ThroughputAnomalyDetectorStats{
+				{AggType: "svc", DestinationServicePortName: "ns/web:http", FlowEndSeconds: "2023-03-01T08:00:00Z", Metric: "newConnections", Throughput: "10", AlgoCalc: "10", Anomaly: "false"},
+				{AggType: "svc", DestinationServicePortName: "ns/web:http", FlowEndSeconds: "2023-03-01T08:01:00Z", Metric: "newConnections", Throughput: "50", AlgoCalc: "10", Anomaly: "true"},
+				{AggType: "svc", DestinationServicePortName: "ns/web:http", FlowEndSeconds: "2023-03-01T08:02:00Z", Metric: "newConnections", Throughput: "12", AlgoCalc: "26", Anomaly: "false"},
+				{AggType: "external", DestinationIP: "10.0.0.1", FlowEndSeconds: "2023-03-01T08:00:00Z", Metric: "newConnections", Throughput: "20", AlgoCalc: "invalid", Anomaly: "false"},
+				{AggType: "external", DestinationIP: "10.0.0.1", FlowEndSeconds: "2023-03-01T08:02:00Z", Metric: "newConnections", Throughput: "20", AlgoCalc: "20", Anomaly: "false"},
+				{AggType: "external", DestinationIP: "10.0.0.1", FlowEndSeconds: "invalid", Throughput: "20"},
+			},
+			expected: `Service ns/web:http: newConnections from 10 to 50, 1 anomalous
+  newConnections ▁█▁
+  baseline       ▁▁▃
+  anomaly         ^
+  time           2023-03-01T08:00:00Z - 2023-03-01T08:02:00Z
+
+external IP 10.0.0.1: newConnections from 20 to 20, 0 anomalous
+  newConnections ██
+  baseline        █
+  anomaly
+  time           2023-03-01T08:00:00Z - 2023-03-01T08:02:00Z
+`,
+		},
+		{
+			name:     "no stats",
+			stats:    []intelligence.ThroughputAnomalyDetectorStats{{Anomaly: "NO ANOMALY DETECTED"}},
+			expected: "",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, plotAnomalies(tt.stats))
+		})
+	}
+}
+
+func TestBucketSamples(t *testing.T) {
+	start := time.Date(2023, 3, 1, 8, 0, 0, 0, time.UTC)
+	var samples []plotSample
+	for i := 0; i < 2*plotWidth; i++ {
+		samples = append(samples, plotSample{time: start.Add(time.Duration(i) * time.Minute), value: float64(i)})
+	}
+	buckets := bucketSamples(samples)
+	assert.Len(t, buckets, plotWidth)
+	assert.Equal(t, float64(2*plotWidth-1), buckets[plotWidth-1].sample.value)
+
+	// A gap between samples is kept as empty columns.
+	samples = []plotSample{
+		{time: start, value: 1},
+		{time: start.Add(time.Minute), value: 2},
+		{time: start.Add(time.Hour), value: 3, anomalous: true},
+	}
+	buckets = bucketSamples(samples)
+	assert.Len(t, buckets, 3)
+	assert.Equal(t, float64(2), buckets[0].sample.value, fmt.Sprintf("%+v", buckets))
+	assert.Nil(t, buckets[1].sample)
+	assert.True(t, buckets[2].anomalous)
+}
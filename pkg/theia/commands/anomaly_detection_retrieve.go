@@ -18,9 +18,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
 	"antrea.io/theia/pkg/util"
 )
 
@@ -40,6 +42,10 @@ Use Service ClusterIP when getting the result
 $ theia throughput-anomaly-detection retrieve tad-e998433e-accb-4888-9fc8-06563f073e86 --use-cluster-ip
 Save the anomaly detection result to file
 $ theia throughput-anomaly-detection retrieve tad-e998433e-accb-4888-9fc8-06563f073e86 --use-cluster-ip --file output.yaml
+Show the flows which contributed the most to each anomaly
+$ theia throughput-anomaly-detection retrieve tad-e998433e-accb-4888-9fc8-06563f073e86 --contributors
+Plot the anomalies of each entity over time in the terminal
+$ theia throughput-anomaly-detection retrieve tad-e998433e-accb-4888-9fc8-06563f073e86 --plot
 `,
 	RunE: throughputAnomalyDetectionRetrieve,
 }
@@ -58,6 +64,16 @@ func init() {
 		"",
 		"The file path where you want to save the result.",
 	)
+	throughputAnomalyDetectionRetrieveCmd.Flags().Bool(
+		"contributors",
+		false,
+		`Show, for each anomaly, the flows which transferred the most bytes in the minute before and after it, instead of the anomalies only.`,
+	)
+	throughputAnomalyDetectionRetrieveCmd.Flags().Bool(
+		"plot",
+		false,
+		`Draw, for each entity, sparklines of the analysed metric and of the baseline calculated by the algorithm over time, with the anomalies marked, instead of a table.`,
+	)
 }
 
 func throughputAnomalyDetectionRetrieve(cmd *cobra.Command, args []string) error {
@@ -80,6 +96,17 @@ func throughputAnomalyDetectionRetrieve(cmd *cobra.Command, args []string) error
 	if err != nil {
 		return err
 	}
+	contributors, err := cmd.Flags().GetBool("contributors")
+	if err != nil {
+		return err
+	}
+	plot, err := cmd.Flags().GetBool("plot")
+	if err != nil {
+		return err
+	}
+	if plot && (contributors || filePath != "") {
+		return fmt.Errorf("plot can't be used together with contributors or file")
+	}
 	theiaClient, pf, err := SetupTheiaClientAndConnection(cmd, useClusterIP)
 	if err != nil {
 		return fmt.Errorf("couldn't setup Theia manager client, %v", err)
@@ -97,6 +124,20 @@ func throughputAnomalyDetectionRetrieve(cmd *cobra.Command, args []string) error
 			return nil
 		}
 	}
+	if contributors {
+		tadContributors, err := getThroughputAnomalyDetectorContributors(theiaClient, tadName)
+		if err != nil {
+			return fmt.Errorf("error when getting contributing flows of anomaly detection job: %v", err)
+		}
+		return outputAnomalyContributors(tadContributors, filePath)
+	}
+	if plot {
+		if !tad.SaveNormalPoints {
+			fmt.Print("Only the anomalies were saved by the job, run it with --save-normal-points to plot the whole series and the baseline\n\n")
+		}
+		fmt.Print(plotAnomalies(tad.Stats))
+		return nil
+	}
 	data, _ := json.MarshalIndent(tad.Stats, "", " ")
 	if filePath != "" {
 		if err := os.WriteFile(filePath, data, 0600); err != nil {
@@ -104,6 +145,13 @@ func throughputAnomalyDetectionRetrieve(cmd *cobra.Command, args []string) error
 		}
 		return nil
 	} else {
+		// The normal values saved by the job are only plotted
+		anomalies := anomalousStats(tad.Stats)
+		if len(anomalies) == 0 {
+			fmt.Printf("No Anomaly found in id: %v\n", tad.Status.SparkApplication)
+			return nil
+		}
+		tad.Stats = anomalies
 		var result [][]string
 		switch tad.Stats[0].AggType {
 		case "None":
@@ -133,8 +181,110 @@ func throughputAnomalyDetectionRetrieve(cmd *cobra.Command, args []string) error
 			for _, p := range tad.Stats {
 				result = append(result, []string{p.Id, p.DestinationServicePortName, p.FlowEndSeconds, p.Throughput, p.AggType, p.AlgoType, p.AlgoCalc, p.Anomaly})
 			}
+		case "node":
+			result = append(result, []string{"id", "sourceNodeName", "destinationNodeName", "flowEndSeconds", "throughput", "aggType", "algoType", "algoCalc", "anomaly"})
+			for _, p := range tad.Stats {
+				result = append(result, []string{p.Id, p.SourceNodeName, p.DestinationNodeName, p.FlowEndSeconds, p.Throughput, p.AggType, p.AlgoType, p.AlgoCalc, p.Anomaly})
+			}
+		case "namespace":
+			result = append(result, []string{"id", "podNamespace", "direction", "flowEndSeconds", "throughput", "aggType", "algoType", "algoCalc", "anomaly"})
+			for _, p := range tad.Stats {
+				result = append(result, []string{p.Id, p.PodNamespace, p.Direction, p.FlowEndSeconds, p.Throughput, p.AggType, p.AlgoType, p.AlgoCalc, p.Anomaly})
+			}
+		}
+		// The throughput column holds the values of the analysed metric
+		if metric := tad.Stats[0].Metric; metric != "" && metric != "throughput" {
+			for i, column := range result[0] {
+				if column == "throughput" {
+					result[0][i] = metric
+				}
+			}
+		}
+		// The ENSEMBLE algorithm also gives the verdict of each algorithm
+		if tad.Stats[0].AlgoType == "ENSEMBLE" {
+			result[0] = append(result[0], "algoVerdicts")
+			for i, p := range tad.Stats {
+				result[i+1] = append(result[i+1], p.AlgoVerdicts)
+			}
+		}
+		if tad.Stats[0].AlgoParams != "" {
+			fmt.Printf("Algorithm parameters: %s\n", tad.Stats[0].AlgoParams)
 		}
 		TableOutput(result)
 	}
 	return nil
 }
+
+func outputAnomalyContributors(contributors intelligence.ThroughputAnomalyDetectorContributors, filePath string) error {
+	if filePath != "" {
+		data, _ := json.MarshalIndent(contributors, "", " ")
+		if err := os.WriteFile(filePath, data, 0600); err != nil {
+			return fmt.Errorf("error when writing contributing flows to file: %v", err)
+		}
+		return nil
+	}
+	for _, anomaly := range contributors.Anomalies {
+		metric := anomaly.Anomaly.Metric
+		if metric == "" {
+			metric = "throughput"
+		}
+		fmt.Printf("\nAnomaly of %s at %s: %s %s, %s calculated %s\n", describeAnomaly(anomaly.Anomaly), anomaly.Anomaly.FlowEndSeconds, metric, anomaly.Anomaly.Throughput, anomaly.Anomaly.AlgoType, anomaly.Anomaly.AlgoCalc)
+		if anomaly.ErrorMsg != "" {
+			fmt.Printf("Error: %s\n", anomaly.ErrorMsg)
+			continue
+		}
+		if len(anomaly.Flows) == 0 {
+			fmt.Printf("No flows found between %s and %s\n", FormatTimestamp(anomaly.WindowStart.Time), FormatTimestamp(anomaly.WindowEnd.Time))
+			continue
+		}
+		fmt.Printf("Top flows between %s and %s\n", FormatTimestamp(anomaly.WindowStart.Time), FormatTimestamp(anomaly.WindowEnd.Time))
+		flows := [][]string{{"Source", "Destination", "DestinationService", "Port", "Protocol", "Bytes", "Packets", "Flows"}}
+		for _, flow := range anomaly.Flows {
+			flows = append(flows, []string{
+				formatEndpoint(flow.SourcePodNamespace, flow.SourcePodName, flow.SourceIP),
+				formatEndpoint(flow.DestinationPodNamespace, flow.DestinationPodName, flow.DestinationIP),
+				flow.DestinationServicePortName,
+				strconv.Itoa(flow.DestinationTransportPort),
+				flow.Protocol,
+				strconv.FormatInt(flow.Bytes, 10),
+				strconv.FormatInt(flow.Packets, 10),
+				strconv.FormatInt(flow.FlowCount, 10),
+			})
+		}
+		TableOutput(flows)
+	}
+	return nil
+}
+
+// describeAnomaly returns what the throughput of an anomaly was aggregated
+// over.
+func describeAnomaly(stat intelligence.ThroughputAnomalyDetectorStats) string {
+	switch stat.AggType {
+	case "pod":
+		if stat.PodName != "" {
+			return fmt.Sprintf("%s Pod %s/%s", stat.Direction, stat.PodNamespace, stat.PodName)
+		}
+		return fmt.Sprintf("%s Pods %s in Namespace %s", stat.Direction, stat.PodLabels, stat.PodNamespace)
+	case "external":
+		return fmt.Sprintf("external IP %s", stat.DestinationIP)
+	case "svc":
+		return fmt.Sprintf("Service %s", stat.DestinationServicePortName)
+	case "node":
+		return fmt.Sprintf("Nodes %s to %s", stat.SourceNodeName, stat.DestinationNodeName)
+	case "namespace":
+		return fmt.Sprintf("%s Namespace %s", stat.Direction, stat.PodNamespace)
+	}
+	return fmt.Sprintf("flow %s:%s to %s:%s", stat.SourceIP, stat.SourceTransportPort, stat.DestinationIP, stat.DestinationTransportPort)
+}
+
+// anomalousStats returns the anomalous stats, leaving out the normal values
+// saved by the jobs run with save-normal-points.
+func anomalousStats(stats []intelligence.ThroughputAnomalyDetectorStats) []intelligence.ThroughputAnomalyDetectorStats {
+	var anomalies []intelligence.ThroughputAnomalyDetectorStats
+	for _, stat := range stats {
+		if stat.Anomaly != "false" {
+			anomalies = append(anomalies, stat)
+		}
+	}
+	return anomalies
+}
@@ -36,13 +36,28 @@ import (
 )
 
 func TestAnomalyDetectorRetrieve(t *testing.T) {
+	svcStat := func(flowEnd, throughput, anomaly string) anomalydetector.ThroughputAnomalyDetectorStats {
+		return anomalydetector.ThroughputAnomalyDetectorStats{
+			Id:                         tadName,
+			AggType:                    "svc",
+			DestinationServicePortName: "db/postgres:5432",
+			FlowEndSeconds:             flowEnd,
+			Throughput:                 throughput,
+			AlgoType:                   "EWMA",
+			AlgoCalc:                   "1000000000",
+			Anomaly:                    anomaly,
+		}
+	}
 	testCases := []struct {
 		name             string
 		testServer       *httptest.Server
 		expectedMsg      []string
+		unexpectedMsg    []string
 		expectedErrorMsg string
 		tadName          string
 		filePath         string
+		contributors     bool
+		plot             bool
 	}{
 		{
 			name: "Valid case No agg_type",
@@ -156,10 +171,120 @@ func TestAnomalyDetectorRetrieve(t *testing.T) {
 							State: "COMPLETED",
 						},
 						Stats: []anomalydetector.ThroughputAnomalyDetectorStats{{
-							Id:       "tad-1234abcd-1234-abcd-12ab-12345678abcd",
-							Anomaly:  "true",
-							AlgoCalc: "1234567",
-							AggType:  "svc",
+							Id:         "tad-1234abcd-1234-abcd-12ab-12345678abcd",
+							Anomaly:    "true",
+							AlgoCalc:   "1234567",
+							AggType:    "svc",
+							AlgoParams: `{"ewmaAlpha": 0.5, "sensitivity": 1.0}`,
+						}},
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(tad)
+				}
+			})),
+			tadName:          "tad-1234abcd-1234-abcd-12ab-12345678abcd",
+			expectedMsg:      []string{"id                                       destinationServicePortName flowEndSeconds throughput     aggType        algoType       algoCalc       anomaly", "svc                           1234567        true", `Algorithm parameters: {"ewmaAlpha": 0.5, "sensitivity": 1.0}`},
+			expectedErrorMsg: "",
+		},
+		{
+			name: "Valid case ENSEMBLE",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/throughputanomalydetectors/%s", tadName):
+					tad := &anomalydetector.ThroughputAnomalyDetector{
+						Status: anomalydetector.ThroughputAnomalyDetectorStatus{
+							State: "COMPLETED",
+						},
+						Stats: []anomalydetector.ThroughputAnomalyDetectorStats{{
+							Id:           "tad-1234abcd-1234-abcd-12ab-12345678abcd",
+							Anomaly:      "true",
+							AlgoCalc:     "1234567",
+							AggType:      "svc",
+							AlgoType:     "ENSEMBLE",
+							AlgoVerdicts: "EWMA:true,ARIMA:true,DBSCAN:false",
+						}},
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(tad)
+				}
+			})),
+			tadName:          "tad-1234abcd-1234-abcd-12ab-12345678abcd",
+			expectedMsg:      []string{"algoVerdicts", "EWMA:true,ARIMA:true,DBSCAN:false"},
+			expectedErrorMsg: "",
+		},
+		{
+			name: "Valid case agg_type: node",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/throughputanomalydetectors/%s", tadName):
+					tad := &anomalydetector.ThroughputAnomalyDetector{
+						Status: anomalydetector.ThroughputAnomalyDetectorStatus{
+							State: "COMPLETED",
+						},
+						Stats: []anomalydetector.ThroughputAnomalyDetectorStats{{
+							Id:                  "tad-1234abcd-1234-abcd-12ab-12345678abcd",
+							Anomaly:             "true",
+							AlgoCalc:            "1234567",
+							AggType:             "node",
+							SourceNodeName:      "node-1",
+							DestinationNodeName: "node-2",
+						}},
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(tad)
+				}
+			})),
+			tadName:          "tad-1234abcd-1234-abcd-12ab-12345678abcd",
+			expectedMsg:      []string{"id                                       sourceNodeName destinationNodeName flowEndSeconds throughput     aggType        algoType       algoCalc       anomaly", "node-1         node-2"},
+			expectedErrorMsg: "",
+		},
+		{
+			name: "Valid case agg_type: namespace",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/throughputanomalydetectors/%s", tadName):
+					tad := &anomalydetector.ThroughputAnomalyDetector{
+						Status: anomalydetector.ThroughputAnomalyDetectorStatus{
+							State: "COMPLETED",
+						},
+						Stats: []anomalydetector.ThroughputAnomalyDetectorStats{{
+							Id:           "tad-1234abcd-1234-abcd-12ab-12345678abcd",
+							Anomaly:      "true",
+							AlgoCalc:     "1234567",
+							AggType:      "namespace",
+							PodNamespace: "testnamespace",
+							Direction:    "inbound",
+						}},
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(tad)
+				}
+			})),
+			tadName:          "tad-1234abcd-1234-abcd-12ab-12345678abcd",
+			expectedMsg:      []string{"id                                       podNamespace   direction      flowEndSeconds throughput     aggType        algoType       algoCalc       anomaly", "testnamespace  inbound"},
+			expectedErrorMsg: "",
+		},
+		{
+			name: "Valid case metric: newConnections",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/throughputanomalydetectors/%s", tadName):
+					tad := &anomalydetector.ThroughputAnomalyDetector{
+						Status: anomalydetector.ThroughputAnomalyDetectorStatus{
+							State: "COMPLETED",
+						},
+						Stats: []anomalydetector.ThroughputAnomalyDetectorStats{{
+							Id:                         "tad-1234abcd-1234-abcd-12ab-12345678abcd",
+							Anomaly:                    "true",
+							AlgoCalc:                   "12",
+							AggType:                    "svc",
+							DestinationServicePortName: "test-service",
+							Metric:                     "newConnections",
+							Throughput:                 "120",
 						}},
 					}
 					w.Header().Set("Content-Type", "application/json")
@@ -168,9 +293,166 @@ func TestAnomalyDetectorRetrieve(t *testing.T) {
 				}
 			})),
 			tadName:          "tad-1234abcd-1234-abcd-12ab-12345678abcd",
-			expectedMsg:      []string{"id                                       destinationServicePortName flowEndSeconds throughput     aggType        algoType       algoCalc       anomaly", "svc                           1234567        true"},
+			expectedMsg:      []string{"newConnections", "test-service"},
+			expectedErrorMsg: "",
+		},
+		{
+			name: "Valid case with contributors",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var resp interface{}
+				switch strings.TrimSpace(r.URL.Path) {
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/throughputanomalydetectors/%s", tadName):
+					resp = &anomalydetector.ThroughputAnomalyDetector{
+						Status: anomalydetector.ThroughputAnomalyDetectorStatus{
+							State: "COMPLETED",
+						},
+						Stats: []anomalydetector.ThroughputAnomalyDetectorStats{{
+							Id:      tadName,
+							Anomaly: "true",
+						}},
+					}
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/throughputanomalydetectors/%s/contributors", tadName):
+					resp = &anomalydetector.ThroughputAnomalyDetectorContributors{
+						Anomalies: []anomalydetector.AnomalyContributors{{
+							Anomaly: anomalydetector.ThroughputAnomalyDetectorStats{
+								Id:                         tadName,
+								AggType:                    "svc",
+								DestinationServicePortName: "db/postgres:5432",
+								FlowEndSeconds:             "2023-03-01T08:00:00Z",
+								Throughput:                 "4000000000",
+								AlgoType:                   "EWMA",
+								AlgoCalc:                   "1000000000",
+								Anomaly:                    "true",
+							},
+							Flows: []anomalydetector.ContributingFlow{{
+								SourcePodNamespace:         "web",
+								SourcePodName:              "frontend",
+								SourceIP:                   "10.10.0.1",
+								DestinationIP:              "10.96.0.10",
+								DestinationServicePortName: "db/postgres:5432",
+								DestinationTransportPort:   5432,
+								Protocol:                   "TCP",
+								Bytes:                      30000000000,
+								Packets:                    20000000,
+								FlowCount:                  12,
+							}},
+						}},
+					}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(resp)
+			})),
+			tadName:          tadName,
+			contributors:     true,
+			expectedMsg:      []string{"Anomaly of Service db/postgres:5432 at 2023-03-01T08:00:00Z: throughput 4000000000, EWMA calculated 1000000000", "web/frontend(10.10.0.1)", "30000000000"},
+			expectedErrorMsg: "",
+		},
+		{
+			name: "Valid case with plot",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/throughputanomalydetectors/%s", tadName):
+					tad := &anomalydetector.ThroughputAnomalyDetector{
+						SaveNormalPoints: true,
+						Status: anomalydetector.ThroughputAnomalyDetectorStatus{
+							State: "COMPLETED",
+						},
+						Stats: []anomalydetector.ThroughputAnomalyDetectorStats{
+							svcStat("2023-03-01T07:58:00Z", "1000000000", "false"),
+							svcStat("2023-03-01T07:59:00Z", "1100000000", "false"),
+							svcStat("2023-03-01T08:00:00Z", "4000000000", "true"),
+						},
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(tad)
+				}
+			})),
+			tadName:          tadName,
+			plot:             true,
+			expectedMsg:      []string{"Service db/postgres:5432: throughput from 1e+09 to 4e+09, 1 anomalous\n  throughput ▁▁█\n  baseline   ▁▁▁\n  anomaly      ^\n"},
+			expectedErrorMsg: "",
+		},
+		{
+			name: "Valid case with plot of anomalies only",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/throughputanomalydetectors/%s", tadName):
+					tad := &anomalydetector.ThroughputAnomalyDetector{
+						Status: anomalydetector.ThroughputAnomalyDetectorStatus{
+							State: "COMPLETED",
+						},
+						Stats: []anomalydetector.ThroughputAnomalyDetectorStats{
+							svcStat("2023-03-01T08:00:00Z", "4000000000", "true"),
+						},
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(tad)
+				}
+			})),
+			tadName:          tadName,
+			plot:             true,
+			expectedMsg:      []string{"run it with --save-normal-points", "throughput █"},
+			expectedErrorMsg: "",
+		},
+		{
+			name: "Valid case with normal points",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/throughputanomalydetectors/%s", tadName):
+					tad := &anomalydetector.ThroughputAnomalyDetector{
+						SaveNormalPoints: true,
+						Status: anomalydetector.ThroughputAnomalyDetectorStatus{
+							State: "COMPLETED",
+						},
+						Stats: []anomalydetector.ThroughputAnomalyDetectorStats{
+							svcStat("2023-03-01T07:59:00Z", "1100000000", "false"),
+							svcStat("2023-03-01T08:00:00Z", "4000000000", "true"),
+						},
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(tad)
+				}
+			})),
+			tadName:          tadName,
+			expectedMsg:      []string{"db/postgres:5432           2023-03-01T08:00:00Z 4000000000"},
+			unexpectedMsg:    []string{"1100000000"},
+			expectedErrorMsg: "",
+		},
+		{
+			name: "Valid case for No Anomaly Found with normal points",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/throughputanomalydetectors/%s", tadName):
+					tad := &anomalydetector.ThroughputAnomalyDetector{
+						SaveNormalPoints: true,
+						Status: anomalydetector.ThroughputAnomalyDetectorStatus{
+							State: "COMPLETED",
+						},
+						Stats: []anomalydetector.ThroughputAnomalyDetectorStats{
+							svcStat("2023-03-01T07:59:00Z", "1100000000", "false"),
+						},
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(tad)
+				}
+			})),
+			tadName:          tadName,
+			expectedMsg:      []string{"No Anomaly found"},
 			expectedErrorMsg: "",
 		},
+		{
+			name:             "Plot with contributors",
+			testServer:       httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+			tadName:          tadName,
+			contributors:     true,
+			plot:             true,
+			expectedErrorMsg: "plot can't be used together with contributors or file",
+		},
 		{
 			name: "Valid case for No Anomaly Found",
 			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -297,6 +579,8 @@ func TestAnomalyDetectorRetrieve(t *testing.T) {
 				cmd.Flags().String("name", tt.tadName, "")
 				cmd.Flags().String("file", tt.filePath, "")
 				cmd.Flags().Bool("use-cluster-ip", true, "")
+				cmd.Flags().Bool("contributors", tt.contributors, "")
+				cmd.Flags().Bool("plot", tt.plot, "")
 			}
 
 			orig := os.Stdout
@@ -318,6 +602,9 @@ func TestAnomalyDetectorRetrieve(t *testing.T) {
 					for _, msg := range tt.expectedMsg {
 						assert.Contains(t, outcome, msg)
 					}
+					for _, msg := range tt.unexpectedMsg {
+						assert.NotContains(t, outcome, msg)
+					}
 				}
 			} else {
 				assert.Error(t, err)
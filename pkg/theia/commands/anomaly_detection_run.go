@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"regexp"
 	"strings"
 	"time"
@@ -35,11 +36,22 @@ import (
 var throughputAnomalyDetectionAlgoCmd = &cobra.Command{
 	Use:   "run",
 	Short: "throughput anomaly detection using Algo",
-	Long:  `throughput anomaly detection using algorithms, currently supported algorithms are EWMA, ARIMA and DBSCAN`,
+	Long: `throughput anomaly detection using algorithms, currently supported algorithms are EWMA, ARIMA and DBSCAN.
+The ENSEMBLE algorithm runs all of them over the same flows and reports a throughput as anomalous when a quorum of them agree`,
 	Example: `Run the specific algorithm for throughput anomaly detection
 	$ theia throughput-anomaly-detection run --algo ARIMA --start-time 2022-01-01T00:00:00 --end-time 2022-01-31T23:59:59
 	Run throughput anomaly detection algorithm of type ARIMA and limit on flow records from '2022-01-01 00:00:00' to '2022-01-31 23:59:59'
-	Please note, algo is a mandatory argument'`,
+	Please note, algo is a mandatory argument'
+	Run throughput anomaly detection algorithm of type EWMA with a smoothing factor of 0.3, and only report throughputs deviating by more than 3 standard deviations
+	$ theia throughput-anomaly-detection run --algo EWMA --ewma-alpha 0.3 --sensitivity 3
+	Run anomaly detection algorithm of type DBSCAN on the number of connections started towards each Service
+	$ theia throughput-anomaly-detection run --algo DBSCAN --agg-flow svc --metric newConnections
+	Run anomaly detection algorithm of type EWMA on all the egress traffic to the internet from the Namespaces labeled with env=prod
+	$ theia throughput-anomaly-detection run --algo EWMA --flow-filter '{"source":{"namespaceSelector":{"matchLabels":{"env":"prod"}}},"flowTypes":["pod_to_external"]}'
+	Run anomaly detection algorithm of type EWMA on each Service, saving the normal values too so that retrieve --plot draws the whole series
+	$ theia throughput-anomaly-detection run --algo EWMA --agg-flow svc --save-normal-points
+	Estimate the flow records read by a throughput anomaly detection job without starting it
+	$ theia throughput-anomaly-detection run --algo EWMA --start-time 2022-01-01T00:00:00 --estimate`,
 	RunE: throughputAnomalyDetectionAlgo,
 }
 
@@ -151,6 +163,27 @@ be a list of namespace string, for example: '["kube-system","flow-aggregator","f
 	}
 	throughputAnomalyDetection.ExecutorMemory = executorMemory
 
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return err
+	}
+	if timeout < 0 {
+		return fmt.Errorf("timeout should be a duration >= 0")
+	}
+	throughputAnomalyDetection.ActiveDeadlineSeconds = int64(math.Ceil(timeout.Seconds()))
+
+	if cmd.Flags().Changed("ttl-after-finished") {
+		ttlAfterFinished, err := cmd.Flags().GetDuration("ttl-after-finished")
+		if err != nil {
+			return err
+		}
+		if ttlAfterFinished < 0 || ttlAfterFinished.Seconds() > math.MaxInt32 {
+			return fmt.Errorf("ttl-after-finished should be a duration >= 0")
+		}
+		ttlSeconds := int32(math.Ceil(ttlAfterFinished.Seconds()))
+		throughputAnomalyDetection.TTLSecondsAfterFinished = &ttlSeconds
+	}
+
 	aggregatedFlow, err := cmd.Flags().GetString("agg-flow")
 	if err != nil {
 		return err
@@ -202,11 +235,71 @@ be a list of namespace string, for example: '["kube-system","flow-aggregator","f
 				throughputAnomalyDetection.ServicePortName = servicePortName
 			}
 			throughputAnomalyDetection.AggregatedFlow = aggregatedFlow
+		case "node":
+			nodeName, err := cmd.Flags().GetString("node-name")
+			if err != nil {
+				return err
+			}
+			if nodeName != "" {
+				throughputAnomalyDetection.NodeName = nodeName
+			}
+			throughputAnomalyDetection.AggregatedFlow = aggregatedFlow
+		case "namespace":
+			podNameSpace, err := cmd.Flags().GetString("pod-namespace")
+			if err != nil {
+				return err
+			}
+			if podNameSpace != "" {
+				throughputAnomalyDetection.PodNameSpace = podNameSpace
+			}
+			throughputAnomalyDetection.AggregatedFlow = aggregatedFlow
 		default:
-			return fmt.Errorf("throughput anomaly detector aggregated flow type should be 'pod' or 'external' or 'svc'")
+			return fmt.Errorf("throughput anomaly detector aggregated flow type should be 'pod' or 'external' or 'svc' or 'node' or 'namespace'")
 		}
 	}
 
+	metric, err := cmd.Flags().GetString("metric")
+	if err != nil {
+		return err
+	}
+	switch metric {
+	case "", "throughput", "reverseThroughput", "packetRate", "octetDelta":
+	case "newConnections":
+		if aggregatedFlow == "" {
+			return fmt.Errorf("metric 'newConnections' requires agg-flow to be specified")
+		}
+	default:
+		return fmt.Errorf("throughput anomaly detector metric should be 'throughput' or 'reverseThroughput' or 'packetRate' or 'octetDelta' or 'newConnections'")
+	}
+	throughputAnomalyDetection.Metric = metric
+
+	saveNormalPoints, err := cmd.Flags().GetBool("save-normal-points")
+	if err != nil {
+		return err
+	}
+	throughputAnomalyDetection.SaveNormalPoints = saveNormalPoints
+
+	algoParams, err := getThroughputAnomalyDetectorAlgoParams(cmd)
+	if err != nil {
+		return err
+	}
+	throughputAnomalyDetection.AlgoParams = algoParams
+
+	flowFilter, err := cmd.Flags().GetString("flow-filter")
+	if err != nil {
+		return err
+	}
+	if flowFilter != "" {
+		var parsedFlowFilter anomalydetector.ThroughputAnomalyDetectorFlowFilter
+		decoder := json.NewDecoder(strings.NewReader(flowFilter))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&parsedFlowFilter); err != nil {
+			return fmt.Errorf(`parsing flow-filter: %v, flow-filter should be a JSON object, for example:
+'{"source":{"namespaceSelector":{"matchLabels":{"env":"prod"}}},"flowTypes":["pod_to_external"]}'`, err)
+		}
+		throughputAnomalyDetection.FlowFilter = &parsedFlowFilter
+	}
+
 	tadID := uuid.New().String()
 	throughputAnomalyDetection.Name = "tad-" + tadID
 	throughputAnomalyDetection.Namespace = config.FlowVisibilityNS
@@ -222,6 +315,27 @@ be a list of namespace string, for example: '["kube-system","flow-aggregator","f
 	if pf != nil {
 		defer pf.Stop()
 	}
+	if cmd.Flags().Changed("estimate") {
+		estimateFlag, err := cmd.Flags().GetBool("estimate")
+		if err != nil {
+			return err
+		}
+		if estimateFlag {
+			var estimated anomalydetector.ThroughputAnomalyDetector
+			err = theiaClient.Post().
+				AbsPath("/apis/intelligence.theia.antrea.io/v1alpha1/").
+				Resource("throughputanomalydetectors").
+				Param("dryRun", metav1.DryRunAll).
+				Body(&throughputAnomalyDetection).
+				Do(context.TODO()).
+				Into(&estimated)
+			if err != nil {
+				return fmt.Errorf("failed to estimate Throughput Anomaly Detection job: %v", err)
+			}
+			printJobInputEstimate(estimated.Status.InputEstimate)
+			return nil
+		}
+	}
 	err = theiaClient.Post().
 		AbsPath("/apis/intelligence.theia.antrea.io/v1alpha1/").
 		Resource("throughputanomalydetectors").
@@ -235,11 +349,70 @@ be a list of namespace string, for example: '["kube-system","flow-aggregator","f
 	return nil
 }
 
+// getThroughputAnomalyDetectorAlgoParams returns the algorithm parameters
+// given by the flags, or nil if none of them is given. The parameters are
+// further validated against the algorithm by the Theia manager.
+func getThroughputAnomalyDetectorAlgoParams(cmd *cobra.Command) (*anomalydetector.ThroughputAnomalyDetectorAlgoParams, error) {
+	sensitivity, err := cmd.Flags().GetFloat64("sensitivity")
+	if err != nil {
+		return nil, err
+	}
+	if sensitivity < 0 {
+		return nil, fmt.Errorf("sensitivity should be a positive number")
+	}
+	ewmaAlpha, err := cmd.Flags().GetFloat64("ewma-alpha")
+	if err != nil {
+		return nil, err
+	}
+	if ewmaAlpha < 0 || ewmaAlpha > 1 {
+		return nil, fmt.Errorf("ewma-alpha should be a number in (0, 1]")
+	}
+	arimaOrder, err := cmd.Flags().GetIntSlice("arima-order")
+	if err != nil {
+		return nil, err
+	}
+	if len(arimaOrder) > 0 && len(arimaOrder) != 3 {
+		return nil, fmt.Errorf("arima-order should have 3 elements (p, d, q)")
+	}
+	dbscanEps, err := cmd.Flags().GetFloat64("dbscan-eps")
+	if err != nil {
+		return nil, err
+	}
+	if dbscanEps < 0 {
+		return nil, fmt.Errorf("dbscan-eps should be a positive number")
+	}
+	dbscanMinSamples, err := cmd.Flags().GetInt("dbscan-min-samples")
+	if err != nil {
+		return nil, err
+	}
+	if dbscanMinSamples < 0 {
+		return nil, fmt.Errorf("dbscan-min-samples should be a positive integer")
+	}
+	quorum, err := cmd.Flags().GetInt("quorum")
+	if err != nil {
+		return nil, err
+	}
+	if quorum < 0 {
+		return nil, fmt.Errorf("quorum should be a positive integer")
+	}
+	if sensitivity == 0 && ewmaAlpha == 0 && len(arimaOrder) == 0 && dbscanEps == 0 && dbscanMinSamples == 0 && quorum == 0 {
+		return nil, nil
+	}
+	return &anomalydetector.ThroughputAnomalyDetectorAlgoParams{
+		Sensitivity:      sensitivity,
+		EWMAAlpha:        ewmaAlpha,
+		ARIMAOrder:       arimaOrder,
+		DBSCANEps:        dbscanEps,
+		DBSCANMinSamples: dbscanMinSamples,
+		Quorum:           quorum,
+	}, nil
+}
+
 func init() {
 	throughputanomalyDetectionCmd.AddCommand(throughputAnomalyDetectionAlgoCmd)
 	throughputAnomalyDetectionAlgoCmd.Flags().StringP("algo", "a", "",
 		`The algorithm used by throughput anomaly detection.
-		Currently supported Algorithms are EWMA, ARIMA and DBSCAN.`)
+		Currently supported Algorithms are EWMA, ARIMA, DBSCAN and ENSEMBLE.`)
 
 	err := throughputAnomalyDetectionAlgoCmd.MarkFlagRequired("algo")
 	if err != nil {
@@ -295,11 +468,31 @@ Example values include 0.1, 500m, 1.5, 5, etc.`,
 		"512M",
 		`Specify the memory request for the executor Pod. Values conform to the Kubernetes resource quantity convention.
 Example values include 512M, 1G, 8G, etc.`,
+	)
+	throughputAnomalyDetectionAlgoCmd.Flags().Duration(
+		"timeout",
+		0,
+		`The maximum duration of the anomaly detection job once it is started, e.g. 2h. The job is
+stopped and marked as failed when it runs longer. Defaults to the job timeout of
+Theia Manager if 0.`,
+	)
+	throughputAnomalyDetectionAlgoCmd.Flags().Duration(
+		"ttl-after-finished",
+		0,
+		`The duration after which the anomaly detection job is deleted together with its results once it
+is completed or failed, e.g. 168h. The job is retained until deleted manually or pruned by the job
+history limits of Theia Manager if not set.`,
+	)
+	throughputAnomalyDetectionAlgoCmd.Flags().Bool(
+		"estimate",
+		false,
+		`Enable this option to only print the estimated number of flow records the anomaly detection
+job reads, without starting it.`,
 	)
 	throughputAnomalyDetectionAlgoCmd.Flags().String(
 		"agg-flow",
 		"",
-		`Specifies which aggregated flow to perform anomaly detection on, options are pod/svc/external`,
+		`Specifies which aggregated flow to perform anomaly detection on, options are pod/svc/external/node/namespace`,
 	)
 	throughputAnomalyDetectionAlgoCmd.Flags().String(
 		"pod-label",
@@ -314,7 +507,8 @@ Example values include 512M, 1G, 8G, etc.`,
 	throughputAnomalyDetectionAlgoCmd.Flags().String(
 		"pod-namespace",
 		"",
-		`On choosing agg-flow as pod, user has option to specify podnamespace for inbound/outbound throughput, podnamespace argument should be combined with podlabels or podname`,
+		`On choosing agg-flow as pod, user has option to specify podnamespace for inbound/outbound throughput, podnamespace argument should be combined with podlabels or podname.
+On choosing agg-flow as namespace, user has option to specify the namespace for inbound/outbound throughput, default would be all namespaces`,
 	)
 	throughputAnomalyDetectionAlgoCmd.Flags().String(
 		"external-ip",
@@ -326,4 +520,60 @@ Example values include 512M, 1G, 8G, etc.`,
 		"",
 		`On choosing agg-flow as svc, user has option to specify svc-port-name for inbound throughput, default would be all service port names`,
 	)
+	throughputAnomalyDetectionAlgoCmd.Flags().String(
+		"node-name",
+		"",
+		`On choosing agg-flow as node, user has option to specify node-name for throughput between Nodes, default would be all Nodes`,
+	)
+	throughputAnomalyDetectionAlgoCmd.Flags().String(
+		"metric",
+		"",
+		`Specifies which metric of the flows to perform anomaly detection on, options are throughput/reverseThroughput/packetRate/octetDelta/newConnections, default would be throughput.
+newConnections counts the connections started at each time and requires agg-flow to be specified`,
+	)
+	throughputAnomalyDetectionAlgoCmd.Flags().Bool(
+		"save-normal-points",
+		false,
+		`Saves the non-anomalous values together with the anomalies, so that the whole series and the baseline calculated by the algorithm can be plotted by retrieve --plot`,
+	)
+	throughputAnomalyDetectionAlgoCmd.Flags().String(
+		"flow-filter",
+		"",
+		`Restricts the flows to perform anomaly detection on, as a JSON object with the optional fields:
+source/destination: {"namespaceSelector": <label selector>, "podSelector": <label selector>, "cidrs": [<CIDR>]}
+ports: [{"start": <port>, "end": <port>}], destination port ranges
+protocols: TCP/UDP/SCTP/ICMP
+flowTypes: pod_to_pod/pod_to_svc/pod_to_external
+Flows must match all the given fields, default would be all flows`,
+	)
+	throughputAnomalyDetectionAlgoCmd.Flags().Float64(
+		"sensitivity",
+		0,
+		`Number of standard deviations the throughput may deviate from the value calculated by EWMA or ARIMA before it is considered anomalous, default would be 1`,
+	)
+	throughputAnomalyDetectionAlgoCmd.Flags().Float64(
+		"ewma-alpha",
+		0,
+		`Smoothing factor of EWMA, in (0, 1], default would be 0.5`,
+	)
+	throughputAnomalyDetectionAlgoCmd.Flags().IntSlice(
+		"arima-order",
+		nil,
+		`Order (p,d,q) of the ARIMA model, e.g. 2,1,0, default would be 1,1,1`,
+	)
+	throughputAnomalyDetectionAlgoCmd.Flags().Float64(
+		"dbscan-eps",
+		0,
+		`Maximum throughput distance between two samples of the same DBSCAN cluster, default would be 250000000`,
+	)
+	throughputAnomalyDetectionAlgoCmd.Flags().Int(
+		"dbscan-min-samples",
+		0,
+		`Number of samples in a neighborhood for a point to be considered as a DBSCAN core point, default would be 4`,
+	)
+	throughputAnomalyDetectionAlgoCmd.Flags().Int(
+		"quorum",
+		0,
+		`Number of algorithms which must find a throughput anomalous for the ENSEMBLE algorithm to report it, default would be 2`,
+	)
 }
@@ -21,6 +21,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -37,12 +38,19 @@ func TestAnomalyDetectionRun(t *testing.T) {
 		testServer       *httptest.Server
 		expectedMsg      []string
 		expectedErrorMsg string
+		estimateFlag     bool
 	}{
 		{
 			name: "Valid case",
 			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				switch strings.TrimSpace(r.URL.Path) {
 				case "/apis/intelligence.theia.antrea.io/v1alpha1/throughputanomalydetectors":
+					var tad anomalydetector.ThroughputAnomalyDetector
+					if err := json.NewDecoder(r.Body).Decode(&tad); err != nil || tad.ActiveDeadlineSeconds != 5400 ||
+						tad.TTLSecondsAfterFinished == nil || *tad.TTLSecondsAfterFinished != 604800 || !tad.SaveNormalPoints {
+						http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+						return
+					}
 					w.Header().Set("Content-Type", "application/json")
 					w.WriteHeader(http.StatusOK)
 				}
@@ -73,6 +81,29 @@ func TestAnomalyDetectionRun(t *testing.T) {
 			expectedMsg:      []string{},
 			expectedErrorMsg: "failed to Post Throughput Anomaly Detection job",
 		},
+		{
+			name: "Estimate case",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case "/apis/intelligence.theia.antrea.io/v1alpha1/throughputanomalydetectors":
+					if r.URL.Query().Get("dryRun") != "All" {
+						http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+						return
+					}
+					tad := &anomalydetector.ThroughputAnomalyDetector{
+						Status: anomalydetector.ThroughputAnomalyDetectorStatus{
+							InputEstimate: &anomalydetector.JobInputEstimate{Rows: 1000, Bytes: 2048},
+						},
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(tad)
+				}
+			})),
+			expectedMsg:      []string{},
+			expectedErrorMsg: "",
+			estimateFlag:     true,
+		},
 		{
 			name:             TheiaClientSetupDeniedTestCase,
 			testServer:       httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
@@ -135,11 +166,29 @@ func TestAnomalyDetectionRun(t *testing.T) {
 			cmd.Flags().String("pod-namespace", "testpodnamespace", "")
 			cmd.Flags().String("external-ip", "10.0.0.1", "")
 			cmd.Flags().String("svc-port-name", "testportname", "")
+			cmd.Flags().String("node-name", "testnodename", "")
+			cmd.Flags().String("metric", "packetRate", "")
+			cmd.Flags().Bool("save-normal-points", false, "")
+			cmd.Flags().Set("save-normal-points", "true")
+			cmd.Flags().Float64("sensitivity", 2, "")
+			cmd.Flags().Float64("ewma-alpha", 0, "")
+			cmd.Flags().IntSlice("arima-order", []int{2, 1, 0}, "")
+			cmd.Flags().Float64("dbscan-eps", 0, "")
+			cmd.Flags().Int("dbscan-min-samples", 0, "")
+			cmd.Flags().Int("quorum", 0, "")
+			cmd.Flags().String("flow-filter", `{"source":{"namespaceSelector":{"matchLabels":{"env":"prod"}}},"flowTypes":["pod_to_external"]}`, "")
 			cmd.Flags().Int32("executor-instances", 1, "")
 			cmd.Flags().String("driver-core-request", "1", "")
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 90*time.Minute, "")
+			cmd.Flags().Duration("ttl-after-finished", 0, "")
+			cmd.Flags().Set("ttl-after-finished", "168h")
+			cmd.Flags().Bool("estimate", false, "")
+			if tt.estimateFlag {
+				cmd.Flags().Set("estimate", "true")
+			}
 			if tt.name == "Valid case with args" {
 				err = throughputAnomalyDetectionAlgo(cmd, []string{"tadName"})
 			} else {
@@ -236,6 +285,14 @@ func TestThroughputAnomalyDetectionAlgo(t *testing.T) {
 			name:             "Invalid executor-memory",
 			expectedErrorMsg: "executor-memory should conform to the Kubernetes resource quantity convention",
 		},
+		{
+			name:             "Invalid timeout",
+			expectedErrorMsg: "timeout should be a duration >= 0",
+		},
+		{
+			name:             "Invalid ttl-after-finished",
+			expectedErrorMsg: "ttl-after-finished should be a duration >= 0",
+		},
 		{
 			name:             "Unspecified agg-flow",
 			expectedErrorMsg: ErrorMsgUnspecifiedCase,
@@ -264,9 +321,33 @@ func TestThroughputAnomalyDetectionAlgo(t *testing.T) {
 			name:             "Unspecified svc-port-name",
 			expectedErrorMsg: ErrorMsgUnspecifiedCase,
 		},
+		{
+			name:             "Unspecified node-name",
+			expectedErrorMsg: ErrorMsgUnspecifiedCase,
+		},
+		{
+			name:             "Unspecified pod-namespace for namespace agg-flow",
+			expectedErrorMsg: ErrorMsgUnspecifiedCase,
+		},
 		{
 			name:             "Invalid agg-flow",
-			expectedErrorMsg: "aggregated flow type should be 'pod' or 'external' or 'svc'",
+			expectedErrorMsg: "aggregated flow type should be 'pod' or 'external' or 'svc' or 'node' or 'namespace'",
+		},
+		{
+			name:             "Invalid metric",
+			expectedErrorMsg: "metric should be 'throughput' or 'reverseThroughput' or 'packetRate' or 'octetDelta' or 'newConnections'",
+		},
+		{
+			name:             "Invalid metric without agg-flow",
+			expectedErrorMsg: "metric 'newConnections' requires agg-flow to be specified",
+		},
+		{
+			name:             "Invalid arima-order",
+			expectedErrorMsg: "arima-order should have 3 elements (p, d, q)",
+		},
+		{
+			name:             "Invalid flow-filter",
+			expectedErrorMsg: "parsing flow-filter: json: unknown field \"namespaces\"",
 		},
 		{
 			name:             "Unspecified use-cluster-ip",
@@ -378,6 +459,30 @@ func TestThroughputAnomalyDetectionAlgo(t *testing.T) {
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "mock_executor-memory", "")
+		case "Invalid timeout":
+			cmd.Flags().String("algo", "ARIMA", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 16:04:05", "")
+			cmd.Flags().String("ns-ignore-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().Int32("executor-instances", 1, "")
+			cmd.Flags().String("driver-core-request", "1", "")
+			cmd.Flags().String("driver-memory", "1m", "")
+			cmd.Flags().String("executor-core-request", "1", "")
+			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", -time.Minute, "")
+		case "Invalid ttl-after-finished":
+			cmd.Flags().String("algo", "ARIMA", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 16:04:05", "")
+			cmd.Flags().String("ns-ignore-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().Int32("executor-instances", 1, "")
+			cmd.Flags().String("driver-core-request", "1", "")
+			cmd.Flags().String("driver-memory", "1m", "")
+			cmd.Flags().String("executor-core-request", "1", "")
+			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
+			cmd.Flags().Duration("ttl-after-finished", 0, "")
+			cmd.Flags().Set("ttl-after-finished", "-1m")
 		case "Unspecified agg-flow":
 			cmd.Flags().String("algo", "ARIMA", "")
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
@@ -388,6 +493,7 @@ func TestThroughputAnomalyDetectionAlgo(t *testing.T) {
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
 		case "Unspecified pod-label":
 			cmd.Flags().String("algo", "ARIMA", "")
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
@@ -398,6 +504,7 @@ func TestThroughputAnomalyDetectionAlgo(t *testing.T) {
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
 			cmd.Flags().String("agg-flow", "pod", "")
 		case "Unspecified pod-name":
 			cmd.Flags().String("algo", "ARIMA", "")
@@ -409,6 +516,7 @@ func TestThroughputAnomalyDetectionAlgo(t *testing.T) {
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
 			cmd.Flags().String("agg-flow", "pod", "")
 			cmd.Flags().String("pod-label", "mock_pod-label", "")
 		case "Unspecified pod-namespace":
@@ -421,6 +529,7 @@ func TestThroughputAnomalyDetectionAlgo(t *testing.T) {
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
 			cmd.Flags().String("agg-flow", "pod", "")
 			cmd.Flags().String("pod-label", "mock_pod_label", "")
 			cmd.Flags().String("pod-name", "mock_pod-name", "")
@@ -434,6 +543,7 @@ func TestThroughputAnomalyDetectionAlgo(t *testing.T) {
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
 			cmd.Flags().String("agg-flow", "pod", "")
 			cmd.Flags().String("pod-label", "", "")
 			cmd.Flags().String("pod-name", "", "")
@@ -448,6 +558,7 @@ func TestThroughputAnomalyDetectionAlgo(t *testing.T) {
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
 			cmd.Flags().String("agg-flow", "external", "")
 		case "Unspecified svc-port-name":
 			cmd.Flags().String("algo", "ARIMA", "")
@@ -459,7 +570,32 @@ func TestThroughputAnomalyDetectionAlgo(t *testing.T) {
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
 			cmd.Flags().String("agg-flow", "svc", "")
+		case "Unspecified node-name":
+			cmd.Flags().String("algo", "ARIMA", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 16:04:05", "")
+			cmd.Flags().String("ns-ignore-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().Int32("executor-instances", 1, "")
+			cmd.Flags().String("driver-core-request", "1", "")
+			cmd.Flags().String("driver-memory", "1m", "")
+			cmd.Flags().String("executor-core-request", "1", "")
+			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
+			cmd.Flags().String("agg-flow", "node", "")
+		case "Unspecified pod-namespace for namespace agg-flow":
+			cmd.Flags().String("algo", "ARIMA", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 16:04:05", "")
+			cmd.Flags().String("ns-ignore-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().Int32("executor-instances", 1, "")
+			cmd.Flags().String("driver-core-request", "1", "")
+			cmd.Flags().String("driver-memory", "1m", "")
+			cmd.Flags().String("executor-core-request", "1", "")
+			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
+			cmd.Flags().String("agg-flow", "namespace", "")
 		case "Invalid agg-flow":
 			cmd.Flags().String("algo", "ARIMA", "")
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
@@ -470,7 +606,29 @@ func TestThroughputAnomalyDetectionAlgo(t *testing.T) {
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
 			cmd.Flags().String("agg-flow", "mock_agg-flow", "")
+		case "Invalid flow-filter":
+			cmd.Flags().String("algo", "ARIMA", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 16:04:05", "")
+			cmd.Flags().String("ns-ignore-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().Int32("executor-instances", 1, "")
+			cmd.Flags().String("driver-core-request", "1", "")
+			cmd.Flags().String("driver-memory", "1m", "")
+			cmd.Flags().String("executor-core-request", "1", "")
+			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
+			cmd.Flags().String("agg-flow", "", "")
+			cmd.Flags().String("metric", "", "")
+			cmd.Flags().Bool("save-normal-points", false, "")
+			cmd.Flags().Float64("sensitivity", 0, "")
+			cmd.Flags().Float64("ewma-alpha", 0, "")
+			cmd.Flags().IntSlice("arima-order", nil, "")
+			cmd.Flags().Float64("dbscan-eps", 0, "")
+			cmd.Flags().Int("dbscan-min-samples", 0, "")
+			cmd.Flags().Int("quorum", 0, "")
+			cmd.Flags().String("flow-filter", `{"source":{"namespaces":["prod"]}}`, "")
 		case "Unspecified use-cluster-ip":
 			cmd.Flags().String("algo", "ARIMA", "")
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
@@ -481,8 +639,61 @@ func TestThroughputAnomalyDetectionAlgo(t *testing.T) {
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
 			cmd.Flags().String("agg-flow", "svc", "")
 			cmd.Flags().String("svc-port-name", "mock_svc_name", "")
+			cmd.Flags().String("metric", "newConnections", "")
+			cmd.Flags().Bool("save-normal-points", false, "")
+			cmd.Flags().Float64("sensitivity", 2, "")
+			cmd.Flags().Float64("ewma-alpha", 0, "")
+			cmd.Flags().IntSlice("arima-order", []int{2, 1, 0}, "")
+			cmd.Flags().Float64("dbscan-eps", 0, "")
+			cmd.Flags().Int("dbscan-min-samples", 0, "")
+			cmd.Flags().Int("quorum", 0, "")
+			cmd.Flags().String("flow-filter", "", "")
+		case "Invalid metric":
+			cmd.Flags().String("algo", "ARIMA", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 16:04:05", "")
+			cmd.Flags().String("ns-ignore-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().Int32("executor-instances", 1, "")
+			cmd.Flags().String("driver-core-request", "1", "")
+			cmd.Flags().String("driver-memory", "1m", "")
+			cmd.Flags().String("executor-core-request", "1", "")
+			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
+			cmd.Flags().String("agg-flow", "", "")
+			cmd.Flags().String("metric", "mock_metric", "")
+		case "Invalid metric without agg-flow":
+			cmd.Flags().String("algo", "ARIMA", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 16:04:05", "")
+			cmd.Flags().String("ns-ignore-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().Int32("executor-instances", 1, "")
+			cmd.Flags().String("driver-core-request", "1", "")
+			cmd.Flags().String("driver-memory", "1m", "")
+			cmd.Flags().String("executor-core-request", "1", "")
+			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
+			cmd.Flags().String("agg-flow", "", "")
+			cmd.Flags().String("metric", "newConnections", "")
+		case "Invalid arima-order":
+			cmd.Flags().String("algo", "ARIMA", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 16:04:05", "")
+			cmd.Flags().String("ns-ignore-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().Int32("executor-instances", 1, "")
+			cmd.Flags().String("driver-core-request", "1", "")
+			cmd.Flags().String("driver-memory", "1m", "")
+			cmd.Flags().String("executor-core-request", "1", "")
+			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
+			cmd.Flags().String("agg-flow", "", "")
+			cmd.Flags().String("metric", "", "")
+			cmd.Flags().Bool("save-normal-points", false, "")
+			cmd.Flags().Float64("sensitivity", 0, "")
+			cmd.Flags().Float64("ewma-alpha", 0, "")
+			cmd.Flags().IntSlice("arima-order", []int{1, 1}, "")
 		}
 		err := throughputAnomalyDetectionAlgo(cmd, []string{})
 		if tt.expectedErrorMsg == "" {
@@ -27,7 +27,9 @@ var anomalyDetectionStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Check the status of a anomaly detection job",
 	Long: `Check the current status of a anomaly detection job by name.
-It will return the status of this anomaly detection job like SUBMITTED, RUNNING, COMPLETED, or FAILED.`,
+It will return the status of this anomaly detection job like SUBMITTED, RUNNING, COMPLETED, or FAILED.
+For a running job, it also reports the elapsed time, the estimated remaining time,
+the current stage and the number of active and failed executors.`,
 	Args: cobra.RangeArgs(0, 1),
 	Example: `
 Check the current status of job with name tad-e998433e-accb-4888-9fc8-06563f073e86
@@ -91,6 +93,12 @@ func anomalyDetectionStatus(cmd *cobra.Command, args []string) error {
 	}
 	errorMessage := tad.Status.ErrorMsg
 	fmt.Printf("Status of this anomaly detection job is %s\n", state)
+	if tad.Status.State == "RUNNING" {
+		printJobProgress(tad.Status.StartTime, tad.Status.Progress)
+	}
+	if tad.Status.InputEstimate != nil {
+		printJobInputEstimate(tad.Status.InputEstimate)
+	}
 	if errorMessage != "" {
 		fmt.Printf("Error message: %s\n", errorMessage)
 	}
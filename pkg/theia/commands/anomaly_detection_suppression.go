@@ -0,0 +1,347 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+)
+
+// anomalySuppressionCmd represents the anomaly suppression command group
+var anomalySuppressionCmd = &cobra.Command{
+	Use:     "suppression",
+	Aliases: []string{"sup"},
+	Short:   "Commands of throughput anomaly suppressions",
+	Long: `Command group of throughput anomaly suppressions. Anomalies matched by a
+suppression are filtered out of the results and the alerts of all the
+throughput anomaly detection jobs.
+	Must specify a subcommand like create, list or delete`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("Error: Must also specify a subcommand like create, list or delete")
+	},
+}
+
+// anomalySuppressionCreateCmd represents the anomaly suppression create command
+var anomalySuppressionCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Mark throughput anomalies as expected",
+	Long: `Mark the throughput anomalies of an entity, within a time window or both
+as expected. Unspecified entity fields match any anomaly.`,
+	Example: `
+Suppress the anomalies of Pod default/backup between 02:00 and 03:00 UTC every day for 30 days
+$ theia throughput-anomaly-detection suppression create --agg-type pod --pod-namespace default --pod-name backup --daily-start-time 02:00 --daily-end-time 03:00 --expire-in 720h --reason "nightly backup"
+Suppress all the anomalies during a planned maintenance
+$ theia throughput-anomaly-detection suppression create --start-time 2022-01-01T00:00:00 --end-time 2022-01-01T04:00:00 --reason maintenance
+Suppress a single anomaly of Service default/web:http found at 2022-01-01T08:30:00
+$ theia throughput-anomaly-detection suppression create --agg-type svc --service-port-name default/web:http --start-time 2022-01-01T08:30:00 --end-time 2022-01-01T08:30:00
+`,
+	RunE: anomalySuppressionCreate,
+}
+
+// anomalySuppressionListCmd represents the anomaly suppression list command
+var anomalySuppressionListCmd = &cobra.Command{
+	Use:     "list",
+	Short:   "List all throughput anomaly suppressions",
+	Aliases: []string{"ls"},
+	Example: `
+List all throughput anomaly suppressions
+$ theia throughput-anomaly-detection suppression list
+`,
+	RunE: anomalySuppressionList,
+}
+
+// anomalySuppressionDeleteCmd represents the anomaly suppression delete command
+var anomalySuppressionDeleteCmd = &cobra.Command{
+	Use:     "delete",
+	Short:   "Delete throughput anomaly suppressions",
+	Long:    `Delete throughput anomaly suppressions by Name.`,
+	Aliases: []string{"del"},
+	Args:    cobra.RangeArgs(0, 1),
+	Example: `
+Delete the throughput anomaly suppression with Name as-e998433e-accb-4888-9fc8-06563f073e86
+$ theia throughput-anomaly-detection suppression delete as-e998433e-accb-4888-9fc8-06563f073e86
+`,
+	RunE: anomalySuppressionDelete,
+}
+
+// anomalySuppressionEntityFlags maps the flags selecting the entity of the
+// suppressed anomalies to the AnomalySuppression fields.
+var anomalySuppressionEntityFlags = []struct {
+	name  string
+	usage string
+	field func(*intelligence.AnomalySuppression) *string
+}{
+	{"agg-type", "Aggregated flow type of the suppressed anomalies, among None, pod, external, svc, node and namespace.", func(s *intelligence.AnomalySuppression) *string { return &s.AggType }},
+	{"source-ip", "Source IP of the suppressed anomalies.", func(s *intelligence.AnomalySuppression) *string { return &s.SourceIP }},
+	{"destination-ip", "Destination IP, or external IP, of the suppressed anomalies.", func(s *intelligence.AnomalySuppression) *string { return &s.DestinationIP }},
+	{"pod-namespace", "Pod Namespace of the suppressed anomalies.", func(s *intelligence.AnomalySuppression) *string { return &s.PodNamespace }},
+	{"pod-labels", "Pod labels of the suppressed anomalies, as shown in the results of the job.", func(s *intelligence.AnomalySuppression) *string { return &s.PodLabels }},
+	{"pod-name", "Pod name of the suppressed anomalies.", func(s *intelligence.AnomalySuppression) *string { return &s.PodName }},
+	{"direction", "Direction of the suppressed anomalies, inbound or outbound.", func(s *intelligence.AnomalySuppression) *string { return &s.Direction }},
+	{"service-port-name", "Service port name of the suppressed anomalies.", func(s *intelligence.AnomalySuppression) *string { return &s.DestinationServicePortName }},
+	{"source-node-name", "Source Node name of the suppressed anomalies.", func(s *intelligence.AnomalySuppression) *string { return &s.SourceNodeName }},
+	{"destination-node-name", "Destination Node name of the suppressed anomalies.", func(s *intelligence.AnomalySuppression) *string { return &s.DestinationNodeName }},
+}
+
+func anomalySuppressionCreate(cmd *cobra.Command, args []string) error {
+	suppression := intelligence.AnomalySuppression{}
+	name, err := cmd.Flags().GetString("name")
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = "as-" + uuid.New().String()
+	}
+	suppression.Name = name
+	for _, flag := range anomalySuppressionEntityFlags {
+		value, err := cmd.Flags().GetString(flag.name)
+		if err != nil {
+			return err
+		}
+		*flag.field(&suppression) = value
+	}
+	for _, flag := range []struct {
+		name  string
+		field *metav1.Time
+	}{
+		{"start-time", &suppression.StartTime},
+		{"end-time", &suppression.EndTime},
+	} {
+		value, err := cmd.Flags().GetString(flag.name)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+		timeObj, err := time.Parse("2006-01-02 15:04:05", strings.Replace(value, "T", " ", 1))
+		if err != nil {
+			return fmt.Errorf(`parsing %s: %v, %s should be in 
+'YYYY-MM-DDThh:mm:ss' format, for example: 2006-01-02T15:04:05`, flag.name, err, flag.name)
+		}
+		*flag.field = metav1.NewTime(timeObj)
+	}
+	suppression.DailyStartTime, err = cmd.Flags().GetString("daily-start-time")
+	if err != nil {
+		return err
+	}
+	suppression.DailyEndTime, err = cmd.Flags().GetString("daily-end-time")
+	if err != nil {
+		return err
+	}
+	expireIn, err := cmd.Flags().GetDuration("expire-in")
+	if err != nil {
+		return err
+	}
+	if expireIn < 0 {
+		return fmt.Errorf("expire-in should not be negative")
+	}
+	if expireIn > 0 {
+		suppression.ExpirationTime = metav1.NewTime(time.Now().Add(expireIn).Truncate(time.Second))
+	}
+	suppression.Reason, err = cmd.Flags().GetString("reason")
+	if err != nil {
+		return err
+	}
+	useClusterIP, err := cmd.Flags().GetBool("use-cluster-ip")
+	if err != nil {
+		return err
+	}
+	theiaClient, pf, err := SetupTheiaClientAndConnection(cmd, useClusterIP)
+	if err != nil {
+		return fmt.Errorf("couldn't setup Theia manager client, %v", err)
+	}
+	if pf != nil {
+		defer pf.Stop()
+	}
+	err = theiaClient.Post().
+		AbsPath("/apis/intelligence.theia.antrea.io/v1alpha1/").
+		Resource("anomalysuppressions").
+		Body(&suppression).
+		Do(context.TODO()).
+		Error()
+	if err != nil {
+		return fmt.Errorf("failed to post throughput anomaly suppression: %v", err)
+	}
+	fmt.Printf("Successfully created throughput anomaly suppression with name: %s\n", suppression.Name)
+	return nil
+}
+
+func anomalySuppressionList(cmd *cobra.Command, args []string) error {
+	useClusterIP, err := cmd.Flags().GetBool("use-cluster-ip")
+	if err != nil {
+		return err
+	}
+	theiaClient, pf, err := SetupTheiaClientAndConnection(cmd, useClusterIP)
+	if err != nil {
+		return fmt.Errorf("couldn't setup Theia manager client, %v", err)
+	}
+	if pf != nil {
+		defer pf.Stop()
+	}
+	suppressionList := &intelligence.AnomalySuppressionList{}
+	err = theiaClient.Get().
+		AbsPath("/apis/intelligence.theia.antrea.io/v1alpha1/").
+		Resource("anomalysuppressions").
+		Do(context.TODO()).Into(suppressionList)
+	if err != nil {
+		return fmt.Errorf("error when getting throughput anomaly suppression list: %v", err)
+	}
+
+	suppressionTable := [][]string{
+		{"Name", "Anomalies", "Window", "Expiration", "Reason"},
+	}
+	for _, suppression := range suppressionList.Items {
+		suppressionTable = append(suppressionTable,
+			[]string{
+				suppression.Name,
+				describeSuppressedAnomalies(suppression),
+				describeSuppressionWindow(suppression),
+				FormatTimestamp(suppression.ExpirationTime.Time),
+				suppression.Reason,
+			})
+	}
+	TableOutput(suppressionTable)
+	return nil
+}
+
+func anomalySuppressionDelete(cmd *cobra.Command, args []string) error {
+	name, err := cmd.Flags().GetString("name")
+	if err != nil {
+		return err
+	}
+	if name == "" && len(args) == 1 {
+		name = args[0]
+	}
+	if name == "" {
+		return fmt.Errorf("name of the throughput anomaly suppression should be specified")
+	}
+	useClusterIP, err := cmd.Flags().GetBool("use-cluster-ip")
+	if err != nil {
+		return err
+	}
+	theiaClient, pf, err := SetupTheiaClientAndConnection(cmd, useClusterIP)
+	if err != nil {
+		return fmt.Errorf("couldn't setup Theia manager client, %v", err)
+	}
+	if pf != nil {
+		defer pf.Stop()
+	}
+	for _, name := range strings.Fields(name) {
+		err = theiaClient.Delete().
+			AbsPath("/apis/intelligence.theia.antrea.io/v1alpha1/").
+			Resource("anomalysuppressions").
+			Name(name).
+			Do(context.TODO()).
+			Error()
+		if err != nil {
+			return fmt.Errorf("error when deleting throughput anomaly suppression: %v", err)
+		}
+		fmt.Printf("Successfully deleted throughput anomaly suppression with name: %s\n", name)
+	}
+	return nil
+}
+
+// describeSuppressedAnomalies returns the entity fields set in the
+// suppression, or "all" if it matches the anomalies of any entity.
+func describeSuppressedAnomalies(suppression intelligence.AnomalySuppression) string {
+	var fields []string
+	for _, flag := range anomalySuppressionEntityFlags {
+		if value := *flag.field(&suppression); value != "" {
+			fields = append(fields, fmt.Sprintf("%s=%s", flag.name, value))
+		}
+	}
+	if len(fields) == 0 {
+		return "all"
+	}
+	return strings.Join(fields, ",")
+}
+
+// describeSuppressionWindow returns the time window of the suppressed
+// anomalies, or "always" if the suppression has no time condition.
+func describeSuppressionWindow(suppression intelligence.AnomalySuppression) string {
+	var windows []string
+	if !suppression.StartTime.IsZero() || !suppression.EndTime.IsZero() {
+		windows = append(windows, fmt.Sprintf("%s - %s", FormatTimestamp(suppression.StartTime.Time), FormatTimestamp(suppression.EndTime.Time)))
+	}
+	if suppression.DailyStartTime != "" {
+		windows = append(windows, fmt.Sprintf("daily %s - %s UTC", suppression.DailyStartTime, suppression.DailyEndTime))
+	}
+	if len(windows) == 0 {
+		return "always"
+	}
+	return strings.Join(windows, ", ")
+}
+
+func init() {
+	throughputanomalyDetectionCmd.AddCommand(anomalySuppressionCmd)
+	anomalySuppressionCmd.AddCommand(anomalySuppressionCreateCmd)
+	anomalySuppressionCmd.AddCommand(anomalySuppressionListCmd)
+	anomalySuppressionCmd.AddCommand(anomalySuppressionDeleteCmd)
+
+	anomalySuppressionCreateCmd.Flags().String(
+		"name",
+		"",
+		"Name of the suppression. A name is generated if not specified.",
+	)
+	for _, flag := range anomalySuppressionEntityFlags {
+		anomalySuppressionCreateCmd.Flags().String(flag.name, "", flag.usage)
+	}
+	anomalySuppressionCreateCmd.Flags().String(
+		"start-time",
+		"",
+		`The start time of the suppressed anomalies, in UTC.
+Format is YYYY-MM-DDThh:mm:ss in UTC timezone.`,
+	)
+	anomalySuppressionCreateCmd.Flags().String(
+		"end-time",
+		"",
+		`The end time of the suppressed anomalies, in UTC.
+Format is YYYY-MM-DDThh:mm:ss in UTC timezone.`,
+	)
+	anomalySuppressionCreateCmd.Flags().String(
+		"daily-start-time",
+		"",
+		"The start of the daily window of the suppressed anomalies, in HH:MM format and UTC.",
+	)
+	anomalySuppressionCreateCmd.Flags().String(
+		"daily-end-time",
+		"",
+		"The end of the daily window of the suppressed anomalies, in HH:MM format and UTC. It may be before daily-start-time for a window spanning midnight.",
+	)
+	anomalySuppressionCreateCmd.Flags().Duration(
+		"expire-in",
+		0,
+		"Delete the suppression after this duration, e.g. 720h. It never expires if not specified.",
+	)
+	anomalySuppressionCreateCmd.Flags().String(
+		"reason",
+		"",
+		"Why the anomalies are expected.",
+	)
+	anomalySuppressionDeleteCmd.Flags().String(
+		"name",
+		"",
+		"Name of the suppression.",
+	)
+}
@@ -0,0 +1,312 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+	"antrea.io/theia/pkg/theia/portforwarder"
+)
+
+func setupTheiaClientWithServer(t *testing.T, testServer *httptest.Server, denied bool) {
+	oldFunc := SetupTheiaClientAndConnection
+	if denied {
+		SetupTheiaClientAndConnection = func(cmd *cobra.Command, useClusterIP bool) (restclient.Interface, *portforwarder.PortForwarder, error) {
+			return nil, nil, errors.New("mock_error")
+		}
+	} else {
+		SetupTheiaClientAndConnection = func(cmd *cobra.Command, useClusterIP bool) (restclient.Interface, *portforwarder.PortForwarder, error) {
+			clientConfig := &restclient.Config{Host: testServer.URL, TLSClientConfig: restclient.TLSClientConfig{Insecure: true}}
+			clientset, _ := kubernetes.NewForConfig(clientConfig)
+			return clientset.CoreV1().RESTClient(), nil, nil
+		}
+	}
+	t.Cleanup(func() {
+		SetupTheiaClientAndConnection = oldFunc
+	})
+}
+
+func TestAnomalySuppressionCreate(t *testing.T) {
+	testCases := []struct {
+		name                string
+		flags               map[string]string
+		expectedSuppression *intelligence.AnomalySuppression
+		expectedErrorMsg    string
+	}{
+		{
+			name: "Valid daily window case",
+			flags: map[string]string{
+				"name":             "backup",
+				"agg-type":         "pod",
+				"pod-namespace":    "default",
+				"pod-name":         "backup",
+				"daily-start-time": "02:00",
+				"daily-end-time":   "03:00",
+				"reason":           "nightly backup",
+			},
+			expectedSuppression: &intelligence.AnomalySuppression{
+				ObjectMeta:     metav1.ObjectMeta{Name: "backup"},
+				AggType:        "pod",
+				PodNamespace:   "default",
+				PodName:        "backup",
+				DailyStartTime: "02:00",
+				DailyEndTime:   "03:00",
+				Reason:         "nightly backup",
+			},
+		},
+		{
+			name: "Valid maintenance window case",
+			flags: map[string]string{
+				"name":       "maintenance",
+				"start-time": "2022-01-01T00:00:00",
+				"end-time":   "2022-01-01T04:00:00",
+			},
+			expectedSuppression: &intelligence.AnomalySuppression{
+				ObjectMeta: metav1.ObjectMeta{Name: "maintenance"},
+				// Times are decoded in the local timezone by the test server.
+				StartTime: metav1.NewTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).Local()),
+				EndTime:   metav1.NewTime(time.Date(2022, 1, 1, 4, 0, 0, 0, time.UTC).Local()),
+			},
+		},
+		{
+			name: "Invalid start-time",
+			flags: map[string]string{
+				"start-time": "2022-01-01",
+			},
+			expectedErrorMsg: "start-time should be in 'YYYY-MM-DDThh:mm:ss' format",
+		},
+		{
+			name: "Negative expire-in",
+			flags: map[string]string{
+				"pod-name":  "backup",
+				"expire-in": "-1h",
+			},
+			expectedErrorMsg: "expire-in should not be negative",
+		},
+		{
+			name:             "Unspecified name",
+			expectedErrorMsg: ErrorMsgUnspecifiedCase,
+		},
+		{
+			name:             TheiaClientSetupDeniedTestCase,
+			flags:            map[string]string{"pod-name": "backup"},
+			expectedErrorMsg: TheiaClientSetupDeniedErr,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var posted *intelligence.AnomalySuppression
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.TrimSpace(r.URL.Path) == "/apis/intelligence.theia.antrea.io/v1alpha1/anomalysuppressions" && r.Method == "POST" {
+					posted = &intelligence.AnomalySuppression{}
+					json.NewDecoder(r.Body).Decode(posted)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+				}
+			}))
+			defer testServer.Close()
+			setupTheiaClientWithServer(t, testServer, tt.name == TheiaClientSetupDeniedTestCase)
+			cmd := new(cobra.Command)
+			if tt.name != "Unspecified name" {
+				cmd.Flags().String("name", "", "")
+			}
+			for _, flag := range anomalySuppressionEntityFlags {
+				cmd.Flags().String(flag.name, "", "")
+			}
+			for _, flag := range []string{"start-time", "end-time", "daily-start-time", "daily-end-time"} {
+				cmd.Flags().String(flag, "", "")
+			}
+			cmd.Flags().Duration("expire-in", 0, "")
+			cmd.Flags().String("reason", "", "")
+			cmd.Flags().Bool("use-cluster-ip", true, "")
+			for flag, value := range tt.flags {
+				require.NoError(t, cmd.Flags().Set(flag, value))
+			}
+			err := anomalySuppressionCreate(cmd, []string{})
+			if tt.expectedErrorMsg == "" {
+				assert.NoError(t, err)
+				require.NotNil(t, posted)
+				assert.Equal(t, tt.expectedSuppression.Name, posted.Name)
+				posted.ObjectMeta = tt.expectedSuppression.ObjectMeta
+				assert.Equal(t, tt.expectedSuppression, posted)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, strings.Join(strings.Fields(err.Error()), " "), tt.expectedErrorMsg)
+			}
+		})
+	}
+}
+
+func TestAnomalySuppressionList(t *testing.T) {
+	testCases := []struct {
+		name             string
+		testServer       *httptest.Server
+		expectedMsg      []string
+		expectedErrorMsg string
+	}{
+		{
+			name: "Valid case",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case "/apis/intelligence.theia.antrea.io/v1alpha1/anomalysuppressions":
+					suppressionList := &intelligence.AnomalySuppressionList{
+						Items: []intelligence.AnomalySuppression{
+							{
+								ObjectMeta:     metav1.ObjectMeta{Name: "backup"},
+								PodNamespace:   "default",
+								PodName:        "backup",
+								DailyStartTime: "02:00",
+								DailyEndTime:   "03:00",
+								ExpirationTime: metav1.NewTime(time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)),
+								Reason:         "nightly backup",
+							},
+							{
+								ObjectMeta: metav1.ObjectMeta{Name: "maintenance"},
+								StartTime:  metav1.NewTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)),
+								EndTime:    metav1.NewTime(time.Date(2022, 1, 1, 4, 0, 0, 0, time.UTC)),
+							},
+						},
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(suppressionList)
+				}
+			})),
+			expectedMsg: []string{
+				"pod-namespace=default,pod-name=backup",
+				"daily 02:00 - 03:00 UTC",
+				"2022-02-01 00:00:00",
+				"nightly backup",
+				"all",
+				"2022-01-01 00:00:00 - 2022-01-01 04:00:00",
+			},
+		},
+		{
+			name: "AnomalySuppressionList not found",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			})),
+			expectedErrorMsg: "error when getting throughput anomaly suppression list:",
+		},
+		{
+			name:             "Unspecified use-cluster-ip",
+			testServer:       httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+			expectedErrorMsg: ErrorMsgUnspecifiedCase,
+		},
+		{
+			name:             TheiaClientSetupDeniedTestCase,
+			testServer:       httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+			expectedErrorMsg: TheiaClientSetupDeniedErr,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.testServer.Close()
+			setupTheiaClientWithServer(t, tt.testServer, tt.name == TheiaClientSetupDeniedTestCase)
+			cmd := new(cobra.Command)
+			if tt.name != "Unspecified use-cluster-ip" {
+				cmd.Flags().Bool("use-cluster-ip", true, "")
+			}
+
+			orig := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			defer func() { os.Stdout = orig }()
+			err := anomalySuppressionList(cmd, []string{})
+			if tt.expectedErrorMsg == "" {
+				assert.NoError(t, err)
+				outcome := readStdout(t, r, w)
+				for _, msg := range tt.expectedMsg {
+					assert.Contains(t, outcome, msg)
+				}
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErrorMsg)
+			}
+		})
+	}
+}
+
+func TestAnomalySuppressionDelete(t *testing.T) {
+	testCases := []struct {
+		name             string
+		args             []string
+		testServer       *httptest.Server
+		expectedErrorMsg string
+	}{
+		{
+			name: "Valid case",
+			args: []string{"backup"},
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.TrimSpace(r.URL.Path) == "/apis/intelligence.theia.antrea.io/v1alpha1/anomalysuppressions/backup" && r.Method == "DELETE" {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+				} else {
+					http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+				}
+			})),
+		},
+		{
+			name: "AnomalySuppression not found",
+			args: []string{"backup"},
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			})),
+			expectedErrorMsg: "error when deleting throughput anomaly suppression",
+		},
+		{
+			name:             "Missing name",
+			testServer:       httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+			expectedErrorMsg: "name of the throughput anomaly suppression should be specified",
+		},
+		{
+			name:             TheiaClientSetupDeniedTestCase,
+			args:             []string{"backup"},
+			testServer:       httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+			expectedErrorMsg: TheiaClientSetupDeniedErr,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.testServer.Close()
+			setupTheiaClientWithServer(t, tt.testServer, tt.name == TheiaClientSetupDeniedTestCase)
+			cmd := new(cobra.Command)
+			cmd.Flags().String("name", "", "")
+			cmd.Flags().Bool("use-cluster-ip", true, "")
+			err := anomalySuppressionDelete(cmd, tt.args)
+			if tt.expectedErrorMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErrorMsg)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"antrea.io/theia/pkg/util"
+)
+
+// jobKind describes a kind of job run by Theia Manager, for the commands
+// which are shared by all the kinds of jobs.
+type jobKind struct {
+	// description names the jobs in help and error messages.
+	description string
+	// command is the theia command of the jobs.
+	command string
+	// exampleName is the name of a job used in examples.
+	exampleName string
+	// validateName returns an error if the name of a job is invalid.
+	validateName func(name string) error
+}
+
+var (
+	anomalyDetectionJob = jobKind{
+		description:  "throughput anomaly detection",
+		command:      "throughput-anomaly-detection",
+		exampleName:  "tad-e998433e-accb-4888-9fc8-06563f073e86",
+		validateName: util.ParseADAlgorithmID,
+	}
+	policyRecommendationJob = jobKind{
+		description:  "policy recommendation",
+		command:      "policy-recommendation",
+		exampleName:  "pr-e998433e-accb-4888-9fc8-06563f073e86",
+		validateName: util.ParseRecommendationName,
+	}
+)
+
+func init() {
+	throughputanomalyDetectionCmd.AddCommand(newJobLogsCommand("throughputanomalydetectors", anomalyDetectionJob))
+	policyRecommendationCmd.AddCommand(newJobLogsCommand("networkpolicyrecommendations", policyRecommendationJob))
+}
+
+// newJobLogsCommand returns the logs command of the jobs of the given
+// resource.
+func newJobLogsCommand(resource string, kind jobKind) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: fmt.Sprintf("Print the Spark logs of a %s job", kind.description),
+		Long: fmt.Sprintf(`Print the container logs of the Spark driver of a %s job,
+or of one of its executors. The logs are available until the Spark
+Application of the job is removed, which happens when the job completes.`, kind.description),
+		Args: cobra.RangeArgs(0, 1),
+		Example: fmt.Sprintf(`
+Print the driver logs of job with name %[2]s
+$ theia %[1]s logs --name %[2]s
+Or
+$ theia %[1]s logs %[2]s
+Stream the logs of executor 1 of the job until it terminates
+$ theia %[1]s logs %[2]s --executor 1 --follow
+`, kind.command, kind.exampleName),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return jobLogs(cmd, args, resource, kind)
+		},
+	}
+	cmd.Flags().StringP(
+		"name",
+		"",
+		"",
+		fmt.Sprintf("Name of the %s job.", kind.description),
+	)
+	cmd.Flags().BoolP(
+		"follow",
+		"f",
+		false,
+		"Stream the logs until the Pod terminates.",
+	)
+	cmd.Flags().Int(
+		"executor",
+		0,
+		"ID of the executor whose logs are printed. The driver logs are printed if it is 0.",
+	)
+	return cmd
+}
+
+func jobLogs(cmd *cobra.Command, args []string, resource string, kind jobKind) error {
+	name, err := cmd.Flags().GetString("name")
+	if err != nil {
+		return err
+	}
+	if name == "" && len(args) == 1 {
+		name = args[0]
+	}
+	err = kind.validateName(name)
+	if err != nil {
+		return err
+	}
+	follow, err := cmd.Flags().GetBool("follow")
+	if err != nil {
+		return err
+	}
+	executor, err := cmd.Flags().GetInt("executor")
+	if err != nil {
+		return err
+	}
+	if executor < 0 {
+		return fmt.Errorf("executor should be an integer >= 0")
+	}
+	useClusterIP, err := cmd.Flags().GetBool("use-cluster-ip")
+	if err != nil {
+		return err
+	}
+	theiaClient, pf, err := SetupTheiaClientAndConnection(cmd, useClusterIP)
+	if err != nil {
+		return fmt.Errorf("couldn't setup Theia manager client, %v", err)
+	}
+	if pf != nil {
+		defer pf.Stop()
+	}
+	err = streamJobLogs(theiaClient, resource, name, follow, executor, os.Stdout)
+	if err != nil {
+		return fmt.Errorf("error when getting the logs of %s job: %v", kind.description, err)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+
+	"antrea.io/theia/pkg/theia/portforwarder"
+)
+
+func TestJobLogs(t *testing.T) {
+	jobs := []struct {
+		resource            string
+		kind                jobKind
+		jobName             string
+		invalidNameErrorMsg string
+	}{
+		{
+			resource:            "throughputanomalydetectors",
+			kind:                anomalyDetectionJob,
+			jobName:             tadName,
+			invalidNameErrorMsg: "not a valid Throughput Anomaly Detection job name",
+		},
+		{
+			resource:            "networkpolicyrecommendations",
+			kind:                policyRecommendationJob,
+			jobName:             nprName,
+			invalidNameErrorMsg: "not a valid policy recommendation job name",
+		},
+	}
+	testCases := []struct {
+		name             string
+		handler          func(logsPath string) http.HandlerFunc
+		invalidName      bool
+		follow           bool
+		executor         int
+		expectedMsg      string
+		expectedErrorMsg string
+	}{
+		{
+			name: "Valid case",
+			handler: func(logsPath string) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					if strings.TrimSpace(r.URL.Path) == logsPath && r.URL.Query().Get("follow") == "false" && r.URL.Query().Get("executor") == "0" {
+						w.Header().Set("Content-Type", "text/plain")
+						w.WriteHeader(http.StatusOK)
+						w.Write([]byte("driver logs\n"))
+					}
+				}
+			},
+			expectedMsg: "driver logs\n",
+		},
+		{
+			name: "Valid case with executor and follow",
+			handler: func(logsPath string) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					if strings.TrimSpace(r.URL.Path) == logsPath && r.URL.Query().Get("follow") == "true" && r.URL.Query().Get("executor") == "2" {
+						w.Header().Set("Content-Type", "text/plain")
+						w.WriteHeader(http.StatusOK)
+						w.Write([]byte("executor logs\n"))
+					}
+				}
+			},
+			follow:      true,
+			executor:    2,
+			expectedMsg: "executor logs\n",
+		},
+		{
+			name: "Spark Pod not found",
+			handler: func(logsPath string) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				}
+			},
+			expectedErrorMsg: "error when getting the logs of",
+		},
+		{
+			name:             "Invalid executor",
+			executor:         -1,
+			expectedErrorMsg: "executor should be an integer >= 0",
+		},
+		{
+			name:        "Invalid job name",
+			invalidName: true,
+		},
+		{
+			name:             TheiaClientSetupDeniedTestCase,
+			expectedErrorMsg: TheiaClientSetupDeniedErr,
+		},
+	}
+	for _, job := range jobs {
+		for _, tt := range testCases {
+			t.Run(job.resource+"/"+tt.name, func(t *testing.T) {
+				logsPath := fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/%s/%s/log", job.resource, job.jobName)
+				handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+				if tt.handler != nil {
+					handler = tt.handler(logsPath)
+				}
+				testServer := httptest.NewServer(handler)
+				defer testServer.Close()
+				oldFunc := SetupTheiaClientAndConnection
+				if tt.name == TheiaClientSetupDeniedTestCase {
+					SetupTheiaClientAndConnection = func(cmd *cobra.Command, useClusterIP bool) (restclient.Interface, *portforwarder.PortForwarder, error) {
+						return nil, nil, errors.New("mock_error")
+					}
+				} else {
+					SetupTheiaClientAndConnection = func(cmd *cobra.Command, useClusterIP bool) (restclient.Interface, *portforwarder.PortForwarder, error) {
+						clientConfig := &restclient.Config{Host: testServer.URL, TLSClientConfig: restclient.TLSClientConfig{Insecure: true}}
+						clientset, _ := kubernetes.NewForConfig(clientConfig)
+						return clientset.CoreV1().RESTClient(), nil, nil
+					}
+				}
+				defer func() {
+					SetupTheiaClientAndConnection = oldFunc
+				}()
+				jobName, expectedErrorMsg := job.jobName, tt.expectedErrorMsg
+				if tt.invalidName {
+					jobName, expectedErrorMsg = "mock_name", job.invalidNameErrorMsg
+				}
+				cmd := new(cobra.Command)
+				cmd.Flags().String("name", jobName, "")
+				cmd.Flags().Bool("follow", tt.follow, "")
+				cmd.Flags().Int("executor", tt.executor, "")
+				cmd.Flags().Bool("use-cluster-ip", true, "")
+
+				orig := os.Stdout
+				r, w, _ := os.Pipe()
+				os.Stdout = w
+				defer func() { os.Stdout = orig }()
+				err := newJobLogsCommand(job.resource, job.kind).RunE(cmd, []string{})
+				if expectedErrorMsg == "" {
+					assert.NoError(t, err)
+					assert.Equal(t, tt.expectedMsg, readStdout(t, r, w))
+				} else {
+					assert.Error(t, err)
+					assert.Contains(t, err.Error(), expectedErrorMsg)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,389 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	restclient "k8s.io/client-go/rest"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	"antrea.io/theia/pkg/util"
+	policyutil "antrea.io/theia/pkg/util/policy"
+)
+
+// policyRecommendationCompareCmd represents the policy-recommendation compare command
+var policyRecommendationCompareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Compare the results of two policy recommendation jobs",
+	Long: `Compare the results of two completed policy recommendation jobs.
+Policies are matched by name, or by kind, Namespace and appliedTo when their
+names differ. It prints the policies which are only recommended by one of the
+jobs, and the rules which are added, removed or modified in matched policies.`,
+	Args: cobra.ExactArgs(2),
+	Example: `
+Compare the result of job pr-e998433e-accb-4888-9fc8-06563f073e86 with the newer job pr-8fe5cc2e-1a73-4e2c-a6ee-e8d9bd38e4a3
+$ theia policy-recommendation compare pr-e998433e-accb-4888-9fc8-06563f073e86 pr-8fe5cc2e-1a73-4e2c-a6ee-e8d9bd38e4a3
+Print the differences in JSON format
+$ theia policy-recommendation compare pr-e998433e-accb-4888-9fc8-06563f073e86 pr-8fe5cc2e-1a73-4e2c-a6ee-e8d9bd38e4a3 --output json
+`,
+	RunE: policyRecommendationCompare,
+}
+
+// ruleSummary describes a rule of a recommended policy with its direction,
+// action, peers and ports.
+type ruleSummary struct {
+	Direction string   `json:"direction"`
+	Action    string   `json:"action"`
+	Peers     []string `json:"peers"`
+	Ports     []string `json:"ports,omitempty"`
+}
+
+type policySummary struct {
+	Kind      string        `json:"kind"`
+	Name      string        `json:"name"`
+	Namespace string        `json:"namespace,omitempty"`
+	AppliedTo string        `json:"appliedTo"`
+	Rules     []ruleSummary `json:"rules,omitempty"`
+}
+
+type ruleChange struct {
+	Old ruleSummary `json:"old"`
+	New ruleSummary `json:"new"`
+}
+
+type policyDiff struct {
+	Kind          string        `json:"kind"`
+	OldName       string        `json:"oldName"`
+	NewName       string        `json:"newName"`
+	Namespace     string        `json:"namespace,omitempty"`
+	AppliedTo     string        `json:"appliedTo"`
+	AddedRules    []ruleSummary `json:"addedRules,omitempty"`
+	RemovedRules  []ruleSummary `json:"removedRules,omitempty"`
+	ModifiedRules []ruleChange  `json:"modifiedRules,omitempty"`
+}
+
+type recommendationComparison struct {
+	Old              string          `json:"old"`
+	New              string          `json:"new"`
+	AddedPolicies    []policySummary `json:"addedPolicies,omitempty"`
+	RemovedPolicies  []policySummary `json:"removedPolicies,omitempty"`
+	ModifiedPolicies []policyDiff    `json:"modifiedPolicies,omitempty"`
+}
+
+func init() {
+	policyRecommendationCmd.AddCommand(policyRecommendationCompareCmd)
+	policyRecommendationCompareCmd.Flags().StringP(
+		"output",
+		"o",
+		"text",
+		"Output format of the differences, text or json.",
+	)
+}
+
+func policyRecommendationCompare(cmd *cobra.Command, args []string) error {
+	for _, prName := range args {
+		if err := util.ParseRecommendationName(prName); err != nil {
+			return err
+		}
+	}
+	output, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return err
+	}
+	if output != "text" && output != "json" {
+		return fmt.Errorf("output should be text or json")
+	}
+	useClusterIP, err := cmd.Flags().GetBool("use-cluster-ip")
+	if err != nil {
+		return err
+	}
+	theiaClient, pf, err := SetupTheiaClientAndConnection(cmd, useClusterIP)
+	if err != nil {
+		return fmt.Errorf("couldn't setup Theia manager client, %v", err)
+	}
+	if pf != nil {
+		defer pf.Stop()
+	}
+	oldPolicies, err := getRecommendedPolicySummaries(theiaClient, args[0])
+	if err != nil {
+		return err
+	}
+	newPolicies, err := getRecommendedPolicySummaries(theiaClient, args[1])
+	if err != nil {
+		return err
+	}
+	comparison := comparePolicies(oldPolicies, newPolicies)
+	comparison.Old, comparison.New = args[0], args[1]
+	if output == "json" {
+		data, _ := json.MarshalIndent(comparison, "", " ")
+		fmt.Println(string(data))
+		return nil
+	}
+	printComparison(comparison)
+	return nil
+}
+
+func getRecommendedPolicySummaries(theiaClient restclient.Interface, prName string) ([]policySummary, error) {
+	npr, err := getPolicyRecommendationByName(theiaClient, prName)
+	if err != nil {
+		return nil, fmt.Errorf("error when getting policy recommendation job by job name: %v", err)
+	}
+	if npr.Status.State != crdv1alpha1.NPRecommendationStateCompleted {
+		return nil, fmt.Errorf("policy recommendation job %s is not completed, current state: %s", prName, npr.Status.State)
+	}
+	policies, err := summarizePolicies(npr.Status.RecommendationOutcome)
+	if err != nil {
+		return nil, fmt.Errorf("error when parsing the result of policy recommendation job %s: %v", prName, err)
+	}
+	return policies, nil
+}
+
+// summarizePolicies parses the YAML of recommended policies separated by
+// "---". ClusterGroups are not summarized, they are described as the Services
+// they select in the rules referring to them.
+func summarizePolicies(outcome string) ([]policySummary, error) {
+	var policies []*policyutil.Policy
+	groupServices := make(map[string]string)
+	for _, policyYaml := range strings.Split(outcome, "---\n") {
+		if strings.TrimSpace(policyYaml) == "" {
+			continue
+		}
+		policy, err := policyutil.Parse(policyYaml)
+		if err != nil {
+			return nil, err
+		}
+		if policy.Kind == "ClusterGroup" {
+			if ref := policy.Spec.ServiceReference; ref != nil {
+				groupServices[policy.Metadata.Name] = ref.Namespace + "/" + ref.Name
+			}
+			continue
+		}
+		policies = append(policies, policy)
+	}
+	summaries := make([]policySummary, 0, len(policies))
+	for _, policy := range policies {
+		namespace := policy.Metadata.Namespace
+		summary := policySummary{
+			Kind:      policy.Kind,
+			Name:      policy.Metadata.Name,
+			Namespace: namespace,
+		}
+		var appliedTo []string
+		for _, peer := range policy.Spec.AppliedTo {
+			appliedTo = append(appliedTo, policyutil.DescribePeer(peer, namespace, groupServices))
+		}
+		sort.Strings(appliedTo)
+		summary.AppliedTo = strings.Join(appliedTo, "; ")
+		for _, direction := range []string{"Ingress", "Egress"} {
+			rules := policy.Spec.Ingress
+			if direction == "Egress" {
+				rules = policy.Spec.Egress
+			}
+			for _, rule := range rules {
+				summary.Rules = append(summary.Rules, summarizeRule(direction, rule, namespace, groupServices))
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func summarizeRule(direction string, rule policyutil.Rule, namespace string, groupServices map[string]string) ruleSummary {
+	summary := ruleSummary{
+		Direction: direction,
+		Action:    rule.Action,
+	}
+	if summary.Action == "" {
+		// Rules of K8s NetworkPolicies have no action.
+		summary.Action = "Allow"
+	}
+	peers := rule.From
+	if direction == "Egress" {
+		peers = rule.To
+	}
+	for _, peer := range peers {
+		summary.Peers = append(summary.Peers, policyutil.DescribePeer(peer, namespace, groupServices))
+	}
+	for _, svc := range rule.ToServices {
+		summary.Peers = append(summary.Peers, "service="+svc.Namespace+"/"+svc.Name)
+	}
+	sort.Strings(summary.Peers)
+	for _, port := range rule.Ports {
+		summary.Ports = append(summary.Ports, policyutil.DescribePort(port))
+	}
+	sort.Strings(summary.Ports)
+	return summary
+}
+
+// comparePolicies matches the old and new policies by kind, Namespace and
+// name first, as names of some recommended policies are fixed. The remaining
+// policies are matched by kind, Namespace and appliedTo, as names of most
+// recommended policies end with a random suffix.
+func comparePolicies(oldPolicies, newPolicies []policySummary) recommendationComparison {
+	var comparison recommendationComparison
+	matched := make(map[int]int)
+	newMatched := make(map[int]bool)
+	for _, keyFunc := range []func(p policySummary) string{
+		func(p policySummary) string { return p.Kind + "/" + p.Namespace + "/" + p.Name },
+		func(p policySummary) string { return p.Kind + "/" + p.Namespace + "/" + p.AppliedTo },
+	} {
+		newIndexes := make(map[string][]int)
+		for j, p := range newPolicies {
+			if !newMatched[j] {
+				key := keyFunc(p)
+				newIndexes[key] = append(newIndexes[key], j)
+			}
+		}
+		for i, p := range oldPolicies {
+			if _, ok := matched[i]; ok {
+				continue
+			}
+			key := keyFunc(p)
+			if candidates := newIndexes[key]; len(candidates) > 0 {
+				matched[i] = candidates[0]
+				newMatched[candidates[0]] = true
+				newIndexes[key] = candidates[1:]
+			}
+		}
+	}
+	for i, oldPolicy := range oldPolicies {
+		j, ok := matched[i]
+		if !ok {
+			comparison.RemovedPolicies = append(comparison.RemovedPolicies, oldPolicy)
+			continue
+		}
+		newPolicy := newPolicies[j]
+		diff := policyDiff{
+			Kind:      oldPolicy.Kind,
+			OldName:   oldPolicy.Name,
+			NewName:   newPolicy.Name,
+			Namespace: newPolicy.Namespace,
+			AppliedTo: newPolicy.AppliedTo,
+		}
+		diff.AddedRules, diff.RemovedRules, diff.ModifiedRules = compareRules(oldPolicy.Rules, newPolicy.Rules)
+		if len(diff.AddedRules)+len(diff.RemovedRules)+len(diff.ModifiedRules) > 0 {
+			comparison.ModifiedPolicies = append(comparison.ModifiedPolicies, diff)
+		}
+	}
+	for j, newPolicy := range newPolicies {
+		if !newMatched[j] {
+			comparison.AddedPolicies = append(comparison.AddedPolicies, newPolicy)
+		}
+	}
+	return comparison
+}
+
+// compareRules returns the rules only in newRules, the rules only in
+// oldRules, and the rules whose ports or peers changed. A rule is considered
+// modified when a rule with the same direction and action has the same peers
+// but different ports, or the same ports but different peers.
+func compareRules(oldRules, newRules []ruleSummary) (added, removed []ruleSummary, modified []ruleChange) {
+	fullKey := func(r ruleSummary) string {
+		return r.Direction + "|" + r.Action + "|" + strings.Join(r.Peers, ";") + "|" + strings.Join(r.Ports, ";")
+	}
+	peersKey := func(r ruleSummary) string {
+		return r.Direction + "|" + r.Action + "|" + strings.Join(r.Peers, ";")
+	}
+	portsKey := func(r ruleSummary) string {
+		return r.Direction + "|" + r.Action + "|" + strings.Join(r.Ports, ";")
+	}
+	oldLeft := append([]ruleSummary(nil), oldRules...)
+	newLeft := append([]ruleSummary(nil), newRules...)
+	for pass, keyFunc := range []func(r ruleSummary) string{fullKey, peersKey, portsKey} {
+		var oldRemaining []ruleSummary
+		for _, oldRule := range oldLeft {
+			found := -1
+			for j, newRule := range newLeft {
+				if keyFunc(oldRule) == keyFunc(newRule) {
+					found = j
+					break
+				}
+			}
+			if found < 0 {
+				oldRemaining = append(oldRemaining, oldRule)
+				continue
+			}
+			if pass > 0 {
+				modified = append(modified, ruleChange{Old: oldRule, New: newLeft[found]})
+			}
+			newLeft = append(newLeft[:found], newLeft[found+1:]...)
+		}
+		oldLeft = oldRemaining
+	}
+	return newLeft, oldLeft, modified
+}
+
+func describeRule(rule ruleSummary) string {
+	preposition := "from"
+	if rule.Direction == "Egress" {
+		preposition = "to"
+	}
+	ports := "any port"
+	if len(rule.Ports) > 0 {
+		ports = strings.Join(rule.Ports, ",")
+	}
+	return fmt.Sprintf("%s %s on %s", preposition, strings.Join(rule.Peers, "; "), ports)
+}
+
+func printComparison(comparison recommendationComparison) {
+	fmt.Printf("Comparing policy recommendation jobs %s (old) and %s (new)\n", comparison.Old, comparison.New)
+	if len(comparison.AddedPolicies)+len(comparison.RemovedPolicies)+len(comparison.ModifiedPolicies) == 0 {
+		fmt.Println("No difference found")
+		return
+	}
+	policyName := func(namespace, name string) string {
+		if namespace == "" {
+			return name
+		}
+		return namespace + "/" + name
+	}
+	ruleLine := func(prefix string, rule ruleSummary) string {
+		return fmt.Sprintf("%s     %s %s %s\n", prefix, rule.Direction, rule.Action, describeRule(rule))
+	}
+	for _, policy := range comparison.AddedPolicies {
+		fmt.Printf("+ %s %s, appliedTo: %s\n", policy.Kind, policyName(policy.Namespace, policy.Name), policy.AppliedTo)
+		for _, rule := range policy.Rules {
+			fmt.Print(ruleLine("+", rule))
+		}
+	}
+	for _, policy := range comparison.RemovedPolicies {
+		fmt.Printf("- %s %s, appliedTo: %s\n", policy.Kind, policyName(policy.Namespace, policy.Name), policy.AppliedTo)
+		for _, rule := range policy.Rules {
+			fmt.Print(ruleLine("-", rule))
+		}
+	}
+	for _, diff := range comparison.ModifiedPolicies {
+		name := policyName(diff.Namespace, diff.OldName)
+		if diff.NewName != diff.OldName {
+			name += " -> " + diff.NewName
+		}
+		fmt.Printf("~ %s %s, appliedTo: %s\n", diff.Kind, name, diff.AppliedTo)
+		for _, rule := range diff.AddedRules {
+			fmt.Print(ruleLine("+", rule))
+		}
+		for _, rule := range diff.RemovedRules {
+			fmt.Print(ruleLine("-", rule))
+		}
+		for _, change := range diff.ModifiedRules {
+			fmt.Printf("~     %s %s %s -> %s\n", change.Old.Direction, change.Old.Action, describeRule(change.Old), describeRule(change.New))
+		}
+	}
+	fmt.Printf("%d policies added, %d removed, %d modified\n", len(comparison.AddedPolicies), len(comparison.RemovedPolicies), len(comparison.ModifiedPolicies))
+}
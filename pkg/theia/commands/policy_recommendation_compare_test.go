@@ -0,0 +1,304 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+	"antrea.io/theia/pkg/theia/portforwarder"
+)
+
+const (
+	oldPolicyOutcome = `apiVersion: crd.antrea.io/v1alpha1
+kind: NetworkPolicy
+metadata:
+  name: recommend-allow-anp-abcde
+  namespace: db
+spec:
+  appliedTo:
+  - podSelector:
+      matchLabels:
+        app: postgres
+  egress: []
+  ingress:
+  - action: Allow
+    from:
+    - namespaceSelector:
+        matchLabels:
+          kubernetes.io/metadata.name: web
+      podSelector:
+        matchLabels:
+          app: foo
+    ports:
+    - port: 5432
+      protocol: TCP
+  priority: 5
+  tier: Application
+---
+apiVersion: crd.antrea.io/v1alpha1
+kind: NetworkPolicy
+metadata:
+  name: recommend-allow-anp-fghij
+  namespace: web
+spec:
+  appliedTo:
+  - podSelector:
+      matchLabels:
+        app: bar
+  egress:
+  - action: Allow
+    ports:
+    - port: 443
+      protocol: TCP
+    to:
+    - ipBlock:
+        cidr: 192.168.0.1/32
+  ingress: []
+  priority: 5
+  tier: Application
+`
+	newPolicyOutcome = `apiVersion: crd.antrea.io/v1alpha1
+kind: NetworkPolicy
+metadata:
+  name: recommend-allow-anp-klmno
+  namespace: db
+spec:
+  appliedTo:
+  - podSelector:
+      matchLabels:
+        app: postgres
+  egress: []
+  ingress:
+  - action: Allow
+    from:
+    - namespaceSelector:
+        matchLabels:
+          kubernetes.io/metadata.name: web
+      podSelector:
+        matchLabels:
+          app: foo
+    ports:
+    - port: 5432
+      protocol: TCP
+    - port: 5433
+      protocol: TCP
+  - action: Allow
+    from:
+    - ipBlock:
+        cidr: 10.0.0.1/32
+    ports:
+    - port: 5432
+      protocol: TCP
+    - port: 5433
+      protocol: TCP
+  priority: 5
+  tier: Application
+---
+apiVersion: crd.antrea.io/v1alpha1
+kind: ClusterNetworkPolicy
+metadata:
+  name: recommend-reject-acnp-pqrst
+spec:
+  appliedTo:
+  - namespaceSelector:
+      matchLabels:
+        kubernetes.io/metadata.name: db
+    podSelector:
+      matchLabels:
+        app: postgres
+  egress:
+  - action: Reject
+    to:
+    - podSelector: {}
+  ingress:
+  - action: Reject
+    from:
+    - podSelector: {}
+  priority: 5
+  tier: Baseline
+`
+)
+
+var newNprName = "pr-8fe5cc2e-1a73-4e2c-a6ee-e8d9bd38e4a3"
+
+func TestComparePolicies(t *testing.T) {
+	oldPolicies, err := summarizePolicies(oldPolicyOutcome)
+	assert.NoError(t, err)
+	newPolicies, err := summarizePolicies(newPolicyOutcome)
+	assert.NoError(t, err)
+	comparison := comparePolicies(oldPolicies, newPolicies)
+
+	assert.Len(t, comparison.AddedPolicies, 1)
+	assert.Equal(t, "recommend-reject-acnp-pqrst", comparison.AddedPolicies[0].Name)
+	assert.Len(t, comparison.RemovedPolicies, 1)
+	assert.Equal(t, "recommend-allow-anp-fghij", comparison.RemovedPolicies[0].Name)
+	assert.Equal(t, []policyDiff{{
+		Kind:      "NetworkPolicy",
+		OldName:   "recommend-allow-anp-abcde",
+		NewName:   "recommend-allow-anp-klmno",
+		Namespace: "db",
+		AppliedTo: "namespace=db,app=postgres",
+		AddedRules: []ruleSummary{
+			{Direction: "Ingress", Action: "Allow", Peers: []string{"cidr=10.0.0.1/32"}, Ports: []string{"TCP/5432", "TCP/5433"}},
+		},
+		ModifiedRules: []ruleChange{{
+			Old: ruleSummary{Direction: "Ingress", Action: "Allow", Peers: []string{"namespace=web,app=foo"}, Ports: []string{"TCP/5432"}},
+			New: ruleSummary{Direction: "Ingress", Action: "Allow", Peers: []string{"namespace=web,app=foo"}, Ports: []string{"TCP/5432", "TCP/5433"}},
+		}},
+	}}, comparison.ModifiedPolicies)
+}
+
+func TestPolicyRecommendationCompare(t *testing.T) {
+	completedNpr := func(name, outcome string) intelligence.NetworkPolicyRecommendation {
+		npr := intelligence.NetworkPolicyRecommendation{}
+		npr.Name = name
+		npr.Status.State = crdv1alpha1.NPRecommendationStateCompleted
+		npr.Status.RecommendationOutcome = outcome
+		return npr
+	}
+	nprHandler := func(nprs ...intelligence.NetworkPolicyRecommendation) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			for _, npr := range nprs {
+				if strings.TrimSpace(r.URL.Path) == fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/networkpolicyrecommendations/%s", npr.Name) {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(npr)
+					return
+				}
+			}
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		}
+	}
+	runningNpr := completedNpr(newNprName, "")
+	runningNpr.Status.State = crdv1alpha1.NPRecommendationStateRunning
+	testCases := []struct {
+		name             string
+		testServer       *httptest.Server
+		args             []string
+		output           string
+		expectedMsg      []string
+		expectedErrorMsg string
+	}{
+		{
+			name:       "Valid case",
+			testServer: httptest.NewServer(nprHandler(completedNpr(nprName, oldPolicyOutcome), completedNpr(newNprName, newPolicyOutcome))),
+			args:       []string{nprName, newNprName},
+			output:     "text",
+			expectedMsg: []string{
+				"+ ClusterNetworkPolicy recommend-reject-acnp-pqrst",
+				"- NetworkPolicy web/recommend-allow-anp-fghij, appliedTo: namespace=web,app=bar",
+				"~ NetworkPolicy db/recommend-allow-anp-abcde -> recommend-allow-anp-klmno",
+				"+     Ingress Allow from cidr=10.0.0.1/32 on TCP/5432,TCP/5433",
+				"~     Ingress Allow from namespace=web,app=foo on TCP/5432 -> from namespace=web,app=foo on TCP/5432,TCP/5433",
+				"1 policies added, 1 removed, 1 modified",
+			},
+		},
+		{
+			name:        "Valid case with json output",
+			testServer:  httptest.NewServer(nprHandler(completedNpr(nprName, oldPolicyOutcome), completedNpr(newNprName, newPolicyOutcome))),
+			args:        []string{nprName, newNprName},
+			output:      "json",
+			expectedMsg: []string{`"oldName": "recommend-allow-anp-abcde"`, `"newName": "recommend-allow-anp-klmno"`},
+		},
+		{
+			name:        "No difference",
+			testServer:  httptest.NewServer(nprHandler(completedNpr(nprName, oldPolicyOutcome), completedNpr(newNprName, oldPolicyOutcome))),
+			args:        []string{nprName, newNprName},
+			output:      "text",
+			expectedMsg: []string{"No difference found"},
+		},
+		{
+			name:             "NetworkPolicyRecommendation not completed",
+			testServer:       httptest.NewServer(nprHandler(completedNpr(nprName, oldPolicyOutcome), runningNpr)),
+			args:             []string{nprName, newNprName},
+			output:           "text",
+			expectedErrorMsg: fmt.Sprintf("policy recommendation job %s is not completed", newNprName),
+		},
+		{
+			name:             "Invalid nprName",
+			testServer:       httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+			args:             []string{nprName, "mock_nprName"},
+			output:           "text",
+			expectedErrorMsg: "not a valid policy recommendation job name",
+		},
+		{
+			name:             "Invalid output",
+			testServer:       httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+			args:             []string{nprName, newNprName},
+			output:           "yaml",
+			expectedErrorMsg: "output should be text or json",
+		},
+		{
+			name:             TheiaClientSetupDeniedTestCase,
+			testServer:       httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+			args:             []string{nprName, newNprName},
+			output:           "text",
+			expectedErrorMsg: TheiaClientSetupDeniedErr,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.testServer.Close()
+			oldFunc := SetupTheiaClientAndConnection
+			if tt.name == TheiaClientSetupDeniedTestCase {
+				SetupTheiaClientAndConnection = func(cmd *cobra.Command, useClusterIP bool) (restclient.Interface, *portforwarder.PortForwarder, error) {
+					return nil, nil, errors.New("mock_error")
+				}
+			} else {
+				SetupTheiaClientAndConnection = func(cmd *cobra.Command, useClusterIP bool) (restclient.Interface, *portforwarder.PortForwarder, error) {
+					clientConfig := &restclient.Config{Host: tt.testServer.URL, TLSClientConfig: restclient.TLSClientConfig{Insecure: true}}
+					clientset, _ := kubernetes.NewForConfig(clientConfig)
+					return clientset.CoreV1().RESTClient(), nil, nil
+				}
+			}
+			defer func() {
+				SetupTheiaClientAndConnection = oldFunc
+			}()
+			cmd := new(cobra.Command)
+			cmd.Flags().String("output", tt.output, "")
+			cmd.Flags().Bool("use-cluster-ip", true, "")
+
+			orig := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			defer func() { os.Stdout = orig }()
+			err := policyRecommendationCompare(cmd, tt.args)
+			if tt.expectedErrorMsg == "" {
+				assert.NoError(t, err)
+				outcome := readStdout(t, r, w)
+				for _, msg := range tt.expectedMsg {
+					assert.Contains(t, outcome, msg)
+				}
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErrorMsg)
+			}
+		})
+	}
+}
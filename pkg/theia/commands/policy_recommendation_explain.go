@@ -0,0 +1,155 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"antrea.io/theia/pkg/util"
+)
+
+// policyRecommendationExplainCmd represents the policy-recommendation explain command
+var policyRecommendationExplainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Show the flows supporting each rule of a policy recommendation result",
+	Long: `Show the flows supporting each rule of a completed policy recommendation job.
+For every recommended rule, it prints the number of matching flows, the bytes
+they transferred, when they were first and last seen between the start and end
+time of the job, and a few sample flows with their source and destination Pods.`,
+	Args: cobra.RangeArgs(0, 1),
+	Example: `
+Explain the recommendation result with job name pr-e998433e-accb-4888-9fc8-06563f073e86
+$ theia policy-recommendation explain --name pr-e998433e-accb-4888-9fc8-06563f073e86
+Or
+$ theia policy-recommendation explain pr-e998433e-accb-4888-9fc8-06563f073e86
+Use Service ClusterIP when getting the explanation
+$ theia policy-recommendation explain pr-e998433e-accb-4888-9fc8-06563f073e86 --use-cluster-ip
+Save the explanation to file in JSON format
+$ theia policy-recommendation explain pr-e998433e-accb-4888-9fc8-06563f073e86 --file explanation.json
+`,
+	RunE: policyRecommendationExplain,
+}
+
+func init() {
+	policyRecommendationCmd.AddCommand(policyRecommendationExplainCmd)
+	policyRecommendationExplainCmd.Flags().StringP(
+		"name",
+		"",
+		"",
+		"Name of the policy recommendation job.",
+	)
+	policyRecommendationExplainCmd.Flags().StringP(
+		"file",
+		"f",
+		"",
+		"The file path where you want to save the explanation in JSON format.",
+	)
+}
+
+func policyRecommendationExplain(cmd *cobra.Command, args []string) error {
+	prName, err := cmd.Flags().GetString("name")
+	if err != nil {
+		return err
+	}
+	if prName == "" && len(args) == 1 {
+		prName = args[0]
+	}
+	err = util.ParseRecommendationName(prName)
+	if err != nil {
+		return err
+	}
+	filePath, err := cmd.Flags().GetString("file")
+	if err != nil {
+		return err
+	}
+	useClusterIP, err := cmd.Flags().GetBool("use-cluster-ip")
+	if err != nil {
+		return err
+	}
+	theiaClient, pf, err := SetupTheiaClientAndConnection(cmd, useClusterIP)
+	if err != nil {
+		return fmt.Errorf("couldn't setup Theia manager client, %v", err)
+	}
+	if pf != nil {
+		defer pf.Stop()
+	}
+	explanation, err := getPolicyRecommendationExplanation(theiaClient, prName)
+	if err != nil {
+		return fmt.Errorf("error when explaining policy recommendation job: %v", err)
+	}
+	if filePath != "" {
+		data, _ := json.MarshalIndent(explanation, "", " ")
+		if err := os.WriteFile(filePath, data, 0600); err != nil {
+			return fmt.Errorf("error when writing explanation to file: %v", err)
+		}
+		return nil
+	}
+	fmt.Printf("Flows between %s and %s\n", FormatTimestamp(explanation.StartInterval.Time), FormatTimestamp(explanation.EndInterval.Time))
+	for _, policy := range explanation.Policies {
+		name := policy.Name
+		if policy.Namespace != "" {
+			name = policy.Namespace + "/" + policy.Name
+		}
+		fmt.Printf("\n%s %s\nAppliedTo: %s\n", policy.Kind, name, policy.AppliedTo)
+		rules := [][]string{{"Direction", "Rule", "Action", "Peer", "Ports", "Flows", "Bytes", "FirstSeen", "LastSeen", "Error"}}
+		samples := [][]string{{"Direction", "Rule", "Source", "Destination", "DestinationService", "Port", "Protocol", "Flows"}}
+		for _, rule := range policy.Rules {
+			rules = append(rules, []string{
+				rule.Direction,
+				strconv.Itoa(rule.Index),
+				rule.Action,
+				rule.Peer,
+				strings.Join(rule.Ports, ","),
+				strconv.FormatInt(rule.FlowCount, 10),
+				strconv.FormatInt(rule.Bytes, 10),
+				FormatTimestamp(rule.FirstSeen.Time),
+				FormatTimestamp(rule.LastSeen.Time),
+				rule.ErrorMsg,
+			})
+			for _, sample := range rule.SampleFlows {
+				samples = append(samples, []string{
+					rule.Direction,
+					strconv.Itoa(rule.Index),
+					formatEndpoint(sample.SourcePodNamespace, sample.SourcePodName, sample.SourceIP),
+					formatEndpoint(sample.DestinationPodNamespace, sample.DestinationPodName, sample.DestinationIP),
+					sample.DestinationServicePortName,
+					strconv.Itoa(sample.DestinationTransportPort),
+					sample.Protocol,
+					strconv.FormatInt(sample.FlowCount, 10),
+				})
+			}
+		}
+		TableOutput(rules)
+		if len(samples) > 1 {
+			fmt.Println("Sample flows:")
+			TableOutput(samples)
+		}
+	}
+	return nil
+}
+
+// formatEndpoint returns namespace/name for Pods and the IP address otherwise.
+func formatEndpoint(namespace, name, ip string) string {
+	if name == "" {
+		return ip
+	}
+	return fmt.Sprintf("%s/%s(%s)", namespace, name, ip)
+}
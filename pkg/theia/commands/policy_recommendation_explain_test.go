@@ -0,0 +1,194 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+
+	intelligence "antrea.io/theia/pkg/apis/intelligence/v1alpha1"
+	"antrea.io/theia/pkg/theia/portforwarder"
+)
+
+func TestPolicyRecommendationExplain(t *testing.T) {
+	explanation := &intelligence.NetworkPolicyRecommendationExplanation{
+		StartInterval: metav1.NewTime(time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)),
+		EndInterval:   metav1.NewTime(time.Date(2023, 3, 2, 0, 0, 0, 0, time.UTC)),
+		Policies: []intelligence.PolicyExplanation{
+			{
+				Kind:      "NetworkPolicy",
+				Name:      "recommend-allow-anp-abcde",
+				Namespace: "db",
+				AppliedTo: "namespace=db,app=postgres",
+				Rules: []intelligence.RuleExplanation{
+					{
+						Direction: "Ingress",
+						Action:    "Allow",
+						Peer:      "namespace=web,app=foo",
+						Ports:     []string{"TCP/5432"},
+						FlowCount: 3,
+						Bytes:     4096,
+						FirstSeen: metav1.NewTime(time.Date(2023, 3, 1, 8, 0, 0, 0, time.UTC)),
+						LastSeen:  metav1.NewTime(time.Date(2023, 3, 1, 9, 30, 0, 0, time.UTC)),
+						SampleFlows: []intelligence.FlowSample{
+							{
+								SourcePodNamespace:       "web",
+								SourcePodName:            "foo-7c5d8",
+								SourceIP:                 "10.10.1.5",
+								DestinationPodNamespace:  "db",
+								DestinationPodName:       "postgres-0",
+								DestinationIP:            "10.10.2.7",
+								DestinationTransportPort: 5432,
+								Protocol:                 "TCP",
+								FlowCount:                3,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	testCases := []struct {
+		name             string
+		testServer       *httptest.Server
+		expectedMsg      []string
+		expectedErrorMsg string
+		nprName          string
+		filePath         string
+	}{
+		{
+			name: "Valid case",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/networkpolicyrecommendations/%s/explain", nprName):
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(explanation)
+				}
+			})),
+			nprName: nprName,
+			expectedMsg: []string{
+				"Flows between 2023-03-01 00:00:00 and 2023-03-02 00:00:00",
+				"NetworkPolicy db/recommend-allow-anp-abcde",
+				"AppliedTo: namespace=db,app=postgres",
+				"namespace=web,app=foo",
+				"TCP/5432",
+				"4096",
+				"2023-03-01 08:00:00",
+				"2023-03-01 09:30:00",
+				"web/foo-7c5d8(10.10.1.5)",
+				"db/postgres-0(10.10.2.7)",
+			},
+		},
+		{
+			name: "Valid case with filePath",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/networkpolicyrecommendations/%s/explain", nprName):
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(explanation)
+				}
+			})),
+			nprName:     nprName,
+			expectedMsg: []string{`"flowCount": 3`, `"sourcePodName": "foo-7c5d8"`},
+			filePath:    "/tmp/testExplanation",
+		},
+		{
+			name: "NetworkPolicyRecommendation not completed",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/networkpolicyrecommendations/%s/explain", nprName):
+					http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				}
+			})),
+			nprName:          nprName,
+			expectedErrorMsg: "error when explaining policy recommendation job",
+		},
+		{
+			name:             "Invalid nprName",
+			testServer:       httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+			nprName:          "mock_nprName",
+			expectedErrorMsg: "not a valid policy recommendation job name",
+		},
+		{
+			name:             TheiaClientSetupDeniedTestCase,
+			testServer:       httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+			nprName:          nprName,
+			expectedErrorMsg: TheiaClientSetupDeniedErr,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.testServer.Close()
+			oldFunc := SetupTheiaClientAndConnection
+			if tt.name == TheiaClientSetupDeniedTestCase {
+				SetupTheiaClientAndConnection = func(cmd *cobra.Command, useClusterIP bool) (restclient.Interface, *portforwarder.PortForwarder, error) {
+					return nil, nil, errors.New("mock_error")
+				}
+			} else {
+				SetupTheiaClientAndConnection = func(cmd *cobra.Command, useClusterIP bool) (restclient.Interface, *portforwarder.PortForwarder, error) {
+					clientConfig := &restclient.Config{Host: tt.testServer.URL, TLSClientConfig: restclient.TLSClientConfig{Insecure: true}}
+					clientset, _ := kubernetes.NewForConfig(clientConfig)
+					return clientset.CoreV1().RESTClient(), nil, nil
+				}
+			}
+			defer func() {
+				SetupTheiaClientAndConnection = oldFunc
+			}()
+			cmd := new(cobra.Command)
+			cmd.Flags().String("name", tt.nprName, "")
+			cmd.Flags().String("file", tt.filePath, "")
+			cmd.Flags().Bool("use-cluster-ip", true, "")
+
+			orig := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			defer func() { os.Stdout = orig }()
+			err := policyRecommendationExplain(cmd, []string{})
+			if tt.expectedErrorMsg == "" {
+				assert.NoError(t, err)
+				var outcome string
+				if tt.filePath != "" {
+					result, err := os.ReadFile(tt.filePath)
+					assert.NoError(t, err)
+					outcome = string(result)
+					defer os.RemoveAll(tt.filePath)
+				} else {
+					outcome = readStdout(t, r, w)
+				}
+				for _, msg := range tt.expectedMsg {
+					assert.Contains(t, outcome, msg)
+				}
+			} else {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErrorMsg)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"regexp"
 	"strings"
@@ -26,6 +27,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
@@ -47,6 +49,14 @@ Run an initial policy recommendation job with policy type anp-deny-applied and l
 $ theia policy-recommendation run --type initial --policy-type anp-deny-applied --start-time '2022-01-01 00:00:00' --end-time '2022-01-31 23:59:59'
 Run a policy recommendation job with default configuration but doesn't recommend toServices ANPs
 $ theia policy-recommendation run --to-services=false
+Run a policy recommendation job placing the recommended allow policies in the SecurityOps Tier with priorities 10, 10.5, 11, ...
+$ theia policy-recommendation run --tier SecurityOps --base-priority 10 --priority-step 0.5
+Run a policy recommendation job recommending AdminNetworkPolicies and a BaselineAdminNetworkPolicy
+$ theia policy-recommendation run --policy-type admin-np
+Run a policy recommendation job only for Pods with label tier=backend in Namespace payments
+$ theia policy-recommendation run --target-namespaces '["payments"]' --target-labels tier=backend
+Estimate the flow records read by a policy recommendation job without starting it
+$ theia policy-recommendation run --type initial --start-time '2022-01-01 00:00:00' --estimate
 `,
 	RunE: policyRecommendationRun,
 }
@@ -75,9 +85,9 @@ func policyRecommendationRun(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	if policyType != "anp-deny-applied" && policyType != "anp-deny-all" && policyType != "k8s-np" {
+	if policyType != "anp-deny-applied" && policyType != "anp-deny-all" && policyType != "k8s-np" && policyType != "admin-np" {
 		return fmt.Errorf(`type of generated NetworkPolicy should be
-anp-deny-applied or anp-deny-all or k8s-np`)
+anp-deny-applied or anp-deny-all or k8s-np or admin-np`)
 	}
 	networkPolicyRecommendation.PolicyType = policyType
 
@@ -126,6 +136,33 @@ be a list of namespace string, for example: '["kube-system","flow-aggregator","f
 		networkPolicyRecommendation.NSAllowList = parsedNsAllowList
 	}
 
+	targetNamespaces, err := cmd.Flags().GetString("target-namespaces")
+	if err != nil {
+		return err
+	}
+	if targetNamespaces != "" {
+		var parsedTargetNamespaces []string
+		err := json.Unmarshal([]byte(targetNamespaces), &parsedTargetNamespaces)
+		if err != nil {
+			return fmt.Errorf(`parsing target-namespaces: %v, target-namespaces should
+be a list of namespace string, for example: '["payments","orders"]'`, err)
+		}
+		networkPolicyRecommendation.TargetNamespaces = parsedTargetNamespaces
+	}
+
+	targetLabels, err := cmd.Flags().GetString("target-labels")
+	if err != nil {
+		return err
+	}
+	if targetLabels != "" {
+		parsedTargetLabels, err := labels.ConvertSelectorToLabelsMap(targetLabels)
+		if err != nil {
+			return fmt.Errorf(`parsing target-labels: %v, target-labels should
+be a comma-separated list of key=value pairs, for example: 'tier=backend,app=api'`, err)
+		}
+		networkPolicyRecommendation.TargetLabels = parsedTargetLabels
+	}
+
 	excludeLabels, err := cmd.Flags().GetBool("exclude-labels")
 	if err != nil {
 		return err
@@ -138,6 +175,33 @@ be a list of namespace string, for example: '["kube-system","flow-aggregator","f
 	}
 	networkPolicyRecommendation.ToServices = toServices
 
+	tier, err := cmd.Flags().GetString("tier")
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(tier, "baseline") {
+		return fmt.Errorf("tier should not be Baseline, which is reserved for the recommended deny policies")
+	}
+	networkPolicyRecommendation.Tier = tier
+
+	basePriority, err := cmd.Flags().GetFloat64("base-priority")
+	if err != nil {
+		return err
+	}
+	if basePriority < 1 || basePriority > 10000 {
+		return fmt.Errorf("base-priority should be a number between 1 and 10000")
+	}
+	networkPolicyRecommendation.BasePriority = basePriority
+
+	priorityStep, err := cmd.Flags().GetFloat64("priority-step")
+	if err != nil {
+		return err
+	}
+	if priorityStep < 0 {
+		return fmt.Errorf("priority-step should be a number >= 0")
+	}
+	networkPolicyRecommendation.PriorityStep = priorityStep
+
 	executorInstances, err := cmd.Flags().GetInt32("executor-instances")
 	if err != nil {
 		return err
@@ -187,6 +251,27 @@ be a list of namespace string, for example: '["kube-system","flow-aggregator","f
 	}
 	networkPolicyRecommendation.ExecutorMemory = executorMemory
 
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	if err != nil {
+		return err
+	}
+	if timeout < 0 {
+		return fmt.Errorf("timeout should be a duration >= 0")
+	}
+	networkPolicyRecommendation.ActiveDeadlineSeconds = int64(math.Ceil(timeout.Seconds()))
+
+	if cmd.Flags().Changed("ttl-after-finished") {
+		ttlAfterFinished, err := cmd.Flags().GetDuration("ttl-after-finished")
+		if err != nil {
+			return err
+		}
+		if ttlAfterFinished < 0 || ttlAfterFinished.Seconds() > math.MaxInt32 {
+			return fmt.Errorf("ttl-after-finished should be a duration >= 0")
+		}
+		ttlSeconds := int32(math.Ceil(ttlAfterFinished.Seconds()))
+		networkPolicyRecommendation.TTLSecondsAfterFinished = &ttlSeconds
+	}
+
 	filePath, err := cmd.Flags().GetString("file")
 	if err != nil {
 		return err
@@ -212,6 +297,28 @@ be a list of namespace string, for example: '["kube-system","flow-aggregator","f
 	networkPolicyRecommendation.Name = "pr-" + recoID
 	networkPolicyRecommendation.Namespace = config.FlowVisibilityNS
 
+	if cmd.Flags().Changed("estimate") {
+		estimateFlag, err := cmd.Flags().GetBool("estimate")
+		if err != nil {
+			return err
+		}
+		if estimateFlag {
+			var estimated intelligence.NetworkPolicyRecommendation
+			err = theiaClient.Post().
+				AbsPath("/apis/intelligence.theia.antrea.io/v1alpha1/").
+				Resource("networkpolicyrecommendations").
+				Param("dryRun", metav1.DryRunAll).
+				Body(&networkPolicyRecommendation).
+				Do(context.TODO()).
+				Into(&estimated)
+			if err != nil {
+				return fmt.Errorf("failed to estimate policy recommendation job: %v", err)
+			}
+			printJobInputEstimate(estimated.Status.InputEstimate)
+			return nil
+		}
+	}
+
 	err = theiaClient.Post().
 		AbsPath("/apis/intelligence.theia.antrea.io/v1alpha1/").
 		Resource("networkpolicyrecommendations").
@@ -282,10 +389,11 @@ func init() {
 		"p",
 		"anp-deny-applied",
 		`Types of generated NetworkPolicy.
-Currently we have 3 generated NetworkPolicy types:
+Currently we have 4 generated NetworkPolicy types:
 anp-deny-applied: Recommending allow ANP/ACNP policies, with default deny rules only on Pods which have an allow rule applied.
 anp-deny-all: Recommending allow ANP/ACNP policies, with default deny rules for whole cluster.
-k8s-np: Recommending allow K8s NetworkPolicies.`,
+k8s-np: Recommending allow K8s NetworkPolicies.
+admin-np: Recommending allow AdminNetworkPolicies, with a default deny BaselineAdminNetworkPolicy for whole cluster.`,
 	)
 	policyRecommendationRunCmd.Flags().StringP(
 		"start-time",
@@ -308,6 +416,19 @@ Format is YYYY-MM-DD hh:mm:ss in UTC timezone. No limit of the end time of flow
 		`List of default allow Namespaces.
 If no Namespaces provided, Traffic inside Antrea CNI related Namespaces: ['kube-system', 'flow-aggregator',
 'flow-visibility'] will be allowed by default.`,
+	)
+	policyRecommendationRunCmd.Flags().String(
+		"target-namespaces",
+		"",
+		`List of Namespaces to recommend policies for, for example: '["payments","orders"]'.
+Only flows from or to Pods in these Namespaces are considered, and only these Pods are selected
+in the appliedTo of the recommended policies. All Namespaces are targeted by default.`,
+	)
+	policyRecommendationRunCmd.Flags().String(
+		"target-labels",
+		"",
+		`Labels of the Pods to recommend policies for, as comma-separated key=value pairs,
+for example: 'tier=backend,app=api'. Can be combined with target-namespaces. All Pods are targeted by default.`,
 	)
 	policyRecommendationRunCmd.Flags().Bool(
 		"exclude-labels",
@@ -320,6 +441,23 @@ If no Namespaces provided, Traffic inside Antrea CNI related Namespaces: ['kube-
 		true,
 		`Use the toServices feature in ANP and recommendation toServices rules for Pod-to-Service flows,
 only works when option is anp-deny-applied or anp-deny-all.`,
+	)
+	policyRecommendationRunCmd.Flags().String(
+		"tier",
+		"Application",
+		`The Tier of the recommended allow ANP/ACNP policies, only works when option is anp-deny-applied or anp-deny-all.
+The recommended deny policies are always in the Baseline Tier.`,
+	)
+	policyRecommendationRunCmd.Flags().Float64(
+		"base-priority",
+		5,
+		"The priority of the first recommended ANP/ACNP policy in each Tier, between 1 and 10000.",
+	)
+	policyRecommendationRunCmd.Flags().Float64(
+		"priority-step",
+		0,
+		`The priority increment between consecutive recommended ANP/ACNP policies in the same Tier.
+All the policies in a Tier get the base priority by default.`,
 	)
 	policyRecommendationRunCmd.Flags().Int32(
 		"executor-instances",
@@ -349,6 +487,26 @@ Example values include 0.1, 500m, 1.5, 5, etc.`,
 		"512M",
 		`Specify the memory request for the executor Pod. Values conform to the Kubernetes resource quantity convention.
 Example values include 512M, 1G, 8G, etc.`,
+	)
+	policyRecommendationRunCmd.Flags().Duration(
+		"timeout",
+		0,
+		`The maximum duration of the policy recommendation job once it is started, e.g. 2h. The job is
+stopped and marked as failed when it runs longer. Defaults to the job timeout of
+Theia Manager if 0.`,
+	)
+	policyRecommendationRunCmd.Flags().Duration(
+		"ttl-after-finished",
+		0,
+		`The duration after which the policy recommendation job is deleted together with its results once it
+is completed or failed, e.g. 168h. The job is retained until deleted manually or pruned by the job
+history limits of Theia Manager if not set.`,
+	)
+	policyRecommendationRunCmd.Flags().Bool(
+		"estimate",
+		false,
+		`Enable this option to only print the estimated number of flow records the policy recommendation
+job reads, without starting it.`,
 	)
 	policyRecommendationRunCmd.Flags().Bool(
 		"wait",
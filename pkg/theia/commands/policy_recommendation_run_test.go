@@ -22,6 +22,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -39,6 +40,7 @@ func TestPolicyRecommendationRun(t *testing.T) {
 		expectedMsg      []string
 		expectedErrorMsg string
 		waitFlag         bool
+		estimateFlag     bool
 	}{
 		{
 			name: "Valid case",
@@ -75,6 +77,12 @@ func TestPolicyRecommendationRun(t *testing.T) {
 						http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
 						return
 					}
+					var npr intelligence.NetworkPolicyRecommendation
+					if err := json.NewDecoder(r.Body).Decode(&npr); err != nil || npr.ActiveDeadlineSeconds != 5400 ||
+						npr.TTLSecondsAfterFinished == nil || *npr.TTLSecondsAfterFinished != 604800 {
+						http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+						return
+					}
 					w.Header().Set("Content-Type", "application/json")
 					w.WriteHeader(http.StatusOK)
 				}
@@ -95,6 +103,31 @@ func TestPolicyRecommendationRun(t *testing.T) {
 			expectedMsg:      []string{},
 			expectedErrorMsg: "failed to post policy recommendation job",
 		},
+		{
+			name: "Estimate case",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case "/apis/intelligence.theia.antrea.io/v1alpha1/networkpolicyrecommendations":
+					if r.URL.Query().Get("dryRun") != "All" {
+						http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+						return
+					}
+					npr := &intelligence.NetworkPolicyRecommendation{
+						Status: intelligence.NetworkPolicyRecommendationStatus{
+							InputEstimate: &intelligence.JobInputEstimate{Rows: 1000, Bytes: 2048},
+						},
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(npr)
+				}
+			})),
+			expectedMsg: []string{
+				"Estimated input: 1000 flow records, 2Ki bytes",
+			},
+			expectedErrorMsg: "",
+			estimateFlag:     true,
+		},
 		{
 			name:             TheiaClientSetupDeniedTestCase,
 			testServer:       httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
@@ -128,13 +161,25 @@ func TestPolicyRecommendationRun(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 			cmd.Flags().Int32("executor-instances", 1, "")
 			cmd.Flags().String("driver-core-request", "1", "")
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 90*time.Minute, "")
+			cmd.Flags().Duration("ttl-after-finished", 0, "")
+			cmd.Flags().Set("ttl-after-finished", "168h")
+			cmd.Flags().Bool("estimate", false, "")
+			if tt.estimateFlag {
+				cmd.Flags().Set("estimate", "true")
+			}
 			cmd.Flags().Bool("wait", tt.waitFlag, "")
 			cmd.Flags().String("file", "", "")
 
@@ -184,7 +229,7 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 		},
 		{
 			name:             "Invalid policy-type",
-			expectedErrorMsg: "type of generated NetworkPolicy should be\nanp-deny-applied or anp-deny-all or k8s-np",
+			expectedErrorMsg: "type of generated NetworkPolicy should be\nanp-deny-applied or anp-deny-all or k8s-np or admin-np",
 		},
 		{
 			name:             "Unspecified start-time",
@@ -210,6 +255,22 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			name:             "Invalid ns-allow-list",
 			expectedErrorMsg: "ns-allow-list should \nbe a list of namespace string",
 		},
+		{
+			name:             "Unspecified target-namespaces",
+			expectedErrorMsg: ErrorMsgUnspecifiedCase,
+		},
+		{
+			name:             "Invalid target-namespaces",
+			expectedErrorMsg: "target-namespaces should\nbe a list of namespace string",
+		},
+		{
+			name:             "Unspecified target-labels",
+			expectedErrorMsg: ErrorMsgUnspecifiedCase,
+		},
+		{
+			name:             "Invalid target-labels",
+			expectedErrorMsg: "target-labels should\nbe a comma-separated list of key=value pairs",
+		},
 		{
 			name:             "Unspecified exclude-labels",
 			expectedErrorMsg: ErrorMsgUnspecifiedCase,
@@ -218,6 +279,30 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			name:             "Unspecified to-services",
 			expectedErrorMsg: ErrorMsgUnspecifiedCase,
 		},
+		{
+			name:             "Unspecified tier",
+			expectedErrorMsg: ErrorMsgUnspecifiedCase,
+		},
+		{
+			name:             "Invalid tier",
+			expectedErrorMsg: "tier should not be Baseline",
+		},
+		{
+			name:             "Unspecified base-priority",
+			expectedErrorMsg: ErrorMsgUnspecifiedCase,
+		},
+		{
+			name:             "Invalid base-priority",
+			expectedErrorMsg: "base-priority should be a number between 1 and 10000",
+		},
+		{
+			name:             "Unspecified priority-step",
+			expectedErrorMsg: ErrorMsgUnspecifiedCase,
+		},
+		{
+			name:             "Invalid priority-step",
+			expectedErrorMsg: "priority-step should be a number >= 0",
+		},
 		{
 			name:             "Unspecified executor-instances",
 			expectedErrorMsg: ErrorMsgUnspecifiedCase,
@@ -258,6 +343,14 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			name:             "Invalid executor-memory",
 			expectedErrorMsg: "executor-memory should conform to the Kubernetes resource quantity convention",
 		},
+		{
+			name:             "Invalid timeout",
+			expectedErrorMsg: "timeout should be a duration >= 0",
+		},
+		{
+			name:             "Invalid ttl-after-finished",
+			expectedErrorMsg: "ttl-after-finished should be a duration >= 0",
+		},
 		{
 			name:             "Unspecified file",
 			expectedErrorMsg: ErrorMsgUnspecifiedCase,
@@ -321,6 +414,38 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "mock_wrong_ns-allow-list", "")
+		case "Unspecified target-namespaces":
+			cmd.Flags().String("type", "initial", "")
+			cmd.Flags().Int("limit", 0, "")
+			cmd.Flags().String("policy-type", "anp-deny-applied", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
+			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+		case "Invalid target-namespaces":
+			cmd.Flags().String("type", "initial", "")
+			cmd.Flags().Int("limit", 0, "")
+			cmd.Flags().String("policy-type", "anp-deny-applied", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
+			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "mock_wrong_target-namespaces", "")
+		case "Unspecified target-labels":
+			cmd.Flags().String("type", "initial", "")
+			cmd.Flags().Int("limit", 0, "")
+			cmd.Flags().String("policy-type", "anp-deny-applied", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
+			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+		case "Invalid target-labels":
+			cmd.Flags().String("type", "initial", "")
+			cmd.Flags().Int("limit", 0, "")
+			cmd.Flags().String("policy-type", "anp-deny-applied", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
+			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier", "")
 		case "Unspecified exclude-labels":
 			cmd.Flags().String("type", "initial", "")
 			cmd.Flags().Int("limit", 0, "")
@@ -328,6 +453,8 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 		case "Unspecified to-services":
 			cmd.Flags().String("type", "initial", "")
 			cmd.Flags().Int("limit", 0, "")
@@ -335,7 +462,84 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
+		case "Unspecified tier":
+			cmd.Flags().String("type", "initial", "")
+			cmd.Flags().Int("limit", 0, "")
+			cmd.Flags().String("policy-type", "anp-deny-applied", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
+			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
+			cmd.Flags().Bool("exclude-labels", true, "")
+			cmd.Flags().Bool("to-services", true, "")
+		case "Invalid tier":
+			cmd.Flags().String("type", "initial", "")
+			cmd.Flags().Int("limit", 0, "")
+			cmd.Flags().String("policy-type", "anp-deny-applied", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
+			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
+			cmd.Flags().Bool("exclude-labels", true, "")
+			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Baseline", "")
+		case "Unspecified base-priority":
+			cmd.Flags().String("type", "initial", "")
+			cmd.Flags().Int("limit", 0, "")
+			cmd.Flags().String("policy-type", "anp-deny-applied", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
+			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
+			cmd.Flags().Bool("exclude-labels", true, "")
+			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+		case "Invalid base-priority":
+			cmd.Flags().String("type", "initial", "")
+			cmd.Flags().Int("limit", 0, "")
+			cmd.Flags().String("policy-type", "anp-deny-applied", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
+			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
+			cmd.Flags().Bool("exclude-labels", true, "")
+			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 0, "")
+		case "Unspecified priority-step":
+			cmd.Flags().String("type", "initial", "")
+			cmd.Flags().Int("limit", 0, "")
+			cmd.Flags().String("policy-type", "anp-deny-applied", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
+			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
+			cmd.Flags().Bool("exclude-labels", true, "")
+			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+		case "Invalid priority-step":
+			cmd.Flags().String("type", "initial", "")
+			cmd.Flags().Int("limit", 0, "")
+			cmd.Flags().String("policy-type", "anp-deny-applied", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
+			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
+			cmd.Flags().Bool("exclude-labels", true, "")
+			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", -1, "")
 		case "Unspecified executor-instances":
 			cmd.Flags().Bool("use-cluster-ip", true, "")
 			cmd.Flags().String("type", "initial", "")
@@ -344,8 +548,13 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 		case "Invalid executor-instances":
 			cmd.Flags().String("type", "initial", "")
 			cmd.Flags().Int("limit", 0, "")
@@ -353,8 +562,13 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 			cmd.Flags().Int32("executor-instances", -1, "")
 		case "Unspecified driver-core-request":
 			cmd.Flags().String("type", "initial", "")
@@ -363,8 +577,13 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 			cmd.Flags().Int32("executor-instances", 1, "")
 		case "Invalid driver-core-request":
 			cmd.Flags().String("type", "initial", "")
@@ -373,8 +592,13 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 			cmd.Flags().Int32("executor-instances", 1, "")
 			cmd.Flags().String("driver-core-request", "mock_driver-core-request", "")
 		case "Unspcified driver-memory":
@@ -384,8 +608,13 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 			cmd.Flags().Int32("executor-instances", 1, "")
 			cmd.Flags().String("driver-core-request", "1", "")
 		case "Invalid driver-memory":
@@ -395,8 +624,13 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 			cmd.Flags().Int32("executor-instances", 1, "")
 			cmd.Flags().String("driver-core-request", "1", "")
 			cmd.Flags().String("driver-memory", "mock_driver-memory", "")
@@ -407,8 +641,13 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 			cmd.Flags().Int32("executor-instances", 1, "")
 			cmd.Flags().String("driver-core-request", "1", "")
 			cmd.Flags().String("driver-memory", "1m", "")
@@ -419,8 +658,13 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 			cmd.Flags().Int32("executor-instances", 1, "")
 			cmd.Flags().String("driver-core-request", "1", "")
 			cmd.Flags().String("driver-memory", "1m", "")
@@ -432,8 +676,13 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 			cmd.Flags().Int32("executor-instances", 1, "")
 			cmd.Flags().String("driver-core-request", "1", "")
 			cmd.Flags().String("driver-memory", "1m", "")
@@ -445,13 +694,60 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 			cmd.Flags().Int32("executor-instances", 1, "")
 			cmd.Flags().String("driver-core-request", "1", "")
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "mock_executor-memory", "")
+		case "Invalid timeout":
+			cmd.Flags().String("type", "initial", "")
+			cmd.Flags().Int("limit", 0, "")
+			cmd.Flags().String("policy-type", "anp-deny-applied", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
+			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
+			cmd.Flags().Bool("exclude-labels", true, "")
+			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
+			cmd.Flags().Int32("executor-instances", 1, "")
+			cmd.Flags().String("driver-core-request", "1", "")
+			cmd.Flags().String("driver-memory", "1m", "")
+			cmd.Flags().String("executor-core-request", "1", "")
+			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", -time.Minute, "")
+		case "Invalid ttl-after-finished":
+			cmd.Flags().String("type", "initial", "")
+			cmd.Flags().Int("limit", 0, "")
+			cmd.Flags().String("policy-type", "anp-deny-applied", "")
+			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
+			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
+			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
+			cmd.Flags().Bool("exclude-labels", true, "")
+			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
+			cmd.Flags().Int32("executor-instances", 1, "")
+			cmd.Flags().String("driver-core-request", "1", "")
+			cmd.Flags().String("driver-memory", "1m", "")
+			cmd.Flags().String("executor-core-request", "1", "")
+			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
+			cmd.Flags().Duration("ttl-after-finished", 0, "")
+			cmd.Flags().Set("ttl-after-finished", "-1m")
 		case "Unspecified file":
 			cmd.Flags().String("type", "initial", "")
 			cmd.Flags().Int("limit", 0, "")
@@ -459,13 +755,19 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 			cmd.Flags().Int32("executor-instances", 1, "")
 			cmd.Flags().String("driver-core-request", "1", "")
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
 		case "Unspecified use-cluster-ip":
 			cmd.Flags().String("type", "initial", "")
 			cmd.Flags().Int("limit", 0, "")
@@ -473,13 +775,19 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 			cmd.Flags().Int32("executor-instances", 1, "")
 			cmd.Flags().String("driver-core-request", "1", "")
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
 			cmd.Flags().String("file", "filename", "")
 		case "Unspecified waitFlag":
 			cmd.Flags().String("type", "initial", "")
@@ -488,13 +796,19 @@ func TestPolicyRecommendationRunErrs(t *testing.T) {
 			cmd.Flags().String("start-time", "2006-01-02 15:04:05", "")
 			cmd.Flags().String("end-time", "2006-01-03 15:04:05", "")
 			cmd.Flags().String("ns-allow-list", "[\"kube-system\",\"flow-aggregator\",\"flow-visibility\"]", "")
+			cmd.Flags().String("target-namespaces", "[\"payments\"]", "")
+			cmd.Flags().String("target-labels", "tier=backend", "")
 			cmd.Flags().Bool("exclude-labels", true, "")
 			cmd.Flags().Bool("to-services", true, "")
+			cmd.Flags().String("tier", "Application", "")
+			cmd.Flags().Float64("base-priority", 5, "")
+			cmd.Flags().Float64("priority-step", 0, "")
 			cmd.Flags().Int32("executor-instances", 1, "")
 			cmd.Flags().String("driver-core-request", "1", "")
 			cmd.Flags().String("driver-memory", "1m", "")
 			cmd.Flags().String("executor-core-request", "1", "")
 			cmd.Flags().String("executor-memory", "1m", "")
+			cmd.Flags().Duration("timeout", 0, "")
 			cmd.Flags().String("file", "filename", "")
 			cmd.Flags().Bool("use-cluster-ip", true, "")
 		}
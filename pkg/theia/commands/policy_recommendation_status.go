@@ -27,7 +27,9 @@ var policyRecommendationStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Check the status of a policy recommendation job",
 	Long: `Check the current status of a policy recommendation job by name.
-It will return the status of this policy recommendation job like SUBMITTED, RUNNING, COMPLETED, or FAILED.`,
+It will return the status of this policy recommendation job like SUBMITTED, RUNNING, COMPLETED, or FAILED.
+For a running job, it also reports the elapsed time, the estimated remaining time,
+the current stage and the number of active and failed executors.`,
 	Args: cobra.RangeArgs(0, 1),
 	Example: `
 Check the current status of job with name pr-e998433e-accb-4888-9fc8-06563f073e86
@@ -91,6 +93,12 @@ func policyRecommendationStatus(cmd *cobra.Command, args []string) error {
 	}
 	errorMessage := npr.Status.ErrorMsg
 	fmt.Printf("Status of this policy recommendation job is %s\n", state)
+	if npr.Status.State == "RUNNING" {
+		printJobProgress(npr.Status.StartTime, npr.Status.Progress)
+	}
+	if npr.Status.InputEstimate != nil {
+		printJobInputEstimate(npr.Status.InputEstimate)
+	}
 	if errorMessage != "" {
 		fmt.Printf("Error message: %s\n", errorMessage)
 	}
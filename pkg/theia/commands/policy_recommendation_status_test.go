@@ -23,9 +23,11 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 
@@ -66,6 +68,42 @@ func TestPolicyRecommendationStatus(t *testing.T) {
 			},
 			expectedErrorMsg: "",
 		},
+		{
+			name: "Valid case with progress",
+			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch strings.TrimSpace(r.URL.Path) {
+				case fmt.Sprintf("/apis/intelligence.theia.antrea.io/v1alpha1/networkpolicyrecommendations/%s", nprName):
+					npr := &intelligence.NetworkPolicyRecommendation{
+						Status: intelligence.NetworkPolicyRecommendationStatus{
+							State:           "RUNNING",
+							CompletedStages: 2,
+							TotalStages:     5,
+							Progress: &intelligence.JobProgress{
+								CurrentStage:     "groupBy",
+								CompletedTasks:   4,
+								TotalTasks:       10,
+								ActiveExecutors:  2,
+								FailedExecutors:  1,
+								EstimatedEndTime: metav1.NewTime(time.Now().Add(time.Hour)),
+							},
+							StartTime: metav1.NewTime(time.Now().Add(-time.Hour)),
+						},
+					}
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(npr)
+				}
+			})),
+			nprName: nprName,
+			expectedMsg: []string{
+				"Status of this policy recommendation job is RUNNING: 2/5 (40%) stages completed",
+				"Elapsed time: 1h0m0s",
+				"Estimated time remaining: 59m",
+				"Current stage: groupBy, 4/10 tasks completed",
+				"Executors: 2 active, 1 failed",
+			},
+			expectedErrorMsg: "",
+		},
 		{
 			name: "total stage is zero ",
 			testServer: httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -17,14 +17,17 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
@@ -205,6 +208,39 @@ func FormatTimestamp(timestamp time.Time) string {
 	return timestamp.UTC().Format("2006-01-02 15:04:05")
 }
 
+// printJobProgress prints the detailed progress of a running job, to tell a
+// slow job from a stuck one.
+func printJobProgress(startTime metav1.Time, progress *intelligence.JobProgress) {
+	now := time.Now()
+	if !startTime.IsZero() {
+		fmt.Printf("Elapsed time: %s\n", now.Sub(startTime.Time).Truncate(time.Second))
+	}
+	if progress == nil {
+		return
+	}
+	if !progress.EstimatedEndTime.IsZero() {
+		remaining := progress.EstimatedEndTime.Sub(now).Truncate(time.Second)
+		if remaining < 0 {
+			remaining = 0
+		}
+		fmt.Printf("Estimated time remaining: %s\n", remaining)
+	}
+	if progress.CurrentStage != "" {
+		fmt.Printf("Current stage: %s, %d/%d tasks completed\n", progress.CurrentStage, progress.CompletedTasks, progress.TotalTasks)
+	}
+	fmt.Printf("Executors: %d active, %d failed\n", progress.ActiveExecutors, progress.FailedExecutors)
+}
+
+// printJobInputEstimate prints the estimated flow records a job reads from
+// ClickHouse.
+func printJobInputEstimate(estimate *intelligence.JobInputEstimate) {
+	if estimate == nil {
+		fmt.Println("Estimated input: N/A")
+		return
+	}
+	fmt.Printf("Estimated input: %d flow records, %s bytes\n", estimate.Rows, resource.NewQuantity(estimate.Bytes, resource.BinarySI).String())
+}
+
 func getPolicyRecommendationByName(theiaClient restclient.Interface, name string) (npr intelligence.NetworkPolicyRecommendation, err error) {
 	err = theiaClient.Get().
 		AbsPath("/apis/intelligence.theia.antrea.io/v1alpha1/").
@@ -218,6 +254,20 @@ func getPolicyRecommendationByName(theiaClient restclient.Interface, name string
 	return npr, nil
 }
 
+func getPolicyRecommendationExplanation(theiaClient restclient.Interface, name string) (explanation intelligence.NetworkPolicyRecommendationExplanation, err error) {
+	err = theiaClient.Get().
+		AbsPath("/apis/intelligence.theia.antrea.io/v1alpha1/").
+		Resource("networkpolicyrecommendations").
+		Name(name).
+		SubResource("explain").
+		Do(context.TODO()).
+		Into(&explanation)
+	if err != nil {
+		return explanation, fmt.Errorf("failed to explain policy recommendation job %s: %v", name, err)
+	}
+	return explanation, nil
+}
+
 func getClickHouseStatusByCategory(theiaClient restclient.Interface, name string) (status stats.ClickHouseStats, err error) {
 	err = theiaClient.Get().
 		AbsPath("/apis/stats.theia.antrea.io/v1alpha1/").
@@ -243,3 +293,38 @@ func GetThroughputAnomalyDetectorByID(theiaClient restclient.Interface, name str
 	}
 	return tad, nil
 }
+
+func getThroughputAnomalyDetectorContributors(theiaClient restclient.Interface, name string) (contributors intelligence.ThroughputAnomalyDetectorContributors, err error) {
+	err = theiaClient.Get().
+		AbsPath("/apis/intelligence.theia.antrea.io/v1alpha1/").
+		Resource("throughputanomalydetectors").
+		Name(name).
+		SubResource("contributors").
+		Do(context.TODO()).
+		Into(&contributors)
+	if err != nil {
+		return contributors, fmt.Errorf("failed to get contributing flows of Throughput Anomaly Detector job %s: %v", name, err)
+	}
+	return contributors, nil
+}
+
+// streamJobLogs copies the logs of the Spark driver, or of the executor with
+// the given ID if it is not 0, of a job to out.
+func streamJobLogs(theiaClient restclient.Interface, resource, name string, follow bool, executor int, out io.Writer) error {
+	stream, err := theiaClient.Get().
+		AbsPath("/apis/intelligence.theia.antrea.io/v1alpha1/").
+		Resource(resource).
+		Name(name).
+		SubResource("log").
+		Param("follow", strconv.FormatBool(follow)).
+		Param("executor", strconv.Itoa(executor)).
+		Stream(context.TODO())
+	if err != nil {
+		return fmt.Errorf("failed to get the logs of job %s: %v", name, err)
+	}
+	defer stream.Close()
+	if _, err := io.Copy(out, stream); err != nil {
+		return fmt.Errorf("failed to read the logs of job %s: %v", name, err)
+	}
+	return nil
+}
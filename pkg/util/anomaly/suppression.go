@@ -0,0 +1,152 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package anomaly matches the throughput anomalies found by
+// ThroughputAnomalyDetectors against AnomalySuppressions.
+package anomaly
+
+import (
+	"fmt"
+	"time"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+)
+
+const dailyTimeLayout = "15:04"
+
+// Anomaly holds the entity whose throughput is anomalous, as reported in the
+// results of ThroughputAnomalyDetectors, and the time of the anomaly.
+type Anomaly struct {
+	AggType                    string
+	SourceIP                   string
+	DestinationIP              string
+	PodNamespace               string
+	PodLabels                  string
+	PodName                    string
+	Direction                  string
+	DestinationServicePortName string
+	SourceNodeName             string
+	DestinationNodeName        string
+	Time                       time.Time
+}
+
+// IsSuppressed returns whether the anomaly is matched by any of the
+// suppressions which have not expired at the given time.
+func IsSuppressed(anomaly Anomaly, suppressions []*crdv1alpha1.AnomalySuppression, now time.Time) bool {
+	for _, suppression := range suppressions {
+		if IsExpired(suppression, now) {
+			continue
+		}
+		if matches(&suppression.Spec, anomaly) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired returns whether the suppression has expired at the given time.
+func IsExpired(suppression *crdv1alpha1.AnomalySuppression, now time.Time) bool {
+	expiration := suppression.Spec.ExpirationTime
+	return !expiration.IsZero() && !now.Before(expiration.Time)
+}
+
+func matches(spec *crdv1alpha1.AnomalySuppressionSpec, anomaly Anomaly) bool {
+	for _, field := range []struct{ expected, actual string }{
+		{spec.AggType, anomaly.AggType},
+		{spec.SourceIP, anomaly.SourceIP},
+		{spec.DestinationIP, anomaly.DestinationIP},
+		{spec.PodNamespace, anomaly.PodNamespace},
+		{spec.PodLabels, anomaly.PodLabels},
+		{spec.PodName, anomaly.PodName},
+		{spec.Direction, anomaly.Direction},
+		{spec.DestinationServicePortName, anomaly.DestinationServicePortName},
+		{spec.SourceNodeName, anomaly.SourceNodeName},
+		{spec.DestinationNodeName, anomaly.DestinationNodeName},
+	} {
+		if field.expected != "" && field.expected != field.actual {
+			return false
+		}
+	}
+	if spec.StartTime.IsZero() && spec.EndTime.IsZero() && spec.DailyStartTime == "" {
+		return true
+	}
+	// The time conditions can not be checked without the time of the anomaly
+	if anomaly.Time.IsZero() {
+		return false
+	}
+	if !spec.StartTime.IsZero() && anomaly.Time.Before(spec.StartTime.Time) {
+		return false
+	}
+	if !spec.EndTime.IsZero() && anomaly.Time.After(spec.EndTime.Time) {
+		return false
+	}
+	if spec.DailyStartTime != "" {
+		start, err := parseDailyTime(spec.DailyStartTime)
+		if err != nil {
+			return false
+		}
+		end, err := parseDailyTime(spec.DailyEndTime)
+		if err != nil {
+			return false
+		}
+		t := anomaly.Time.UTC()
+		sinceMidnight := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+		if start < end {
+			return sinceMidnight >= start && sinceMidnight < end
+		}
+		// The daily window spans midnight
+		return sinceMidnight >= start || sinceMidnight < end
+	}
+	return true
+}
+
+// parseDailyTime returns the duration since midnight of a time of the day in
+// HH:MM format.
+func parseDailyTime(dailyTime string) (time.Duration, error) {
+	t, err := time.Parse(dailyTimeLayout, dailyTime)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ValidateSpec returns an error if the AnomalySuppressionSpec is invalid.
+func ValidateSpec(spec *crdv1alpha1.AnomalySuppressionSpec) error {
+	if (spec.DailyStartTime == "") != (spec.DailyEndTime == "") {
+		return fmt.Errorf("dailyStartTime and dailyEndTime should be specified together")
+	}
+	if spec.DailyStartTime != "" {
+		start, err := parseDailyTime(spec.DailyStartTime)
+		if err != nil {
+			return fmt.Errorf("dailyStartTime should be in HH:MM format")
+		}
+		end, err := parseDailyTime(spec.DailyEndTime)
+		if err != nil {
+			return fmt.Errorf("dailyEndTime should be in HH:MM format")
+		}
+		if start == end {
+			return fmt.Errorf("dailyStartTime and dailyEndTime should be different")
+		}
+	}
+	if !spec.StartTime.IsZero() && !spec.EndTime.IsZero() && spec.EndTime.Before(&spec.StartTime) {
+		return fmt.Errorf("endTime should not be before startTime")
+	}
+	if spec.AggType == "" && spec.SourceIP == "" && spec.DestinationIP == "" && spec.PodNamespace == "" &&
+		spec.PodLabels == "" && spec.PodName == "" && spec.Direction == "" && spec.DestinationServicePortName == "" &&
+		spec.SourceNodeName == "" && spec.DestinationNodeName == "" &&
+		spec.StartTime.IsZero() && spec.EndTime.IsZero() && spec.DailyStartTime == "" {
+		return fmt.Errorf("an entity or a time window should be specified")
+	}
+	return nil
+}
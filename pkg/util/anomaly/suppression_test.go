@@ -0,0 +1,167 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package anomaly
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	crdv1alpha1 "antrea.io/theia/pkg/apis/crd/v1alpha1"
+)
+
+func TestIsSuppressed(t *testing.T) {
+	now := time.Date(2023, 3, 2, 12, 0, 0, 0, time.UTC)
+	backup := Anomaly{
+		AggType:                    "svc",
+		DestinationServicePortName: "db/backup:873",
+		Time:                       time.Date(2023, 3, 1, 2, 30, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		name     string
+		spec     crdv1alpha1.AnomalySuppressionSpec
+		anomaly  Anomaly
+		expected bool
+	}{
+		{
+			name:     "Matching entity",
+			spec:     crdv1alpha1.AnomalySuppressionSpec{AggType: "svc", DestinationServicePortName: "db/backup:873"},
+			anomaly:  backup,
+			expected: true,
+		},
+		{
+			name:     "Other entity",
+			spec:     crdv1alpha1.AnomalySuppressionSpec{AggType: "svc", DestinationServicePortName: "db/postgres:5432"},
+			anomaly:  backup,
+			expected: false,
+		},
+		{
+			name:     "Within daily window",
+			spec:     crdv1alpha1.AnomalySuppressionSpec{DestinationServicePortName: "db/backup:873", DailyStartTime: "02:00", DailyEndTime: "04:00"},
+			anomaly:  backup,
+			expected: true,
+		},
+		{
+			name:     "Outside daily window",
+			spec:     crdv1alpha1.AnomalySuppressionSpec{DestinationServicePortName: "db/backup:873", DailyStartTime: "03:00", DailyEndTime: "04:00"},
+			anomaly:  backup,
+			expected: false,
+		},
+		{
+			name:     "Within daily window spanning midnight",
+			spec:     crdv1alpha1.AnomalySuppressionSpec{DailyStartTime: "23:00", DailyEndTime: "03:00"},
+			anomaly:  backup,
+			expected: true,
+		},
+		{
+			name: "Within maintenance window",
+			spec: crdv1alpha1.AnomalySuppressionSpec{
+				StartTime: metav1.NewTime(time.Date(2023, 3, 1, 2, 0, 0, 0, time.UTC)),
+				EndTime:   metav1.NewTime(time.Date(2023, 3, 1, 3, 0, 0, 0, time.UTC)),
+			},
+			anomaly:  backup,
+			expected: true,
+		},
+		{
+			name: "Single anomaly",
+			spec: crdv1alpha1.AnomalySuppressionSpec{
+				DestinationServicePortName: "db/backup:873",
+				StartTime:                  metav1.NewTime(backup.Time),
+				EndTime:                    metav1.NewTime(backup.Time),
+			},
+			anomaly:  backup,
+			expected: true,
+		},
+		{
+			name:     "After maintenance window",
+			spec:     crdv1alpha1.AnomalySuppressionSpec{EndTime: metav1.NewTime(time.Date(2023, 3, 1, 2, 0, 0, 0, time.UTC))},
+			anomaly:  backup,
+			expected: false,
+		},
+		{
+			name:     "Unknown anomaly time",
+			spec:     crdv1alpha1.AnomalySuppressionSpec{DailyStartTime: "00:00", DailyEndTime: "23:59"},
+			anomaly:  Anomaly{AggType: "svc"},
+			expected: false,
+		},
+		{
+			name:     "Expired",
+			spec:     crdv1alpha1.AnomalySuppressionSpec{AggType: "svc", ExpirationTime: metav1.NewTime(now)},
+			anomaly:  backup,
+			expected: false,
+		},
+		{
+			name:     "Not expired",
+			spec:     crdv1alpha1.AnomalySuppressionSpec{AggType: "svc", ExpirationTime: metav1.NewTime(now.Add(time.Hour))},
+			anomaly:  backup,
+			expected: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suppressions := []*crdv1alpha1.AnomalySuppression{{Spec: tt.spec}}
+			assert.Equal(t, tt.expected, IsSuppressed(tt.anomaly, suppressions, now))
+		})
+	}
+}
+
+func TestValidateSpec(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        crdv1alpha1.AnomalySuppressionSpec
+		expectedErr error
+	}{
+		{
+			name: "Valid",
+			spec: crdv1alpha1.AnomalySuppressionSpec{AggType: "svc", DailyStartTime: "23:00", DailyEndTime: "01:00"},
+		},
+		{
+			name:        "Empty",
+			spec:        crdv1alpha1.AnomalySuppressionSpec{Reason: "backup"},
+			expectedErr: fmt.Errorf("an entity or a time window should be specified"),
+		},
+		{
+			name:        "Missing daily end time",
+			spec:        crdv1alpha1.AnomalySuppressionSpec{DailyStartTime: "23:00"},
+			expectedErr: fmt.Errorf("dailyStartTime and dailyEndTime should be specified together"),
+		},
+		{
+			name:        "Invalid daily start time",
+			spec:        crdv1alpha1.AnomalySuppressionSpec{DailyStartTime: "25:00", DailyEndTime: "01:00"},
+			expectedErr: fmt.Errorf("dailyStartTime should be in HH:MM format"),
+		},
+		{
+			name:        "Empty daily window",
+			spec:        crdv1alpha1.AnomalySuppressionSpec{DailyStartTime: "01:00", DailyEndTime: "01:00"},
+			expectedErr: fmt.Errorf("dailyStartTime and dailyEndTime should be different"),
+		},
+		{
+			name: "End before start",
+			spec: crdv1alpha1.AnomalySuppressionSpec{
+				StartTime: metav1.NewTime(time.Date(2023, 3, 1, 2, 0, 0, 0, time.UTC)),
+				EndTime:   metav1.NewTime(time.Date(2023, 3, 1, 1, 0, 0, 0, time.UTC)),
+			},
+			expectedErr: fmt.Errorf("endTime should not be before startTime"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedErr, ValidateSpec(&tt.spec))
+		})
+	}
+}
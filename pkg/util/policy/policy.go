@@ -0,0 +1,219 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package policy parses the policies recommended by policy recommendation
+// jobs into a common representation of their appliedTo, rules, peers and
+// ports.
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Policy holds the fields of a recommended K8s NetworkPolicy, Antrea
+// NetworkPolicy, Antrea ClusterNetworkPolicy, AdminNetworkPolicy,
+// BaselineAdminNetworkPolicy or ClusterGroup.
+type Policy struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Spec struct {
+		PodSelector      *LabelSelector  `yaml:"podSelector"`
+		AppliedTo        []Peer          `yaml:"appliedTo"`
+		Subject          *AdminSubject   `yaml:"subject"`
+		Ingress          []Rule          `yaml:"ingress"`
+		Egress           []Rule          `yaml:"egress"`
+		ServiceReference *NamespacedName `yaml:"serviceReference"`
+	} `yaml:"spec"`
+}
+
+type Rule struct {
+	Action     string           `yaml:"action"`
+	From       []Peer           `yaml:"from"`
+	To         []Peer           `yaml:"to"`
+	ToServices []NamespacedName `yaml:"toServices"`
+	Ports      []Port           `yaml:"ports"`
+}
+
+type Peer struct {
+	PodSelector       *LabelSelector `yaml:"podSelector"`
+	NamespaceSelector *LabelSelector `yaml:"namespaceSelector"`
+	IPBlock           *IPBlock       `yaml:"ipBlock"`
+	Group             string         `yaml:"group"`
+	// Peer fields of AdminNetworkPolicy and BaselineAdminNetworkPolicy.
+	Namespaces *LabelSelector `yaml:"namespaces"`
+	Pods       *NamespacedPod `yaml:"pods"`
+	Networks   []string       `yaml:"networks"`
+}
+
+// AdminSubject is the subject of AdminNetworkPolicy and
+// BaselineAdminNetworkPolicy, which plays the role of appliedTo.
+type AdminSubject struct {
+	Namespaces *LabelSelector `yaml:"namespaces"`
+	Pods       *NamespacedPod `yaml:"pods"`
+}
+
+type NamespacedPod struct {
+	NamespaceSelector *LabelSelector `yaml:"namespaceSelector"`
+	PodSelector       *LabelSelector `yaml:"podSelector"`
+}
+
+type IPBlock struct {
+	CIDR string `yaml:"cidr"`
+}
+
+type LabelSelector struct {
+	MatchLabels      map[string]string `yaml:"matchLabels"`
+	MatchExpressions []interface{}     `yaml:"matchExpressions"`
+}
+
+type NamespacedName struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+}
+
+type Port struct {
+	Protocol   string `yaml:"protocol"`
+	Port       int    `yaml:"port"`
+	PortNumber *struct {
+		Protocol string `yaml:"protocol"`
+		Port     int    `yaml:"port"`
+	} `yaml:"portNumber"`
+}
+
+// Parse parses a recommended policy in YAML format. The appliedTo of K8s
+// NetworkPolicies and the subject, peers and ports of AdminNetworkPolicies
+// and BaselineAdminNetworkPolicies are rewritten into the appliedTo, peers
+// and ports used by Antrea NetworkPolicies, so that all policies can be
+// handled the same way.
+func Parse(policyYaml string) (*Policy, error) {
+	policy := new(Policy)
+	if err := yaml.Unmarshal([]byte(policyYaml), policy); err != nil {
+		return nil, fmt.Errorf("failed to parse recommended policy: %v", err)
+	}
+	switch policy.Kind {
+	case "NetworkPolicy":
+		if policy.Spec.PodSelector != nil {
+			// K8s NetworkPolicy selects the Pods of its own Namespace.
+			policy.Spec.AppliedTo = []Peer{{PodSelector: policy.Spec.PodSelector}}
+		}
+	case "AdminNetworkPolicy", "BaselineAdminNetworkPolicy":
+		normalizeAdminPolicy(policy)
+	}
+	return policy, nil
+}
+
+func normalizeAdminPolicy(policy *Policy) {
+	if subject := policy.Spec.Subject; subject != nil {
+		if subject.Namespaces != nil {
+			policy.Spec.AppliedTo = []Peer{{NamespaceSelector: subject.Namespaces}}
+		} else if subject.Pods != nil {
+			policy.Spec.AppliedTo = []Peer{{NamespaceSelector: subject.Pods.NamespaceSelector, PodSelector: subject.Pods.PodSelector}}
+		}
+	}
+	normalizeRules := func(rules []Rule) {
+		for i := range rules {
+			rules[i].From = normalizeAdminPeers(rules[i].From)
+			rules[i].To = normalizeAdminPeers(rules[i].To)
+			for j, port := range rules[i].Ports {
+				if port.PortNumber != nil {
+					rules[i].Ports[j].Protocol = port.PortNumber.Protocol
+					rules[i].Ports[j].Port = port.PortNumber.Port
+				}
+			}
+		}
+	}
+	normalizeRules(policy.Spec.Ingress)
+	normalizeRules(policy.Spec.Egress)
+}
+
+func normalizeAdminPeers(peers []Peer) []Peer {
+	var result []Peer
+	for _, peer := range peers {
+		switch {
+		case peer.Namespaces != nil:
+			result = append(result, Peer{NamespaceSelector: peer.Namespaces})
+		case peer.Pods != nil:
+			result = append(result, Peer{NamespaceSelector: peer.Pods.NamespaceSelector, PodSelector: peer.Pods.PodSelector})
+		case len(peer.Networks) > 0:
+			for _, cidr := range peer.Networks {
+				result = append(result, Peer{IPBlock: &IPBlock{CIDR: cidr}})
+			}
+		default:
+			result = append(result, peer)
+		}
+	}
+	return result
+}
+
+// DescribePeer returns a short description of a peer, for example
+// "namespace=web,app=foo". The Namespace of the policy is used for Pod
+// selectors without Namespace selector, and ClusterGroups are described by
+// the Services they select when found in groupServices.
+func DescribePeer(peer Peer, namespace string, groupServices map[string]string) string {
+	if peer.Group != "" {
+		if svc, ok := groupServices[peer.Group]; ok {
+			return "service=" + svc
+		}
+		return "group=" + peer.Group
+	}
+	if peer.IPBlock != nil {
+		return "cidr=" + peer.IPBlock.CIDR
+	}
+	var parts []string
+	if peer.NamespaceSelector != nil {
+		for _, key := range SortedKeys(peer.NamespaceSelector.MatchLabels) {
+			parts = append(parts, "namespace="+peer.NamespaceSelector.MatchLabels[key])
+		}
+	} else if namespace != "" && peer.PodSelector != nil {
+		parts = append(parts, "namespace="+namespace)
+	}
+	if peer.PodSelector != nil {
+		for _, key := range SortedKeys(peer.PodSelector.MatchLabels) {
+			parts = append(parts, key+"="+peer.PodSelector.MatchLabels[key])
+		}
+	}
+	if len(parts) == 0 {
+		return "all"
+	}
+	return strings.Join(parts, ",")
+}
+
+// DescribePort returns a port in PROTOCOL/port format, TCP being the default
+// protocol.
+func DescribePort(port Port) string {
+	protocol := strings.ToUpper(port.Protocol)
+	if protocol == "" {
+		protocol = "TCP"
+	}
+	if port.Port == 0 {
+		return protocol
+	}
+	return fmt.Sprintf("%s/%d", protocol, port.Port)
+}
+
+func SortedKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,187 @@
+// Copyright 2023 Antrea Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for _, tt := range []struct {
+		name              string
+		policyYaml        string
+		expectedAppliedTo string
+		expectedIngress   []string
+		expectedEgress    []string
+		expectedErr       string
+	}{
+		{
+			name: "K8s NetworkPolicy",
+			policyYaml: `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: recommend-k8s-np-abcde
+  namespace: db
+spec:
+  ingress:
+  - from:
+    - namespaceSelector:
+        matchLabels:
+          name: web
+      podSelector:
+        matchLabels:
+          app: foo
+    ports:
+    - port: 5432
+      protocol: TCP
+  podSelector:
+    matchLabels:
+      app: postgres
+  policyTypes:
+  - Ingress
+`,
+			expectedAppliedTo: "namespace=db,app=postgres",
+			expectedIngress:   []string{"namespace=web,app=foo TCP/5432"},
+		},
+		{
+			name: "AdminNetworkPolicy",
+			policyYaml: `apiVersion: policy.networking.k8s.io/v1alpha1
+kind: AdminNetworkPolicy
+metadata:
+  name: recommend-allow-adminnp-abcde
+spec:
+  egress:
+  - action: Allow
+    ports:
+    - portNumber:
+        port: 443
+        protocol: TCP
+    to:
+    - networks:
+      - 192.168.0.1/32
+  ingress:
+  - action: Allow
+    from:
+    - pods:
+        namespaceSelector:
+          matchLabels:
+            kubernetes.io/metadata.name: web
+        podSelector:
+          matchLabels:
+            app: foo
+    ports:
+    - portNumber:
+        port: 5432
+        protocol: TCP
+  priority: 5
+  subject:
+    pods:
+      namespaceSelector:
+        matchLabels:
+          kubernetes.io/metadata.name: db
+      podSelector:
+        matchLabels:
+          app: postgres
+`,
+			expectedAppliedTo: "namespace=db,app=postgres",
+			expectedIngress:   []string{"namespace=web,app=foo TCP/5432"},
+			expectedEgress:    []string{"cidr=192.168.0.1/32 TCP/443"},
+		},
+		{
+			name: "BaselineAdminNetworkPolicy",
+			policyYaml: `apiVersion: policy.networking.k8s.io/v1alpha1
+kind: BaselineAdminNetworkPolicy
+metadata:
+  name: default
+spec:
+  ingress:
+  - action: Deny
+    from:
+    - namespaces: {}
+  subject:
+    namespaces: {}
+`,
+			expectedAppliedTo: "all",
+			expectedIngress:   []string{"all"},
+		},
+		{
+			name:        "Invalid YAML",
+			policyYaml:  "kind: [NetworkPolicy",
+			expectedErr: "failed to parse recommended policy",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := Parse(tt.policyYaml)
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, policy.Spec.AppliedTo, 1)
+			assert.Equal(t, tt.expectedAppliedTo, DescribePeer(policy.Spec.AppliedTo[0], policy.Metadata.Namespace, nil))
+			describeRules := func(rules []Rule, ingress bool) []string {
+				var descriptions []string
+				for _, rule := range rules {
+					peers := rule.To
+					if ingress {
+						peers = rule.From
+					}
+					for _, peer := range peers {
+						description := DescribePeer(peer, policy.Metadata.Namespace, nil)
+						for _, port := range rule.Ports {
+							description += " " + DescribePort(port)
+						}
+						descriptions = append(descriptions, description)
+					}
+				}
+				return descriptions
+			}
+			assert.Equal(t, tt.expectedIngress, describeRules(policy.Spec.Ingress, true))
+			assert.Equal(t, tt.expectedEgress, describeRules(policy.Spec.Egress, false))
+		})
+	}
+}
+
+func TestDescribePeer(t *testing.T) {
+	groupServices := map[string]string{"cg-db-postgres": "db/postgres"}
+	for _, tt := range []struct {
+		name     string
+		peer     Peer
+		expected string
+	}{
+		{
+			name:     "ClusterGroup of Service",
+			peer:     Peer{Group: "cg-db-postgres"},
+			expected: "service=db/postgres",
+		},
+		{
+			name:     "unknown ClusterGroup",
+			peer:     Peer{Group: "cg-other"},
+			expected: "group=cg-other",
+		},
+		{
+			name:     "Pod selector in policy Namespace",
+			peer:     Peer{PodSelector: &LabelSelector{MatchLabels: map[string]string{"tier": "web", "app": "foo"}}},
+			expected: "namespace=db,app=foo,tier=web",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DescribePeer(tt.peer, "db", groupServices))
+		})
+	}
+}
@@ -39,7 +39,8 @@ func ParseADAlgorithmName(algoName string) error {
 	case
 		"EWMA",
 		"ARIMA",
-		"DBSCAN":
+		"DBSCAN",
+		"ENSEMBLE":
 		return nil
 	}
 	return fmt.Errorf("input name %s is not a valid Throughput Anomaly Detection algorithm name", algoName)
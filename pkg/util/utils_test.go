@@ -96,6 +96,11 @@ func TestParseADAlgorithmName(t *testing.T) {
 			algoName:         "ARIMA",
 			expectedErrorMsg: "",
 		},
+		{
+			name:             "Valid ensemble case",
+			algoName:         "ENSEMBLE",
+			expectedErrorMsg: "",
+		},
 		{
 			name:             "Invalid name",
 			algoName:         "mock_name",
@@ -13,6 +13,7 @@
 # WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 # See the License for the specific language governing permissions and
 # limitations under the License.
+import functools
 import getopt
 import json
 import logging
@@ -105,6 +106,27 @@ AGG_FLOW_TABLE_COLUMNS_SVC = [
     'sum(throughput)'
 ]
 
+AGG_FLOW_TABLE_COLUMNS_NODE = [
+    'sourceNodeName',
+    'destinationNodeName',
+    'flowEndSeconds',
+    'sum(throughput)'
+]
+
+AGG_FLOW_TABLE_COLUMNS_NAMESPACE_INBOUND = [
+    "destinationPodNamespace AS podNamespace",
+    "'inbound' AS direction",
+    "flowEndSeconds",
+    "sum(throughput)"
+]
+
+AGG_FLOW_TABLE_COLUMNS_NAMESPACE_OUTBOUND = [
+    "sourcePodNamespace AS podNamespace",
+    "'outbound' AS direction",
+    "flowEndSeconds",
+    "sum(throughput)"
+]
+
 # Column names to be used to group and identify a connection uniquely
 DF_GROUP_COLUMNS = [
     'sourceIP',
@@ -136,25 +158,63 @@ DF_AGG_GRP_COLUMNS_SVC = [
     'destinationServicePortName',
 ]
 
+DF_AGG_GRP_COLUMNS_NODE = [
+    'sourceNodeName',
+    'destinationNodeName',
+]
+
+DF_AGG_GRP_COLUMNS_NAMESPACE = [
+    'podNamespace',
+    'direction',
+]
+
 MEANINGLESS_LABELS = [
     "pod-template-hash",
     "controller-revision-hash",
     "pod-template-generation",
 ]
 
-
-def calculate_ewma(throughput_list):
+# Default values of the algorithm parameters. A throughput is anomalous if
+# it deviates from the value calculated by EWMA or ARIMA by more than
+# sensitivity times the standard deviation.
+DEFAULT_SENSITIVITY = 1.0
+DEFAULT_EWMA_ALPHA = 0.5
+DEFAULT_ARIMA_ORDER = (1, 1, 1)
+DEFAULT_DBSCAN_EPS = 250000000.0
+DEFAULT_DBSCAN_MIN_SAMPLES = 4
+# The ENSEMBLE algorithm reports a throughput as anomalous when at least
+# quorum of the ENSEMBLE_ALGOS find it anomalous.
+ENSEMBLE_ALGOS = ["EWMA", "ARIMA", "DBSCAN"]
+DEFAULT_QUORUM = 2
+
+# Expressions of the metrics which can be analysed instead of the throughput,
+# evaluated on each flow record. The throughput is the number of bits sent
+# since the previous flow record of the connection divided by the time
+# elapsed, from which the packet rate is derived. A flow record is the first
+# one of its connection when its total and delta packet counts are equal.
+DEFAULT_METRIC = "throughput"
+METRIC_EXPRESSIONS = {
+    "throughput": "throughput",
+    "reverseThroughput": "reverseThroughput",
+    "packetRate": ("if(octetDeltaCount = 0, 0, packetDeltaCount * "
+                   "throughput / (octetDeltaCount * 8))"),
+    "octetDelta": "octetDeltaCount",
+    "newConnections": "packetTotalCount = packetDeltaCount",
+}
+
+
+def calculate_ewma(throughput_list, alpha=DEFAULT_EWMA_ALPHA):
     """
     The function calculates Exponential Weighted Moving Average (EWMA) for
     a given list of throughput values of a connection
     Args:
         throughput_list: Column of a dataframe containing throughput
+        alpha: The smoothing factor of EWMA
     Returns:
         A list of EWMA values calculated for the set of throughput values
         for that specific connection.
     """
 
-    alpha = 0.5  # Can be changed and passed as UDF value later.
     prev_ewma_val = 0.0
     ewma_row = []
     for ele in throughput_list:
@@ -165,22 +225,27 @@ def calculate_ewma(throughput_list):
     return ewma_row
 
 
-def calculate_ewma_anomaly(throughput_row, stddev):
+def calculate_ewma_anomaly(throughput_row, stddev,
+                           alpha=DEFAULT_EWMA_ALPHA,
+                           sensitivity=DEFAULT_SENSITIVITY):
     """
     The function categorizes whether a network flow is Anomalous or not
     based on the calculated EWMA value.
-    A network flow record is anomalous if abs(throughput - ewma) > Standard
-    Deviation.
+    A network flow record is anomalous if abs(throughput - ewma) >
+    sensitivity * Standard Deviation.
     True - Anomalous Traffic, False - Not Anomalous
 
     Args:
         throughput_row : The row of a dataframe containing all throughput data.
         stddev : The row of a dataframe containing standard Deviation
+        alpha : The smoothing factor of EWMA
+        sensitivity : The number of standard deviations above which a
+        throughput is anomalous
     Returns:
         A list of boolean values which signifies if a network flow record
         of that connection is anomalous or not.
     """
-    ewma_arr = calculate_ewma(throughput_row)
+    ewma_arr = calculate_ewma(throughput_row, alpha)
     anomaly_result = []
 
     if ewma_arr is None:
@@ -204,15 +269,14 @@ def calculate_ewma_anomaly(throughput_row, stddev):
                              "for this flow record")
                 result = False
             else:
-                float(stddev)
                 result = True if (abs(float(throughput_row[i]) - float(
                     ewma_arr[i])) >
-                                  float(stddev)) else False
+                                  sensitivity * float(stddev)) else False
             anomaly_result.append(result)
     return anomaly_result
 
 
-def calculate_arima(throughputs):
+def calculate_arima(throughputs, order=DEFAULT_ARIMA_ORDER):
     """
     The function calculates AutoRegressive Integrated Moving Average
     (ARIMA) for a given list of throughput values of a connection
@@ -221,6 +285,7 @@ def calculate_arima(throughputs):
     taken into account for calculation. We return empty value in that case.
     Args:
         throughputs: Column of a dataframe containing throughput
+        order: The (p, d, q) order of the ARIMA model
     Returns:
         A list of ARIMA values calculated for the set of throughput values
         for that specific connection.
@@ -244,7 +309,7 @@ def calculate_arima(throughputs):
             history = [x for x in train]
             predictions = list()
             for t in range(len(test)):
-                model = ARIMA(history, order=(1, 1, 1))
+                model = ARIMA(history, order=tuple(order))
                 model_fit = model.fit()
                 output = model_fit.forecast()
                 yhat = output[0]
@@ -264,22 +329,27 @@ def calculate_arima(throughputs):
             return None
 
 
-def calculate_arima_anomaly(throughput_row, stddev):
+def calculate_arima_anomaly(throughput_row, stddev,
+                            order=DEFAULT_ARIMA_ORDER,
+                            sensitivity=DEFAULT_SENSITIVITY):
     """
     The function categorizes whether a network flow is Anomalous or not based
     on the calculated ARIMA value. A traffic is anomalous if abs(throughput
-    - arima) > Standard Deviation. True - Anomalous Traffic, False - Not
-    Anomalous
+    - arima) > sensitivity * Standard Deviation. True - Anomalous Traffic,
+    False - Not Anomalous
 
     Args:
         Throughput_list : The row of a dataframe containing all throughput
         data.
         stddev : The row of a dataframe containing standard Deviation
+        order : The (p, d, q) order of the ARIMA model
+        sensitivity : The number of standard deviations above which a
+        throughput is anomalous
     Returns:
         A list of boolean values which signifies if a network flow record
         of that connection is anomalous or not.
     """
-    arima_arr = calculate_arima(throughput_row)
+    arima_arr = calculate_arima(throughput_row, order)
     anomaly_result = []
 
     if arima_arr is None:
@@ -304,7 +374,7 @@ def calculate_arima_anomaly(throughput_row, stddev):
                 result = False
             else:
                 result = True if (abs(float(throughput_row[i]) - float(
-                    arima_arr[i])) > float(stddev)) else False
+                    arima_arr[i])) > sensitivity * float(stddev)) else False
             anomaly_result.append(result)
     return anomaly_result
 
@@ -322,13 +392,18 @@ def calculate_dbscan(throughput_list):
     return placeholder_throughput_list
 
 
-def calculate_dbscan_anomaly(throughput_row, stddev=None):
+def calculate_dbscan_anomaly(throughput_row, stddev=None,
+                             eps=DEFAULT_DBSCAN_EPS,
+                             min_samples=DEFAULT_DBSCAN_MIN_SAMPLES):
     """
     The function calculates Density-based spatial clustering of applications
     with Noise (DBSCAN) for a given list of throughput values of a connection
     Args:
         throughput_row : The row of a dataframe containing all throughput data.
         stddev : The row of a dataframe containing standard Deviation
+        eps : The maximum distance between two samples of a cluster
+        min_samples : The number of samples in a neighborhood for a point
+        to be considered as a core point
         Assumption: Since DBSCAN needs only numeric value to train and start
         prediction, any connection with null values will not be taken
         into account for calculation. We return empty value in that case.
@@ -339,7 +414,7 @@ def calculate_dbscan_anomaly(throughput_row, stddev=None):
     anomaly_result = []
     np_throughput_list = np.array(throughput_row)
     np_throughput_list = np_throughput_list.reshape(-1, 1)
-    outlier_detection = DBSCAN(min_samples=4, eps=250000000)
+    outlier_detection = DBSCAN(min_samples=min_samples, eps=eps)
     clusters = outlier_detection.fit_predict(np_throughput_list)
     for i in clusters:
         if i == -1:
@@ -349,12 +424,97 @@ def calculate_dbscan_anomaly(throughput_row, stddev=None):
     return anomaly_result
 
 
+def calculate_ensemble_anomaly(throughput_row, stddev,
+                               quorum=DEFAULT_QUORUM,
+                               alpha=DEFAULT_EWMA_ALPHA,
+                               order=DEFAULT_ARIMA_ORDER,
+                               sensitivity=DEFAULT_SENSITIVITY,
+                               eps=DEFAULT_DBSCAN_EPS,
+                               min_samples=DEFAULT_DBSCAN_MIN_SAMPLES):
+    """
+    The function runs all the ENSEMBLE_ALGOS on the throughput values of a
+    connection and combines their verdicts. A throughput is anomalous if at
+    least quorum of the algorithms find it anomalous.
+
+    Args:
+        throughput_row : The row of a dataframe containing all throughput data.
+        stddev : The row of a dataframe containing standard Deviation
+        quorum : The number of algorithms which must agree on an anomaly
+        The other arguments are the parameters of the algorithms.
+    Returns:
+        A tuple of three lists: the number of algorithms finding each
+        throughput anomalous, the combined verdicts, and the verdict of each
+        algorithm formatted as "EWMA:true,ARIMA:false,DBSCAN:true".
+    """
+    results = {
+        "EWMA": calculate_ewma_anomaly(throughput_row, stddev, alpha,
+                                       sensitivity),
+        "ARIMA": calculate_arima_anomaly(throughput_row, stddev, order,
+                                         sensitivity),
+        "DBSCAN": calculate_dbscan_anomaly(throughput_row, stddev, eps,
+                                           min_samples),
+    }
+    votes = []
+    anomaly_result = []
+    verdicts = []
+    for i in range(len(throughput_row)):
+        # An algorithm which could not evaluate the throughput, e.g. ARIMA
+        # with too few values, does not vote for an anomaly.
+        verdict = {algo: i < len(results[algo]) and bool(results[algo][i])
+                   for algo in ENSEMBLE_ALGOS}
+        vote = sum(verdict.values())
+        votes.append(float(vote))
+        anomaly_result.append(vote >= quorum)
+        verdicts.append(",".join(
+            "{}:{}".format(algo, str(verdict[algo]).lower())
+            for algo in ENSEMBLE_ALGOS))
+    return votes, anomaly_result, verdicts
+
+
+def get_algo_params(algo_type, sensitivity=None, ewma_alpha=None,
+                    arima_order=None, dbscan_eps=None,
+                    dbscan_min_samples=None, quorum=None):
+    """
+    The function returns the parameters used by the given algorithm, with
+    the default values of the parameters which are not specified.
+    """
+    if algo_type == "EWMA":
+        return {
+            "sensitivity": sensitivity or DEFAULT_SENSITIVITY,
+            "ewmaAlpha": ewma_alpha or DEFAULT_EWMA_ALPHA,
+        }
+    elif algo_type == "ARIMA":
+        return {
+            "sensitivity": sensitivity or DEFAULT_SENSITIVITY,
+            "arimaOrder": list(arima_order or DEFAULT_ARIMA_ORDER),
+        }
+    elif algo_type == "DBSCAN":
+        return {
+            "dbscanEps": dbscan_eps or DEFAULT_DBSCAN_EPS,
+            "dbscanMinSamples": (dbscan_min_samples or
+                                 DEFAULT_DBSCAN_MIN_SAMPLES),
+        }
+    elif algo_type == "ENSEMBLE":
+        algo_params = {"quorum": quorum or DEFAULT_QUORUM}
+        for algo in ENSEMBLE_ALGOS:
+            algo_params.update(get_algo_params(
+                algo, sensitivity, ewma_alpha, arima_order, dbscan_eps,
+                dbscan_min_samples))
+        return algo_params
+    return {}
+
+
 def filter_df_with_true_anomalies(
-        spark, plotDF, algo_type, agg_flow=None, pod_label=None):
+        spark, plotDF, algo_type, agg_flow=None, pod_label=None,
+        save_normal_points=False):
+    zip_columns = ["flowEndSeconds", "algoCalc", "throughputs", "anomaly"]
+    verdict_columns = []
+    if algo_type == "ENSEMBLE":
+        zip_columns.append("algoVerdicts")
+        verdict_columns.append(
+            f.col("new.algoVerdicts").alias("algoVerdicts"))
     newDF = plotDF.withColumn(
-        "new", f.arrays_zip(
-            "flowEndSeconds", "algoCalc", "throughputs",
-            "anomaly")).withColumn(
+        "new", f.arrays_zip(*zip_columns)).withColumn(
         "new", f.explode("new"))
     if agg_flow == "pod":
         plotDF = newDF.select(
@@ -364,7 +524,7 @@ def filter_df_with_true_anomalies(
             "throughputStandardDeviation", "algoType",
             f.col("new.algoCalc").alias("algoCalc"),
             f.col("new.throughputs").alias("throughput"),
-            f.col("new.anomaly").alias("anomaly"))
+            f.col("new.anomaly").alias("anomaly"), *verdict_columns)
     elif agg_flow == "external":
         plotDF = newDF.select(
             "destinationIP", "aggType",
@@ -372,7 +532,7 @@ def filter_df_with_true_anomalies(
             "throughputStandardDeviation", "algoType",
             f.col("new.algoCalc").alias("algoCalc"),
             f.col("new.throughputs").alias("throughput"),
-            f.col("new.anomaly").alias("anomaly"))
+            f.col("new.anomaly").alias("anomaly"), *verdict_columns)
     elif agg_flow == "svc":
         plotDF = newDF.select(
             "destinationServicePortName", "aggType",
@@ -380,7 +540,23 @@ def filter_df_with_true_anomalies(
             "throughputStandardDeviation", "algoType",
             f.col("new.algoCalc").alias("algoCalc"),
             f.col("new.throughputs").alias("throughput"),
-            f.col("new.anomaly").alias("anomaly"))
+            f.col("new.anomaly").alias("anomaly"), *verdict_columns)
+    elif agg_flow == "node":
+        plotDF = newDF.select(
+            "sourceNodeName", "destinationNodeName", "aggType",
+            f.col("new.flowEndSeconds").alias("flowEndSeconds"),
+            "throughputStandardDeviation", "algoType",
+            f.col("new.algoCalc").alias("algoCalc"),
+            f.col("new.throughputs").alias("throughput"),
+            f.col("new.anomaly").alias("anomaly"), *verdict_columns)
+    elif agg_flow == "namespace":
+        plotDF = newDF.select(
+            "podNamespace", "direction", "aggType",
+            f.col("new.flowEndSeconds").alias("flowEndSeconds"),
+            "throughputStandardDeviation", "algoType",
+            f.col("new.algoCalc").alias("algoCalc"),
+            f.col("new.throughputs").alias("throughput"),
+            f.col("new.anomaly").alias("anomaly"), *verdict_columns)
     else:
         plotDF = newDF.select(
             "sourceIP", "sourceTransportPort", "destinationIP",
@@ -390,8 +566,13 @@ def filter_df_with_true_anomalies(
             "throughputStandardDeviation", "algoType",
             f.col("new.algoCalc").alias("algoCalc"),
             f.col("new.throughputs").alias("throughput"),
-            f.col("new.anomaly").alias("anomaly"))
-    ret_plot = plotDF.where(~plotDF.anomaly.isin([False]))
+            f.col("new.anomaly").alias("anomaly"), *verdict_columns)
+    if save_normal_points:
+        # Keep the whole series, so that the anomalies can be compared with
+        # the normal throughputs and the values calculated by the Algo
+        ret_plot = plotDF
+    else:
+        ret_plot = plotDF.where(~plotDF.anomaly.isin([False]))
     if ret_plot.count() == 0:
         ret_plot = ret_plot.collect()
         if agg_flow == "":
@@ -410,11 +591,14 @@ def filter_df_with_true_anomalies(
             "podName": 'None',
             "destinationServicePortName": 'None',
             "direction": 'None',
+            "sourceNodeName": 'None',
+            "destinationNodeName": 'None',
             "flowEndSeconds": 0,
             "throughputStandardDeviation": 0,
             "aggType": agg_type,
             "algoType": algo_type,
             "algoCalc": 0.0,
+            "algoVerdicts": "",
             "throughput": 0.0,
             "anomaly": "NO ANOMALY DETECTED"})
         ret_plot = spark.createDataFrame(ret_plot)
@@ -422,7 +606,8 @@ def filter_df_with_true_anomalies(
 
 
 def plot_anomaly(spark, init_plot_df, algo_type, algo_func, anomaly_func,
-                 tad_id_input, agg_flow=None, pod_label=None):
+                 tad_id_input, agg_flow=None, pod_label=None,
+                 algo_params=None, metric=None, save_normal_points=False):
     # Insert the Algo currently in use
     init_plot_df = init_plot_df.withColumn('algoType', f.lit(algo_type))
     # Schema List
@@ -436,11 +621,8 @@ def plot_anomaly(spark, init_plot_df, algo_type, algo_func, anomaly_func,
         StructField('flowEndSeconds', ArrayType(TimestampType(), True)),
         StructField('throughputStandardDeviation', DoubleType(), True)
     ]
-    # Calculate anomaly Values on the DF
-    algo_func_rdd = init_plot_df.rdd.map(
-        lambda x: (x[0], x[1], x[2], x[3], x[4], x[5],
-                   x[6], x[7], x[8], x[9], x[10], algo_func(x[8]),
-                   anomaly_func(x[8], x[7])))
+    # Index of the throughputs and their standard deviation in the DF
+    throughput_idx, stddev_idx = 8, 7
     if agg_flow == "pod":
         if pod_label:
             schema_list = [
@@ -460,54 +642,109 @@ def plot_anomaly(spark, init_plot_df, algo_type, algo_func, anomaly_func,
                                                         True)),
                 StructField('throughputStandardDeviation', DoubleType(), True)
             ]
-        algo_func_rdd = init_plot_df.rdd.map(
-            lambda x: (x[0], x[1], x[2], x[3], x[4], x[5],
-                       x[6], x[7], algo_func(x[5]),
-                       anomaly_func(x[5], x[4])))
+        throughput_idx, stddev_idx = 5, 4
     elif agg_flow == "svc":
         schema_list = [
             StructField('destinationServicePortName', StringType(), True),
             StructField('flowEndSeconds', ArrayType(TimestampType(), True)),
             StructField('throughputStandardDeviation', DoubleType(), True)
         ]
-        algo_func_rdd = init_plot_df.rdd.map(
-            lambda x: (x[0], x[1], x[2], x[3], x[4], x[5], algo_func(x[3]),
-                       anomaly_func(x[3], x[2])))
+        throughput_idx, stddev_idx = 3, 2
     elif agg_flow == "external":
         schema_list = [
             StructField('destinationIP', StringType(), True),
             StructField('flowEndSeconds', ArrayType(TimestampType(), True)),
             StructField('throughputStandardDeviation', DoubleType(), True)
         ]
+        throughput_idx, stddev_idx = 3, 2
+    elif agg_flow == "node" or agg_flow == "namespace":
+        if agg_flow == "node":
+            schema_list = [
+                StructField('sourceNodeName', StringType(), True),
+                StructField('destinationNodeName', StringType(), True),
+            ]
+        else:
+            schema_list = [
+                StructField('podNamespace', StringType(), True),
+                StructField('direction', StringType(), True),
+            ]
+        schema_list += [
+            StructField('flowEndSeconds', ArrayType(TimestampType(), True)),
+            StructField('throughputStandardDeviation', DoubleType(), True)
+        ]
+        throughput_idx, stddev_idx = 4, 3
+
+    # Calculate anomaly Values on the DF
+    if algo_type == "ENSEMBLE":
+        # The ENSEMBLE anomaly function also returns the algoCalc values,
+        # which are the number of algorithms finding each throughput
+        # anomalous, and the verdict of each algorithm.
+        algo_func_rdd = init_plot_df.rdd.map(
+            lambda x: tuple(x) + anomaly_func(x[throughput_idx],
+                                              x[stddev_idx]))
+    else:
         algo_func_rdd = init_plot_df.rdd.map(
-            lambda x: (x[0], x[1], x[2], x[3], x[4], x[5], algo_func(x[3]),
-                       anomaly_func(x[3], x[2])))
+            lambda x: tuple(x) + (algo_func(x[throughput_idx]),
+                                  anomaly_func(x[throughput_idx],
+                                               x[stddev_idx])))
 
     # Schema for the Dataframe to be created from the RDD
-    algo_func_rdd_Schema = StructType(schema_list + [
+    result_schema_list = [
         StructField('throughputs', ArrayType(DecimalType(38, 18), True)),
         StructField('aggType', StringType(), True),
         StructField('algoType', StringType(), True),
         StructField('algoCalc', ArrayType(DoubleType(), True)),
         StructField('anomaly', ArrayType(BooleanType(), True))
-    ])
+    ]
+    if algo_type == "ENSEMBLE":
+        result_schema_list.append(
+            StructField('algoVerdicts', ArrayType(StringType(), True)))
+    algo_func_rdd_Schema = StructType(schema_list + result_schema_list)
 
     anomalyDF = spark.createDataFrame(algo_func_rdd, algo_func_rdd_Schema)
     ret_plotDF = filter_df_with_true_anomalies(spark, anomalyDF, algo_type,
-                                               agg_flow, pod_label)
+                                               agg_flow, pod_label,
+                                               save_normal_points)
     # Write anomalous records to DB/CSV - Module WIP
     # Module to write to CSV. Optional.
     ret_plotDF = ret_plotDF.withColumn(
         "anomaly", f.col("anomaly").cast(
             "string"))
     ret_plotDF = ret_plotDF.withColumn('id', f.lit(str(tad_id_input)))
+    # Record the parameters of the algorithm so that runs are reproducible
+    ret_plotDF = ret_plotDF.withColumn(
+        'algoParams', f.lit(json.dumps(algo_params or {}, sort_keys=True)))
+    # The throughput column holds the values of the analysed metric
+    ret_plotDF = ret_plotDF.withColumn(
+        'metric', f.lit(metric or DEFAULT_METRIC))
     return ret_plotDF
 
 
+def get_metric_columns(columns, metric=None):
+    """
+    The function replaces the aggregated throughput in the given columns of
+    the flow record table with the given metric. The aggregated column keeps
+    its name, so that the values of all the metrics are read the same way.
+    """
+    if not metric or metric == DEFAULT_METRIC:
+        return columns
+    metric_columns = []
+    for column in columns:
+        if column.endswith("(throughput)"):
+            column = "{}({}) AS `{}`".format(
+                column[:-len("(throughput)")], METRIC_EXPRESSIONS[metric],
+                column)
+        metric_columns.append(column)
+    return metric_columns
+
+
 def generate_tad_sql_query(start_time, end_time, ns_ignore_list,
                            agg_flow=None, pod_label=None, external_ip=None,
                            svc_port_name=None, pod_name=None,
-                           pod_namespace=None):
+                           pod_namespace=None, node_name=None, metric=None,
+                           flow_filter=None):
+    # flow_filter is a boolean expression on the flows table, which is
+    # generated from the validated flow filter of the job by the controller.
     if agg_flow == "pod":
         agg_flow_table_columns_pod_inbound = (
             AGG_FLOW_TABLE_COLUMNS_POD_INBOUND)
@@ -553,25 +790,67 @@ def generate_tad_sql_query(start_time, end_time, ns_ignore_list,
                     ", ".join("'{}'".format(x) for x in ns_ignore_list)))
         else:
             sql_query_extension = ""
+        if flow_filter:
+            sql_query_extension += " AND ({})".format(flow_filter)
         sql_query = (
             "SELECT * FROM "
             "(SELECT {0} FROM {1} WHERE {2} {6} GROUP BY {3}) "
             "UNION ALL "
             "(SELECT {4} FROM {1} WHERE {5} {6} GROUP BY {3}) ".format(
-                ", ".join(agg_flow_table_columns_pod_inbound),
+                ", ".join(get_metric_columns(
+                    agg_flow_table_columns_pod_inbound, metric)),
                 table_name, inbound_condition, ", ".join(
                     df_agg_grp_columns_pod + ['flowEndSeconds']),
-                ", ".join(agg_flow_table_columns_pod_outbound),
+                ", ".join(get_metric_columns(
+                    agg_flow_table_columns_pod_outbound, metric)),
                 outbound_condition, sql_query_extension))
+    elif agg_flow == "namespace":
+        inbound_condition = ["destinationPodNamespace <> ''"]
+        outbound_condition = ["sourcePodNamespace <> ''"]
+        if pod_namespace:
+            inbound_condition.append(
+                "destinationPodNamespace = '{}'".format(pod_namespace))
+            outbound_condition.append(
+                "sourcePodNamespace = '{}'".format(pod_namespace))
+        sql_query_extension = []
+        if ns_ignore_list:
+            sql_query_extension.append(
+                "sourcePodNamespace NOT IN ({0}) AND "
+                "destinationPodNamespace NOT IN ({0})".format(
+                    ", ".join("'{}'".format(x) for x in ns_ignore_list)))
+        if start_time:
+            sql_query_extension.append(
+                "flowStartSeconds >= '{}'".format(start_time))
+        if end_time:
+            sql_query_extension.append(
+                "flowEndSeconds < '{}'".format(end_time))
+        if flow_filter:
+            sql_query_extension.append("({})".format(flow_filter))
+        sql_query = (
+            "SELECT * FROM "
+            "(SELECT {0} FROM {1} WHERE {2} GROUP BY {3}) "
+            "UNION ALL "
+            "(SELECT {4} FROM {1} WHERE {5} GROUP BY {3}) ".format(
+                ", ".join(get_metric_columns(
+                    AGG_FLOW_TABLE_COLUMNS_NAMESPACE_INBOUND, metric)),
+                table_name,
+                " AND ".join(inbound_condition + sql_query_extension),
+                ", ".join(DF_AGG_GRP_COLUMNS_NAMESPACE + ['flowEndSeconds']),
+                ", ".join(get_metric_columns(
+                    AGG_FLOW_TABLE_COLUMNS_NAMESPACE_OUTBOUND, metric)),
+                " AND ".join(outbound_condition + sql_query_extension)))
     else:
         common_flow_table_columns = FLOW_TABLE_COLUMNS
         if agg_flow == "external":
             common_flow_table_columns = AGG_FLOW_TABLE_COLUMNS_EXTERNAL
         elif agg_flow == "svc":
             common_flow_table_columns = AGG_FLOW_TABLE_COLUMNS_SVC
+        elif agg_flow == "node":
+            common_flow_table_columns = AGG_FLOW_TABLE_COLUMNS_NODE
 
         sql_query = ("SELECT {} FROM {} ".format(
-            ", ".join(common_flow_table_columns), table_name))
+            ", ".join(get_metric_columns(common_flow_table_columns, metric)),
+            table_name))
         sql_query_extension = []
         if ns_ignore_list:
             sql_query_extension.append(
@@ -584,6 +863,8 @@ def generate_tad_sql_query(start_time, end_time, ns_ignore_list,
         if end_time:
             sql_query_extension.append(
                 "flowEndSeconds < '{}'".format(end_time))
+        if flow_filter:
+            sql_query_extension.append("({})".format(flow_filter))
         if agg_flow:
             if agg_flow == "external":
                 # TODO agg=destination IP, change the name to external
@@ -599,6 +880,13 @@ def generate_tad_sql_query(start_time, end_time, ns_ignore_list,
                 else:
                     sql_query_extension.append(
                         "destinationServicePortName <> ''")
+            elif agg_flow == "node":
+                if node_name:
+                    sql_query_extension.append(
+                        "(sourceNodeName = '{0}' OR "
+                        "destinationNodeName = '{0}')".format(node_name))
+                sql_query_extension.append(
+                    "sourceNodeName <> '' AND destinationNodeName <> ''")
 
         if sql_query_extension:
             sql_query += "WHERE " + " AND ".join(sql_query_extension) + " "
@@ -607,6 +895,8 @@ def generate_tad_sql_query(start_time, end_time, ns_ignore_list,
             df_group_columns = DF_AGG_GRP_COLUMNS_EXTERNAL
         elif agg_flow == "svc":
             df_group_columns = DF_AGG_GRP_COLUMNS_SVC
+        elif agg_flow == "node":
+            df_group_columns = DF_AGG_GRP_COLUMNS_NODE
 
         sql_query += "GROUP BY {} ".format(
             ", ".join(df_group_columns + [
@@ -623,6 +913,10 @@ def assign_flow_type(prepared_DF, agg_flow=None, direction=None):
         prepared_DF = prepared_DF.withColumn('aggType', f.lit("svc"))
     elif agg_flow == "pod":
         prepared_DF = prepared_DF.withColumn('aggType', f.lit("pod"))
+    elif agg_flow == "node":
+        prepared_DF = prepared_DF.withColumn('aggType', f.lit("node"))
+    elif agg_flow == "namespace":
+        prepared_DF = prepared_DF.withColumn('aggType', f.lit("namespace"))
     else:
         prepared_DF = prepared_DF.withColumn('aggType', f.lit("None"))
     return prepared_DF
@@ -647,11 +941,14 @@ def remove_meaningless_labels(podLabels):
 def anomaly_detection(algo_type, db_jdbc_address, start_time, end_time,
                       tad_id_input, ns_ignore_list, agg_flow=None,
                       pod_label=None, external_ip=None, svc_port_name=None,
-                      pod_name=None, pod_namespace=None):
+                      pod_name=None, pod_namespace=None, node_name=None,
+                      algo_params=None, metric=None, flow_filter=None,
+                      save_normal_points=False):
     spark = SparkSession.builder.getOrCreate()
     sql_query = generate_tad_sql_query(
         start_time, end_time, ns_ignore_list, agg_flow, pod_label,
-        external_ip, svc_port_name, pod_name, pod_namespace)
+        external_ip, svc_port_name, pod_name, pod_namespace, node_name,
+        metric, flow_filter)
     initDF = (
         spark.read.format("jdbc").option(
             'driver', "ru.yandex.clickhouse.ClickHouseDriver").option(
@@ -671,6 +968,10 @@ def anomaly_detection(algo_type, db_jdbc_address, start_time, end_time,
             df_agg_grp_columns = DF_AGG_GRP_COLUMNS_EXTERNAL
         elif agg_flow == "svc":
             df_agg_grp_columns = DF_AGG_GRP_COLUMNS_SVC
+        elif agg_flow == "node":
+            df_agg_grp_columns = DF_AGG_GRP_COLUMNS_NODE
+        elif agg_flow == "namespace":
+            df_agg_grp_columns = DF_AGG_GRP_COLUMNS_NAMESPACE
         prepared_DF = initDF.groupby(df_agg_grp_columns).agg(
             f.collect_list("flowEndSeconds").alias("flowEndSeconds"),
             f.stddev_samp("sum(throughput)").alias(
@@ -694,19 +995,48 @@ def anomaly_detection(algo_type, db_jdbc_address, start_time, end_time,
             )
         )
 
+    if algo_params is None:
+        algo_params = get_algo_params(algo_type)
     if algo_type == "EWMA":
-        ret_plot = plot_anomaly(spark, prepared_DF, algo_type, calculate_ewma,
-                                calculate_ewma_anomaly, tad_id_input, agg_flow,
-                                pod_label)
+        ret_plot = plot_anomaly(
+            spark, prepared_DF, algo_type,
+            functools.partial(calculate_ewma,
+                              alpha=algo_params["ewmaAlpha"]),
+            functools.partial(calculate_ewma_anomaly,
+                              alpha=algo_params["ewmaAlpha"],
+                              sensitivity=algo_params["sensitivity"]),
+            tad_id_input, agg_flow, pod_label, algo_params, metric,
+            save_normal_points)
     elif algo_type == "ARIMA":
-        ret_plot = plot_anomaly(spark, prepared_DF, algo_type, calculate_arima,
-                                calculate_arima_anomaly, tad_id_input,
-                                agg_flow, pod_label)
+        ret_plot = plot_anomaly(
+            spark, prepared_DF, algo_type,
+            functools.partial(calculate_arima,
+                              order=algo_params["arimaOrder"]),
+            functools.partial(calculate_arima_anomaly,
+                              order=algo_params["arimaOrder"],
+                              sensitivity=algo_params["sensitivity"]),
+            tad_id_input, agg_flow, pod_label, algo_params, metric,
+            save_normal_points)
     elif algo_type == "DBSCAN":
-        ret_plot = plot_anomaly(spark, prepared_DF, algo_type,
-                                calculate_dbscan,
-                                calculate_dbscan_anomaly, tad_id_input,
-                                agg_flow, pod_label)
+        ret_plot = plot_anomaly(
+            spark, prepared_DF, algo_type, calculate_dbscan,
+            functools.partial(calculate_dbscan_anomaly,
+                              eps=algo_params["dbscanEps"],
+                              min_samples=algo_params["dbscanMinSamples"]),
+            tad_id_input, agg_flow, pod_label, algo_params, metric,
+            save_normal_points)
+    elif algo_type == "ENSEMBLE":
+        ret_plot = plot_anomaly(
+            spark, prepared_DF, algo_type, None,
+            functools.partial(calculate_ensemble_anomaly,
+                              quorum=algo_params["quorum"],
+                              alpha=algo_params["ewmaAlpha"],
+                              order=algo_params["arimaOrder"],
+                              sensitivity=algo_params["sensitivity"],
+                              eps=algo_params["dbscanEps"],
+                              min_samples=algo_params["dbscanMinSamples"]),
+            tad_id_input, agg_flow, pod_label, algo_params, metric,
+            save_normal_points)
     return spark, ret_plot
 
 
@@ -741,12 +1071,24 @@ def main():
     svc_port_name = ""
     pod_name = ""
     pod_namespace = ""
+    node_name = ""
+    sensitivity = None
+    ewma_alpha = None
+    arima_order = None
+    dbscan_eps = None
+    dbscan_min_samples = None
+    quorum = None
+    metric = DEFAULT_METRIC
+    flow_filter = ""
+    save_normal_points = False
     help_message = """
     Start the Throughput Anomaly Detection spark job.
         Options:
         -h, --help: Show help message.
         -a, --algo=EWMA: Type argument describes the anomaly detection Algo
-            to use. Currently Supported Algos are EWMA, ARIMA and DBSCAN
+            to use. Currently Supported Algos are EWMA, ARIMA, DBSCAN and
+            ENSEMBLE, which runs all the other Algos and combines their
+            results
         -d, --db_jdbc_url=None: The JDBC URL used by Spark jobs connect to
             the ClickHouse database for reading flow records and writing
             result. jdbc:clickhouse://clickhouse-clickhouse.flow-visibility
@@ -770,11 +1112,33 @@ def main():
         -N, --pod-name=None: Aggregated Flow Throughput Anomaly Detection
             to/from Pod using pod Name
         -P, --pod-namespace=None: Aggregated Flow Throughput Anomaly Detection
-            to/from Pod using pod namespace
+            to/from Pod using pod namespace, or to/from the given namespace
+            when agg-flow is namespace
         -x, --external-ip=None: Aggregated Flow Throughput Anomaly Detection
             to Destination IP
         -p, --svc-port-name=None: Aggregated Flow Throughput Anomaly Detection
             to Destination Service Port
+        --node-name=None: Aggregated Flow Throughput Anomaly Detection
+            between Nodes, limited to the traffic to/from the given Node
+        --sensitivity=1.0: Number of standard deviations the throughput may
+            deviate from the value calculated by EWMA or ARIMA before it is
+            considered anomalous
+        --ewma-alpha=0.5: Smoothing factor of EWMA, in (0, 1]
+        --arima-order=1,1,1: Order (p, d, q) of the ARIMA model
+        --dbscan-eps=250000000: Maximum throughput distance between two
+            samples of the same DBSCAN cluster
+        --dbscan-min-samples=4: Number of samples in a neighborhood for a
+            point to be considered as a DBSCAN core point
+        --quorum=2: Number of Algos which must find a throughput anomalous
+            for ENSEMBLE to report it
+        --metric=throughput: Metric to analyse instead of the throughput.
+            Currently supported metrics are throughput, reverseThroughput,
+            packetRate, octetDelta and newConnections
+        --flow-filter=None: ClickHouse boolean expression restricting the
+            flows considered for the Throughput Anomaly Detection. It is
+            generated by the Theia Manager from the flow filter of the job
+        --save-normal-points: Save the non-anomalous throughputs together
+            with the anomalies, so that the whole series can be plotted
         """
 
     # TODO: change to use argparse instead of getopt for options
@@ -796,6 +1160,16 @@ def main():
                 "svc-port-name=",
                 "pod-name=",
                 "pod-namespace=",
+                "node-name=",
+                "sensitivity=",
+                "ewma-alpha=",
+                "arima-order=",
+                "dbscan-eps=",
+                "dbscan-min-samples=",
+                "quorum=",
+                "metric=",
+                "flow-filter=",
+                "save-normal-points",
             ],
         )
     except getopt.GetoptError as e:
@@ -808,7 +1182,7 @@ def main():
         sys.exit()
     for opt, arg in opts:
         if opt in ("-a", "--algo"):
-            valid_algos = ['EWMA', 'ARIMA', 'DBSCAN']
+            valid_algos = ['EWMA', 'ARIMA', 'DBSCAN', 'ENSEMBLE']
             if arg not in valid_algos:
                 logger.error(
                     "Algorithm should be in {}".format(
@@ -868,6 +1242,61 @@ def main():
             external_ip = arg
         elif opt in ("-", "--svc-port-name"):
             svc_port_name = arg
+        elif opt == "--node-name":
+            node_name = arg
+        elif opt in ("--sensitivity", "--ewma-alpha", "--dbscan-eps"):
+            try:
+                value = float(arg)
+            except ValueError:
+                value = 0
+            if value <= 0 or (opt == "--ewma-alpha" and value > 1):
+                logger.error("{} should be a positive number{}.".format(
+                    opt[2:], " not larger than 1"
+                    if opt == "--ewma-alpha" else ""))
+                logger.info(help_message)
+                sys.exit(2)
+            if opt == "--sensitivity":
+                sensitivity = value
+            elif opt == "--ewma-alpha":
+                ewma_alpha = value
+            else:
+                dbscan_eps = value
+        elif opt == "--arima-order":
+            try:
+                arima_order = [int(x) for x in arg.split(",")]
+            except ValueError:
+                arima_order = []
+            if len(arima_order) != 3 or min(arima_order) < 0:
+                logger.error("arima-order should be 3 non-negative integers "
+                             "separated by commas.")
+                logger.info(help_message)
+                sys.exit(2)
+        elif opt == "--dbscan-min-samples":
+            if not arg.isdigit() or int(arg) == 0:
+                logger.error("dbscan-min-samples should be a positive "
+                             "integer.")
+                logger.info(help_message)
+                sys.exit(2)
+            dbscan_min_samples = int(arg)
+        elif opt == "--quorum":
+            if not arg.isdigit() or not (
+                    1 <= int(arg) <= len(ENSEMBLE_ALGOS)):
+                logger.error("quorum should be an integer between 1 and "
+                             "{}.".format(len(ENSEMBLE_ALGOS)))
+                logger.info(help_message)
+                sys.exit(2)
+            quorum = int(arg)
+        elif opt == "--metric":
+            if arg not in METRIC_EXPRESSIONS:
+                logger.error("metric should be in {}.".format(
+                    " or ".join(METRIC_EXPRESSIONS)))
+                logger.info(help_message)
+                sys.exit(2)
+            metric = arg
+        elif opt == "--flow-filter":
+            flow_filter = arg
+        elif opt == "--save-normal-points":
+            save_normal_points = True
 
     func_start_time = time.time()
     logger.info("Script started at {}".format(
@@ -884,7 +1313,13 @@ def main():
         external_ip,
         svc_port_name,
         pod_name,
-        pod_namespace
+        pod_namespace,
+        node_name,
+        get_algo_params(algo_type, sensitivity, ewma_alpha, arima_order,
+                        dbscan_eps, dbscan_min_samples, quorum),
+        metric,
+        flow_filter,
+        save_normal_points,
     )
     func_end_time = time.time()
     tad_id = write_anomaly_detection_result(
@@ -195,6 +195,78 @@ def test_generate_sql_query(test_input, expected_sql_query):
     assert sql_query == expected_sql_query
 
 
+@pytest.mark.parametrize(
+    "test_input, expected_sql_query",
+    [
+        (
+                {"agg_flow": "node"},
+                "SELECT {} FROM {} WHERE "
+                "sourceNodeName <> '' AND destinationNodeName <> '' "
+                "GROUP BY {} ".format(
+                    ", ".join(ad.AGG_FLOW_TABLE_COLUMNS_NODE),
+                    table_name,
+                    ", ".join(ad.DF_AGG_GRP_COLUMNS_NODE + [
+                        'flowEndSeconds']),
+                )
+        ),
+        (
+                {"agg_flow": "node", "node_name": "test-node",
+                 "start_time": "2022-01-01 00:00:00"},
+                "SELECT {} FROM {} WHERE "
+                "flowStartSeconds >= '2022-01-01 00:00:00' AND "
+                "(sourceNodeName = 'test-node' OR "
+                "destinationNodeName = 'test-node') AND "
+                "sourceNodeName <> '' AND destinationNodeName <> '' "
+                "GROUP BY {} ".format(
+                    ", ".join(ad.AGG_FLOW_TABLE_COLUMNS_NODE),
+                    table_name,
+                    ", ".join(ad.DF_AGG_GRP_COLUMNS_NODE + [
+                        'flowEndSeconds']),
+                )
+        ),
+        (
+                {"agg_flow": "namespace"},
+                "SELECT * FROM "
+                "(SELECT {0} FROM {1} WHERE {2} GROUP BY {3}) "
+                "UNION ALL "
+                "(SELECT {4} FROM {1} WHERE {5} GROUP BY {3}) ".format(
+                    ", ".join(ad.AGG_FLOW_TABLE_COLUMNS_NAMESPACE_INBOUND),
+                    table_name, "destinationPodNamespace <> ''",
+                    ", ".join(ad.DF_AGG_GRP_COLUMNS_NAMESPACE + [
+                        'flowEndSeconds']),
+                    ", ".join(ad.AGG_FLOW_TABLE_COLUMNS_NAMESPACE_OUTBOUND),
+                    "sourcePodNamespace <> ''")
+        ),
+        (
+                {"agg_flow": "namespace", "pod_namespace": "TestPodNamespace",
+                 "ns_ignore_list": ["mock_ns"],
+                 "end_time": "2022-01-01 23:59:59"},
+                "SELECT * FROM "
+                "(SELECT {0} FROM {1} WHERE {2} AND {6} GROUP BY {3}) "
+                "UNION ALL "
+                "(SELECT {4} FROM {1} WHERE {5} AND {6} GROUP BY {3}) ".format(
+                    ", ".join(ad.AGG_FLOW_TABLE_COLUMNS_NAMESPACE_INBOUND),
+                    table_name,
+                    "destinationPodNamespace <> '' AND "
+                    "destinationPodNamespace = 'TestPodNamespace'",
+                    ", ".join(ad.DF_AGG_GRP_COLUMNS_NAMESPACE + [
+                        'flowEndSeconds']),
+                    ", ".join(ad.AGG_FLOW_TABLE_COLUMNS_NAMESPACE_OUTBOUND),
+                    "sourcePodNamespace <> '' AND "
+                    "sourcePodNamespace = 'TestPodNamespace'",
+                    "sourcePodNamespace NOT IN ('mock_ns') AND "
+                    "destinationPodNamespace NOT IN ('mock_ns') AND "
+                    "flowEndSeconds < '2022-01-01 23:59:59'")
+        ),
+    ],
+)
+def test_generate_sql_query_node_namespace(test_input, expected_sql_query):
+    kwargs = {"start_time": "", "end_time": "", "ns_ignore_list": []}
+    kwargs.update(test_input)
+    sql_query = ad.generate_tad_sql_query(**kwargs)
+    assert sql_query == expected_sql_query
+
+
 # Introduced 2 anomalies in between the lists
 throughput_list = [
     4007380032, 4006917952, 4004471308, 4005277827, 4005486294,
@@ -400,3 +472,104 @@ def test_calculate_dbscan_anomaly(test_input, expected_dbscan_anomaly):
     throughput_list, stddev = test_input
     anomaly_list = ad.calculate_dbscan_anomaly(throughput_list, stddev)
     assert anomaly_list == expected_dbscan_anomaly
+
+
+@pytest.mark.parametrize(
+    "test_input, expected_ewma_anomaly",
+    [
+        ((throughput_list, stddev, 0.5, 1.0), expected_anomaly_list_ewma),
+        ((throughput_list, stddev, 0.5, 10.0),
+         [False] * len(throughput_list)),
+    ],
+)
+def test_calculate_ewma_anomaly_params(test_input, expected_ewma_anomaly):
+    throughput_list, stddev, alpha, sensitivity = test_input
+    anomaly_list = ad.calculate_ewma_anomaly(
+        throughput_list, stddev, alpha=alpha, sensitivity=sensitivity)
+    assert anomaly_list == expected_ewma_anomaly
+
+
+@pytest.mark.parametrize(
+    "test_input, expected_algo_params",
+    [
+        (("EWMA", {}), {"sensitivity": 1.0, "ewmaAlpha": 0.5}),
+        (("EWMA", {"sensitivity": 2.5, "ewma_alpha": 0.3}),
+         {"sensitivity": 2.5, "ewmaAlpha": 0.3}),
+        (("ARIMA", {"arima_order": [2, 1, 0]}),
+         {"sensitivity": 1.0, "arimaOrder": [2, 1, 0]}),
+        (("DBSCAN", {"dbscan_min_samples": 6}),
+         {"dbscanEps": 250000000.0, "dbscanMinSamples": 6}),
+        (("ENSEMBLE", {"quorum": 3}),
+         {"quorum": 3, "sensitivity": 1.0, "ewmaAlpha": 0.5,
+          "arimaOrder": [1, 1, 1], "dbscanEps": 250000000.0,
+          "dbscanMinSamples": 4}),
+    ],
+)
+def test_get_algo_params(test_input, expected_algo_params):
+    algo_type, kwargs = test_input
+    assert ad.get_algo_params(algo_type, **kwargs) == expected_algo_params
+
+
+@pytest.mark.parametrize(
+    "test_input, expected_votes",
+    [
+        ((throughput_list, stddev),
+         [e + a + d for e, a, d in zip(expected_anomaly_list_ewma,
+                                       expected_anomaly_list_arima,
+                                       expected_dbscan_anomaly_list)]),
+    ],
+)
+def test_calculate_ensemble_anomaly(test_input, expected_votes):
+    throughput_list, stddev = test_input
+    for quorum in range(1, 4):
+        votes, anomaly_list, verdicts = ad.calculate_ensemble_anomaly(
+            throughput_list, stddev, quorum=quorum)
+        assert votes == [float(v) for v in expected_votes]
+        assert anomaly_list == [v >= quorum for v in expected_votes]
+    assert verdicts[0] == "EWMA:false,ARIMA:false,DBSCAN:false"
+    assert verdicts[58] == "EWMA:false,ARIMA:true,DBSCAN:true"
+
+
+@pytest.mark.parametrize(
+    "test_input, expected_columns",
+    [
+        ((["sourcePodName", "max(throughput)"], None),
+         ["sourcePodName", "max(throughput)"]),
+        ((["sourcePodName", "max(throughput)"], "throughput"),
+         ["sourcePodName", "max(throughput)"]),
+        ((["sourcePodName", "sum(throughput)"], "octetDelta"),
+         ["sourcePodName", "sum(octetDeltaCount) AS `sum(throughput)`"]),
+        ((["throughput"], "reverseThroughput"), ["throughput"]),
+        ((["max(throughput)"], "newConnections"),
+         ["max(packetTotalCount = packetDeltaCount) AS `max(throughput)`"]),
+    ],
+)
+def test_get_metric_columns(test_input, expected_columns):
+    columns, metric = test_input
+    assert ad.get_metric_columns(columns, metric) == expected_columns
+
+
+def test_generate_sql_query_metric():
+    sql_query = ad.generate_tad_sql_query(
+        "", "", ["kube-system"], agg_flow="pod", metric="packetRate")
+    assert "sum(if(octetDeltaCount = 0, 0, packetDeltaCount * " \
+        "throughput / (octetDeltaCount * 8))) AS `sum(throughput)`" \
+        in sql_query
+    assert ad.generate_tad_sql_query(
+        "", "", ["kube-system"], agg_flow="pod") == \
+        ad.generate_tad_sql_query(
+            "", "", ["kube-system"], agg_flow="pod", metric="throughput")
+
+
+@pytest.mark.parametrize("agg_flow", [None, "external", "pod", "namespace"])
+def test_generate_sql_query_flow_filter(agg_flow):
+    flow_filter = "sourcePodNamespace IN ('prod') AND flowType = 3"
+    sql_query = ad.generate_tad_sql_query(
+        "", "", [], agg_flow=agg_flow, flow_filter=flow_filter)
+    # The flow filter applies to both the inbound and the outbound flows of
+    # Pods and Namespaces
+    expected_count = 2 if agg_flow in ("pod", "namespace") else 1
+    assert sql_query.count("({})".format(flow_filter)) == expected_count
+    assert ad.generate_tad_sql_query("", "", [], agg_flow=agg_flow) == \
+        ad.generate_tad_sql_query(
+            "", "", [], agg_flow=agg_flow, flow_filter="")
@@ -0,0 +1,212 @@
+#!/usr/bin/python3
+
+# Copyright 2023 Antrea Authors
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http:#www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# This library is used to define the AdminNetworkPolicy and
+# BaselineAdminNetworkPolicy APIs of Kubernetes sig-network
+# (https://github.com/kubernetes-sigs/network-policy-api) in Python.
+# Code structure is following antrea_crd.py.
+
+API_VERSION = "policy.networking.k8s.io/v1alpha1"
+
+
+class Model(object):
+    attribute_types = {}
+
+    def to_dict(self):
+        """Returns the model properties as a dict"""
+        result = {}
+
+        for attr, _ in self.attribute_types.items():
+            value = getattr(self, attr)
+            if isinstance(value, list):
+                result[attr] = list(
+                    map(
+                        lambda x: x.to_dict() if hasattr(x, "to_dict") else x,
+                        value,
+                    )
+                )
+            elif hasattr(value, "to_dict"):
+                result[attr] = value.to_dict()
+            elif isinstance(value, dict):
+                result[attr] = dict(
+                    map(
+                        lambda item: (item[0], item[1].to_dict())
+                        if hasattr(item[1], "to_dict")
+                        else item,
+                        value.items(),
+                    )
+                )
+            else:
+                result[attr] = value
+
+        return result
+
+    def __eq__(self, other):
+        """Returns true if both objects are equal"""
+        if not isinstance(other, type(self)):
+            return False
+
+        return self.to_dict() == other.to_dict()
+
+
+class AdminNetworkPolicy(Model):
+    attribute_types = {
+        "kind": "string",
+        "api_version": "string",
+        "metadata": "kubernetes.client.V1ObjectMeta",
+        "spec": "AdminNetworkPolicySpec",
+    }
+
+    def __init__(
+        self,
+        kind="AdminNetworkPolicy",
+        api_version=API_VERSION,
+        metadata=None,
+        spec=None,
+    ):
+        self.kind = kind
+        self.api_version = api_version
+        self.metadata = metadata
+        self.spec = spec
+
+
+class AdminNetworkPolicySpec(Model):
+    attribute_types = {
+        "priority": "int",
+        "subject": "AdminNetworkPolicySubject",
+        "ingress": "list[AdminNetworkPolicyIngressRule]",
+        "egress": "list[AdminNetworkPolicyEgressRule]",
+    }
+
+    def __init__(self, priority=None, subject=None, ingress=None, egress=None):
+        self.priority = priority
+        self.subject = subject
+        self.ingress = ingress
+        self.egress = egress
+
+
+class BaselineAdminNetworkPolicy(Model):
+    attribute_types = {
+        "kind": "string",
+        "api_version": "string",
+        "metadata": "kubernetes.client.V1ObjectMeta",
+        "spec": "BaselineAdminNetworkPolicySpec",
+    }
+
+    def __init__(
+        self,
+        kind="BaselineAdminNetworkPolicy",
+        api_version=API_VERSION,
+        metadata=None,
+        spec=None,
+    ):
+        self.kind = kind
+        self.api_version = api_version
+        self.metadata = metadata
+        self.spec = spec
+
+
+class BaselineAdminNetworkPolicySpec(Model):
+    attribute_types = {
+        "subject": "AdminNetworkPolicySubject",
+        "ingress": "list[AdminNetworkPolicyIngressRule]",
+        "egress": "list[AdminNetworkPolicyEgressRule]",
+    }
+
+    def __init__(self, subject=None, ingress=None, egress=None):
+        self.subject = subject
+        self.ingress = ingress
+        self.egress = egress
+
+
+class AdminNetworkPolicySubject(Model):
+    attribute_types = {
+        "namespaces": "kubernetes.client.V1LabelSelector",
+        "pods": "NamespacedPod",
+    }
+
+    def __init__(self, namespaces=None, pods=None):
+        self.namespaces = namespaces
+        self.pods = pods
+
+
+class NamespacedPod(Model):
+    attribute_types = {
+        "namespace_selector": "kubernetes.client.V1LabelSelector",
+        "pod_selector": "kubernetes.client.V1LabelSelector",
+    }
+
+    def __init__(self, namespace_selector=None, pod_selector=None):
+        self.namespace_selector = namespace_selector
+        self.pod_selector = pod_selector
+
+
+class AdminNetworkPolicyIngressRule(Model):
+    attribute_types = {
+        "name": "string",
+        "action": "string",
+        "_from": "list[AdminNetworkPolicyPeer]",
+        "ports": "list[AdminNetworkPolicyPort]",
+    }
+
+    def __init__(self, name=None, action=None, _from=None, ports=None):
+        self.name = name
+        self.action = action
+        self._from = _from
+        self.ports = ports
+
+
+class AdminNetworkPolicyEgressRule(Model):
+    attribute_types = {
+        "name": "string",
+        "action": "string",
+        "to": "list[AdminNetworkPolicyPeer]",
+        "ports": "list[AdminNetworkPolicyPort]",
+    }
+
+    def __init__(self, name=None, action=None, to=None, ports=None):
+        self.name = name
+        self.action = action
+        self.to = to
+        self.ports = ports
+
+
+class AdminNetworkPolicyPeer(Model):
+    attribute_types = {
+        "namespaces": "kubernetes.client.V1LabelSelector",
+        "pods": "NamespacedPod",
+        "networks": "list[string]",
+    }
+
+    def __init__(self, namespaces=None, pods=None, networks=None):
+        self.namespaces = namespaces
+        self.pods = pods
+        self.networks = networks
+
+
+class AdminNetworkPolicyPort(Model):
+    attribute_types = {"port_number": "Port"}
+
+    def __init__(self, port_number=None):
+        self.port_number = port_number
+
+
+class Port(Model):
+    attribute_types = {"protocol": "string", "port": "int"}
+
+    def __init__(self, protocol=None, port=None):
+        self.protocol = protocol
+        self.port = port
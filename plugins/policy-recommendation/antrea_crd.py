@@ -791,3 +791,5 @@ class PolicyKind():
     KNP = "knp"
     ACNP = "acnp"
     ACG = "acg"
+    ADMINNP = "adminnp"
+    BANP = "banp"
@@ -25,11 +25,13 @@ import sys
 import uuid
 
 import kubernetes.client
+import yaml
 from pyspark.sql import SparkSession
 from pyspark.sql.functions import udf
-from pyspark.sql.types import StringType
+from pyspark.sql.types import BooleanType, StringType
 from urllib.parse import urlparse
 
+import admin_network_policy as adminnp
 import antrea_crd
 from policy_recommendation_utils import (
     is_intstring,
@@ -60,7 +62,9 @@ NAMESPACE_ALLOW_LIST = [
 
 ROW_DELIMITER = "#"
 PEER_DELIMITER = "|"
+DEFAULT_POLICY_TIER = "Application"
 DEFAULT_POLICY_PRIORITY = 5
+MAX_POLICY_PRIORITY = 10000
 
 MEANINGLESS_LABELS = [
     "pod-template-hash",
@@ -68,6 +72,8 @@ MEANINGLESS_LABELS = [
     "pod-template-generation",
 ]
 broadcast_ns_allow_list = None
+broadcast_target_ns_list = None
+broadcast_target_labels = None
 
 logger = logging.getLogger("policy_recommendation")
 logger.setLevel(logging.INFO)
@@ -107,6 +113,47 @@ def remove_meaningless_labels(podLabels):
     return json.dumps(labels_dict, sort_keys=True)
 
 
+def get_target_ns_list():
+    if broadcast_target_ns_list:
+        return broadcast_target_ns_list.value
+    return []
+
+
+def get_target_labels():
+    if broadcast_target_labels:
+        return broadcast_target_labels.value
+    return {}
+
+
+def is_target_workload(namespace, podLabels):
+    target_ns_list = get_target_ns_list()
+    target_labels = get_target_labels()
+    if target_ns_list and namespace not in target_ns_list:
+        return False
+    if target_labels:
+        try:
+            labels_dict = json.loads(podLabels)
+        except Exception:
+            return False
+        for key, value in target_labels.items():
+            if labels_dict.get(key) != value:
+                return False
+    return True
+
+
+# Only keep the flows whose source (for egress rules) or destination (for
+# ingress rules) is a target workload, so that recommended policies are only
+# applied to target workloads.
+def filter_applied_to_flows(flows_df, side):
+    if not get_target_ns_list() and not get_target_labels():
+        return flows_df
+    return flows_df.filter(
+        udf(is_target_workload, BooleanType())(
+            "{}PodNamespace".format(side), "{}PodLabels".format(side)
+        )
+    )
+
+
 def get_protocol_string(protocolIdentifier):
     if protocolIdentifier == 6:
         return "TCP"
@@ -431,7 +478,7 @@ def generate_anp(network_peers):
                 namespace=ns,
             ),
             spec=antrea_crd.NetworkPolicySpec(
-                tier="Application",
+                tier=DEFAULT_POLICY_TIER,
                 priority=DEFAULT_POLICY_PRIORITY,
                 applied_to=[
                     antrea_crd.NetworkPolicyPeer(
@@ -529,7 +576,7 @@ def generate_svc_acnp(x):
                 name=np_name,
             ),
             spec=antrea_crd.NetworkPolicySpec(
-                tier="Application",
+                tier=DEFAULT_POLICY_TIER,
                 priority=DEFAULT_POLICY_PRIORITY,
                 applied_to=[
                     antrea_crd.NetworkPolicyPeer(
@@ -583,6 +630,10 @@ def generate_reject_acnp(applied_to):
                 match_labels={"kubernetes.io/metadata.name": ns}
             ),
         )
+    return generate_reject_acnp_for_peers(np_name, [applied_to])
+
+
+def generate_reject_acnp_for_peers(np_name, applied_to):
     np = antrea_crd.ClusterNetworkPolicy(
         kind="ClusterNetworkPolicy",
         api_version="crd.antrea.io/v1alpha1",
@@ -592,7 +643,7 @@ def generate_reject_acnp(applied_to):
         spec=antrea_crd.NetworkPolicySpec(
             tier="Baseline",
             priority=DEFAULT_POLICY_PRIORITY,
-            applied_to=[applied_to],
+            applied_to=applied_to,
             egress=[
                 antrea_crd.Rule(
                     action="Reject",
@@ -618,12 +669,234 @@ def generate_reject_acnp(applied_to):
     return [dict_to_yaml(np.to_dict())]
 
 
+def generate_target_reject_acnp():
+    target_labels = get_target_labels()
+    pod_selector = kubernetes.client.V1LabelSelector(
+        match_labels=target_labels if target_labels else None
+    )
+    target_ns_list = get_target_ns_list()
+    if target_ns_list:
+        applied_to = [
+            antrea_crd.NetworkPolicyPeer(
+                pod_selector=pod_selector,
+                namespace_selector=kubernetes.client.V1LabelSelector(
+                    match_labels={"kubernetes.io/metadata.name": ns}
+                ),
+            )
+            for ns in target_ns_list
+        ]
+    else:
+        applied_to = [
+            antrea_crd.NetworkPolicyPeer(
+                pod_selector=pod_selector,
+                namespace_selector=kubernetes.client.V1LabelSelector(),
+            )
+        ]
+    return generate_reject_acnp_for_peers(
+        "recommend-reject-target-acnp", applied_to
+    )
+
+
+def generate_admin_np_peer(ns, labels_dict):
+    return adminnp.AdminNetworkPolicyPeer(
+        pods=adminnp.NamespacedPod(
+            namespace_selector=kubernetes.client.V1LabelSelector(
+                match_labels={"kubernetes.io/metadata.name": ns}
+            ),
+            pod_selector=kubernetes.client.V1LabelSelector(
+                match_labels=labels_dict
+            ),
+        )
+    )
+
+
+def generate_admin_np_egress_rule(egress):
+    if len(egress.split(ROW_DELIMITER)) == 4:
+        # Pod-to-Pod flow
+        ns, labels, port, protocolIdentifier = egress.split(ROW_DELIMITER)
+        try:
+            labels_dict = json.loads(labels)
+        except Exception as e:
+            logger.error(
+                "Error {}: labels {} in egress {} are not in json format"
+                .format(
+                    e, labels, egress
+                )
+            )
+            return ""
+        egress_peer = generate_admin_np_peer(ns, labels_dict)
+    elif len(egress.split(ROW_DELIMITER)) == 3:
+        # Pod-to-External flow
+        destinationIP, port, protocolIdentifier = egress.split(ROW_DELIMITER)
+        if get_IP_version(destinationIP) == "v4":
+            cidr = destinationIP + "/32"
+        else:
+            cidr = destinationIP + "/128"
+        egress_peer = adminnp.AdminNetworkPolicyPeer(networks=[cidr])
+    else:
+        logger.fatal("Egress tuple {} has wrong format".format(egress))
+        sys.exit(1)
+    ports = adminnp.AdminNetworkPolicyPort(
+        port_number=adminnp.Port(protocol=protocolIdentifier, port=int(port))
+    )
+    return adminnp.AdminNetworkPolicyEgressRule(
+        action="Allow", to=[egress_peer], ports=[ports]
+    )
+
+
+def generate_admin_np_ingress_rule(ingress):
+    if len(ingress.split(ROW_DELIMITER)) != 4:
+        logger.fatal("Ingress tuple {} has wrong format".format(ingress))
+        sys.exit(1)
+    ns, labels, port, protocolIdentifier = ingress.split(ROW_DELIMITER)
+    try:
+        labels_dict = json.loads(labels)
+    except Exception as e:
+        logger.error(
+            "Error {}: labels {} in ingress {} are not in json format".format(
+                e, labels, ingress
+            )
+        )
+        return ""
+    ports = adminnp.AdminNetworkPolicyPort(
+        port_number=adminnp.Port(protocol=protocolIdentifier, port=int(port))
+    )
+    return adminnp.AdminNetworkPolicyIngressRule(
+        action="Allow",
+        _from=[generate_admin_np_peer(ns, labels_dict)],
+        ports=[ports],
+    )
+
+
+def generate_admin_np(network_peers):
+    applied_to, (ingresses, egresses) = network_peers
+    ns, labels = applied_to.split(ROW_DELIMITER)
+    if broadcast_ns_allow_list:
+        if ns in broadcast_ns_allow_list.value:
+            return []
+    else:
+        if ns in NAMESPACE_ALLOW_LIST:
+            return []
+    try:
+        labels_dict = json.loads(labels)
+    except Exception as e:
+        logger.error(
+            "Error {}: labels {} in applied_to {} are not in json format"
+            .format(
+                e, labels, applied_to
+            )
+        )
+        return []
+    ingress_list = list(set(ingresses.split(PEER_DELIMITER)))
+    egress_list = list(set(egresses.split(PEER_DELIMITER)))
+    egressRules = []
+    for egress in egress_list:
+        if ROW_DELIMITER in egress:
+            egress_rule = generate_admin_np_egress_rule(egress)
+            if egress_rule:
+                egressRules.append(egress_rule)
+    ingressRules = []
+    for ingress in ingress_list:
+        if ROW_DELIMITER in ingress:
+            ingress_rule = generate_admin_np_ingress_rule(ingress)
+            if ingress_rule:
+                ingressRules.append(ingress_rule)
+    if egressRules or ingressRules:
+        np = adminnp.AdminNetworkPolicy(
+            metadata=kubernetes.client.V1ObjectMeta(
+                name=generate_policy_name("recommend-allow-adminnp"),
+            ),
+            spec=adminnp.AdminNetworkPolicySpec(
+                priority=DEFAULT_POLICY_PRIORITY,
+                subject=adminnp.AdminNetworkPolicySubject(
+                    pods=adminnp.NamespacedPod(
+                        namespace_selector=kubernetes.client.V1LabelSelector(
+                            match_labels={"kubernetes.io/metadata.name": ns}
+                        ),
+                        pod_selector=kubernetes.client.V1LabelSelector(
+                            match_labels=labels_dict
+                        ),
+                    )
+                ),
+                egress=egressRules,
+                ingress=ingressRules,
+            ),
+        )
+        return [dict_to_yaml(np.to_dict())]
+    return []
+
+
+# BaselineAdminNetworkPolicy is a cluster singleton which must be named
+# "default". It denies all the traffic of the whole cluster, or of the target
+# workloads if they are specified, which is not allowed by other policies.
+def generate_deny_banp():
+    target_ns_list = get_target_ns_list()
+    target_labels = get_target_labels()
+    if target_ns_list or target_labels:
+        namespace_selector = kubernetes.client.V1LabelSelector()
+        if target_ns_list:
+            namespace_selector = kubernetes.client.V1LabelSelector(
+                match_expressions=[
+                    kubernetes.client.V1LabelSelectorRequirement(
+                        key="kubernetes.io/metadata.name",
+                        operator="In",
+                        values=list(target_ns_list),
+                    )
+                ]
+            )
+        subject = adminnp.AdminNetworkPolicySubject(
+            pods=adminnp.NamespacedPod(
+                namespace_selector=namespace_selector,
+                pod_selector=kubernetes.client.V1LabelSelector(
+                    match_labels=target_labels if target_labels else None
+                ),
+            )
+        )
+    else:
+        subject = adminnp.AdminNetworkPolicySubject(
+            namespaces=kubernetes.client.V1LabelSelector()
+        )
+    banp = adminnp.BaselineAdminNetworkPolicy(
+        metadata=kubernetes.client.V1ObjectMeta(name="default"),
+        spec=adminnp.BaselineAdminNetworkPolicySpec(
+            subject=subject,
+            egress=[
+                adminnp.AdminNetworkPolicyEgressRule(
+                    action="Deny",
+                    to=[
+                        adminnp.AdminNetworkPolicyPeer(
+                            namespaces=kubernetes.client.V1LabelSelector()
+                        ),
+                        adminnp.AdminNetworkPolicyPeer(
+                            networks=["0.0.0.0/0", "::/0"]
+                        ),
+                    ],
+                )
+            ],
+            ingress=[
+                adminnp.AdminNetworkPolicyIngressRule(
+                    action="Deny",
+                    _from=[
+                        adminnp.AdminNetworkPolicyPeer(
+                            namespaces=kubernetes.client.V1LabelSelector()
+                        )
+                    ],
+                )
+            ],
+        ),
+    )
+    return dict_to_yaml(banp.to_dict())
+
+
 def recommend_k8s_policies(flows_df):
-    egress_rdd = flows_df.rdd.map(
+    egress_rdd = filter_applied_to_flows(flows_df, "source").rdd.map(
         lambda flow: map_flow_to_egress(flow, k8s=True)
     ).reduceByKey(lambda a, b: ("", a[1] + PEER_DELIMITER + b[1]))
     ingress_rdd = (
-        flows_df.filter(flows_df.flowType != "pod_to_external")
+        filter_applied_to_flows(
+            flows_df.filter(flows_df.flowType != "pod_to_external"),
+            "destination",
+        )
         .rdd.map(map_flow_to_ingress)
         .reduceByKey(lambda a, b: (a[0] + PEER_DELIMITER + b[0], ""))
     )
@@ -639,7 +912,10 @@ def recommend_antrea_policies(
     flows_df, option=1, deny_rules=True, to_services=True
 ):
     ingress_rdd = (
-        flows_df.filter(flows_df.flowType != "pod_to_external")
+        filter_applied_to_flows(
+            flows_df.filter(flows_df.flowType != "pod_to_external"),
+            "destination",
+        )
         .rdd.map(map_flow_to_ingress)
         .reduceByKey(lambda a, b: (a[0] + PEER_DELIMITER + b[0], ""))
     )
@@ -651,6 +927,9 @@ def recommend_antrea_policies(
         )
     else:
         unprotected_flows_df = flows_df
+    unprotected_flows_df = filter_applied_to_flows(
+        unprotected_flows_df, "source"
+    )
     egress_rdd = unprotected_flows_df.rdd.map(map_flow_to_egress).reduceByKey(
         lambda a, b: ("", a[1] + PEER_DELIMITER + b[1])
     )
@@ -665,8 +944,8 @@ def recommend_antrea_policies(
     svc_cg_list = []
     svc_acnp_list = []
     if not to_services:
-        unprotected_svc_flows_df = flows_df.filter(
-            flows_df.flowType == "pod_to_svc"
+        unprotected_svc_flows_df = filter_applied_to_flows(
+            flows_df.filter(flows_df.flowType == "pod_to_svc"), "source"
         )
         svc_df = unprotected_svc_flows_df.groupBy(
             ["destinationServicePortName"]
@@ -696,12 +975,16 @@ def recommend_antrea_policies(
                 antrea_crd.PolicyKind.ACNP: svc_acnp_list + deny_anp_list
             }
         else:
-            # Recommend deny ACNP for whole cluster
-            deny_all_policy = generate_reject_acnp("")
+            # Recommend deny ACNP for whole cluster, or for all target
+            # workloads if targets are specified
+            if get_target_ns_list() or get_target_labels():
+                deny_all_policies = generate_target_reject_acnp()
+            else:
+                deny_all_policies = generate_reject_acnp("")
             return {
                 antrea_crd.PolicyKind.ANP: anp_list,
                 antrea_crd.PolicyKind.ACG: svc_cg_list,
-                antrea_crd.PolicyKind.ACNP: svc_acnp_list + [deny_all_policy]
+                antrea_crd.PolicyKind.ACNP: svc_acnp_list + deny_all_policies
             }
     else:
         return {
@@ -711,15 +994,43 @@ def recommend_antrea_policies(
         }
 
 
+def recommend_admin_policies(flows_df):
+    # AdminNetworkPolicy doesn't support Service peers, Pod-to-Service flows
+    # are matched by their destination Pods like K8s NetworkPolicies.
+    egress_rdd = filter_applied_to_flows(flows_df, "source").rdd.map(
+        lambda flow: map_flow_to_egress(flow, k8s=True)
+    ).reduceByKey(lambda a, b: ("", a[1] + PEER_DELIMITER + b[1]))
+    ingress_rdd = (
+        filter_applied_to_flows(
+            flows_df.filter(flows_df.flowType != "pod_to_external"),
+            "destination",
+        )
+        .rdd.map(map_flow_to_ingress)
+        .reduceByKey(lambda a, b: (a[0] + PEER_DELIMITER + b[0], ""))
+    )
+    network_peers_rdd = ingress_rdd.union(egress_rdd).reduceByKey(
+        combine_network_peers
+    )
+    admin_np_list = network_peers_rdd.flatMap(generate_admin_np).collect()
+    return {
+        antrea_crd.PolicyKind.ADMINNP: admin_np_list,
+        antrea_crd.PolicyKind.BANP: [generate_deny_banp()],
+    }
+
+
 def recommend_policies_for_unprotected_flows(
     unprotected_flows_df, option=1, to_services=True
 ):
-    if option not in [1, 2, 3]:
+    if option not in [1, 2, 3, 4]:
         logger.error("Error: option {} is not valid".format(option))
         return {}
     if option == 3:
         # Recommend K8s native NetworkPolicies for unprotected flows
         return recommend_k8s_policies(unprotected_flows_df)
+    if option == 4:
+        # Recommend AdminNetworkPolicies and BaselineAdminNetworkPolicy for
+        # unprotected flows
+        return recommend_admin_policies(unprotected_flows_df)
     else:
         return recommend_antrea_policies(
             unprotected_flows_df, option, True, to_services
@@ -782,7 +1093,123 @@ def recommend_policies_for_ns_allow_list(ns_allow_list):
     return {antrea_crd.PolicyKind.ACNP: policies}
 
 
-def generate_sql_query(table_name, limit, start_time, end_time, unprotected):
+def recommend_admin_policies_for_ns_allow_list(ns_allow_list):
+    policies = []
+    for ns in ns_allow_list:
+        np_name = generate_policy_name(
+            "recommend-allow-adminnp-{}".format(ns)
+        )
+        admin_np = adminnp.AdminNetworkPolicy(
+            metadata=kubernetes.client.V1ObjectMeta(
+                name=np_name,
+            ),
+            spec=adminnp.AdminNetworkPolicySpec(
+                priority=DEFAULT_POLICY_PRIORITY,
+                subject=adminnp.AdminNetworkPolicySubject(
+                    namespaces=kubernetes.client.V1LabelSelector(
+                        match_labels={"kubernetes.io/metadata.name": ns}
+                    )
+                ),
+                egress=[
+                    adminnp.AdminNetworkPolicyEgressRule(
+                        action="Allow",
+                        to=[
+                            adminnp.AdminNetworkPolicyPeer(
+                                namespaces=kubernetes.client
+                                .V1LabelSelector()
+                            ),
+                            adminnp.AdminNetworkPolicyPeer(
+                                networks=["0.0.0.0/0", "::/0"]
+                            ),
+                        ],
+                    )
+                ],
+                ingress=[
+                    adminnp.AdminNetworkPolicyIngressRule(
+                        action="Allow",
+                        _from=[
+                            adminnp.AdminNetworkPolicyPeer(
+                                namespaces=kubernetes.client
+                                .V1LabelSelector()
+                            )
+                        ],
+                    )
+                ],
+            ),
+        )
+        policies.append(dict_to_yaml(admin_np.to_dict()))
+    return {antrea_crd.PolicyKind.ADMINNP: policies}
+
+
+def place_antrea_policies(
+    policies,
+    tier=DEFAULT_POLICY_TIER,
+    base_priority=DEFAULT_POLICY_PRIORITY,
+    priority_step=0,
+):
+    """
+    Move the recommended allow Antrea policies to the given Tier, and assign
+    priorities to the Antrea policies of every Tier, starting from
+    base_priority and increased by priority_step for each policy.
+    """
+    next_priorities = {}
+    for kind in [antrea_crd.PolicyKind.ANP, antrea_crd.PolicyKind.ACNP]:
+        placed_policies = []
+        for policy_yaml in policies.get(kind, []):
+            policy = yaml.load(policy_yaml, Loader=yaml.FullLoader)
+            spec = policy["spec"]
+            if spec["tier"] == DEFAULT_POLICY_TIER:
+                spec["tier"] = tier
+            priority = next_priorities.get(spec["tier"], base_priority)
+            if priority > MAX_POLICY_PRIORITY:
+                logger.warning(
+                    "Priority {} of policy {} exceeds {}, capping it".format(
+                        priority, policy["metadata"]["name"],
+                        MAX_POLICY_PRIORITY
+                    )
+                )
+                priority = MAX_POLICY_PRIORITY
+            spec["priority"] = priority
+            next_priorities[spec["tier"]] = priority + priority_step
+            placed_policies.append(yaml.dump(policy))
+        if kind in policies:
+            policies[kind] = placed_policies
+    return policies
+
+
+def generate_target_filter(target_ns_list, target_labels):
+    side_filters = []
+    for side in ["source", "destination"]:
+        conditions = []
+        if target_ns_list:
+            conditions.append(
+                "{}PodNamespace IN ({})".format(
+                    side, ", ".join("'{}'".format(ns) for ns in target_ns_list)
+                )
+            )
+        if target_labels:
+            for key in sorted(target_labels):
+                conditions.append(
+                    "JSONExtractString({}PodLabels, '{}') == '{}'".format(
+                        side, key, target_labels[key]
+                    )
+                )
+        if conditions:
+            side_filters.append("({})".format(" AND ".join(conditions)))
+    if not side_filters:
+        return ""
+    return "({})".format(" OR ".join(side_filters))
+
+
+def generate_sql_query(
+    table_name,
+    limit,
+    start_time,
+    end_time,
+    unprotected,
+    target_ns_list=None,
+    target_labels=None,
+):
     sql_query = "SELECT {} FROM {}".format(
         ", ".join(FLOW_TABLE_COLUMNS), table_name
     )
@@ -796,6 +1223,9 @@ AND egressNetworkPolicyName == ''"
         sql_query += " AND flowStartSeconds >= '{}'".format(start_time)
     if end_time:
         sql_query += " AND flowEndSeconds < '{}'".format(end_time)
+    target_filter = generate_target_filter(target_ns_list, target_labels)
+    if target_filter:
+        sql_query += " AND {}".format(target_filter)
     sql_query += " GROUP BY {}".format(", ".join(FLOW_TABLE_COLUMNS))
     if limit:
         sql_query += " LIMIT {}".format(limit)
@@ -888,6 +1318,8 @@ def initial_recommendation_job(
     ns_allow_list=NAMESPACE_ALLOW_LIST,
     rm_labels=False,
     to_services=True,
+    target_ns_list=None,
+    target_labels=None,
 ):
     """
     Start an initial policy recommendation Spark job on a cluster having no
@@ -900,7 +1332,7 @@ def initial_recommendation_job(
         limit: Limit on the number of flow records fetched in database.
                Default value is 100, setting to 0 means unlimited.
         option: Option of network isolation preference in policy
-                recommendation. Currently we have 3 options and default value
+                recommendation. Currently we have 4 options and default value
                 is 1:
                 1: Recommending allow ANP/ACNP policies, with default deny
                    rules only on appliedTo Pod labels which have allow rules
@@ -909,6 +1341,8 @@ def initial_recommendation_job(
                    rules for whole cluster.
                 3: Recommending allow K8s NetworkPolicies, with no deny rules
                    at all.
+                4: Recommending allow AdminNetworkPolicies, with a default
+                   deny BaselineAdminNetworkPolicy for whole cluster.
         start_time: The start time of the flow records considered for the
                     policy recommendation. Default value is None, which means
                     no limit of the start time of flow records.
@@ -922,19 +1356,36 @@ def initial_recommendation_job(
                    'pod-template-generation'.
         to_services: Use the toServices feature in ANP, only works when
                      option is 1 or 2.
+        target_ns_list: List of namespaces of the target workloads. Default
+                        value is None, which means all namespaces.
+        target_labels: Pod labels of the target workloads. Default value is
+                       None, which means all Pods.
 
     Returns:
         A list of recommended policies, each recommended policy is a string of
         YAML format.
     """
     sql_query = generate_sql_query(
-        table_name, limit, start_time, end_time, True
+        table_name, limit, start_time, end_time, True,
+        target_ns_list, target_labels
     )
     unprotected_flows_df = read_flow_df(
         spark, db_jdbc_address, sql_query, rm_labels
     )
+    # Policies for allowed namespaces are applied to workloads outside of the
+    # target workloads, they are not recommended when targets are specified.
+    if target_ns_list or target_labels:
+        ns_allow_list = []
+    if option == 4:
+        ns_allow_policies = recommend_admin_policies_for_ns_allow_list(
+            ns_allow_list
+        )
+    else:
+        ns_allow_policies = recommend_policies_for_ns_allow_list(
+            ns_allow_list
+        )
     return merge_policy_dict(
-        recommend_policies_for_ns_allow_list(ns_allow_list),
+        ns_allow_policies,
         recommend_policies_for_unprotected_flows(
             unprotected_flows_df, option, to_services
         )
@@ -951,6 +1402,8 @@ def subsequent_recommendation_job(
     end_time=None,
     rm_labels=False,
     to_services=True,
+    target_ns_list=None,
+    target_labels=None,
 ):
     """
     Start a subsequent policy recommendation Spark job on a cluster having
@@ -963,7 +1416,7 @@ def subsequent_recommendation_job(
         limit: Limit on the number of flow records fetched in database.
                Default value is 100, setting to 0 means unlimited.
         option: Option of network isolation preference in policy
-                recommendation. Currently we have 3 options and default value
+                recommendation. Currently we have 4 options and default value
                 is 1:
                 1: Recommending allow ANP/ACNP policies, with default deny
                    rules only on appliedTo Pod labels which have allow rules
@@ -972,6 +1425,8 @@ def subsequent_recommendation_job(
                    rules for whole cluster.
                 3: Recommending allow K8s NetworkPolicies, with no deny rules
                    at all.
+                4: Recommending allow AdminNetworkPolicies, with a default
+                   deny BaselineAdminNetworkPolicy for whole cluster.
         start_time: The start time of the flow records considered for the
                     policy recommendation. Default value is None, which means
                     no limit of the start time of flow records.
@@ -983,6 +1438,10 @@ def subsequent_recommendation_job(
                    'pod-template-generation'.
         to_services: Use the toServices feature in ANP, only works when option
                      is 1 or 2.
+        target_ns_list: List of namespaces of the target workloads. Default
+                        value is None, which means all namespaces.
+        target_labels: Pod labels of the target workloads. Default value is
+                       None, which means all Pods.
 
     Returns:
         A list of recommended policies, each recommended policy is a string of
@@ -990,7 +1449,8 @@ def subsequent_recommendation_job(
     """
     recommend_policies = {}
     sql_query = generate_sql_query(
-        table_name, limit, start_time, end_time, True
+        table_name, limit, start_time, end_time, True,
+        target_ns_list, target_labels
     )
     unprotected_flows_df = read_flow_df(
         spark, db_jdbc_address, sql_query, rm_labels
@@ -1003,7 +1463,8 @@ def subsequent_recommendation_job(
     )
     if option in [1, 2]:
         sql_query = generate_sql_query(
-            table_name, limit, start_time, end_time, False
+            table_name, limit, start_time, end_time, False,
+            target_ns_list, target_labels
         )
         trusted_denied_flows_df = read_flow_df(
             spark, db_jdbc_address, sql_query, rm_labels
@@ -1031,6 +1492,11 @@ def main(argv):
     recommendation_id_input = ""
     rm_labels = True
     to_services = True
+    target_ns_list = []
+    target_labels = {}
+    tier = DEFAULT_POLICY_TIER
+    base_priority = DEFAULT_POLICY_PRIORITY
+    priority_step = 0
     help_message = """
     Start the policy recommendation spark job.
 
@@ -1045,12 +1511,14 @@ def main(argv):
     -l, --limit=0: The limit on the number of flow records read from the
         database. 0 means no limit.
     -o, --option=1: Option of network isolation preference in policy
-        recommendation. Currently we have 3 options:
+        recommendation. Currently we have 4 options:
         1: Recommending allow ANP/ACNP policies, with default deny rules only
            on appliedTo Pod labels which have allow rules recommended.
         2: Recommending allow ANP/ACNP policies, with default deny rules for
            whole cluster.
         3: Recommending allow K8s NetworkPolicies, with no deny rules at all.
+        4: Recommending allow AdminNetworkPolicies, with a default deny
+           BaselineAdminNetworkPolicy for whole cluster.
     -s, --start_time=None: The start time of the flow records considered for
         the policy recommendation.
         Format is YYYY-MM-DD hh:mm:ss in UTC timezone. Default value is None,
@@ -1072,6 +1540,21 @@ def main(argv):
         toServices rules for Pod-to-Service flows, only works when option is
         1 or 2. This feature is enabled by default, provide false to disable
         this feature.
+    --target_ns_list=[]: List of namespaces of the workloads to recommend
+        policies for. Only flows from or to these workloads are considered,
+        and only these workloads are selected in the appliedTo of recommended
+        policies. Default value is an empty list, which means all namespaces.
+    --target_labels={}: Pod labels of the workloads to recommend policies
+        for, in JSON format. Can be combined with target_ns_list. Default
+        value is an empty dict, which means all Pods.
+    --tier=Application: The Tier of the recommended allow ANP/ACNP policies,
+        only works when option is 1 or 2. The recommended deny policies are
+        always in the Baseline Tier.
+    --base_priority=5: The priority of the first recommended ANP/ACNP policy
+        in each Tier, between 1 and 10000.
+    --priority_step=0: The priority increment between consecutive
+        recommended ANP/ACNP policies in the same Tier. 0 means all the
+        policies in a Tier have the base priority.
 
     Usage Example:
     python3 policy_recommendation_job.py
@@ -1083,6 +1566,8 @@ def main(argv):
         -n '["kube-system","flow-aggregator","flow-visibility"]'
     """
     global broadcast_ns_allow_list
+    global broadcast_target_ns_list
+    global broadcast_target_labels
     spark = SparkSession.builder.getOrCreate()
     broadcast_ns_allow_list = spark.sparkContext.broadcast(
         NAMESPACE_ALLOW_LIST)
@@ -1104,6 +1589,11 @@ def main(argv):
                 "id=",
                 "rm_labels=",
                 "to_services=",
+                "target_ns_list=",
+                "target_labels=",
+                "tier=",
+                "base_priority=",
+                "priority_step=",
             ],
         )
     except getopt.GetoptError as e:
@@ -1142,10 +1632,10 @@ def main(argv):
                 sys.exit(2)
             limit = int(arg)
         elif opt in ("-o", "--option"):
-            if not is_intstring(arg) or int(arg) not in [1, 2, 3]:
+            if not is_intstring(arg) or int(arg) not in [1, 2, 3, 4]:
                 logger.error(
                     "Option of network isolation preference should be \
-                    1 or 2 or 3."
+                    1 or 2 or 3 or 4."
                 )
                 logger.info(help_message)
                 sys.exit(2)
@@ -1186,6 +1676,52 @@ def main(argv):
         elif opt in ("--to_services"):
             if arg == "false":
                 to_services = False
+        elif opt in ("--target_ns_list"):
+            arg_list = json.loads(arg)
+            if not isinstance(arg_list, list):
+                logger.error("target_ns_list should be a list.")
+                logger.info(help_message)
+                sys.exit(2)
+            target_ns_list = arg_list
+        elif opt in ("--target_labels"):
+            arg_dict = json.loads(arg)
+            if not isinstance(arg_dict, dict):
+                logger.error("target_labels should be a dict.")
+                logger.info(help_message)
+                sys.exit(2)
+            target_labels = arg_dict
+        elif opt in ("--tier"):
+            if arg.lower() == "baseline":
+                logger.error(
+                    "tier should not be Baseline, which is reserved for the \
+                    recommended deny policies."
+                )
+                logger.info(help_message)
+                sys.exit(2)
+            tier = arg
+        elif opt in ("--base_priority"):
+            try:
+                base_priority = float(arg)
+            except ValueError:
+                base_priority = 0
+            if base_priority < 1 or base_priority > MAX_POLICY_PRIORITY:
+                logger.error(
+                    "base_priority should be a number between 1 and 10000."
+                )
+                logger.info(help_message)
+                sys.exit(2)
+        elif opt in ("--priority_step"):
+            try:
+                priority_step = float(arg)
+            except ValueError:
+                priority_step = -1
+            if priority_step < 0:
+                logger.error("priority_step should be a number >= 0.")
+                logger.info(help_message)
+                sys.exit(2)
+
+    broadcast_target_ns_list = spark.sparkContext.broadcast(target_ns_list)
+    broadcast_target_labels = spark.sparkContext.broadcast(target_labels)
 
     if recommendation_type == "initial":
         result = initial_recommendation_job(
@@ -1199,6 +1735,11 @@ def main(argv):
             broadcast_ns_allow_list.value,
             rm_labels,
             to_services,
+            target_ns_list,
+            target_labels,
+        )
+        result = place_antrea_policies(
+            result, tier, base_priority, priority_step
         )
         recommendation_id = write_recommendation_result(
             spark,
@@ -1225,6 +1766,11 @@ def main(argv):
             end_time,
             rm_labels,
             to_services,
+            target_ns_list,
+            target_labels,
+        )
+        result = place_antrea_policies(
+            result, tier, base_priority, priority_step
         )
         recommendation_id = write_recommendation_result(
             spark,
@@ -19,6 +19,7 @@ import kubernetes.client
 
 from pyspark.sql import SparkSession, Row
 
+import admin_network_policy as adminnp
 import antrea_crd
 import policy_recommendation_job as pr
 from policy_recommendation_utils import (
@@ -156,6 +157,42 @@ def test_generate_sql_query(test_input, expected_sql_query):
     assert sql_query == expected_sql_query
 
 
+@pytest.mark.parametrize(
+    "test_input, expected_sql_query",
+    [
+        (
+            (["db"], {}),
+            "SELECT {} FROM {} WHERE ingressNetworkPolicyName == '' AND \
+egressNetworkPolicyName == '' AND ((sourcePodNamespace IN ('db')) OR \
+(destinationPodNamespace IN ('db'))) GROUP BY {}".format(
+                ", ".join(pr.FLOW_TABLE_COLUMNS),
+                table_name,
+                ", ".join(pr.FLOW_TABLE_COLUMNS),
+            ),
+        ),
+        (
+            (["db", "web"], {"tier": "backend"}),
+            "SELECT {} FROM {} WHERE ingressNetworkPolicyName == '' AND \
+egressNetworkPolicyName == '' AND ((sourcePodNamespace IN ('db', 'web') AND \
+JSONExtractString(sourcePodLabels, 'tier') == 'backend') OR \
+(destinationPodNamespace IN ('db', 'web') AND \
+JSONExtractString(destinationPodLabels, 'tier') == 'backend')) \
+GROUP BY {}".format(
+                ", ".join(pr.FLOW_TABLE_COLUMNS),
+                table_name,
+                ", ".join(pr.FLOW_TABLE_COLUMNS),
+            ),
+        ),
+    ],
+)
+def test_generate_sql_query_with_targets(test_input, expected_sql_query):
+    target_ns_list, target_labels = test_input
+    sql_query = pr.generate_sql_query(
+        table_name, 0, "", "", True, target_ns_list, target_labels
+    )
+    assert sql_query == expected_sql_query
+
+
 @pytest.mark.parametrize(
     "test_input, expected_policies",
     [
@@ -1536,3 +1573,153 @@ def test_recommend_antrea_policies(
                 )
             policy["metadata"]["name"] = expect_policy["metadata"]["name"]
             assert policy == expect_policy
+
+
+@pytest.mark.parametrize(
+    "test_input, expected_egress_rule",
+    [
+        (
+            "wrongformat",
+            "",
+        ),
+        (
+            'antrea-test#{"podname":"perftest-a"}#5201#TCP',
+            adminnp.AdminNetworkPolicyEgressRule(
+                action="Allow",
+                to=[
+                    adminnp.AdminNetworkPolicyPeer(
+                        pods=adminnp.NamespacedPod(
+                            namespace_selector=kubernetes.client
+                            .V1LabelSelector(
+                                match_labels={
+                                    "kubernetes.io/metadata.name":
+                                    "antrea-test"
+                                }
+                            ),
+                            pod_selector=kubernetes.client.V1LabelSelector(
+                                match_labels={"podname": "perftest-a"}
+                            ),
+                        )
+                    )
+                ],
+                ports=[
+                    adminnp.AdminNetworkPolicyPort(
+                        port_number=adminnp.Port(port=5201, protocol="TCP")
+                    )
+                ],
+            ),
+        ),
+        (
+            "192.168.0.1#80#TCP",
+            adminnp.AdminNetworkPolicyEgressRule(
+                action="Allow",
+                to=[
+                    adminnp.AdminNetworkPolicyPeer(
+                        networks=["192.168.0.1/32"]
+                    )
+                ],
+                ports=[
+                    adminnp.AdminNetworkPolicyPort(
+                        port_number=adminnp.Port(port=80, protocol="TCP")
+                    )
+                ],
+            ),
+        ),
+    ],
+)
+def test_generate_admin_np_egress_rule(test_input, expected_egress_rule):
+    if test_input == "wrongformat":
+        with pytest.raises(SystemExit) as pytest_wrapped_e:
+            pr.generate_admin_np_egress_rule(test_input)
+        assert pytest_wrapped_e.type == SystemExit
+        assert pytest_wrapped_e.value.code == 1
+    else:
+        egress_rule = pr.generate_admin_np_egress_rule(test_input)
+        assert egress_rule == expected_egress_rule
+
+
+@pytest.mark.parametrize(
+    "test_input, expected_ingress_rule",
+    [
+        (
+            "wrongformat",
+            "",
+        ),
+        (
+            'antrea-test#{"podname":"perftest-a"}#5201#TCP',
+            adminnp.AdminNetworkPolicyIngressRule(
+                action="Allow",
+                _from=[
+                    adminnp.AdminNetworkPolicyPeer(
+                        pods=adminnp.NamespacedPod(
+                            namespace_selector=kubernetes.client
+                            .V1LabelSelector(
+                                match_labels={
+                                    "kubernetes.io/metadata.name":
+                                    "antrea-test"
+                                }
+                            ),
+                            pod_selector=kubernetes.client.V1LabelSelector(
+                                match_labels={"podname": "perftest-a"}
+                            ),
+                        )
+                    )
+                ],
+                ports=[
+                    adminnp.AdminNetworkPolicyPort(
+                        port_number=adminnp.Port(port=5201, protocol="TCP")
+                    )
+                ],
+            ),
+        ),
+    ],
+)
+def test_generate_admin_np_ingress_rule(test_input, expected_ingress_rule):
+    if test_input == "wrongformat":
+        with pytest.raises(SystemExit) as pytest_wrapped_e:
+            pr.generate_admin_np_ingress_rule(test_input)
+        assert pytest_wrapped_e.type == SystemExit
+        assert pytest_wrapped_e.value.code == 1
+    else:
+        ingress_rule = pr.generate_admin_np_ingress_rule(test_input)
+        assert ingress_rule == expected_ingress_rule
+
+
+def test_place_antrea_policies():
+    def policy(kind, name, tier):
+        return yaml.dump(
+            {
+                "kind": kind,
+                "metadata": {"name": name},
+                "spec": {"tier": tier, "priority": 5},
+            }
+        )
+
+    policies = {
+        antrea_crd.PolicyKind.ANP: [
+            policy("NetworkPolicy", "anp-a", "Application"),
+            policy("NetworkPolicy", "anp-b", "Application"),
+        ],
+        antrea_crd.PolicyKind.ACNP: [
+            policy("ClusterNetworkPolicy", "acnp-a", "Application"),
+            policy("ClusterNetworkPolicy", "acnp-platform", "Platform"),
+            policy("ClusterNetworkPolicy", "acnp-reject-a", "Baseline"),
+            policy("ClusterNetworkPolicy", "acnp-reject-b", "Baseline"),
+        ],
+    }
+    placed = pr.place_antrea_policies(policies, "SecurityOps", 10, 0.5)
+    placed_specs = {
+        p["metadata"]["name"]: p["spec"]
+        for p in [
+            yaml.load(i, Loader=yaml.FullLoader)
+            for i in flatten_policy_dict(placed)
+        ]
+    }
+    assert placed_specs == {
+        "anp-a": {"tier": "SecurityOps", "priority": 10},
+        "anp-b": {"tier": "SecurityOps", "priority": 10.5},
+        "acnp-a": {"tier": "SecurityOps", "priority": 11},
+        "acnp-platform": {"tier": "Platform", "priority": 10},
+        "acnp-reject-a": {"tier": "Baseline", "priority": 10},
+        "acnp-reject-b": {"tier": "Baseline", "priority": 10.5},
+    }